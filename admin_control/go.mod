@@ -3,7 +3,10 @@ module crmSystem
 go 1.23
 
 require (
+	crmSystem/jwks v0.0.0
+	crmSystem/lifecycle v0.0.0
 	github.com/go-playground/validator/v10 v10.23.0
+	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/golang/mock v1.6.0
 	github.com/gorilla/mux v1.8.1
 	github.com/stretchr/testify v1.8.4
@@ -25,3 +28,6 @@ require (
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20241015192408-796eee8c2d53 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace crmSystem/jwks => ../jwks
+replace crmSystem/lifecycle => ../lifecycle
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"crmSystem/lifecycle"
 	"crmSystem/transport_rest"
 	"crmSystem/utils"
+	"errors"
 	"log"
 	"net/http"
 	"os"
@@ -30,11 +32,20 @@ func main() {
 		Handler: handler.InitRouter(),
 	}
 
-	// Запускаем HTTP сервер с TLS в основной горутине
-	log.Println("HTTP SERVER STARTED WITH TLS ON PORT", httpPort)
-	if err := httpServer.ListenAndServeTLS(utils.ServerCertFile, utils.ServerKeyFile); err != nil {
-		log.Fatalf("Ошибка запуска HTTP сервера с TLS: %v", err)
-	}
-
-	// Программа продолжает работать, пока сервер работает
+	// Остановка по SIGINT и SIGTERM: новые запросы не принимаются, текущие завершаются в течение DRAIN_TIMEOUT
+	ctx, stop := lifecycle.ShutdownContext()
+	defer stop()
+
+	// Запускаем HTTP сервер с TLS в отдельной горутине
+	go func() {
+		log.Println("HTTP SERVER STARTED WITH TLS ON PORT", httpPort)
+		if err := httpServer.ListenAndServeTLS(utils.ServerCertFile, utils.ServerKeyFile); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Ошибка запуска HTTP сервера с TLS: %v", err)
+		}
+	}()
+
+	// Программа продолжает работать, пока не получен сигнал остановки
+	<-ctx.Done()
+	log.Printf("Получен сигнал остановки, завершаем текущие запросы")
+	lifecycle.ShutdownHTTPServer(httpServer, lifecycle.DrainTimeout())
 }
@@ -20,6 +20,12 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	DbAdminService_RegisterUsersInCompany_FullMethodName = "/protobuff.dbAdminService/RegisterUsersInCompany"
+	DbAdminService_SetMfaPolicy_FullMethodName           = "/protobuff.dbAdminService/SetMfaPolicy"
+	DbAdminService_UnlockUser_FullMethodName             = "/protobuff.dbAdminService/UnlockUser"
+	DbAdminService_SetOidcConfig_FullMethodName          = "/protobuff.dbAdminService/SetOidcConfig"
+	DbAdminService_ListCompanyApiKeys_FullMethodName     = "/protobuff.dbAdminService/ListCompanyApiKeys"
+	DbAdminService_RevokeCompanyApiKey_FullMethodName    = "/protobuff.dbAdminService/RevokeCompanyApiKey"
+	DbAdminService_SetEmailOtpPolicy_FullMethodName      = "/protobuff.dbAdminService/SetEmailOtpPolicy"
 )
 
 // DbAdminServiceClient is the client API for DbAdminService service.
@@ -28,6 +34,18 @@ const (
 type DbAdminServiceClient interface {
 	// Метод для регистрации пользователя в компании
 	RegisterUsersInCompany(ctx context.Context, in *RegisterUsersRequest, opts ...grpc.CallOption) (*RegisterUsersResponse, error)
+	// Метод для включения или отключения обязательной двухфакторной аутентификации в компании
+	SetMfaPolicy(ctx context.Context, in *SetMfaPolicyRequest, opts ...grpc.CallOption) (*SetMfaPolicyResponse, error)
+	// Метод для снятия блокировки входа пользователя компании после неудачных попыток
+	UnlockUser(ctx context.Context, in *UnlockUserRequest, opts ...grpc.CallOption) (*UnlockUserResponse, error)
+	// Метод для настройки входа через OpenID Connect провайдера компании
+	SetOidcConfig(ctx context.Context, in *SetOidcConfigRequest, opts ...grpc.CallOption) (*SetOidcConfigResponse, error)
+	// Метод для получения API ключей всех пользователей компании
+	ListCompanyApiKeys(ctx context.Context, in *ListCompanyApiKeysRequest, opts ...grpc.CallOption) (*ListCompanyApiKeysResponse, error)
+	// Метод для отзыва API ключа любого пользователя компании
+	RevokeCompanyApiKey(ctx context.Context, in *RevokeCompanyApiKeyRequest, opts ...grpc.CallOption) (*RevokeCompanyApiKeyResponse, error)
+	// Метод для разрешения входа по одноразовому коду из письма пользователям компании
+	SetEmailOtpPolicy(ctx context.Context, in *SetEmailOtpPolicyRequest, opts ...grpc.CallOption) (*SetEmailOtpPolicyResponse, error)
 }
 
 type dbAdminServiceClient struct {
@@ -48,12 +66,84 @@ func (c *dbAdminServiceClient) RegisterUsersInCompany(ctx context.Context, in *R
 	return out, nil
 }
 
+func (c *dbAdminServiceClient) SetMfaPolicy(ctx context.Context, in *SetMfaPolicyRequest, opts ...grpc.CallOption) (*SetMfaPolicyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetMfaPolicyResponse)
+	err := c.cc.Invoke(ctx, DbAdminService_SetMfaPolicy_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAdminServiceClient) UnlockUser(ctx context.Context, in *UnlockUserRequest, opts ...grpc.CallOption) (*UnlockUserResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnlockUserResponse)
+	err := c.cc.Invoke(ctx, DbAdminService_UnlockUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAdminServiceClient) SetOidcConfig(ctx context.Context, in *SetOidcConfigRequest, opts ...grpc.CallOption) (*SetOidcConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetOidcConfigResponse)
+	err := c.cc.Invoke(ctx, DbAdminService_SetOidcConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAdminServiceClient) ListCompanyApiKeys(ctx context.Context, in *ListCompanyApiKeysRequest, opts ...grpc.CallOption) (*ListCompanyApiKeysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCompanyApiKeysResponse)
+	err := c.cc.Invoke(ctx, DbAdminService_ListCompanyApiKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAdminServiceClient) RevokeCompanyApiKey(ctx context.Context, in *RevokeCompanyApiKeyRequest, opts ...grpc.CallOption) (*RevokeCompanyApiKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeCompanyApiKeyResponse)
+	err := c.cc.Invoke(ctx, DbAdminService_RevokeCompanyApiKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAdminServiceClient) SetEmailOtpPolicy(ctx context.Context, in *SetEmailOtpPolicyRequest, opts ...grpc.CallOption) (*SetEmailOtpPolicyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetEmailOtpPolicyResponse)
+	err := c.cc.Invoke(ctx, DbAdminService_SetEmailOtpPolicy_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DbAdminServiceServer is the server API for DbAdminService service.
 // All implementations must embed UnimplementedDbAdminServiceServer
 // for forward compatibility.
 type DbAdminServiceServer interface {
 	// Метод для регистрации пользователя в компании
 	RegisterUsersInCompany(context.Context, *RegisterUsersRequest) (*RegisterUsersResponse, error)
+	// Метод для включения или отключения обязательной двухфакторной аутентификации в компании
+	SetMfaPolicy(context.Context, *SetMfaPolicyRequest) (*SetMfaPolicyResponse, error)
+	// Метод для снятия блокировки входа пользователя компании после неудачных попыток
+	UnlockUser(context.Context, *UnlockUserRequest) (*UnlockUserResponse, error)
+	// Метод для настройки входа через OpenID Connect провайдера компании
+	SetOidcConfig(context.Context, *SetOidcConfigRequest) (*SetOidcConfigResponse, error)
+	// Метод для получения API ключей всех пользователей компании
+	ListCompanyApiKeys(context.Context, *ListCompanyApiKeysRequest) (*ListCompanyApiKeysResponse, error)
+	// Метод для отзыва API ключа любого пользователя компании
+	RevokeCompanyApiKey(context.Context, *RevokeCompanyApiKeyRequest) (*RevokeCompanyApiKeyResponse, error)
+	// Метод для разрешения входа по одноразовому коду из письма пользователям компании
+	SetEmailOtpPolicy(context.Context, *SetEmailOtpPolicyRequest) (*SetEmailOtpPolicyResponse, error)
 	mustEmbedUnimplementedDbAdminServiceServer()
 }
 
@@ -67,6 +157,24 @@ type UnimplementedDbAdminServiceServer struct{}
 func (UnimplementedDbAdminServiceServer) RegisterUsersInCompany(context.Context, *RegisterUsersRequest) (*RegisterUsersResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RegisterUsersInCompany not implemented")
 }
+func (UnimplementedDbAdminServiceServer) SetMfaPolicy(context.Context, *SetMfaPolicyRequest) (*SetMfaPolicyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMfaPolicy not implemented")
+}
+func (UnimplementedDbAdminServiceServer) UnlockUser(context.Context, *UnlockUserRequest) (*UnlockUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnlockUser not implemented")
+}
+func (UnimplementedDbAdminServiceServer) SetOidcConfig(context.Context, *SetOidcConfigRequest) (*SetOidcConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetOidcConfig not implemented")
+}
+func (UnimplementedDbAdminServiceServer) ListCompanyApiKeys(context.Context, *ListCompanyApiKeysRequest) (*ListCompanyApiKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCompanyApiKeys not implemented")
+}
+func (UnimplementedDbAdminServiceServer) RevokeCompanyApiKey(context.Context, *RevokeCompanyApiKeyRequest) (*RevokeCompanyApiKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeCompanyApiKey not implemented")
+}
+func (UnimplementedDbAdminServiceServer) SetEmailOtpPolicy(context.Context, *SetEmailOtpPolicyRequest) (*SetEmailOtpPolicyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetEmailOtpPolicy not implemented")
+}
 func (UnimplementedDbAdminServiceServer) mustEmbedUnimplementedDbAdminServiceServer() {}
 func (UnimplementedDbAdminServiceServer) testEmbeddedByValue()                        {}
 
@@ -106,6 +214,114 @@ func _DbAdminService_RegisterUsersInCompany_Handler(srv interface{}, ctx context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DbAdminService_SetMfaPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetMfaPolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAdminServiceServer).SetMfaPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAdminService_SetMfaPolicy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAdminServiceServer).SetMfaPolicy(ctx, req.(*SetMfaPolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAdminService_UnlockUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnlockUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAdminServiceServer).UnlockUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAdminService_UnlockUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAdminServiceServer).UnlockUser(ctx, req.(*UnlockUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAdminService_SetOidcConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetOidcConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAdminServiceServer).SetOidcConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAdminService_SetOidcConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAdminServiceServer).SetOidcConfig(ctx, req.(*SetOidcConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAdminService_ListCompanyApiKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCompanyApiKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAdminServiceServer).ListCompanyApiKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAdminService_ListCompanyApiKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAdminServiceServer).ListCompanyApiKeys(ctx, req.(*ListCompanyApiKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAdminService_RevokeCompanyApiKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeCompanyApiKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAdminServiceServer).RevokeCompanyApiKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAdminService_RevokeCompanyApiKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAdminServiceServer).RevokeCompanyApiKey(ctx, req.(*RevokeCompanyApiKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAdminService_SetEmailOtpPolicy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetEmailOtpPolicyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAdminServiceServer).SetEmailOtpPolicy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAdminService_SetEmailOtpPolicy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAdminServiceServer).SetEmailOtpPolicy(ctx, req.(*SetEmailOtpPolicyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DbAdminService_ServiceDesc is the grpc.ServiceDesc for DbAdminService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -117,6 +333,30 @@ var DbAdminService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RegisterUsersInCompany",
 			Handler:    _DbAdminService_RegisterUsersInCompany_Handler,
 		},
+		{
+			MethodName: "SetMfaPolicy",
+			Handler:    _DbAdminService_SetMfaPolicy_Handler,
+		},
+		{
+			MethodName: "UnlockUser",
+			Handler:    _DbAdminService_UnlockUser_Handler,
+		},
+		{
+			MethodName: "SetOidcConfig",
+			Handler:    _DbAdminService_SetOidcConfig_Handler,
+		},
+		{
+			MethodName: "ListCompanyApiKeys",
+			Handler:    _DbAdminService_ListCompanyApiKeys_Handler,
+		},
+		{
+			MethodName: "RevokeCompanyApiKey",
+			Handler:    _DbAdminService_RevokeCompanyApiKey_Handler,
+		},
+		{
+			MethodName: "SetEmailOtpPolicy",
+			Handler:    _DbAdminService_SetEmailOtpPolicy_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "dbservice/proto/dbadmin.proto",
@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"crmSystem/proto/dbadmin"
+	"crmSystem/tests/mocks"
+	"crmSystem/transport_rest"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestCallCompanyApiKeys проверяет список ключей компании и отзыв ключа администратором
+func TestCallCompanyApiKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	createdAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	mockDb := mocks.NewMockDbAdminServiceClient(ctrl)
+	mockDb.EXPECT().ListCompanyApiKeys(gomock.Any(), &dbadmin.ListCompanyApiKeysRequest{}).
+		Return(&dbadmin.ListCompanyApiKeysResponse{Keys: []*dbadmin.CompanyApiKey{{
+			Id: "5", Email: "bot@corp.example", Name: "reports", Prefix: "crm_abcdefgh",
+			Scopes: []string{"timers:read"}, CreatedAt: createdAt.Unix(),
+		}}}, nil)
+
+	response, err := transport_rest.CallListCompanyApiKeys(context.Background(), mockDb)
+	require.NoError(t, err)
+	require.Len(t, response.Keys, 1)
+	assert.Equal(t, "bot@corp.example", response.Keys[0].Email)
+	assert.Equal(t, createdAt, response.Keys[0].CreatedAt)
+	// Бессрочный и ещё не использованный ключ
+	assert.Nil(t, response.Keys[0].ExpiresAt)
+	assert.Nil(t, response.Keys[0].LastUsedAt)
+
+	mockDb.EXPECT().RevokeCompanyApiKey(gomock.Any(), &dbadmin.RevokeCompanyApiKeyRequest{Id: "5"}).
+		Return(&dbadmin.RevokeCompanyApiKeyResponse{Message: "API ключ отозван"}, nil)
+	revoked, err := transport_rest.CallRevokeCompanyApiKey(context.Background(), mockDb, "5")
+	require.NoError(t, err)
+	assert.Equal(t, "API ключ отозван", revoked.Message)
+
+	// Ключ другой компании не найден
+	mockDb.EXPECT().RevokeCompanyApiKey(gomock.Any(), &dbadmin.RevokeCompanyApiKeyRequest{Id: "6"}).
+		Return(nil, status.Error(codes.NotFound, "API ключ не найден"))
+	_, err = transport_rest.CallRevokeCompanyApiKey(context.Background(), mockDb, "6")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
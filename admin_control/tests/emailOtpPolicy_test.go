@@ -0,0 +1,33 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"crmSystem/proto/dbadmin"
+	"crmSystem/tests/mocks"
+	"crmSystem/transport_rest"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestCallSetEmailOtpPolicy проверяет передачу политики входа по коду из письма в dbservice и отказ в правах
+func TestCallSetEmailOtpPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDb := mocks.NewMockDbAdminServiceClient(ctrl)
+	mockDb.EXPECT().SetEmailOtpPolicy(gomock.Any(), &dbadmin.SetEmailOtpPolicyRequest{Enabled: true}).
+		Return(&dbadmin.SetEmailOtpPolicyResponse{Message: "Пользователи компании могут входить по коду из письма"}, nil)
+	mockDb.EXPECT().SetEmailOtpPolicy(gomock.Any(), &dbadmin.SetEmailOtpPolicyRequest{Enabled: false}).
+		Return(nil, status.Error(codes.PermissionDenied, "изменять политику входа по коду может только администратор компании"))
+
+	response, err := transport_rest.CallSetEmailOtpPolicy(context.Background(), mockDb, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "Пользователи компании могут входить по коду из письма", response.Message)
+
+	_, err = transport_rest.CallSetEmailOtpPolicy(context.Background(), mockDb, false)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -3,28 +3,56 @@ package tests
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"crmSystem/jwks"
 	"crmSystem/proto/dbadmin"
 	"crmSystem/proto/email-service"
 	"crmSystem/proto/logs"
 	"crmSystem/tests/mocks"
 	"crmSystem/transport_rest"
 	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
 )
 
-// testHandler — обертка для transport_rest.Handler с замоканными зависимостями
-type testHandler struct {
-	*transport_rest.Handler
-	grpcServiceConnector func(token string, client interface{}) (interface{}, error, *grpc.ClientConn)
-	saveLogsError        func(ctx context.Context, client logs.LogsServiceClient, database, userId, errorMessage string) error
+// signAccessToken подписывает access token пользователя ключом key с заголовком kid, как auth сервис
+func signAccessToken(t *testing.T, key *rsa.PrivateKey, kid string) string {
+	return signUserToken(t, key, kid, "access")
+}
+
+// userTokenParser проверяет access token ключами keys, как рабочий обработчик
+func userTokenParser(keys *jwks.KeySet) func(token string) (*utils.UserClaims, error) {
+	return func(token string) (*utils.UserClaims, error) {
+		return utils.ParseUserTokenWith(keys, token)
+	}
+}
+
+// signUserToken подписывает токен пользователя типа tokenType
+func signUserToken(t *testing.T, key *rsa.PrivateKey, kid string, tokenType string) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"sub":  "test-user",
+		"db":   "test-db",
+		"cid":  "company-123",
+		"role": "admin",
+		"typ":  tokenType,
+		"exp":  time.Now().Add(time.Minute).Unix(),
+	})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
 }
 
 func TestAddUsers(t *testing.T) {
@@ -35,27 +63,28 @@ func TestAddUsers(t *testing.T) {
 	mockLogs := mocks.NewMockLogsServiceClient(ctrl)
 	mockEmail := mocks.NewMockEmailServiceClient(ctrl)
 
-	h := &testHandler{
-		Handler: &transport_rest.Handler{},
-		grpcServiceConnector: func(token string, client interface{}) (interface{}, error, *grpc.ClientConn) {
-			conn, _ := grpc.Dial("fake", grpc.WithInsecure())
-			switch client.(type) {
-			case func(grpc.ClientConnInterface) dbadmin.DbAdminServiceClient:
-				return mockDbAdmin, nil, conn
-			case func(grpc.ClientConnInterface) logs.LogsServiceClient:
-				return mockLogs, nil, conn
-			case func(grpc.ClientConnInterface) email.EmailServiceClient:
-				return mockEmail, nil, conn
-			}
-			return nil, fmt.Errorf("unknown client"), nil
+	// Токены проверяются сгенерированным ключом, ключи с диска не читаются
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	forgedKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keys := jwks.NewKeySet("", nil, &signingKey.PublicKey)
+	kid := jwks.Thumbprint(&signingKey.PublicKey)
+	validToken := signAccessToken(t, signingKey, kid)
+
+	noClose := func() error { return nil }
+	h := transport_rest.NewHandlerWith(transport_rest.Dependencies{
+		ParseToken: userTokenParser(keys),
+		DialDbAdmin: func(string) (dbadmin.DbAdminServiceClient, func() error, error) {
+			return mockDbAdmin, noClose, nil
 		},
-		saveLogsError: func(ctx context.Context, client logs.LogsServiceClient, database, userId, errorMessage string) error {
-			if client == nil {
-				return fmt.Errorf("logs client is nil")
-			}
-			return nil // Мок успешного сохранения логов
+		DialLogs: func(string) (logs.LogsServiceClient, func() error, error) {
+			return mockLogs, noClose, nil
 		},
-	}
+		DialEmail: func(string) (email.EmailServiceClient, func() error, error) {
+			return mockEmail, noClose, nil
+		},
+	})
 
 	tests := []struct {
 		name           string
@@ -66,14 +95,10 @@ func TestAddUsers(t *testing.T) {
 		expectedBody   string
 	}{
 		{
-			name: "Success",
-			cookies: []*http.Cookie{
-				{Name: "access_token", Value: "valid-token"},
-				{Name: "user-id", Value: "test-user"},
-				{Name: "database", Value: "test-db"},
-				{Name: "DbName", Value: "test-database"}, // Изменено на test-database
-			},
+			name:    "Success",
+			cookies: []*http.Cookie{{Name: "access_token", Value: validToken}},
 			body: types.RegisterUsersRequest{
+				DbName:    "test-database",
 				CompanyId: "company-123",
 				Users: []*types.User{
 					{Email: "user1@example.com", Phone: "1234567890", RoleId: int64(1)},
@@ -88,16 +113,16 @@ func TestAddUsers(t *testing.T) {
 				}).Return(&dbadmin.RegisterUsersResponse{
 					Message: "Users registered",
 					Users: []*dbadmin.UserResponse{
-						{Email: "user1@example.com", Phone: "1234567890", RoleId: int64(1), Password: "pass123"},
+						{Email: "user1@example.com", Phone: "1234567890", RoleId: int64(1), InviteToken: "invite123"},
 					},
 				}, nil)
 
 				mockEmail.EXPECT().SendEmail(gomock.Any(), gomock.Any()).DoAndReturn(
-					func(ctx context.Context, req *email.SendEmailRequest) (*email.SendEmailResponse, error) {
+					func(ctx context.Context, req *email.SendEmailRequest, _ ...grpc.CallOption) (*email.SendEmailResponse, error) {
 						assert.Equal(t, "user1@example.com", req.Email)
 						assert.Equal(t, "Welcome to our service! FROM PETR", req.Message)
 						assert.Contains(t, req.Body, "user1@example.com")
-						assert.Contains(t, req.Body, "pass123")
+						assert.Contains(t, req.Body, "?token=invite123")
 						return &email.SendEmailResponse{Message: "Email sent"}, nil
 					},
 				)
@@ -106,61 +131,59 @@ func TestAddUsers(t *testing.T) {
 			expectedBody:   `{"message":"Successfully sent to all 1 users.","failures":""}`,
 		},
 		{
-			name: "Missing token",
-			cookies: []*http.Cookie{
-				{Name: "user-id", Value: "test-user"},
-				{Name: "database", Value: "test-db"},
-				{Name: "DbName", Value: "test-database"},
-			},
+			name:           "Missing token",
 			body:           types.RegisterUsersRequest{},
 			mockSetup:      func() {},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   `{"message":"access_token не найден http: named cookie not present"}`,
 		},
 		{
-			name: "Invalid JSON",
-			cookies: []*http.Cookie{
-				{Name: "access_token", Value: "valid-token"},
-				{Name: "user-id", Value: "test-user"},
-				{Name: "database", Value: "test-db"},
-				{Name: "DbName", Value: "test-database"},
-			},
-			body: "invalid json",
+			name:           "Forged token",
+			cookies:        []*http.Cookie{{Name: "access_token", Value: signAccessToken(t, forgedKey, kid)}},
+			body:           types.RegisterUsersRequest{},
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"message":"Недействительный токен token signature is invalid: crypto/rsa: verification error"}`,
+		},
+		{
+			name:           "Refresh token",
+			cookies:        []*http.Cookie{{Name: "access_token", Value: signUserToken(t, signingKey, kid, "refresh")}},
+			body:           types.RegisterUsersRequest{},
+			mockSetup:      func() {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"message":"Недействительный токен требуется access token"}`,
+		},
+		{
+			name:    "Invalid JSON",
+			cookies: []*http.Cookie{{Name: "access_token", Value: validToken}},
+			body:    "invalid json",
 			mockSetup: func() {
-				mockLogs.EXPECT().SaveLogs(gomock.Any(), gomock.Any()).Return(&logs.LogResponse{}, nil).AnyTimes()
+				mockLogs.EXPECT().SaveLogs(gomock.Any(), gomock.Any()).Return(&logs.LogResponse{}, nil)
 			},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"error":"Ошибка при декодировании данных"}`,
+			expectedBody:   `{"message":"Ошибка при декодировании данных json: cannot unmarshal string into Go value of type types.RegisterUsersRequest"}`,
 		},
 		{
-			name: "Validation Error",
-			cookies: []*http.Cookie{
-				{Name: "access_token", Value: "valid-token"},
-				{Name: "user-id", Value: "test-user"},
-				{Name: "database", Value: "test-db"},
-				{Name: "DbName", Value: "test-database"},
-			},
+			name:    "Validation Error",
+			cookies: []*http.Cookie{{Name: "access_token", Value: validToken}},
 			body: types.RegisterUsersRequest{
+				DbName:    "test-database",
 				CompanyId: "",
 				Users: []*types.User{
 					{Email: "invalid", Phone: "", RoleId: int64(0)},
 				},
 			},
 			mockSetup: func() {
-				mockLogs.EXPECT().SaveLogs(gomock.Any(), gomock.Any()).Return(&logs.LogResponse{}, nil).AnyTimes()
+				mockLogs.EXPECT().SaveLogs(gomock.Any(), gomock.Any()).Return(&logs.LogResponse{}, nil)
 			},
 			expectedStatus: http.StatusBadRequest,
 			expectedBody:   `{"message":"Ошибка валидации Поле 'CompanyId' не прошло валидацию"}`,
 		},
 		{
-			name: "gRPC RegisterUsers Failure",
-			cookies: []*http.Cookie{
-				{Name: "access_token", Value: "valid-token"},
-				{Name: "user-id", Value: "test-user"},
-				{Name: "database", Value: "test-db"},
-				{Name: "DbName", Value: "test-database"},
-			},
+			name:    "gRPC RegisterUsers Failure",
+			cookies: []*http.Cookie{{Name: "access_token", Value: validToken}},
 			body: types.RegisterUsersRequest{
+				DbName:    "test-database",
 				CompanyId: "company-123",
 				Users: []*types.User{
 					{Email: "user1@example.com", Phone: "1234567890", RoleId: int64(1)},
@@ -168,20 +191,16 @@ func TestAddUsers(t *testing.T) {
 			},
 			mockSetup: func() {
 				mockDbAdmin.EXPECT().RegisterUsersInCompany(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("database error"))
-				mockLogs.EXPECT().SaveLogs(gomock.Any(), gomock.Any()).Return(&logs.LogResponse{}, nil).AnyTimes()
+				mockLogs.EXPECT().SaveLogs(gomock.Any(), gomock.Any()).Return(&logs.LogResponse{}, nil).Times(2)
 			},
-			expectedStatus: http.StatusBadRequest,
-			expectedBody:   `{"Ошибка валидации Поле 'DbName' не прошло валидацию"}`,
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"message":"Не корректная ошибка на сервере. Не удалось выполнить gRPC вызов: database error"}`,
 		},
 		{
-			name: "Partial Email Failure",
-			cookies: []*http.Cookie{
-				{Name: "access_token", Value: "valid-token"},
-				{Name: "user-id", Value: "test-user"},
-				{Name: "database", Value: "test-db"},
-				{Name: "DbName", Value: "test-database"},
-			},
+			name:    "Partial Email Failure",
+			cookies: []*http.Cookie{{Name: "access_token", Value: validToken}},
 			body: types.RegisterUsersRequest{
+				DbName:    "test-database",
 				CompanyId: "company-123",
 				Users: []*types.User{
 					{Email: "user1@example.com", Phone: "1234567890", RoleId: int64(1)},
@@ -189,44 +208,32 @@ func TestAddUsers(t *testing.T) {
 				},
 			},
 			mockSetup: func() {
-				mockDbAdmin.EXPECT().RegisterUsersInCompany(gomock.Any(), &dbadmin.RegisterUsersRequest{
-					CompanyId: "company-123",
-					Users: []*dbadmin.User{
-						{Email: "user1@example.com", Phone: "1234567890", RoleId: int64(1)},
-						{Email: "user2@example.com", Phone: "0987654321", RoleId: int64(2)},
-					},
-				}).Return(&dbadmin.RegisterUsersResponse{
+				mockDbAdmin.EXPECT().RegisterUsersInCompany(gomock.Any(), gomock.Any()).Return(&dbadmin.RegisterUsersResponse{
 					Message: "Users registered",
 					Users: []*dbadmin.UserResponse{
-						{Email: "user1@example.com", Phone: "1234567890", RoleId: int64(1), Password: "pass123"},
-						{Email: "user2@example.com", Phone: "0987654321", RoleId: int64(2), Password: "pass456"},
+						{Email: "user1@example.com", Phone: "1234567890", RoleId: int64(1), InviteToken: "invite123"},
+						{Email: "user2@example.com", Phone: "0987654321", RoleId: int64(2), InviteToken: "invite456"},
 					},
 				}, nil)
 
 				mockEmail.EXPECT().SendEmail(gomock.Any(), gomock.Any()).DoAndReturn(
-					func(ctx context.Context, req *email.SendEmailRequest) (*email.SendEmailResponse, error) {
+					func(ctx context.Context, req *email.SendEmailRequest, _ ...grpc.CallOption) (*email.SendEmailResponse, error) {
 						assert.Equal(t, "user1@example.com", req.Email)
-						assert.Equal(t, "Welcome to our service! FROM PETR", req.Message)
-						assert.Contains(t, req.Body, "user1@example.com")
-						assert.Contains(t, req.Body, "pass123")
 						return &email.SendEmailResponse{Message: "Email sent"}, nil
 					},
 				)
-
 				mockEmail.EXPECT().SendEmail(gomock.Any(), gomock.Any()).DoAndReturn(
-					func(ctx context.Context, req *email.SendEmailRequest) (*email.SendEmailResponse, error) {
+					func(ctx context.Context, req *email.SendEmailRequest, _ ...grpc.CallOption) (*email.SendEmailResponse, error) {
 						assert.Equal(t, "user2@example.com", req.Email)
-						assert.Equal(t, "Welcome to our service! FROM PETR", req.Message)
-						assert.Contains(t, req.Body, "user2@example.com")
-						assert.Contains(t, req.Body, "pass456")
 						return nil, fmt.Errorf("email service error")
 					},
 				)
 
-				mockLogs.EXPECT().SaveLogs(gomock.Any(), gomock.Any()).Return(&logs.LogResponse{}, nil).AnyTimes()
+				mockLogs.EXPECT().SaveLogs(gomock.Any(), gomock.Any()).Return(&logs.LogResponse{}, nil)
 			},
 			expectedStatus: http.StatusOK,
-			expectedBody:   `{"message":"Ошибка валидации Поле 'DbName' не прошло валидацию"}`,
+			expectedBody: `{"message":"Successfully sent to 1 users, failed for 1 users.",` +
+				`"failures":"Failed to send email to user2@example.com: email service error"}`,
 		},
 	}
 
@@ -239,12 +246,10 @@ func TestAddUsers(t *testing.T) {
 			}
 			w := httptest.NewRecorder()
 
-			t.Logf("Cookies sent: %+v", req.Cookies()) // Отладка кук
 			tt.mockSetup()
 
 			h.AddUsers(w, req)
 
-			t.Logf("Response body: %s", w.Body.String()) // Отладка ответа
 			assert.Equal(t, tt.expectedStatus, w.Code)
 			assert.JSONEq(t, tt.expectedBody, w.Body.String())
 		})
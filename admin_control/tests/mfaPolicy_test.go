@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"crmSystem/proto/dbadmin"
+	"crmSystem/tests/mocks"
+	"crmSystem/transport_rest"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestCallSetMfaPolicy проверяет передачу политики 2FA в dbservice и возврат отказа в правах
+func TestCallSetMfaPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDb := mocks.NewMockDbAdminServiceClient(ctrl)
+	mockDb.EXPECT().SetMfaPolicy(gomock.Any(), &dbadmin.SetMfaPolicyRequest{Required: true}).
+		Return(&dbadmin.SetMfaPolicyResponse{Message: "Политика 2FA обновлена"}, nil)
+	mockDb.EXPECT().SetMfaPolicy(gomock.Any(), &dbadmin.SetMfaPolicyRequest{Required: false}).
+		Return(nil, status.Error(codes.PermissionDenied, "изменять политику 2FA может только администратор компании"))
+
+	response, err := transport_rest.CallSetMfaPolicy(context.Background(), mockDb, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "Политика 2FA обновлена", response.Message)
+
+	_, err = transport_rest.CallSetMfaPolicy(context.Background(), mockDb, false)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+// TestCallUnlockUser проверяет передачу запроса на снятие блокировки входа в dbservice
+func TestCallUnlockUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDb := mocks.NewMockDbAdminServiceClient(ctrl)
+	mockDb.EXPECT().UnlockUser(gomock.Any(), &dbadmin.UnlockUserRequest{Email: "user@example.com"}).
+		Return(&dbadmin.UnlockUserResponse{Message: "Блокировка входа пользователя user@example.com снята"}, nil)
+	mockDb.EXPECT().UnlockUser(gomock.Any(), &dbadmin.UnlockUserRequest{Email: "other@example.com"}).
+		Return(nil, status.Error(codes.NotFound, "пользователь other@example.com не найден в компании"))
+
+	response, err := transport_rest.CallUnlockUser(context.Background(), mockDb, "user@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "Блокировка входа пользователя user@example.com снята", response.Message)
+
+	_, err = transport_rest.CallUnlockUser(context.Background(), mockDb, "other@example.com")
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
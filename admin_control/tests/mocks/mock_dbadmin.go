@@ -56,6 +56,126 @@ func (mr *MockDbAdminServiceClientMockRecorder) RegisterUsersInCompany(ctx, in i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterUsersInCompany", reflect.TypeOf((*MockDbAdminServiceClient)(nil).RegisterUsersInCompany), varargs...)
 }
 
+// SetMfaPolicy mocks base method.
+func (m *MockDbAdminServiceClient) SetMfaPolicy(ctx context.Context, in *dbadmin.SetMfaPolicyRequest, opts ...grpc.CallOption) (*dbadmin.SetMfaPolicyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SetMfaPolicy", varargs...)
+	ret0, _ := ret[0].(*dbadmin.SetMfaPolicyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetMfaPolicy indicates an expected call of SetMfaPolicy.
+func (mr *MockDbAdminServiceClientMockRecorder) SetMfaPolicy(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMfaPolicy", reflect.TypeOf((*MockDbAdminServiceClient)(nil).SetMfaPolicy), varargs...)
+}
+
+// UnlockUser mocks base method.
+func (m *MockDbAdminServiceClient) UnlockUser(ctx context.Context, in *dbadmin.UnlockUserRequest, opts ...grpc.CallOption) (*dbadmin.UnlockUserResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UnlockUser", varargs...)
+	ret0, _ := ret[0].(*dbadmin.UnlockUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnlockUser indicates an expected call of UnlockUser.
+func (mr *MockDbAdminServiceClientMockRecorder) UnlockUser(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockUser", reflect.TypeOf((*MockDbAdminServiceClient)(nil).UnlockUser), varargs...)
+}
+
+// SetOidcConfig mocks base method.
+func (m *MockDbAdminServiceClient) SetOidcConfig(ctx context.Context, in *dbadmin.SetOidcConfigRequest, opts ...grpc.CallOption) (*dbadmin.SetOidcConfigResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SetOidcConfig", varargs...)
+	ret0, _ := ret[0].(*dbadmin.SetOidcConfigResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetOidcConfig indicates an expected call of SetOidcConfig.
+func (mr *MockDbAdminServiceClientMockRecorder) SetOidcConfig(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOidcConfig", reflect.TypeOf((*MockDbAdminServiceClient)(nil).SetOidcConfig), varargs...)
+}
+
+// ListCompanyApiKeys mocks base method.
+func (m *MockDbAdminServiceClient) ListCompanyApiKeys(ctx context.Context, in *dbadmin.ListCompanyApiKeysRequest, opts ...grpc.CallOption) (*dbadmin.ListCompanyApiKeysResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListCompanyApiKeys", varargs...)
+	ret0, _ := ret[0].(*dbadmin.ListCompanyApiKeysResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCompanyApiKeys indicates an expected call of ListCompanyApiKeys.
+func (mr *MockDbAdminServiceClientMockRecorder) ListCompanyApiKeys(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCompanyApiKeys", reflect.TypeOf((*MockDbAdminServiceClient)(nil).ListCompanyApiKeys), varargs...)
+}
+
+// RevokeCompanyApiKey mocks base method.
+func (m *MockDbAdminServiceClient) RevokeCompanyApiKey(ctx context.Context, in *dbadmin.RevokeCompanyApiKeyRequest, opts ...grpc.CallOption) (*dbadmin.RevokeCompanyApiKeyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RevokeCompanyApiKey", varargs...)
+	ret0, _ := ret[0].(*dbadmin.RevokeCompanyApiKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeCompanyApiKey indicates an expected call of RevokeCompanyApiKey.
+func (mr *MockDbAdminServiceClientMockRecorder) RevokeCompanyApiKey(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeCompanyApiKey", reflect.TypeOf((*MockDbAdminServiceClient)(nil).RevokeCompanyApiKey), varargs...)
+}
+
+// SetEmailOtpPolicy mocks base method.
+func (m *MockDbAdminServiceClient) SetEmailOtpPolicy(ctx context.Context, in *dbadmin.SetEmailOtpPolicyRequest, opts ...grpc.CallOption) (*dbadmin.SetEmailOtpPolicyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SetEmailOtpPolicy", varargs...)
+	ret0, _ := ret[0].(*dbadmin.SetEmailOtpPolicyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetEmailOtpPolicy indicates an expected call of SetEmailOtpPolicy.
+func (mr *MockDbAdminServiceClientMockRecorder) SetEmailOtpPolicy(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmailOtpPolicy", reflect.TypeOf((*MockDbAdminServiceClient)(nil).SetEmailOtpPolicy), varargs...)
+}
+
 // MockDbAdminServiceServer is a mock of DbAdminServiceServer interface.
 type MockDbAdminServiceServer struct {
 	ctrl     *gomock.Controller
@@ -94,6 +214,96 @@ func (mr *MockDbAdminServiceServerMockRecorder) RegisterUsersInCompany(arg0, arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterUsersInCompany", reflect.TypeOf((*MockDbAdminServiceServer)(nil).RegisterUsersInCompany), arg0, arg1)
 }
 
+// SetMfaPolicy mocks base method.
+func (m *MockDbAdminServiceServer) SetMfaPolicy(arg0 context.Context, arg1 *dbadmin.SetMfaPolicyRequest) (*dbadmin.SetMfaPolicyResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMfaPolicy", arg0, arg1)
+	ret0, _ := ret[0].(*dbadmin.SetMfaPolicyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetMfaPolicy indicates an expected call of SetMfaPolicy.
+func (mr *MockDbAdminServiceServerMockRecorder) SetMfaPolicy(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMfaPolicy", reflect.TypeOf((*MockDbAdminServiceServer)(nil).SetMfaPolicy), arg0, arg1)
+}
+
+// UnlockUser mocks base method.
+func (m *MockDbAdminServiceServer) UnlockUser(arg0 context.Context, arg1 *dbadmin.UnlockUserRequest) (*dbadmin.UnlockUserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnlockUser", arg0, arg1)
+	ret0, _ := ret[0].(*dbadmin.UnlockUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnlockUser indicates an expected call of UnlockUser.
+func (mr *MockDbAdminServiceServerMockRecorder) UnlockUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnlockUser", reflect.TypeOf((*MockDbAdminServiceServer)(nil).UnlockUser), arg0, arg1)
+}
+
+// SetOidcConfig mocks base method.
+func (m *MockDbAdminServiceServer) SetOidcConfig(arg0 context.Context, arg1 *dbadmin.SetOidcConfigRequest) (*dbadmin.SetOidcConfigResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOidcConfig", arg0, arg1)
+	ret0, _ := ret[0].(*dbadmin.SetOidcConfigResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetOidcConfig indicates an expected call of SetOidcConfig.
+func (mr *MockDbAdminServiceServerMockRecorder) SetOidcConfig(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOidcConfig", reflect.TypeOf((*MockDbAdminServiceServer)(nil).SetOidcConfig), arg0, arg1)
+}
+
+// ListCompanyApiKeys mocks base method.
+func (m *MockDbAdminServiceServer) ListCompanyApiKeys(arg0 context.Context, arg1 *dbadmin.ListCompanyApiKeysRequest) (*dbadmin.ListCompanyApiKeysResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListCompanyApiKeys", arg0, arg1)
+	ret0, _ := ret[0].(*dbadmin.ListCompanyApiKeysResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListCompanyApiKeys indicates an expected call of ListCompanyApiKeys.
+func (mr *MockDbAdminServiceServerMockRecorder) ListCompanyApiKeys(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListCompanyApiKeys", reflect.TypeOf((*MockDbAdminServiceServer)(nil).ListCompanyApiKeys), arg0, arg1)
+}
+
+// RevokeCompanyApiKey mocks base method.
+func (m *MockDbAdminServiceServer) RevokeCompanyApiKey(arg0 context.Context, arg1 *dbadmin.RevokeCompanyApiKeyRequest) (*dbadmin.RevokeCompanyApiKeyResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeCompanyApiKey", arg0, arg1)
+	ret0, _ := ret[0].(*dbadmin.RevokeCompanyApiKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeCompanyApiKey indicates an expected call of RevokeCompanyApiKey.
+func (mr *MockDbAdminServiceServerMockRecorder) RevokeCompanyApiKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeCompanyApiKey", reflect.TypeOf((*MockDbAdminServiceServer)(nil).RevokeCompanyApiKey), arg0, arg1)
+}
+
+// SetEmailOtpPolicy mocks base method.
+func (m *MockDbAdminServiceServer) SetEmailOtpPolicy(arg0 context.Context, arg1 *dbadmin.SetEmailOtpPolicyRequest) (*dbadmin.SetEmailOtpPolicyResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEmailOtpPolicy", arg0, arg1)
+	ret0, _ := ret[0].(*dbadmin.SetEmailOtpPolicyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetEmailOtpPolicy indicates an expected call of SetEmailOtpPolicy.
+func (mr *MockDbAdminServiceServerMockRecorder) SetEmailOtpPolicy(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEmailOtpPolicy", reflect.TypeOf((*MockDbAdminServiceServer)(nil).SetEmailOtpPolicy), arg0, arg1)
+}
+
 // mustEmbedUnimplementedDbAdminServiceServer mocks base method.
 func (m *MockDbAdminServiceServer) mustEmbedUnimplementedDbAdminServiceServer() {
 	m.ctrl.T.Helper()
@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"crmSystem/proto/dbadmin"
+	"crmSystem/tests/mocks"
+	"crmSystem/transport_rest"
+	"crmSystem/transport_rest/types"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestCallSetOidcConfig проверяет передачу настроек провайдера в dbservice и конфликт доменов
+func TestCallSetOidcConfig(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	enabled := true
+	req := &types.OidcConfigRequest{
+		Issuer:       "https://idp.example.com",
+		ClientId:     "crm",
+		ClientSecret: "secret",
+		Domains:      []string{"example.com"},
+		Enabled:      &enabled,
+	}
+	assert.NoError(t, validator.New().Struct(req))
+
+	mockDb := mocks.NewMockDbAdminServiceClient(ctrl)
+	mockDb.EXPECT().SetOidcConfig(gomock.Any(), &dbadmin.SetOidcConfigRequest{
+		Issuer: "https://idp.example.com", ClientId: "crm", ClientSecret: "secret",
+		Domains: []string{"example.com"}, Enabled: true,
+	}).Return(&dbadmin.SetOidcConfigResponse{Message: "Вход через корпоративного провайдера настроен"}, nil)
+
+	response, err := transport_rest.CallSetOidcConfig(context.Background(), mockDb, req)
+	assert.NoError(t, err)
+	assert.Equal(t, "Вход через корпоративного провайдера настроен", response.Message)
+
+	mockDb.EXPECT().SetOidcConfig(gomock.Any(), gomock.Any()).
+		Return(nil, status.Error(codes.AlreadyExists, "домен уже используется другой компанией: example.com"))
+	_, err = transport_rest.CallSetOidcConfig(context.Background(), mockDb, req)
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	// Домен указывается без адреса пользователя
+	req.Domains = []string{"user@example.com"}
+	assert.Error(t, validator.New().Struct(req))
+}
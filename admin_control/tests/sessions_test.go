@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"crmSystem/jwks"
+	"crmSystem/proto/auth"
+	"crmSystem/transport_rest"
+	"crmSystem/transport_rest/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeAuthClient — AuthServiceClient с сессиями пользователя 7 в памяти
+type fakeAuthClient struct {
+	auth.AuthServiceClient
+	sessions map[string]*auth.UserSession
+}
+
+func (f *fakeAuthClient) ListUserSessions(_ context.Context, in *auth.ListUserSessionsRequest, _ ...grpc.CallOption) (*auth.ListUserSessionsResponse, error) {
+	if in.UserId != "7" {
+		return nil, status.Error(codes.InvalidArgument, "некорректный ID пользователя")
+	}
+	res := &auth.ListUserSessionsResponse{}
+	for _, session := range f.sessions {
+		res.Sessions = append(res.Sessions, session)
+	}
+	return res, nil
+}
+
+func (f *fakeAuthClient) RevokeUserSession(_ context.Context, in *auth.RevokeUserSessionRequest, _ ...grpc.CallOption) (*auth.RevokeUserSessionResponse, error) {
+	if _, ok := f.sessions[in.SessionId]; !ok || in.UserId != "7" {
+		return nil, status.Error(codes.NotFound, "сессия не найдена")
+	}
+	delete(f.sessions, in.SessionId)
+	return &auth.RevokeUserSessionResponse{Message: "Сессия завершена"}, nil
+}
+
+// TestUserSessions проверяет, что просмотр и завершение сессий выполняются через auth сервис
+// от имени администратора, а ошибки auth сервиса отображаются в HTTP статусы
+func TestUserSessions(t *testing.T) {
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keys := jwks.NewKeySet("", nil, &signingKey.PublicKey)
+	validToken := signAccessToken(t, signingKey, jwks.Thumbprint(&signingKey.PublicKey))
+
+	client := &fakeAuthClient{sessions: map[string]*auth.UserSession{
+		"laptop": {Id: "laptop", Device: "Chrome, Windows", CreatedAt: 100, RotatedAt: 200},
+	}}
+	var dialedToken string
+	router := transport_rest.NewHandlerWith(transport_rest.Dependencies{
+		ParseToken: userTokenParser(keys),
+		DialAuth: func(token string) (auth.AuthServiceClient, func() error, error) {
+			dialedToken = token
+			return client, func() error { return nil }, nil
+		},
+	}).InitRouter()
+
+	serve := func(method string, path string, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.AddCookie(&http.Cookie{Name: "access_token", Value: token})
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(http.MethodGet, "/admin/users/7/sessions", validToken)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, validToken, dialedToken)
+	var sessions types.SessionsResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&sessions))
+	require.Len(t, sessions.Sessions, 1)
+	assert.Equal(t, "laptop", sessions.Sessions[0].Id)
+	assert.Equal(t, int64(200), sessions.Sessions[0].LastSeenAt.Unix())
+
+	assert.Equal(t, http.StatusBadRequest, serve(http.MethodGet, "/admin/users/abc/sessions", validToken).Code)
+
+	// Сессия завершается один раз, повторный запрос получает 404
+	assert.Equal(t, http.StatusOK, serve(http.MethodDelete, "/admin/users/7/sessions/laptop", validToken).Code)
+	assert.Equal(t, http.StatusNotFound, serve(http.MethodDelete, "/admin/users/7/sessions/laptop", validToken).Code)
+
+	// Refresh token не даёт доступа, auth сервис не вызывается
+	dialedToken = ""
+	refreshToken := signUserToken(t, signingKey, jwks.Thumbprint(&signingKey.PublicKey), "refresh")
+	assert.Equal(t, http.StatusUnauthorized, serve(http.MethodGet, "/admin/users/7/sessions", refreshToken).Code)
+	assert.Empty(t, dialedToken)
+}
@@ -0,0 +1,116 @@
+package transport_rest
+
+import (
+	"context"
+	"crmSystem/proto/dbadmin"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"errors"
+	"github.com/gorilla/mux"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"net/http"
+	"time"
+)
+
+// CompanyApiKeys возвращает API ключи всех пользователей компании. Доступно только администратору.
+func (h *Handler) CompanyApiKeys(w http.ResponseWriter, r *http.Request) {
+	h.withAdminApiKeys(w, r, func(ctx context.Context, client dbadmin.DbAdminServiceClient) (interface{}, error) {
+		return CallListCompanyApiKeys(ctx, client)
+	})
+}
+
+// RevokeCompanyApiKey отзывает API ключ любого пользователя компании. Доступно только администратору.
+func (h *Handler) RevokeCompanyApiKey(w http.ResponseWriter, r *http.Request) {
+	keyId := mux.Vars(r)["id"]
+
+	h.withAdminApiKeys(w, r, func(ctx context.Context, client dbadmin.DbAdminServiceClient) (interface{}, error) {
+		return CallRevokeCompanyApiKey(ctx, client, keyId)
+	})
+}
+
+// CallListCompanyApiKeys получает из dbservice API ключи компании администратора.
+func CallListCompanyApiKeys(ctx context.Context, client dbadmin.DbAdminServiceClient) (*types.CompanyApiKeysResponse, error) {
+	resDB, err := client.ListCompanyApiKeys(ctx, &dbadmin.ListCompanyApiKeysRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &types.CompanyApiKeysResponse{Keys: make([]types.CompanyApiKeyResponse, 0, len(resDB.Keys))}
+	for _, key := range resDB.Keys {
+		response.Keys = append(response.Keys, types.CompanyApiKeyResponse{
+			Id:         key.Id,
+			Email:      key.Email,
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scopes:     key.Scopes,
+			ExpiresAt:  optionalTime(key.ExpiresAt),
+			CreatedAt:  time.Unix(key.CreatedAt, 0).UTC(),
+			LastUsedAt: optionalTime(key.LastUsedAt),
+		})
+	}
+	return response, nil
+}
+
+// CallRevokeCompanyApiKey отзывает в dbservice API ключ keyId компании администратора.
+func CallRevokeCompanyApiKey(ctx context.Context, client dbadmin.DbAdminServiceClient, keyId string) (*types.MessageResponse, error) {
+	resDB, err := client.RevokeCompanyApiKey(ctx, &dbadmin.RevokeCompanyApiKeyRequest{Id: keyId})
+	if err != nil {
+		return nil, err
+	}
+	return &types.MessageResponse{Message: resDB.Message}, nil
+}
+
+// withAdminApiKeys подключается к dbservice с токеном администратора, выполняет call и записывает ответ.
+// Права администратора проверяет dbservice по подписанному access token.
+func (h *Handler) withAdminApiKeys(w http.ResponseWriter, r *http.Request,
+	call func(ctx context.Context, client dbadmin.DbAdminServiceClient) (interface{}, error)) {
+
+	token, user := h.userFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	client, err, conn := utils.GRPCServiceConnector(token, dbadmin.NewDbAdminServiceClient)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу: %v", err)
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}(conn)
+
+	response, err := call(ctx, client)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.PermissionDenied:
+			utils.CreateError(w, http.StatusForbidden, "Недостаточно прав", errors.New(status.Convert(err).Message()))
+		case codes.NotFound:
+			utils.CreateError(w, http.StatusNotFound, "API ключ не найден", errors.New(status.Convert(err).Message()))
+		default:
+			utils.CreateError(w, http.StatusInternalServerError, "Не корректная ошибка на сервере.", err)
+		}
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, response); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
+
+// optionalTime переводит время в секундах в указатель, 0 означает отсутствие значения.
+func optionalTime(unix int64) *time.Time {
+	if unix == 0 {
+		return nil
+	}
+	t := time.Unix(unix, 0).UTC()
+	return &t
+}
@@ -2,6 +2,8 @@ package transport_rest
 
 import (
 	"context"
+	"crmSystem/lifecycle"
+	"crmSystem/proto/auth"
 	"crmSystem/proto/dbadmin"
 	"crmSystem/proto/email-service"
 	"crmSystem/proto/logs"
@@ -11,28 +13,75 @@ import (
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
-	grpc "google.golang.org/grpc"
-	"google.golang.org/grpc/metadata"
 	"log"
 	"net/http"
+	"net/url"
+	"os"
 	"strings"
 	"sync"
 	"time"
 )
 
 type Handler struct {
+	deps Dependencies
+}
+
+// Dependencies проверка токенов и подключения к сервисам, которые использует обработчик.
+// NewHandler берёт рабочие реализации, в тестах они заменяются.
+type Dependencies struct {
+	ParseToken  func(token string) (*utils.UserClaims, error)                          // Проверка access token
+	DialDbAdmin func(token string) (dbadmin.DbAdminServiceClient, func() error, error) // Подключение к dbservice
+	DialLogs    func(token string) (logs.LogsServiceClient, func() error, error)       // Подключение к Logs
+	DialEmail   func(token string) (email.EmailServiceClient, func() error, error)     // Подключение к email-service
+	DialAuth    func(token string) (auth.AuthServiceClient, func() error, error)       // Подключение к auth сервису
 }
 
 func NewHandler() *Handler {
-	return &Handler{}
+	return NewHandlerWith(Dependencies{
+		ParseToken: utils.ParseUserToken,
+		DialDbAdmin: func(token string) (dbadmin.DbAdminServiceClient, func() error, error) {
+			return utils.DialService(token, dbadmin.NewDbAdminServiceClient)
+		},
+		DialLogs: func(token string) (logs.LogsServiceClient, func() error, error) {
+			return utils.DialService(token, logs.NewLogsServiceClient)
+		},
+		DialEmail: func(token string) (email.EmailServiceClient, func() error, error) {
+			return utils.DialService(token, email.NewEmailServiceClient)
+		},
+		DialAuth: func(token string) (auth.AuthServiceClient, func() error, error) {
+			return utils.DialService(token, auth.NewAuthServiceClient)
+		},
+	})
+}
+
+// NewHandlerWith создаёт обработчик с зависимостями deps
+func NewHandlerWith(deps Dependencies) *Handler {
+	return &Handler{deps: deps}
+}
+
+// userFromToken проверяет access_token из cookie. При ошибке записывает ответ и возвращает nil
+func (h *Handler) userFromToken(w http.ResponseWriter, r *http.Request) (string, *utils.UserClaims) {
+	return utils.UserFromToken(w, r, h.deps.ParseToken)
 }
 
 func (h *Handler) InitRouter() *mux.Router {
 	r := mux.NewRouter()
 
+	// Проверки состояния сервиса для docker-compose
+	r.Handle("/healthz", lifecycle.LivenessHandler()).Methods(http.MethodGet)
+	r.Handle("/readyz", lifecycle.ReadinessHandler()).Methods(http.MethodGet)
+
 	adminRouts := r.PathPrefix("/admin").Subrouter()
 	{
 		adminRouts.HandleFunc("/addusers", utils.RecoverMiddleware(h.AddUsers)).Methods(http.MethodPost)
+		adminRouts.HandleFunc("/mfa-policy", utils.RecoverMiddleware(h.SetMfaPolicy)).Methods(http.MethodPost)
+		adminRouts.HandleFunc("/email-otp-policy", utils.RecoverMiddleware(h.SetEmailOtpPolicy)).Methods(http.MethodPost)
+		adminRouts.HandleFunc("/unlock", utils.RecoverMiddleware(h.UnlockUser)).Methods(http.MethodPost)
+		adminRouts.HandleFunc("/oidc", utils.RecoverMiddleware(h.SetOidcConfig)).Methods(http.MethodPut)
+		adminRouts.HandleFunc("/api-keys", utils.RecoverMiddleware(h.CompanyApiKeys)).Methods(http.MethodGet)
+		adminRouts.HandleFunc("/api-keys/{id}", utils.RecoverMiddleware(h.RevokeCompanyApiKey)).Methods(http.MethodDelete)
+		adminRouts.HandleFunc("/users/{userId}/sessions", utils.RecoverMiddleware(h.UserSessions)).Methods(http.MethodGet)
+		adminRouts.HandleFunc("/users/{userId}/sessions/{id}", utils.RecoverMiddleware(h.RevokeUserSession)).Methods(http.MethodDelete)
 	}
 
 	return r
@@ -40,34 +89,19 @@ func (h *Handler) InitRouter() *mux.Router {
 
 func (h *Handler) AddUsers(w http.ResponseWriter, r *http.Request) {
 
-	// Получаем cookie с именами
-	token := utils.GetFromCookies(w, r, "access_token")
-	if token == "" {
-		utils.CreateError(w, http.StatusBadRequest, "Токен не найден", fmt.Errorf(""))
-		return
-	}
-
-	userId := utils.GetFromCookies(w, r, "user-id")
-	if userId == "" {
-		utils.CreateError(w, http.StatusBadRequest, "user-id не найден", fmt.Errorf(""))
+	// Получаем токен и данные пользователя из подписанного access token,
+	// в dbservice они не передаются в метаданных: он берёт их из проверенного токена
+	token, user := h.userFromToken(w, r)
+	if user == nil {
 		return
 	}
+	database := user.Database
+	userId := user.UserId
 
-	database := utils.GetFromCookies(w, r, "database")
-	if database == "" {
-		utils.CreateError(w, http.StatusBadRequest, "database не найдена", fmt.Errorf(""))
-		return
-	}
-
-	md := metadata.Pairs(
-		"user-id", userId,
-		"database", database,
-	)
-
-	ctxWithMetadata := metadata.NewOutgoingContext(context.Background(), md)
+	ctxWithMetadata := context.Background()
 
 	// Устанавливаем соединение с gRPC сервером Logs
-	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
+	clientLogs, closeLogs, err := h.deps.DialLogs(token)
 	if err != nil {
 		log.Printf("Не удалось подключиться к серверу: %v", err)
 		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
@@ -77,8 +111,8 @@ func (h *Handler) AddUsers(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	} else {
-		defer func(conn *grpc.ClientConn) {
-			err := conn.Close()
+		defer func() {
+			err := closeLogs()
 			if err != nil {
 				log.Printf("Ошибка закрытия соединения: %v", err)
 				errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, err.Error())
@@ -87,7 +121,7 @@ func (h *Handler) AddUsers(w http.ResponseWriter, r *http.Request) {
 				}
 				return
 			}
-		}(conn)
+		}()
 	}
 
 	var reqUsers types.RegisterUsersRequest
@@ -124,7 +158,7 @@ func (h *Handler) AddUsers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Устанавливаем соединение с gRPC сервером dbService
-	client, err, conn := utils.GRPCServiceConnector(token, dbadmin.NewDbAdminServiceClient)
+	client, closeDbAdmin, err := h.deps.DialDbAdmin(token)
 	if err != nil {
 		log.Printf("Не удалось подключиться к серверу: %v", err)
 		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
@@ -134,8 +168,8 @@ func (h *Handler) AddUsers(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	} else {
-		defer func(conn *grpc.ClientConn) {
-			err := conn.Close()
+		defer func() {
+			err := closeDbAdmin()
 			if err != nil {
 				log.Printf("Ошибка закрытия соединения: %v", err)
 				errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, err.Error())
@@ -144,7 +178,7 @@ func (h *Handler) AddUsers(w http.ResponseWriter, r *http.Request) {
 				}
 				return
 			}
-		}(conn)
+		}()
 	}
 
 	//Вызываем регистрацию пользователя на dbservice
@@ -159,7 +193,7 @@ func (h *Handler) AddUsers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Устанавливаем соединение с gRPC сервером dbService
-	clientEmail, err, conn := utils.GRPCServiceConnector(token, email.NewEmailServiceClient)
+	clientEmail, closeEmail, err := h.deps.DialEmail(token)
 	if err != nil {
 		log.Printf("Не удалось подключиться к серверу: %v", err)
 		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
@@ -171,8 +205,8 @@ func (h *Handler) AddUsers(w http.ResponseWriter, r *http.Request) {
 
 		return
 	} else {
-		defer func(conn *grpc.ClientConn) {
-			err := conn.Close()
+		defer func() {
+			err := closeEmail()
 			if err != nil {
 				log.Printf("Ошибка закрытия соединения: %v", err)
 				errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, err.Error())
@@ -181,7 +215,7 @@ func (h *Handler) AddUsers(w http.ResponseWriter, r *http.Request) {
 				}
 				return
 			}
-		}(conn)
+		}()
 	}
 
 	// Подготовим список успешных и неуспешных отправок
@@ -190,24 +224,7 @@ func (h *Handler) AddUsers(w http.ResponseWriter, r *http.Request) {
 
 	// Отправка на почту всем пользователям
 	for _, user := range response.Users {
-		mailRequest := types.SendEmailRequest{
-			Email:   user.Email, // используем email текущего пользователя
-			Message: "Welcome to our service! FROM PETR",
-			Body: fmt.Sprintf(
-				`Hello %s,
-
-				Thank you for signing up for our service! We are excited to have you on board.
-				
-				Here are your login details:
-				- **Login**: %s
-				- **Password**: %s
-				
-				If you have any questions, feel free to contact our support team.
-				
-				Best regards,
-				The Team at Our Service`,
-				user.Email, user.Email, user.Password),
-		}
+		mailRequest := welcomeEmail(user)
 
 		// Отправляем письмо
 		_, err := SendToEmailUser(clientEmail, &mailRequest)
@@ -258,6 +275,46 @@ func (h *Handler) AddUsers(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// welcomeEmail формирует письмо добавленному пользователю.
+// Новый пользователь получает ссылку приглашения, по которой сам задаёт пароль,
+// уже зарегистрированный - уведомление о добавлении в компанию.
+func welcomeEmail(user *dbadmin.UserResponse) types.SendEmailRequest {
+	if user.InviteToken == "" {
+		return types.SendEmailRequest{
+			Email:   user.Email,
+			Message: "You have been added to a company",
+			Body: fmt.Sprintf(
+				`Hello %s,
+
+				You have been added to a company in our service.
+				Sign in with your existing login and password.
+
+				Best regards,
+				The Team at Our Service`,
+				user.Email),
+		}
+	}
+
+	link := os.Getenv("INVITE_URL") + "?token=" + url.QueryEscape(user.InviteToken)
+	return types.SendEmailRequest{
+		Email:   user.Email, // используем email текущего пользователя
+		Message: "Welcome to our service! FROM PETR",
+		Body: fmt.Sprintf(
+			`Hello %s,
+
+			You have been invited to our service! We are excited to have you on board.
+
+			Your login is %s. To set your password and activate the account, follow the link:
+			%s
+
+			The link is valid for 72 hours and can be used once.
+
+			Best regards,
+			The Team at Our Service`,
+			user.Email, user.Email, link),
+	}
+}
+
 func TransformUsersConcurrently(users []*types.User) []*dbadmin.User {
 	// Канал для передачи преобразованных пользователей
 	resultChan := make(chan *dbadmin.User, len(users))
@@ -341,10 +398,13 @@ func SendToEmailUser(client email.EmailServiceClient, req *types.SendEmailReques
 	}
 
 	resDB, err := client.SendEmail(ctx, reqMail)
+	if err != nil {
+		return nil, err
+	}
 
 	response = &email.SendEmailResponse{
 		Message: resDB.Message,
 	}
 
-	return response, err
+	return response, nil
 }
@@ -0,0 +1,97 @@
+package transport_rest
+
+import (
+	"context"
+	"crmSystem/proto/dbadmin"
+	"crmSystem/proto/logs"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"net/http"
+	"time"
+)
+
+// SetMfaPolicy включает или отключает обязательную двухфакторную аутентификацию в компании.
+// Компания и роль берутся dbservice из подписанного access token, изменить политику может только администратор.
+func (h *Handler) SetMfaPolicy(w http.ResponseWriter, r *http.Request) {
+	token, user := h.userFromToken(w, r)
+	if user == nil {
+		return
+	}
+	database := user.Database
+	userId := user.UserId
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	// Устанавливаем соединение с gRPC сервером Logs
+	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу: %v", err)
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}(conn)
+
+	var req types.MfaPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка при декодировании данных", err)
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка валидации", fmt.Errorf("поле 'Required' не прошло валидацию"))
+		return
+	}
+
+	// Устанавливаем соединение с gRPC сервером dbService
+	client, err, dbConn := utils.GRPCServiceConnector(token, dbadmin.NewDbAdminServiceClient)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу: %v", err)
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}(dbConn)
+
+	response, err := CallSetMfaPolicy(ctx, client, *req.Required)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.PermissionDenied:
+			utils.CreateError(w, http.StatusForbidden, "Недостаточно прав", errors.New(status.Convert(err).Message()))
+		default:
+			utils.CreateError(w, http.StatusInternalServerError, "Не корректная ошибка на сервере.", err)
+			errLogs := utils.SaveLogsError(ctx, clientLogs, database, userId, err.Error())
+			if errLogs != nil {
+				log.Printf("Не удалось передать логи ошибки: %v", errLogs)
+			}
+		}
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, response); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
+
+// CallSetMfaPolicy передаёт в dbservice новую политику 2FA компании пользователя.
+func CallSetMfaPolicy(ctx context.Context, client dbadmin.DbAdminServiceClient, required bool) (*types.MfaPolicyResponse, error) {
+	resDB, err := client.SetMfaPolicy(ctx, &dbadmin.SetMfaPolicyRequest{Required: required})
+	if err != nil {
+		return nil, err
+	}
+	return &types.MfaPolicyResponse{Message: resDB.Message}, nil
+}
@@ -0,0 +1,111 @@
+package transport_rest
+
+import (
+	"context"
+	"crmSystem/proto/dbadmin"
+	"crmSystem/proto/logs"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"encoding/json"
+	"errors"
+	"github.com/go-playground/validator/v10"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"net/http"
+	"time"
+)
+
+// SetOidcConfig настраивает вход пользователей компании через корпоративного OpenID Connect провайдера.
+// Доступно только администратору, компания берётся dbservice из подписанного access token.
+func (h *Handler) SetOidcConfig(w http.ResponseWriter, r *http.Request) {
+	token, user := h.userFromToken(w, r)
+	if user == nil {
+		return
+	}
+	database := user.Database
+	userId := user.UserId
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	// Устанавливаем соединение с gRPC сервером Logs
+	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу: %v", err)
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}(conn)
+
+	var req types.OidcConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка при декодировании данных", err)
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) && len(validationErrors) > 0 {
+			err = errors.New("поле '" + validationErrors[0].Field() + "' не прошло валидацию")
+		}
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка валидации", err)
+		return
+	}
+
+	// Устанавливаем соединение с gRPC сервером dbService
+	client, err, dbConn := utils.GRPCServiceConnector(token, dbadmin.NewDbAdminServiceClient)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу: %v", err)
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}(dbConn)
+
+	response, err := CallSetOidcConfig(ctx, client, &req)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.PermissionDenied:
+			utils.CreateError(w, http.StatusForbidden, "Недостаточно прав", errors.New(status.Convert(err).Message()))
+		case codes.InvalidArgument:
+			utils.CreateError(w, http.StatusBadRequest, "Ошибка валидации", errors.New(status.Convert(err).Message()))
+		case codes.AlreadyExists:
+			utils.CreateError(w, http.StatusConflict, "Домен уже используется", errors.New(status.Convert(err).Message()))
+		default:
+			utils.CreateError(w, http.StatusInternalServerError, "Не корректная ошибка на сервере.", err)
+			errLogs := utils.SaveLogsError(ctx, clientLogs, database, userId, err.Error())
+			if errLogs != nil {
+				log.Printf("Не удалось передать логи ошибки: %v", errLogs)
+			}
+		}
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, response); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
+
+// CallSetOidcConfig передаёт в dbservice настройки провайдера компании.
+func CallSetOidcConfig(ctx context.Context, client dbadmin.DbAdminServiceClient,
+	req *types.OidcConfigRequest) (*types.OidcConfigResponse, error) {
+	resDB, err := client.SetOidcConfig(ctx, &dbadmin.SetOidcConfigRequest{
+		Issuer:       req.Issuer,
+		ClientId:     req.ClientId,
+		ClientSecret: req.ClientSecret,
+		Domains:      req.Domains,
+		Enabled:      req.Enabled != nil && *req.Enabled,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &types.OidcConfigResponse{Message: resDB.Message}, nil
+}
@@ -0,0 +1,104 @@
+package transport_rest
+
+import (
+	"context"
+	"crmSystem/proto/auth"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"net/http"
+	"time"
+)
+
+// UserSessions возвращает активные сессии пользователя компании администратора.
+func (h *Handler) UserSessions(w http.ResponseWriter, r *http.Request) {
+	h.withAuthSessions(w, r, func(ctx context.Context, client auth.AuthServiceClient, userId string) {
+		res, err := client.ListUserSessions(ctx, &auth.ListUserSessionsRequest{UserId: userId})
+		if err != nil {
+			sessionsError(w, "Не удалось получить сессии", err)
+			return
+		}
+
+		response := types.SessionsResponse{Sessions: make([]types.SessionResponse, 0, len(res.Sessions))}
+		for _, session := range res.Sessions {
+			response.Sessions = append(response.Sessions, types.SessionResponse{
+				Id:         session.Id,
+				Device:     session.Device,
+				Ip:         session.Ip,
+				UserAgent:  session.UserAgent,
+				CreatedAt:  time.Unix(session.CreatedAt, 0).UTC(),
+				LastSeenAt: time.Unix(session.RotatedAt, 0).UTC(),
+			})
+		}
+
+		if err := utils.WriteJSON(w, http.StatusOK, response); err != nil {
+			log.Printf("Ошибка записи ответа: %v", err)
+		}
+	})
+}
+
+// RevokeUserSession завершает сессию пользователя компании администратора.
+// Уже выданный access token сессии остаётся действительным до истечения срока.
+func (h *Handler) RevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	sessionId := mux.Vars(r)["id"]
+
+	h.withAuthSessions(w, r, func(ctx context.Context, client auth.AuthServiceClient, userId string) {
+		res, err := client.RevokeUserSession(ctx, &auth.RevokeUserSessionRequest{UserId: userId, SessionId: sessionId})
+		if err != nil {
+			sessionsError(w, "Не удалось завершить сессию", err)
+			return
+		}
+
+		if err := utils.WriteJSON(w, http.StatusOK, types.MessageResponse{Message: res.Message}); err != nil {
+			log.Printf("Ошибка записи ответа: %v", err)
+		}
+	})
+}
+
+// withAuthSessions проверяет access token, подключается к auth сервису от имени администратора и вызывает
+// call с ID пользователя из пути. Сессии хранит auth сервис, он же проверяет роль администратора.
+func (h *Handler) withAuthSessions(w http.ResponseWriter, r *http.Request,
+	call func(ctx context.Context, client auth.AuthServiceClient, userId string)) {
+
+	token, admin := h.userFromToken(w, r)
+	if admin == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), time.Second*10)
+	defer cancel()
+
+	client, closeAuth, err := h.deps.DialAuth(token)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу: %v", err)
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer func() {
+		if err := closeAuth(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}()
+
+	call(ctx, client, mux.Vars(r)["userId"])
+}
+
+// sessionsError отображает ошибку auth сервиса в HTTP статус
+func sessionsError(w http.ResponseWriter, message string, err error) {
+	errorMessage := status.Convert(err).Message()
+	switch status.Code(err) {
+	case codes.PermissionDenied:
+		utils.CreateError(w, http.StatusForbidden, "Недостаточно прав", err)
+	case codes.InvalidArgument:
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка валидации", err)
+	case codes.NotFound:
+		utils.CreateError(w, http.StatusNotFound, errorMessage, err)
+	case codes.Unauthenticated:
+		utils.CreateError(w, http.StatusUnauthorized, errorMessage, err)
+	default:
+		utils.CreateError(w, http.StatusInternalServerError, message, err)
+	}
+}
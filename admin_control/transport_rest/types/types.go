@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 type SendEmailRequest struct {
 	Email   string `json:"email" validate:"required,email"`
 	Message string `json:"message"`
@@ -28,10 +30,10 @@ type RegisterUsersRequest struct {
 }
 
 type UserResponse struct {
-	Email    string `json:"email" validate:"required,email"`
-	Phone    string `json:"phone" validate:"phone"`
-	RoleId   string `json:"roleId"`
-	Password string `json:"password"`
+	Email       string `json:"email" validate:"required,email"`
+	Phone       string `json:"phone" validate:"phone"`
+	RoleId      string `json:"roleId"`
+	InviteToken string `json:"inviteToken"` // Пусто, если пользователь уже был зарегистрирован
 }
 
 type RegisterUsersResponse struct {
@@ -39,3 +41,73 @@ type RegisterUsersResponse struct {
 	Users     []UserResponse `json:"userResponse"` // Исправлено users вместо userResponse
 	CompanyId string         `json:"companyId"`    // Исправлено на CompanyId
 }
+
+type MfaPolicyRequest struct {
+	Required *bool `json:"required" validate:"required"` // Требовать 2FA от всех пользователей компании
+}
+
+type MfaPolicyResponse struct {
+	Message string `json:"message"`
+}
+
+type EmailOtpPolicyRequest struct {
+	Enabled *bool `json:"enabled" validate:"required"` // Разрешить вход по одноразовому коду из письма
+}
+
+type EmailOtpPolicyResponse struct {
+	Message string `json:"message"`
+}
+
+type UnlockUserRequest struct {
+	Email string `json:"email" validate:"required,email"` // Пользователь компании, вход которого нужно разблокировать
+}
+
+type UnlockUserResponse struct {
+	Message string `json:"message"`
+}
+
+type OidcConfigRequest struct {
+	Issuer       string   `json:"issuer" validate:"required,url"`                       // Адрес OpenID Connect провайдера компании
+	ClientId     string   `json:"clientId" validate:"required"`                         // ID клиента CRM у провайдера
+	ClientSecret string   `json:"clientSecret" validate:"required"`                     // Секрет клиента CRM у провайдера
+	Domains      []string `json:"domains" validate:"required,min=1,dive,required,fqdn"` // Почтовые домены пользователей компании
+	Enabled      *bool    `json:"enabled" validate:"required"`                          // Разрешить вход через провайдера
+}
+
+type OidcConfigResponse struct {
+	Message string `json:"message"`
+}
+
+// SessionResponse активная сессия (вход) пользователя компании.
+type SessionResponse struct {
+	Id         string    `json:"id"`
+	Device     string    `json:"device"`
+	Ip         string    `json:"ip"`
+	UserAgent  string    `json:"userAgent"`
+	CreatedAt  time.Time `json:"createdAt"`  // Время входа
+	LastSeenAt time.Time `json:"lastSeenAt"` // Время последнего обновления токенов
+}
+
+type SessionsResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+}
+
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// CompanyApiKeyResponse API ключ пользователя компании без секрета.
+type CompanyApiKeyResponse struct {
+	Id         string     `json:"id"`
+	Email      string     `json:"email"` // Владелец ключа
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+type CompanyApiKeysResponse struct {
+	Keys []CompanyApiKeyResponse `json:"keys"`
+}
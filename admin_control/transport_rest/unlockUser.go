@@ -0,0 +1,99 @@
+package transport_rest
+
+import (
+	"context"
+	"crmSystem/proto/dbadmin"
+	"crmSystem/proto/logs"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	grpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"log"
+	"net/http"
+	"time"
+)
+
+// UnlockUser снимает блокировку входа пользователя компании после неудачных попыток.
+// Доступно только администратору, компания берётся dbservice из подписанного access token.
+func (h *Handler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	token, user := h.userFromToken(w, r)
+	if user == nil {
+		return
+	}
+	database := user.Database
+	userId := user.UserId
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	// Устанавливаем соединение с gRPC сервером Logs
+	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу: %v", err)
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}(conn)
+
+	var req types.UnlockUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка при декодировании данных", err)
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка валидации", fmt.Errorf("поле 'Email' не прошло валидацию"))
+		return
+	}
+
+	// Устанавливаем соединение с gRPC сервером dbService
+	client, err, dbConn := utils.GRPCServiceConnector(token, dbadmin.NewDbAdminServiceClient)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу: %v", err)
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}(dbConn)
+
+	response, err := CallUnlockUser(ctx, client, req.Email)
+	if err != nil {
+		switch status.Code(err) {
+		case codes.PermissionDenied:
+			utils.CreateError(w, http.StatusForbidden, "Недостаточно прав", errors.New(status.Convert(err).Message()))
+		case codes.NotFound:
+			utils.CreateError(w, http.StatusNotFound, "Пользователь не найден", errors.New(status.Convert(err).Message()))
+		default:
+			utils.CreateError(w, http.StatusInternalServerError, "Не корректная ошибка на сервере.", err)
+			errLogs := utils.SaveLogsError(ctx, clientLogs, database, userId, err.Error())
+			if errLogs != nil {
+				log.Printf("Не удалось передать логи ошибки: %v", errLogs)
+			}
+		}
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, response); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
+
+// CallUnlockUser передаёт в dbservice запрос на снятие блокировки входа пользователя с адресом email.
+func CallUnlockUser(ctx context.Context, client dbadmin.DbAdminServiceClient, email string) (*types.UnlockUserResponse, error) {
+	resDB, err := client.UnlockUser(ctx, &dbadmin.UnlockUserRequest{Email: email})
+	if err != nil {
+		return nil, err
+	}
+	return &types.UnlockUserResponse{Message: resDB.Message}, nil
+}
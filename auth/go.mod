@@ -3,6 +3,8 @@ module crmSystem
 go 1.23
 
 require (
+	crmSystem/jwks v0.0.0
+	crmSystem/lifecycle v0.0.0
 	github.com/go-playground/validator/v10 v10.23.0
 	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/golang/mock v1.6.0
@@ -29,3 +31,6 @@ require (
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240903143218-8af14fe29dc1 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+replace crmSystem/jwks => ../jwks
+replace crmSystem/lifecycle => ../lifecycle
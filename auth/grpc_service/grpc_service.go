@@ -2,14 +2,49 @@ package grpc_service
 
 import (
 	"crmSystem/proto/auth"
+	"crmSystem/proto/redis"
+	"crmSystem/utils"
+	"fmt"
 )
 
 type AuthServiceServer struct {
 	auth.UnimplementedAuthServiceServer
+	deps Dependencies
+}
+
+// Dependencies проверка токенов и подключение к хранилищу сессий, которые использует сервис.
+// NewGRPCService берёт рабочие реализации, в тестах они заменяются.
+type Dependencies struct {
+	ParseToken   func(token string) (utils.UserClaims, error)      // Проверка access token
+	DialSessions func() (*utils.RefreshStore, func() error, error) // Подключение к хранилищу сессий
 }
 
 func NewGRPCService() *AuthServiceServer {
-	return &AuthServiceServer{}
+	return NewGRPCServiceWith(Dependencies{
+		ParseToken:   utils.ParseAccessTokenString,
+		DialSessions: dialRefreshStore,
+	})
+}
+
+// NewGRPCServiceWith создаёт сервис с зависимостями deps
+func NewGRPCServiceWith(deps Dependencies) *AuthServiceServer {
+	return &AuthServiceServer{deps: deps}
+}
+
+// dialRefreshStore подключается к redis сервису с внутренним токеном auth сервиса
+func dialRefreshStore() (*utils.RefreshStore, func() error, error) {
+	token, err := utils.InternalJwtGenerator()
+	if err != nil {
+		return nil, nil, fmt.Errorf("не удалось создать токен: %w", err)
+	}
+	client, err, conn := utils.GRPCServiceConnector(token, redis.NewRedisServiceClient)
+	if err != nil {
+		if conn != nil {
+			_ = conn.Close()
+		}
+		return nil, nil, fmt.Errorf("не удалось подключиться к серверу redis: %w", err)
+	}
+	return utils.NewRefreshStore(client), conn.Close, nil
 }
 
 /*func callRegisterCompany(client dbauth.DbAuthServiceClient, req *auth.RegisterAuthRequest, ctx context.Context) (response *auth.RegisterAuthResponse, err error) {
@@ -0,0 +1,126 @@
+package grpc_service
+
+import (
+	"context"
+	"crmSystem/proto/auth"
+	"crmSystem/utils"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ListUserSessions возвращает активные сессии пользователя компании администратора, начиная с последней активной.
+func (s *AuthServiceServer) ListUserSessions(ctx context.Context, req *auth.ListUserSessionsRequest) (*auth.ListUserSessionsResponse, error) {
+	var response *auth.ListUserSessionsResponse
+	err := s.withCompanyUserSessions(ctx, req.UserId, func(ctx context.Context, _ utils.UserClaims, store *utils.RefreshStore, user utils.UserClaims) error {
+		sessions, err := store.ListSessions(ctx, user)
+		if err != nil {
+			log.Printf("Ошибка получения сессий пользователя: %v", err)
+			return status.Errorf(codes.Internal, "не удалось получить сессии")
+		}
+
+		response = &auth.ListUserSessionsResponse{Sessions: make([]*auth.UserSession, 0, len(sessions))}
+		for _, session := range sessions {
+			response.Sessions = append(response.Sessions, &auth.UserSession{
+				Id:        session.Id,
+				Device:    session.Device,
+				Ip:        session.Ip,
+				UserAgent: session.UserAgent,
+				CreatedAt: session.CreatedAt,
+				RotatedAt: session.RotatedAt,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// RevokeUserSession завершает сессию пользователя компании администратора.
+// Уже выданный access token сессии остаётся действительным до истечения срока.
+func (s *AuthServiceServer) RevokeUserSession(ctx context.Context, req *auth.RevokeUserSessionRequest) (*auth.RevokeUserSessionResponse, error) {
+	if req.SessionId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "не указан ID сессии")
+	}
+
+	err := s.withCompanyUserSessions(ctx, req.UserId, func(ctx context.Context, admin utils.UserClaims, store *utils.RefreshStore, user utils.UserClaims) error {
+		err := store.RevokeUserSession(ctx, user, req.SessionId)
+		if errors.Is(err, utils.ErrSessionNotFound) {
+			return status.Errorf(codes.NotFound, "сессия не найдена")
+		}
+		if err != nil {
+			log.Printf("Ошибка завершения сессии: %v", err)
+			return status.Errorf(codes.Internal, "не удалось завершить сессию")
+		}
+		log.Printf("Администратор %s базы %s завершил сессию %s пользователя %s",
+			admin.UserId, admin.Database, req.SessionId, user.UserId)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &auth.RevokeUserSessionResponse{Message: "Сессия завершена"}, nil
+}
+
+// withCompanyUserSessions проверяет, что вызов выполняет администратор компании (access token
+// в метаданных authorization), подключается к хранилищу сессий и вызывает call для пользователя
+// userId базы компании администратора. Сессии пользователей других компаний недоступны.
+func (s *AuthServiceServer) withCompanyUserSessions(ctx context.Context, userId string,
+	call func(ctx context.Context, admin utils.UserClaims, store *utils.RefreshStore, user utils.UserClaims) error) error {
+
+	admin, err := s.userFromMetadata(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Сессии пользователей компании доступны только администратору (роль первого пользователя компании)
+	if admin.Role != os.Getenv("FIRST_ROLE") {
+		return status.Errorf(codes.PermissionDenied, "управлять сессиями пользователей может только администратор компании")
+	}
+	if _, err := strconv.ParseInt(userId, 10, 64); err != nil {
+		return status.Errorf(codes.InvalidArgument, "некорректный ID пользователя")
+	}
+
+	store, closeStore, err := s.deps.DialSessions()
+	if err != nil {
+		log.Printf("Ошибка подключения к хранилищу сессий: %v", err)
+		return status.Errorf(codes.Unavailable, "ошибка подключения к хранилищу сессий")
+	}
+	defer func() {
+		if err := closeStore(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	return call(ctx, admin, store, utils.UserClaims{Database: admin.Database, UserId: userId})
+}
+
+// userFromMetadata проверяет access token из метаданных authorization ("Bearer <token>")
+func (s *AuthServiceServer) userFromMetadata(ctx context.Context) (utils.UserClaims, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok || len(md.Get("authorization")) == 0 {
+		return utils.UserClaims{}, status.Errorf(codes.Unauthenticated, "токен не передан")
+	}
+	token, found := strings.CutPrefix(md.Get("authorization")[0], "Bearer ")
+	if !found || token == "" {
+		return utils.UserClaims{}, status.Errorf(codes.Unauthenticated, "неверный формат заголовка authorization")
+	}
+
+	user, err := s.deps.ParseToken(token)
+	if err != nil {
+		return utils.UserClaims{}, status.Errorf(codes.Unauthenticated, "недействительный токен: %v", err)
+	}
+	return user, nil
+}
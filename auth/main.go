@@ -2,9 +2,12 @@ package main
 
 import (
 	"crmSystem/grpc_service"
+	"crmSystem/lifecycle"
 	"crmSystem/proto/auth"
 	"crmSystem/transport_rest"
 	"crmSystem/utils"
+	"errors"
+	"flag"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
@@ -18,6 +21,15 @@ import (
 
 func main() {
 	grpcPort := os.Getenv("AUTH_SERVICE_GRPC_PORT")
+
+	healthcheck := flag.Bool("healthcheck", false, "проверить состояние запущенного сервиса и завершиться (healthcheck docker-compose)")
+	flag.Parse()
+	if *healthcheck {
+		if err := lifecycle.ProbeHealth("localhost:"+grpcPort, utils.ClientCACertFile, utils.ServerCertFile, utils.ServerKeyFile); err != nil {
+			log.Fatalf("Сервис не готов: %v", err)
+		}
+		return
+	}
 	lis, err := net.Listen("tcp", ":"+grpcPort)
 	if err != nil {
 		log.Fatalf("Не удалось запустить сервер: %v", err)
@@ -51,6 +63,9 @@ func main() {
 	// Включаем отражение для gRPC
 	reflection.Register(grpcServer)
 
+	// Состояние сервера для healthcheck docker-compose, сервис не имеет собственных зависимостей
+	stopHealth := lifecycle.StartHealthServer(grpcServer, 10*time.Second)
+
 	log.Printf("gRPC сервер запущен на %s с TLS", ":"+grpcPort)
 
 	// HTTP сервер с обработчиком
@@ -64,16 +79,36 @@ func main() {
 		Handler: handler.InitRouter(),
 	}
 
+	// Остановка по SIGINT и SIGTERM: новые запросы не принимаются, текущие завершаются в течение DRAIN_TIMEOUT
+	ctx, stop := lifecycle.ShutdownContext()
+	defer stop()
+
 	// Запускаем HTTP сервер с TLS в отдельной горутине
 	go func() {
 		log.Println("HTTP SERVER STARTED WITH TLS ON PORT", httpPort)
-		if err := httpServer.ListenAndServeTLS(utils.ServerCertFile, utils.ServerKeyFile); err != nil {
+		if err := httpServer.ListenAndServeTLS(utils.ServerCertFile, utils.ServerKeyFile); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatalf("Ошибка запуска HTTP сервера с TLS: %v", err)
 		}
 	}()
 
 	// Запуск gRPC сервера
-	if err := grpcServer.Serve(lis); err != nil {
-		log.Fatalf("Ошибка запуска gRPC сервера: %v", err)
-	}
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			log.Fatalf("Ошибка запуска gRPC сервера: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("Получен сигнал остановки, завершаем текущие запросы")
+	stopHealth()
+
+	// HTTP и gRPC серверы останавливаются одновременно, общее время ограничено DRAIN_TIMEOUT
+	drainTimeout := lifecycle.DrainTimeout()
+	done := make(chan struct{})
+	go func() {
+		lifecycle.ShutdownHTTPServer(httpServer, drainTimeout)
+		close(done)
+	}()
+	lifecycle.StopGRPCServer(grpcServer, drainTimeout)
+	<-done
 }
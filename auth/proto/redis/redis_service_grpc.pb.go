@@ -0,0 +1,437 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.27.3
+// source: redis/proto/redis_service.proto
+
+package redis
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	RedisService_Save_FullMethodName       = "/protobuff.RedisService/Save"
+	RedisService_Get_FullMethodName        = "/protobuff.RedisService/Get"
+	RedisService_Set_FullMethodName        = "/protobuff.RedisService/Set"
+	RedisService_GetDel_FullMethodName     = "/protobuff.RedisService/GetDel"
+	RedisService_Delete_FullMethodName     = "/protobuff.RedisService/Delete"
+	RedisService_SetAdd_FullMethodName     = "/protobuff.RedisService/SetAdd"
+	RedisService_SetRemove_FullMethodName  = "/protobuff.RedisService/SetRemove"
+	RedisService_SetMembers_FullMethodName = "/protobuff.RedisService/SetMembers"
+	RedisService_Increment_FullMethodName  = "/protobuff.RedisService/Increment"
+)
+
+// RedisServiceClient is the client API for RedisService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type RedisServiceClient interface {
+	// Метод для регистрации
+	Save(ctx context.Context, in *SaveRedisRequest, opts ...grpc.CallOption) (*SaveRedisResponse, error)
+	Get(ctx context.Context, in *GetRedisRequest, opts ...grpc.CallOption) (*GetRedisResponse, error)
+	// Сохранение с перезаписью существующего значения
+	Set(ctx context.Context, in *SaveRedisRequest, opts ...grpc.CallOption) (*SaveRedisResponse, error)
+	// Получение значения с его удалением (одноразовые ключи)
+	GetDel(ctx context.Context, in *GetRedisRequest, opts ...grpc.CallOption) (*GetRedisResponse, error)
+	// Удаление ключей
+	Delete(ctx context.Context, in *DeleteRedisRequest, opts ...grpc.CallOption) (*DeleteRedisResponse, error)
+	// Работа с множествами
+	SetAdd(ctx context.Context, in *SetRedisRequest, opts ...grpc.CallOption) (*SetRedisResponse, error)
+	SetRemove(ctx context.Context, in *SetRedisRequest, opts ...grpc.CallOption) (*SetRedisResponse, error)
+	SetMembers(ctx context.Context, in *GetRedisRequest, opts ...grpc.CallOption) (*SetMembersRedisResponse, error)
+	// Атомарное увеличение счётчика на 1 (счётчики неудачных попыток входа)
+	Increment(ctx context.Context, in *IncrementRedisRequest, opts ...grpc.CallOption) (*IncrementRedisResponse, error)
+}
+
+type redisServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewRedisServiceClient(cc grpc.ClientConnInterface) RedisServiceClient {
+	return &redisServiceClient{cc}
+}
+
+func (c *redisServiceClient) Save(ctx context.Context, in *SaveRedisRequest, opts ...grpc.CallOption) (*SaveRedisResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SaveRedisResponse)
+	err := c.cc.Invoke(ctx, RedisService_Save_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redisServiceClient) Get(ctx context.Context, in *GetRedisRequest, opts ...grpc.CallOption) (*GetRedisResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRedisResponse)
+	err := c.cc.Invoke(ctx, RedisService_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redisServiceClient) Set(ctx context.Context, in *SaveRedisRequest, opts ...grpc.CallOption) (*SaveRedisResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SaveRedisResponse)
+	err := c.cc.Invoke(ctx, RedisService_Set_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redisServiceClient) GetDel(ctx context.Context, in *GetRedisRequest, opts ...grpc.CallOption) (*GetRedisResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetRedisResponse)
+	err := c.cc.Invoke(ctx, RedisService_GetDel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redisServiceClient) Delete(ctx context.Context, in *DeleteRedisRequest, opts ...grpc.CallOption) (*DeleteRedisResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteRedisResponse)
+	err := c.cc.Invoke(ctx, RedisService_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redisServiceClient) SetAdd(ctx context.Context, in *SetRedisRequest, opts ...grpc.CallOption) (*SetRedisResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetRedisResponse)
+	err := c.cc.Invoke(ctx, RedisService_SetAdd_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redisServiceClient) SetRemove(ctx context.Context, in *SetRedisRequest, opts ...grpc.CallOption) (*SetRedisResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetRedisResponse)
+	err := c.cc.Invoke(ctx, RedisService_SetRemove_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redisServiceClient) SetMembers(ctx context.Context, in *GetRedisRequest, opts ...grpc.CallOption) (*SetMembersRedisResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetMembersRedisResponse)
+	err := c.cc.Invoke(ctx, RedisService_SetMembers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *redisServiceClient) Increment(ctx context.Context, in *IncrementRedisRequest, opts ...grpc.CallOption) (*IncrementRedisResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IncrementRedisResponse)
+	err := c.cc.Invoke(ctx, RedisService_Increment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RedisServiceServer is the server API for RedisService service.
+// All implementations must embed UnimplementedRedisServiceServer
+// for forward compatibility.
+type RedisServiceServer interface {
+	// Метод для регистрации
+	Save(context.Context, *SaveRedisRequest) (*SaveRedisResponse, error)
+	Get(context.Context, *GetRedisRequest) (*GetRedisResponse, error)
+	// Сохранение с перезаписью существующего значения
+	Set(context.Context, *SaveRedisRequest) (*SaveRedisResponse, error)
+	// Получение значения с его удалением (одноразовые ключи)
+	GetDel(context.Context, *GetRedisRequest) (*GetRedisResponse, error)
+	// Удаление ключей
+	Delete(context.Context, *DeleteRedisRequest) (*DeleteRedisResponse, error)
+	// Работа с множествами
+	SetAdd(context.Context, *SetRedisRequest) (*SetRedisResponse, error)
+	SetRemove(context.Context, *SetRedisRequest) (*SetRedisResponse, error)
+	SetMembers(context.Context, *GetRedisRequest) (*SetMembersRedisResponse, error)
+	// Атомарное увеличение счётчика на 1 (счётчики неудачных попыток входа)
+	Increment(context.Context, *IncrementRedisRequest) (*IncrementRedisResponse, error)
+	mustEmbedUnimplementedRedisServiceServer()
+}
+
+// UnimplementedRedisServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedRedisServiceServer struct{}
+
+func (UnimplementedRedisServiceServer) Save(context.Context, *SaveRedisRequest) (*SaveRedisResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Save not implemented")
+}
+func (UnimplementedRedisServiceServer) Get(context.Context, *GetRedisRequest) (*GetRedisResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedRedisServiceServer) Set(context.Context, *SaveRedisRequest) (*SaveRedisResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Set not implemented")
+}
+func (UnimplementedRedisServiceServer) GetDel(context.Context, *GetRedisRequest) (*GetRedisResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDel not implemented")
+}
+func (UnimplementedRedisServiceServer) Delete(context.Context, *DeleteRedisRequest) (*DeleteRedisResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedRedisServiceServer) SetAdd(context.Context, *SetRedisRequest) (*SetRedisResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetAdd not implemented")
+}
+func (UnimplementedRedisServiceServer) SetRemove(context.Context, *SetRedisRequest) (*SetRedisResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRemove not implemented")
+}
+func (UnimplementedRedisServiceServer) SetMembers(context.Context, *GetRedisRequest) (*SetMembersRedisResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMembers not implemented")
+}
+func (UnimplementedRedisServiceServer) Increment(context.Context, *IncrementRedisRequest) (*IncrementRedisResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Increment not implemented")
+}
+func (UnimplementedRedisServiceServer) mustEmbedUnimplementedRedisServiceServer() {}
+func (UnimplementedRedisServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeRedisServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to RedisServiceServer will
+// result in compilation errors.
+type UnsafeRedisServiceServer interface {
+	mustEmbedUnimplementedRedisServiceServer()
+}
+
+func RegisterRedisServiceServer(s grpc.ServiceRegistrar, srv RedisServiceServer) {
+	// If the following call pancis, it indicates UnimplementedRedisServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&RedisService_ServiceDesc, srv)
+}
+
+func _RedisService_Save_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaveRedisRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedisServiceServer).Save(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RedisService_Save_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedisServiceServer).Save(ctx, req.(*SaveRedisRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RedisService_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRedisRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedisServiceServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RedisService_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedisServiceServer).Get(ctx, req.(*GetRedisRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RedisService_Set_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SaveRedisRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedisServiceServer).Set(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RedisService_Set_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedisServiceServer).Set(ctx, req.(*SaveRedisRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RedisService_GetDel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRedisRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedisServiceServer).GetDel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RedisService_GetDel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedisServiceServer).GetDel(ctx, req.(*GetRedisRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RedisService_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRedisRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedisServiceServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RedisService_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedisServiceServer).Delete(ctx, req.(*DeleteRedisRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RedisService_SetAdd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRedisRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedisServiceServer).SetAdd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RedisService_SetAdd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedisServiceServer).SetAdd(ctx, req.(*SetRedisRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RedisService_SetRemove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRedisRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedisServiceServer).SetRemove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RedisService_SetRemove_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedisServiceServer).SetRemove(ctx, req.(*SetRedisRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RedisService_SetMembers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRedisRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedisServiceServer).SetMembers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RedisService_SetMembers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedisServiceServer).SetMembers(ctx, req.(*GetRedisRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RedisService_Increment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IncrementRedisRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RedisServiceServer).Increment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RedisService_Increment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RedisServiceServer).Increment(ctx, req.(*IncrementRedisRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// RedisService_ServiceDesc is the grpc.ServiceDesc for RedisService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var RedisService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "protobuff.RedisService",
+	HandlerType: (*RedisServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Save",
+			Handler:    _RedisService_Save_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _RedisService_Get_Handler,
+		},
+		{
+			MethodName: "Set",
+			Handler:    _RedisService_Set_Handler,
+		},
+		{
+			MethodName: "GetDel",
+			Handler:    _RedisService_GetDel_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _RedisService_Delete_Handler,
+		},
+		{
+			MethodName: "SetAdd",
+			Handler:    _RedisService_SetAdd_Handler,
+		},
+		{
+			MethodName: "SetRemove",
+			Handler:    _RedisService_SetRemove_Handler,
+		},
+		{
+			MethodName: "SetMembers",
+			Handler:    _RedisService_SetMembers_Handler,
+		},
+		{
+			MethodName: "Increment",
+			Handler:    _RedisService_Increment_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "redis/proto/redis_service.proto",
+}
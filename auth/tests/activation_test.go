@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	email "crmSystem/proto/email-service"
+	"crmSystem/tests/mocks"
+	"crmSystem/utils"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestSendActivationLink checks the activation token is mailed as an escaped link.
+func TestSendActivationLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockEmail := mocks.NewMockEmailServiceClient(ctrl)
+	mockEmail.EXPECT().SendEmail(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, req *email.SendEmailRequest, _ ...interface{}) (*email.SendEmailResponse, error) {
+			assert.Equal(t, "owner@example.com", req.Email)
+			assert.Contains(t, req.Body, "https://crm/activate?token=a%2Bb")
+			return &email.SendEmailResponse{}, nil
+		})
+
+	err := utils.SendActivationLink(context.Background(), mockEmail, "owner@example.com", "https://crm/activate", "a+b")
+	require.NoError(t, err)
+}
+
+// TestActivateAccount checks dbservice errors are mapped to activation errors.
+func TestActivateAccount(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockDb := mocks.NewMockDbAuthServiceClient(ctrl)
+
+	mockDb.EXPECT().ActivateAccount(gomock.Any(), &dbauth.ActivateAccountRequest{Token: "verify"}).
+		Return(&dbauth.ActivateAccountResponse{}, nil)
+	mockDb.EXPECT().ActivateAccount(gomock.Any(), &dbauth.ActivateAccountRequest{Token: "used"}).
+		Return(nil, status.Error(codes.NotFound, "ссылка активации недействительна или устарела"))
+	mockDb.EXPECT().ActivateAccount(gomock.Any(), &dbauth.ActivateAccountRequest{Token: "invite"}).
+		Return(nil, status.Error(codes.InvalidArgument, "для активации приглашения необходимо задать пароль"))
+	mockDb.EXPECT().ActivateAccount(gomock.Any(), &dbauth.ActivateAccountRequest{Token: "invite", Password: "NewPassword1!"}).
+		Return(&dbauth.ActivateAccountResponse{}, nil)
+
+	assert.NoError(t, utils.ActivateAccount(ctx, mockDb, "verify", ""))
+	assert.ErrorIs(t, utils.ActivateAccount(ctx, mockDb, "used", ""), utils.ErrActivationTokenInvalid)
+	assert.ErrorIs(t, utils.ActivateAccount(ctx, mockDb, "invite", ""), utils.ErrActivationPasswordRequired)
+	assert.NoError(t, utils.ActivateAccount(ctx, mockDb, "invite", "NewPassword1!"))
+}
+
+// TestRegistrationStatus checks the provisioning state is returned and unknown registrations are reported.
+func TestRegistrationStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockDb := mocks.NewMockDbAuthServiceClient(ctrl)
+
+	mockDb.EXPECT().GetProvisioningStatus(gomock.Any(), &dbauth.GetProvisioningStatusRequest{ProvisioningId: "abc"}).
+		Return(&dbauth.GetProvisioningStatusResponse{Status: "pending", Message: "Рабочее пространство компании создаётся"}, nil)
+	mockDb.EXPECT().GetProvisioningStatus(gomock.Any(), &dbauth.GetProvisioningStatusRequest{ProvisioningId: "unknown"}).
+		Return(nil, status.Error(codes.NotFound, "регистрация не найдена"))
+	mockDb.EXPECT().GetProvisioningStatus(gomock.Any(), &dbauth.GetProvisioningStatusRequest{ProvisioningId: "broken"}).
+		Return(nil, status.Error(codes.Internal, "ошибка подключения к базе данных авторизации"))
+
+	state, message, err := utils.RegistrationStatus(ctx, mockDb, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "pending", state)
+	assert.Equal(t, "Рабочее пространство компании создаётся", message)
+
+	_, _, err = utils.RegistrationStatus(ctx, mockDb, "unknown")
+	assert.ErrorIs(t, err, utils.ErrRegistrationNotFound)
+
+	_, _, err = utils.RegistrationStatus(ctx, mockDb, "broken")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, utils.ErrRegistrationNotFound)
+}
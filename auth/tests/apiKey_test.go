@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	"crmSystem/tests/mocks"
+	"crmSystem/transport_rest"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestNormalizeApiKeyScopes(t *testing.T) {
+	scopes, err := utils.NormalizeApiKeyScopes([]string{" Timers:Write", "chats:read", "chats:read"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chats:read", "timers:write"}, scopes)
+
+	for _, invalid := range [][]string{nil, {"chats"}, {"chats:delete"}, {"billing:read"}} {
+		_, err := utils.NormalizeApiKeyScopes(invalid)
+		assert.Error(t, err, "%v", invalid)
+	}
+}
+
+func TestApiKeyScopeCheck(t *testing.T) {
+	tests := []struct {
+		method  string
+		uri     string
+		scopes  []string
+		allowed bool
+	}{
+		{method: http.MethodGet, uri: "/chats/list?page=2", scopes: []string{"chats:read"}, allowed: true},
+		{method: http.MethodGet, uri: "/chats", scopes: []string{"chats:write"}, allowed: true},
+		{method: http.MethodPost, uri: "/chats/create", scopes: []string{"chats:read"}, allowed: false},
+		{method: http.MethodPost, uri: "/timer/start", scopes: []string{"timers:write"}, allowed: true},
+		{method: http.MethodDelete, uri: "/admin/users/1", scopes: []string{"chats:write", "timers:write"}, allowed: false},
+		// A prefix match must end at a path segment boundary
+		{method: http.MethodGet, uri: "/chatsecret", scopes: []string{"chats:read"}, allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.uri, func(t *testing.T) {
+			required, err := utils.RequiredApiKeyScope(tt.method, tt.uri)
+			assert.Equal(t, tt.allowed, err == nil && utils.ApiKeyAllows(tt.scopes, required))
+		})
+	}
+}
+
+func TestGenerateApiKey(t *testing.T) {
+	key, prefix, hash, err := utils.GenerateApiKey()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, prefix))
+	assert.Equal(t, utils.HashApiKey(key), hash)
+	assert.NotContains(t, hash, key)
+
+	r := httptest.NewRequest(http.MethodGet, "/chats", nil)
+	r.Header.Set("Authorization", "ApiKey "+key)
+	parsed, err := utils.ApiKeyFromRequest(r)
+	require.NoError(t, err)
+	assert.Equal(t, key, parsed)
+
+	r.Header.Set("Authorization", "Bearer "+key)
+	_, err = utils.ApiKeyFromRequest(r)
+	assert.ErrorIs(t, err, utils.ErrApiKeyMissing)
+}
+
+// expectVerifyApiKey sets up dbservice to accept the key and send the owner's identity in the response header.
+func expectVerifyApiKey(mockDb *mocks.MockDbAuthServiceClient, key string, scopes []string) {
+	mockDb.EXPECT().VerifyApiKey(gomock.Any(), &dbauth.VerifyApiKeyRequest{KeyHash: utils.HashApiKey(key)}, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ *dbauth.VerifyApiKeyRequest, opts ...grpc.CallOption) (*dbauth.VerifyApiKeyResponse, error) {
+			for _, opt := range opts {
+				if header, ok := opt.(grpc.HeaderCallOption); ok {
+					*header.HeaderAddr = metadata.Pairs("database", "company_3", "user-id", "7", "company-id", "3",
+						"role", "user", "auth-user-id", "11")
+				}
+			}
+			return &dbauth.VerifyApiKeyResponse{Id: "5", Scopes: scopes}, nil
+		})
+}
+
+func TestAuthorizeApiKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDb := mocks.NewMockDbAuthServiceClient(ctrl)
+	ctx := context.Background()
+
+	expectVerifyApiKey(mockDb, "crm_reader", []string{"chats:read"})
+	user, err := transport_rest.AuthorizeApiKey(ctx, mockDb, "crm_reader", http.MethodGet, "/chats/list")
+	require.NoError(t, err)
+	assert.Equal(t, utils.UserClaims{
+		Database: "company_3", UserId: "7", CompanyId: "3", Role: "user", AuthUserId: "11",
+		SessionId: "apikey:5", Scopes: []string{"chats:read"},
+	}, user)
+
+	// A read-only key cannot write
+	expectVerifyApiKey(mockDb, "crm_reader", []string{"chats:read"})
+	_, err = transport_rest.AuthorizeApiKey(ctx, mockDb, "crm_reader", http.MethodPost, "/chats/create")
+	assert.Equal(t, uint32(http.StatusForbidden), utils.ApiKeyErrorStatus(err))
+
+	// Paths outside the key areas are rejected before dbservice is called
+	_, err = transport_rest.AuthorizeApiKey(ctx, mockDb, "crm_reader", http.MethodGet, "/auth/api-keys")
+	assert.Equal(t, uint32(http.StatusForbidden), utils.ApiKeyErrorStatus(err))
+
+	// Revoked or unknown keys are reported as unauthenticated
+	mockDb.EXPECT().VerifyApiKey(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, status.Error(codes.Unauthenticated, "API ключ недействителен"))
+	_, err = transport_rest.AuthorizeApiKey(ctx, mockDb, "crm_revoked", http.MethodGet, "/chats")
+	assert.Equal(t, uint32(http.StatusUnauthorized), utils.ApiKeyErrorStatus(err))
+}
+
+func TestIssueApiKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDb := mocks.NewMockDbAuthServiceClient(ctrl)
+	user := utils.UserClaims{AuthUserId: "11"}
+
+	var savedHash string
+	mockDb.EXPECT().CreateApiKey(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, req *dbauth.CreateApiKeyRequest, _ ...grpc.CallOption) (*dbauth.CreateApiKeyResponse, error) {
+			assert.Equal(t, "11", req.UserId)
+			assert.Equal(t, []string{"timers:read"}, req.Scopes)
+			savedHash = req.KeyHash
+			return &dbauth.CreateApiKeyResponse{Id: "5"}, nil
+		})
+
+	res, err := transport_rest.IssueApiKey(context.Background(), mockDb, user,
+		&types.CreateApiKeyRequest{Name: "reports", Scopes: []string{"timers:read"}})
+	require.NoError(t, err)
+	assert.Equal(t, "5", res.Id)
+	assert.True(t, strings.HasPrefix(res.Key, res.Prefix))
+	// Only the hash of the key is sent to dbservice
+	assert.Equal(t, utils.HashApiKey(res.Key), savedHash)
+
+	_, err = transport_rest.IssueApiKey(context.Background(), mockDb, user,
+		&types.CreateApiKeyRequest{Name: "reports", Scopes: []string{"timers:delete"}})
+	assert.Equal(t, uint32(http.StatusBadRequest), utils.ApiKeyErrorStatus(err))
+}
@@ -0,0 +1,19 @@
+package tests
+
+import (
+	"crmSystem/utils"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestClientIp checks the client address is taken from nginx X-Real-IP or from the connection.
+func TestClientIp(t *testing.T) {
+	r := httptest.NewRequest("POST", "/auth/login", nil)
+	r.RemoteAddr = "172.18.0.5:43210"
+	assert.Equal(t, "172.18.0.5", utils.ClientIp(r))
+
+	r.Header.Set("X-Real-IP", "203.0.113.7")
+	assert.Equal(t, "203.0.113.7", utils.ClientIp(r))
+}
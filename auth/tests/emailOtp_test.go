@@ -0,0 +1,148 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	email "crmSystem/proto/email-service"
+	"crmSystem/tests/mocks"
+	"crmSystem/utils"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var emailOtpCodePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+// TestSendEmailOtp checks the code is mailed only to allowed users and stored hashed with a TTL.
+func TestSendEmailOtp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockDb := mocks.NewMockDbAuthServiceClient(ctrl)
+	mockEmail := mocks.NewMockEmailServiceClient(ctrl)
+	redis := mocks.NewFakeRedisServiceClient()
+	store := utils.NewEmailOtpStore(redis)
+
+	var sentCode string
+	mockDb.EXPECT().FindEmailOtpUser(gomock.Any(), &dbauth.FindEmailOtpUserRequest{Email: "user@example.com"}).
+		Return(&dbauth.FindEmailOtpUserResponse{UserId: "42"}, nil)
+	mockEmail.EXPECT().SendEmail(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, req *email.SendEmailRequest, _ ...interface{}) (*email.SendEmailResponse, error) {
+			assert.Equal(t, "user@example.com", req.Email)
+			sentCode = emailOtpCodePattern.FindString(req.Body)
+			return &email.SendEmailResponse{}, nil
+		})
+
+	require.NoError(t, utils.SendEmailOtp(ctx, mockDb, store, mockEmail, "user@example.com"))
+	require.Len(t, sentCode, utils.EmailOtpDigits)
+
+	// The raw code and email must not be stored in redis
+	require.Len(t, redis.Values, 1)
+	for key, value := range redis.Values {
+		assert.True(t, strings.HasPrefix(key, "emailOtp:"))
+		assert.NotContains(t, key, "user@example.com")
+		assert.NotContains(t, value, sentCode)
+		assert.Equal(t, int64(utils.EmailOtpTTL.Seconds()), redis.Expirations[key])
+	}
+
+	// Unknown email or company without the policy: no error and no email
+	mockDb.EXPECT().FindEmailOtpUser(gomock.Any(), &dbauth.FindEmailOtpUserRequest{Email: "nobody@example.com"}).
+		Return(nil, status.Error(codes.NotFound, "пользователь не найден"))
+	mockDb.EXPECT().FindEmailOtpUser(gomock.Any(), &dbauth.FindEmailOtpUserRequest{Email: "other@example.com"}).
+		Return(nil, status.Error(codes.FailedPrecondition, "вход по коду из письма не разрешён"))
+
+	assert.NoError(t, utils.SendEmailOtp(ctx, mockDb, store, mockEmail, "nobody@example.com"))
+	assert.NoError(t, utils.SendEmailOtp(ctx, mockDb, store, mockEmail, "other@example.com"))
+}
+
+// TestEmailOtpVerify checks codes are single-use, bound to the email and limited in attempts.
+func TestEmailOtpVerify(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Single use", func(t *testing.T) {
+		store := utils.NewEmailOtpStore(mocks.NewFakeRedisServiceClient())
+		code, err := store.Issue(ctx, "user@example.com", "42")
+		require.NoError(t, err)
+
+		_, err = store.Verify(ctx, "other@example.com", code)
+		assert.ErrorIs(t, err, utils.ErrEmailOtpInvalid)
+
+		authUserId, err := store.Verify(ctx, " User@Example.com", code)
+		require.NoError(t, err)
+		assert.Equal(t, "42", authUserId)
+
+		_, err = store.Verify(ctx, "user@example.com", code)
+		assert.ErrorIs(t, err, utils.ErrEmailOtpInvalid)
+	})
+
+	t.Run("Attempts exhausted", func(t *testing.T) {
+		store := utils.NewEmailOtpStore(mocks.NewFakeRedisServiceClient())
+		code, err := store.Issue(ctx, "user@example.com", "42")
+		require.NoError(t, err)
+
+		wrong := "000000"
+		if code == wrong {
+			wrong = "111111"
+		}
+		for i := 0; i < utils.EmailOtpMaxAttempts; i++ {
+			_, err = store.Verify(ctx, "user@example.com", wrong)
+			assert.ErrorIs(t, err, utils.ErrEmailOtpInvalid)
+		}
+
+		// The correct code no longer works once the attempts are used up
+		_, err = store.Verify(ctx, "user@example.com", code)
+		assert.ErrorIs(t, err, utils.ErrEmailOtpInvalid)
+	})
+
+	t.Run("New code resets attempts", func(t *testing.T) {
+		store := utils.NewEmailOtpStore(mocks.NewFakeRedisServiceClient())
+		first, err := store.Issue(ctx, "user@example.com", "42")
+		require.NoError(t, err)
+		for i := 0; i < utils.EmailOtpMaxAttempts-1; i++ {
+			_, _ = store.Verify(ctx, "user@example.com", "not-a-code")
+		}
+
+		second, err := store.Issue(ctx, "user@example.com", "42")
+		require.NoError(t, err)
+		if first != second {
+			_, err = store.Verify(ctx, "user@example.com", first)
+			assert.ErrorIs(t, err, utils.ErrEmailOtpInvalid)
+		}
+
+		authUserId, err := store.Verify(ctx, "user@example.com", second)
+		require.NoError(t, err)
+		assert.Equal(t, "42", authUserId)
+	})
+}
+
+// TestEmailOtpRateLimit checks code requests are limited per email and per client IP.
+func TestEmailOtpRateLimit(t *testing.T) {
+	ctx := context.Background()
+	redis := mocks.NewFakeRedisServiceClient()
+	store := utils.NewEmailOtpStore(redis)
+
+	for i := 0; i < utils.EmailOtpRequestsPerMail; i++ {
+		require.NoError(t, store.AllowRequest(ctx, "user@example.com", "10.0.0.1"))
+	}
+	assert.ErrorIs(t, store.AllowRequest(ctx, "USER@example.com", "10.0.0.2"), utils.ErrEmailOtpRateLimited)
+
+	// The window is stored as the counter TTL
+	for key := range redis.Values {
+		assert.Equal(t, int64(utils.EmailOtpRequestWindow.Seconds()), redis.Expirations[key])
+	}
+
+	// Different emails from one IP are limited by the IP counter
+	var err error
+	for i := 0; i < utils.EmailOtpRequestsPerIp && err == nil; i++ {
+		err = store.AllowRequest(ctx, strings.Repeat("a", i+1)+"@example.com", "10.0.0.3")
+	}
+	assert.NoError(t, err)
+	assert.ErrorIs(t, store.AllowRequest(ctx, "last@example.com", "10.0.0.3"), utils.ErrEmailOtpRateLimited)
+}
@@ -61,9 +61,7 @@ func TestLogin(t *testing.T) {
 				}, gomock.Any()).DoAndReturn(
 					func(ctx context.Context, req *dbauth.LoginDBRequest, opts ...grpc.CallOption) (*dbauth.LoginDBResponse, error) {
 						md := metadata.New(map[string]string{
-							"database":   "test-db",
-							"user-id":    "user-123",
-							"company-id": "company-123",
+							"activation-token": "activation-token-123",
 						})
 						for k, v := range md {
 							grpc.SetHeader(ctx, metadata.Pairs(k, v[0]))
@@ -203,19 +201,21 @@ func TestRegister(t *testing.T) {
 				}, gomock.Any()).DoAndReturn(
 					func(ctx context.Context, req *dbauth.RegisterCompanyRequest, opts ...grpc.CallOption) (*dbauth.RegisterCompanyResponse, error) {
 						md := metadata.New(map[string]string{
-							"database":   "test-db",
-							"user-id":    "user-123",
-							"company-id": "company-123",
+							"activation-token": "activation-token-123",
 						})
 						for k, v := range md {
 							grpc.SetHeader(ctx, metadata.Pairs(k, v[0]))
 						}
-						return &dbauth.RegisterCompanyResponse{Message: "Registration successful"}, nil
+						return &dbauth.RegisterCompanyResponse{
+							Message:            "Registration successful",
+							ProvisioningId:     "provisioning-123",
+							ProvisioningStatus: "pending",
+						}, nil
 					},
 				)
 			},
-			expectedStatus: http.StatusOK,
-			expectedBody:   `{"message":"Registration successful"}`,
+			expectedStatus: http.StatusAccepted,
+			expectedBody:   `{"message":"Registration successful","provisioningId":"provisioning-123","status":"pending"}`,
 		},
 		{
 			name:    "Invalid JSON",
@@ -338,8 +338,8 @@ func TestRefreshToken(t *testing.T) {
 			mockSetup: func() {
 				mockLogs.EXPECT().SaveLogs(gomock.Any(), gomock.Any()).Return(&logs.LogResponse{}, nil).AnyTimes()
 			},
-			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   `{"error":"Не удалось создать токен","message":"cookie refresh_token not found"}`,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"message":"Не удалось обновить токен: refresh_token не найден"}`,
 		},
 		{
 			name: "Missing Access Token",
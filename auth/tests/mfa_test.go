@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/tests/mocks"
+	"crmSystem/utils"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestMfaErrorStatus checks dbservice MFA errors are mapped to HTTP statuses.
+func TestMfaErrorStatus(t *testing.T) {
+	assert.Equal(t, uint32(http.StatusUnauthorized), utils.MfaErrorStatus(status.Error(codes.Unauthenticated, "неверный код")))
+	assert.Equal(t, uint32(http.StatusTooManyRequests), utils.MfaErrorStatus(status.Error(codes.ResourceExhausted, "заблокировано")))
+	assert.Equal(t, uint32(http.StatusConflict), utils.MfaErrorStatus(status.Error(codes.FailedPrecondition, "уже подключена")))
+	assert.Equal(t, uint32(http.StatusBadRequest), utils.MfaErrorStatus(status.Error(codes.NotFound, "не найден")))
+	assert.Equal(t, uint32(http.StatusInternalServerError), utils.MfaErrorStatus(errors.New("ошибка")))
+}
+
+// TestMfaTokenStore checks a pending MFA token can be consumed only once and is rejected afterwards.
+func TestMfaTokenStore(t *testing.T) {
+	redisClient := mocks.NewFakeRedisServiceClient()
+	store := utils.NewMfaTokenStore(redisClient)
+	ctx := context.Background()
+
+	assert.NoError(t, store.Check(ctx, "jti-1"))
+	assert.NoError(t, store.Consume(ctx, "jti-1"))
+
+	// The consumed token is rejected and cannot be consumed by a concurrent request
+	assert.ErrorIs(t, store.Check(ctx, "jti-1"), utils.ErrMfaTokenUsed)
+	assert.ErrorIs(t, store.Consume(ctx, "jti-1"), utils.ErrMfaTokenUsed)
+
+	// The record lives as long as the token itself
+	assert.Equal(t, int64(utils.MfaPendingTokenTTL.Seconds()), redisClient.Expirations["mfaTokenUsed:jti-1"])
+	assert.NoError(t, store.Check(ctx, "jti-2"))
+}
@@ -0,0 +1,155 @@
+package mocks
+
+import (
+	"context"
+	"crmSystem/proto/redis"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// FakeRedisServiceClient хранящая данные в памяти реализация RedisServiceClient для тестов.
+// Время существования ключей сохраняется в Expirations и учитывается только при вызове Advance.
+type FakeRedisServiceClient struct {
+	mu          sync.Mutex
+	Values      map[string]string
+	Sets        map[string]map[string]struct{}
+	Expirations map[string]int64
+}
+
+func NewFakeRedisServiceClient() *FakeRedisServiceClient {
+	return &FakeRedisServiceClient{
+		Values:      map[string]string{},
+		Sets:        map[string]map[string]struct{}{},
+		Expirations: map[string]int64{},
+	}
+}
+
+func (f *FakeRedisServiceClient) Save(_ context.Context, in *redis.SaveRedisRequest, _ ...grpc.CallOption) (*redis.SaveRedisResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.Values[in.Key]; ok {
+		return &redis.SaveRedisResponse{Status: http.StatusConflict}, nil
+	}
+	f.Values[in.Key] = in.Value
+	f.Expirations[in.Key] = in.Expiration
+	return &redis.SaveRedisResponse{Status: http.StatusOK}, nil
+}
+
+func (f *FakeRedisServiceClient) Get(_ context.Context, in *redis.GetRedisRequest, _ ...grpc.CallOption) (*redis.GetRedisResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, ok := f.Values[in.Key]
+	if !ok {
+		return &redis.GetRedisResponse{Status: http.StatusNotFound, Message: "Ключ не найден"}, nil
+	}
+	return &redis.GetRedisResponse{Status: http.StatusOK, Message: value}, nil
+}
+
+func (f *FakeRedisServiceClient) Set(_ context.Context, in *redis.SaveRedisRequest, _ ...grpc.CallOption) (*redis.SaveRedisResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Values[in.Key] = in.Value
+	f.Expirations[in.Key] = in.Expiration
+	return &redis.SaveRedisResponse{Status: http.StatusOK}, nil
+}
+
+func (f *FakeRedisServiceClient) GetDel(_ context.Context, in *redis.GetRedisRequest, _ ...grpc.CallOption) (*redis.GetRedisResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, ok := f.Values[in.Key]
+	if !ok {
+		return &redis.GetRedisResponse{Status: http.StatusNotFound, Message: "Ключ не найден"}, nil
+	}
+	delete(f.Values, in.Key)
+	delete(f.Expirations, in.Key)
+	return &redis.GetRedisResponse{Status: http.StatusOK, Message: value}, nil
+}
+
+func (f *FakeRedisServiceClient) Delete(_ context.Context, in *redis.DeleteRedisRequest, _ ...grpc.CallOption) (*redis.DeleteRedisResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var deleted int64
+	for _, key := range in.Keys {
+		if _, ok := f.Values[key]; ok {
+			delete(f.Values, key)
+			deleted++
+		}
+		if _, ok := f.Sets[key]; ok {
+			delete(f.Sets, key)
+			deleted++
+		}
+		delete(f.Expirations, key)
+	}
+	return &redis.DeleteRedisResponse{Status: http.StatusOK, Deleted: deleted}, nil
+}
+
+func (f *FakeRedisServiceClient) SetAdd(_ context.Context, in *redis.SetRedisRequest, _ ...grpc.CallOption) (*redis.SetRedisResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	set, ok := f.Sets[in.Key]
+	if !ok {
+		set = map[string]struct{}{}
+		f.Sets[in.Key] = set
+	}
+	for _, member := range in.Members {
+		set[member] = struct{}{}
+	}
+	if in.Expiration > 0 {
+		f.Expirations[in.Key] = in.Expiration
+	}
+	return &redis.SetRedisResponse{Status: http.StatusOK}, nil
+}
+
+func (f *FakeRedisServiceClient) SetRemove(_ context.Context, in *redis.SetRedisRequest, _ ...grpc.CallOption) (*redis.SetRedisResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, member := range in.Members {
+		delete(f.Sets[in.Key], member)
+	}
+	return &redis.SetRedisResponse{Status: http.StatusOK}, nil
+}
+
+func (f *FakeRedisServiceClient) SetMembers(_ context.Context, in *redis.GetRedisRequest, _ ...grpc.CallOption) (*redis.SetMembersRedisResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	members := make([]string, 0, len(f.Sets[in.Key]))
+	for member := range f.Sets[in.Key] {
+		members = append(members, member)
+	}
+	return &redis.SetMembersRedisResponse{Status: http.StatusOK, Members: members}, nil
+}
+
+func (f *FakeRedisServiceClient) Increment(_ context.Context, in *redis.IncrementRedisRequest, _ ...grpc.CallOption) (*redis.IncrementRedisResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, _ := strconv.ParseInt(f.Values[in.Key], 10, 64)
+	value++
+	f.Values[in.Key] = strconv.FormatInt(value, 10)
+	if value == 1 && in.Expiration > 0 {
+		f.Expirations[in.Key] = in.Expiration
+	}
+	return &redis.IncrementRedisResponse{Status: http.StatusOK, Value: value}, nil
+}
+
+// Advance сдвигает время хранилища на d: ключи, время существования которых истекло, удаляются.
+func (f *FakeRedisServiceClient) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key, expiration := range f.Expirations {
+		if expiration <= 0 {
+			continue
+		}
+		expiration -= int64(d.Seconds())
+		if expiration > 0 {
+			f.Expirations[key] = expiration
+			continue
+		}
+		delete(f.Values, key)
+		delete(f.Sets, key)
+		delete(f.Expirations, key)
+	}
+}
@@ -76,6 +76,326 @@ func (mr *MockDbAuthServiceClientMockRecorder) RegisterCompany(ctx, in interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterCompany", reflect.TypeOf((*MockDbAuthServiceClient)(nil).RegisterCompany), varargs...)
 }
 
+// FindAuthUser mocks base method.
+func (m *MockDbAuthServiceClient) FindAuthUser(ctx context.Context, in *dbauth.FindAuthUserRequest, opts ...grpc.CallOption) (*dbauth.FindAuthUserResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "FindAuthUser", varargs...)
+	ret0, _ := ret[0].(*dbauth.FindAuthUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAuthUser indicates an expected call of FindAuthUser.
+func (mr *MockDbAuthServiceClientMockRecorder) FindAuthUser(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAuthUser", reflect.TypeOf((*MockDbAuthServiceClient)(nil).FindAuthUser), varargs...)
+}
+
+// ResetPassword mocks base method.
+func (m *MockDbAuthServiceClient) ResetPassword(ctx context.Context, in *dbauth.ResetPasswordRequest, opts ...grpc.CallOption) (*dbauth.ResetPasswordResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ResetPassword", varargs...)
+	ret0, _ := ret[0].(*dbauth.ResetPasswordResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockDbAuthServiceClientMockRecorder) ResetPassword(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockDbAuthServiceClient)(nil).ResetPassword), varargs...)
+}
+
+// ActivateAccount mocks base method.
+func (m *MockDbAuthServiceClient) ActivateAccount(ctx context.Context, in *dbauth.ActivateAccountRequest, opts ...grpc.CallOption) (*dbauth.ActivateAccountResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ActivateAccount", varargs...)
+	ret0, _ := ret[0].(*dbauth.ActivateAccountResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ActivateAccount indicates an expected call of ActivateAccount.
+func (mr *MockDbAuthServiceClientMockRecorder) ActivateAccount(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivateAccount", reflect.TypeOf((*MockDbAuthServiceClient)(nil).ActivateAccount), varargs...)
+}
+
+// BeginTotpEnrollment mocks base method.
+func (m *MockDbAuthServiceClient) BeginTotpEnrollment(ctx context.Context, in *dbauth.BeginTotpEnrollmentRequest, opts ...grpc.CallOption) (*dbauth.BeginTotpEnrollmentResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "BeginTotpEnrollment", varargs...)
+	ret0, _ := ret[0].(*dbauth.BeginTotpEnrollmentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginTotpEnrollment indicates an expected call of BeginTotpEnrollment.
+func (mr *MockDbAuthServiceClientMockRecorder) BeginTotpEnrollment(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTotpEnrollment", reflect.TypeOf((*MockDbAuthServiceClient)(nil).BeginTotpEnrollment), varargs...)
+}
+
+// ConfirmTotpEnrollment mocks base method.
+func (m *MockDbAuthServiceClient) ConfirmTotpEnrollment(ctx context.Context, in *dbauth.MfaCodeRequest, opts ...grpc.CallOption) (*dbauth.ConfirmTotpEnrollmentResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ConfirmTotpEnrollment", varargs...)
+	ret0, _ := ret[0].(*dbauth.ConfirmTotpEnrollmentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTotpEnrollment indicates an expected call of ConfirmTotpEnrollment.
+func (mr *MockDbAuthServiceClientMockRecorder) ConfirmTotpEnrollment(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTotpEnrollment", reflect.TypeOf((*MockDbAuthServiceClient)(nil).ConfirmTotpEnrollment), varargs...)
+}
+
+// VerifyMfa mocks base method.
+func (m *MockDbAuthServiceClient) VerifyMfa(ctx context.Context, in *dbauth.MfaCodeRequest, opts ...grpc.CallOption) (*dbauth.VerifyMfaResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "VerifyMfa", varargs...)
+	ret0, _ := ret[0].(*dbauth.VerifyMfaResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyMfa indicates an expected call of VerifyMfa.
+func (mr *MockDbAuthServiceClientMockRecorder) VerifyMfa(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMfa", reflect.TypeOf((*MockDbAuthServiceClient)(nil).VerifyMfa), varargs...)
+}
+
+// DisableTotp mocks base method.
+func (m *MockDbAuthServiceClient) DisableTotp(ctx context.Context, in *dbauth.MfaCodeRequest, opts ...grpc.CallOption) (*dbauth.DisableTotpResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DisableTotp", varargs...)
+	ret0, _ := ret[0].(*dbauth.DisableTotpResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableTotp indicates an expected call of DisableTotp.
+func (mr *MockDbAuthServiceClientMockRecorder) DisableTotp(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTotp", reflect.TypeOf((*MockDbAuthServiceClient)(nil).DisableTotp), varargs...)
+}
+
+// GetOidcProvider mocks base method.
+func (m *MockDbAuthServiceClient) GetOidcProvider(ctx context.Context, in *dbauth.GetOidcProviderRequest, opts ...grpc.CallOption) (*dbauth.GetOidcProviderResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetOidcProvider", varargs...)
+	ret0, _ := ret[0].(*dbauth.GetOidcProviderResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOidcProvider indicates an expected call of GetOidcProvider.
+func (mr *MockDbAuthServiceClientMockRecorder) GetOidcProvider(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOidcProvider", reflect.TypeOf((*MockDbAuthServiceClient)(nil).GetOidcProvider), varargs...)
+}
+
+// LoginOidc mocks base method.
+func (m *MockDbAuthServiceClient) LoginOidc(ctx context.Context, in *dbauth.LoginOidcRequest, opts ...grpc.CallOption) (*dbauth.LoginOidcResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "LoginOidc", varargs...)
+	ret0, _ := ret[0].(*dbauth.LoginOidcResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginOidc indicates an expected call of LoginOidc.
+func (mr *MockDbAuthServiceClientMockRecorder) LoginOidc(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginOidc", reflect.TypeOf((*MockDbAuthServiceClient)(nil).LoginOidc), varargs...)
+}
+
+// CreateApiKey mocks base method.
+func (m *MockDbAuthServiceClient) CreateApiKey(ctx context.Context, in *dbauth.CreateApiKeyRequest, opts ...grpc.CallOption) (*dbauth.CreateApiKeyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "CreateApiKey", varargs...)
+	ret0, _ := ret[0].(*dbauth.CreateApiKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateApiKey indicates an expected call of CreateApiKey.
+func (mr *MockDbAuthServiceClientMockRecorder) CreateApiKey(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApiKey", reflect.TypeOf((*MockDbAuthServiceClient)(nil).CreateApiKey), varargs...)
+}
+
+// ListApiKeys mocks base method.
+func (m *MockDbAuthServiceClient) ListApiKeys(ctx context.Context, in *dbauth.ListApiKeysRequest, opts ...grpc.CallOption) (*dbauth.ListApiKeysResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListApiKeys", varargs...)
+	ret0, _ := ret[0].(*dbauth.ListApiKeysResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListApiKeys indicates an expected call of ListApiKeys.
+func (mr *MockDbAuthServiceClientMockRecorder) ListApiKeys(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListApiKeys", reflect.TypeOf((*MockDbAuthServiceClient)(nil).ListApiKeys), varargs...)
+}
+
+// RevokeApiKey mocks base method.
+func (m *MockDbAuthServiceClient) RevokeApiKey(ctx context.Context, in *dbauth.RevokeApiKeyRequest, opts ...grpc.CallOption) (*dbauth.RevokeApiKeyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "RevokeApiKey", varargs...)
+	ret0, _ := ret[0].(*dbauth.RevokeApiKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeApiKey indicates an expected call of RevokeApiKey.
+func (mr *MockDbAuthServiceClientMockRecorder) RevokeApiKey(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeApiKey", reflect.TypeOf((*MockDbAuthServiceClient)(nil).RevokeApiKey), varargs...)
+}
+
+// VerifyApiKey mocks base method.
+func (m *MockDbAuthServiceClient) VerifyApiKey(ctx context.Context, in *dbauth.VerifyApiKeyRequest, opts ...grpc.CallOption) (*dbauth.VerifyApiKeyResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "VerifyApiKey", varargs...)
+	ret0, _ := ret[0].(*dbauth.VerifyApiKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyApiKey indicates an expected call of VerifyApiKey.
+func (mr *MockDbAuthServiceClientMockRecorder) VerifyApiKey(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyApiKey", reflect.TypeOf((*MockDbAuthServiceClient)(nil).VerifyApiKey), varargs...)
+}
+
+// FindEmailOtpUser mocks base method.
+func (m *MockDbAuthServiceClient) FindEmailOtpUser(ctx context.Context, in *dbauth.FindEmailOtpUserRequest, opts ...grpc.CallOption) (*dbauth.FindEmailOtpUserResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "FindEmailOtpUser", varargs...)
+	ret0, _ := ret[0].(*dbauth.FindEmailOtpUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindEmailOtpUser indicates an expected call of FindEmailOtpUser.
+func (mr *MockDbAuthServiceClientMockRecorder) FindEmailOtpUser(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindEmailOtpUser", reflect.TypeOf((*MockDbAuthServiceClient)(nil).FindEmailOtpUser), varargs...)
+}
+
+// LoginEmailOtp mocks base method.
+func (m *MockDbAuthServiceClient) LoginEmailOtp(ctx context.Context, in *dbauth.LoginEmailOtpRequest, opts ...grpc.CallOption) (*dbauth.LoginEmailOtpResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "LoginEmailOtp", varargs...)
+	ret0, _ := ret[0].(*dbauth.LoginEmailOtpResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginEmailOtp indicates an expected call of LoginEmailOtp.
+func (mr *MockDbAuthServiceClientMockRecorder) LoginEmailOtp(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginEmailOtp", reflect.TypeOf((*MockDbAuthServiceClient)(nil).LoginEmailOtp), varargs...)
+}
+
+// GetProvisioningStatus mocks base method.
+func (m *MockDbAuthServiceClient) GetProvisioningStatus(ctx context.Context, in *dbauth.GetProvisioningStatusRequest, opts ...grpc.CallOption) (*dbauth.GetProvisioningStatusResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetProvisioningStatus", varargs...)
+	ret0, _ := ret[0].(*dbauth.GetProvisioningStatusResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProvisioningStatus indicates an expected call of GetProvisioningStatus.
+func (mr *MockDbAuthServiceClientMockRecorder) GetProvisioningStatus(ctx, in interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProvisioningStatus", reflect.TypeOf((*MockDbAuthServiceClient)(nil).GetProvisioningStatus), varargs...)
+}
+
 // MockDbAuthServiceServer is a mock of DbAuthServiceServer interface.
 type MockDbAuthServiceServer struct {
 	ctrl     *gomock.Controller
@@ -129,6 +449,246 @@ func (mr *MockDbAuthServiceServerMockRecorder) RegisterCompany(arg0, arg1 interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterCompany", reflect.TypeOf((*MockDbAuthServiceServer)(nil).RegisterCompany), arg0, arg1)
 }
 
+// FindAuthUser mocks base method.
+func (m *MockDbAuthServiceServer) FindAuthUser(arg0 context.Context, arg1 *dbauth.FindAuthUserRequest) (*dbauth.FindAuthUserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAuthUser", arg0, arg1)
+	ret0, _ := ret[0].(*dbauth.FindAuthUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAuthUser indicates an expected call of FindAuthUser.
+func (mr *MockDbAuthServiceServerMockRecorder) FindAuthUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAuthUser", reflect.TypeOf((*MockDbAuthServiceServer)(nil).FindAuthUser), arg0, arg1)
+}
+
+// ResetPassword mocks base method.
+func (m *MockDbAuthServiceServer) ResetPassword(arg0 context.Context, arg1 *dbauth.ResetPasswordRequest) (*dbauth.ResetPasswordResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", arg0, arg1)
+	ret0, _ := ret[0].(*dbauth.ResetPasswordResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockDbAuthServiceServerMockRecorder) ResetPassword(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockDbAuthServiceServer)(nil).ResetPassword), arg0, arg1)
+}
+
+// ActivateAccount mocks base method.
+func (m *MockDbAuthServiceServer) ActivateAccount(arg0 context.Context, arg1 *dbauth.ActivateAccountRequest) (*dbauth.ActivateAccountResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActivateAccount", arg0, arg1)
+	ret0, _ := ret[0].(*dbauth.ActivateAccountResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ActivateAccount indicates an expected call of ActivateAccount.
+func (mr *MockDbAuthServiceServerMockRecorder) ActivateAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivateAccount", reflect.TypeOf((*MockDbAuthServiceServer)(nil).ActivateAccount), arg0, arg1)
+}
+
+// BeginTotpEnrollment mocks base method.
+func (m *MockDbAuthServiceServer) BeginTotpEnrollment(arg0 context.Context, arg1 *dbauth.BeginTotpEnrollmentRequest) (*dbauth.BeginTotpEnrollmentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BeginTotpEnrollment", arg0, arg1)
+	ret0, _ := ret[0].(*dbauth.BeginTotpEnrollmentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BeginTotpEnrollment indicates an expected call of BeginTotpEnrollment.
+func (mr *MockDbAuthServiceServerMockRecorder) BeginTotpEnrollment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BeginTotpEnrollment", reflect.TypeOf((*MockDbAuthServiceServer)(nil).BeginTotpEnrollment), arg0, arg1)
+}
+
+// ConfirmTotpEnrollment mocks base method.
+func (m *MockDbAuthServiceServer) ConfirmTotpEnrollment(arg0 context.Context, arg1 *dbauth.MfaCodeRequest) (*dbauth.ConfirmTotpEnrollmentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmTotpEnrollment", arg0, arg1)
+	ret0, _ := ret[0].(*dbauth.ConfirmTotpEnrollmentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConfirmTotpEnrollment indicates an expected call of ConfirmTotpEnrollment.
+func (mr *MockDbAuthServiceServerMockRecorder) ConfirmTotpEnrollment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmTotpEnrollment", reflect.TypeOf((*MockDbAuthServiceServer)(nil).ConfirmTotpEnrollment), arg0, arg1)
+}
+
+// VerifyMfa mocks base method.
+func (m *MockDbAuthServiceServer) VerifyMfa(arg0 context.Context, arg1 *dbauth.MfaCodeRequest) (*dbauth.VerifyMfaResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyMfa", arg0, arg1)
+	ret0, _ := ret[0].(*dbauth.VerifyMfaResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyMfa indicates an expected call of VerifyMfa.
+func (mr *MockDbAuthServiceServerMockRecorder) VerifyMfa(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyMfa", reflect.TypeOf((*MockDbAuthServiceServer)(nil).VerifyMfa), arg0, arg1)
+}
+
+// DisableTotp mocks base method.
+func (m *MockDbAuthServiceServer) DisableTotp(arg0 context.Context, arg1 *dbauth.MfaCodeRequest) (*dbauth.DisableTotpResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DisableTotp", arg0, arg1)
+	ret0, _ := ret[0].(*dbauth.DisableTotpResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DisableTotp indicates an expected call of DisableTotp.
+func (mr *MockDbAuthServiceServerMockRecorder) DisableTotp(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DisableTotp", reflect.TypeOf((*MockDbAuthServiceServer)(nil).DisableTotp), arg0, arg1)
+}
+
+// GetOidcProvider mocks base method.
+func (m *MockDbAuthServiceServer) GetOidcProvider(arg0 context.Context, arg1 *dbauth.GetOidcProviderRequest) (*dbauth.GetOidcProviderResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOidcProvider", arg0, arg1)
+	ret0, _ := ret[0].(*dbauth.GetOidcProviderResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOidcProvider indicates an expected call of GetOidcProvider.
+func (mr *MockDbAuthServiceServerMockRecorder) GetOidcProvider(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOidcProvider", reflect.TypeOf((*MockDbAuthServiceServer)(nil).GetOidcProvider), arg0, arg1)
+}
+
+// LoginOidc mocks base method.
+func (m *MockDbAuthServiceServer) LoginOidc(arg0 context.Context, arg1 *dbauth.LoginOidcRequest) (*dbauth.LoginOidcResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginOidc", arg0, arg1)
+	ret0, _ := ret[0].(*dbauth.LoginOidcResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginOidc indicates an expected call of LoginOidc.
+func (mr *MockDbAuthServiceServerMockRecorder) LoginOidc(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginOidc", reflect.TypeOf((*MockDbAuthServiceServer)(nil).LoginOidc), arg0, arg1)
+}
+
+// CreateApiKey mocks base method.
+func (m *MockDbAuthServiceServer) CreateApiKey(arg0 context.Context, arg1 *dbauth.CreateApiKeyRequest) (*dbauth.CreateApiKeyResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateApiKey", arg0, arg1)
+	ret0, _ := ret[0].(*dbauth.CreateApiKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateApiKey indicates an expected call of CreateApiKey.
+func (mr *MockDbAuthServiceServerMockRecorder) CreateApiKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateApiKey", reflect.TypeOf((*MockDbAuthServiceServer)(nil).CreateApiKey), arg0, arg1)
+}
+
+// ListApiKeys mocks base method.
+func (m *MockDbAuthServiceServer) ListApiKeys(arg0 context.Context, arg1 *dbauth.ListApiKeysRequest) (*dbauth.ListApiKeysResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListApiKeys", arg0, arg1)
+	ret0, _ := ret[0].(*dbauth.ListApiKeysResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListApiKeys indicates an expected call of ListApiKeys.
+func (mr *MockDbAuthServiceServerMockRecorder) ListApiKeys(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListApiKeys", reflect.TypeOf((*MockDbAuthServiceServer)(nil).ListApiKeys), arg0, arg1)
+}
+
+// RevokeApiKey mocks base method.
+func (m *MockDbAuthServiceServer) RevokeApiKey(arg0 context.Context, arg1 *dbauth.RevokeApiKeyRequest) (*dbauth.RevokeApiKeyResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeApiKey", arg0, arg1)
+	ret0, _ := ret[0].(*dbauth.RevokeApiKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeApiKey indicates an expected call of RevokeApiKey.
+func (mr *MockDbAuthServiceServerMockRecorder) RevokeApiKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeApiKey", reflect.TypeOf((*MockDbAuthServiceServer)(nil).RevokeApiKey), arg0, arg1)
+}
+
+// VerifyApiKey mocks base method.
+func (m *MockDbAuthServiceServer) VerifyApiKey(arg0 context.Context, arg1 *dbauth.VerifyApiKeyRequest) (*dbauth.VerifyApiKeyResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyApiKey", arg0, arg1)
+	ret0, _ := ret[0].(*dbauth.VerifyApiKeyResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// VerifyApiKey indicates an expected call of VerifyApiKey.
+func (mr *MockDbAuthServiceServerMockRecorder) VerifyApiKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyApiKey", reflect.TypeOf((*MockDbAuthServiceServer)(nil).VerifyApiKey), arg0, arg1)
+}
+
+// FindEmailOtpUser mocks base method.
+func (m *MockDbAuthServiceServer) FindEmailOtpUser(arg0 context.Context, arg1 *dbauth.FindEmailOtpUserRequest) (*dbauth.FindEmailOtpUserResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindEmailOtpUser", arg0, arg1)
+	ret0, _ := ret[0].(*dbauth.FindEmailOtpUserResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindEmailOtpUser indicates an expected call of FindEmailOtpUser.
+func (mr *MockDbAuthServiceServerMockRecorder) FindEmailOtpUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindEmailOtpUser", reflect.TypeOf((*MockDbAuthServiceServer)(nil).FindEmailOtpUser), arg0, arg1)
+}
+
+// LoginEmailOtp mocks base method.
+func (m *MockDbAuthServiceServer) LoginEmailOtp(arg0 context.Context, arg1 *dbauth.LoginEmailOtpRequest) (*dbauth.LoginEmailOtpResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LoginEmailOtp", arg0, arg1)
+	ret0, _ := ret[0].(*dbauth.LoginEmailOtpResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LoginEmailOtp indicates an expected call of LoginEmailOtp.
+func (mr *MockDbAuthServiceServerMockRecorder) LoginEmailOtp(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoginEmailOtp", reflect.TypeOf((*MockDbAuthServiceServer)(nil).LoginEmailOtp), arg0, arg1)
+}
+
+// GetProvisioningStatus mocks base method.
+func (m *MockDbAuthServiceServer) GetProvisioningStatus(arg0 context.Context, arg1 *dbauth.GetProvisioningStatusRequest) (*dbauth.GetProvisioningStatusResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProvisioningStatus", arg0, arg1)
+	ret0, _ := ret[0].(*dbauth.GetProvisioningStatusResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProvisioningStatus indicates an expected call of GetProvisioningStatus.
+func (mr *MockDbAuthServiceServerMockRecorder) GetProvisioningStatus(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProvisioningStatus", reflect.TypeOf((*MockDbAuthServiceServer)(nil).GetProvisioningStatus), arg0, arg1)
+}
+
 // mustEmbedUnimplementedDbAuthServiceServer mocks base method.
 func (m *MockDbAuthServiceServer) mustEmbedUnimplementedDbAuthServiceServer() {
 	m.ctrl.T.Helper()
@@ -0,0 +1,293 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	"crmSystem/tests/mocks"
+	"crmSystem/utils"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const oidcRedirectURL = "https://crm.example/auth/oidc/callback"
+
+// stubIdp is a minimal OpenID Connect provider: discovery, JWKS and a token endpoint with PKCE.
+type stubIdp struct {
+	t      *testing.T
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	// Authorization requests by issued code
+	challenges map[string]string
+	nonces     map[string]string
+
+	// Overrides of ID token claims
+	audience string
+	nonce    string
+	subject  string
+}
+
+func newStubIdp(t *testing.T) *stubIdp {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	idp := &stubIdp{t: t, key: key, challenges: map[string]string{}, nonces: map[string]string{}, subject: "idp-user-1"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA", "kid": "key-1", "use": "sig", "alg": "RS256",
+				"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+// authorize simulates the user signing in at the provider and returns the issued code.
+func (idp *stubIdp) authorize(authorizationUrl string) (code string, state string) {
+	parsed, err := url.Parse(authorizationUrl)
+	require.NoError(idp.t, err)
+	assert.Equal(idp.t, idp.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+
+	query := parsed.Query()
+	assert.Equal(idp.t, "code", query.Get("response_type"))
+	assert.Equal(idp.t, "crm-client", query.Get("client_id"))
+	assert.Equal(idp.t, oidcRedirectURL, query.Get("redirect_uri"))
+	assert.Equal(idp.t, "S256", query.Get("code_challenge_method"))
+
+	code = "code-" + query.Get("state")[:8]
+	idp.challenges[code] = query.Get("code_challenge")
+	idp.nonces[code] = query.Get("nonce")
+	return code, query.Get("state")
+}
+
+func (idp *stubIdp) token(w http.ResponseWriter, r *http.Request) {
+	clientId, secret, ok := r.BasicAuth()
+	if !ok || clientId != "crm-client" || secret != "crm-secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	code := r.PostFormValue("code")
+	challenge, ok := idp.challenges[code]
+	if !ok || utils.PkceChallenge(r.PostFormValue("code_verifier")) != challenge ||
+		r.PostFormValue("redirect_uri") != oidcRedirectURL {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+	delete(idp.challenges, code)
+
+	audience, nonce := "crm-client", idp.nonces[code]
+	if idp.audience != "" {
+		audience = idp.audience
+	}
+	if idp.nonce != "" {
+		nonce = idp.nonce
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"sub":            idp.subject,
+		"aud":            audience,
+		"exp":            time.Now().Add(5 * time.Minute).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email":          "user@corp.example",
+		"email_verified": true,
+	})
+	token.Header["kid"] = "key-1"
+	idToken, err := token.SignedString(idp.key)
+	require.NoError(idp.t, err)
+
+	_ = json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "token_type": "Bearer", "id_token": idToken})
+}
+
+// expectProvider sets up dbservice to return the stub provider for corp.example.
+func (idp *stubIdp) expectProvider(mockDb *mocks.MockDbAuthServiceClient, times int) {
+	mockDb.EXPECT().GetOidcProvider(gomock.Any(), &dbauth.GetOidcProviderRequest{Domain: "corp.example"}).
+		Return(&dbauth.GetOidcProviderResponse{
+			CompanyId: "3", Issuer: idp.server.URL, ClientId: "crm-client", ClientSecret: "crm-secret",
+		}, nil).Times(times)
+}
+
+// TestOidcLogin checks the authorization code flow with PKCE against a stub provider.
+func TestOidcLogin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	idp := newStubIdp(t)
+	mockDb := mocks.NewMockDbAuthServiceClient(ctrl)
+	redis := mocks.NewFakeRedisServiceClient()
+	client := utils.NewOidcClient(redis, idp.server.Client())
+
+	idp.expectProvider(mockDb, 2)
+
+	authorizationUrl, startedState, err := client.Start(ctx, mockDb, "user@Corp.example", oidcRedirectURL)
+	require.NoError(t, err)
+	code, state := idp.authorize(authorizationUrl)
+	assert.Equal(t, startedState, state)
+
+	// The code verifier is kept server side, only its hash is sent to the provider
+	require.Len(t, redis.Values, 1)
+	for _, value := range redis.Values {
+		var saved map[string]string
+		require.NoError(t, json.Unmarshal([]byte(value), &saved))
+		require.NotEmpty(t, saved["verifier"])
+		assert.NotContains(t, authorizationUrl, saved["verifier"])
+		assert.Contains(t, authorizationUrl, utils.PkceChallenge(saved["verifier"]))
+	}
+
+	identity, err := client.Finish(ctx, mockDb, state, code, oidcRedirectURL)
+	require.NoError(t, err)
+	assert.Equal(t, &utils.OidcIdentity{
+		CompanyId: "3", Subject: "idp-user-1", Email: "user@corp.example", EmailVerified: true,
+	}, identity)
+
+	// The state is single-use
+	_, err = client.Finish(ctx, mockDb, state, code, oidcRedirectURL)
+	assert.ErrorIs(t, err, utils.ErrOidcStateInvalid)
+	assert.Equal(t, uint32(http.StatusBadRequest), utils.OidcErrorStatus(err))
+}
+
+// TestOidcLoginRejectsInvalidIdToken checks ID tokens for another client or with a foreign nonce are rejected.
+func TestOidcLoginRejectsInvalidIdToken(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(idp *stubIdp)
+	}{
+		{name: "Another audience", setup: func(idp *stubIdp) { idp.audience = "other-client" }},
+		{name: "Foreign nonce", setup: func(idp *stubIdp) { idp.nonce = "replayed-nonce" }},
+		{name: "Missing subject", setup: func(idp *stubIdp) { idp.subject = "" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			idp := newStubIdp(t)
+			tt.setup(idp)
+			mockDb := mocks.NewMockDbAuthServiceClient(ctrl)
+			client := utils.NewOidcClient(mocks.NewFakeRedisServiceClient(), idp.server.Client())
+			idp.expectProvider(mockDb, 2)
+
+			authorizationUrl, _, err := client.Start(ctx, mockDb, "user@corp.example", oidcRedirectURL)
+			require.NoError(t, err)
+			code, state := idp.authorize(authorizationUrl)
+
+			_, err = client.Finish(ctx, mockDb, state, code, oidcRedirectURL)
+			assert.ErrorIs(t, err, utils.ErrOidcProvider)
+			assert.Equal(t, uint32(http.StatusBadGateway), utils.OidcErrorStatus(err))
+		})
+	}
+}
+
+// TestOidcLoginRejectsWrongCode checks a code issued for another PKCE challenge is rejected by the provider.
+func TestOidcLoginRejectsWrongCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	idp := newStubIdp(t)
+	mockDb := mocks.NewMockDbAuthServiceClient(ctrl)
+	client := utils.NewOidcClient(mocks.NewFakeRedisServiceClient(), idp.server.Client())
+	idp.expectProvider(mockDb, 3)
+
+	first, _, err := client.Start(ctx, mockDb, "user@corp.example", oidcRedirectURL)
+	require.NoError(t, err)
+	second, _, err := client.Start(ctx, mockDb, "user@corp.example", oidcRedirectURL)
+	require.NoError(t, err)
+
+	// The code of the first login is presented with the state of the second one
+	code, _ := idp.authorize(first)
+	_, state := idp.authorize(second)
+
+	_, err = client.Finish(ctx, mockDb, state, code, oidcRedirectURL)
+	assert.ErrorIs(t, err, utils.ErrOidcProvider)
+}
+
+// TestOidcStartUnknownDomain checks a domain without a configured provider is reported as not found.
+func TestOidcStartUnknownDomain(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDb := mocks.NewMockDbAuthServiceClient(ctrl)
+	mockDb.EXPECT().GetOidcProvider(gomock.Any(), &dbauth.GetOidcProviderRequest{Domain: "gmail.com"}).
+		Return(nil, status.Error(codes.NotFound, "вход через корпоративного провайдера не настроен для этого домена"))
+
+	client := utils.NewOidcClient(mocks.NewFakeRedisServiceClient(), http.DefaultClient)
+	_, _, err := client.Start(context.Background(), mockDb, "user@gmail.com", oidcRedirectURL)
+	assert.Equal(t, uint32(http.StatusNotFound), utils.OidcErrorStatus(err))
+
+	_, _, err = client.Start(context.Background(), mockDb, "not-an-email", oidcRedirectURL)
+	assert.Equal(t, uint32(http.StatusBadRequest), utils.OidcErrorStatus(err))
+}
+
+// TestCheckOidcStateCookie checks the callback is accepted only in the browser that started the login.
+func TestCheckOidcStateCookie(t *testing.T) {
+	callback := func(cookie string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?state=state-1&code=code-1", nil)
+		if cookie != "" {
+			r.AddCookie(&http.Cookie{Name: utils.OidcStateCookie, Value: cookie})
+		}
+		return r
+	}
+
+	assert.NoError(t, utils.CheckOidcStateCookie(callback(utils.OidcStateBinding("state-1")), "state-1"))
+
+	// The cookie keeps only a hash of the state
+	assert.NotEqual(t, "state-1", utils.OidcStateBinding("state-1"))
+
+	// No cookie: the link with the attacker's code was opened in the victim's browser
+	assert.ErrorIs(t, utils.CheckOidcStateCookie(callback(""), "state-1"), utils.ErrOidcStateInvalid)
+	// The browser started another login
+	assert.ErrorIs(t, utils.CheckOidcStateCookie(callback(utils.OidcStateBinding("state-2")), "state-1"), utils.ErrOidcStateInvalid)
+	assert.ErrorIs(t, utils.CheckOidcStateCookie(callback(utils.OidcStateBinding("")), ""), utils.ErrOidcStateInvalid)
+}
+
+// TestOidcLoginRedirect checks the frontend learns about a pending second factor from the redirect address.
+func TestOidcLoginRedirect(t *testing.T) {
+	location, err := utils.OidcLoginRedirect("https://crm.example/app?tab=home", "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://crm.example/app?tab=home", location)
+
+	location, err = utils.OidcLoginRedirect("https://crm.example/app?tab=home", "enroll")
+	require.NoError(t, err)
+	parsed, err := url.Parse(location)
+	require.NoError(t, err)
+	assert.Equal(t, "/app", parsed.Path)
+	assert.Equal(t, "enroll", parsed.Query().Get("mfa"))
+	assert.Equal(t, "home", parsed.Query().Get("tab"))
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	email "crmSystem/proto/email-service"
+	"crmSystem/tests/mocks"
+	"crmSystem/utils"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestSendPasswordResetLink checks the token is stored hashed and the link is mailed only to existing users.
+func TestSendPasswordResetLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockDb := mocks.NewMockDbAuthServiceClient(ctrl)
+	mockEmail := mocks.NewMockEmailServiceClient(ctrl)
+	redis := mocks.NewFakeRedisServiceClient()
+	store := utils.NewPasswordResetStore(redis)
+
+	var sentToken string
+	mockDb.EXPECT().FindAuthUser(gomock.Any(), &dbauth.FindAuthUserRequest{Email: "user@example.com"}).
+		Return(&dbauth.FindAuthUserResponse{UserId: "42"}, nil)
+	mockEmail.EXPECT().SendEmail(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, req *email.SendEmailRequest, _ ...interface{}) (*email.SendEmailResponse, error) {
+			assert.Equal(t, "user@example.com", req.Email)
+			start := strings.Index(req.Body, "?token=")
+			require.NotEqual(t, -1, start)
+			token := strings.Fields(req.Body[start+len("?token="):])[0]
+			sentToken, _ = url.QueryUnescape(token)
+			return &email.SendEmailResponse{}, nil
+		})
+
+	err := utils.SendPasswordResetLink(ctx, mockDb, store, mockEmail, "user@example.com", "https://crm/reset")
+	require.NoError(t, err)
+	require.NotEmpty(t, sentToken)
+
+	// The raw token must not be stored in redis
+	for key, value := range redis.Values {
+		assert.NotContains(t, key, sentToken)
+		assert.Equal(t, "42", value)
+		assert.Equal(t, int64(utils.PasswordResetTTL.Seconds()), redis.Expirations[key])
+	}
+
+	// Unknown email: no error and no email
+	mockDb.EXPECT().FindAuthUser(gomock.Any(), &dbauth.FindAuthUserRequest{Email: "nobody@example.com"}).
+		Return(nil, status.Error(codes.NotFound, "пользователь не найден"))
+
+	err = utils.SendPasswordResetLink(ctx, mockDb, store, mockEmail, "nobody@example.com", "https://crm/reset")
+	assert.NoError(t, err)
+}
+
+// TestResetPasswordWithToken checks the reset token is single-use.
+func TestResetPasswordWithToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.Background()
+	mockDb := mocks.NewMockDbAuthServiceClient(ctrl)
+	store := utils.NewPasswordResetStore(mocks.NewFakeRedisServiceClient())
+
+	token, err := store.Issue(ctx, "42")
+	require.NoError(t, err)
+
+	mockDb.EXPECT().ResetPassword(gomock.Any(), &dbauth.ResetPasswordRequest{UserId: "42", Password: "NewPassword1!"}).
+		Return(&dbauth.ResetPasswordResponse{}, nil).Times(1)
+
+	assert.NoError(t, utils.ResetPasswordWithToken(ctx, store, mockDb, token, "NewPassword1!"))
+
+	// Second use of the same token
+	assert.ErrorIs(t, utils.ResetPasswordWithToken(ctx, store, mockDb, token, "Another1!"), utils.ErrResetTokenInvalid)
+
+	// Unknown token
+	assert.ErrorIs(t, utils.ResetPasswordWithToken(ctx, store, mockDb, "forged", "Another1!"), utils.ErrResetTokenInvalid)
+}
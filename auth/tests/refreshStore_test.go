@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/tests/mocks"
+	"crmSystem/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testUser = utils.UserClaims{Database: "company_a", UserId: "7", CompanyId: "1", Role: "admin"}
+
+var testClient = utils.SessionClient{
+	Ip:        "203.0.113.7",
+	UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/129.0 Safari/537.36",
+}
+
+// startTestSession creates a session and returns the user bound to it with the first token id.
+func startTestSession(t *testing.T, store *utils.RefreshStore) (utils.UserClaims, string) {
+	t.Helper()
+	sessionId, tokenId, err := store.StartSession(context.Background(), testUser, testClient)
+	require.NoError(t, err)
+	user := testUser
+	user.SessionId = sessionId
+	return user, tokenId
+}
+
+// TestRefreshStoreRotate checks that every refresh token can be used exactly once.
+func TestRefreshStoreRotate(t *testing.T) {
+	ctx := context.Background()
+	redis := mocks.NewFakeRedisServiceClient()
+	store := utils.NewRefreshStore(redis)
+
+	user, first := startTestSession(t, store)
+	assert.Equal(t, int64(utils.RefreshTokenTTL.Seconds()), redis.Expirations["refreshToken:"+first])
+
+	second, err := store.Rotate(ctx, user, first)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+
+	third, err := store.Rotate(ctx, user, second)
+	require.NoError(t, err)
+	assert.NotEqual(t, second, third)
+}
+
+// TestRefreshStoreReuseRevokesSession checks that presenting an old token revokes the whole family.
+func TestRefreshStoreReuseRevokesSession(t *testing.T) {
+	ctx := context.Background()
+	store := utils.NewRefreshStore(mocks.NewFakeRedisServiceClient())
+
+	user, first := startTestSession(t, store)
+	otherUser, otherToken := startTestSession(t, store)
+
+	second, err := store.Rotate(ctx, user, first)
+	require.NoError(t, err)
+
+	// Stolen first token is presented again
+	_, err = store.Rotate(ctx, user, first)
+	assert.ErrorIs(t, err, utils.ErrRefreshTokenReused)
+
+	// The legitimate latest token is revoked together with the family
+	_, err = store.Rotate(ctx, user, second)
+	assert.ErrorIs(t, err, utils.ErrRefreshTokenReused)
+
+	// Other sessions of the same user are untouched
+	_, err = store.Rotate(ctx, otherUser, otherToken)
+	assert.NoError(t, err)
+}
+
+// TestRefreshStoreTokenOfAnotherSession checks that a token cannot be used with a foreign session id.
+func TestRefreshStoreTokenOfAnotherSession(t *testing.T) {
+	store := utils.NewRefreshStore(mocks.NewFakeRedisServiceClient())
+
+	user, _ := startTestSession(t, store)
+	_, otherToken := startTestSession(t, store)
+
+	_, err := store.Rotate(context.Background(), user, otherToken)
+	assert.ErrorIs(t, err, utils.ErrRefreshTokenReused)
+}
+
+// TestRefreshStoreLogout checks revocation of a single session and of all sessions.
+func TestRefreshStoreLogout(t *testing.T) {
+	ctx := context.Background()
+	redis := mocks.NewFakeRedisServiceClient()
+	store := utils.NewRefreshStore(redis)
+
+	laptop, laptopToken := startTestSession(t, store)
+	phone, phoneToken := startTestSession(t, store)
+	tablet, tabletToken := startTestSession(t, store)
+
+	require.NoError(t, store.RevokeSession(ctx, laptop, laptop.SessionId))
+
+	_, err := store.Rotate(ctx, laptop, laptopToken)
+	assert.Error(t, err)
+	_, err = store.Rotate(ctx, phone, phoneToken)
+	require.NoError(t, err)
+
+	revoked, err := store.RevokeAllSessions(ctx, tablet)
+	require.NoError(t, err)
+	assert.Equal(t, 2, revoked)
+
+	_, err = store.Rotate(ctx, tablet, tabletToken)
+	assert.Error(t, err)
+	assert.Empty(t, redis.Values)
+	assert.Empty(t, redis.Sets)
+}
+
+// TestRefreshStoreLogoutAfterRotation checks that logout from all devices reaches a session
+// that has been kept alive by rotation past the TTL of the login.
+func TestRefreshStoreLogoutAfterRotation(t *testing.T) {
+	ctx := context.Background()
+	redis := mocks.NewFakeRedisServiceClient()
+	store := utils.NewRefreshStore(redis)
+
+	user, token := startTestSession(t, store)
+
+	redis.Advance(utils.RefreshTokenTTL - time.Hour)
+	token, err := store.Rotate(ctx, user, token)
+	require.NoError(t, err)
+	redis.Advance(2 * time.Hour)
+
+	revoked, err := store.RevokeAllSessions(ctx, user)
+	require.NoError(t, err)
+	assert.Equal(t, 1, revoked)
+
+	_, err = store.Rotate(ctx, user, token)
+	assert.Error(t, err)
+}
+
+// TestRefreshStoreListSessions checks listing of active sessions with client data and removal of expired ones.
+func TestRefreshStoreListSessions(t *testing.T) {
+	ctx := context.Background()
+	redis := mocks.NewFakeRedisServiceClient()
+	store := utils.NewRefreshStore(redis)
+
+	laptop, _ := startTestSession(t, store)
+	phone, _ := startTestSession(t, store)
+
+	sessions, err := store.ListSessions(ctx, testUser)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "203.0.113.7", sessions[0].Ip)
+	assert.Equal(t, "Chrome, Windows", sessions[0].Device)
+
+	// The phone session expires in redis while still listed in the user's set
+	delete(redis.Values, "refreshSession:"+phone.SessionId)
+
+	sessions, err = store.ListSessions(ctx, testUser)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, laptop.SessionId, sessions[0].Id)
+	assert.NotContains(t, redis.Sets["refreshSessions:company_a:7"], phone.SessionId)
+}
+
+// TestRefreshStoreRevokeUserSession checks a user can revoke only their own sessions.
+func TestRefreshStoreRevokeUserSession(t *testing.T) {
+	ctx := context.Background()
+	store := utils.NewRefreshStore(mocks.NewFakeRedisServiceClient())
+
+	laptop, laptopToken := startTestSession(t, store)
+	other := utils.UserClaims{Database: "company_b", UserId: "7", CompanyId: "2"}
+
+	assert.ErrorIs(t, store.RevokeUserSession(ctx, other, laptop.SessionId), utils.ErrSessionNotFound)
+	assert.ErrorIs(t, store.RevokeUserSession(ctx, testUser, "missing"), utils.ErrSessionNotFound)
+
+	require.NoError(t, store.RevokeUserSession(ctx, testUser, laptop.SessionId))
+	_, err := store.Rotate(ctx, laptop, laptopToken)
+	assert.Error(t, err)
+}
+
+// TestDeviceFromUserAgent checks the short device description built from User-Agent.
+func TestDeviceFromUserAgent(t *testing.T) {
+	assert.Equal(t, "Safari, iOS", utils.DeviceFromUserAgent(
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1"))
+	assert.Equal(t, "Firefox, Linux", utils.DeviceFromUserAgent(
+		"Mozilla/5.0 (X11; Linux x86_64; rv:131.0) Gecko/20100101 Firefox/131.0"))
+	assert.Equal(t, "curl", utils.DeviceFromUserAgent("curl/8.5.0"))
+	assert.Equal(t, "Неизвестное устройство", utils.DeviceFromUserAgent(""))
+}
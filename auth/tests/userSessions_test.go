@@ -0,0 +1,138 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/grpc_service"
+	"crmSystem/jwks"
+	"crmSystem/proto/auth"
+	"crmSystem/tests/mocks"
+	"crmSystem/utils"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// signSessionsToken signs a token of the company_a user with the given role and type.
+func signSessionsToken(t *testing.T, key *rsa.PrivateKey, role string, tokenType string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"sub":  "1",
+		"db":   "company_a",
+		"cid":  "1",
+		"role": role,
+		"typ":  tokenType,
+		"exp":  time.Now().Add(time.Minute).Unix(),
+	})
+	token.Header["kid"] = jwks.Thumbprint(&key.PublicKey)
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+// withBearer returns an incoming gRPC context carrying the token in the authorization metadata.
+func withBearer(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+// TestUserSessionsRPC checks a company admin lists and revokes sessions of a user of the same company only.
+func TestUserSessionsRPC(t *testing.T) {
+	t.Setenv("FIRST_ROLE", "admin")
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keys := jwks.NewKeySet("", nil, &key.PublicKey)
+
+	store := utils.NewRefreshStore(mocks.NewFakeRedisServiceClient())
+	user, _ := startTestSession(t, store)
+	otherCompany := utils.UserClaims{Database: "company_b", UserId: user.UserId, CompanyId: "2"}
+	_, _, err = store.StartSession(context.Background(), otherCompany, testClient)
+	require.NoError(t, err)
+
+	service := grpc_service.NewGRPCServiceWith(grpc_service.Dependencies{
+		ParseToken: func(token string) (utils.UserClaims, error) {
+			return utils.ParseAccessTokenWith(keys, token)
+		},
+		DialSessions: func() (*utils.RefreshStore, func() error, error) {
+			return store, func() error { return nil }, nil
+		},
+	})
+	adminCtx := withBearer(signSessionsToken(t, key, "admin", "access"))
+
+	// Only the session of the admin's company is listed
+	listed, err := service.ListUserSessions(adminCtx, &auth.ListUserSessionsRequest{UserId: user.UserId})
+	require.NoError(t, err)
+	require.Len(t, listed.Sessions, 1)
+	assert.Equal(t, user.SessionId, listed.Sessions[0].Id)
+	assert.Equal(t, testClient.Ip, listed.Sessions[0].Ip)
+
+	// Tokens of regular users, refresh tokens and missing tokens are rejected
+	_, err = service.ListUserSessions(withBearer(signSessionsToken(t, key, "user", "access")),
+		&auth.ListUserSessionsRequest{UserId: user.UserId})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = service.ListUserSessions(withBearer(signSessionsToken(t, key, "admin", "refresh")),
+		&auth.ListUserSessionsRequest{UserId: user.UserId})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = service.ListUserSessions(context.Background(), &auth.ListUserSessionsRequest{UserId: user.UserId})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = service.ListUserSessions(adminCtx, &auth.ListUserSessionsRequest{UserId: "7 OR 1"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// The session is revoked once, then it is no longer found
+	_, err = service.RevokeUserSession(adminCtx, &auth.RevokeUserSessionRequest{UserId: user.UserId, SessionId: user.SessionId})
+	require.NoError(t, err)
+	_, err = service.RevokeUserSession(adminCtx, &auth.RevokeUserSessionRequest{UserId: user.UserId, SessionId: user.SessionId})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	listed, err = service.ListUserSessions(adminCtx, &auth.ListUserSessionsRequest{UserId: user.UserId})
+	require.NoError(t, err)
+	assert.Empty(t, listed.Sessions)
+
+	// The session of the other company is untouched
+	sessions, err := store.ListSessions(context.Background(), otherCompany)
+	require.NoError(t, err)
+	assert.Len(t, sessions, 1)
+}
+
+// TestUserSessionsRPCAfterRotation checks a session kept alive by rotation past the TTL of the login
+// is still listed and can be revoked by the admin.
+func TestUserSessionsRPCAfterRotation(t *testing.T) {
+	t.Setenv("FIRST_ROLE", "admin")
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keys := jwks.NewKeySet("", nil, &key.PublicKey)
+
+	redis := mocks.NewFakeRedisServiceClient()
+	store := utils.NewRefreshStore(redis)
+	user, token := startTestSession(t, store)
+
+	redis.Advance(utils.RefreshTokenTTL - time.Hour)
+	_, err = store.Rotate(context.Background(), user, token)
+	require.NoError(t, err)
+	redis.Advance(2 * time.Hour)
+
+	service := grpc_service.NewGRPCServiceWith(grpc_service.Dependencies{
+		ParseToken: func(token string) (utils.UserClaims, error) {
+			return utils.ParseAccessTokenWith(keys, token)
+		},
+		DialSessions: func() (*utils.RefreshStore, func() error, error) {
+			return store, func() error { return nil }, nil
+		},
+	})
+	adminCtx := withBearer(signSessionsToken(t, key, "admin", "access"))
+
+	listed, err := service.ListUserSessions(adminCtx, &auth.ListUserSessionsRequest{UserId: user.UserId})
+	require.NoError(t, err)
+	require.Len(t, listed.Sessions, 1)
+	assert.Equal(t, user.SessionId, listed.Sessions[0].Id)
+
+	_, err = service.RevokeUserSession(adminCtx, &auth.RevokeUserSessionRequest{UserId: user.UserId, SessionId: user.SessionId})
+	require.NoError(t, err)
+}
@@ -0,0 +1,121 @@
+package transport_rest
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	"crmSystem/proto/logs"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Activate подтверждает email владельца компании или принимает приглашение пользователя
+// по одноразовому токену из письма. Приглашённый пользователь задаёт пароль в этом же запросе.
+func (h *Handler) Activate(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	var req types.ActivateAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка при декодировании данных", err)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.RegisterValidation("password", validatePassword); err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Ошибка регистрации кастомного валидатора", err)
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) && len(validationErrors) > 0 {
+			err = fmt.Errorf("поле '%s' не прошло валидацию", validationErrors[0].Field())
+		}
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка валидации", err)
+		return
+	}
+
+	token, err := utils.InternalJwtGenerator()
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось создать токен", err)
+		return
+	}
+
+	// Устанавливаем соединение с gRPC сервером Logs
+	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer closeConnection(conn)
+
+	dbClient, err, dbConn := utils.GRPCServiceConnector(token, dbauth.NewDbAuthServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer closeConnection(dbConn)
+
+	err = utils.ActivateAccount(ctx, dbClient, req.Token, req.Password)
+	if errors.Is(err, utils.ErrActivationTokenInvalid) || errors.Is(err, utils.ErrActivationPasswordRequired) {
+		utils.CreateError(w, http.StatusBadRequest, "Не удалось активировать учётную запись", err)
+		return
+	}
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось активировать учётную запись", err)
+		if errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error()); errLogs != nil {
+			log.Printf("Ошибка сохранения лога: %v", errLogs)
+		}
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, types.MessageResponse{Message: "Учётная запись активирована, теперь можно войти"}); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
+
+// RegisterStatus возвращает состояние создания рабочего пространства компании по provisioningId
+// из ответа регистрации. Клиент опрашивает его, пока состояние pending.
+func (h *Handler) RegisterStatus(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	provisioningId := r.URL.Query().Get("id")
+	if provisioningId == "" {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка валидации", fmt.Errorf("не указан идентификатор регистрации"))
+		return
+	}
+
+	token, err := utils.InternalJwtGenerator()
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось создать токен", err)
+		return
+	}
+
+	dbClient, err, dbConn := utils.GRPCServiceConnector(token, dbauth.NewDbAuthServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer closeConnection(dbConn)
+
+	state, message, err := utils.RegistrationStatus(ctx, dbClient, provisioningId)
+	if errors.Is(err, utils.ErrRegistrationNotFound) {
+		utils.CreateError(w, http.StatusNotFound, "Не удалось получить состояние регистрации", err)
+		return
+	}
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось получить состояние регистрации", err)
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, types.RegisterStatusResponse{Status: state, Message: message}); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
@@ -0,0 +1,263 @@
+package transport_rest
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log"
+	"net/http"
+	"time"
+)
+
+// CreateApiKey создаёт API ключ текущего пользователя. Ключ возвращается в ответе один раз,
+// в базе хранится только его хэш.
+func (h *Handler) CreateApiKey(w http.ResponseWriter, r *http.Request) {
+	user, ok := apiKeyOwner(w, r)
+	if !ok {
+		return
+	}
+
+	var req types.CreateApiKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка при декодировании данных", err)
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка валидации", fmt.Errorf("поля 'Name' и 'Scopes' обязательны"))
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка валидации", fmt.Errorf("срок действия ключа уже истёк"))
+		return
+	}
+
+	apiKeyCall(w, func(ctx context.Context, client dbauth.DbAuthServiceClient) (interface{}, error) {
+		return IssueApiKey(ctx, client, user, &req)
+	})
+}
+
+// ApiKeys возвращает API ключи текущего пользователя.
+func (h *Handler) ApiKeys(w http.ResponseWriter, r *http.Request) {
+	user, ok := apiKeyOwner(w, r)
+	if !ok {
+		return
+	}
+
+	apiKeyCall(w, func(ctx context.Context, client dbauth.DbAuthServiceClient) (interface{}, error) {
+		res, err := client.ListApiKeys(ctx, &dbauth.ListApiKeysRequest{UserId: user.AuthUserId})
+		if err != nil {
+			return nil, err
+		}
+
+		response := types.ApiKeysResponse{Keys: make([]types.ApiKeyResponse, 0, len(res.Keys))}
+		for _, key := range res.Keys {
+			response.Keys = append(response.Keys, apiKeyResponse(key))
+		}
+		return response, nil
+	})
+}
+
+// RevokeApiKey отзывает API ключ текущего пользователя по его ID.
+func (h *Handler) RevokeApiKey(w http.ResponseWriter, r *http.Request) {
+	user, ok := apiKeyOwner(w, r)
+	if !ok {
+		return
+	}
+	keyId := mux.Vars(r)["id"]
+
+	apiKeyCall(w, func(ctx context.Context, client dbauth.DbAuthServiceClient) (interface{}, error) {
+		res, err := client.RevokeApiKey(ctx, &dbauth.RevokeApiKeyRequest{UserId: user.AuthUserId, Id: keyId})
+		if err != nil {
+			return nil, err
+		}
+		return types.MessageResponse{Message: res.Message}, nil
+	})
+}
+
+// VerifyApiKey проверяет API ключ для NGINX (auth_request) вместо JWT cookie.
+//
+// Исходный запрос передаётся в заголовках X-Original-URI и X-Original-Method. Если области доступа ключа
+// разрешают запрос, в заголовке X-Access-Token возвращается короткоживущий access токен владельца ключа,
+// который NGINX передаёт сервису в cookie access_token. Эндпоинт доступен только внутри NGINX.
+func (h *Handler) VerifyApiKey(w http.ResponseWriter, r *http.Request) {
+	key, err := utils.ApiKeyFromRequest(r)
+	if err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	apiKeyCall(w, func(ctx context.Context, client dbauth.DbAuthServiceClient) (interface{}, error) {
+		user, err := AuthorizeApiKey(ctx, client, key, r.Header.Get("X-Original-Method"), r.Header.Get("X-Original-URI"))
+		if err != nil {
+			return nil, err
+		}
+
+		token, err := utils.ApiKeyTokenGenerator(user)
+		if err != nil {
+			return nil, err
+		}
+		w.Header().Set("X-Access-Token", token)
+		w.WriteHeader(http.StatusNoContent)
+		return nil, nil
+	})
+}
+
+// IssueApiKey создаёт API ключ пользователя user и сохраняет его хэш в dbservice.
+func IssueApiKey(ctx context.Context, client dbauth.DbAuthServiceClient, user utils.UserClaims,
+	req *types.CreateApiKeyRequest) (*types.CreateApiKeyResponse, error) {
+
+	scopes, err := utils.NormalizeApiKeyScopes(req.Scopes)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	key, prefix, hash, err := utils.GenerateApiKey()
+	if err != nil {
+		return nil, err
+	}
+
+	var expiresAt int64
+	if req.ExpiresAt != nil {
+		expiresAt = req.ExpiresAt.Unix()
+	}
+
+	res, err := client.CreateApiKey(ctx, &dbauth.CreateApiKeyRequest{
+		UserId:    user.AuthUserId,
+		Name:      req.Name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.CreateApiKeyResponse{
+		ApiKeyResponse: apiKeyResponse(&dbauth.AuthApiKey{
+			Id:        res.Id,
+			Name:      req.Name,
+			Prefix:    prefix,
+			Scopes:    scopes,
+			ExpiresAt: expiresAt,
+			CreatedAt: time.Now().Unix(),
+		}),
+		Key: key,
+	}, nil
+}
+
+// AuthorizeApiKey проверяет ключ key и его доступ к запросу method uri
+// и возвращает данные владельца ключа с областями доступа ключа.
+func AuthorizeApiKey(ctx context.Context, client dbauth.DbAuthServiceClient, key string, method string,
+	uri string) (utils.UserClaims, error) {
+
+	required, err := utils.RequiredApiKeyScope(method, uri)
+	if err != nil {
+		return utils.UserClaims{}, status.Errorf(codes.PermissionDenied, "%v", err)
+	}
+
+	header := metadata.MD{}
+	res, err := client.VerifyApiKey(ctx, &dbauth.VerifyApiKeyRequest{KeyHash: utils.HashApiKey(key)}, grpc.Header(&header))
+	if err != nil {
+		return utils.UserClaims{}, err
+	}
+
+	if !utils.ApiKeyAllows(res.Scopes, required) {
+		return utils.UserClaims{}, status.Errorf(codes.PermissionDenied, "%v: требуется %s", utils.ErrApiKeyScope, required)
+	}
+
+	user, err := userClaimsFromHeader(header)
+	if err != nil {
+		return utils.UserClaims{}, err
+	}
+	user.SessionId = "apikey:" + res.Id
+	user.Scopes = res.Scopes
+	return user, nil
+}
+
+// apiKeyOwner возвращает пользователя сессии, управляющего своими API ключами.
+// Токен, выпущенный по API ключу, не позволяет создавать и отзывать ключи.
+func apiKeyOwner(w http.ResponseWriter, r *http.Request) (utils.UserClaims, bool) {
+	user, err := utils.ParseAccessToken(r)
+	if err != nil {
+		utils.CreateError(w, http.StatusUnauthorized, "Пользователь не авторизован", err)
+		return utils.UserClaims{}, false
+	}
+	if len(user.Scopes) != 0 || user.AuthUserId == "" {
+		utils.CreateError(w, http.StatusForbidden, "Недостаточно прав",
+			errors.New("управлять API ключами можно только после входа в систему"))
+		return utils.UserClaims{}, false
+	}
+	return user, true
+}
+
+// apiKeyResponse переводит ключ из ответа dbservice в ответ клиенту.
+func apiKeyResponse(key *dbauth.AuthApiKey) types.ApiKeyResponse {
+	response := types.ApiKeyResponse{
+		Id:        key.Id,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    key.Scopes,
+		CreatedAt: time.Unix(key.CreatedAt, 0).UTC(),
+	}
+	if key.ExpiresAt != 0 {
+		expiresAt := time.Unix(key.ExpiresAt, 0).UTC()
+		response.ExpiresAt = &expiresAt
+	}
+	if key.LastUsedAt != 0 {
+		lastUsedAt := time.Unix(key.LastUsedAt, 0).UTC()
+		response.LastUsedAt = &lastUsedAt
+	}
+	return response
+}
+
+// apiKeyCall подключается к dbservice, выполняет call и записывает результат или ошибку в ответ.
+// Если call вернул nil без ошибки, ответ уже записан.
+func apiKeyCall(w http.ResponseWriter, call func(ctx context.Context, client dbauth.DbAuthServiceClient) (interface{}, error)) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	token, err := utils.InternalJwtGenerator()
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось создать токен", err)
+		return
+	}
+
+	client, err, conn := utils.GRPCServiceConnector(token, dbauth.NewDbAuthServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer closeConnection(conn)
+
+	response, err := call(ctx, client)
+	if err != nil {
+		httpStatus := utils.ApiKeyErrorStatus(err)
+		if httpStatus == http.StatusInternalServerError {
+			log.Printf("Ошибка работы с API ключами: %v", err)
+		}
+		utils.CreateError(w, httpStatus, "Ошибка API ключа", errors.New(status.Convert(err).Message()))
+		return
+	}
+	if response == nil {
+		return
+	}
+
+	httpStatus := uint32(http.StatusOK)
+	if _, created := response.(*types.CreateApiKeyResponse); created {
+		httpStatus = http.StatusCreated
+	}
+	if err := utils.WriteJSON(w, httpStatus, response); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package transport_rest
+
+import (
+	"context"
+	"crmSystem/proto/redis"
+	"crmSystem/utils"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"log"
+	"net/http"
+)
+
+// legacyIdentityCookies cookie, в которых раньше передавались данные пользователя.
+// Теперь эти данные находятся только в подписанном токене, старые cookie удаляются у клиента.
+var legacyIdentityCookies = []string{"database", "user-id", "company-id"}
+
+// connectRefreshStore подключается к redis сервису, в котором хранятся сессии пользователей.
+// Соединение необходимо закрыть после использования.
+func connectRefreshStore(token string) (*utils.RefreshStore, *grpc.ClientConn, error) {
+	client, err, conn := utils.GRPCServiceConnector(token, redis.NewRedisServiceClient)
+	if err != nil {
+		return nil, nil, fmt.Errorf("не удалось подключиться к серверу redis: %v", err)
+	}
+	return utils.NewRefreshStore(client), conn, nil
+}
+
+// startSession создаёт новую сессию пользователя и устанавливает токены в cookie.
+// client - данные клиента, с которого выполнен вход, они сохраняются в сессии.
+func startSession(ctx context.Context, w http.ResponseWriter, token string, user utils.UserClaims,
+	client utils.SessionClient) error {
+	store, conn, err := connectRefreshStore(token)
+	if err != nil {
+		return err
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}(conn)
+
+	sessionId, tokenId, err := store.StartSession(ctx, user, client)
+	if err != nil {
+		return err
+	}
+
+	user.SessionId = sessionId
+	return setAuthCookies(w, user, tokenId)
+}
+
+// setAuthCookies формирует access и refresh токены с данными пользователя и устанавливает их в cookie.
+// refreshTokenId - ID refresh токена, зарегистрированного в сессии пользователя.
+func setAuthCookies(w http.ResponseWriter, user utils.UserClaims, refreshTokenId string) error {
+	accessToken, err := utils.JwtGenerator(user, "access", "")
+	if err != nil {
+		return fmt.Errorf("не удалось сформировать access token: %s", err)
+	}
+
+	refreshToken, err := utils.JwtGenerator(user, "refresh", refreshTokenId)
+	if err != nil {
+		return fmt.Errorf("не удалось сформировать refresh token: %s", err)
+	}
+
+	utils.AddCookie(w, "access_token", accessToken)
+	utils.AddCookie(w, "refresh_token", refreshToken, int(utils.RefreshTokenTTL.Seconds()))
+
+	for _, name := range legacyIdentityCookies {
+		utils.AddCookie(w, name, "", -1)
+	}
+
+	return nil
+}
+
+// clearAuthCookies удаляет токены пользователя из cookie.
+func clearAuthCookies(w http.ResponseWriter) {
+	utils.AddCookie(w, "access_token", "", -1)
+	utils.AddCookie(w, "refresh_token", "", -1)
+}
+
+// userClaimsFromHeader собирает данные пользователя из заголовков ответа dbservice.
+func userClaimsFromHeader(header metadata.MD) (utils.UserClaims, error) {
+	database, userID, companyID := header.Get("database"), header.Get("user-id"), header.Get("company-id")
+	if len(database) == 0 || len(userID) == 0 || len(companyID) == 0 {
+		return utils.UserClaims{}, fmt.Errorf("отсутствуют необходимые метаданные")
+	}
+
+	user := utils.UserClaims{
+		Database:  database[0],
+		UserId:    userID[0],
+		CompanyId: companyID[0],
+	}
+	if role := header.Get("role"); len(role) != 0 {
+		user.Role = role[0]
+	}
+	if authUserID := header.Get("auth-user-id"); len(authUserID) != 0 {
+		user.AuthUserId = authUserID[0]
+	}
+	return user, nil
+}
@@ -0,0 +1,242 @@
+package transport_rest
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	email "crmSystem/proto/email-service"
+	"crmSystem/proto/logs"
+	"crmSystem/proto/redis"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// emailOtpRequestMessage одинаковый ответ на запрос кода, по которому нельзя определить,
+// зарегистрирован ли адрес и разрешён ли его компании вход по коду.
+const emailOtpRequestMessage = "Если для адреса разрешён вход по коду, на него отправлено письмо с кодом"
+
+// RequestEmailOtp принимает email и отправляет на него одноразовый код для входа без пароля.
+//
+// Частота запросов ограничивается для адреса и IP клиента до ответа, одинаково для любых адресов.
+// Поиск пользователя и отправка письма выполняются в фоне, как при восстановлении пароля.
+func (h *Handler) RequestEmailOtp(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	var req types.EmailOtpRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка при декодировании данных", err)
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка валидации", fmt.Errorf("поле 'Email' не прошло валидацию"))
+		return
+	}
+	address := utils.NormalizeOtpEmail(req.Email)
+
+	token, err := utils.InternalJwtGenerator()
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось создать токен", err)
+		return
+	}
+
+	redisClient, err, redisConn := utils.GRPCServiceConnector(token, redis.NewRedisServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer closeConnection(redisConn)
+
+	err = utils.NewEmailOtpStore(redisClient).AllowRequest(ctx, address, utils.SessionClientFromRequest(r).Ip)
+	if err != nil {
+		writeEmailOtpError(w, err)
+		return
+	}
+
+	go processEmailOtpRequest(address)
+
+	if err := utils.WriteJSON(w, http.StatusOK, types.MessageResponse{Message: emailOtpRequestMessage}); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
+
+// processEmailOtpRequest подключается к необходимым сервисам и отправляет код входа.
+// Выполняется в отдельной горутине, ошибки только записываются в логи.
+func processEmailOtpRequest(address string) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Printf("Паника при отправке кода входа: %v", rec)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	token, err := utils.InternalJwtGenerator()
+	if err != nil {
+		log.Printf("Не удалось создать токен: %v", err)
+		return
+	}
+
+	// Устанавливаем соединение с gRPC сервером Logs
+	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу логов: %v", err)
+		return
+	}
+	defer closeConnection(conn)
+
+	saveError := func(err error) {
+		if errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error()); errLogs != nil {
+			log.Printf("Ошибка сохранения лога: %v", errLogs)
+		}
+	}
+
+	dbClient, err, dbConn := utils.GRPCServiceConnector(token, dbauth.NewDbAuthServiceClient)
+	if err != nil {
+		saveError(err)
+		return
+	}
+	defer closeConnection(dbConn)
+
+	redisClient, err, redisConn := utils.GRPCServiceConnector(token, redis.NewRedisServiceClient)
+	if err != nil {
+		saveError(err)
+		return
+	}
+	defer closeConnection(redisConn)
+
+	emailClient, err, emailConn := utils.GRPCServiceConnector(token, email.NewEmailServiceClient)
+	if err != nil {
+		saveError(err)
+		return
+	}
+	defer closeConnection(emailConn)
+
+	if err := utils.SendEmailOtp(ctx, dbClient, utils.NewEmailOtpStore(redisClient), emailClient, address); err != nil {
+		saveError(err)
+	}
+}
+
+// VerifyEmailOtp проверяет код из письма и выполняет вход так же, как Login:
+// устанавливает cookie сессии или, если нужен второй фактор, cookie ожидания mfa_token.
+func (h *Handler) VerifyEmailOtp(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	var req types.EmailOtpVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка при декодировании данных", err)
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) && len(validationErrors) > 0 {
+			err = fmt.Errorf("поле '%s' не прошло валидацию", validationErrors[0].Field())
+		}
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка валидации", err)
+		return
+	}
+	sessionClient := utils.SessionClientFromRequest(r)
+
+	token, err := utils.InternalJwtGenerator()
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось создать токен", err)
+		return
+	}
+
+	// Устанавливаем соединение с gRPC сервером Logs
+	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer closeConnection(conn)
+
+	saveError := func(err error) {
+		if errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error()); errLogs != nil {
+			log.Printf("Ошибка сохранения лога: %v", errLogs)
+		}
+	}
+
+	dbClient, err, dbConn := utils.GRPCServiceConnector(token, dbauth.NewDbAuthServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer closeConnection(dbConn)
+
+	redisClient, err, redisConn := utils.GRPCServiceConnector(token, redis.NewRedisServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer closeConnection(redisConn)
+
+	store := utils.NewEmailOtpStore(redisClient)
+	if err := store.AllowVerify(ctx, sessionClient.Ip); err != nil {
+		writeEmailOtpError(w, err)
+		return
+	}
+
+	authUserId, err := store.Verify(ctx, req.Email, req.Code)
+	if err != nil {
+		writeEmailOtpError(w, err)
+		if !errors.Is(err, utils.ErrEmailOtpInvalid) {
+			saveError(err)
+		}
+		return
+	}
+
+	// Код верный: dbservice повторно проверяет политику компании и передаёт данные пользователя в заголовках
+	header := metadata.MD{}
+	res, err := dbClient.LoginEmailOtp(ctx, &dbauth.LoginEmailOtpRequest{UserId: authUserId}, grpc.Header(&header))
+	if err != nil {
+		switch status.Code(err) {
+		case codes.NotFound:
+			// Пользователь удалён после выдачи кода
+			writeEmailOtpError(w, utils.ErrEmailOtpInvalid)
+		case codes.FailedPrecondition:
+			utils.CreateError(w, http.StatusForbidden, "Ошибка входа по коду", errors.New(status.Convert(err).Message()))
+		default:
+			utils.CreateError(w, http.StatusInternalServerError, "Ошибка входа по коду", errors.New(status.Convert(err).Message()))
+			saveError(err)
+		}
+		return
+	}
+
+	response, err := completeLogin(ctx, w, token, header, res.Message, sessionClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Ошибка входа по коду", err)
+		saveError(err)
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, response); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
+
+// writeEmailOtpError записывает в ответ ошибку проверки или ограничения частоты запросов кода.
+func writeEmailOtpError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, utils.ErrEmailOtpRateLimited):
+		w.Header().Set("Retry-After", strconv.Itoa(int(utils.EmailOtpRequestWindow.Seconds())))
+		utils.CreateError(w, http.StatusTooManyRequests, "Ошибка входа по коду", err)
+	case errors.Is(err, utils.ErrEmailOtpInvalid):
+		utils.CreateError(w, http.StatusUnauthorized, "Ошибка входа по коду", err)
+	default:
+		utils.CreateError(w, http.StatusInternalServerError, "Ошибка входа по коду", err)
+	}
+}
@@ -2,11 +2,14 @@ package transport_rest
 
 import (
 	"context"
+	"crmSystem/lifecycle"
 	"crmSystem/proto/dbauth"
+	email "crmSystem/proto/email-service"
 	"crmSystem/proto/logs"
 	"crmSystem/transport_rest/types"
 	"crmSystem/utils"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/handlers"
@@ -17,6 +20,7 @@ import (
 	"google.golang.org/grpc/status"
 	"log"
 	"net/http"
+	"os"
 	"time"
 )
 
@@ -30,12 +34,39 @@ func NewHandler() *Handler {
 func (h *Handler) InitRouter() http.Handler {
 	r := mux.NewRouter()
 
+	// Проверки состояния сервиса для docker-compose
+	r.Handle("/healthz", lifecycle.LivenessHandler()).Methods(http.MethodGet)
+	r.Handle("/readyz", lifecycle.ReadinessHandler()).Methods(http.MethodGet)
+
+	// Публичные ключи проверки токенов для сервисов (без авторизации)
+	r.HandleFunc("/.well-known/jwks.json", utils.RecoverMiddleware(h.Jwks)).Methods(http.MethodGet)
+
 	authRouts := r.PathPrefix("/auth").Subrouter()
 	{
 		authRouts.HandleFunc("/login", utils.RecoverMiddleware(h.Login)).Methods(http.MethodPost)
 		authRouts.HandleFunc("/register", utils.RecoverMiddleware(h.Register)).Methods(http.MethodPost)
+		authRouts.HandleFunc("/register/status", utils.RecoverMiddleware(h.RegisterStatus)).Methods(http.MethodGet)
 		authRouts.HandleFunc("/refresh", utils.RecoverMiddleware(h.RefreshToken)).Methods(http.MethodPost)
 		authRouts.HandleFunc("/check", utils.RecoverMiddleware(h.CheckAuth)).Methods(http.MethodPost)
+		authRouts.HandleFunc("/logout", utils.RecoverMiddleware(h.Logout)).Methods(http.MethodPost)
+		authRouts.HandleFunc("/logout-all", utils.RecoverMiddleware(h.LogoutAll)).Methods(http.MethodPost)
+		authRouts.HandleFunc("/password/forgot", utils.RecoverMiddleware(h.ForgotPassword)).Methods(http.MethodPost)
+		authRouts.HandleFunc("/password/reset", utils.RecoverMiddleware(h.ResetPassword)).Methods(http.MethodPost)
+		authRouts.HandleFunc("/otp/request", utils.RecoverMiddleware(h.RequestEmailOtp)).Methods(http.MethodPost)
+		authRouts.HandleFunc("/otp/verify", utils.RecoverMiddleware(h.VerifyEmailOtp)).Methods(http.MethodPost)
+		authRouts.HandleFunc("/activate", utils.RecoverMiddleware(h.Activate)).Methods(http.MethodPost)
+		authRouts.HandleFunc("/mfa/verify", utils.RecoverMiddleware(h.VerifyMfa)).Methods(http.MethodPost)
+		authRouts.HandleFunc("/mfa/totp/setup", utils.RecoverMiddleware(h.TotpSetup)).Methods(http.MethodPost)
+		authRouts.HandleFunc("/mfa/totp/confirm", utils.RecoverMiddleware(h.TotpConfirm)).Methods(http.MethodPost)
+		authRouts.HandleFunc("/mfa/totp/disable", utils.RecoverMiddleware(h.TotpDisable)).Methods(http.MethodPost)
+		authRouts.HandleFunc("/sessions", utils.RecoverMiddleware(h.Sessions)).Methods(http.MethodGet)
+		authRouts.HandleFunc("/sessions/{id}", utils.RecoverMiddleware(h.RevokeSession)).Methods(http.MethodDelete)
+		authRouts.HandleFunc("/oidc/start", utils.RecoverMiddleware(h.OidcStart)).Methods(http.MethodPost)
+		authRouts.HandleFunc("/oidc/callback", utils.RecoverMiddleware(h.OidcCallback)).Methods(http.MethodGet)
+		authRouts.HandleFunc("/api-keys", utils.RecoverMiddleware(h.ApiKeys)).Methods(http.MethodGet)
+		authRouts.HandleFunc("/api-keys", utils.RecoverMiddleware(h.CreateApiKey)).Methods(http.MethodPost)
+		authRouts.HandleFunc("/api-keys/verify", utils.RecoverMiddleware(h.VerifyApiKey)).Methods(http.MethodGet)
+		authRouts.HandleFunc("/api-keys/{id}", utils.RecoverMiddleware(h.RevokeApiKey)).Methods(http.MethodDelete)
 
 	}
 
@@ -160,7 +191,7 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Проводим авторизацию пользователя с запросом к dbservice
-	response, responseStatus, err := loginUser(w, client, &req, token)
+	response, responseStatus, err := loginUser(w, client, &req, token, utils.SessionClientFromRequest(r))
 	if err != nil {
 		utils.CreateError(w, responseStatus, "Ошибка на сервере", err)
 		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
@@ -180,7 +211,8 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func loginUser(w http.ResponseWriter, client dbauth.DbAuthServiceClient, req *types.LoginAuthRequest, token string) (response *types.LoginAuthResponse, responseStatus uint32, err error) {
+func loginUser(w http.ResponseWriter, client dbauth.DbAuthServiceClient, req *types.LoginAuthRequest, token string,
+	sessionClient utils.SessionClient) (response *types.LoginAuthResponse, responseStatus uint32, err error) {
 
 	// Формируем запрос на вход в систему
 	reqLogin := &dbauth.LoginDBRequest{
@@ -193,6 +225,9 @@ func loginUser(w http.ResponseWriter, client dbauth.DbAuthServiceClient, req *ty
 	ctxWithMetadata, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
+	// IP адрес клиента нужен dbservice для учёта неудачных попыток входа с одного адреса
+	ctxWithMetadata = metadata.AppendToOutgoingContext(ctxWithMetadata, "client-ip", sessionClient.Ip)
+
 	// Устанавливаем соединение с gRPC сервером Logs
 	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
 	if err != nil {
@@ -213,11 +248,12 @@ func loginUser(w http.ResponseWriter, client dbauth.DbAuthServiceClient, req *ty
 		}(conn)
 	}
 
-	// Заголовки из ответа
+	// Заголовки и трейлеры из ответа
 	header := metadata.MD{}
+	trailer := metadata.MD{}
 
 	// Выполняем gRPC вызов LoginDB, передавая указатель для получения заголовков
-	resDB, err := client.LoginDB(ctxWithMetadata, reqLogin, grpc.Header(&header))
+	resDB, err := client.LoginDB(ctxWithMetadata, reqLogin, grpc.Header(&header), grpc.Trailer(&trailer))
 	if err != nil {
 		// Получаем сообщение об ошибке
 		errorMessage := status.Convert(err).Message()
@@ -234,6 +270,21 @@ func loginUser(w http.ResponseWriter, client dbauth.DbAuthServiceClient, req *ty
 			}
 			return nil, http.StatusUnauthorized, fmt.Errorf("неавторизированный запрос : %s", errorMessage)
 
+		case codes.FailedPrecondition:
+			// Пароль верный, но email не подтверждён или приглашение не принято
+			return nil, http.StatusForbidden, fmt.Errorf("%s", errorMessage)
+
+		case codes.Unavailable:
+			// База данных компании ещё создаётся
+			return nil, http.StatusServiceUnavailable, fmt.Errorf("%s", errorMessage)
+
+		case codes.ResourceExhausted:
+			// Слишком много неудачных попыток: вход временно заблокирован
+			if retryAfter := trailer.Get("retry-after"); len(retryAfter) != 0 {
+				w.Header().Set("Retry-After", retryAfter[0])
+			}
+			return nil, http.StatusTooManyRequests, fmt.Errorf("%s", errorMessage)
+
 		default:
 			errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, "", "", err.Error())
 			if errLogs != nil {
@@ -243,40 +294,53 @@ func loginUser(w http.ResponseWriter, client dbauth.DbAuthServiceClient, req *ty
 		}
 	}
 
-	// Проверяем наличие метаданных в ответе
-	database := header.Get("database")
-	userID := header.Get("user-id")
-	companyID := header.Get("company-id")
-
-	if len(database) == 0 || len(userID) == 0 || len(companyID) == 0 {
-		errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, "", "", "отсутствуют необходимые метаданные")
+	response, err = completeLogin(ctxWithMetadata, w, token, header, resDB.Message, sessionClient)
+	if err != nil {
+		errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, "", "", err.Error())
 		if errLogs != nil {
-			log.Printf("отсутствуют необходимые метаданные: %v", err)
+			log.Printf("Ошибка завершения входа: %v", err)
 		}
-		return nil, http.StatusInternalServerError, fmt.Errorf("отсутствуют необходимые метаданные")
+		return nil, http.StatusInternalServerError, err
 	}
+	return response, http.StatusOK, nil
+}
 
-	accessToken, err := utils.JwtGenerator(userID[0], "access")
-	if err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("не удалось сформировать access token %s", err)
+// completeLogin завершает вход после проверки первого фактора (пароля или кода из письма).
+//
+// Если dbservice передал заголовок mfa-required, вместо сессии выдаётся короткоживущий токен ожидания,
+// который обменивается на токены через /auth/mfa/*. Иначе данные пользователя из заголовков
+// переносятся в подписанные токены сессии.
+func completeLogin(ctx context.Context, w http.ResponseWriter, token string, header metadata.MD, message string,
+	sessionClient utils.SessionClient) (*types.LoginAuthResponse, error) {
+
+	if mfa := header.Get("mfa-required"); len(mfa) != 0 {
+		authUserId := header.Get("auth-user-id")
+		if len(authUserId) == 0 {
+			return nil, fmt.Errorf("отсутствуют необходимые метаданные")
+		}
+		if err := setMfaCookie(w, authUserId[0], mfa[0]); err != nil {
+			return nil, err
+		}
+		return &types.LoginAuthResponse{
+			Message: message,
+			Mfa:     mfa[0],
+		}, nil
 	}
 
-	refreshToken, err := utils.JwtGenerator(userID[0], "refresh")
+	// Проверяем наличие метаданных в ответе
+	user, err := userClaimsFromHeader(header)
 	if err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("не удалось сформировать refresh token %s", err)
+		return nil, err
 	}
 
-	// Устанавливаем Cookie
-	utils.AddCookie(w, "access_token", accessToken)
-	utils.AddCookie(w, "refresh_token", refreshToken)
-	utils.AddCookie(w, "database", database[0])
-	utils.AddCookie(w, "user-id", userID[0])
-	utils.AddCookie(w, "company-id", companyID[0])
-
-	response = &types.LoginAuthResponse{
-		Message: resDB.Message,
+	// Данные пользователя передаются только внутри подписанных токенов
+	if err := startSession(ctx, w, token, user, sessionClient); err != nil {
+		return nil, err
 	}
-	return response, http.StatusOK, nil
+
+	return &types.LoginAuthResponse{
+		Message: message,
+	}, nil
 }
 
 func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
@@ -385,7 +449,7 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Вызываем метод регистрации компании через gRPC
-	response, responseStatus, err := callRegisterCompany(w, client, &req, ctx, clientLogs)
+	response, responseStatus, err := callRegisterCompany(w, client, &req, ctx, clientLogs, iternalToken)
 	if err != nil {
 		utils.CreateError(w, responseStatus, "Ошибка регистрации компании", err)
 		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
@@ -395,8 +459,8 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Если запрос успешно выполнен, возвращаем JSON-ответ
-	if err := utils.WriteJSON(w, http.StatusOK, response); err != nil {
+	// База компании создаётся в фоне: регистрация принята, состояние опрашивается по provisioningId
+	if err := utils.WriteJSON(w, http.StatusAccepted, response); err != nil {
 		utils.CreateError(w, http.StatusInternalServerError, "Ошибка записи ответа", err)
 		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
 		if errLogs != nil {
@@ -406,7 +470,7 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 }
 
 func callRegisterCompany(w http.ResponseWriter, client dbauth.DbAuthServiceClient,
-	req *types.RegisterAuthRequest, ctx context.Context, clientLogs logs.LogsServiceClient) (response *types.RegisterAuthResponse, responseStatus uint32, err error) {
+	req *types.RegisterAuthRequest, ctx context.Context, clientLogs logs.LogsServiceClient, token string) (response *types.RegisterAuthResponse, responseStatus uint32, err error) {
 
 	// Создаем контекст с тайм-аутом для запроса
 	// В случае превышения порога ожидания с сервера в 10 секунд будет ошибка контекста.
@@ -458,45 +522,56 @@ func callRegisterCompany(w http.ResponseWriter, client dbauth.DbAuthServiceClien
 
 	//Получен ответ о логинизации от dbservice
 
-	// Проверяем наличие метаданных в ответе
-	database := header.Get("database")
-	userID := header.Get("user-id")
-	companyID := header.Get("company-id")
-
-	if len(database) == 0 || len(userID) == 0 || len(companyID) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", "отсутствуют необходимые метаданные")
+	// Вход станет доступен после подтверждения email, поэтому сессия не создаётся,
+	// а на указанный адрес отправляется ссылка активации
+	activationToken := header.Get("activation-token")
+	if len(activationToken) == 0 || activationToken[0] == "" {
+		err := fmt.Errorf("отсутствует токен активации в ответе сервера")
+		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
 		if errLogs != nil {
 			log.Printf("Отсутствуют необходимые метаданные: %v", err)
 		}
-		return nil, http.StatusInternalServerError, fmt.Errorf("отсутствуют необходимые метаданные")
+		return nil, http.StatusInternalServerError, err
 	}
 
-	accessToken, err := utils.JwtGenerator(userID[0], "access")
+	emailClient, err, emailConn := utils.GRPCServiceConnector(token, email.NewEmailServiceClient)
 	if err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("не удалось сформировать access token: %s", err)
+		return nil, http.StatusInternalServerError, err
 	}
+	defer closeConnection(emailConn)
 
-	refreshToken, err := utils.JwtGenerator(userID[0], "access")
+	err = utils.SendActivationLink(ctx, emailClient, req.Email, os.Getenv("ACTIVATION_URL"), activationToken[0])
 	if err != nil {
-		return nil, http.StatusInternalServerError, fmt.Errorf("не удалось сформировать refresh token %s", err)
+		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
+		if errLogs != nil {
+			log.Printf("Ошибка отправки письма активации: %v", err)
+		}
+		return nil, http.StatusInternalServerError, err
 	}
 
-	// Устанавливаем HttpOnly Cookie
-	utils.AddCookie(w, "access_token", accessToken)
-	utils.AddCookie(w, "refresh_token", refreshToken)
-	utils.AddCookie(w, "database", database[0])
-	utils.AddCookie(w, "user-id", userID[0])
-	utils.AddCookie(w, "company-id", companyID[0])
-
 	response = &types.RegisterAuthResponse{
-		Message: resDB.Message,
+		Message:        resDB.Message,
+		ProvisioningId: resDB.ProvisioningId,
+		Status:         resDB.ProvisioningStatus,
 	}
 	return response, http.StatusOK, nil
 
 }
 
+// RefreshToken обновляет пару токенов по refresh token из cookie.
+// Каждый refresh token может быть использован только один раз: при повторном
+// использовании вся сессия отзывается, а пользователю нужно войти заново.
 func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	// Проверяем подпись refresh токена
+	user, tokenId, err := utils.ParseRefreshToken(r)
+	if err != nil {
+		clearAuthCookies(w)
+		utils.CreateError(w, http.StatusUnauthorized, "Не удалось обновить токен", err)
+		return
+	}
 
 	token, err := utils.InternalJwtGenerator()
 	if err != nil {
@@ -522,32 +597,150 @@ func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		}
 	}(conn)
 
-	newAccessToken, err := utils.ValidateAndRefreshToken(w, r)
+	// Устанавливаем соединение с redis, где хранятся сессии
+	store, redisConn, err := connectRefreshStore(token)
 	if err != nil {
-		utils.CreateError(w, http.StatusInternalServerError, "Не удалось создать токен", err)
-		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
+		utils.CreateError(w, http.StatusInternalServerError, "Ошибка подключения", err)
+		errLogs := utils.SaveLogsError(ctx, clientLogs, user.Database, user.UserId, err.Error())
 		if errLogs != nil {
-			return
-		} // Логируем ошибку
+			log.Printf("Ошибка подключения: %v", err)
+		}
+		return
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}(redisConn)
+
+	// Заменяем использованный refresh token новым
+	newTokenId, err := store.Rotate(ctx, user, tokenId)
+	switch {
+	case errors.Is(err, utils.ErrRefreshTokenReused):
+		clearAuthCookies(w)
+		utils.CreateError(w, http.StatusUnauthorized, "Не удалось обновить токен", err)
+		errLogs := utils.SaveLogsWarning(ctx, clientLogs, user.Database, user.UserId,
+			fmt.Sprintf("Повторное использование refresh token, сессия %s отозвана", user.SessionId))
+		if errLogs != nil {
+			log.Printf("Ошибка сохранения лога: %v", errLogs)
+		}
+		return
+	case errors.Is(err, utils.ErrSessionRevoked):
+		clearAuthCookies(w)
+		utils.CreateError(w, http.StatusUnauthorized, "Не удалось обновить токен", err)
+		return
+	case err != nil:
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось обновить токен", err)
+		errLogs := utils.SaveLogsError(ctx, clientLogs, user.Database, user.UserId, err.Error())
+		if errLogs != nil {
+			log.Printf("Ошибка сохранения лога: %v", errLogs)
+		}
 		return
 	}
 
-	// Добавляем куку в заголовок ответа
-	utils.AddCookie(w, "access_token", newAccessToken)
+	// Добавляем новые токены в cookie
+	if err := setAuthCookies(w, user, newTokenId); err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось создать токен", err)
+		errLogs := utils.SaveLogsError(ctx, clientLogs, user.Database, user.UserId, err.Error())
+		if errLogs != nil {
+			log.Printf("Ошибка сохранения лога: %v", errLogs)
+		}
+		return
+	}
 
 	// Возвращаем JSON-ответ об успешном обновлении токена
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
 	response := map[string]string{"message": "Токен успешно обновлён"}
-	err = json.NewEncoder(w).Encode(response)
+	if err := utils.WriteJSON(w, http.StatusOK, response); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
+
+// Logout завершает текущую сессию пользователя: её refresh token больше не будет принят.
+// Cookie удаляются в любом случае, даже если токен уже недействителен.
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	clearAuthCookies(w)
+
+	user, _, err := utils.ParseRefreshToken(r)
 	if err != nil {
-		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
-		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
-		if errLogs != nil {
+		// Сессии, которую можно отозвать, нет, пользователь уже не авторизован
+		if err := utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Выход выполнен"}); err != nil {
+			log.Printf("Ошибка записи ответа: %v", err)
+		}
+		return
+	}
+
+	token, err := utils.InternalJwtGenerator()
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось создать токен", err)
+		return
+	}
+
+	store, conn, err := connectRefreshStore(token)
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Ошибка подключения", err)
+		return
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}(conn)
+
+	if err := store.RevokeSession(ctx, user, user.SessionId); err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось завершить сессию", err)
+		return
+	}
 
-		} // Логируем ошибку
+	if err := utils.WriteJSON(w, http.StatusOK, map[string]string{"message": "Выход выполнен"}); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
+
+// LogoutAll завершает все сессии пользователя на всех устройствах.
+// Уже выданные access токены остаются действительными до истечения их срока (utils.AccessTokenTTL).
+func (h *Handler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	user, _, err := utils.ParseRefreshToken(r)
+	if err != nil {
+		clearAuthCookies(w)
+		utils.CreateError(w, http.StatusUnauthorized, "Не удалось завершить сессии", err)
 		return
 	}
+
+	token, err := utils.InternalJwtGenerator()
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось создать токен", err)
+		return
+	}
+
+	store, conn, err := connectRefreshStore(token)
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Ошибка подключения", err)
+		return
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}(conn)
+
+	revoked, err := store.RevokeAllSessions(ctx, user)
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось завершить сессии", err)
+		return
+	}
+
+	clearAuthCookies(w)
+
+	response := map[string]string{"message": fmt.Sprintf("Завершено сессий: %d", revoked)}
+	if err := utils.WriteJSON(w, http.StatusOK, response); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
 }
 
 func (h *Handler) CheckAuth(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,25 @@
+package transport_rest
+
+import (
+	"crmSystem/utils"
+	"log"
+	"net/http"
+)
+
+// Jwks отдаёт действующие публичные ключи проверки токенов в формате JSON Web Key Set.
+// Сервисы выбирают ключ по заголовку kid токена, поэтому во время ротации ключа
+// в наборе одновременно находятся старый и новый ключи.
+func (h *Handler) Jwks(w http.ResponseWriter, r *http.Request) {
+	keys, err := utils.VerificationKeys()
+	if err != nil {
+		log.Printf("Ошибка загрузки ключей проверки токенов: %v", err)
+		utils.CreateError(w, http.StatusInternalServerError, "Ключи проверки токенов недоступны", err)
+		return
+	}
+
+	// Сервисы запрашивают набор повторно, когда встречают токен с неизвестным kid
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := utils.WriteJSON(w, http.StatusOK, keys.JWKS()); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
@@ -0,0 +1,315 @@
+package transport_rest
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	"crmSystem/proto/logs"
+	"crmSystem/proto/redis"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Требуемый шаг второго фактора из токена ожидания.
+const (
+	mfaMethodTotp   = "totp"
+	mfaMethodEnroll = "enroll"
+)
+
+// mfaSubject пользователь, для которого выполняется операция двухфакторной аутентификации.
+type mfaSubject struct {
+	AuthUserId string
+	Pending    bool   // Вход ещё не завершён, пользователь предъявил токен ожидания второго фактора
+	TokenId    string // jti токена ожидания, если Pending
+}
+
+// setMfaCookie выдаёт токен ожидания второго фактора в HTTP-only cookie.
+// Токен хранится отдельно от access_token и не принимается другими сервисами.
+func setMfaCookie(w http.ResponseWriter, authUserId string, method string) error {
+	token, err := utils.MfaTokenGenerator(authUserId, method)
+	if err != nil {
+		return fmt.Errorf("не удалось сформировать токен второго фактора: %s", err)
+	}
+	utils.AddCookie(w, "mfa_token", token, int(utils.MfaPendingTokenTTL.Seconds()))
+	return nil
+}
+
+// clearMfaCookie удаляет токен ожидания второго фактора после завершения входа.
+func clearMfaCookie(w http.ResponseWriter) {
+	utils.AddCookie(w, "mfa_token", "", -1)
+}
+
+// connectMfaTokenStore подключается к redis сервису, в котором хранятся погашенные токены ожидания.
+// Соединение необходимо закрыть после использования.
+func connectMfaTokenStore(token string) (*utils.MfaTokenStore, *grpc.ClientConn, error) {
+	client, err, conn := utils.GRPCServiceConnector(token, redis.NewRedisServiceClient)
+	if err != nil {
+		return nil, nil, fmt.Errorf("не удалось подключиться к серверу redis: %v", err)
+	}
+	return utils.NewMfaTokenStore(client), conn, nil
+}
+
+// mfaTokenError приводит ошибку проверки токена ожидания к ошибке gRPC для mfaCall.
+// Погашенный токен означает, что нужно войти заново.
+func mfaTokenError(err error) error {
+	if errors.Is(err, utils.ErrMfaTokenUsed) {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	return err
+}
+
+// VerifyMfa завершает вход: проверяет код TOTP или код восстановления
+// и обменивает токен ожидания на access и refresh токены.
+//
+// Неверные коды считает dbservice. После блокировки проверки кодов токен ожидания гасится,
+// как и после успешного входа, и для следующей попытки нужно снова ввести пароль.
+func (h *Handler) VerifyMfa(w http.ResponseWriter, r *http.Request) {
+	pending, err := utils.ParseMfaToken(r)
+	if err != nil || pending.Method != mfaMethodTotp {
+		utils.CreateError(w, http.StatusUnauthorized, "Войдите заново", fmt.Errorf("токен второго фактора отсутствует или истёк"))
+		return
+	}
+
+	req, ok := decodeMfaCode(w, r)
+	if !ok {
+		return
+	}
+
+	mfaCall(w, func(ctx context.Context, client dbauth.DbAuthServiceClient, token string) (interface{}, error) {
+		store, conn, err := connectMfaTokenStore(token)
+		if err != nil {
+			return nil, err
+		}
+		defer closeConnection(conn)
+
+		if err := store.Check(ctx, pending.TokenId); err != nil {
+			return nil, mfaTokenError(err)
+		}
+
+		header, trailer := metadata.MD{}, metadata.MD{}
+		res, err := client.VerifyMfa(ctx, &dbauth.MfaCodeRequest{UserId: pending.AuthUserId, Code: req.Code},
+			grpc.Header(&header), grpc.Trailer(&trailer))
+		if status.Code(err) == codes.ResourceExhausted {
+			// Проверка кодов заблокирована: подбор кода нельзя продолжить с этим токеном
+			if errConsume := store.Consume(ctx, pending.TokenId); errConsume != nil && !errors.Is(errConsume, utils.ErrMfaTokenUsed) {
+				log.Printf("Ошибка погашения токена второго фактора: %v", errConsume)
+			}
+			clearMfaCookie(w)
+			if retryAfter := trailer.Get("retry-after"); len(retryAfter) != 0 {
+				w.Header().Set("Retry-After", retryAfter[0])
+			}
+			return nil, err
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Токен гасится до выдачи сессии, поэтому параллельный запрос с тем же токеном её не получит
+		if err := store.Consume(ctx, pending.TokenId); err != nil {
+			return nil, mfaTokenError(err)
+		}
+		if err := finishMfaLogin(ctx, w, token, header, utils.SessionClientFromRequest(r)); err != nil {
+			return nil, err
+		}
+		return types.LoginAuthResponse{Message: res.Message}, nil
+	})
+}
+
+// TotpSetup начинает подключение TOTP и возвращает секрет и URI otpauth:// для QR-кода.
+// Доступен авторизованному пользователю и при входе, если компания требует подключить 2FA.
+func (h *Handler) TotpSetup(w http.ResponseWriter, r *http.Request) {
+	subject, err := mfaSubjectFromRequest(r)
+	if err != nil {
+		utils.CreateError(w, http.StatusUnauthorized, "Пользователь не авторизован", err)
+		return
+	}
+
+	mfaCall(w, func(ctx context.Context, client dbauth.DbAuthServiceClient, token string) (interface{}, error) {
+		if subject.Pending {
+			if err := checkPendingToken(ctx, token, subject.TokenId, false); err != nil {
+				return nil, err
+			}
+		}
+		res, err := client.BeginTotpEnrollment(ctx, &dbauth.BeginTotpEnrollmentRequest{UserId: subject.AuthUserId})
+		if err != nil {
+			return nil, err
+		}
+		return types.TotpSetupResponse{Secret: res.Secret, ProvisioningUri: res.ProvisioningUri}, nil
+	})
+}
+
+// TotpConfirm включает TOTP по первому коду из приложения и возвращает коды восстановления.
+// Если подключение было обязательным шагом входа, вход завершается и выдаются токены.
+func (h *Handler) TotpConfirm(w http.ResponseWriter, r *http.Request) {
+	subject, err := mfaSubjectFromRequest(r)
+	if err != nil {
+		utils.CreateError(w, http.StatusUnauthorized, "Пользователь не авторизован", err)
+		return
+	}
+
+	req, ok := decodeMfaCode(w, r)
+	if !ok {
+		return
+	}
+
+	mfaCall(w, func(ctx context.Context, client dbauth.DbAuthServiceClient, token string) (interface{}, error) {
+		if subject.Pending {
+			if err := checkPendingToken(ctx, token, subject.TokenId, false); err != nil {
+				return nil, err
+			}
+		}
+		header := metadata.MD{}
+		res, err := client.ConfirmTotpEnrollment(ctx,
+			&dbauth.MfaCodeRequest{UserId: subject.AuthUserId, Code: req.Code}, grpc.Header(&header))
+		if err != nil {
+			return nil, err
+		}
+		if subject.Pending {
+			if err := checkPendingToken(ctx, token, subject.TokenId, true); err != nil {
+				return nil, err
+			}
+			if err := finishMfaLogin(ctx, w, token, header, utils.SessionClientFromRequest(r)); err != nil {
+				return nil, err
+			}
+		}
+		return types.TotpConfirmResponse{Message: res.Message, RecoveryCodes: res.RecoveryCodes}, nil
+	})
+}
+
+// TotpDisable отключает TOTP авторизованного пользователя после проверки текущего кода.
+func (h *Handler) TotpDisable(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ParseAccessToken(r)
+	if err != nil || user.AuthUserId == "" {
+		utils.CreateError(w, http.StatusUnauthorized, "Пользователь не авторизован", fmt.Errorf("войдите заново"))
+		return
+	}
+
+	req, ok := decodeMfaCode(w, r)
+	if !ok {
+		return
+	}
+
+	mfaCall(w, func(ctx context.Context, client dbauth.DbAuthServiceClient, _ string) (interface{}, error) {
+		res, err := client.DisableTotp(ctx, &dbauth.MfaCodeRequest{UserId: user.AuthUserId, Code: req.Code})
+		if err != nil {
+			return nil, err
+		}
+		return types.MessageResponse{Message: res.Message}, nil
+	})
+}
+
+// mfaSubjectFromRequest определяет пользователя по access token или, если вход ещё не завершён,
+// по токену ожидания, выданному для обязательного подключения TOTP.
+func mfaSubjectFromRequest(r *http.Request) (*mfaSubject, error) {
+	if user, err := utils.ParseAccessToken(r); err == nil {
+		if user.AuthUserId == "" {
+			// Токен выдан до появления 2FA и не содержит ID пользователя авторизации
+			return nil, fmt.Errorf("войдите заново")
+		}
+		return &mfaSubject{AuthUserId: user.AuthUserId}, nil
+	}
+
+	pending, err := utils.ParseMfaToken(r)
+	if err != nil || pending.Method != mfaMethodEnroll {
+		return nil, fmt.Errorf("токен отсутствует или истёк")
+	}
+	return &mfaSubject{AuthUserId: pending.AuthUserId, Pending: true, TokenId: pending.TokenId}, nil
+}
+
+// checkPendingToken проверяет, что токен ожидания tokenId не погашен. Если consume = true,
+// токен гасится: так завершение входа по одному токену возможно только один раз.
+func checkPendingToken(ctx context.Context, token string, tokenId string, consume bool) error {
+	store, conn, err := connectMfaTokenStore(token)
+	if err != nil {
+		return err
+	}
+	defer closeConnection(conn)
+
+	if consume {
+		return mfaTokenError(store.Consume(ctx, tokenId))
+	}
+	return mfaTokenError(store.Check(ctx, tokenId))
+}
+
+// finishMfaLogin создаёт сессию по данным пользователя из заголовков dbservice и удаляет токен ожидания.
+func finishMfaLogin(ctx context.Context, w http.ResponseWriter, token string, header metadata.MD,
+	client utils.SessionClient) error {
+	user, err := userClaimsFromHeader(header)
+	if err != nil {
+		return err
+	}
+	if err := startSession(ctx, w, token, user, client); err != nil {
+		return err
+	}
+	clearMfaCookie(w)
+	return nil
+}
+
+// decodeMfaCode разбирает и проверяет тело запроса с кодом. При ошибке записывает ответ клиенту.
+func decodeMfaCode(w http.ResponseWriter, r *http.Request) (*types.MfaCodeRequest, bool) {
+	var req types.MfaCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка при декодировании данных", err)
+		return nil, false
+	}
+	if err := validator.New().Struct(req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка валидации", fmt.Errorf("поле 'Code' не прошло валидацию"))
+		return nil, false
+	}
+	return &req, true
+}
+
+// mfaCall подключается к dbservice, выполняет call и записывает результат или ошибку в ответ.
+func mfaCall(w http.ResponseWriter,
+	call func(ctx context.Context, client dbauth.DbAuthServiceClient, token string) (interface{}, error)) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	token, err := utils.InternalJwtGenerator()
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось создать токен", err)
+		return
+	}
+
+	// Устанавливаем соединение с gRPC сервером Logs
+	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer closeConnection(conn)
+
+	client, err, dbConn := utils.GRPCServiceConnector(token, dbauth.NewDbAuthServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer closeConnection(dbConn)
+
+	response, err := call(ctx, client, token)
+	if err != nil {
+		httpStatus := utils.MfaErrorStatus(err)
+		utils.CreateError(w, httpStatus, "Ошибка двухфакторной аутентификации", errors.New(status.Convert(err).Message()))
+		if httpStatus == http.StatusInternalServerError {
+			if errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error()); errLogs != nil {
+				log.Printf("Ошибка сохранения лога: %v", errLogs)
+			}
+		}
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, response); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
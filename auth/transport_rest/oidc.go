@@ -0,0 +1,163 @@
+package transport_rest
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	"crmSystem/proto/logs"
+	"crmSystem/proto/redis"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// oidcHttpClient клиент для запросов к провайдерам компаний.
+var oidcHttpClient = &http.Client{Timeout: 10 * time.Second}
+
+// OidcStart начинает вход через корпоративного OpenID Connect провайдера:
+// выбирает провайдера по домену email и возвращает адрес входа у провайдера.
+func (h *Handler) OidcStart(w http.ResponseWriter, r *http.Request) {
+	var req types.OidcStartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка при декодировании данных", err)
+		return
+	}
+	if err := validator.New().Struct(req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка валидации", fmt.Errorf("поле 'Email' не прошло валидацию"))
+		return
+	}
+
+	oidcCall(w, func(ctx context.Context, oidc *utils.OidcClient, client dbauth.DbAuthServiceClient, token string) (interface{}, error) {
+		authorizationUrl, state, err := oidc.Start(ctx, client, req.Email, os.Getenv("OIDC_REDIRECT_URL"))
+		if err != nil {
+			return nil, err
+		}
+		// Провайдер вернёт пользователя на callback, где state из адреса сверяется с этим cookie
+		utils.AddCookie(w, utils.OidcStateCookie, utils.OidcStateBinding(state), int(utils.OidcStateTTL.Seconds()))
+		return types.OidcStartResponse{AuthorizationUrl: authorizationUrl}, nil
+	})
+}
+
+// OidcCallback завершает вход: провайдер перенаправляет пользователя сюда с кодом авторизации.
+// state из адреса должен совпадать с cookie, установленным OidcStart в этом браузере.
+// После проверки ID токена пользователь сопоставляется с authusers и получает обычные токены в cookie
+// или, если нужен второй фактор, токен ожидания.
+func (h *Handler) OidcCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if providerError := query.Get("error"); providerError != "" {
+		utils.CreateError(w, http.StatusForbidden, "Вход через провайдера отменён",
+			fmt.Errorf("%s %s", providerError, query.Get("error_description")))
+		return
+	}
+
+	// Вход должен быть начат в этом же браузере, иначе state и код могли быть подставлены злоумышленником
+	if err := utils.CheckOidcStateCookie(r, query.Get("state")); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка входа через корпоративного провайдера", err)
+		return
+	}
+	utils.AddCookie(w, utils.OidcStateCookie, "", -1)
+
+	oidcCall(w, func(ctx context.Context, oidc *utils.OidcClient, client dbauth.DbAuthServiceClient, token string) (interface{}, error) {
+		identity, err := oidc.Finish(ctx, client, query.Get("state"), query.Get("code"), os.Getenv("OIDC_REDIRECT_URL"))
+		if err != nil {
+			return nil, err
+		}
+
+		header := metadata.MD{}
+		res, err := client.LoginOidc(ctx, &dbauth.LoginOidcRequest{
+			CompanyId:     identity.CompanyId,
+			Subject:       identity.Subject,
+			Email:         identity.Email,
+			EmailVerified: identity.EmailVerified,
+		}, grpc.Header(&header))
+		if err != nil {
+			return nil, err
+		}
+
+		// Второй фактор CRM запрашивается так же, как при входе по паролю
+		response, err := completeLogin(ctx, w, token, header, res.Message, utils.SessionClientFromRequest(r))
+		if err != nil {
+			return nil, err
+		}
+
+		// Вход выполняется в браузере, после установки cookie пользователь возвращается во фронтенд
+		if redirectUrl := os.Getenv("OIDC_LOGIN_REDIRECT_URL"); redirectUrl != "" {
+			location, err := utils.OidcLoginRedirect(redirectUrl, response.Mfa)
+			if err != nil {
+				return nil, err
+			}
+			http.Redirect(w, r, location, http.StatusFound)
+			return nil, nil
+		}
+		return response, nil
+	})
+}
+
+// oidcCall подключается к dbservice и redis, выполняет call и записывает результат или ошибку в ответ.
+// Если call вернул nil без ошибки, ответ уже записан.
+func oidcCall(w http.ResponseWriter,
+	call func(ctx context.Context, oidc *utils.OidcClient, client dbauth.DbAuthServiceClient, token string) (interface{}, error)) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*20)
+	defer cancel()
+
+	token, err := utils.InternalJwtGenerator()
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось создать токен", err)
+		return
+	}
+
+	// Устанавливаем соединение с gRPC сервером Logs
+	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer closeConnection(conn)
+
+	client, err, dbConn := utils.GRPCServiceConnector(token, dbauth.NewDbAuthServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer closeConnection(dbConn)
+
+	clientRedis, err, redisConn := utils.GRPCServiceConnector(token, redis.NewRedisServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer closeConnection(redisConn)
+
+	response, err := call(ctx, utils.NewOidcClient(clientRedis, oidcHttpClient), client, token)
+	if err != nil {
+		httpStatus := utils.OidcErrorStatus(err)
+		message := err.Error()
+		if _, ok := status.FromError(err); ok {
+			message = status.Convert(err).Message()
+		}
+		utils.CreateError(w, httpStatus, "Ошибка входа через корпоративного провайдера", errors.New(message))
+		if httpStatus >= http.StatusInternalServerError {
+			if errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error()); errLogs != nil {
+				log.Printf("Ошибка сохранения лога: %v", errLogs)
+			}
+		}
+		return
+	}
+	if response == nil {
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, response); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
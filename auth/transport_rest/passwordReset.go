@@ -0,0 +1,188 @@
+package transport_rest
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	email "crmSystem/proto/email-service"
+	"crmSystem/proto/logs"
+	"crmSystem/proto/redis"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// forgotPasswordMessage одинаковый ответ на запрос сброса пароля,
+// по которому нельзя определить, зарегистрирован ли адрес.
+const forgotPasswordMessage = "Если адрес зарегистрирован, на него отправлено письмо со ссылкой для сброса пароля"
+
+// ForgotPassword принимает email и отправляет на него ссылку для сброса пароля.
+//
+// Ответ не зависит от того, существует ли пользователь: поиск пользователя и отправка
+// письма выполняются в фоне уже после ответа клиенту, поэтому по содержанию
+// и времени ответа нельзя определить зарегистрированные адреса.
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+
+	var req types.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка при декодировании данных", err)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка валидации", fmt.Errorf("поле 'Email' не прошло валидацию"))
+		return
+	}
+
+	go processForgotPassword(req.Email)
+
+	if err := utils.WriteJSON(w, http.StatusOK, types.MessageResponse{Message: forgotPasswordMessage}); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
+
+// processForgotPassword подключается к необходимым сервисам и отправляет ссылку для сброса пароля.
+// Выполняется в отдельной горутине, ошибки только записываются в логи.
+func processForgotPassword(address string) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Printf("Паника при отправке ссылки сброса пароля: %v", rec)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	token, err := utils.InternalJwtGenerator()
+	if err != nil {
+		log.Printf("Не удалось создать токен: %v", err)
+		return
+	}
+
+	// Устанавливаем соединение с gRPC сервером Logs
+	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу логов: %v", err)
+		return
+	}
+	defer closeConnection(conn)
+
+	saveError := func(err error) {
+		if errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error()); errLogs != nil {
+			log.Printf("Ошибка сохранения лога: %v", errLogs)
+		}
+	}
+
+	dbClient, err, dbConn := utils.GRPCServiceConnector(token, dbauth.NewDbAuthServiceClient)
+	if err != nil {
+		saveError(err)
+		return
+	}
+	defer closeConnection(dbConn)
+
+	redisClient, err, redisConn := utils.GRPCServiceConnector(token, redis.NewRedisServiceClient)
+	if err != nil {
+		saveError(err)
+		return
+	}
+	defer closeConnection(redisConn)
+
+	emailClient, err, emailConn := utils.GRPCServiceConnector(token, email.NewEmailServiceClient)
+	if err != nil {
+		saveError(err)
+		return
+	}
+	defer closeConnection(emailConn)
+
+	err = utils.SendPasswordResetLink(ctx, dbClient, utils.NewPasswordResetStore(redisClient), emailClient,
+		address, os.Getenv("PASSWORD_RESET_URL"))
+	if err != nil {
+		saveError(err)
+	}
+}
+
+// ResetPassword устанавливает новый пароль по одноразовому токену из письма.
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	var req types.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка при декодировании данных", err)
+		return
+	}
+
+	validate := validator.New()
+	if err := validate.RegisterValidation("password", validatePassword); err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Ошибка регистрации кастомного валидатора", err)
+		return
+	}
+	if err := validate.Struct(req); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) && len(validationErrors) > 0 {
+			err = fmt.Errorf("поле '%s' не прошло валидацию", validationErrors[0].Field())
+		}
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка валидации", err)
+		return
+	}
+
+	token, err := utils.InternalJwtGenerator()
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось создать токен", err)
+		return
+	}
+
+	// Устанавливаем соединение с gRPC сервером Logs
+	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer closeConnection(conn)
+
+	dbClient, err, dbConn := utils.GRPCServiceConnector(token, dbauth.NewDbAuthServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer closeConnection(dbConn)
+
+	redisClient, err, redisConn := utils.GRPCServiceConnector(token, redis.NewRedisServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer closeConnection(redisConn)
+
+	err = utils.ResetPasswordWithToken(ctx, utils.NewPasswordResetStore(redisClient), dbClient, req.Token, req.Password)
+	if errors.Is(err, utils.ErrResetTokenInvalid) {
+		utils.CreateError(w, http.StatusBadRequest, "Не удалось сбросить пароль", err)
+		return
+	}
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось сбросить пароль", err)
+		if errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error()); errLogs != nil {
+			log.Printf("Ошибка сохранения лога: %v", errLogs)
+		}
+		return
+	}
+
+	if err := utils.WriteJSON(w, http.StatusOK, types.MessageResponse{Message: "Пароль успешно изменён"}); err != nil {
+		log.Printf("Ошибка записи ответа: %v", err)
+	}
+}
+
+// closeConnection закрывает gRPC соединение, записывая ошибку в лог.
+func closeConnection(conn *grpc.ClientConn) {
+	if err := conn.Close(); err != nil {
+		log.Printf("Ошибка закрытия соединения: %v", err)
+	}
+}
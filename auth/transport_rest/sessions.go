@@ -0,0 +1,104 @@
+package transport_rest
+
+import (
+	"context"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"errors"
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Sessions возвращает активные сессии (входы) текущего пользователя.
+func (h *Handler) Sessions(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ParseAccessToken(r)
+	if err != nil {
+		utils.CreateError(w, http.StatusUnauthorized, "Пользователь не авторизован", err)
+		return
+	}
+
+	withRefreshStore(w, func(ctx context.Context, store *utils.RefreshStore) {
+		sessions, err := store.ListSessions(ctx, user)
+		if err != nil {
+			utils.CreateError(w, http.StatusInternalServerError, "Не удалось получить сессии", err)
+			return
+		}
+
+		response := types.SessionsResponse{Sessions: make([]types.SessionResponse, 0, len(sessions))}
+		for _, session := range sessions {
+			response.Sessions = append(response.Sessions, types.SessionResponse{
+				Id:         session.Id,
+				Device:     session.Device,
+				Ip:         session.Ip,
+				UserAgent:  session.UserAgent,
+				CreatedAt:  time.Unix(session.CreatedAt, 0).UTC(),
+				LastSeenAt: time.Unix(session.RotatedAt, 0).UTC(),
+				Current:    session.Id == user.SessionId,
+			})
+		}
+
+		if err := utils.WriteJSON(w, http.StatusOK, response); err != nil {
+			log.Printf("Ошибка записи ответа: %v", err)
+		}
+	})
+}
+
+// RevokeSession завершает сессию текущего пользователя по её ID.
+// Уже выданный access token сессии остаётся действительным до истечения срока.
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	user, err := utils.ParseAccessToken(r)
+	if err != nil {
+		utils.CreateError(w, http.StatusUnauthorized, "Пользователь не авторизован", err)
+		return
+	}
+	sessionId := mux.Vars(r)["id"]
+
+	withRefreshStore(w, func(ctx context.Context, store *utils.RefreshStore) {
+		err := store.RevokeUserSession(ctx, user, sessionId)
+		if errors.Is(err, utils.ErrSessionNotFound) {
+			utils.CreateError(w, http.StatusNotFound, "Сессия не найдена", err)
+			return
+		}
+		if err != nil {
+			utils.CreateError(w, http.StatusInternalServerError, "Не удалось завершить сессию", err)
+			return
+		}
+
+		// Завершение текущей сессии равносильно выходу
+		if sessionId == user.SessionId {
+			clearAuthCookies(w)
+		}
+
+		if err := utils.WriteJSON(w, http.StatusOK, types.MessageResponse{Message: "Сессия завершена"}); err != nil {
+			log.Printf("Ошибка записи ответа: %v", err)
+		}
+	})
+}
+
+// withRefreshStore подключается к хранилищу сессий и вызывает call. При ошибке подключения записывает ответ клиенту.
+func withRefreshStore(w http.ResponseWriter, call func(ctx context.Context, store *utils.RefreshStore)) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	token, err := utils.InternalJwtGenerator()
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Не удалось создать токен", err)
+		return
+	}
+
+	store, conn, err := connectRefreshStore(token)
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Ошибка подключения", err)
+		return
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}(conn)
+
+	call(ctx, store)
+}
@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 type RegisterAuthRequest struct {
 	Email       string `json:"email" validate:"required,email"`
 	Phone       string `json:"phone" validate:"omitempty,phone"`
@@ -10,6 +12,14 @@ type RegisterAuthRequest struct {
 }
 
 type RegisterAuthResponse struct {
+	Message        string `json:"message"`
+	ProvisioningId string `json:"provisioningId"` // Идентификатор для GET /auth/register/status
+	Status         string `json:"status"`         // pending, ready или failed
+}
+
+// RegisterStatusResponse состояние создания рабочего пространства компании после регистрации.
+type RegisterStatusResponse struct {
+	Status  string `json:"status"`
 	Message string `json:"message"`
 }
 
@@ -21,6 +31,7 @@ type LoginAuthRequest struct {
 
 type LoginAuthResponse struct {
 	Message string `json:"message"`
+	Mfa     string `json:"mfa,omitempty"` // "totp" - нужен код, "enroll" - нужно подключить TOTP
 }
 
 type SendEmailRequest struct {
@@ -36,3 +47,101 @@ type SendEmailResponse struct {
 type ErrorResponse struct {
 	Message string `json:"message"`
 }
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,password"`
+}
+
+// EmailOtpRequest запрос одноразового кода для входа без пароля.
+type EmailOtpRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// EmailOtpVerifyRequest вход по коду из письма.
+type EmailOtpVerifyRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Code  string `json:"code" validate:"required,numeric,len=6"`
+}
+
+// ActivateAccountRequest подтверждение email или принятие приглашения.
+// Пароль обязателен только для приглашённых пользователей.
+type ActivateAccountRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"omitempty,password"`
+}
+
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// MfaCodeRequest код TOTP из приложения или одноразовый код восстановления.
+type MfaCodeRequest struct {
+	Code string `json:"code" validate:"required,max=20"`
+}
+
+type TotpSetupResponse struct {
+	Secret          string `json:"secret"`          // Секрет для ручного ввода в приложение
+	ProvisioningUri string `json:"provisioningUri"` // URI otpauth:// для QR-кода
+}
+
+type TotpConfirmResponse struct {
+	Message       string   `json:"message"`
+	RecoveryCodes []string `json:"recoveryCodes"` // Показываются один раз, их нужно сохранить
+}
+
+// SessionResponse активная сессия (вход) пользователя.
+type SessionResponse struct {
+	Id         string    `json:"id"`
+	Device     string    `json:"device"`
+	Ip         string    `json:"ip"`
+	UserAgent  string    `json:"userAgent"`
+	CreatedAt  time.Time `json:"createdAt"`  // Время входа
+	LastSeenAt time.Time `json:"lastSeenAt"` // Время последнего обновления токенов
+	Current    bool      `json:"current"`    // Сессия, из которой выполнен запрос
+}
+
+type SessionsResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+}
+
+// OidcStartRequest email пользователя, по домену которого выбирается провайдер компании.
+type OidcStartRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type OidcStartResponse struct {
+	AuthorizationUrl string `json:"authorizationUrl"` // Адрес входа у провайдера, на который переходит пользователь
+}
+
+// CreateApiKeyRequest новый API ключ: области доступа вида "chats:read", "timers:write", "admin:read".
+type CreateApiKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"` // Не указано - ключ бессрочный
+}
+
+// ApiKeyResponse API ключ без секрета.
+type ApiKeyResponse struct {
+	Id         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // Начало ключа, по которому его можно узнать
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+}
+
+// CreateApiKeyResponse созданный ключ, значение Key показывается только один раз.
+type CreateApiKeyResponse struct {
+	ApiKeyResponse
+	Key string `json:"key"`
+}
+
+type ApiKeysResponse struct {
+	Keys []ApiKeyResponse `json:"keys"`
+}
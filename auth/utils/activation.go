@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	email "crmSystem/proto/email-service"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrActivationTokenInvalid возвращается, если ссылка активации не найдена, уже использована или истекла.
+	ErrActivationTokenInvalid = errors.New("ссылка активации недействительна или устарела")
+
+	// ErrActivationPasswordRequired возвращается при активации приглашения без пароля.
+	ErrActivationPasswordRequired = errors.New("для активации приглашения необходимо задать пароль")
+)
+
+// SendActivationLink отправляет на address ссылку для подтверждения email после регистрации компании.
+// Сам токен выдаёт dbservice, в redis хранится только его хэш.
+func SendActivationLink(ctx context.Context, emailClient email.EmailServiceClient,
+	address string, activationURL string, token string) error {
+
+	link := activationURL + "?token=" + url.QueryEscape(token)
+	_, err := emailClient.SendEmail(ctx, &email.SendEmailRequest{
+		Email:   address,
+		Message: "Подтверждение регистрации",
+		Body: fmt.Sprintf(
+			`Здравствуйте!
+
+Ваша компания зарегистрирована в CRM системе.
+Чтобы подтвердить email и начать работу, перейдите по ссылке: %s
+
+Ссылка действительна 24 часа и может быть использована один раз.
+Если вы не регистрировались, просто проигнорируйте это письмо.`,
+			link),
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка отправки письма: %v", err)
+	}
+
+	return nil
+}
+
+// ActivateAccount активирует учётную запись по токену из письма.
+// Для приглашённого пользователя password обязателен и становится его паролем.
+func ActivateAccount(ctx context.Context, dbClient dbauth.DbAuthServiceClient, token string, password string) error {
+	_, err := dbClient.ActivateAccount(ctx, &dbauth.ActivateAccountRequest{
+		Token:    token,
+		Password: password,
+	})
+	switch status.Code(err) {
+	case codes.OK:
+		return nil
+	case codes.NotFound:
+		return ErrActivationTokenInvalid
+	case codes.InvalidArgument:
+		return ErrActivationPasswordRequired
+	default:
+		return fmt.Errorf("ошибка активации учётной записи: %v", status.Convert(err).Message())
+	}
+}
+
+// ErrRegistrationNotFound возвращается, если регистрация с указанным идентификатором не найдена.
+var ErrRegistrationNotFound = errors.New("регистрация не найдена")
+
+// RegistrationStatus возвращает состояние создания рабочего пространства компании (pending, ready или failed)
+// и сообщение для клиента по идентификатору, выданному при регистрации.
+func RegistrationStatus(ctx context.Context, dbClient dbauth.DbAuthServiceClient, provisioningId string) (state string, message string, err error) {
+	res, err := dbClient.GetProvisioningStatus(ctx, &dbauth.GetProvisioningStatusRequest{
+		ProvisioningId: provisioningId,
+	})
+	switch status.Code(err) {
+	case codes.OK:
+		return res.Status, res.Message, nil
+	case codes.NotFound:
+		return "", "", ErrRegistrationNotFound
+	default:
+		return "", "", fmt.Errorf("ошибка получения состояния регистрации: %v", status.Convert(err).Message())
+	}
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ApiKeyAccessTokenTTL время жизни access токена, выпускаемого по API ключу для одного запроса.
+const ApiKeyAccessTokenTTL = time.Minute
+
+// apiKeyPrefix начало каждого API ключа, по нему ключ легко найти в логах и репозиториях.
+const apiKeyPrefix = "crm_"
+
+// Уровни доступа API ключа. Запись включает чтение.
+const (
+	ApiKeyAccessRead  = "read"
+	ApiKeyAccessWrite = "write"
+)
+
+// apiKeyAreas области доступа API ключей и пути REST API, которые они открывают.
+var apiKeyAreas = map[string]string{
+	"chats":  "/chats",
+	"timers": "/timer",
+	"admin":  "/admin",
+}
+
+var (
+	// ErrApiKeyMissing возвращается, если запрос не содержит заголовок Authorization: ApiKey.
+	ErrApiKeyMissing = errors.New("отсутствует API ключ")
+
+	// ErrApiKeyScope возвращается, если у ключа нет доступа к запрошенному разделу.
+	ErrApiKeyScope = errors.New("у API ключа нет доступа к этому разделу")
+)
+
+// GenerateApiKey создаёт новый API ключ и возвращает его, отображаемый префикс и хэш для хранения.
+func GenerateApiKey() (key string, prefix string, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", fmt.Errorf("не удалось сгенерировать API ключ: %w", err)
+	}
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, key[:len(apiKeyPrefix)+8], HashApiKey(key), nil
+}
+
+// HashApiKey возвращает SHA-256 хэш API ключа. Ключ случайный и длинный,
+// поэтому медленное хэширование, как для паролей, не требуется.
+func HashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ApiKeyFromRequest возвращает API ключ из заголовка "Authorization: ApiKey <ключ>".
+func ApiKeyFromRequest(r *http.Request) (string, error) {
+	scheme, key, found := strings.Cut(strings.TrimSpace(r.Header.Get("Authorization")), " ")
+	if !found || !strings.EqualFold(scheme, "ApiKey") {
+		return "", ErrApiKeyMissing
+	}
+	key = strings.TrimSpace(key)
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return "", ErrApiKeyMissing
+	}
+	return key, nil
+}
+
+// NormalizeApiKeyScopes проверяет области доступа вида "chats:read" или "timers:write",
+// удаляет повторы и возвращает их в порядке сортировки.
+func NormalizeApiKeyScopes(scopes []string) ([]string, error) {
+	unique := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		area, access, found := strings.Cut(scope, ":")
+		if _, ok := apiKeyAreas[area]; !ok || !found || (access != ApiKeyAccessRead && access != ApiKeyAccessWrite) {
+			return nil, fmt.Errorf("неизвестная область доступа %q", scope)
+		}
+		unique[scope] = true
+	}
+	if len(unique) == 0 {
+		return nil, fmt.Errorf("не указаны области доступа")
+	}
+
+	normalized := make([]string, 0, len(unique))
+	for scope := range unique {
+		normalized = append(normalized, scope)
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// RequiredApiKeyScope возвращает область доступа, необходимую для запроса method к пути uri.
+// Запросы GET, HEAD и OPTIONS требуют чтения, остальные - записи.
+func RequiredApiKeyScope(method string, uri string) (string, error) {
+	path, _, _ := strings.Cut(uri, "?")
+	for area, prefix := range apiKeyAreas {
+		if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+		switch method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			return area + ":" + ApiKeyAccessRead, nil
+		default:
+			return area + ":" + ApiKeyAccessWrite, nil
+		}
+	}
+	return "", ErrApiKeyScope
+}
+
+// ApiKeyAllows проверяет, что области доступа ключа scopes включают required.
+func ApiKeyAllows(scopes []string, required string) bool {
+	area, access, _ := strings.Cut(required, ":")
+	for _, scope := range scopes {
+		if scope == required || (access == ApiKeyAccessRead && scope == area+":"+ApiKeyAccessWrite) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApiKeyErrorStatus приводит ошибку проверки или управления API ключами к HTTP статусу.
+// NGINX auth_request различает только 401 и 403, остальные статусы считаются ошибкой сервера.
+func ApiKeyErrorStatus(err error) uint32 {
+	switch status.Code(err) {
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
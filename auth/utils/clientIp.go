@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIp возвращает IP адрес клиента. За nginx адрес берётся из заголовка X-Real-IP,
+// который nginx перезаписывает для каждого запроса, иначе - из адреса соединения.
+func ClientIp(r *http.Request) string {
+	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+		return ip
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
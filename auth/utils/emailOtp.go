@@ -0,0 +1,258 @@
+package utils
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	email "crmSystem/proto/email-service"
+	"crmSystem/proto/redis"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Ограничения входа по одноразовому коду из письма
+const (
+	EmailOtpTTL         = 10 * time.Minute // Время действия кода
+	EmailOtpMaxAttempts = 5                // Неверных вводов одного кода, после которых код удаляется
+	EmailOtpDigits      = 6
+
+	EmailOtpRequestWindow   = 15 * time.Minute // Окно ограничения частоты запросов
+	EmailOtpRequestsPerMail = 3                // Писем на один адрес за окно
+	EmailOtpRequestsPerIp   = 20               // Запросов кода с одного IP за окно
+	EmailOtpVerifiesPerIp   = 30               // Проверок кода с одного IP за окно
+)
+
+const (
+	emailOtpPrefix         = "emailOtp:"
+	emailOtpAttemptsPrefix = "emailOtpAttempts:"
+	emailOtpRateMailPrefix = "emailOtpRate:mail:"
+	emailOtpRateIpPrefix   = "emailOtpRate:ip:"
+	emailOtpVerifyIpPrefix = "emailOtpRate:verify:"
+)
+
+var (
+	// ErrEmailOtpInvalid возвращается, если код неверен, уже использован, истёк или исчерпаны попытки ввода.
+	ErrEmailOtpInvalid = errors.New("код недействителен или устарел, запросите новый код")
+
+	// ErrEmailOtpRateLimited возвращается, если превышена частота запросов кода.
+	ErrEmailOtpRateLimited = errors.New("слишком много запросов кода, попробуйте позже")
+)
+
+// emailOtpEntry выданный код: в redis хранится только SHA-256 хэш кода.
+type emailOtpEntry struct {
+	UserId   string `json:"userId"`
+	CodeHash string `json:"codeHash"`
+}
+
+// EmailOtpStore хранит одноразовые коды входа и счётчики попыток через gRPC сервис redis.
+//
+// Код действует EmailOtpTTL, новый запрос заменяет предыдущий код. Каждая проверка
+// увеличивает счётчик попыток, после EmailOtpMaxAttempts неверных вводов код удаляется.
+type EmailOtpStore struct {
+	client redis.RedisServiceClient
+}
+
+func NewEmailOtpStore(client redis.RedisServiceClient) *EmailOtpStore {
+	return &EmailOtpStore{client: client}
+}
+
+// AllowRequest учитывает запрос кода на address с адреса ip и проверяет ограничения частоты.
+// Ограничение действует одинаково для зарегистрированных и неизвестных адресов.
+func (s *EmailOtpStore) AllowRequest(ctx context.Context, address string, ip string) error {
+	if err := s.allow(ctx, emailOtpRateMailPrefix+emailOtpHash(address), EmailOtpRequestsPerMail); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return s.allow(ctx, emailOtpRateIpPrefix+ip, EmailOtpRequestsPerIp)
+}
+
+// AllowVerify учитывает проверку кода с адреса ip и проверяет ограничение частоты.
+func (s *EmailOtpStore) AllowVerify(ctx context.Context, ip string) error {
+	if ip == "" {
+		return nil
+	}
+	return s.allow(ctx, emailOtpVerifyIpPrefix+ip, EmailOtpVerifiesPerIp)
+}
+
+// allow увеличивает счётчик key в окне EmailOtpRequestWindow и возвращает ErrEmailOtpRateLimited,
+// если счётчик превысил limit.
+func (s *EmailOtpStore) allow(ctx context.Context, key string, limit int64) error {
+	res, err := s.client.Increment(ctx, &redis.IncrementRedisRequest{
+		Key:        key,
+		Expiration: int64(EmailOtpRequestWindow.Seconds()),
+	})
+	if err := redisError(res.GetStatus(), err); err != nil {
+		return fmt.Errorf("ошибка учёта запросов кода: %w", err)
+	}
+	if res.GetValue() > limit {
+		return ErrEmailOtpRateLimited
+	}
+	return nil
+}
+
+// Issue создаёт код входа для пользователя authUserId с email address и заменяет предыдущий код.
+func (s *EmailOtpStore) Issue(ctx context.Context, address string, authUserId string) (string, error) {
+	code, err := generateEmailOtp()
+	if err != nil {
+		return "", err
+	}
+
+	value, err := json.Marshal(emailOtpEntry{UserId: authUserId, CodeHash: emailOtpCodeHash(address, code)})
+	if err != nil {
+		return "", fmt.Errorf("не удалось сохранить код входа: %w", err)
+	}
+
+	res, err := s.client.Set(ctx, &redis.SaveRedisRequest{
+		Key:        emailOtpPrefix + emailOtpHash(address),
+		Value:      string(value),
+		Expiration: int64(EmailOtpTTL.Seconds()),
+	})
+	if err := redisError(res.GetStatus(), err); err != nil {
+		return "", fmt.Errorf("не удалось сохранить код входа: %w", err)
+	}
+
+	// Попытки ввода считаются для каждого кода заново
+	if _, err := s.client.Delete(ctx, &redis.DeleteRedisRequest{
+		Keys: []string{emailOtpAttemptsPrefix + emailOtpHash(address)},
+	}); err != nil {
+		return "", fmt.Errorf("не удалось сбросить счётчик попыток: %w", err)
+	}
+
+	return code, nil
+}
+
+// Verify проверяет код для address и удаляет его, возвращая ID пользователя.
+// Один код может быть использован только один раз.
+func (s *EmailOtpStore) Verify(ctx context.Context, address string, code string) (string, error) {
+	key := emailOtpPrefix + emailOtpHash(address)
+	attemptsKey := emailOtpAttemptsPrefix + emailOtpHash(address)
+
+	res, err := s.client.Get(ctx, &redis.GetRedisRequest{Key: key})
+	if err != nil {
+		return "", fmt.Errorf("ошибка проверки кода входа: %w", err)
+	}
+	if res.GetStatus() == http.StatusNotFound {
+		return "", ErrEmailOtpInvalid
+	}
+	if err := redisError(res.GetStatus(), nil); err != nil {
+		return "", fmt.Errorf("ошибка проверки кода входа: %w", err)
+	}
+
+	var entry emailOtpEntry
+	if err := json.Unmarshal([]byte(res.GetMessage()), &entry); err != nil {
+		return "", fmt.Errorf("ошибка разбора кода входа: %w", err)
+	}
+
+	// Попытка учитывается до сравнения, поэтому параллельные запросы не обходят ограничение
+	attempts, err := s.client.Increment(ctx, &redis.IncrementRedisRequest{
+		Key:        attemptsKey,
+		Expiration: int64(EmailOtpTTL.Seconds()),
+	})
+	if err := redisError(attempts.GetStatus(), err); err != nil {
+		return "", fmt.Errorf("ошибка учёта попыток ввода кода: %w", err)
+	}
+	if attempts.GetValue() > EmailOtpMaxAttempts {
+		s.discard(ctx, key, attemptsKey)
+		return "", ErrEmailOtpInvalid
+	}
+
+	if subtle.ConstantTimeCompare([]byte(entry.CodeHash), []byte(emailOtpCodeHash(address, code))) != 1 {
+		return "", ErrEmailOtpInvalid
+	}
+
+	// Код удаляется атомарно: из двух одновременных запросов с верным кодом войдёт только один
+	consumed, err := s.client.GetDel(ctx, &redis.GetRedisRequest{Key: key})
+	if err != nil {
+		return "", fmt.Errorf("ошибка проверки кода входа: %w", err)
+	}
+	if consumed.GetStatus() == http.StatusNotFound || consumed.GetMessage() != res.GetMessage() {
+		return "", ErrEmailOtpInvalid
+	}
+	s.discard(ctx, attemptsKey)
+
+	return entry.UserId, nil
+}
+
+// discard удаляет ключи кода, ошибка не мешает ответу: ключи удалятся по истечении времени жизни.
+func (s *EmailOtpStore) discard(ctx context.Context, keys ...string) {
+	_, _ = s.client.Delete(ctx, &redis.DeleteRedisRequest{Keys: keys})
+}
+
+// generateEmailOtp создаёт случайный код из EmailOtpDigits цифр.
+func generateEmailOtp() (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(EmailOtpDigits), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать код: %w", err)
+	}
+	return fmt.Sprintf("%0*d", EmailOtpDigits, n), nil
+}
+
+// NormalizeOtpEmail приводит email к виду, по которому хранятся код и счётчики.
+func NormalizeOtpEmail(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
+
+// emailOtpHash часть ключа redis для адреса, сам адрес в ключах не хранится.
+func emailOtpHash(address string) string {
+	sum := sha256.Sum256([]byte(NormalizeOtpEmail(address)))
+	return hex.EncodeToString(sum[:])
+}
+
+// emailOtpCodeHash хэш кода, привязанный к адресу.
+func emailOtpCodeHash(address string, code string) string {
+	sum := sha256.Sum256([]byte(NormalizeOtpEmail(address) + ":" + strings.TrimSpace(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// SendEmailOtp выдаёт код входа и отправляет его на address.
+//
+// Если пользователь не найден или его компания не разрешила вход по коду, письмо не отправляется
+// и ошибка не возвращается: вызывающий код не должен отличать этот случай от успешной отправки.
+func SendEmailOtp(ctx context.Context, dbClient dbauth.DbAuthServiceClient, store *EmailOtpStore,
+	emailClient email.EmailServiceClient, address string) error {
+
+	user, err := dbClient.FindEmailOtpUser(ctx, &dbauth.FindEmailOtpUserRequest{Email: address})
+	if code := status.Code(err); code == codes.NotFound || code == codes.FailedPrecondition {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка поиска пользователя: %v", status.Convert(err).Message())
+	}
+
+	code, err := store.Issue(ctx, address, user.GetUserId())
+	if err != nil {
+		return err
+	}
+
+	_, err = emailClient.SendEmail(ctx, &email.SendEmailRequest{
+		Email:   address,
+		Message: "Код для входа",
+		Body: fmt.Sprintf(
+			`Здравствуйте!
+
+Ваш код для входа: %s
+
+Код действителен %d минут и может быть использован один раз.
+Если вы не запрашивали вход, просто проигнорируйте это письмо.`,
+			code, int(EmailOtpTTL.Minutes())),
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка отправки письма: %v", err)
+	}
+
+	return nil
+}
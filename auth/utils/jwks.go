@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"crmSystem/jwks"
+	"sync"
+)
+
+const (
+	// publicKeyFile публичный ключ текущей пары, им проверяются токены без kid, выпущенные до ротации.
+	publicKeyFile = "./opensslkeys/public_key.pem"
+
+	// jwksKeysDir дополнительные публичные ключи (*.pem), которые остаются действительными во время ротации:
+	// предыдущий ключ до истечения выпущенных им токенов или следующий ключ до переключения подписи.
+	jwksKeysDir = "./opensslkeys/jwks"
+)
+
+var (
+	verificationKeysOnce sync.Once
+	verificationKeys     *jwks.KeySet
+	verificationKeysErr  error
+)
+
+// VerificationKeys загружает действующие ключи проверки токенов (один раз за время работы):
+// ключ текущей подписи, public_key.pem и ключи из каталога opensslkeys/jwks.
+func VerificationKeys() (*jwks.KeySet, error) {
+	verificationKeysOnce.Do(func() {
+		keys, err := jwks.LoadKeyFiles(publicKeyFile, jwksKeysDir)
+		if err != nil {
+			verificationKeysErr = err
+			return
+		}
+
+		// Ключ подписи публикуется сразу, даже если public_key.pem ещё не заменён
+		if signingKey, err := loadPrivateKey(); err == nil {
+			keys = append(keys, &signingKey.PublicKey)
+		}
+
+		verificationKeys = jwks.NewKeySet("", nil, keys...)
+	})
+	return verificationKeys, verificationKeysErr
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"context"
+	"crmSystem/proto/redis"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MfaErrorStatus приводит ошибку dbservice при проверке второго фактора к HTTP статусу.
+//
+// Неверный код возвращает 401, блокировка после слишком многих неверных кодов возвращает 429, нарушение состояния 2FA (уже подключена, не подключена,
+// обязательна в компании) возвращает 409, остальные ошибки считаются внутренними.
+func MfaErrorStatus(err error) uint32 {
+	switch status.Code(err) {
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.FailedPrecondition:
+		return http.StatusConflict
+	case codes.NotFound, codes.InvalidArgument:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// mfaTokenUsedPrefix ключ redis погашенного токена ожидания второго фактора: mfaTokenUsed:<jti>.
+const mfaTokenUsedPrefix = "mfaTokenUsed:"
+
+// ErrMfaTokenUsed возвращается при предъявлении погашенного токена ожидания второго фактора.
+var ErrMfaTokenUsed = errors.New("токен второго фактора уже использован, войдите заново")
+
+// MfaTokenStore хранит погашенные токены ожидания второго фактора через gRPC сервис redis.
+//
+// Токен гасится после завершения входа и после блокировки проверки кодов, поэтому
+// один токен нельзя использовать для повторного входа или для продолжения подбора кода.
+// Запись хранится, пока токен не истечёт.
+type MfaTokenStore struct {
+	client redis.RedisServiceClient
+}
+
+func NewMfaTokenStore(client redis.RedisServiceClient) *MfaTokenStore {
+	return &MfaTokenStore{client: client}
+}
+
+// Check возвращает ErrMfaTokenUsed, если токен tokenId уже погашен.
+func (s *MfaTokenStore) Check(ctx context.Context, tokenId string) error {
+	res, err := s.client.Get(ctx, &redis.GetRedisRequest{Key: mfaTokenUsedPrefix + tokenId})
+	if err != nil {
+		return fmt.Errorf("не удалось проверить токен второго фактора: %w", err)
+	}
+	if res.GetStatus() == http.StatusOK {
+		return ErrMfaTokenUsed
+	}
+	return nil
+}
+
+// Consume гасит токен tokenId. Если токен уже был погашен параллельным запросом, возвращается ErrMfaTokenUsed.
+func (s *MfaTokenStore) Consume(ctx context.Context, tokenId string) error {
+	res, err := s.client.Save(ctx, &redis.SaveRedisRequest{
+		Key:        mfaTokenUsedPrefix + tokenId,
+		Value:      "1",
+		Expiration: int64(MfaPendingTokenTTL.Seconds()),
+	})
+	if err != nil {
+		return fmt.Errorf("не удалось погасить токен второго фактора: %w", err)
+	}
+	switch res.GetStatus() {
+	case http.StatusOK:
+		return nil
+	case http.StatusConflict:
+		return ErrMfaTokenUsed
+	default:
+		return fmt.Errorf("не удалось погасить токен второго фактора: redis сервис вернул статус %d", res.GetStatus())
+	}
+}
@@ -0,0 +1,448 @@
+package utils
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	"crmSystem/proto/redis"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// OidcStateTTL время, за которое пользователь должен завершить вход у провайдера.
+const OidcStateTTL = 10 * time.Minute
+
+const oidcStatePrefix = "oidcState:"
+
+// OidcStateCookie cookie, которое связывает начатый вход с браузером пользователя.
+// В cookie хранится хэш state, сам state передаётся только провайдеру.
+const OidcStateCookie = "oidc_state"
+
+var (
+	// ErrOidcStateInvalid возвращается, если параметр state не найден, уже использован или истёк.
+	ErrOidcStateInvalid = errors.New("вход через провайдера не начат или устарел, начните вход заново")
+
+	// ErrOidcProvider возвращается при ошибке обращения к провайдеру или недействительном ответе провайдера.
+	ErrOidcProvider = errors.New("ошибка корпоративного провайдера")
+)
+
+// OidcIdentity пользователь, подтверждённый провайдером в ID токене.
+type OidcIdentity struct {
+	CompanyId     string // Компания, провайдер которой подтвердил пользователя
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// oidcDiscovery необходимые поля документа /.well-known/openid-configuration.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksUri               string `json:"jwks_uri"`
+}
+
+// oidcState данные начатого входа, сохраняемые в redis до возврата пользователя от провайдера.
+type oidcState struct {
+	Domain   string `json:"domain"`
+	Verifier string `json:"verifier"` // code_verifier PKCE, провайдер получает только его хэш
+	Nonce    string `json:"nonce"`
+}
+
+// oidcIdTokenClaims claims ID токена, используемые при входе.
+type oidcIdTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified oidcBool `json:"email_verified"`
+	Azp           string   `json:"azp"`
+}
+
+// oidcBool логическое значение claim, которое часть провайдеров передаёт строкой "true".
+type oidcBool bool
+
+func (b *oidcBool) UnmarshalJSON(data []byte) error {
+	*b = oidcBool(strings.Trim(string(data), `"`) == "true")
+	return nil
+}
+
+// OidcClient выполняет вход через OpenID Connect провайдера компании
+// по схеме authorization code с PKCE (RFC 7636).
+type OidcClient struct {
+	redis      redis.RedisServiceClient
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+func NewOidcClient(redisClient redis.RedisServiceClient, httpClient *http.Client) *OidcClient {
+	return &OidcClient{redis: redisClient, httpClient: httpClient, now: time.Now}
+}
+
+// Start выбирает провайдера по домену email пользователя и возвращает адрес,
+// на который нужно перенаправить пользователя для входа, и state входа для cookie OidcStateCookie.
+// redirectURL - адрес /auth/oidc/callback, зарегистрированный у провайдера.
+func (c *OidcClient) Start(ctx context.Context, dbClient dbauth.DbAuthServiceClient, email string,
+	redirectURL string) (authorizationUrl string, state string, err error) {
+
+	at := strings.LastIndex(email, "@")
+	if at <= 0 || at == len(email)-1 {
+		return "", "", status.Errorf(codes.InvalidArgument, "некорректный email")
+	}
+	domain := strings.ToLower(strings.TrimSpace(email[at+1:]))
+
+	provider, err := dbClient.GetOidcProvider(ctx, &dbauth.GetOidcProviderRequest{Domain: domain})
+	if err != nil {
+		return "", "", err
+	}
+
+	discovery, err := c.discover(ctx, provider.Issuer)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err = randomOidcValue()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := randomOidcValue()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomOidcValue()
+	if err != nil {
+		return "", "", err
+	}
+
+	data, err := json.Marshal(oidcState{Domain: domain, Verifier: verifier, Nonce: nonce})
+	if err != nil {
+		return "", "", fmt.Errorf("ошибка сохранения состояния входа: %w", err)
+	}
+	res, err := c.redis.Save(ctx, &redis.SaveRedisRequest{
+		Key:        oidcStatePrefix + state,
+		Value:      string(data),
+		Expiration: int64(OidcStateTTL.Seconds()),
+	})
+	if err := redisError(res.GetStatus(), err); err != nil {
+		return "", "", fmt.Errorf("ошибка сохранения состояния входа: %w", err)
+	}
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.ClientId},
+		"redirect_uri":          {redirectURL},
+		"scope":                 {"openid email profile"},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {PkceChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+		"login_hint":            {email},
+	}
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), state, nil
+}
+
+// Finish обменивает код авторизации на ID токен и возвращает подтверждённого провайдером пользователя.
+// Параметр state используется один раз: повторный вызов с тем же state возвращает ErrOidcStateInvalid.
+func (c *OidcClient) Finish(ctx context.Context, dbClient dbauth.DbAuthServiceClient, state string, code string,
+	redirectURL string) (*OidcIdentity, error) {
+
+	res, err := c.redis.GetDel(ctx, &redis.GetRedisRequest{Key: oidcStatePrefix + state})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка проверки состояния входа: %w", err)
+	}
+	if res.GetStatus() == http.StatusNotFound || state == "" {
+		return nil, ErrOidcStateInvalid
+	}
+	if err := redisError(res.GetStatus(), nil); err != nil {
+		return nil, fmt.Errorf("ошибка проверки состояния входа: %w", err)
+	}
+
+	var saved oidcState
+	if err := json.Unmarshal([]byte(res.GetMessage()), &saved); err != nil {
+		return nil, ErrOidcStateInvalid
+	}
+
+	// Секрет клиента не хранится в redis, настройки провайдера запрашиваются повторно
+	provider, err := dbClient.GetOidcProvider(ctx, &dbauth.GetOidcProviderRequest{Domain: saved.Domain})
+	if err != nil {
+		return nil, err
+	}
+
+	discovery, err := c.discover(ctx, provider.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	rawIdToken, err := c.exchangeCode(ctx, discovery, provider, code, saved.Verifier, redirectURL)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := c.verifyIdToken(ctx, discovery, provider.ClientId, rawIdToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Nonce != saved.Nonce {
+		return nil, fmt.Errorf("%w: nonce ID токена не совпадает", ErrOidcProvider)
+	}
+
+	return &OidcIdentity{
+		CompanyId:     provider.CompanyId,
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+	}, nil
+}
+
+// OidcStateBinding возвращает значение cookie OidcStateCookie для state.
+func OidcStateBinding(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// CheckOidcStateCookie проверяет, что вход с параметром state начат в этом же браузере.
+// Без проверки злоумышленник может подставить жертве ссылку с кодом своего входа (login CSRF),
+// и жертва окажется в чужой учётной записи.
+func CheckOidcStateCookie(r *http.Request, state string) error {
+	cookie, err := r.Cookie(OidcStateCookie)
+	if err != nil || state == "" {
+		return ErrOidcStateInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(OidcStateBinding(state))) != 1 {
+		return ErrOidcStateInvalid
+	}
+	return nil
+}
+
+// OidcLoginRedirect возвращает адрес фронтенда, на который пользователь возвращается после входа.
+// Если для входа нужен второй фактор, способ передаётся в параметре mfa: вместо сессии выдан
+// токен ожидания, и фронтенд завершает вход через /auth/mfa/*.
+func OidcLoginRedirect(redirectUrl string, mfa string) (string, error) {
+	if mfa == "" {
+		return redirectUrl, nil
+	}
+
+	parsed, err := url.Parse(redirectUrl)
+	if err != nil {
+		return "", fmt.Errorf("неверный адрес OIDC_LOGIN_REDIRECT_URL: %w", err)
+	}
+	query := parsed.Query()
+	query.Set("mfa", mfa)
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), nil
+}
+
+// PkceChallenge возвращает code_challenge метода S256 для code_verifier.
+func PkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// OidcErrorStatus приводит ошибку входа через провайдера к HTTP статусу.
+//
+// Домен без провайдера возвращает 404, пользователь без учётной записи или с неподтверждённым email - 403,
+// устаревший state - 400, ошибки провайдера - 502, остальные ошибки считаются внутренними.
+func OidcErrorStatus(err error) uint32 {
+	switch {
+	case errors.Is(err, ErrOidcStateInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrOidcProvider):
+		return http.StatusBadGateway
+	}
+
+	switch status.Code(err) {
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// discover загружает документ конфигурации провайдера и проверяет, что он выдан тем же issuer.
+func (c *OidcClient) discover(ctx context.Context, issuer string) (*oidcDiscovery, error) {
+	var discovery oidcDiscovery
+	if err := c.getJSON(ctx, strings.TrimSuffix(issuer, "/")+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, err
+	}
+	if discovery.Issuer != issuer {
+		return nil, fmt.Errorf("%w: issuer %q не совпадает с настройками компании", ErrOidcProvider, discovery.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JwksUri == "" {
+		return nil, fmt.Errorf("%w: в конфигурации провайдера отсутствуют необходимые адреса", ErrOidcProvider)
+	}
+	return &discovery, nil
+}
+
+// exchangeCode обменивает код авторизации на ID токен. Клиент аутентифицируется методом client_secret_basic.
+func (c *OidcClient) exchangeCode(ctx context.Context, discovery *oidcDiscovery, provider *dbauth.GetOidcProviderResponse,
+	code string, verifier string, redirectURL string) (string, error) {
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrOidcProvider, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(provider.ClientId), url.QueryEscape(provider.ClientSecret))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrOidcProvider, err)
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		IdToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil {
+		return "", fmt.Errorf("%w: некорректный ответ на запрос токена: %v", ErrOidcProvider, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: код авторизации отклонён: %s %s", ErrOidcProvider, token.Error, token.ErrorDescription)
+	}
+	if token.IdToken == "" {
+		return "", fmt.Errorf("%w: провайдер не вернул ID токен", ErrOidcProvider)
+	}
+	return token.IdToken, nil
+}
+
+// verifyIdToken проверяет подпись ID токена ключами провайдера, issuer, audience и срок действия.
+func (c *OidcClient) verifyIdToken(ctx context.Context, discovery *oidcDiscovery, clientId string,
+	rawIdToken string) (*oidcIdTokenClaims, error) {
+
+	keys, err := c.fetchJwks(ctx, discovery.JwksUri)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &oidcIdTokenClaims{}
+	_, err = jwt.ParseWithClaims(rawIdToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := keys[kid]; ok {
+			return key, nil
+		}
+		if kid == "" && len(keys) == 1 {
+			for _, key := range keys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("ключ %q не найден у провайдера", kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(discovery.Issuer),
+		jwt.WithAudience(clientId),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+		jwt.WithTimeFunc(c.now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: недействительный ID токен: %v", ErrOidcProvider, err)
+	}
+
+	// При нескольких получателях токен должен быть выдан именно CRM
+	if len(claims.Audience) > 1 && claims.Azp != clientId {
+		return nil, fmt.Errorf("%w: ID токен выдан другому клиенту", ErrOidcProvider)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: в ID токене отсутствует sub", ErrOidcProvider)
+	}
+	return claims, nil
+}
+
+// fetchJwks загружает RSA ключи подписи провайдера по их kid.
+func (c *OidcClient) fetchJwks(ctx context.Context, jwksUri string) (map[string]*rsa.PublicKey, error) {
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := c.getJSON(ctx, jwksUri, &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, key := range jwks.Keys {
+		if key.Kty != "RSA" || (key.Use != "" && key.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(key.N)
+		e, errE := base64.RawURLEncoding.DecodeString(key.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+		keys[key.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: у провайдера нет ключей подписи RSA", ErrOidcProvider)
+	}
+	return keys, nil
+}
+
+// getJSON выполняет GET запрос к провайдеру и разбирает JSON ответ в v.
+func (c *OidcClient) getJSON(ctx context.Context, address string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrOidcProvider, err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrOidcProvider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s вернул статус %d", ErrOidcProvider, address, resp.StatusCode)
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v); err != nil {
+		return fmt.Errorf("%w: некорректный ответ %s: %v", ErrOidcProvider, address, err)
+	}
+	return nil
+}
+
+// randomOidcValue создаёт случайное значение для state, nonce и code_verifier.
+func randomOidcValue() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать случайное значение: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	email "crmSystem/proto/email-service"
+	"crmSystem/proto/redis"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PasswordResetTTL время действия ссылки для сброса пароля.
+const PasswordResetTTL = 30 * time.Minute
+
+const passwordResetPrefix = "passwordReset:"
+
+// ErrResetTokenInvalid возвращается, если токен сброса пароля не найден, уже использован или истёк.
+var ErrResetTokenInvalid = errors.New("ссылка для сброса пароля недействительна или устарела")
+
+// PasswordResetStore хранит одноразовые токены сброса пароля через gRPC сервис redis.
+//
+// В redis сохраняется только SHA-256 хэш токена, сам токен есть лишь в письме пользователю.
+type PasswordResetStore struct {
+	client redis.RedisServiceClient
+}
+
+func NewPasswordResetStore(client redis.RedisServiceClient) *PasswordResetStore {
+	return &PasswordResetStore{client: client}
+}
+
+// Issue создаёт токен сброса пароля для пользователя authUserId.
+func (s *PasswordResetStore) Issue(ctx context.Context, authUserId string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать токен: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	res, err := s.client.Save(ctx, &redis.SaveRedisRequest{
+		Key:        passwordResetKey(token),
+		Value:      authUserId,
+		Expiration: int64(PasswordResetTTL.Seconds()),
+	})
+	if err := redisError(res.GetStatus(), err); err != nil {
+		return "", fmt.Errorf("не удалось сохранить токен сброса пароля: %w", err)
+	}
+
+	return token, nil
+}
+
+// Consume проверяет токен и удаляет его, возвращая ID пользователя.
+// Один токен может быть использован только один раз.
+func (s *PasswordResetStore) Consume(ctx context.Context, token string) (string, error) {
+	res, err := s.client.GetDel(ctx, &redis.GetRedisRequest{Key: passwordResetKey(token)})
+	if err != nil {
+		return "", fmt.Errorf("ошибка проверки токена сброса пароля: %w", err)
+	}
+	if res.GetStatus() == http.StatusNotFound {
+		return "", ErrResetTokenInvalid
+	}
+	if err := redisError(res.GetStatus(), nil); err != nil {
+		return "", fmt.Errorf("ошибка проверки токена сброса пароля: %w", err)
+	}
+	return res.GetMessage(), nil
+}
+
+// passwordResetKey ключ redis для токена сброса пароля.
+func passwordResetKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return passwordResetPrefix + hex.EncodeToString(sum[:])
+}
+
+// SendPasswordResetLink выдаёт токен сброса пароля и отправляет ссылку на address.
+//
+// Если пользователь с таким email не найден, письмо не отправляется и ошибка не возвращается:
+// вызывающий код не должен отличать этот случай от успешной отправки.
+func SendPasswordResetLink(ctx context.Context, dbClient dbauth.DbAuthServiceClient, store *PasswordResetStore,
+	emailClient email.EmailServiceClient, address string, resetURL string) error {
+
+	user, err := dbClient.FindAuthUser(ctx, &dbauth.FindAuthUserRequest{Email: address})
+	if status.Code(err) == codes.NotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка поиска пользователя: %v", status.Convert(err).Message())
+	}
+
+	token, err := store.Issue(ctx, user.GetUserId())
+	if err != nil {
+		return err
+	}
+
+	link := resetURL + "?token=" + url.QueryEscape(token)
+	_, err = emailClient.SendEmail(ctx, &email.SendEmailRequest{
+		Email:   address,
+		Message: "Восстановление пароля",
+		Body: fmt.Sprintf(
+			`Здравствуйте!
+
+Для вашей учётной записи был запрошен сброс пароля.
+Чтобы задать новый пароль, перейдите по ссылке: %s
+
+Ссылка действительна %d минут и может быть использована один раз.
+Если вы не запрашивали сброс пароля, просто проигнорируйте это письмо.`,
+			link, int(PasswordResetTTL.Minutes())),
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка отправки письма: %v", err)
+	}
+
+	return nil
+}
+
+// ResetPasswordWithToken проверяет одноразовый токен и устанавливает новый пароль пользователю.
+func ResetPasswordWithToken(ctx context.Context, store *PasswordResetStore, dbClient dbauth.DbAuthServiceClient,
+	token string, password string) error {
+
+	authUserId, err := store.Consume(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	_, err = dbClient.ResetPassword(ctx, &dbauth.ResetPasswordRequest{
+		UserId:   authUserId,
+		Password: password,
+	})
+	if status.Code(err) == codes.NotFound {
+		// Пользователь удалён после выдачи токена
+		return ErrResetTokenInvalid
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка обновления пароля: %v", status.Convert(err).Message())
+	}
+
+	return nil
+}
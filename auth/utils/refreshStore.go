@@ -0,0 +1,345 @@
+package utils
+
+import (
+	"context"
+	"crmSystem/proto/redis"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Ключи redis для хранения сессий:
+// - refreshSession:<sid> - данные сессии (семейства refresh токенов) и ID текущего токена;
+// - refreshToken:<jti> - ID сессии, к которой относится ещё не использованный refresh token;
+// - refreshSessions:<database>:<userId> - множество ID активных сессий пользователя.
+const (
+	refreshSessionPrefix  = "refreshSession:"
+	refreshTokenPrefix    = "refreshToken:"
+	refreshSessionsPrefix = "refreshSessions:"
+)
+
+var (
+	// ErrRefreshTokenReused возвращается при повторном предъявлении уже использованного refresh токена.
+	// Вся сессия при этом отзывается.
+	ErrRefreshTokenReused = errors.New("refresh token уже был использован, сессия отозвана")
+
+	// ErrSessionRevoked возвращается, если сессия токена была завершена или истекла.
+	ErrSessionRevoked = errors.New("сессия завершена")
+
+	// ErrSessionNotFound возвращается, если у пользователя нет активной сессии с указанным ID.
+	ErrSessionNotFound = errors.New("сессия не найдена")
+)
+
+// SessionClient данные клиента, с которого выполнен вход.
+type SessionClient struct {
+	Ip        string
+	UserAgent string
+}
+
+// SessionClientFromRequest возвращает данные клиента из HTTP запроса.
+func SessionClientFromRequest(r *http.Request) SessionClient {
+	return SessionClient{
+		Ip:        ClientIp(r),
+		UserAgent: r.UserAgent(),
+	}
+}
+
+// RefreshSession данные сессии, сохраняемые в redis.
+type RefreshSession struct {
+	Database  string `json:"database"`
+	UserId    string `json:"user_id"`
+	CompanyId string `json:"company_id"`
+	TokenId   string `json:"token_id"`   // jti текущего refresh токена сессии
+	CreatedAt int64  `json:"created_at"` // Время входа (unix)
+	RotatedAt int64  `json:"rotated_at"` // Время последнего обновления токена (unix)
+	Ip        string `json:"ip"`         // IP адрес клиента при входе
+	UserAgent string `json:"user_agent"` // User-Agent клиента при входе
+	Device    string `json:"device"`     // Описание устройства, полученное из User-Agent
+}
+
+// ActiveSession активная сессия пользователя для просмотра списка входов.
+type ActiveSession struct {
+	Id string
+	RefreshSession
+}
+
+// RefreshStore хранит выданные refresh токены через gRPC сервис redis.
+//
+// Каждый вход создаёт сессию (семейство токенов). При обновлении токен сессии
+// заменяется новым, а старый становится недействительным. Повторное использование
+// старого токена означает его кражу, поэтому вся сессия отзывается.
+type RefreshStore struct {
+	client redis.RedisServiceClient
+}
+
+func NewRefreshStore(client redis.RedisServiceClient) *RefreshStore {
+	return &RefreshStore{client: client}
+}
+
+// StartSession создаёт новую сессию пользователя и возвращает её ID и ID первого refresh токена.
+// Данные клиента сохраняются в сессии для просмотра списка активных входов.
+func (s *RefreshStore) StartSession(ctx context.Context, user UserClaims, client SessionClient) (sessionId string, tokenId string, err error) {
+	sessionId, err = newTokenId()
+	if err != nil {
+		return "", "", err
+	}
+	tokenId, err = newTokenId()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now().Unix()
+	session := &RefreshSession{
+		Database:  user.Database,
+		UserId:    user.UserId,
+		CompanyId: user.CompanyId,
+		TokenId:   tokenId,
+		CreatedAt: now,
+		RotatedAt: now,
+		Ip:        client.Ip,
+		UserAgent: client.UserAgent,
+		Device:    DeviceFromUserAgent(client.UserAgent),
+	}
+
+	if err := s.saveSession(ctx, sessionId, session); err != nil {
+		return "", "", err
+	}
+
+	return sessionId, tokenId, nil
+}
+
+// Rotate помечает refresh токен tokenId использованным и выдаёт ID нового токена той же сессии.
+//
+// Если токен уже был использован, сессия отзывается и возвращается ErrRefreshTokenReused.
+// Если сессия завершена, возвращается ErrSessionRevoked.
+func (s *RefreshStore) Rotate(ctx context.Context, user UserClaims, tokenId string) (string, error) {
+
+	// Токен забирается атомарно, поэтому обновить сессию по нему можно только один раз
+	res, err := s.client.GetDel(ctx, &redis.GetRedisRequest{Key: refreshTokenPrefix + tokenId})
+	if err != nil {
+		return "", fmt.Errorf("ошибка получения refresh токена: %w", err)
+	}
+
+	if res.GetStatus() == http.StatusNotFound || res.GetMessage() != user.SessionId {
+		if err := s.RevokeSession(ctx, user, user.SessionId); err != nil {
+			return "", err
+		}
+		return "", ErrRefreshTokenReused
+	}
+	if err := redisError(res.GetStatus(), nil); err != nil {
+		return "", fmt.Errorf("ошибка получения refresh токена: %w", err)
+	}
+
+	session, err := s.getSession(ctx, user.SessionId)
+	if err != nil {
+		return "", err
+	}
+
+	newTokenId, err := newTokenId()
+	if err != nil {
+		return "", err
+	}
+
+	session.TokenId = newTokenId
+	session.RotatedAt = time.Now().Unix()
+	if err := s.saveSession(ctx, user.SessionId, session); err != nil {
+		return "", err
+	}
+
+	return newTokenId, nil
+}
+
+// RevokeSession завершает сессию пользователя: ни один её refresh токен больше не будет принят.
+func (s *RefreshStore) RevokeSession(ctx context.Context, user UserClaims, sessionId string) error {
+	keys := []string{refreshSessionPrefix + sessionId}
+
+	// Удаляем и текущий токен сессии, если сессия ещё существует
+	session, err := s.getSession(ctx, sessionId)
+	if err == nil {
+		keys = append(keys, refreshTokenPrefix+session.TokenId)
+	} else if !errors.Is(err, ErrSessionRevoked) {
+		return err
+	}
+
+	delRes, err := s.client.Delete(ctx, &redis.DeleteRedisRequest{Keys: keys})
+	if err := redisError(delRes.GetStatus(), err); err != nil {
+		return fmt.Errorf("не удалось отозвать сессию: %w", err)
+	}
+
+	setRes, err := s.client.SetRemove(ctx, &redis.SetRedisRequest{
+		Key:     userSessionsKey(user),
+		Members: []string{sessionId},
+	})
+	if err := redisError(setRes.GetStatus(), err); err != nil {
+		return fmt.Errorf("не удалось отозвать сессию: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAllSessions завершает все сессии пользователя (выход на всех устройствах).
+// Возвращает количество завершённых сессий.
+func (s *RefreshStore) RevokeAllSessions(ctx context.Context, user UserClaims) (int, error) {
+	res, err := s.client.SetMembers(ctx, &redis.GetRedisRequest{Key: userSessionsKey(user)})
+	if err := redisError(res.GetStatus(), err); err != nil {
+		return 0, fmt.Errorf("не удалось получить сессии пользователя: %w", err)
+	}
+
+	revoked := 0
+	for _, sessionId := range res.GetMembers() {
+		if err := s.RevokeSession(ctx, user, sessionId); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+
+	delRes, err := s.client.Delete(ctx, &redis.DeleteRedisRequest{Keys: []string{userSessionsKey(user)}})
+	if err := redisError(delRes.GetStatus(), err); err != nil {
+		return revoked, fmt.Errorf("не удалось удалить список сессий: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// ListSessions возвращает активные сессии пользователя, начиная с последней активной.
+// Истёкшие сессии удаляются из списка сессий пользователя.
+func (s *RefreshStore) ListSessions(ctx context.Context, user UserClaims) ([]ActiveSession, error) {
+	res, err := s.client.SetMembers(ctx, &redis.GetRedisRequest{Key: userSessionsKey(user)})
+	if err := redisError(res.GetStatus(), err); err != nil {
+		return nil, fmt.Errorf("не удалось получить сессии пользователя: %w", err)
+	}
+
+	sessions := make([]ActiveSession, 0, len(res.GetMembers()))
+	var expired []string
+	for _, sessionId := range res.GetMembers() {
+		session, err := s.getSession(ctx, sessionId)
+		if errors.Is(err, ErrSessionRevoked) {
+			expired = append(expired, sessionId)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, ActiveSession{Id: sessionId, RefreshSession: *session})
+	}
+
+	if len(expired) > 0 {
+		setRes, err := s.client.SetRemove(ctx, &redis.SetRedisRequest{Key: userSessionsKey(user), Members: expired})
+		if err := redisError(setRes.GetStatus(), err); err != nil {
+			return nil, fmt.Errorf("не удалось удалить истёкшие сессии: %w", err)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].RotatedAt > sessions[j].RotatedAt
+	})
+	return sessions, nil
+}
+
+// RevokeUserSession завершает сессию sessionId, если она принадлежит пользователю user.
+// Возвращает ErrSessionNotFound, если такой активной сессии у пользователя нет.
+func (s *RefreshStore) RevokeUserSession(ctx context.Context, user UserClaims, sessionId string) error {
+	session, err := s.getSession(ctx, sessionId)
+	if errors.Is(err, ErrSessionRevoked) {
+		return ErrSessionNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if session.Database != user.Database || session.UserId != user.UserId {
+		return ErrSessionNotFound
+	}
+	return s.RevokeSession(ctx, user, sessionId)
+}
+
+// getSession загружает данные сессии. Возвращает ErrSessionRevoked, если сессии нет.
+func (s *RefreshStore) getSession(ctx context.Context, sessionId string) (*RefreshSession, error) {
+	res, err := s.client.Get(ctx, &redis.GetRedisRequest{Key: refreshSessionPrefix + sessionId})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения сессии: %w", err)
+	}
+	if res.GetStatus() == http.StatusNotFound {
+		return nil, ErrSessionRevoked
+	}
+	if err := redisError(res.GetStatus(), nil); err != nil {
+		return nil, fmt.Errorf("ошибка получения сессии: %w", err)
+	}
+
+	session := &RefreshSession{}
+	if err := json.Unmarshal([]byte(res.GetMessage()), session); err != nil {
+		return nil, fmt.Errorf("повреждены данные сессии: %w", err)
+	}
+	return session, nil
+}
+
+// saveSession сохраняет данные сессии и ID её текущего токена, продлевая их на время жизни refresh токена.
+// Множество сессий пользователя продлевается вместе с ними, иначе при обновлении токенов оно истекает
+// раньше сессии, и такую сессию не видят список сессий и выход со всех устройств.
+func (s *RefreshStore) saveSession(ctx context.Context, sessionId string, session *RefreshSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	expiration := int64(RefreshTokenTTL.Seconds())
+
+	res, err := s.client.Set(ctx, &redis.SaveRedisRequest{
+		Key:        refreshSessionPrefix + sessionId,
+		Value:      string(data),
+		Expiration: expiration,
+	})
+	if err := redisError(res.GetStatus(), err); err != nil {
+		return fmt.Errorf("не удалось сохранить сессию: %w", err)
+	}
+
+	res, err = s.client.Set(ctx, &redis.SaveRedisRequest{
+		Key:        refreshTokenPrefix + session.TokenId,
+		Value:      sessionId,
+		Expiration: expiration,
+	})
+	if err := redisError(res.GetStatus(), err); err != nil {
+		return fmt.Errorf("не удалось сохранить refresh token: %w", err)
+	}
+
+	// Добавляем сессию в список сессий пользователя для выхода со всех устройств
+	setRes, err := s.client.SetAdd(ctx, &redis.SetRedisRequest{
+		Key:        userSessionsKey(UserClaims{Database: session.Database, UserId: session.UserId}),
+		Members:    []string{sessionId},
+		Expiration: expiration,
+	})
+	if err := redisError(setRes.GetStatus(), err); err != nil {
+		return fmt.Errorf("не удалось сохранить сессию пользователя: %w", err)
+	}
+
+	return nil
+}
+
+// userSessionsKey ключ множества сессий пользователя. ID пользователя уникален только внутри базы компании.
+func userSessionsKey(user UserClaims) string {
+	return refreshSessionsPrefix + user.Database + ":" + user.UserId
+}
+
+// redisError приводит ответ redis сервиса к ошибке.
+func redisError(status uint32, err error) error {
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("redis сервис вернул статус %d", status)
+	}
+	return nil
+}
+
+// newTokenId генерирует случайный идентификатор токена или сессии.
+func newTokenId() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать идентификатор: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
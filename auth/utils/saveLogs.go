@@ -24,3 +24,17 @@ func SaveLogsError(ctx context.Context, clientLogs logs.LogsServiceClient, datab
 
 	return nil
 }
+
+// SaveLogsWarning сохраняет предупреждение, например о подозрительных действиях с токенами
+func SaveLogsWarning(ctx context.Context, clientLogs logs.LogsServiceClient, database string, userId string, message string) error {
+	logRequest := &logs.LogRequest{
+		Name:     "auth",
+		Level:    "warning",
+		Message:  message,
+		Database: database,
+		UserID:   userId,
+	}
+
+	_, err := clientLogs.SaveLogs(ctx, logRequest)
+	return err
+}
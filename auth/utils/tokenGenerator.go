@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"crmSystem/jwks"
 	"crypto/rsa"
 	"encoding/pem"
 	"fmt"
@@ -9,12 +10,121 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 )
 
-// JwtGenerate генерирует JWT токен (access или refresh)
-func JwtGenerator(username, tokenType string) (string, error) {
+// UserClaims данные пользователя, которые передаются в подписанном JWT токене.
+// Сервисы получают базу данных компании и права пользователя только из этих claims.
+type UserClaims struct {
+	Database   string   // База данных компании (claim "db")
+	UserId     string   // ID пользователя в базе данных компании (claim "sub")
+	CompanyId  string   // ID компании (claim "cid")
+	Role       string   // Роль пользователя в компании (claim "role")
+	SessionId  string   // ID сессии (семейства refresh токенов) (claim "sid")
+	AuthUserId string   // ID пользователя в базе данных авторизации (claim "aid"), нужен для настройки 2FA
+	Scopes     []string // Области доступа токена, выпущенного по API ключу (claim "scp"), пусто для сессии
+}
+
+// Время жизни токенов
+const (
+	AccessTokenTTL     = 15 * time.Minute   // 15 минут
+	RefreshTokenTTL    = 7 * 24 * time.Hour // 7 дней
+	MfaPendingTokenTTL = 5 * time.Minute    // Время на ввод второго фактора после проверки пароля
+)
+
+// MfaPendingTokenType тип токена, который выдаётся после проверки пароля до ввода второго фактора.
+// Токен не содержит данных компании и не даёт доступа к сервисам.
+const MfaPendingTokenType = "mfa_pending"
+
+// userClaimsFromMap восстанавливает данные пользователя из claims проверенного токена.
+func userClaimsFromMap(claims jwt.MapClaims) (UserClaims, error) {
+	user := UserClaims{}
+	user.UserId, _ = claims["sub"].(string)
+	user.Database, _ = claims["db"].(string)
+	user.CompanyId, _ = claims["cid"].(string)
+	user.Role, _ = claims["role"].(string)
+	user.SessionId, _ = claims["sid"].(string)
+	user.AuthUserId, _ = claims["aid"].(string)
+	if scopes, ok := claims["scp"].(string); ok {
+		user.Scopes = strings.Fields(scopes)
+	}
+	if user.UserId == "" || user.Database == "" || user.CompanyId == "" {
+		return UserClaims{}, fmt.Errorf("токен не содержит данных пользователя")
+	}
+	return user, nil
+}
+
+// JwtGenerate генерирует JWT токен (access или refresh) с данными пользователя в claims.
+// tokenId записывается в claim "jti" и используется для однократного использования refresh токенов.
+func JwtGenerator(user UserClaims, tokenType string, tokenId string) (string, error) {
+
+	rsaKey, err := loadPrivateKey()
+	if err != nil {
+		return "", err
+	}
+
+	// Определяем срок действия токена
+	var expiresAt time.Time
+	switch tokenType {
+	case "access":
+		expiresAt = time.Now().Add(AccessTokenTTL)
+	case "refresh":
+		expiresAt = time.Now().Add(RefreshTokenTTL)
+	default:
+		return "", fmt.Errorf("неизвестный тип токена: %s", tokenType)
+	}
+
+	return signUserToken(rsaKey, user, tokenType, tokenId, expiresAt)
+}
+
+// ApiKeyTokenGenerator генерирует короткоживущий access токен владельца API ключа.
+// Токен выпускается для одного запроса и содержит области доступа ключа в claim "scp".
+func ApiKeyTokenGenerator(user UserClaims) (string, error) {
+	rsaKey, err := loadPrivateKey()
+	if err != nil {
+		return "", err
+	}
+	return signUserToken(rsaKey, user, "access", "", time.Now().Add(ApiKeyAccessTokenTTL))
+}
+
+// signUserToken подписывает токен с данными пользователя в claims.
+func signUserToken(rsaKey *rsa.PrivateKey, user UserClaims, tokenType string, tokenId string,
+	expiresAt time.Time) (string, error) {
+
+	// Создаём токен
+	token := jwt.New(jwt.SigningMethodRS256)
+	claims := token.Claims.(jwt.MapClaims)
+	claims["sub"] = user.UserId
+	claims["db"] = user.Database
+	claims["cid"] = user.CompanyId
+	claims["role"] = user.Role
+	claims["sid"] = user.SessionId
+	if user.AuthUserId != "" {
+		claims["aid"] = user.AuthUserId
+	}
+	if len(user.Scopes) != 0 {
+		claims["scp"] = strings.Join(user.Scopes, " ")
+	}
+	claims["typ"] = tokenType
+	if tokenId != "" {
+		claims["jti"] = tokenId
+	}
+	claims["iat"] = time.Now().Unix()
+	claims["exp"] = expiresAt.Unix()
+
+	// Подписываем токен, kid позволяет сервисам выбрать ключ проверки во время ротации
+	token.Header["kid"] = jwks.Thumbprint(&rsaKey.PublicKey)
+	tokenString, err := token.SignedString(rsaKey)
+	if err != nil {
+		return "", fmt.Errorf("ошибка подписания токена: %v", err)
+	}
+
+	return tokenString, nil
+}
 
+// loadPrivateKey загружает и расшифровывает закрытый RSA ключ для подписи токенов пользователя.
+func loadPrivateKey() (*rsa.PrivateKey, error) {
 	// Путь к зашифрованному закрытому ключу
 	keyFile := "./opensslkeys/private_key.pem"
 
@@ -23,181 +133,204 @@ func JwtGenerator(username, tokenType string) (string, error) {
 	// Читаем закрытый ключ
 	keyData, err := ioutil.ReadFile(keyFile)
 	if err != nil {
-		return "", fmt.Errorf("ошибка чтения файла: %v", err)
+		return nil, fmt.Errorf("ошибка чтения файла: %v", err)
 	}
 
 	// Декодируем PEM
 	block, _ := pem.Decode(keyData)
 	if block == nil {
-		return "", fmt.Errorf("ошибка: не удалось распознать PEM-формат")
+		return nil, fmt.Errorf("ошибка: не удалось распознать PEM-формат")
 	}
 
 	// Проверяем тип ключа
 	if block.Type != "ENCRYPTED PRIVATE KEY" {
-		return "", fmt.Errorf("ошибка: блок не является зашифрованным приватным ключом")
+		return nil, fmt.Errorf("ошибка: блок не является зашифрованным приватным ключом")
 	}
 
 	// Расшифровываем ключ
 	privKey, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(password))
 	if err != nil {
-		return "", fmt.Errorf("ошибка расшифровки ключа: %v", err)
+		return nil, fmt.Errorf("ошибка расшифровки ключа: %v", err)
 	}
 
 	// Преобразуем в *rsa.PrivateKey
 	rsaKey, ok := privKey.(*rsa.PrivateKey)
 	if !ok {
-		return "", fmt.Errorf("ошибка: не удалось преобразовать в RSA ключ")
+		return nil, fmt.Errorf("ошибка: не удалось преобразовать в RSA ключ")
 	}
 
-	// Определяем срок действия токена
-	var expiresAt time.Time
-	switch tokenType {
-	case "access":
-		expiresAt = time.Now().Add(15 * time.Minute) // 15 минут
-	case "refresh":
-		expiresAt = time.Now().Add(7 * 24 * time.Hour) // 7 дней
-	default:
-		return "", fmt.Errorf("неизвестный тип токена: %s", tokenType)
+	return rsaKey, nil
+}
+
+// MfaTokenGenerator генерирует токен ожидания второго фактора для пользователя authUserId.
+// method - требуемый шаг: "totp" (ввести код) или "enroll" (подключить TOTP по требованию компании).
+func MfaTokenGenerator(authUserId string, method string) (string, error) {
+	rsaKey, err := loadPrivateKey()
+	if err != nil {
+		return "", err
 	}
 
-	// Создаём токен
-	token := jwt.New(jwt.SigningMethodRS256)
-	claims := token.Claims.(jwt.MapClaims)
-	claims["sub"] = username
-	claims["exp"] = expiresAt.Unix()
+	tokenId, err := newTokenId()
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"typ": MfaPendingTokenType,
+		"aid": authUserId,
+		"mfa": method,
+		"jti": tokenId,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(MfaPendingTokenTTL).Unix(),
+	})
+	token.Header["kid"] = jwks.Thumbprint(&rsaKey.PublicKey)
 
-	// Подписываем токен
 	tokenString, err := token.SignedString(rsaKey)
 	if err != nil {
 		return "", fmt.Errorf("ошибка подписания токена: %v", err)
 	}
-
 	return tokenString, nil
 }
 
-// ValidateAndRefreshToken проверяет токены в cookie и обновляет access token при необходимости
-func ValidateAndRefreshToken(_ http.ResponseWriter, r *http.Request) (string, error) {
+// MfaPendingToken данные токена ожидания второго фактора.
+type MfaPendingToken struct {
+	AuthUserId string // ID пользователя авторизации
+	Method     string // Требуемый шаг: "totp" или "enroll"
+	TokenId    string // jti токена, по нему токен погашается после входа или блокировки
+}
 
-	refreshToken, err := GetFromCookies(r, "refresh_token")
+// ParseMfaToken проверяет токен ожидания второго фактора из cookie mfa_token.
+// Погашенные токены проверяет MfaTokenStore.
+func ParseMfaToken(r *http.Request) (*MfaPendingToken, error) {
+	claims, err := parseCookieToken(r, "mfa_token")
 	if err != nil {
-		return "токен не найден", err
+		return nil, err
 	}
-
-	accessToken, err := GetFromCookies(r, "access_token")
-	if err != nil {
-		return "токен не найден", err
+	if typ, _ := claims["typ"].(string); typ != MfaPendingTokenType {
+		return nil, fmt.Errorf("передан токен неверного типа")
 	}
 
-	pubKey, err := loadPublicKey()
-	if err != nil {
-		return "", fmt.Errorf("ошибка загрузки публичного ключа: %v", err)
+	pending := &MfaPendingToken{}
+	pending.AuthUserId, _ = claims["aid"].(string)
+	pending.Method, _ = claims["mfa"].(string)
+	pending.TokenId, _ = claims["jti"].(string)
+	if pending.AuthUserId == "" || pending.Method == "" || pending.TokenId == "" {
+		return nil, fmt.Errorf("токен не содержит данных пользователя")
 	}
+	return pending, nil
+}
 
-	claims, err := validateToken(accessToken, pubKey)
-	if err == nil {
-		return accessToken, nil // access token действителен
+// ParseAccessToken проверяет access token из cookie и возвращает данные пользователя.
+func ParseAccessToken(r *http.Request) (UserClaims, error) {
+	tokenString, err := GetFromCookies(r, "access_token")
+	if err != nil {
+		return UserClaims{}, err
 	}
+	return ParseAccessTokenString(tokenString)
+}
 
-	claims, err = validateToken(refreshToken, pubKey)
+// ParseAccessTokenString проверяет access token, переданный строкой (например в метаданных gRPC),
+// и возвращает данные пользователя.
+func ParseAccessTokenString(tokenString string) (UserClaims, error) {
+	keys, err := VerificationKeys()
 	if err != nil {
-		return "", fmt.Errorf("оба токена недействительны: %v", err)
+		return UserClaims{}, fmt.Errorf("ошибка загрузки публичного ключа: %v", err)
 	}
+	return ParseAccessTokenWith(keys, tokenString)
+}
 
-	username := claims["sub"].(string)
-	newAccessToken, err := JwtGenerator(username, "access")
+// ParseAccessTokenWith проверяет access token ключами keys и возвращает данные пользователя.
+func ParseAccessTokenWith(keys *jwks.KeySet, tokenString string) (UserClaims, error) {
+	claims, err := validateToken(tokenString, keys)
 	if err != nil {
-		return "", fmt.Errorf("ошибка генерации нового access token: %v", err)
+		return UserClaims{}, fmt.Errorf("токен недействителен: %v", err)
 	}
-
-	// Не устанавливаем куку здесь
-	return newAccessToken, nil
+	if typ, _ := claims["typ"].(string); typ != "access" {
+		return UserClaims{}, fmt.Errorf("передан токен неверного типа")
+	}
+	return userClaimsFromMap(claims)
 }
 
-// validateToken проверяет JWT токен
-func validateToken(tokenString string, pubKey *rsa.PublicKey) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
-			return nil, fmt.Errorf("неверный метод подписи")
-		}
-		return pubKey, nil
-	})
+// parseCookieToken проверяет подпись и срок действия токена из cookie name.
+func parseCookieToken(r *http.Request, name string) (jwt.MapClaims, error) {
+	tokenString, err := GetFromCookies(r, name)
 	if err != nil {
 		return nil, err
 	}
 
-	// Проверяем, являются ли claims корректными
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return claims, nil
+	keys, err := VerificationKeys()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка загрузки публичного ключа: %v", err)
 	}
-	return nil, fmt.Errorf("токен недействителен")
+
+	claims, err := validateToken(tokenString, keys)
+	if err != nil {
+		return nil, fmt.Errorf("токен недействителен: %v", err)
+	}
+	return claims, nil
 }
 
-// loadPublicKey загружает публичный RSA ключ
-func loadPublicKey() (*rsa.PublicKey, error) {
-	publicKeyFile := "./opensslkeys/public_key.pem"
+// ParseRefreshToken проверяет refresh token из cookie и возвращает данные пользователя и ID токена (jti).
+// Проверяется только подпись и срок действия, отзыв токена проверяет RefreshStore.
+func ParseRefreshToken(r *http.Request) (UserClaims, string, error) {
 
-	keyData, err := ioutil.ReadFile(publicKeyFile)
+	refreshToken, err := GetFromCookies(r, "refresh_token")
 	if err != nil {
-		return nil, fmt.Errorf("ошибка чтения публичного ключа: %v", err)
+		return UserClaims{}, "", err
 	}
 
-	if len(keyData) == 0 {
-		return nil, fmt.Errorf("файл публичного ключа пуст")
+	keys, err := VerificationKeys()
+	if err != nil {
+		return UserClaims{}, "", fmt.Errorf("ошибка загрузки публичного ключа: %v", err)
 	}
 
-	block, _ := pem.Decode(keyData)
-	if block == nil {
-		return nil, fmt.Errorf("ошибка декодирования PEM: файл не в формате PEM")
+	claims, err := validateToken(refreshToken, keys)
+	if err != nil {
+		return UserClaims{}, "", fmt.Errorf("refresh token недействителен: %v", err)
 	}
 
-	if block.Type != "PUBLIC KEY" && block.Type != "RSA PUBLIC KEY" {
-		return nil, fmt.Errorf("неверный тип PEM блока: ожидается 'PUBLIC KEY' или 'RSA PUBLIC KEY', получено '%s'", block.Type)
+	if typ, _ := claims["typ"].(string); typ != "refresh" {
+		return UserClaims{}, "", fmt.Errorf("передан токен неверного типа")
 	}
 
-	pubKey, err := jwt.ParseRSAPublicKeyFromPEM(keyData)
+	// Данные пользователя переносятся из подписанного refresh token, а не из cookie
+	user, err := userClaimsFromMap(claims)
 	if err != nil {
-		return nil, fmt.Errorf("ошибка парсинга публичного ключа: %v", err)
+		return UserClaims{}, "", err
 	}
 
-	return pubKey, nil
-}
-
-func InternalJwtGenerator() (string, error) {
-	// Путь к зашифрованному закрытому ключу
-	keyFile := "./opensslkeys/private_key.pem"
+	tokenId, _ := claims["jti"].(string)
+	if tokenId == "" || user.SessionId == "" {
+		return UserClaims{}, "", fmt.Errorf("refresh token не привязан к сессии")
+	}
 
-	// Получаем пароль из переменной окружения
-	password := os.Getenv("JWT_SECRET_KEY")
-	/*password := os.Getenv("PEM_PASSWORD")
-	if password == "" {
-		return "", fmt.Errorf("PEM_PASSWORD не установлена")
-	}*/
+	return user, tokenId, nil
+}
 
-	// Чтение файла с ключом
-	keyData, err := ioutil.ReadFile(keyFile)
+// validateToken проверяет JWT токен ключом из набора keys, выбранным по kid
+func validateToken(tokenString string, keys *jwks.KeySet) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, keys.Keyfunc)
 	if err != nil {
-		return "", fmt.Errorf("Ошибка чтения файла: %v", err)
-	}
-
-	// Расшифровка закрытого ключа
-	block, _ := pem.Decode(keyData)
-	if block == nil {
-		return "", fmt.Errorf("Ошибка: не удалось распознать PEM-формат")
+		return nil, err
 	}
 
-	// Проверка, является ли блок зашифрованным
-	if block.Type != "ENCRYPTED PRIVATE KEY" {
-		return "", fmt.Errorf("Ошибка: блок не является зашифрованным приватным ключом")
+	// Проверяем, являются ли claims корректными
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		return claims, nil
 	}
+	return nil, fmt.Errorf("токен недействителен")
+}
 
-	// Расшифровка ключа с использованием pkcs8
-	privKey, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(password))
+// InternalJwtGenerator генерирует короткоживущий токен для запросов к внутренним сервисам.
+func InternalJwtGenerator() (string, error) {
+	rsaKey, err := loadPrivateKey()
 	if err != nil {
-		return "", fmt.Errorf("Ошибка расшифровки ключа: %v", err)
+		return "", err
 	}
 
 	// Генерация JWT
 	token := jwt.New(jwt.SigningMethodRS256)
+	token.Header["kid"] = jwks.Thumbprint(&rsaKey.PublicKey)
 
 	// Установка данных в токен
 	claims := token.Claims.(jwt.MapClaims)
@@ -205,7 +338,7 @@ func InternalJwtGenerator() (string, error) {
 	claims["exp"] = time.Now().Add(3 * time.Minute).Unix() // Время истечения токена
 
 	// Подпись токена
-	tokenString, err := token.SignedString(privKey)
+	tokenString, err := token.SignedString(rsaKey)
 	if err != nil {
 		return "", fmt.Errorf("Ошибка подписывания токена: %v", err)
 	}
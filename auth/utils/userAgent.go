@@ -0,0 +1,52 @@
+package utils
+
+import "strings"
+
+// DeviceFromUserAgent возвращает краткое описание устройства (браузер и операционная система) по User-Agent.
+func DeviceFromUserAgent(userAgent string) string {
+	if userAgent == "" {
+		return "Неизвестное устройство"
+	}
+
+	browser := firstMatch(userAgent, [][2]string{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"YaBrowser/", "Яндекс Браузер"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+	})
+	system := firstMatch(userAgent, [][2]string{
+		{"iPhone", "iOS"},
+		{"iPad", "iPadOS"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"Linux", "Linux"},
+	})
+
+	switch {
+	case browser != "" && system != "":
+		return browser + ", " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	default:
+		// Не браузер, например мобильное приложение или http клиент
+		if name, _, found := strings.Cut(userAgent, "/"); found && name != "" {
+			return name
+		}
+		return "Неизвестное устройство"
+	}
+}
+
+// firstMatch возвращает название первого найденного в строке признака из списка [признак, название].
+func firstMatch(value string, markers [][2]string) string {
+	for _, marker := range markers {
+		if strings.Contains(value, marker[0]) {
+			return marker[1]
+		}
+	}
+	return ""
+}
@@ -2,107 +2,178 @@ package chat
 
 import (
 	"bufio"
-	"bytes"
-	"crmSystem/utils/types"
-	"encoding/json"
+	"context"
+	pb "crmSystem/proto/chats"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"log"
-	"net/http"
-	"os"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// Интервалы повторного подключения к чату после обрыва потока
+	minReconnectInterval = 500 * time.Millisecond
+	maxReconnectInterval = 30 * time.Second
 )
 
-// ListenForMessages периодически запрашивает сообщения чата и выводит их в консоль.
-func ListenForMessages(apiBaseURL string, chatID int64, userID int64, httpClient *http.Client) {
+// printer выводит сообщения в консоль из нескольких горутин
+type printer struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+func (p *printer) printf(format string, args ...interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, _ = fmt.Fprintf(p.out, format, args...)
+}
+
+// Run подключается к чату через поток ChatStream, выводит в output сообщения других участников чата
+// и отправляет строки из input. После обрыва потока клиент переподключается, строки, введённые
+// во время переподключения, отправляются после него. Возвращает nil после ввода exit или конца ввода.
+// Ошибки доступа к чату (недействительный токен, пользователь не в чате) возвращаются без переподключения.
+func Run(ctx context.Context, client pb.ChatServiceClient, chatID int64, input io.Reader, output io.Writer) error {
+	out := &printer{out: output}
+	out.printf("Введите сообщение и нажмите Enter. Для выхода введите 'exit'.\n")
+
+	lines := readLines(input)
+	reconnectInterval := minReconnectInterval
 	for {
-		reqURL := fmt.Sprintf("%s/chats/%d/messages", apiBaseURL, chatID)
-		resp, err := httpClient.Get(reqURL)
-		if err != nil || resp.StatusCode != http.StatusOK {
-			if resp != nil {
-				resp.Body.Close()
-			}
-			time.Sleep(500 * time.Millisecond)
-			continue
+		joined, err := runStream(ctx, client, chatID, lines, out)
+		if err == nil {
+			return nil
 		}
-		body, err := ioutil.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			time.Sleep(500 * time.Millisecond)
-			continue
+		if ctx.Err() != nil {
+			return ctx.Err()
 		}
-		var messages []types.ChatMessage
-		if err := json.Unmarshal(body, &messages); err != nil {
-			time.Sleep(500 * time.Millisecond)
-			continue
+		switch status.Code(err) {
+		case codes.Unauthenticated, codes.PermissionDenied, codes.InvalidArgument:
+			return err
 		}
-		for _, message := range messages {
-			if message.UserID == userID {
-				continue
-			}
-			fmt.Printf("[%s][User %d]: %s\n",
-				message.Time.Format("2006-01-02 15:04:05"),
-				message.UserID, message.Content)
+
+		log.Printf("Соединение с чатом прервано: %v", err)
+		if joined {
+			reconnectInterval = minReconnectInterval
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(reconnectInterval):
 		}
-		time.Sleep(500 * time.Millisecond)
+		reconnectInterval = min(2*reconnectInterval, maxReconnectInterval)
 	}
 }
 
-// ReadAndSendMessages читает сообщения из ввода и отправляет их через HTTP API.
-func ReadAndSendMessages(apiBaseURL string, chatID, userID int64, dbName string, httpClient *http.Client) {
-	fmt.Println("Введите сообщение и нажмите Enter. Для выхода введите 'exit'.")
-	reader := bufio.NewReader(os.Stdin)
-	for {
-		fmt.Print("> ")
-		message, err := reader.ReadString('\n')
-		if err != nil {
-			log.Printf("Ошибка при чтении ввода: %v", err)
-			return
+// readLines читает строки ввода в канал, канал закрывается в конце ввода
+func readLines(input io.Reader) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(input)
+		for scanner.Scan() {
+			lines <- strings.TrimSpace(scanner.Text())
 		}
-		message = strings.TrimSpace(message)
-		if message == "exit" {
-			fmt.Println("Выход из чата...")
-			break
-		}
-		chatMessage := types.ChatMessage{
-			DBName:  dbName,
-			ChatID:  chatID,
-			UserID:  userID,
-			Content: message,
-			Time:    time.Now(),
-		}
-		body, err := json.Marshal(chatMessage)
-		if err != nil {
-			log.Printf("Ошибка при сериализации сообщения: %v", err)
-			continue
-		}
-		req, err := http.NewRequest(
-			"POST",
-			fmt.Sprintf("%s/chats/%d/sendMessage", apiBaseURL, chatID),
-			bytes.NewBuffer(body),
-		)
-		if err != nil {
-			log.Printf("Ошибка при создании запроса: %v", err)
-			continue
-		}
-		req.Header.Set("Content-Type", "application/json")
-		resp, err := httpClient.Do(req)
-		if err != nil {
-			log.Printf("Ошибка при отправке сообщения: %v", err)
-			continue
+		if err := scanner.Err(); err != nil {
+			log.Printf("Ошибка при чтении ввода: %v", err)
 		}
-		defer resp.Body.Close()
-		respBody, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			log.Printf("Ошибка при чтении тела ответа: %v", err)
-			continue
+	}()
+	return lines
+}
+
+// runStream подключается к чату одним потоком и работает до его обрыва, ввода exit или конца ввода.
+// Возвращает true, если сервер подключил поток к чату.
+func runStream(ctx context.Context, client pb.ChatServiceClient, chatID int64, lines <-chan string, out *printer) (bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := client.ChatStream(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err := stream.Send(&pb.ChatStreamRequest{Request: &pb.ChatStreamRequest_Join{Join: &pb.JoinChat{ChatId: chatID}}}); err != nil {
+		return false, err
+	}
+	response, err := stream.Recv()
+	if err != nil {
+		return false, err
+	}
+	if response.GetJoined() == nil {
+		return false, fmt.Errorf("сервер не подтвердил подключение к чату")
+	}
+
+	// Отправленные сообщения по id запроса: текст выводится после подтверждения сервером
+	var pendingMu sync.Mutex
+	pending := make(map[string]string)
+
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			response, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			switch {
+			case response.GetMessage() != nil:
+				message := response.GetMessage()
+				out.printf("[%s][User %d]: %s\n",
+					time.Unix(message.CreatedAt, 0).Format("2006-01-02 15:04:05"),
+					message.UserId, message.Content)
+			case response.GetSent() != nil:
+				sent := response.GetSent()
+				pendingMu.Lock()
+				content := pending[sent.RequestId]
+				delete(pending, sent.RequestId)
+				pendingMu.Unlock()
+				out.printf("[You][%s]: %s\n", time.Unix(sent.CreatedAt, 0).Format("2006-01-02 15:04:05"), content)
+			case response.GetMembers() != nil:
+				members := response.GetMembers()
+				out.printf("[%s] Участники чата изменены (%s): %v\n",
+					time.Unix(members.ChangedAt, 0).Format("2006-01-02 15:04:05"), members.Type, members.UserIds)
+			}
 		}
-		if resp.StatusCode != http.StatusOK {
-			log.Printf("Ошибка от сервера: %s, Статус: %d", respBody, resp.StatusCode)
-			continue
+	}()
+
+	var requestID int64
+	for {
+		select {
+		case err := <-recvErr:
+			if errors.Is(err, io.EOF) {
+				err = status.Errorf(codes.Unavailable, "сервер закрыл поток")
+			}
+			return true, err
+
+		case line, ok := <-lines:
+			if !ok || line == "exit" {
+				out.printf("Выход из чата...\n")
+				if err := stream.CloseSend(); err != nil {
+					return true, nil
+				}
+				// Ожидаем подтверждения последних сообщений, сервер завершает поток после них
+				if err := <-recvErr; !errors.Is(err, io.EOF) {
+					log.Printf("Поток чата завершён с ошибкой: %v", err)
+				}
+				return true, nil
+			}
+			if line == "" {
+				continue
+			}
+
+			requestID++
+			id := strconv.FormatInt(requestID, 10)
+			pendingMu.Lock()
+			pending[id] = line
+			pendingMu.Unlock()
+			if err := stream.Send(&pb.ChatStreamRequest{Request: &pb.ChatStreamRequest_Send{Send: &pb.SendChatMessage{RequestId: id, Content: line}}}); err != nil {
+				log.Printf("Ошибка при отправке сообщения: %v", err)
+			}
 		}
-		fmt.Printf("[You][%s]: %s\n",
-			time.Now().Format("2006-01-02 15:04:05"), message)
 	}
 }
@@ -1,14 +1,17 @@
 package main
 
 import (
+	"context"
 	chat "crmSystem/internal"
+	pb "crmSystem/proto/chats"
 	"crmSystem/utils"
 	"flag"
 	"github.com/joho/godotenv"
 	"log"
-	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 )
 
 func main() {
@@ -18,41 +21,30 @@ func main() {
 	}
 
 	chatID := flag.String("chat_id", "", "ID чата")
-	userID := flag.String("user_id", "", "ID пользователя")
-	dbName := flag.String("db_name", "", "Имя Базы Данных")
+	token := flag.String("token", os.Getenv("CHAT_ACCESS_TOKEN"), "Access token пользователя (по умолчанию CHAT_ACCESS_TOKEN)")
 	flag.Parse()
 
-	if *chatID == "" || *userID == "" || *dbName == "" {
-		log.Fatal("Параметры chat_id,user_id,dbName обязательны")
+	// Пользователь и база компании определяются сервисом чатов по токену
+	if *chatID == "" || *token == "" {
+		log.Fatal("Параметры chat_id и token обязательны")
 	}
 
 	parsedChatID, err := strconv.ParseInt(*chatID, 10, 64)
 	if err != nil {
 		log.Fatalf("Ошибка конвертации chat_id в int64: %v", err)
 	}
-	parsedUserID, err := strconv.ParseInt(*userID, 10, 64)
-	if err != nil {
-		log.Fatalf("Ошибка конвертации user_id в int64: %v", err)
-	}
-
-	apiBaseURL := os.Getenv("CHATS_SERVICE_URL")
-	if apiBaseURL == "" {
-		apiBaseURL = "https://nginx:443"
-	}
 
-	tlsConfig, err := utils.LoadTLSCredentials()
+	// Подключение к сервису чатов через GRPC_PROXY_CONNECTOR, токен передаётся в метаданных authorization
+	client, err, conn := utils.GRPCServiceConnector(*token, pb.NewChatServiceClient)
 	if err != nil {
-		log.Fatalf("Ошибка настройки TLS: %v", err)
+		log.Fatalf("Не удалось подключиться к сервису чатов: %v", err)
 	}
+	defer conn.Close()
 
-	httpClient := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: tlsConfig,
-		},
-	}
-
-	go chat.ListenForMessages(apiBaseURL, parsedChatID, parsedUserID, httpClient)
-	go chat.ReadAndSendMessages(apiBaseURL, parsedChatID, parsedUserID, *dbName, httpClient)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	select {}
+	if err := chat.Run(ctx, client, parsedChatID, os.Stdin, os.Stdout); err != nil && ctx.Err() == nil {
+		log.Fatalf("Ошибка чата: %v", err)
+	}
 }
@@ -4,232 +4,151 @@ import (
 	"bytes"
 	"context"
 	chat "crmSystem/internal"
-	"crmSystem/utils"
-	"crmSystem/utils/types"
-	"encoding/json"
+	pb "crmSystem/proto/chats"
+	"errors"
 	"fmt"
-	"github.com/stretchr/testify/assert"
 	"io"
-	"net/http"
-	"net/http/httptest"
-	"os"
-	"sync"
+	"net"
+	"strings"
 	"testing"
 	"time"
-)
-
-// captureOutput перехватывает вывод в консоль
-func captureOutput(f func()) string {
-	orig := os.Stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
 
-	f()
-
-	w.Close()
-	os.Stdout = orig
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
 
-	var buf bytes.Buffer
-	_, _ = io.Copy(&buf, r)
-	return buf.String()
+// chatServer тестовый ChatService: подтверждает подключение, передаёт сообщение другого пользователя
+// и подтверждает сообщения клиента
+type chatServer struct {
+	pb.UnimplementedChatServiceServer
+	joinErr  error
+	received chan string
 }
 
-func TestListenForMessages(t *testing.T) {
-	// Настраиваем тестовый HTTPS-сервер
-	server := httptest.NewTLSServer(nil)
-	defer server.Close()
-
-	// Настраиваем TLS-клиент с использованием utils.LoadTLSCredentials
-	tlsConfig, err := utils.LoadTLSCredentials()
+func (s *chatServer) ChatStream(stream pb.ChatService_ChatStreamServer) error {
+	request, err := stream.Recv()
 	if err != nil {
-		t.Fatalf("Failed to load TLS credentials: %v", err)
+		return err
 	}
-	httpClient := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: tlsConfig,
-		},
+	if s.joinErr != nil {
+		return s.joinErr
 	}
-
-	chatID := int64(123)
-	userID := int64(456)
-
-	tests := []struct {
-		name           string
-		serverHandler  func(w http.ResponseWriter, r *http.Request)
-		expectedOutput string
-	}{
-		{
-			name: "Success",
-			serverHandler: func(w http.ResponseWriter, r *http.Request) {
-				if r.Method != http.MethodGet || r.URL.Path != fmt.Sprintf("/chats/%d/messages", chatID) {
-					http.Error(w, "Invalid request", http.StatusBadRequest)
-					return
-				}
-				messages := []types.ChatMessage{
-					{
-						ChatID:  chatID,
-						UserID:  789, // Другой пользователь
-						Content: "Hello from user 789",
-						Time:    time.Now().Truncate(time.Second),
-					},
-					{
-						ChatID:  chatID,
-						UserID:  userID, // Текущий пользователь
-						Content: "Own message",
-						Time:    time.Now().Truncate(time.Second),
-					},
-				}
-				_ = json.NewEncoder(w).Encode(messages)
-			},
-			expectedOutput: fmt.Sprintf("[%s][User 789]: Hello from user 789\n", time.Now().Truncate(time.Second).Format("2006-01-02 15:04:05")),
-		},
-		{
-			name: "Server Error",
-			serverHandler: func(w http.ResponseWriter, r *http.Request) {
-				http.Error(w, "Server error", http.StatusInternalServerError)
-			},
-			expectedOutput: "", // Нет вывода, так как запрос неуспешен
-		},
-		{
-			name: "Invalid JSON",
-			serverHandler: func(w http.ResponseWriter, r *http.Request) {
-				_, _ = w.Write([]byte("invalid json"))
-			},
-			expectedOutput: "", // Нет вывода, так как JSON некорректен
-		},
+	chatID := request.GetJoin().GetChatId()
+	if err := stream.Send(&pb.ChatStreamResponse{Response: &pb.ChatStreamResponse_Joined{Joined: &pb.JoinedChat{ChatId: chatID}}}); err != nil {
+		return err
+	}
+	if err := stream.Send(&pb.ChatStreamResponse{Response: &pb.ChatStreamResponse_Message{Message: &pb.ChatStreamMessage{
+		MessageId: 1,
+		ChatId:    chatID,
+		UserId:    789, // Другой пользователь
+		Content:   "Hello from user 789",
+		CreatedAt: testTime.Unix(),
+	}}}); err != nil {
+		return err
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Устанавливаем обработчик для сервера
-			server.Config.Handler = http.HandlerFunc(tt.serverHandler)
-
-			// Создаем контекст с таймаутом для ограничения времени теста
-			ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
-			defer cancel()
-
-			// Перехватываем вывод
-			var output string
-			var wg sync.WaitGroup
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				output = captureOutput(func() {
-					chat.ListenForMessages(server.URL, chatID, userID, httpClient)
-				})
-			}()
-
-			// Ждем завершения контекста
-			<-ctx.Done()
-			wg.Wait()
-
-			// Проверяем вывод
-			if tt.expectedOutput == "" {
-				assert.Empty(t, output, "Expected no output")
-			} else {
-				assert.Contains(t, output, tt.expectedOutput, "Expected output to contain message")
-			}
-		})
+	for messageID := int64(2); ; messageID++ {
+		request, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		send := request.GetSend()
+		s.received <- send.Content
+		if err := stream.Send(&pb.ChatStreamResponse{Response: &pb.ChatStreamResponse_Sent{Sent: &pb.MessageSent{
+			RequestId: send.RequestId,
+			MessageId: messageID,
+			CreatedAt: testTime.Unix(),
+		}}}); err != nil {
+			return err
+		}
 	}
 }
 
-func TestReadAndSendMessages(t *testing.T) {
-	// Настраиваем тестовый HTTPS-сервер
-	server := httptest.NewTLSServer(nil)
-	defer server.Close()
-
-	// Настраиваем TLS-клиент
-	tlsConfig, err := utils.LoadTLSCredentials()
-	if err != nil {
-		t.Fatalf("Failed to load TLS credentials: %v", err)
-	}
-	httpClient := &http.Client{
-		Transport: &http.Transport{
-			TLSClientConfig: tlsConfig,
-		},
-	}
+var testTime = time.Date(2026, 1, 2, 3, 4, 5, 0, time.Local)
+
+// startChatServer запускает тестовый сервер через bufconn и возвращает клиента
+func startChatServer(t *testing.T, server *chatServer) pb.ChatServiceClient {
+	lis := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	pb.RegisterChatServiceServer(grpcServer, server)
+	go func() { _ = grpcServer.Serve(lis) }()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return pb.NewChatServiceClient(conn)
+}
 
+func TestRun(t *testing.T) {
 	chatID := int64(123)
-	userID := int64(456)
-	dbName := "test_db"
+	timestamp := testTime.Format("2006-01-02 15:04:05")
 
 	tests := []struct {
 		name           string
 		input          string
-		serverHandler  func(w http.ResponseWriter, r *http.Request)
-		expectedOutput string
-		expectedStatus int
+		joinErr        error
+		expectedCode   codes.Code
+		expectedSent   []string
+		expectedOutput []string
 	}{
 		{
-			name:  "Success",
-			input: "Hello, world!\n",
-			serverHandler: func(w http.ResponseWriter, r *http.Request) {
-				if r.Method != http.MethodPost || r.URL.Path != fmt.Sprintf("/chats/%d/sendMessage", chatID) {
-					http.Error(w, "Invalid request", http.StatusBadRequest)
-					return
-				}
-				var msg types.ChatMessage
-				if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
-					http.Error(w, "Invalid JSON", http.StatusBadRequest)
-					return
-				}
-				assert.Equal(t, chatID, msg.ChatID)
-				assert.Equal(t, userID, msg.UserID)
-				assert.Equal(t, dbName, msg.DBName)
-				assert.Equal(t, "Hello, world!", msg.Content)
-				w.WriteHeader(http.StatusOK)
+			name:         "Success",
+			input:        "Hello, world!\nexit\n",
+			expectedCode: codes.OK,
+			expectedSent: []string{"Hello, world!"},
+			expectedOutput: []string{
+				fmt.Sprintf("[%s][User 789]: Hello from user 789\n", timestamp),
+				fmt.Sprintf("[You][%s]: Hello, world!\n", timestamp),
+				"Выход из чата...\n",
 			},
-			expectedOutput: fmt.Sprintf("Введите сообщение и нажмите Enter. Для выхода введите 'exit'.\n> [You][%s]: Hello, world!\n", time.Now().Truncate(time.Second).Format("2006-01-02 15:04:05")),
-			expectedStatus: http.StatusOK,
 		},
 		{
-			name:           "Exit",
-			input:          "exit\n",
-			serverHandler:  func(w http.ResponseWriter, r *http.Request) {},
-			expectedOutput: "Введите сообщение и нажмите Enter. Для выхода введите 'exit'.\n> Выход из чата...\n",
-			expectedStatus: 0, // Не проверяем статус, так как запрос не отправляется
+			name:           "End Of Input",
+			input:          "",
+			expectedCode:   codes.OK,
+			expectedOutput: []string{"Выход из чата...\n"},
 		},
 		{
-			name:  "Server Error",
-			input: "Test message\n",
-			serverHandler: func(w http.ResponseWriter, r *http.Request) {
-				http.Error(w, "Server error", http.StatusInternalServerError)
-			},
-			expectedOutput: "Введите сообщение и нажмите Enter. Для выхода введите 'exit'.\n> Ошибка от сервера: Server error, Статус: 500\n",
-			expectedStatus: http.StatusInternalServerError,
+			name:         "Permission Denied",
+			input:        "Hello, world!\n",
+			joinErr:      status.Errorf(codes.PermissionDenied, "пользователь не состоит в чате"),
+			expectedCode: codes.PermissionDenied, // Клиент не переподключается
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Устанавливаем обработчик для сервера
-			server.Config.Handler = http.HandlerFunc(tt.serverHandler)
-
-			// Перехватываем вывод
-			var output string
-			var wg sync.WaitGroup
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				output = captureOutput(func() {
-					// Временно заменяем os.Stdin для теста
-					origStdin := os.Stdin
-					defer func() { os.Stdin = origStdin }()
-					os.Stdin, _ = os.CreateTemp("", "test-stdin")
-					_, _ = os.Stdin.WriteString(tt.input)
-					_ = os.Stdin.Sync()
-					os.Stdin.Seek(0, 0)
+			server := &chatServer{joinErr: tt.joinErr, received: make(chan string, 10)}
+			client := startChatServer(t, server)
 
-					chat.ReadAndSendMessages(server.URL, chatID, userID, dbName, httpClient)
-				})
-			}()
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
 
-			// Ждем завершения или таймаута
-			time.Sleep(500 * time.Millisecond)
-			wg.Wait()
+			var output bytes.Buffer
+			err := chat.Run(ctx, client, chatID, strings.NewReader(tt.input), &output)
+			assert.Equal(t, tt.expectedCode, status.Code(err))
 
-			// Проверяем вывод
-			assert.Contains(t, output, tt.expectedOutput, "Expected output to contain message")
+			// Проверяем отправленные сообщения и вывод
+			close(server.received)
+			var sent []string
+			for content := range server.received {
+				sent = append(sent, content)
+			}
+			assert.Equal(t, tt.expectedSent, sent)
+			for _, expected := range tt.expectedOutput {
+				assert.Contains(t, output.String(), expected)
+			}
 		})
 	}
 }
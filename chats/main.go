@@ -1,15 +1,22 @@
 package main
 
 import (
+	"context"
+	"crmSystem/lifecycle"
+	pb "crmSystem/proto/chats"
+	"crmSystem/transport_grpc"
 	"crmSystem/transport_rest"
 	"crmSystem/utils"
 	"errors"
 	"github.com/joho/godotenv"
 	"github.com/streadway/amqp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
 	"log"
+	"net"
 	"net/http"
 	"os"
-	"sync"
 	"time"
 )
 
@@ -74,25 +81,72 @@ func main() {
 	// Получаем порт из переменных окружения
 	httpPort := os.Getenv("CHAT_SERVICE_HTTP_PORT")
 
-	// Создаем HTTP сервер
+	// Остановка по SIGINT и SIGTERM: новые запросы не принимаются, текущие завершаются в течение DRAIN_TIMEOUT,
+	// затем закрывается соединение с RabbitMQ
+	ctx, stop := lifecycle.ShutdownContext()
+	defer stop()
+
+	// Создаем HTTP сервер. Контексты запросов отменяются при остановке сервиса,
+	// поэтому получатели сообщений из RabbitMQ сразу возвращают уже полученные сообщения
 	httpServer := &http.Server{
-		Addr:    ":" + httpPort,
-		Handler: handler.InitRouter(),
+		Addr:        ":" + httpPort,
+		Handler:     handler.InitRouter(),
+		BaseContext: func(net.Listener) context.Context { return ctx },
 	}
-
-	// Используем WaitGroup для ожидания завершения сервера
-	var wg sync.WaitGroup
-	wg.Add(1)
+	// Shutdown не ожидает WebSocket соединения: клиентам отправляется сообщение закрытия,
+	// неподтверждённые сообщения остаются в их подписках до переподключения
+	httpServer.RegisterOnShutdown(handler.CloseStreams)
 
 	// Запускаем сервер с TLS в отдельной горутине
 	go func() {
 		log.Println("HTTP SERVER STARTED WITH TLS ON PORT", httpPort)
-		if err := httpServer.ListenAndServeTLS(utils.ServerCertFile, utils.ServerKeyFile); err != nil {
+		if err := httpServer.ListenAndServeTLS(utils.ServerCertFile, utils.ServerKeyFile); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatalf("Ошибка запуска HTTP сервера с TLS: %v", err)
 		}
-		wg.Done() // Уменьшаем счетчик после завершения работы сервера
 	}()
 
-	// Ожидаем завершения работы HTTP сервера
-	wg.Wait()
+	// gRPC сервер потоков чатов ChatService
+	grpcPort := os.Getenv("CHAT_SERVICE_GRPC_PORT")
+	lis, err := net.Listen("tcp", ":"+grpcPort)
+	if err != nil {
+		log.Fatalf("Не удалось запустить gRPC сервер: %v", err)
+	}
+
+	tlsConfig, err := utils.LoadTLSCredentials()
+	if err != nil {
+		log.Fatalf("Невозможно загрузить учетные данные TLS: %s", err)
+	}
+
+	// Потоки открыты долго, поэтому время жизни соединения не ограничивается,
+	// оборванные соединения обнаруживаются по keepalive
+	grpcServer := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    30 * time.Second,
+			Timeout: 10 * time.Second,
+		}),
+		grpc.StreamInterceptor(transport_grpc.NewAuthStreamInterceptor(utils.ParseUserToken)),
+	)
+	chatServer := transport_grpc.NewChatServer(transport_grpc.NewRabbitMQBus(rabbitMQConn), transport_grpc.DialDbChatService)
+	pb.RegisterChatServiceServer(grpcServer, chatServer)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Println("gRPC SERVER STARTED WITH TLS ON PORT", grpcPort)
+		serveErr <- grpcServer.Serve(lis)
+	}()
+
+	// Ожидаем сигнала остановки
+	select {
+	case err := <-serveErr:
+		log.Fatalf("Ошибка запуска gRPC сервера: %v", err)
+	case <-ctx.Done():
+	}
+	log.Printf("Получен сигнал остановки, завершаем текущие запросы")
+	lifecycle.ShutdownHTTPServer(httpServer, lifecycle.DrainTimeout())
+
+	// GracefulStop не завершает открытые потоки: клиенты получают Unavailable и переподключаются
+	// к другому экземпляру сервиса
+	chatServer.CloseStreams()
+	lifecycle.StopGRPCServer(grpcServer, lifecycle.DrainTimeout())
 }
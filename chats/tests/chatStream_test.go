@@ -0,0 +1,311 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/jwks"
+	pb "crmSystem/proto/chats"
+	"crmSystem/proto/dbchat"
+	"crmSystem/transport_grpc"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// memorySubscriber receives messages and membership events of one chat.
+type memorySubscriber struct {
+	deliver func(types.ChatMessage)
+	members func(types.ChatMembersEvent)
+}
+
+// memoryBus delivers published messages to subscribers of the same chat in process.
+type memoryBus struct {
+	mu          sync.Mutex
+	subscribers map[string]map[int]memorySubscriber
+	nextID      int
+}
+
+func newMemoryBus() *memoryBus {
+	return &memoryBus{subscribers: make(map[string]map[int]memorySubscriber)}
+}
+
+func (b *memoryBus) Publish(message types.ChatMessage) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, subscriber := range b.subscribers[fmt.Sprintf("%s:%d", message.DBName, message.ChatID)] {
+		subscriber.deliver(message)
+	}
+	return nil
+}
+
+// publishMembers stands in for the REST API publishing a membership change to the chat exchange.
+func (b *memoryBus) publishMembers(event types.ChatMembersEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, subscriber := range b.subscribers[fmt.Sprintf("%s:%d", event.DBName, event.ChatID)] {
+		subscriber.members(event)
+	}
+}
+
+func (b *memoryBus) Subscribe(database string, chatID int64, deliver func(types.ChatMessage), members func(types.ChatMembersEvent), _ func()) (func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key := fmt.Sprintf("%s:%d", database, chatID)
+	if b.subscribers[key] == nil {
+		b.subscribers[key] = make(map[int]memorySubscriber)
+	}
+	b.nextID++
+	id := b.nextID
+	b.subscribers[key][id] = memorySubscriber{deliver: deliver, members: members}
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subscribers[key], id)
+	}, nil
+}
+
+func (b *memoryBus) subscriptions(key string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers[key])
+}
+
+// fakeChatStore stands in for dbservice, calls are made on behalf of the token's user.
+type fakeChatStore struct {
+	dbchat.DbChatServiceClient
+	chats  []*dbchat.ChatInfo
+	lastID *atomic.Int64
+}
+
+func (s *fakeChatStore) ListUserChats(context.Context, *dbchat.ListUserChatsRequest, ...grpc.CallOption) (*dbchat.ListUserChatsResponse, error) {
+	return &dbchat.ListUserChatsResponse{Chats: s.chats}, nil
+}
+
+func (s *fakeChatStore) SaveMessage(_ context.Context, req *dbchat.SaveMessageRequest, _ ...grpc.CallOption) (*dbchat.SaveMessageResponse, error) {
+	return &dbchat.SaveMessageResponse{
+		MessageId: s.lastID.Add(1),
+		ChatId:    req.ChatId,
+		Message:   req.Content,
+		CreatedAt: time.Now().Unix(),
+	}, nil
+}
+
+// testUsers maps access tokens to users; users 7 and 8 are members of chat 3, user 9 of no chats.
+var testUsers = map[string]*utils.UserClaims{
+	"token-7": {Database: "company_db", UserId: "7"},
+	"token-8": {Database: "company_db", UserId: "8"},
+	"token-9": {Database: "company_db", UserId: "9"},
+}
+
+func parseTestToken(token string) (*utils.UserClaims, error) {
+	if user, ok := testUsers[token]; ok {
+		return user, nil
+	}
+	return nil, fmt.Errorf("unknown token")
+}
+
+// startChatServer serves ChatService over bufconn and returns a client and the bus behind the server.
+func startChatServer(t *testing.T) (pb.ChatServiceClient, *memoryBus) {
+	t.Helper()
+	return startChatServerWith(t, parseTestToken)
+}
+
+// startChatServerWith starts the chat service whose streams are authenticated with parse.
+func startChatServerWith(t *testing.T, parse transport_grpc.TokenParser) (pb.ChatServiceClient, *memoryBus) {
+	t.Helper()
+
+	var lastID atomic.Int64
+	dialStore := func(token string) (dbchat.DbChatServiceClient, func() error, error) {
+		store := &fakeChatStore{lastID: &lastID}
+		if token != "token-9" {
+			store.chats = []*dbchat.ChatInfo{{ChatId: 3, ChatName: "general"}}
+		}
+		return store, func() error { return nil }, nil
+	}
+
+	bus := newMemoryBus()
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.StreamInterceptor(transport_grpc.NewAuthStreamInterceptor(parse)))
+	pb.RegisterChatServiceServer(server, transport_grpc.NewChatServer(bus, dialStore))
+	go func() { _ = server.Serve(lis) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return pb.NewChatServiceClient(conn), bus
+}
+
+// joinChat opens a stream as the token's user and waits until the server confirms the join.
+func joinChat(t *testing.T, ctx context.Context, client pb.ChatServiceClient, token string, chatID int64) pb.ChatService_ChatStreamClient {
+	t.Helper()
+	stream, err := client.ChatStream(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token))
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pb.ChatStreamRequest{Request: &pb.ChatStreamRequest_Join{Join: &pb.JoinChat{ChatId: chatID}}}))
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, chatID, resp.GetJoined().GetChatId())
+	return stream
+}
+
+func sendText(t *testing.T, stream pb.ChatService_ChatStreamClient, requestID string, content string) {
+	t.Helper()
+	require.NoError(t, stream.Send(&pb.ChatStreamRequest{Request: &pb.ChatStreamRequest_Send{Send: &pb.SendChatMessage{RequestId: requestID, Content: content}}}))
+}
+
+// TestChatStreamFanOut checks messages reach other members of the chat but are not echoed to the sender.
+func TestChatStreamFanOut(t *testing.T) {
+	client, bus := startChatServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alice := joinChat(t, ctx, client, "token-7", 3)
+	bob := joinChat(t, ctx, client, "token-8", 3)
+	// Both streams share a single subscription of the service instance
+	assert.Equal(t, 1, bus.subscriptions("company_db:3"))
+
+	sendText(t, alice, "r1", "hello")
+	resp, err := alice.Recv()
+	require.NoError(t, err)
+	sent := resp.GetSent()
+	require.NotNil(t, sent)
+	assert.Equal(t, "r1", sent.RequestId)
+
+	resp, err = bob.Recv()
+	require.NoError(t, err)
+	message := resp.GetMessage()
+	require.NotNil(t, message)
+	assert.Equal(t, sent.MessageId, message.MessageId)
+	assert.Equal(t, int64(3), message.ChatId)
+	assert.Equal(t, int64(7), message.UserId)
+	assert.Equal(t, "hello", message.Content)
+
+	sendText(t, bob, "r2", "hi")
+	resp, err = bob.Recv()
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetSent())
+
+	// The next response for the sender is the other member's message, not its own
+	resp, err = alice.Recv()
+	require.NoError(t, err)
+	require.NotNil(t, resp.GetMessage())
+	assert.Equal(t, int64(8), resp.GetMessage().UserId)
+	assert.Equal(t, "hi", resp.GetMessage().Content)
+
+	// The subscription is dropped with the last stream of the chat
+	require.NoError(t, alice.CloseSend())
+	require.NoError(t, bob.CloseSend())
+	assert.Eventually(t, func() bool { return bus.subscriptions("company_db:3") == 0 }, time.Second, 10*time.Millisecond)
+}
+
+// TestChatStreamRequiresToken checks streams without a valid access token are rejected.
+func TestChatStreamRequiresToken(t *testing.T) {
+	client, _ := startChatServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for name, streamCtx := range map[string]context.Context{
+		"missing": ctx,
+		"invalid": metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer forged"),
+	} {
+		stream, err := client.ChatStream(streamCtx)
+		require.NoError(t, err, name)
+		_, err = stream.Recv()
+		assert.Equal(t, codes.Unauthenticated, status.Code(err), name)
+	}
+}
+
+// TestChatStreamRequiresMembership checks users can only join chats they belong to.
+func TestChatStreamRequiresMembership(t *testing.T) {
+	client, bus := startChatServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.ChatStream(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer token-9"))
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&pb.ChatStreamRequest{Request: &pb.ChatStreamRequest_Join{Join: &pb.JoinChat{ChatId: 3}}}))
+	_, err = stream.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, 0, bus.subscriptions("company_db:3"))
+
+	// The first message of a stream must join a chat
+	stream, err = client.ChatStream(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer token-7"))
+	require.NoError(t, err)
+	sendText(t, stream, "r1", "hello")
+	_, err = stream.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// TestChatStreamMembersChanged checks membership changes reach the chat's streams and removed users are disconnected.
+func TestChatStreamMembersChanged(t *testing.T) {
+	client, bus := startChatServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	alice := joinChat(t, ctx, client, "token-7", 3)
+	bob := joinChat(t, ctx, client, "token-8", 3)
+
+	changed := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	bus.publishMembers(types.ChatMembersEvent{
+		Type: types.MembersRemoved, DBName: "company_db", ChatID: 3, UserIDs: []int64{8}, ActorID: 7, Time: changed,
+	})
+
+	resp, err := alice.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, &pb.MembersChanged{
+		Type: types.MembersRemoved, ChatId: 3, UserIds: []int64{8}, ActorId: 7, ChangedAt: changed.Unix(),
+	}, resp.GetMembers())
+
+	_, err = bob.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+// signStreamToken signs a token of user 7 of company_db with key, the way the auth service does.
+func signStreamToken(t *testing.T, key *rsa.PrivateKey, tokenType string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"sub": "7", "db": "company_db", "cid": "1", "role": "user", "typ": tokenType,
+		"exp": time.Now().Add(time.Minute).Unix(),
+	})
+	token.Header["kid"] = jwks.Thumbprint(&key.PublicKey)
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+// TestChatStreamRequiresAccessToken checks a refresh token signed by the same key cannot open a chat stream.
+func TestChatStreamRequiresAccessToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keys := jwks.NewKeySet("", nil, &key.PublicKey)
+	client, _ := startChatServerWith(t, func(token string) (*utils.UserClaims, error) {
+		return utils.ParseUserTokenWith(keys, token)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.ChatStream(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+signStreamToken(t, key, "refresh")))
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	joinChat(t, ctx, client, signStreamToken(t, key, "access"), 3)
+}
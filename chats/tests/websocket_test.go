@@ -0,0 +1,216 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/proto/dbchat"
+	"crmSystem/proto/logs"
+	"crmSystem/transport_rest"
+	"crmSystem/transport_rest/types"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// discardLogs accepts error logs of the handler.
+type discardLogs struct {
+	logs.LogsServiceClient
+}
+
+func (discardLogs) SaveLogs(context.Context, *logs.LogRequest, ...grpc.CallOption) (*logs.LogResponse, error) {
+	return &logs.LogResponse{}, nil
+}
+
+// recordingAcknowledger records delivery tags acknowledged by the handler.
+type recordingAcknowledger struct {
+	mu    sync.Mutex
+	acked []uint64
+}
+
+func (a *recordingAcknowledger) Ack(tag uint64, _ bool) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.acked = append(a.acked, tag)
+	return nil
+}
+
+func (a *recordingAcknowledger) Nack(uint64, bool, bool) error { return nil }
+func (a *recordingAcknowledger) Reject(uint64, bool) error     { return nil }
+
+func (a *recordingAcknowledger) tags() []uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]uint64(nil), a.acked...)
+}
+
+// openedSubscription arguments of a ChatSubscriptions.Open call.
+type openedSubscription struct {
+	database     string
+	chatID       string
+	userId       string
+	subscription string
+}
+
+// memorySubscriptions hands out a single prepared subscription and records opened ones.
+type memorySubscriptions struct {
+	mu         sync.Mutex
+	opened     []openedSubscription
+	deliveries chan amqp.Delivery
+	resumed    bool
+}
+
+func (s *memorySubscriptions) Open(database string, chatID string, userId string, subscription string,
+	_ int) (*transport_rest.ChatSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.opened = append(s.opened, openedSubscription{database: database, chatID: chatID, userId: userId, subscription: subscription})
+	return &transport_rest.ChatSubscription{
+		Deliveries: s.deliveries,
+		Resumed:    s.resumed,
+		Drop:       func() {},
+		Close:      func() {},
+	}, nil
+}
+
+func (s *memorySubscriptions) openedSubscriptions() []openedSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]openedSubscription(nil), s.opened...)
+}
+
+// newWebSocketHandler returns a REST handler whose dbservice reports users 7 and 8 as members of chat 3.
+func newWebSocketHandler(subscriptions *memorySubscriptions) http.Handler {
+	handler := transport_rest.NewHandlerWith(nil, transport_rest.Dependencies{
+		ParseToken: parseTestToken,
+		DialStore: func(token string) (dbchat.DbChatServiceClient, func() error, error) {
+			store := &fakeChatStore{}
+			if token != "token-9" {
+				store.chats = []*dbchat.ChatInfo{{ChatId: 3, ChatName: "general"}}
+			}
+			return store, func() error { return nil }, nil
+		},
+		DialLogs: func(string) (logs.LogsServiceClient, func() error, error) {
+			return discardLogs{}, func() error { return nil }, nil
+		},
+		Subscriptions: subscriptions,
+	})
+	return handler.InitRouter()
+}
+
+// chatDelivery is a chat message as it arrives from the chat exchange.
+func chatDelivery(t *testing.T, acknowledger amqp.Acknowledger, tag uint64, id int64) amqp.Delivery {
+	t.Helper()
+	body, err := json.Marshal(types.ChatMessage{ID: id, DBName: "company_db", ChatID: 3, UserID: 8, Content: "hello"})
+	require.NoError(t, err)
+	return amqp.Delivery{Acknowledger: acknowledger, DeliveryTag: tag, Body: body}
+}
+
+// TestChatWebSocketRequiresMembership checks users outside the chat are rejected before a subscription is created.
+func TestChatWebSocketRequiresMembership(t *testing.T) {
+	subscriptions := &memorySubscriptions{deliveries: make(chan amqp.Delivery)}
+	handler := newWebSocketHandler(subscriptions)
+
+	req := httptest.NewRequest(http.MethodGet, "/chats/3/ws", nil)
+	req.AddCookie(&http.Cookie{Name: "access_token", Value: "token-9"})
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Empty(t, subscriptions.openedSubscriptions())
+
+	// A forged token is rejected the same way, before dbservice is asked
+	req = httptest.NewRequest(http.MethodGet, "/chats/3/ws", nil)
+	req.AddCookie(&http.Cookie{Name: "access_token", Value: "forged"})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Empty(t, subscriptions.openedSubscriptions())
+}
+
+// TestChatWebSocketResume checks a reconnecting member continues its subscription and messages up to
+// last_message_id are acknowledged without being sent again.
+func TestChatWebSocketResume(t *testing.T) {
+	acknowledger := &recordingAcknowledger{}
+	subscriptions := &memorySubscriptions{deliveries: make(chan amqp.Delivery, 3), resumed: true}
+	subscriptions.deliveries <- chatDelivery(t, acknowledger, 1, 4)
+	subscriptions.deliveries <- chatDelivery(t, acknowledger, 2, 5)
+	subscriptions.deliveries <- chatDelivery(t, acknowledger, 3, 6)
+
+	server := httptest.NewServer(newWebSocketHandler(subscriptions))
+	defer server.Close()
+
+	subscription := strings.Repeat("ab", 16)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/chats/3/ws?subscription=" + subscription + "&last_message_id=5"
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Cookie": {"access_token=token-7"}})
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	var event types.ChatEvent
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, "subscribed", event.Type)
+	assert.Equal(t, subscription, event.Subscription)
+	assert.True(t, event.Resumed)
+
+	// Only the message the client has not seen yet is delivered
+	require.NoError(t, conn.ReadJSON(&event))
+	assert.Equal(t, "message", event.Type)
+	require.NotNil(t, event.Message)
+	assert.Equal(t, int64(6), event.Message.ID)
+
+	assert.Eventually(t, func() bool { return len(acknowledger.tags()) == 3 }, time.Second, 10*time.Millisecond)
+	assert.ElementsMatch(t, []uint64{1, 2, 3}, acknowledger.tags())
+	assert.Equal(t, []openedSubscription{{database: "company_db", chatID: "3", userId: "7", subscription: subscription}},
+		subscriptions.openedSubscriptions())
+}
+
+// TestChatWebSocketSubscriptionOfAnotherUser checks a member presenting another user's subscription gets
+// their own subscription and does not take over the owner's connection.
+func TestChatWebSocketSubscriptionOfAnotherUser(t *testing.T) {
+	subscriptions := &memorySubscriptions{deliveries: make(chan amqp.Delivery)}
+	server := httptest.NewServer(newWebSocketHandler(subscriptions))
+	defer server.Close()
+
+	subscription := strings.Repeat("cd", 16)
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/chats/3/ws?subscription=" + subscription
+	dial := func(token string) *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Cookie": {"access_token=" + token}})
+		require.NoError(t, err)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		var event types.ChatEvent
+		require.NoError(t, conn.ReadJSON(&event))
+		require.Equal(t, "subscribed", event.Type)
+		return conn
+	}
+
+	owner := dial("token-7")
+	defer owner.Close()
+	other := dial("token-8")
+	defer other.Close()
+
+	assert.Equal(t, []openedSubscription{
+		{database: "company_db", chatID: "3", userId: "7", subscription: subscription},
+		{database: "company_db", chatID: "3", userId: "8", subscription: subscription},
+	}, subscriptions.openedSubscriptions())
+
+	// The owner's connection stays open: reading only times out
+	require.NoError(t, owner.SetReadDeadline(time.Now().Add(300*time.Millisecond)))
+	_, _, err := owner.ReadMessage()
+	require.Error(t, err)
+	assert.False(t, websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.ClosePolicyViolation,
+		websocket.CloseGoingAway, websocket.CloseTryAgainLater), "the owner's connection was closed: %v", err)
+	var netErr interface{ Timeout() bool }
+	if assert.ErrorAs(t, err, &netErr) {
+		assert.True(t, netErr.Timeout())
+	}
+}
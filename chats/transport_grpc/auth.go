@@ -0,0 +1,66 @@
+package transport_grpc
+
+import (
+	"context"
+	"crmSystem/utils"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TokenParser проверяет access token и возвращает данные пользователя, в сервисе - utils.ParseUserToken
+type TokenParser func(token string) (*utils.UserClaims, error)
+
+// streamUser пользователь потока и его access token: токен передаётся в dbservice от имени пользователя
+type streamUser struct {
+	token  string
+	claims *utils.UserClaims
+}
+
+type streamUserContextKey struct{}
+
+// authenticatedStream поток с данными пользователя в контексте
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// NewAuthStreamInterceptor создаёт interceptor потоков, который проверяет access token из метаданных
+// authorization ("Bearer <token>") и передаёт данные пользователя обработчику через контекст.
+// База компании и id пользователя берутся только из проверенного токена.
+func NewAuthStreamInterceptor(parse TokenParser) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, ok := metadata.FromIncomingContext(ss.Context())
+		if !ok || len(md.Get("authorization")) == 0 {
+			return status.Errorf(codes.Unauthenticated, "токен не передан")
+		}
+		token, found := strings.CutPrefix(md.Get("authorization")[0], "Bearer ")
+		if !found || token == "" {
+			return status.Errorf(codes.Unauthenticated, "неверный формат заголовка authorization")
+		}
+
+		claims, err := parse(token)
+		if err != nil {
+			return status.Errorf(codes.Unauthenticated, "недействительный токен: %v", err)
+		}
+
+		ctx := context.WithValue(ss.Context(), streamUserContextKey{}, &streamUser{token: token, claims: claims})
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// userFromContext возвращает пользователя, установленного NewAuthStreamInterceptor
+func userFromContext(ctx context.Context) (*streamUser, error) {
+	user, ok := ctx.Value(streamUserContextKey{}).(*streamUser)
+	if !ok || user == nil {
+		return nil, status.Errorf(codes.Unauthenticated, "данные пользователя не найдены в токене")
+	}
+	return user, nil
+}
@@ -0,0 +1,123 @@
+package transport_grpc
+
+import (
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+
+	"github.com/streadway/amqp"
+)
+
+// subscriptionMaxLength максимальное количество сообщений в очереди чата экземпляра сервиса
+const subscriptionMaxLength = 1000
+
+// MessageBus доставляет сохранённые сообщения чатов всем экземплярам сервиса
+type MessageBus interface {
+	// Publish публикует сообщение в чат message.ChatID базы message.DBName
+	Publish(message types.ChatMessage) error
+
+	// Subscribe вызывает deliver для каждого сообщения и members для каждого изменения участников чата chatID
+	// базы database, пока не будет вызвана возвращённая функция отписки. closed вызывается, если подписка
+	// прервалась без отписки.
+	Subscribe(database string, chatID int64, deliver func(types.ChatMessage), members func(types.ChatMembersEvent),
+		closed func()) (func(), error)
+}
+
+// RabbitMQBus MessageBus через fanout обменники чатов RabbitMQ. Те же обменники получают сообщения
+// REST API, поэтому сообщения доходят до WebSocket, Server-Sent Events и gRPC клиентов.
+type RabbitMQBus struct {
+	conn *amqp.Connection
+}
+
+func NewRabbitMQBus(conn *amqp.Connection) *RabbitMQBus {
+	return &RabbitMQBus{conn: conn}
+}
+
+func (b *RabbitMQBus) Publish(message types.ChatMessage) error {
+	return utils.PublishChatMessage(b.conn, message)
+}
+
+// Subscribe создаёт временную очередь экземпляра сервиса, привязанную к обменнику чата.
+// Очередь удаляется при отписке.
+func (b *RabbitMQBus) Subscribe(database string, chatID int64, deliver func(types.ChatMessage), members func(types.ChatMembersEvent),
+	closed func()) (func(), error) {
+	channel, err := b.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+	closeChannel := func() {
+		if err := channel.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
+			log.Printf("Ошибка закрытия канала RabbitMQ: %v", err)
+		}
+	}
+
+	exchangeName := utils.ChatExchangeName(database, strconv.FormatInt(chatID, 10))
+	if err := utils.DeclareChatExchange(channel, exchangeName); err != nil {
+		closeChannel()
+		return nil, err
+	}
+
+	queue, err := channel.QueueDeclare(
+		"",    // Имя очереди (пустое, чтобы RabbitMQ сгенерировал уникальное имя)
+		false, // durable
+		true,  // autoDelete
+		true,  // exclusive
+		false, // noWait
+		amqp.Table{"x-max-length": int64(subscriptionMaxLength)},
+	)
+	if err != nil {
+		closeChannel()
+		return nil, err
+	}
+	if err := channel.QueueBind(queue.Name, "", exchangeName, false, nil); err != nil {
+		closeChannel()
+		return nil, err
+	}
+
+	// Рассылка клиентам не ожидает их, поэтому сообщения подтверждаются при получении
+	deliveries, err := channel.Consume(queue.Name, "", true, true, false, false, nil)
+	if err != nil {
+		closeChannel()
+		return nil, err
+	}
+
+	var unsubscribeOnce sync.Once
+	unsubscribed := make(chan struct{})
+	go func() {
+		for delivery := range deliveries {
+			if delivery.Type == utils.ChatMembersEventType {
+				var event types.ChatMembersEvent
+				if err := json.Unmarshal(delivery.Body, &event); err != nil {
+					log.Printf("Ошибка декодирования события участников: %v", err)
+					continue
+				}
+				members(event)
+				continue
+			}
+
+			var message types.ChatMessage
+			if err := json.Unmarshal(delivery.Body, &message); err != nil {
+				log.Printf("Ошибка декодирования сообщения: %v", err)
+				continue
+			}
+			deliver(message)
+		}
+		select {
+		case <-unsubscribed:
+		default:
+			// Канал или соединение с RabbitMQ закрыто
+			closed()
+		}
+	}()
+
+	return func() {
+		unsubscribeOnce.Do(func() {
+			close(unsubscribed)
+			closeChannel()
+		})
+	}, nil
+}
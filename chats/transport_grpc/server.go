@@ -0,0 +1,245 @@
+package transport_grpc
+
+import (
+	pb "crmSystem/proto/chats"
+	"crmSystem/proto/dbchat"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// chatStreamSendBuffer сколько ответов помещается в очередь отправки клиенту
+const chatStreamSendBuffer = 64
+
+// StoreDialer подключается к dbservice от имени пользователя с access token token.
+// Возвращает клиента и функцию закрытия соединения.
+type StoreDialer func(token string) (dbchat.DbChatServiceClient, func() error, error)
+
+// DialDbChatService StoreDialer через GRPC_PROXY_CONNECTOR
+func DialDbChatService(token string) (dbchat.DbChatServiceClient, func() error, error) {
+	return utils.DialService(token, dbchat.NewDbChatServiceClient)
+}
+
+// chatSubscription подписка экземпляра сервиса на сообщения чата, общая для всех его потоков
+type chatSubscription struct {
+	unsubscribe func()
+	streams     int
+}
+
+// ChatServer реализует ChatService. Экземпляр сервиса подписывается на сообщения чата один раз,
+// пока к чату подключён хотя бы один поток, и рассылает их потокам через utils.MapConnectionsChat.
+type ChatServer struct {
+	pb.UnimplementedChatServiceServer
+
+	bus       MessageBus
+	dialStore StoreDialer
+	streams   *utils.MapConnectionsChat // Потоки по ключу chatKey
+
+	mu            sync.Mutex
+	subscriptions map[string]*chatSubscription
+}
+
+func NewChatServer(bus MessageBus, dialStore StoreDialer) *ChatServer {
+	return &ChatServer{
+		bus:           bus,
+		dialStore:     dialStore,
+		streams:       utils.NewMapConnectionsChat(nil),
+		subscriptions: make(map[string]*chatSubscription),
+	}
+}
+
+// chatKey ключ чата: id чатов уникальны только в базе компании
+func chatKey(database string, chatID int64) string {
+	return fmt.Sprintf("%s:%d", database, chatID)
+}
+
+// CloseStreams закрывает все потоки, вызывается при остановке сервиса до GracefulStop:
+// иначе остановка ожидала бы отключения клиентов
+func (s *ChatServer) CloseStreams() {
+	s.streams.CloseAll()
+}
+
+// join подключает поток к рассылке чата, при первом потоке чата создаётся подписка
+func (s *ChatServer) join(database string, chatID int64, stream *chatStream) (*chatSubscription, error) {
+	key := chatKey(database, chatID)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscription := s.subscriptions[key]
+	if subscription == nil {
+		unsubscribe, err := s.bus.Subscribe(database, chatID,
+			func(message types.ChatMessage) {
+				_ = s.streams.BroadcastMessage(key, message)
+			},
+			func(event types.ChatMembersEvent) {
+				// Потоки удалённых пользователей закрываются при получении события (см. chatStream.Send)
+				_ = s.streams.BroadcastMessage(key, event)
+			},
+			func() {
+				// Потоки чата больше не получат сообщений, клиенты переподключатся
+				s.dropSubscription(key)
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		subscription = &chatSubscription{unsubscribe: unsubscribe}
+		s.subscriptions[key] = subscription
+	}
+	subscription.streams++
+	s.streams.AddChatStream(key, stream)
+	return subscription, nil
+}
+
+// leave отключает поток от рассылки чата, подписка удаляется вместе с последним потоком
+func (s *ChatServer) leave(database string, chatID int64, stream *chatStream, subscription *chatSubscription) {
+	key := chatKey(database, chatID)
+	s.streams.RemoveChatStream(key, stream)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscription.streams--
+	if subscription.streams == 0 {
+		subscription.unsubscribe()
+		if s.subscriptions[key] == subscription {
+			delete(s.subscriptions, key)
+		}
+	}
+}
+
+// dropSubscription удаляет прервавшуюся подписку и закрывает потоки чата
+func (s *ChatServer) dropSubscription(key string) {
+	s.mu.Lock()
+	delete(s.subscriptions, key)
+	s.mu.Unlock()
+
+	s.streams.CloseChatStreams(key)
+}
+
+// ChatStream подключает клиента к чату из первого сообщения потока. Сообщения клиента сохраняются
+// в dbservice и публикуются в обменник чата, клиент получает сообщения других участников чата.
+func (s *ChatServer) ChatStream(stream pb.ChatService_ChatStreamServer) error {
+	user, err := userFromContext(stream.Context())
+	if err != nil {
+		return err
+	}
+	database := user.claims.Database
+	userId, err := strconv.ParseInt(user.claims.UserId, 10, 64)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "некорректный id пользователя в токене")
+	}
+
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	join := first.GetJoin()
+	if join == nil || join.ChatId <= 0 {
+		return status.Errorf(codes.InvalidArgument, "первое сообщение потока должно подключать к чату")
+	}
+	chatID := join.ChatId
+
+	// Подключение к gRPC серверу dbService на время потока
+	client, closeStore, err := s.dialStore(user.token)
+	if err != nil {
+		log.Printf("Ошибка подключения к dbchatclient: %v", err)
+		return status.Errorf(codes.Unavailable, "Ошибка подключения к dbchatclient")
+	}
+	defer func() {
+		if err := closeStore(); err != nil {
+			log.Printf("Ошибка закрытия канала NewDbChatServiceClient: %v", err)
+		}
+	}()
+
+	if err := utils.CheckChatMember(stream.Context(), client, chatID); err != nil {
+		return err
+	}
+
+	cs := newChatStream(chatStreamSendBuffer, userId)
+	subscription, err := s.join(database, chatID, cs)
+	if err != nil {
+		log.Printf("Ошибка подписки на сообщения чата %d: %v", chatID, err)
+		return status.Errorf(codes.Unavailable, "Ошибка подписки на сообщения чата")
+	}
+	defer s.leave(database, chatID, cs, subscription)
+
+	_ = cs.Send(&pb.ChatStreamResponse{Response: &pb.ChatStreamResponse_Joined{Joined: &pb.JoinedChat{ChatId: chatID}}})
+
+	recvDone := make(chan error, 1)
+	go func() {
+		recvDone <- s.receiveMessages(stream, client, database, userId, chatID, cs)
+	}()
+	return cs.run(stream, recvDone)
+}
+
+// receiveMessages сохраняет и публикует сообщения клиента, пока клиент не завершит отправку.
+// Клиент получает MessageSent после публикации сообщения.
+func (s *ChatServer) receiveMessages(stream pb.ChatService_ChatStreamServer, client dbchat.DbChatServiceClient, database string, userId int64, chatID int64, cs *chatStream) error {
+	for {
+		request, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		send := request.GetSend()
+		if send == nil {
+			return status.Errorf(codes.InvalidArgument, "поток уже подключён к чату, ожидается сообщение")
+		}
+		if strings.TrimSpace(send.Content) == "" {
+			return status.Errorf(codes.InvalidArgument, "сообщение не может быть пустым")
+		}
+
+		saved, err := client.SaveMessage(stream.Context(), &dbchat.SaveMessageRequest{
+			ChatId:  chatID,
+			Content: send.Content,
+			Time:    timestamppb.Now(),
+		})
+		if err != nil {
+			log.Printf("Ошибка сохранения сообщения в базе данных: %v", err)
+			return status.Errorf(status.Code(err), "Ошибка сохранения сообщения в базе данных: %s", status.Convert(err).Message())
+		}
+
+		message := types.ChatMessage{
+			ID:      saved.MessageId,
+			DBName:  database,
+			ChatID:  chatID,
+			UserID:  userId,
+			Content: send.Content,
+			Time:    time.Unix(saved.CreatedAt, 0),
+		}
+
+		// Сообщение отмечается до публикации, иначе рассылка могла бы вернуть его отправителю
+		cs.markSent(message.ID)
+		if err := s.bus.Publish(message); err != nil {
+			log.Printf("Ошибка отправки сообщения в RabbitMQ: %v", err)
+			return status.Errorf(codes.Unavailable, "Сообщение сохранено, но не отправлено участникам чата")
+		}
+
+		if err := cs.Send(&pb.ChatStreamResponse{Response: &pb.ChatStreamResponse_Sent{Sent: &pb.MessageSent{
+			RequestId: send.RequestId,
+			MessageId: message.ID,
+			CreatedAt: saved.CreatedAt,
+		}}}); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package transport_grpc
+
+import (
+	pb "crmSystem/proto/chats"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// chatStream поток ChatStream одного клиента, реализует utils.StreamInterface.
+// Ответы ставятся в ограниченную очередь отправки и записываются в поток методом run:
+// рассылка сообщений чата не ожидает медленного клиента.
+type chatStream struct {
+	userId int64 // Пользователь потока
+	outbox chan *pb.ChatStreamResponse
+
+	mu   sync.Mutex
+	sent map[int64]struct{} // id сообщений, отправленных этим клиентом: они не возвращаются ему из рассылки
+
+	closeOnce sync.Once
+	done      chan struct{}
+	closeErr  error
+}
+
+func newChatStream(buffer int, userId int64) *chatStream {
+	return &chatStream{
+		userId: userId,
+		outbox: make(chan *pb.ChatStreamResponse, buffer),
+		sent:   make(map[int64]struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// markSent запоминает id сообщения клиента до его публикации
+func (s *chatStream) markSent(messageID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent[messageID] = struct{}{}
+}
+
+// ownMessage проверяет, что сообщение отправлено этим клиентом. Сообщение из рассылки приходит один раз,
+// поэтому id удаляется после проверки
+func (s *chatStream) ownMessage(messageID int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.sent[messageID]; ok {
+		delete(s.sent, messageID)
+		return true
+	}
+	return false
+}
+
+// Send ставит ответ в очередь отправки без ожидания. Сообщение может быть *pb.ChatStreamResponse,
+// types.ChatMessage или types.ChatMembersEvent из рассылки чата. Если очередь заполнена, поток закрывается
+// с ошибкой ResourceExhausted: клиент переподключится, а не будет получать сообщения с задержкой.
+// Если событие удаляет пользователя потока из чата, поток закрывается с ошибкой PermissionDenied.
+func (s *chatStream) Send(message interface{}) error {
+	var response *pb.ChatStreamResponse
+	switch m := message.(type) {
+	case *pb.ChatStreamResponse:
+		response = m
+	case types.ChatMessage:
+		if s.ownMessage(m.ID) {
+			return nil
+		}
+		response = &pb.ChatStreamResponse{Response: &pb.ChatStreamResponse_Message{Message: &pb.ChatStreamMessage{
+			MessageId: m.ID,
+			ChatId:    m.ChatID,
+			UserId:    m.UserID,
+			Content:   m.Content,
+			CreatedAt: m.Time.Unix(),
+		}}}
+	case types.ChatMembersEvent:
+		if m.Removes(s.userId) {
+			s.closeWithError(status.Errorf(codes.PermissionDenied, "пользователь удалён из чата %d", m.ChatID))
+			return nil
+		}
+		response = &pb.ChatStreamResponse{Response: &pb.ChatStreamResponse_Members{Members: &pb.MembersChanged{
+			Type:      m.Type,
+			ChatId:    m.ChatID,
+			UserIds:   m.UserIDs,
+			ActorId:   m.ActorID,
+			ChangedAt: m.Time.Unix(),
+		}}}
+	default:
+		return status.Errorf(codes.Internal, "неизвестный тип сообщения %T", message)
+	}
+
+	select {
+	case <-s.done:
+		return utils.ErrStreamClosed
+	default:
+	}
+
+	select {
+	case s.outbox <- response:
+		return nil
+	default:
+		s.closeWithError(status.Errorf(codes.ResourceExhausted, "%v", utils.ErrSlowClient))
+		return utils.ErrSlowClient
+	}
+}
+
+// Close закрывает поток, клиент получает ошибку Unavailable и переподключается.
+func (s *chatStream) Close() error {
+	s.closeWithError(status.Errorf(codes.Unavailable, "сервис чатов останавливается"))
+	return nil
+}
+
+// closeWithError закрывает поток, err возвращается клиенту. Повторные вызовы ничего не делают.
+func (s *chatStream) closeWithError(err error) {
+	s.closeOnce.Do(func() {
+		s.closeErr = err
+		close(s.done)
+	})
+}
+
+// IsClosed проверяет, закрыт ли поток.
+func (s *chatStream) IsClosed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// run записывает ответы из очереди отправки в поток, пока поток не будет закрыт, клиент не отключится
+// или чтение запросов не завершится (recvDone). Возвращает ошибку для клиента.
+func (s *chatStream) run(stream pb.ChatService_ChatStreamServer, recvDone <-chan error) error {
+	for {
+		select {
+		case response := <-s.outbox:
+			if err := stream.Send(response); err != nil {
+				return err
+			}
+
+		case err := <-recvDone:
+			s.closeWithError(err)
+			if err != nil {
+				return err
+			}
+			// Клиент завершил отправку: ответы на его последние сообщения ещё в очереди
+			for {
+				select {
+				case response := <-s.outbox:
+					if err := stream.Send(response); err != nil {
+						return err
+					}
+				default:
+					return nil
+				}
+			}
+
+		case <-s.done:
+			return s.closeErr
+
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		}
+	}
+}
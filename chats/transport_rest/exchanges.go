@@ -0,0 +1,57 @@
+package transport_rest
+
+import (
+	"crmSystem/transport_rest/types"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/streadway/amqp"
+)
+
+// notificationsExchange обменник уведомлений пользователей об изменении их чатов.
+// Ключ маршрутизации - пользователь (см. userNotificationKey)
+const notificationsExchange = "chat_notifications"
+
+// userNotificationKey ключ маршрутизации уведомлений пользователя
+func userNotificationKey(database string, userId string) string {
+	return fmt.Sprintf("%s.%s", database, userId)
+}
+
+// declareNotificationsExchange создаёт обменник уведомлений, если его ещё нет
+func declareNotificationsExchange(channel *amqp.Channel) error {
+	return channel.ExchangeDeclare(notificationsExchange, "direct", true, false, false, false, nil)
+}
+
+// publishChatNotification отправляет уведомление каждому из пользователей базы компании database
+func (h *Handler) publishChatNotification(database string, users []types.UserID, notification types.ChatNotification) error {
+	channel, err := h.rabbitMQConn.Channel()
+	if err != nil {
+		return fmt.Errorf("Ошибка подключения к каналу RabbitMQ: %v", err)
+	}
+	defer func(channel *amqp.Channel) {
+		if err := channel.Close(); err != nil {
+			log.Printf("Ошибка закрытия канала rabbitMQConn: %v", err)
+		}
+	}(channel)
+
+	if err := declareNotificationsExchange(channel); err != nil {
+		return fmt.Errorf("Ошибка создания обменника уведомлений: %v", err)
+	}
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("Ошибка сериализации уведомления: %v", err)
+	}
+
+	for _, user := range users {
+		routingKey := userNotificationKey(database, fmt.Sprint(user.UserId))
+		if err := channel.Publish(notificationsExchange, routingKey, false, false, amqp.Publishing{
+			ContentType: "application/json",
+			Body:        body,
+		}); err != nil {
+			return fmt.Errorf("Ошибка публикации уведомления: %v", err)
+		}
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package transport_rest
 
 import (
 	"context"
+	"crmSystem/lifecycle"
 	"crmSystem/proto/logs"
 	"crmSystem/transport_rest/types"
 	"crmSystem/utils"
@@ -9,11 +10,11 @@ import (
 	"fmt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"crmSystem/proto/dbchat" // Импорт gRPC-протокола
@@ -25,26 +26,77 @@ type Handler struct {
 	rabbitMQConn *amqp.Connection
 	clients      map[string]*amqp.Queue
 	grpcClient   dbchat.DbChatServiceClient // gRPC клиент
+	streams      *utils.MapConnectionsChat  // WebSocket соединения по chatID
+	deps         Dependencies
+}
+
+// Dependencies проверка токенов, подключения к сервисам и подписки на чаты, которые использует обработчик.
+// NewHandler берёт рабочие реализации, в тестах они заменяются.
+type Dependencies struct {
+	ParseToken    func(token string) (*utils.UserClaims, error)                        // Проверка access token
+	DialStore     func(token string) (dbchat.DbChatServiceClient, func() error, error) // Подключение к dbservice
+	DialLogs      func(token string) (logs.LogsServiceClient, func() error, error)     // Подключение к Logs
+	Subscriptions ChatSubscriptions                                                    // Подписки WebSocket клиентов
 }
 
 func NewHandler(rabbitMQConn *amqp.Connection) *Handler {
+	return NewHandlerWith(rabbitMQConn, Dependencies{
+		ParseToken: utils.ParseUserToken,
+		DialStore: func(token string) (dbchat.DbChatServiceClient, func() error, error) {
+			return utils.DialService(token, dbchat.NewDbChatServiceClient)
+		},
+		DialLogs: func(token string) (logs.LogsServiceClient, func() error, error) {
+			return utils.DialService(token, logs.NewLogsServiceClient)
+		},
+		Subscriptions: NewRabbitMQSubscriptions(rabbitMQConn),
+	})
+}
+
+// NewHandlerWith создаёт обработчик с зависимостями deps
+func NewHandlerWith(rabbitMQConn *amqp.Connection, deps Dependencies) *Handler {
 	return &Handler{
 		rabbitMQConn: rabbitMQConn,
 		clients:      make(map[string]*amqp.Queue),
+		streams:      utils.NewMapConnectionsChat(nil),
+		deps:         deps,
 	}
 }
 
+// userFromToken проверяет access_token из cookie. При ошибке записывает ответ и возвращает nil
+func (h *Handler) userFromToken(w http.ResponseWriter, r *http.Request) (string, *utils.UserClaims) {
+	return utils.UserFromToken(w, r, h.deps.ParseToken)
+}
+
 func (h *Handler) InitRouter() *mux.Router {
 	r := mux.NewRouter()
+
+	// Проверки состояния сервиса для docker-compose: сообщения чатов передаются через RabbitMQ
+	r.Handle("/healthz", lifecycle.LivenessHandler()).Methods(http.MethodGet)
+	r.Handle("/readyz", lifecycle.ReadinessHandler(lifecycle.HealthCheck{Name: "rabbitmq", Check: h.checkRabbitMQ})).Methods(http.MethodGet)
 	chatsRouts := r.PathPrefix("/chats").Subrouter()
 	{
+		chatsRouts.HandleFunc("/stream", utils.RecoverMiddleware(h.ChatStream)).Methods(http.MethodGet)
 		chatsRouts.HandleFunc("/createNewChat", utils.RecoverMiddleware(h.CreateNewChat)).Methods(http.MethodPost)
 		chatsRouts.HandleFunc("/{chatID}/sendMessage", utils.RecoverMiddleware(h.SendMessage)).Methods(http.MethodPost)
 		chatsRouts.HandleFunc("/{chatID}/messages", utils.RecoverMiddleware(h.GetMessages)).Methods(http.MethodGet)
+		chatsRouts.HandleFunc("/{chatID}/history", utils.RecoverMiddleware(h.GetHistory)).Methods(http.MethodGet)
+		chatsRouts.HandleFunc("/{chatID}/members", utils.RecoverMiddleware(h.ListMembers)).Methods(http.MethodGet)
+		chatsRouts.HandleFunc("/{chatID}/members", utils.RecoverMiddleware(h.AddMembers)).Methods(http.MethodPost)
+		chatsRouts.HandleFunc("/{chatID}/members", utils.RecoverMiddleware(h.RemoveMembers)).Methods(http.MethodDelete)
+		chatsRouts.HandleFunc("/{chatID}/leave", utils.RecoverMiddleware(h.LeaveChat)).Methods(http.MethodPost)
+		chatsRouts.HandleFunc("/{chatID}/ws", utils.RecoverMiddleware(h.ChatWebSocket)).Methods(http.MethodGet)
 	}
 	return r
 }
 
+// checkRabbitMQ проверяет, что соединение с RabbitMQ не закрыто.
+func (h *Handler) checkRabbitMQ(context.Context) error {
+	if h.rabbitMQConn == nil || h.rabbitMQConn.IsClosed() {
+		return fmt.Errorf("соединение с RabbitMQ закрыто")
+	}
+	return nil
+}
+
 func convertToProtoUsers(users []types.UserID) []*dbchat.UserId {
 	protoUsers := make([]*dbchat.UserId, len(users))
 	for i, user := range users {
@@ -58,30 +110,16 @@ func convertToProtoUsers(users []types.UserID) []*dbchat.UserId {
 
 func (h *Handler) CreateNewChat(w http.ResponseWriter, r *http.Request) {
 
-	// Получаем cookie с именами
-	token := utils.GetFromCookies(w, r, "access-token")
-	if token == "" {
-		return
-	}
-
-	userId := utils.GetFromCookies(w, r, "user-id")
-	if userId == "" {
+	// Получаем токен и данные пользователя из подписанного access token,
+	// в dbservice они не передаются в метаданных: он берёт их из проверенного токена
+	token, user := h.userFromToken(w, r)
+	if user == nil {
 		return
 	}
+	database := user.Database
+	userId := user.UserId
 
-	database := utils.GetFromCookies(w, r, "database")
-	if database == "" {
-		return
-	}
-
-	md := metadata.Pairs(
-		"user-id", userId,
-		"database", database,
-	)
-
-	ctxWithMetadata := metadata.NewOutgoingContext(context.Background(), md)
-
-	ctxWithMetadata, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctxWithMetadata, cancel := context.WithTimeout(r.Context(), time.Second*10)
 	defer cancel()
 
 	// Устанавливаем соединение с gRPC сервером Logs
@@ -178,6 +216,17 @@ func (h *Handler) CreateNewChat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Участники чата, подключённые к /chats/stream, начинают получать его сообщения без переподключения.
+	// Чат уже создан, поэтому ошибка уведомления только записывается в логи
+	notification := types.ChatNotification{Type: "chat_created", ChatID: res.ChatId, ChatName: req.ChatName}
+	if err := h.publishChatNotification(database, req.UsersId, notification); err != nil {
+		log.Printf("Ошибка отправки уведомления о создании чата: %v", err)
+		errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, err.Error())
+		if errLogs != nil {
+			log.Printf("Ошибка отправки уведомления о создании чата: %v", err)
+		}
+	}
+
 	w.WriteHeader(http.StatusOK)
 	_, err = w.Write([]byte("Чат создан и опубликован в RabbitMQ успешно"))
 	if err != nil {
@@ -255,31 +304,22 @@ func (h *Handler) SendMessage(w http.ResponseWriter, r *http.Request) {
 	//Данные из параметров маршрута /chats/{chatID}
 	vars := mux.Vars(r)
 	chatID := vars["chatID"]
-
-	// Получаем cookie с именами
-	token := utils.GetFromCookies(w, r, "access-token")
-	if token == "" {
-		return
-	}
-
-	userId := utils.GetFromCookies(w, r, "user-id")
-	if userId == "" {
+	parsedChatID, err := strconv.ParseInt(chatID, 10, 64)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Некорректный id чата", err)
 		return
 	}
 
-	database := utils.GetFromCookies(w, r, "database")
-	if database == "" {
+	// Получаем токен и данные пользователя из подписанного access token,
+	// в dbservice они не передаются в метаданных: он берёт их из проверенного токена
+	token, user := h.userFromToken(w, r)
+	if user == nil {
 		return
 	}
+	database := user.Database
+	userId := user.UserId
 
-	md := metadata.Pairs(
-		"user-id", userId,
-		"database", database,
-	)
-
-	ctxWithMetadata := metadata.NewOutgoingContext(context.Background(), md)
-
-	ctxWithMetadata, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctxWithMetadata, cancel := context.WithTimeout(r.Context(), time.Second*10)
 	defer cancel()
 
 	// Устанавливаем соединение с gRPC сервером Logs
@@ -312,191 +352,83 @@ func (h *Handler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Устанавливаем текущее время для сообщения
-	message.Time = time.Now()
-
-	// Канал для сбора ошибок из горутин
-	errChan := make(chan error, 2)
-	defer close(errChan)
+	// Чат, автор и база компании берутся из маршрута и токена, а не из тела запроса
+	message.ChatID = parsedChatID
+	message.DBName = database
+	message.UserID, _ = strconv.ParseInt(userId, 10, 64)
 
-	// Асинхронная отправка в RabbitMQ
-	go func() {
-		channel, err := h.rabbitMQConn.Channel()
-		if err != nil {
-			errChan <- fmt.Errorf("Ошибка подключения к каналу RabbitMQ: %v", err)
-			errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, err.Error())
-			if errLogs != nil {
-				log.Printf("Ошибка подключения к каналу RabbitMQ: %v", err)
-			}
-			return
+	// Подключение к gRPC серверу dbService
+	client, err, conn := utils.GRPCServiceConnector(token, dbchat.NewDbChatServiceClient)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу: %v", err)
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения к dbchatclient", err)
+		errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, err.Error())
+		if errLogs != nil {
+			log.Printf("Ошибка подключения к gRPC серверу: %v", err)
 		}
-		defer func(channel *amqp.Channel) {
-			err := channel.Close()
+		return
+	} else {
+		defer func(conn *grpc.ClientConn) {
+			err := conn.Close()
 			if err != nil {
 				errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, err.Error())
 				if errLogs != nil {
-					log.Printf("Ошибка закрытия канала rabbitMQConn: %v", err)
-				}
-			}
-		}(channel)
-
-		// Объявление обменника
-		exchangeName := fmt.Sprintf("chat_exchange_%s", chatID)
-		if err := channel.ExchangeDeclare(
-			exchangeName, "fanout", true, false, false, false, nil,
-		); err != nil {
-			errChan <- fmt.Errorf("Ошибка создания обменника: %v", err)
-			errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, err.Error())
-			if errLogs != nil {
-				log.Printf("Ошибка создания обменника: %v", err)
-			}
-			return
-		}
-
-		// Сериализация сообщения
-		body, err := json.Marshal(message)
-		if err != nil {
-			errChan <- fmt.Errorf("Ошибка сериализации сообщения: %v", err)
-			errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, err.Error())
-			if errLogs != nil {
-				log.Printf("Ошибка сериализации сообщения: %v", err)
-			}
-			return
-		}
-
-		// Публикация сообщения
-		if err := channel.Publish(exchangeName, "", false, false, amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		}); err != nil {
-			errChan <- fmt.Errorf("Ошибка публикации сообщения: %v", err)
-			errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, err.Error())
-			if errLogs != nil {
-				log.Printf("Ошибка публикации сообщения: %v", err)
-			}
-			return
-		}
-
-		errChan <- nil
-	}()
-
-	// Асинхронное сохранение в базу данных через gRPC
-	go func() {
-		client, err, conn := utils.GRPCServiceConnector(token, dbchat.NewDbChatServiceClient)
-		if err != nil {
-			errChan <- fmt.Errorf("Ошибка подключения к gRPC серверу: %v", err)
-			errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, err.Error())
-			if errLogs != nil {
-				log.Printf("Ошибка подключения к gRPC серверу: %v", err)
-			}
-			return
-		} else {
-			defer func(conn *grpc.ClientConn) {
-				err := conn.Close()
-				if err != nil {
-					errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, err.Error())
-					if errLogs != nil {
-						log.Printf("Ошибка закрытия канала NewDbChatServiceClient: %v", err)
-					}
+					log.Printf("Ошибка закрытия канала NewDbChatServiceClient: %v", err)
 				}
-			}(conn)
-		}
-
-		// Формирование запроса
-		req := &dbchat.SaveMessageRequest{
-			ChatId:  message.ChatID,
-			Content: message.Content,
-			Time:    timestamppb.New(message.Time),
-		}
-
-		_, err = client.SaveMessage(ctxWithMetadata, req)
-		if err != nil {
-			log.Printf("Ошибка сохранения сообщения в базе данных: %v", err)
-			errChan <- err
-			errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, err.Error())
-			if errLogs != nil {
-				log.Printf("Ошибка сохранения сообщения в базе данных: %v", err)
 			}
-			return
-		}
-
-		errChan <- nil
-	}()
-
-	// Обработка результатов выполнения горутин
-	var rabbitErr, dbErr error
-	for i := 0; i < 2; i++ {
-		if err := <-errChan; err != nil {
-			if rabbitErr == nil {
-				rabbitErr = err
-			} else {
-				dbErr = err
-			}
-		}
+		}(conn)
 	}
 
-	// Ответ клиенту в зависимости от результатов
-	if rabbitErr == nil && dbErr == nil {
-		w.WriteHeader(http.StatusOK)
-		_, err := w.Write([]byte("Сообщение отправлено и сохранено"))
-		if err != nil {
-			utils.CreateError(w, http.StatusInternalServerError, fmt.Sprintf("некорректная ошибка : %s", err), rabbitErr)
-		}
-	} else if rabbitErr != nil && dbErr != nil {
-		utils.CreateError(w, http.StatusInternalServerError, "Ошибка отправки и сохранения сообщения", fmt.Errorf("%v; %v", rabbitErr, dbErr))
-		errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, dbErr.Error())
-		if errLogs != nil {
-			log.Printf("Ошибка отправки и сохранения сообщения: %v", err)
-		}
-	} else if rabbitErr != nil {
+	// Сообщение сохраняется до публикации: получатели узнают id сохранённого сообщения
+	// и передают его при переподключении к WebSocket (last_message_id)
+	saved, err := client.SaveMessage(ctxWithMetadata, &dbchat.SaveMessageRequest{
+		ChatId:  message.ChatID,
+		Content: message.Content,
+		Time:    timestamppb.New(time.Now()),
+	})
+	if err != nil {
 		// Получаем сообщение об ошибке
-		errorMessage := status.Convert(rabbitErr).Message()
-
-		// Код ошибки
-		code := status.Code(rabbitErr)
+		errorMessage := status.Convert(err).Message()
 
 		// Логика в зависимости от кода ошибки
-		switch code {
+		switch status.Code(err) {
 		case codes.Unauthenticated:
-			errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, errorMessage)
-			if errLogs != nil {
-				log.Printf("неизвестная ошибка: %v", errorMessage)
-			}
-			utils.CreateError(w, http.StatusBadRequest, fmt.Sprintf("неизвестная ошибка : %s", errorMessage), rabbitErr)
+			utils.CreateError(w, http.StatusBadRequest, fmt.Sprintf("неизвестная ошибка : %s", errorMessage), err)
+		case codes.PermissionDenied:
+			// Пользователь не состоит в чате или был удалён из него
+			utils.CreateError(w, http.StatusForbidden, errorMessage, err)
+			return
 		default:
-			errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, rabbitErr.Error())
-			if errLogs != nil {
-				log.Printf("Ошибка отправки сообщения в RabbitMQ: %v", rabbitErr)
-			}
-			utils.CreateError(w, http.StatusInternalServerError, "Ошибка отправки сообщения в RabbitMQ.", rabbitErr)
+			utils.CreateError(w, http.StatusInternalServerError, "Ошибка сохранения сообщения в базе данных", err)
 		}
+		errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, err.Error())
+		if errLogs != nil {
+			log.Printf("Ошибка сохранения сообщения в базе данных: %v", err)
+		}
+		return
+	}
+	message.ID = saved.MessageId
+	message.Time = time.Unix(saved.CreatedAt, 0)
 
-	} else {
-		// Получаем сообщение об ошибке
-		errorMessage := status.Convert(rabbitErr).Message()
-
-		// Код ошибки
-		code := status.Code(rabbitErr)
-
-		// Логика в зависимости от кода ошибки
-		switch code {
-		case codes.Unauthenticated:
-			errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, dbErr.Error())
-			if errLogs != nil {
-				log.Printf("Ошибка отправки и сохранения сообщения: %v", rabbitErr)
-			}
-			utils.CreateError(w, http.StatusBadRequest, fmt.Sprintf("неизвестная ошибка : %s", errorMessage), rabbitErr)
-		default:
-			errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, dbErr.Error())
-			if errLogs != nil {
-				log.Printf("Ошибка отправки и сохранения сообщения: %v", rabbitErr)
-			}
-			utils.CreateError(w, http.StatusInternalServerError, "Ошибка сохранения сообщения в базе данных", dbErr)
+	// Публикация сообщения в обменник чата
+	if err := utils.PublishChatMessage(h.rabbitMQConn, message); err != nil {
+		// Сообщение уже сохранено в базе данных, но подписчики чата его не получат
+		utils.CreateError(w, http.StatusInternalServerError, "Сообщение сохранено, но не отправлено в RabbitMQ", err)
+		errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, err.Error())
+		if errLogs != nil {
+			log.Printf("Ошибка отправки сообщения в RabbitMQ: %v", err)
 		}
+		return
+	}
 
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte("Сообщение отправлено и сохранено")); err != nil {
+		log.Printf("Ошибка при записи ответа: %v", err)
 	}
 }
 
+// GetMessages собирает сообщения чата в течение 5 секунд. Сообщения, опубликованные между запросами,
+// не доставляются: для получения сообщений в реальном времени используется ChatWebSocket (/chats/{chatID}/ws)
 func (h *Handler) GetMessages(w http.ResponseWriter, r *http.Request) {
 
 	//TODO Add Get FROM DB
@@ -504,29 +436,16 @@ func (h *Handler) GetMessages(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	chatID := vars["chatID"]
 
-	token := utils.GetFromCookies(w, r, "access-token")
-	if token == "" {
-		return
-	}
-
-	userId := utils.GetFromCookies(w, r, "user-id")
-	if userId == "" {
-		return
-	}
-
-	database := utils.GetFromCookies(w, r, "database")
-	if database == "" {
+	// Получаем токен и данные пользователя из подписанного access token,
+	// в dbservice они не передаются в метаданных: он берёт их из проверенного токена
+	token, user := h.userFromToken(w, r)
+	if user == nil {
 		return
 	}
+	database := user.Database
+	userId := user.UserId
 
-	md := metadata.Pairs(
-		"user-id", userId,
-		"database", database,
-	)
-
-	ctxWithMetadata := metadata.NewOutgoingContext(context.Background(), md)
-
-	ctxWithMetadata, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctxWithMetadata, cancel := context.WithTimeout(r.Context(), time.Second*10)
 	defer cancel()
 
 	// Устанавливаем соединение с gRPC сервером Logs
@@ -570,7 +489,7 @@ func (h *Handler) GetMessages(w http.ResponseWriter, r *http.Request) {
 	}(channel)
 
 	// Имя обменника
-	exchangeName := fmt.Sprintf("chat_exchange_%s", chatID)
+	exchangeName := utils.ChatExchangeName(database, chatID)
 
 	// Создаем временную уникальную очередь для каждого клиента
 	queue, err := channel.QueueDeclare(
@@ -625,7 +544,15 @@ func (h *Handler) GetMessages(w http.ResponseWriter, r *http.Request) {
 loop:
 	for {
 		select {
-		case msg := <-messages:
+		case msg, ok := <-messages:
+			if !ok {
+				// Канал RabbitMQ закрыт
+				break loop
+			}
+			// События изменения участников не входят в список сообщений
+			if msg.Type == utils.ChatMembersEventType {
+				continue
+			}
 			var message types.ChatMessage
 			if err := json.Unmarshal(msg.Body, &message); err != nil {
 				log.Printf("Ошибка декодирования сообщения: %v", err)
@@ -639,6 +566,9 @@ loop:
 		case <-timeout:
 			/*log.Println("Тайм-аут ожидания сообщений")*/
 			break loop
+		case <-r.Context().Done():
+			// Клиент отключился или сервис останавливается: возвращаем уже полученные сообщения
+			break loop
 		}
 	}
 
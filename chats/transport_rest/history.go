@@ -0,0 +1,144 @@
+package transport_rest
+
+import (
+	"context"
+	"crmSystem/proto/dbchat"
+	"crmSystem/proto/logs"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetHistory возвращает страницу истории чата из базы данных (GET /chats/{chatID}/history).
+//
+// Параметры: before - сообщения старее сообщения с этим id, after - новее, limit - размер страницы
+// (по умолчанию 50, не больше 200). Без before и after возвращаются последние сообщения. Для загрузки
+// более старых сообщений клиент передаёт before = id первого сообщения страницы, пока has_more = true.
+// Историю может читать только участник чата.
+func (h *Handler) GetHistory(w http.ResponseWriter, r *http.Request) {
+
+	//Данные из параметров маршрута /chats/{chatID}
+	vars := mux.Vars(r)
+	chatID, err := strconv.ParseInt(vars["chatID"], 10, 64)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Некорректный id чата", err)
+		return
+	}
+
+	request := &dbchat.ListMessagesRequest{ChatId: chatID}
+	query := r.URL.Query()
+	for name, value := range map[string]*int64{"before": &request.BeforeId, "after": &request.AfterId} {
+		if query.Get(name) == "" {
+			continue
+		}
+		parsed, err := strconv.ParseInt(query.Get(name), 10, 64)
+		if err != nil || parsed <= 0 {
+			utils.CreateError(w, http.StatusBadRequest, "Некорректный параметр "+name, err)
+			return
+		}
+		*value = parsed
+	}
+	if query.Get("limit") != "" {
+		limit, err := strconv.ParseInt(query.Get("limit"), 10, 32)
+		if err != nil || limit <= 0 {
+			utils.CreateError(w, http.StatusBadRequest, "Некорректный параметр limit", err)
+			return
+		}
+		request.Limit = int32(limit)
+	}
+
+	// Получаем токен и данные пользователя из подписанного access token
+	token, user := h.userFromToken(w, r)
+	if user == nil {
+		return
+	}
+	database := user.Database
+	userId := user.UserId
+
+	ctxWithMetadata, cancel := context.WithTimeout(r.Context(), time.Second*10)
+	defer cancel()
+
+	// Устанавливаем соединение с gRPC сервером Logs
+	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу: %v", err)
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	} else {
+		defer func(conn *grpc.ClientConn) {
+			if err := conn.Close(); err != nil {
+				log.Printf("Ошибка закрытия соединения: %v", err)
+			}
+		}(conn)
+	}
+	saveError := func(message string, err error) {
+		log.Printf("%s: %v", message, err)
+		errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, err.Error())
+		if errLogs != nil {
+			log.Printf("%s: %v", message, err)
+		}
+	}
+
+	// Подключение к gRPC серверу dbService
+	client, err, conn := utils.GRPCServiceConnector(token, dbchat.NewDbChatServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения к dbchatclient", err)
+		saveError("Ошибка подключения к gRPC серверу", err)
+		return
+	} else {
+		defer func(conn *grpc.ClientConn) {
+			if err := conn.Close(); err != nil {
+				log.Printf("Ошибка закрытия канала NewDbChatServiceClient: %v", err)
+			}
+		}(conn)
+	}
+
+	// Участие пользователя в чате проверяет dbservice по таблице chat_users
+	page, err := client.ListMessages(ctxWithMetadata, request)
+	if err != nil {
+		errorMessage := status.Convert(err).Message()
+		switch status.Code(err) {
+		case codes.PermissionDenied:
+			utils.CreateError(w, http.StatusForbidden, errorMessage, err)
+		case codes.InvalidArgument:
+			utils.CreateError(w, http.StatusBadRequest, errorMessage, err)
+		default:
+			utils.CreateError(w, http.StatusInternalServerError, "Ошибка получения истории чата", err)
+			saveError("Ошибка получения истории чата", err)
+		}
+		return
+	}
+
+	response := types.ChatHistoryResponse{
+		Messages: make([]types.HistoryMessage, 0, len(page.Messages)),
+		HasMore:  page.HasMore,
+	}
+	for _, message := range page.Messages {
+		response.Messages = append(response.Messages, types.HistoryMessage{
+			ID:      message.MessageId,
+			ChatID:  message.ChatId,
+			Content: message.Content,
+			Time:    time.Unix(message.CreatedAt, 0),
+			Author: types.MessageAuthor{
+				UserID: message.GetAuthor().GetUserId(),
+				Email:  message.GetAuthor().GetEmail(),
+				Role:   message.GetAuthor().GetRole(),
+			},
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Ошибка при отправке JSON-ответа: %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+package transport_rest
+
+import (
+	"context"
+	"crmSystem/proto/dbchat"
+	"crmSystem/proto/logs"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// membersCall вызов dbservice обработчика участников чата. saveError записывает ошибку в логи
+type membersCall func(ctx context.Context, client dbchat.DbChatServiceClient, user *utils.UserClaims, chatID int64,
+	saveError func(message string, err error))
+
+// withChatMembers разбирает id чата из маршрута /chats/{chatID}, подключается к Logs и dbservice от имени
+// пользователя из access token и вызывает call. Права пользователя проверяет dbservice по ролям чата.
+func (h *Handler) withChatMembers(w http.ResponseWriter, r *http.Request, call membersCall) {
+
+	//Данные из параметров маршрута /chats/{chatID}
+	vars := mux.Vars(r)
+	chatID, err := strconv.ParseInt(vars["chatID"], 10, 64)
+	if err != nil || chatID <= 0 {
+		utils.CreateError(w, http.StatusBadRequest, "Некорректный id чата", err)
+		return
+	}
+
+	// Получаем токен и данные пользователя из подписанного access token
+	token, user := h.userFromToken(w, r)
+	if user == nil {
+		return
+	}
+
+	ctxWithMetadata, cancel := context.WithTimeout(r.Context(), time.Second*10)
+	defer cancel()
+
+	// Устанавливаем соединение с gRPC сервером Logs
+	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу: %v", err)
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	} else {
+		defer func(conn *grpc.ClientConn) {
+			if err := conn.Close(); err != nil {
+				log.Printf("Ошибка закрытия соединения: %v", err)
+			}
+		}(conn)
+	}
+	saveError := func(message string, err error) {
+		log.Printf("%s: %v", message, err)
+		errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, user.Database, user.UserId, err.Error())
+		if errLogs != nil {
+			log.Printf("%s: %v", message, err)
+		}
+	}
+
+	// Подключение к gRPC серверу dbService
+	client, err, conn := utils.GRPCServiceConnector(token, dbchat.NewDbChatServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения к dbchatclient", err)
+		saveError("Ошибка подключения к gRPC серверу", err)
+		return
+	} else {
+		defer func(conn *grpc.ClientConn) {
+			if err := conn.Close(); err != nil {
+				log.Printf("Ошибка закрытия канала NewDbChatServiceClient: %v", err)
+			}
+		}(conn)
+	}
+
+	call(ctxWithMetadata, client, user, chatID, saveError)
+}
+
+// membersError отображает ошибку dbservice в HTTP статус, неожиданные ошибки записываются в логи
+func membersError(w http.ResponseWriter, message string, err error, saveError func(string, error)) {
+	errorMessage := status.Convert(err).Message()
+	switch status.Code(err) {
+	case codes.PermissionDenied:
+		utils.CreateError(w, http.StatusForbidden, errorMessage, err)
+	case codes.InvalidArgument:
+		utils.CreateError(w, http.StatusBadRequest, errorMessage, err)
+	case codes.NotFound:
+		utils.CreateError(w, http.StatusNotFound, errorMessage, err)
+	default:
+		utils.CreateError(w, http.StatusInternalServerError, message, err)
+		saveError(message, err)
+	}
+}
+
+// publishMembersEvent публикует событие изменения участников в обменник чата. Участники уже изменены,
+// поэтому ошибка публикации только записывается в логи
+func (h *Handler) publishMembersEvent(user *utils.UserClaims, chatID int64, eventType string, userIDs []int64,
+	saveError func(string, error)) {
+	actorID, _ := strconv.ParseInt(user.UserId, 10, 64)
+	event := types.ChatMembersEvent{
+		Type:    eventType,
+		DBName:  user.Database,
+		ChatID:  chatID,
+		UserIDs: userIDs,
+		ActorID: actorID,
+		Time:    time.Now(),
+	}
+	if err := utils.PublishChatMembersEvent(h.rabbitMQConn, event); err != nil {
+		saveError("Ошибка публикации события участников чата", err)
+	}
+}
+
+// writeJSON отправляет успешный JSON-ответ
+func writeJSON(w http.ResponseWriter, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Ошибка при отправке JSON-ответа: %v", err)
+	}
+}
+
+// AddMembers добавляет пользователей в чат (POST /chats/{chatID}/members). Доступно роли чата
+// с правом add_members. Добавленные пользователи получают уведомление chat_added, участники чата -
+// событие members_added.
+func (h *Handler) AddMembers(w http.ResponseWriter, r *http.Request) {
+	h.withChatMembers(w, r, func(ctx context.Context, client dbchat.DbChatServiceClient, user *utils.UserClaims, chatID int64, saveError func(string, error)) {
+		var req types.AddMembersRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.CreateError(w, http.StatusBadRequest, "Ошибка при декодировании данных.", err)
+			return
+		}
+
+		res, err := client.AddMembers(ctx, &dbchat.AddMembersRequest{ChatId: chatID, Users: convertToProtoUsers(req.Users)})
+		if err != nil {
+			membersError(w, "Ошибка добавления участников чата", err, saveError)
+			return
+		}
+
+		if len(res.UserIds) > 0 {
+			h.publishMembersEvent(user, chatID, types.MembersAdded, res.UserIds, saveError)
+
+			// Добавленные пользователи, подключённые к /chats/stream, начинают получать сообщения чата
+			added := make([]types.UserID, len(res.UserIds))
+			for i, id := range res.UserIds {
+				added[i] = types.UserID{UserId: id}
+			}
+			notification := types.ChatNotification{Type: "chat_added", ChatID: chatID}
+			if err := h.publishChatNotification(user.Database, added, notification); err != nil {
+				saveError("Ошибка отправки уведомления о добавлении в чат", err)
+			}
+		}
+
+		writeJSON(w, types.MembersChangedResponse{ChatID: chatID, UserIDs: nonNilIDs(res.UserIds)})
+	})
+}
+
+// RemoveMembers удаляет пользователей из чата (DELETE /chats/{chatID}/members). Доступно роли чата
+// с правом remove_members. Подключения удалённых пользователей к чату закрываются.
+func (h *Handler) RemoveMembers(w http.ResponseWriter, r *http.Request) {
+	h.withChatMembers(w, r, func(ctx context.Context, client dbchat.DbChatServiceClient, user *utils.UserClaims, chatID int64, saveError func(string, error)) {
+		var req types.RemoveMembersRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.CreateError(w, http.StatusBadRequest, "Ошибка при декодировании данных.", err)
+			return
+		}
+
+		res, err := client.RemoveMembers(ctx, &dbchat.RemoveMembersRequest{ChatId: chatID, UserIds: req.UserIDs})
+		if err != nil {
+			membersError(w, "Ошибка удаления участников чата", err, saveError)
+			return
+		}
+
+		if len(res.UserIds) > 0 {
+			h.publishMembersEvent(user, chatID, types.MembersRemoved, res.UserIds, saveError)
+		}
+
+		writeJSON(w, types.MembersChangedResponse{ChatID: chatID, UserIDs: nonNilIDs(res.UserIds)})
+	})
+}
+
+// LeaveChat удаляет пользователя из токена из чата (POST /chats/{chatID}/leave).
+func (h *Handler) LeaveChat(w http.ResponseWriter, r *http.Request) {
+	h.withChatMembers(w, r, func(ctx context.Context, client dbchat.DbChatServiceClient, user *utils.UserClaims, chatID int64, saveError func(string, error)) {
+		if _, err := client.LeaveChat(ctx, &dbchat.LeaveChatRequest{ChatId: chatID}); err != nil {
+			membersError(w, "Ошибка выхода из чата", err, saveError)
+			return
+		}
+
+		userID, _ := strconv.ParseInt(user.UserId, 10, 64)
+		h.publishMembersEvent(user, chatID, types.MemberLeft, []int64{userID}, saveError)
+
+		writeJSON(w, types.MembersChangedResponse{ChatID: chatID, UserIDs: []int64{userID}})
+	})
+}
+
+// ListMembers возвращает участников чата с ролями (GET /chats/{chatID}/members). Доступно участникам чата.
+func (h *Handler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	h.withChatMembers(w, r, func(ctx context.Context, client dbchat.DbChatServiceClient, _ *utils.UserClaims, chatID int64, saveError func(string, error)) {
+		res, err := client.ListMembers(ctx, &dbchat.ListMembersRequest{ChatId: chatID})
+		if err != nil {
+			membersError(w, "Ошибка получения участников чата", err, saveError)
+			return
+		}
+
+		members := make([]types.ChatMember, len(res.Members))
+		for i, member := range res.Members {
+			members[i] = types.ChatMember{
+				UserID: member.UserId,
+				Email:  member.Email,
+				RoleID: member.RoleId,
+				Role:   member.Role,
+			}
+		}
+		writeJSON(w, types.ChatMembersResponse{ChatID: chatID, Members: members})
+	})
+}
+
+// nonNilIDs возвращает пустой список вместо nil, чтобы в JSON был [] а не null
+func nonNilIDs(ids []int64) []int64 {
+	if ids == nil {
+		return []int64{}
+	}
+	return ids
+}
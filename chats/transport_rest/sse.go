@@ -0,0 +1,366 @@
+package transport_rest
+
+import (
+	"context"
+	"crmSystem/proto/dbchat"
+	"crmSystem/proto/logs"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/streadway/amqp"
+	"google.golang.org/grpc"
+)
+
+const (
+	// streamHeartbeatInterval интервал комментария-heartbeat в потоке: прокси не закрывают соединение без данных
+	streamHeartbeatInterval = 25 * time.Second
+
+	// streamRetry через сколько EventSource переподключается после обрыва
+	streamRetry = 3 * time.Second
+
+	// streamWriteTimeout время на запись одного события клиенту
+	streamWriteTimeout = 10 * time.Second
+
+	// streamReplayPageSize количество сообщений в одном запросе пропущенных сообщений к dbservice
+	streamReplayPageSize = 500
+)
+
+// sseWriter записывает события Server-Sent Events и сразу отправляет их клиенту.
+type sseWriter struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// extendDeadline даёт клиенту streamWriteTimeout на получение следующей записи
+func (s *sseWriter) extendDeadline() error {
+	err := s.rc.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+	if errors.Is(err, http.ErrNotSupported) {
+		return nil
+	}
+	return err
+}
+
+// write записывает событие. Пустые id и event не записываются.
+func (s *sseWriter) write(id string, event string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if err := s.extendDeadline(); err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(s.w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	if event != "" {
+		if _, err := fmt.Fprintf(s.w, "event: %s\n", event); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(s.w, "data: %s\n\n", body); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// writeRaw записывает строки протокола (retry, комментарий) без данных события.
+func (s *sseWriter) writeRaw(text string) error {
+	if err := s.extendDeadline(); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprint(s.w, text); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// writeMessage записывает сообщение чата, id события - id сообщения.
+func (s *sseWriter) writeMessage(message types.ChatMessage) error {
+	return s.write(strconv.FormatInt(message.ID, 10), "message", message)
+}
+
+// ChatStream передаёт новые сообщения всех чатов пользователя через Server-Sent Events (GET /chats/stream).
+//
+// Поток нужен клиентам, у которых прокси закрывают WebSocket. Пропущенные сообщения восстанавливаются из
+// таблицы messages: клиент передаёт id последнего полученного сообщения в заголовке Last-Event-ID
+// (EventSource делает это сам при переподключении) или в параметре last_event_id. Сообщение может прийти
+// повторно, клиент пропускает сообщения с уже полученным id. Без id последнего сообщения поток передаёт
+// только новые сообщения: история чатов загружается через /chats/{chatID}/history.
+//
+// События: message - сообщение чата (id события - id сообщения), chat - пользователь добавлен в чат,
+// members - изменились участники одного из чатов пользователя.
+func (h *Handler) ChatStream(w http.ResponseWriter, r *http.Request) {
+
+	// Получаем токен и данные пользователя из подписанного access token
+	token, user := h.userFromToken(w, r)
+	if user == nil {
+		return
+	}
+	database := user.Database
+	userId := user.UserId
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var afterID int64
+	if lastEventID != "" {
+		parsed, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || parsed < 0 {
+			utils.CreateError(w, http.StatusBadRequest, "Некорректный Last-Event-ID", err)
+			return
+		}
+		afterID = parsed
+	}
+
+	ctxWithMetadata, cancel := context.WithTimeout(r.Context(), time.Second*10)
+	defer cancel()
+
+	// Устанавливаем соединение с gRPC сервером Logs
+	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу: %v", err)
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	} else {
+		defer func(conn *grpc.ClientConn) {
+			if err := conn.Close(); err != nil {
+				log.Printf("Ошибка закрытия соединения: %v", err)
+			}
+		}(conn)
+	}
+	// Поток открыт долго, поэтому ошибки записываются в логи с отдельным тайм-аутом
+	saveError := func(message string, err error) {
+		log.Printf("%s: %v", message, err)
+		logsCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		errLogs := utils.SaveLogsError(logsCtx, clientLogs, database, userId, err.Error())
+		if errLogs != nil {
+			log.Printf("%s: %v", message, err)
+		}
+	}
+
+	// Подключение к gRPC серверу dbService
+	client, err, conn := utils.GRPCServiceConnector(token, dbchat.NewDbChatServiceClient)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения к dbchatclient", err)
+		saveError("Ошибка подключения к gRPC серверу", err)
+		return
+	} else {
+		defer func(conn *grpc.ClientConn) {
+			if err := conn.Close(); err != nil {
+				log.Printf("Ошибка закрытия канала NewDbChatServiceClient: %v", err)
+			}
+		}(conn)
+	}
+
+	chats, err := client.ListUserChats(ctxWithMetadata, &dbchat.ListUserChatsRequest{})
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Ошибка получения чатов пользователя", err)
+		saveError("Ошибка получения чатов пользователя", err)
+		return
+	}
+
+	// Канал RabbitMQ потока, очередь удаляется при закрытии канала
+	channel, err := h.rabbitMQConn.Channel()
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Ошибка подключения к каналу RabbitMQ", err)
+		saveError("Ошибка подключения к каналу RabbitMQ", err)
+		return
+	}
+	defer func(channel *amqp.Channel) {
+		if err := channel.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
+			log.Printf("Ошибка закрытия канала RabbitMQ: %v", err)
+		}
+	}(channel)
+
+	// RabbitMQ передаёт потоку ограниченное количество неподтверждённых сообщений, медленный клиент
+	// не накапливает сообщения в памяти сервиса
+	if err := channel.Qos(webSocketSendBuffer, 0, false); err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Ошибка настройки канала RabbitMQ", err)
+		saveError("Ошибка настройки канала RabbitMQ", err)
+		return
+	}
+
+	queue, err := channel.QueueDeclare(
+		"",    // Имя очереди (пустое, чтобы RabbitMQ сгенерировал уникальное имя)
+		false, // durable
+		true,  // autoDelete
+		true,  // exclusive
+		false, // noWait
+		amqp.Table{"x-max-length": int64(subscriptionMaxLength)},
+	)
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Ошибка создания очереди", err)
+		saveError("Ошибка создания очереди", err)
+		return
+	}
+
+	// Очередь привязывается к чатам до чтения пропущенных сообщений из базы, поэтому сообщения,
+	// отправленные во время чтения, не теряются
+	bindChat := func(chatID int64) error {
+		exchangeName := utils.ChatExchangeName(database, strconv.FormatInt(chatID, 10))
+		if err := utils.DeclareChatExchange(channel, exchangeName); err != nil {
+			return err
+		}
+		return channel.QueueBind(queue.Name, "", exchangeName, false, nil)
+	}
+	unbindChat := func(chatID int64) error {
+		exchangeName := utils.ChatExchangeName(database, strconv.FormatInt(chatID, 10))
+		return channel.QueueUnbind(queue.Name, "", exchangeName, nil)
+	}
+	userID, _ := strconv.ParseInt(userId, 10, 64)
+	for _, chat := range chats.Chats {
+		if err := bindChat(chat.ChatId); err != nil {
+			utils.CreateError(w, http.StatusInternalServerError, "Ошибка привязки очереди к обменнику", err)
+			saveError("Ошибка привязки очереди к обменнику", err)
+			return
+		}
+	}
+	if err := declareNotificationsExchange(channel); err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Ошибка создания обменника уведомлений", err)
+		saveError("Ошибка создания обменника уведомлений", err)
+		return
+	}
+	if err := channel.QueueBind(queue.Name, userNotificationKey(database, userId), notificationsExchange, false, nil); err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Ошибка привязки очереди к обменнику уведомлений", err)
+		saveError("Ошибка привязки очереди к обменнику уведомлений", err)
+		return
+	}
+
+	deliveries, err := channel.Consume(queue.Name, "", false, true, false, false, nil)
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Ошибка получения сообщений", err)
+		saveError("Ошибка получения сообщений", err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	stream := &sseWriter{w: w, rc: rc}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// nginx не буферизует ответ
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := stream.writeRaw(fmt.Sprintf("retry: %d\n\n", streamRetry.Milliseconds())); err != nil {
+		return
+	}
+
+	// Пропущенные сообщения из базы. Отправленные сообщения запоминаются, чтобы не отправить их повторно
+	// из очереди: сообщение с меньшим id может быть сохранено позже и прийти из очереди после чтения базы
+	replayed := make(map[int64]struct{})
+	// Без id последнего сообщения клиент получает только новые сообщения
+	if lastEventID != "" {
+		for {
+			page, err := client.ListMessagesSince(r.Context(), &dbchat.ListMessagesSinceRequest{AfterId: afterID, Limit: streamReplayPageSize})
+			if err != nil {
+				saveError("Ошибка получения пропущенных сообщений", err)
+				return
+			}
+			for _, saved := range page.Messages {
+				message := types.ChatMessage{
+					ID:      saved.MessageId,
+					DBName:  database,
+					ChatID:  saved.ChatId,
+					UserID:  saved.UserId,
+					Content: saved.Content,
+					Time:    time.Unix(saved.CreatedAt, 0),
+				}
+				if err := stream.writeMessage(message); err != nil {
+					return
+				}
+				replayed[message.ID] = struct{}{}
+				afterID = message.ID
+			}
+			if len(page.Messages) < streamReplayPageSize {
+				break
+			}
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			// Клиент отключился или сервис останавливается
+			return
+
+		case <-heartbeat.C:
+			if err := stream.writeRaw(": heartbeat\n\n"); err != nil {
+				return
+			}
+
+		case delivery, ok := <-deliveries:
+			if !ok {
+				// Канал RabbitMQ закрыт, EventSource переподключится
+				return
+			}
+
+			if delivery.Exchange == notificationsExchange {
+				var notification types.ChatNotification
+				if err := json.Unmarshal(delivery.Body, &notification); err != nil {
+					log.Printf("Ошибка декодирования уведомления: %v", err)
+					_ = delivery.Ack(false)
+					continue
+				}
+				// Пользователь добавлен в чат: поток начинает получать его сообщения
+				if notification.Type == "chat_created" || notification.Type == "chat_added" {
+					if err := bindChat(notification.ChatID); err != nil {
+						saveError("Ошибка привязки очереди к обменнику", err)
+						return
+					}
+				}
+				if err := stream.write("", "chat", notification); err != nil {
+					return
+				}
+				_ = delivery.Ack(false)
+				continue
+			}
+
+			if delivery.Type == utils.ChatMembersEventType {
+				var members types.ChatMembersEvent
+				if err := json.Unmarshal(delivery.Body, &members); err != nil {
+					log.Printf("Ошибка декодирования события участников: %v", err)
+					_ = delivery.Ack(false)
+					continue
+				}
+				// Пользователь удалён из чата: поток больше не получает его сообщения
+				if members.Removes(userID) {
+					if err := unbindChat(members.ChatID); err != nil {
+						saveError("Ошибка отвязки очереди от обменника", err)
+						return
+					}
+				}
+				if err := stream.write("", "members", members); err != nil {
+					return
+				}
+				_ = delivery.Ack(false)
+				continue
+			}
+
+			var message types.ChatMessage
+			if err := json.Unmarshal(delivery.Body, &message); err != nil {
+				log.Printf("Ошибка декодирования сообщения: %v", err)
+				_ = delivery.Ack(false)
+				continue
+			}
+			if _, ok := replayed[message.ID]; !ok {
+				if err := stream.writeMessage(message); err != nil {
+					return
+				}
+			}
+			_ = delivery.Ack(false)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package transport_rest
+
+import (
+	"crmSystem/utils"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+const (
+	// defaultSubscriptionTTL сколько хранится подписка отключившегося клиента, если CHAT_SUBSCRIPTION_TTL не задана
+	defaultSubscriptionTTL = 5 * time.Minute
+
+	// subscriptionMaxLength максимальное количество сообщений в подписке, более старые сообщения удаляются
+	subscriptionMaxLength = 1000
+)
+
+// ChatSubscription подключение WebSocket клиента к его подписке на сообщения чата
+type ChatSubscription struct {
+	Deliveries <-chan amqp.Delivery // Сообщения подписки, подтверждаются после записи в соединение
+	Resumed    bool                 // Подписка существовала до подключения, пропущенные сообщения будут доставлены
+	Drop       func()               // Удаляет подписку, продолжить её после переподключения нельзя
+	Close      func()               // Закрывает подключение, неподтверждённые сообщения остаются в подписке
+}
+
+// ChatSubscriptions устойчивые подписки WebSocket клиентов на сообщения чатов
+type ChatSubscriptions interface {
+	// Open подключается к подписке subscription пользователя userId на обменник чата chatID базы database
+	// и создаёт её, если подписки ещё нет. Подписка принадлежит пользователю: подписку другого пользователя
+	// с тем же идентификатором продолжить нельзя, для него создаётся новая.
+	// Не больше prefetch сообщений передаётся без подтверждения.
+	Open(database string, chatID string, userId string, subscription string, prefetch int) (*ChatSubscription, error)
+}
+
+// rabbitMQSubscriptions подписки в виде устойчивых очередей RabbitMQ, привязанных к обменнику чата
+type rabbitMQSubscriptions struct {
+	conn *amqp.Connection
+}
+
+// NewRabbitMQSubscriptions создаёт подписки WebSocket клиентов через соединение conn
+func NewRabbitMQSubscriptions(conn *amqp.Connection) ChatSubscriptions {
+	return &rabbitMQSubscriptions{conn: conn}
+}
+
+// subscriptionTTL возвращает время хранения подписки отключившегося клиента из CHAT_SUBSCRIPTION_TTL (например 10m).
+func subscriptionTTL() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("CHAT_SUBSCRIPTION_TTL")); err == nil && value > 0 {
+		return value
+	}
+	return defaultSubscriptionTTL
+}
+
+// subscriptionQueueName имя очереди RabbitMQ подписки клиента на чат. ID чата уникален только внутри базы
+// компании, а подписка принадлежит пользователю, поэтому имя включает базу и ID пользователя
+func subscriptionQueueName(database string, chatID string, userId string, subscription string) string {
+	return fmt.Sprintf("chat_ws_%s_%s_%s_%s", database, chatID, userId, subscription)
+}
+
+// queueExists проверяет, что очередь подписки ещё существует. Проверка выполняется в отдельном
+// канале: RabbitMQ закрывает канал, если очереди нет
+func (s *rabbitMQSubscriptions) queueExists(queueName string) bool {
+	channel, err := s.conn.Channel()
+	if err != nil {
+		return false
+	}
+	defer func() { _ = channel.Close() }()
+
+	_, err = channel.QueueDeclarePassive(queueName, true, false, false, false, nil)
+	return err == nil
+}
+
+func (s *rabbitMQSubscriptions) Open(database string, chatID string, userId string, subscription string,
+	prefetch int) (*ChatSubscription, error) {
+	queueName := subscriptionQueueName(database, chatID, userId, subscription)
+	resumed := s.queueExists(queueName)
+
+	// Канал RabbitMQ соединения: при его закрытии неподтверждённые сообщения возвращаются в подписку
+	channel, err := s.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подключения к каналу RabbitMQ: %w", err)
+	}
+	closeChannel := func() {
+		if err := channel.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
+			log.Printf("Ошибка закрытия канала RabbitMQ: %v", err)
+		}
+	}
+
+	deliveries, err := s.consume(channel, database, chatID, queueName, prefetch)
+	if err != nil {
+		closeChannel()
+		return nil, err
+	}
+
+	return &ChatSubscription{
+		Deliveries: deliveries,
+		Resumed:    resumed,
+		Drop: func() {
+			if _, err := channel.QueueDelete(queueName, false, false, false); err != nil {
+				log.Printf("Ошибка удаления подписки %s: %v", queueName, err)
+			}
+		},
+		Close: closeChannel,
+	}, nil
+}
+
+// consume создаёт очередь подписки, привязывает её к обменнику чата и начинает получать сообщения
+func (s *rabbitMQSubscriptions) consume(channel *amqp.Channel, database string, chatID string, queueName string,
+	prefetch int) (<-chan amqp.Delivery, error) {
+
+	// RabbitMQ не передаёт соединению больше prefetch неподтверждённых сообщений,
+	// остальные ждут в подписке, пока клиент не прочитает отправленные
+	if err := channel.Qos(prefetch, 0, false); err != nil {
+		return nil, fmt.Errorf("ошибка настройки канала RabbitMQ: %w", err)
+	}
+
+	// Обменник создаётся и при отправке сообщения, подписка может появиться раньше первого сообщения
+	exchangeName := utils.ChatExchangeName(database, chatID)
+	if err := utils.DeclareChatExchange(channel, exchangeName); err != nil {
+		return nil, fmt.Errorf("ошибка создания обменника: %w", err)
+	}
+
+	// Подписка удаляется RabbitMQ, если к ней не подключались CHAT_SUBSCRIPTION_TTL
+	_, err := channel.QueueDeclare(
+		queueName, // имя очереди
+		true,      // durable
+		false,     // autoDelete
+		false,     // exclusive (очередь переживает переподключение клиента)
+		false,     // noWait
+		amqp.Table{
+			"x-expires":    int64(subscriptionTTL() / time.Millisecond),
+			"x-max-length": int64(subscriptionMaxLength),
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка создания подписки: %w", err)
+	}
+	if err := channel.QueueBind(queueName, "", exchangeName, false, nil); err != nil {
+		return nil, fmt.Errorf("ошибка привязки подписки к обменнику: %w", err)
+	}
+
+	deliveries, err := channel.Consume(
+		queueName, // Имя очереди
+		"",        // consumer
+		false,     // autoAck (подтверждение после записи в соединение)
+		false,     // exclusive
+		false,     // noLocal
+		false,     // noWait
+		nil,       // arguments
+	)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения сообщений: %w", err)
+	}
+	return deliveries, nil
+}
@@ -26,9 +26,109 @@ type ErrorResponse struct {
 }
 
 type ChatMessage struct {
+	ID      int64     `json:"id"` // ID сохранённого сообщения, возрастает в пределах базы компании
 	DBName  string    `json:"db_name"`
 	ChatID  int64     `json:"chat_id"`
 	UserID  int64     `json:"user_id"`
 	Content string    `json:"content"`
 	Time    time.Time `json:"time"`
 }
+
+// ChatEvent сообщение WebSocket соединения /chats/{chatID}/ws
+type ChatEvent struct {
+	Type              string            `json:"type"`                         // subscribed, message или members
+	Subscription      string            `json:"subscription,omitempty"`       // Подписка для переподключения
+	Resumed           bool              `json:"resumed,omitempty"`            // Подписка продолжена, пропущенные сообщения будут доставлены
+	HeartbeatInterval int64             `json:"heartbeat_interval,omitempty"` // Интервал ping сервера в секундах
+	Message           *ChatMessage      `json:"message,omitempty"`
+	Members           *ChatMembersEvent `json:"members,omitempty"` // Изменение участников чата
+}
+
+// ChatNotification уведомление пользователя об изменении его чатов
+type ChatNotification struct {
+	Type     string `json:"type"` // chat_created или chat_added
+	ChatID   int64  `json:"chat_id"`
+	ChatName string `json:"chat_name,omitempty"`
+}
+
+// Типы событий изменения участников чата
+const (
+	MembersAdded   = "members_added"
+	MembersRemoved = "members_removed"
+	MemberLeft     = "member_left"
+)
+
+// ChatMembersEvent событие изменения участников чата, публикуется в обменник чата
+type ChatMembersEvent struct {
+	Type    string    `json:"type"` // members_added, members_removed или member_left
+	DBName  string    `json:"db_name"`
+	ChatID  int64     `json:"chat_id"`
+	UserIDs []int64   `json:"user_ids"` // Добавленные или удалённые пользователи
+	ActorID int64     `json:"actor_id"` // Пользователь, изменивший участников чата
+	Time    time.Time `json:"time"`
+}
+
+// Removes проверяет, что событие удаляет пользователя из чата
+func (e ChatMembersEvent) Removes(userID int64) bool {
+	if e.Type == MembersAdded {
+		return false
+	}
+	for _, id := range e.UserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// ChatMember участник чата
+type ChatMember struct {
+	UserID int64  `json:"user_id"`
+	Email  string `json:"email,omitempty"`
+	RoleID int64  `json:"role_id,omitempty"` // Роль в чате, 0 - без роли
+	Role   string `json:"role,omitempty"`
+}
+
+// AddMembersRequest запрос добавления участников в чат, role_id = 0 - роль участника по умолчанию
+type AddMembersRequest struct {
+	Users []UserID `json:"users"`
+}
+
+// RemoveMembersRequest запрос удаления участников из чата
+type RemoveMembersRequest struct {
+	UserIDs []int64 `json:"user_ids"`
+}
+
+// MembersChangedResponse пользователи, добавленные в чат или удалённые из него
+type MembersChangedResponse struct {
+	ChatID  int64   `json:"chat_id"`
+	UserIDs []int64 `json:"user_ids"`
+}
+
+// ChatMembersResponse участники чата (GET /chats/{chatID}/members)
+type ChatMembersResponse struct {
+	ChatID  int64        `json:"chat_id"`
+	Members []ChatMember `json:"members"`
+}
+
+// MessageAuthor автор сообщения истории чата
+type MessageAuthor struct {
+	UserID int64  `json:"user_id"` // 0 - пользователь удалён
+	Email  string `json:"email,omitempty"`
+	Role   string `json:"role,omitempty"`
+}
+
+// HistoryMessage сообщение истории чата
+type HistoryMessage struct {
+	ID      int64         `json:"id"`
+	ChatID  int64         `json:"chat_id"`
+	Content string        `json:"content"`
+	Time    time.Time     `json:"time"`
+	Author  MessageAuthor `json:"author"`
+}
+
+// ChatHistoryResponse страница истории чата /chats/{chatID}/history, сообщения в порядке id
+type ChatHistoryResponse struct {
+	Messages []HistoryMessage `json:"messages"`
+	HasMore  bool             `json:"has_more"` // В направлении запроса есть ещё сообщения
+}
@@ -0,0 +1,260 @@
+package transport_rest
+
+import (
+	"context"
+	"crmSystem/transport_rest/types"
+	"crmSystem/utils"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/streadway/amqp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// webSocketSendBuffer сколько сообщений RabbitMQ передаёт соединению без подтверждения и
+	// сколько сообщений помещается в очередь отправки клиенту
+	webSocketSendBuffer = 64
+
+	// webSocketPingInterval интервал ping сервера
+	webSocketPingInterval = 25 * time.Second
+)
+
+// subscriptionPattern формат подписки клиента
+var subscriptionPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// newSubscription создаёт подписку для нового клиента
+func newSubscription() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// CloseStreams закрывает все WebSocket соединения, вызывается при остановке HTTP сервера:
+// http.Server.Shutdown не ожидает соединения, переключённые на WebSocket
+func (h *Handler) CloseStreams() {
+	h.streams.CloseAll()
+}
+
+// ChatWebSocket доставляет сообщения чата в реальном времени через WebSocket.
+//
+// Каждому соединению соответствует устойчивая очередь RabbitMQ (подписка), привязанная к обменнику чата.
+// После отключения клиента подписка хранится CHAT_SUBSCRIPTION_TTL: при переподключении с параметрами
+// subscription и last_message_id клиент получает пропущенные сообщения, сообщения с id не больше
+// last_message_id не отправляются повторно. Сообщение подтверждается в RabbitMQ после записи в соединение.
+// Подключиться может только участник чата, остальным возвращается 403 до создания подписки.
+func (h *Handler) ChatWebSocket(w http.ResponseWriter, r *http.Request) {
+
+	//Данные из параметров маршрута /chats/{chatID}
+	vars := mux.Vars(r)
+	chatID := vars["chatID"]
+	chatIDValue, err := strconv.ParseInt(chatID, 10, 64)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Некорректный id чата", err)
+		return
+	}
+
+	// Получаем токен и данные пользователя из подписанного access token
+	token, user := h.userFromToken(w, r)
+	if user == nil {
+		return
+	}
+	database := user.Database
+	userId := user.UserId
+
+	// Подписка клиента: новая или переданная при переподключении
+	subscription := r.URL.Query().Get("subscription")
+	if subscription != "" && !subscriptionPattern.MatchString(subscription) {
+		utils.CreateError(w, http.StatusBadRequest, "Некорректная подписка", fmt.Errorf("ожидается 32 шестнадцатеричных символа"))
+		return
+	}
+	var lastMessageID int64
+	if value := r.URL.Query().Get("last_message_id"); value != "" {
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || parsed < 0 {
+			utils.CreateError(w, http.StatusBadRequest, "Некорректный last_message_id", err)
+			return
+		}
+		lastMessageID = parsed
+	}
+
+	ctxWithMetadata, cancel := context.WithTimeout(r.Context(), time.Second*10)
+	defer cancel()
+
+	// Устанавливаем соединение с gRPC сервером Logs
+	clientLogs, closeLogs, err := h.deps.DialLogs(token)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу: %v", err)
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения", err)
+		return
+	}
+	defer func() {
+		if err := closeLogs(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}()
+	saveError := func(message string, err error) {
+		log.Printf("%s: %v", message, err)
+		errLogs := utils.SaveLogsError(ctxWithMetadata, clientLogs, database, userId, err.Error())
+		if errLogs != nil {
+			log.Printf("%s: %v", message, err)
+		}
+	}
+
+	// Подписка создаётся и привязывается к обменнику чата только для участника чата
+	client, closeStore, err := h.deps.DialStore(token)
+	if err != nil {
+		utils.CreateError(w, http.StatusBadRequest, "Ошибка подключения к dbchatclient", err)
+		saveError("Ошибка подключения к gRPC серверу", err)
+		return
+	}
+	err = utils.CheckChatMember(ctxWithMetadata, client, chatIDValue)
+	if errClose := closeStore(); errClose != nil {
+		log.Printf("Ошибка закрытия канала NewDbChatServiceClient: %v", errClose)
+	}
+	switch status.Code(err) {
+	case codes.OK:
+	case codes.PermissionDenied:
+		utils.CreateError(w, http.StatusForbidden, status.Convert(err).Message(), err)
+		return
+	default:
+		utils.CreateError(w, http.StatusInternalServerError, "Ошибка проверки участников чата", err)
+		saveError("Ошибка проверки участников чата", err)
+		return
+	}
+
+	if subscription == "" {
+		subscription, err = newSubscription()
+		if err != nil {
+			utils.CreateError(w, http.StatusInternalServerError, "Ошибка создания подписки", err)
+			saveError("Ошибка создания подписки", err)
+			return
+		}
+	}
+
+	chatSubscription, err := h.deps.Subscriptions.Open(database, chatID, userId, subscription, webSocketSendBuffer)
+	if err != nil {
+		utils.CreateError(w, http.StatusInternalServerError, "Ошибка подписки на сообщения чата", err)
+		saveError("Ошибка подписки на сообщения чата", err)
+		return
+	}
+	defer chatSubscription.Close()
+
+	// Upgrade сам отвечает клиенту при ошибке
+	wsConn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Ошибка переключения на WebSocket: %v", err)
+		return
+	}
+
+	stream := utils.NewWebSocketStream(wsConn, userId, subscription, webSocketSendBuffer, webSocketPingInterval)
+
+	// Старое соединение того же пользователя с той же подпиской закрывается, чтобы сообщения
+	// не делились между соединениями. ID чата уникален только внутри базы компании
+	streamKey := database + ":" + chatID
+	h.streams.ReplaceChatStream(streamKey, stream, func(s utils.StreamInterface) bool {
+		other, ok := s.(*utils.WebSocketStream)
+		return ok && other.UserId == userId && other.Subscription == subscription
+	})
+	defer h.streams.RemoveChatStream(streamKey, stream)
+
+	_ = stream.Send(types.ChatEvent{
+		Type:              "subscribed",
+		Subscription:      subscription,
+		Resumed:           chatSubscription.Resumed,
+		HeartbeatInterval: int64(webSocketPingInterval / time.Second),
+	})
+
+	// Пользователь удалён из чата: подписка удаляется, продолжить её после переподключения нельзя
+	userID, _ := strconv.ParseInt(userId, 10, 64)
+	go h.pumpChatMessages(stream, chatSubscription.Deliveries, lastMessageID, userID, chatSubscription.Drop)
+
+	if err := stream.Run(); err != nil {
+		log.Printf("WebSocket соединение чата %s закрыто: %v", chatID, err)
+	}
+}
+
+// pumpChatMessages передаёт сообщения подписки в поток, пока поток не закрыт. События изменения участников
+// передаются клиенту, если пользователь userID удалён из чата, поток закрывается.
+func (h *Handler) pumpChatMessages(stream *utils.WebSocketStream, deliveries <-chan amqp.Delivery, lastMessageID int64,
+	userID int64, dropSubscription func()) {
+	for {
+		select {
+		case <-stream.Done():
+			return
+
+		case delivery, ok := <-deliveries:
+			if !ok {
+				// Канал или соединение с RabbitMQ закрыто, клиент переподключится
+				_ = stream.CloseWithReason(websocket.CloseTryAgainLater, "RabbitMQ недоступен")
+				return
+			}
+
+			var event types.ChatEvent
+			if delivery.Type == utils.ChatMembersEventType {
+				var members types.ChatMembersEvent
+				if err := json.Unmarshal(delivery.Body, &members); err != nil {
+					log.Printf("Ошибка декодирования события участников: %v", err)
+					_ = delivery.Ack(false)
+					continue
+				}
+				if members.Removes(userID) {
+					_ = delivery.Ack(false)
+					dropSubscription()
+					_ = stream.CloseWithReason(websocket.ClosePolicyViolation, "Пользователь удалён из чата")
+					return
+				}
+				event = types.ChatEvent{Type: "members", Members: &members}
+			} else {
+				var message types.ChatMessage
+				if err := json.Unmarshal(delivery.Body, &message); err != nil {
+					log.Printf("Ошибка декодирования сообщения: %v", err)
+					_ = delivery.Ack(false)
+					continue
+				}
+
+				// Клиент уже получил сообщение до переподключения
+				if message.ID != 0 && message.ID <= lastMessageID {
+					_ = delivery.Ack(false)
+					continue
+				}
+				event = types.ChatEvent{Type: "message", Message: &message}
+			}
+
+			err := stream.Send(utils.WebSocketFrame{
+				Payload: event,
+				Delivered: func() {
+					if err := delivery.Ack(false); err != nil {
+						log.Printf("Ошибка подтверждения сообщения: %v", err)
+					}
+				},
+			})
+			if errors.Is(err, utils.ErrSlowClient) {
+				// Неподтверждённые сообщения останутся в подписке до переподключения клиента
+				_ = stream.CloseWithReason(websocket.CloseTryAgainLater, err.Error())
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"crmSystem/transport_rest/types"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/streadway/amqp"
+)
+
+// ChatExchangeName имя fanout обменника сообщений чата. id чатов уникальны только в базе компании,
+// поэтому имя содержит базу компании
+func ChatExchangeName(database string, chatID string) string {
+	return fmt.Sprintf("chat_exchange_%s_%s", database, chatID)
+}
+
+// DeclareChatExchange создаёт обменник сообщений чата, если его ещё нет
+func DeclareChatExchange(channel *amqp.Channel, exchangeName string) error {
+	return channel.ExchangeDeclare(exchangeName, "fanout", true, false, false, false, nil)
+}
+
+// ChatMembersEventType тип AMQP сообщения обменника чата с событием изменения участников (types.ChatMembersEvent).
+// Сообщения чата публикуются без типа
+const ChatMembersEventType = "chat_members"
+
+// PublishChatMessage публикует сохранённое сообщение в fanout обменник чата, из которого его получают
+// все подписки чата: WebSocket, Server-Sent Events и gRPC потоки
+func PublishChatMessage(conn *amqp.Connection, message types.ChatMessage) error {
+	// Сериализация сообщения
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("Ошибка сериализации сообщения: %v", err)
+	}
+
+	// Сообщения сохраняются на диск: подписки устойчивы к перезапуску RabbitMQ
+	return publishToChatExchange(conn, message.DBName, message.ChatID, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    strconv.FormatInt(message.ID, 10),
+		Timestamp:    message.Time,
+		Body:         body,
+	})
+}
+
+// PublishChatMembersEvent публикует событие изменения участников в обменник чата, подписки чата
+// передают его клиентам и отключают удалённых пользователей
+func PublishChatMembersEvent(conn *amqp.Connection, event types.ChatMembersEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("Ошибка сериализации события: %v", err)
+	}
+
+	return publishToChatExchange(conn, event.DBName, event.ChatID, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Type:         ChatMembersEventType,
+		Timestamp:    event.Time,
+		Body:         body,
+	})
+}
+
+// publishToChatExchange публикует сообщение в обменник чата, обменник создаётся, если его ещё нет
+func publishToChatExchange(conn *amqp.Connection, database string, chatID int64, publishing amqp.Publishing) error {
+	channel, err := conn.Channel()
+	if err != nil {
+		return fmt.Errorf("Ошибка подключения к каналу RabbitMQ: %v", err)
+	}
+	defer func(channel *amqp.Channel) {
+		err := channel.Close()
+		if err != nil {
+			log.Printf("Ошибка закрытия канала rabbitMQConn: %v", err)
+		}
+	}(channel)
+
+	// Объявление обменника
+	exchangeName := ChatExchangeName(database, strconv.FormatInt(chatID, 10))
+	if err := DeclareChatExchange(channel, exchangeName); err != nil {
+		return fmt.Errorf("Ошибка создания обменника: %v", err)
+	}
+
+	// Публикация сообщения
+	if err := channel.Publish(exchangeName, "", false, false, publishing); err != nil {
+		return fmt.Errorf("Ошибка публикации сообщения: %v", err)
+	}
+	return nil
+}
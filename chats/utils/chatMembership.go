@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"context"
+	"crmSystem/proto/dbchat"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CheckChatMember проверяет через dbservice, что пользователь токена client состоит в чате chatID.
+// Возвращает PermissionDenied, если пользователь не участник чата, и Unavailable, если dbservice недоступен.
+func CheckChatMember(ctx context.Context, client dbchat.DbChatServiceClient, chatID int64) error {
+	chats, err := client.ListUserChats(ctx, &dbchat.ListUserChatsRequest{})
+	if err != nil {
+		log.Printf("Ошибка получения чатов пользователя: %v", err)
+		return status.Errorf(codes.Unavailable, "Ошибка получения чатов пользователя")
+	}
+	for _, chat := range chats.Chats {
+		if chat.ChatId == chatID {
+			return nil
+		}
+	}
+	return status.Errorf(codes.PermissionDenied, "пользователь не состоит в чате %d", chatID)
+}
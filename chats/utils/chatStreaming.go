@@ -34,6 +34,23 @@ func (m *MapConnectionsChat) AddChatStream(chatId string, stream StreamInterface
 	m.MapChat[chatId] = append(m.MapChat[chatId], stream)
 }
 
+// ReplaceChatStream - добавляет новый поток для chatId и закрывает потоки чата, которые он заменяет
+// (например, клиент переподключился с той же подпиской, а старое соединение ещё не закрыто)
+func (m *MapConnectionsChat) ReplaceChatStream(chatId string, stream StreamInterface, replaces func(StreamInterface) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var kept []StreamInterface
+	for _, s := range m.MapChat[chatId] {
+		if replaces(s) {
+			_ = s.Close()
+			continue
+		}
+		kept = append(kept, s)
+	}
+	m.MapChat[chatId] = append(kept, stream)
+}
+
 // RemoveChatStream - удаляет поток из списка потоков для chatId
 func (m *MapConnectionsChat) RemoveChatStream(chatId string, stream StreamInterface) {
 	m.mu.Lock()
@@ -61,7 +78,8 @@ func (m *MapConnectionsChat) RemoveChatStream(chatId string, stream StreamInterf
 	}
 }
 
-// BroadcastMessage - отправляет сообщение всем потокам в заданном чате
+// BroadcastMessage - отправляет сообщение всем потокам в заданном чате. Ошибка отправки в один поток
+// не прерывает рассылку остальным, возвращаются ошибки всех потоков
 func (m *MapConnectionsChat) BroadcastMessage(chatId string, message interface{}) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -71,10 +89,35 @@ func (m *MapConnectionsChat) BroadcastMessage(chatId string, message interface{}
 		return errors.New("нет активных потоков для этого чата")
 	}
 
+	var errs []error
 	for _, stream := range streams {
 		if err := stream.Send(message); err != nil {
-			return err
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CloseChatStreams - закрывает все потоки чата, например если прервалась подписка на его сообщения
+func (m *MapConnectionsChat) CloseChatStreams(chatId string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, stream := range m.MapChat[chatId] {
+		_ = stream.Close()
+	}
+	delete(m.MapChat, chatId)
+}
+
+// CloseAll - закрывает все потоки всех чатов, используется при остановке сервиса
+func (m *MapConnectionsChat) CloseAll() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, streams := range m.MapChat {
+		for _, stream := range streams {
+			_ = stream.Close()
 		}
 	}
-	return nil
+	m.MapChat = make(map[string][]StreamInterface)
 }
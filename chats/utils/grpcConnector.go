@@ -95,3 +95,16 @@ func (j jwtTokenAuth) GetRequestMetadata(ctx context.Context, uri ...string) (ma
 func (j jwtTokenAuth) RequireTransportSecurity() bool {
 	return true
 }
+
+// DialService подключается к gRPC сервису через GRPCServiceConnector.
+// Возвращает клиента и функцию закрытия соединения.
+func DialService[T any](token string, clientFactory func(grpc.ClientConnInterface) T) (client T, closeConn func() error, err error) {
+	client, err, conn := GRPCServiceConnector(token, clientFactory)
+	if err != nil {
+		if conn != nil {
+			_ = conn.Close()
+		}
+		return client, nil, err
+	}
+	return client, conn.Close, nil
+}
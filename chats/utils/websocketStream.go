@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// webSocketWriteTimeout время на запись одного сообщения клиенту. Клиент, который не читает
+	// соединение, отключается, а неподтверждённые сообщения остаются в его подписке
+	webSocketWriteTimeout = 10 * time.Second
+
+	// webSocketReadLimit максимальный размер сообщения от клиента: клиент только отвечает на ping
+	webSocketReadLimit = 512
+)
+
+var (
+	// ErrStreamClosed поток уже закрыт
+	ErrStreamClosed = errors.New("поток закрыт")
+
+	// ErrSlowClient очередь отправки клиента заполнена
+	ErrSlowClient = errors.New("клиент не успевает получать сообщения")
+)
+
+// WebSocketFrame сообщение для отправки клиенту. Delivered вызывается после записи сообщения в соединение,
+// например для подтверждения сообщения RabbitMQ.
+type WebSocketFrame struct {
+	Payload   interface{}
+	Delivered func()
+}
+
+// WebSocketStream поток сообщений чата в WebSocket соединении, реализует StreamInterface.
+// Сообщения ставятся в ограниченную очередь отправки и записываются в соединение методом Run.
+type WebSocketStream struct {
+	UserId       string // Пользователь, которому принадлежит подписка
+	Subscription string // Подписка клиента, к которой привязано соединение
+
+	conn         *websocket.Conn
+	outbox       chan WebSocketFrame
+	pingInterval time.Duration
+
+	closeOnce sync.Once
+	done      chan struct{}
+	closeCode int
+	closeText string
+}
+
+// NewWebSocketStream создаёт поток с очередью отправки на buffer сообщений.
+// Сервер отправляет ping раз в pingInterval, клиент, не ответивший за два интервала, отключается.
+func NewWebSocketStream(conn *websocket.Conn, userId string, subscription string, buffer int,
+	pingInterval time.Duration) *WebSocketStream {
+	return &WebSocketStream{
+		UserId:       userId,
+		Subscription: subscription,
+		conn:         conn,
+		outbox:       make(chan WebSocketFrame, buffer),
+		pingInterval: pingInterval,
+		done:         make(chan struct{}),
+	}
+}
+
+// Send ставит сообщение в очередь отправки без ожидания. Если очередь заполнена, возвращает ErrSlowClient.
+// Сообщение может быть WebSocketFrame или значением для отправки в JSON.
+func (s *WebSocketStream) Send(message interface{}) error {
+	frame, ok := message.(WebSocketFrame)
+	if !ok {
+		frame = WebSocketFrame{Payload: message}
+	}
+
+	select {
+	case <-s.done:
+		return ErrStreamClosed
+	default:
+	}
+
+	select {
+	case s.outbox <- frame:
+		return nil
+	default:
+		return ErrSlowClient
+	}
+}
+
+// Close закрывает поток с кодом 1001 (сервер завершает соединение).
+func (s *WebSocketStream) Close() error {
+	return s.CloseWithReason(websocket.CloseGoingAway, "")
+}
+
+// CloseWithReason закрывает поток, клиенту отправляется сообщение закрытия с кодом code и причиной text.
+// Повторные вызовы ничего не делают.
+func (s *WebSocketStream) CloseWithReason(code int, text string) error {
+	s.closeOnce.Do(func() {
+		s.closeCode, s.closeText = code, text
+		close(s.done)
+	})
+	return nil
+}
+
+// IsClosed проверяет, закрыт ли поток.
+func (s *WebSocketStream) IsClosed() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Done возвращает канал, который закрывается при закрытии потока.
+func (s *WebSocketStream) Done() <-chan struct{} {
+	return s.done
+}
+
+// Run записывает сообщения из очереди отправки и ping в соединение и читает ответы клиента,
+// пока поток не будет закрыт или соединение не оборвётся. Соединение закрывается при выходе.
+// Закрытие соединения клиентом не считается ошибкой.
+func (s *WebSocketStream) Run() error {
+	defer s.conn.Close()
+	defer s.CloseWithReason(websocket.CloseNormalClosure, "")
+
+	// Ответ на ping продлевает ожидание следующего сообщения от клиента
+	pongTimeout := 2 * s.pingInterval
+	s.conn.SetReadLimit(webSocketReadLimit)
+	if err := s.conn.SetReadDeadline(time.Now().Add(pongTimeout)); err != nil {
+		return err
+	}
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(pongTimeout))
+	})
+
+	// Сообщения клиента не обрабатываются, чтение нужно для ping/pong и закрытия соединения
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			if _, _, err := s.conn.ReadMessage(); err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(s.pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case frame := <-s.outbox:
+			if err := s.conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout)); err != nil {
+				return err
+			}
+			if err := s.conn.WriteJSON(frame.Payload); err != nil {
+				return err
+			}
+			if frame.Delivered != nil {
+				frame.Delivered()
+			}
+
+		case <-ping.C:
+			if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(webSocketWriteTimeout)); err != nil {
+				return err
+			}
+
+		case err := <-readErr:
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				return nil
+			}
+			return err
+
+		case <-s.done:
+			message := websocket.FormatCloseMessage(s.closeCode, s.closeText)
+			_ = s.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(webSocketWriteTimeout))
+			return nil
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Команда оператора CRM для управления миграциями баз данных компаний.
+//
+// Мигрируются база-шаблон и базы компаний из реестра companies. status также показывает базы
+// в формате баз компаний, не зарегистрированные в реестре, и отсутствующие базы зарегистрированных компаний.
+//
+// Запуск внутри контейнера dbservice:
+//
+//	go run ./cmd/migrationctl status
+//	go run ./cmd/migrationctl -actor ops@example.com -dry-run run
+//	go run ./cmd/migrationctl -actor ops@example.com -concurrency 8 run
+//	go run ./cmd/migrationctl -actor ops@example.com -db company_c -version 7 migrate
+//	go run ./cmd/migrationctl -actor ops@example.com -db company_c -force migrate
+//	go run ./cmd/migrationctl -db company_c history
+//
+// run мигрирует все базы до последней версии и останавливается после первой ошибки.
+// migrate мигрирует или откатывает одну базу до версии -version (0 - последняя версия),
+// -force повторяет прерванную (dirty) миграцию. Результаты сохраняются в историю migrationHistory.
+package main
+
+import (
+	"context"
+	"crmSystem/migrations"
+	"crmSystem/provisioning"
+	"crmSystem/utils"
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func main() {
+	envPath := flag.String("env", "/app/.env", "путь к файлу с переменными окружения")
+	actor := flag.String("actor", "", "кто запускает миграции, сохраняется в историю")
+	dbName := flag.String("db", "", "база данных компании для migrate и history")
+	version := flag.Uint("version", 0, "версия для migrate, 0 - последняя")
+	concurrency := flag.Int("concurrency", 0, "число баз, мигрируемых одновременно (по умолчанию MIGRATION_CONCURRENCY)")
+	dryRun := flag.Bool("dry-run", false, "только показать миграции, которые будут применены")
+	force := flag.Bool("force", false, "повторить прерванную миграцию базы")
+	limit := flag.Int("limit", 50, "число записей history")
+	timeout := flag.Duration("timeout", time.Hour, "максимальное время выполнения")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Использование: migrationctl [флаги] status|run|migrate|history\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Загружаем переменные из файла .env
+	if err := godotenv.Load(*envPath); err != nil {
+		log.Fatalf("Ошибка загрузки .env файла: %v", err)
+	}
+	if *concurrency <= 0 {
+		*concurrency = migrations.ConcurrencyFromEnv()
+	}
+
+	authDb, err := sql.Open("postgres", utils.DsnString(os.Getenv("DB_AUTH_NAME")))
+	if err != nil {
+		log.Fatalf("Ошибка подключения к базе данных авторизации: %v", err)
+	}
+	defer authDb.Close()
+
+	plane, err := migrations.NewControlPlane(os.Getenv("MIGRATION_COMPANYDB_PATH"), provisioning.TemplateName(), func() (*sql.DB, error) {
+		return authDb, nil
+	})
+	if err != nil {
+		log.Fatalf("Ошибка чтения миграций компаний: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	defer out.Flush()
+
+	switch command := flag.Arg(0); command {
+	case "status":
+		statuses, err := plane.Status(ctx, *concurrency)
+		if err != nil {
+			log.Fatalf("Ошибка получения версий баз: %v", err)
+		}
+		fmt.Fprintf(out, "Последняя версия: %d\n", plane.LatestVersion())
+		fmt.Fprintln(out, "БАЗА\tВЕРСИЯ\tDIRTY\tОШИБКА")
+		for _, st := range statuses {
+			fmt.Fprintf(out, "%s\t%d\t%t\t%s\n", st.DbName, st.Version, st.Dirty, st.Error)
+		}
+
+		inventory, err := plane.Inventory(ctx)
+		if err != nil {
+			log.Fatalf("Ошибка получения списка баз данных: %v", err)
+		}
+		for _, dbName := range inventory.Unregistered {
+			fmt.Fprintf(out, "%s\t-\t-\tне зарегистрирована в реестре компаний, не мигрируется\n", dbName)
+		}
+		for _, dbName := range inventory.Missing {
+			fmt.Fprintf(out, "%s\t-\t-\tбаза зарегистрированной компании отсутствует на сервере\n", dbName)
+		}
+
+	case "run":
+		report, err := plane.Run(ctx, migrations.RunOptions{Concurrency: *concurrency, DryRun: *dryRun, Actor: *actor})
+		if err != nil {
+			log.Fatalf("Ошибка миграции: %v", err)
+		}
+		printResults(out, report.Results...)
+		if report.Stopped {
+			out.Flush()
+			log.Fatalf("Миграции остановлены после ошибки (запуск %s)", report.RunId)
+		}
+
+	case "migrate":
+		if *dbName == "" {
+			log.Fatalf("Не указана база данных (-db)")
+		}
+		result, err := plane.MigrateTenant(ctx, *dbName, *version, migrations.TenantOptions{
+			DryRun: *dryRun,
+			Force:  *force,
+			Actor:  *actor,
+		})
+		if err != nil {
+			log.Fatalf("Ошибка миграции: %v", err)
+		}
+		printResults(out, *result)
+		if result.Status == migrations.StatusFailed {
+			out.Flush()
+			os.Exit(1)
+		}
+
+	case "history":
+		entries, err := plane.History(ctx, *dbName, *limit)
+		if err != nil {
+			log.Fatalf("Ошибка получения истории: %v", err)
+		}
+		fmt.Fprintln(out, "НАЧАЛО\tЗАПУСК\tБАЗА\tОПЕРАЦИЯ\tВЕРСИИ\tСТАТУС\tКТО\tОШИБКА")
+		for _, e := range entries {
+			fmt.Fprintf(out, "%s\t%s\t%s\t%s\t%d -> %d\t%s\t%s\t%s\n", e.StartedAt.Format(time.RFC3339), e.RunId,
+				e.DbName, e.Operation, e.FromVersion, e.ToVersion, e.Status, e.Actor, e.Error)
+		}
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+// printResults выводит результаты миграции баз таблицей.
+func printResults(out *tabwriter.Writer, results ...migrations.Result) {
+	fmt.Fprintln(out, "БАЗА\tОПЕРАЦИЯ\tВЕРСИИ\tМИГРАЦИИ\tСТАТУС\tОШИБКА")
+	for _, r := range results {
+		pending := make([]string, len(r.Pending))
+		for i, v := range r.Pending {
+			pending[i] = fmt.Sprint(v)
+		}
+		fmt.Fprintf(out, "%s\t%s\t%d -> %d\t%s\t%s\t%s\n", r.DbName, r.Operation, r.FromVersion, r.ToVersion,
+			strings.Join(pending, ","), r.Status, r.Error)
+	}
+}
@@ -0,0 +1,47 @@
+// Однократная команда для перехэширования паролей, сохранённых в открытом виде
+// до введения хэширования в таблице authusers.
+//
+// Запуск внутри контейнера dbservice:
+//
+//	go run ./cmd/rehashpasswords -env /app/.env
+package main
+
+import (
+	"context"
+	"crmSystem/dbauthservice"
+	"crmSystem/utils"
+	"database/sql"
+	"flag"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+	"log"
+	"os"
+	"time"
+)
+
+func main() {
+	envPath := flag.String("env", "/app/.env", "путь к файлу с переменными окружения")
+	timeout := flag.Duration("timeout", 10*time.Minute, "максимальное время выполнения")
+	flag.Parse()
+
+	// Загружаем переменные из файла .env
+	if err := godotenv.Load(*envPath); err != nil {
+		log.Fatalf("Ошибка загрузки .env файла: %v", err)
+	}
+
+	db, err := sql.Open("postgres", utils.DsnString(os.Getenv("DB_AUTH_NAME")))
+	if err != nil {
+		log.Fatalf("Ошибка подключения к базе данных авторизации: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	updated, err := dbauthservice.RehashLegacyPasswords(ctx, db)
+	if err != nil {
+		log.Fatalf("Перехэширование прервано после %d записей: %v", updated, err)
+	}
+
+	log.Printf("Перехэшировано паролей: %d", updated)
+}
@@ -0,0 +1,145 @@
+// Команда оператора CRM для отключения компаний: приостановка, восстановление,
+// выгрузка данных и удаление (при расторжении договора или запросе на удаление данных).
+//
+// Запуск внутри контейнера dbservice:
+//
+//	go run ./cmd/tenantctl -company 3 -actor ops@example.com -reason "договор расторгнут" suspend
+//	go run ./cmd/tenantctl -company 3 -actor ops@example.com resume
+//	go run ./cmd/tenantctl -company 3 -actor ops@example.com -out /backup/company-3.zip export
+//	go run ./cmd/tenantctl -company 3 -actor ops@example.com -grace 720h delete
+//	go run ./cmd/tenantctl purge
+//
+// Удалённая компания безвозвратно удаляется dbservice после срока -grace (по умолчанию 30 дней),
+// команда purge удаляет компании с истёкшим сроком сразу. Все операции записываются в журнал tenantAudit.
+package main
+
+import (
+	"context"
+	"crmSystem/offboarding"
+	"crmSystem/utils"
+	"database/sql"
+	"flag"
+	"fmt"
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+	"log"
+	"os"
+	"time"
+)
+
+func main() {
+	envPath := flag.String("env", "/app/.env", "путь к файлу с переменными окружения")
+	companyId := flag.String("company", "", "ID компании в реестре companies")
+	actor := flag.String("actor", "", "кто выполняет операцию, сохраняется в журнал")
+	reason := flag.String("reason", "", "причина приостановки или удаления")
+	grace := flag.Duration("grace", offboarding.DefaultGracePeriod, "срок до безвозвратного удаления компании")
+	out := flag.String("out", "", "файл архива для export")
+	timeout := flag.Duration("timeout", time.Hour, "максимальное время выполнения")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Использование: tenantctl [флаги] suspend|resume|export|delete|purge\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command := flag.Arg(0)
+	if command != "purge" && *companyId == "" {
+		log.Fatalf("Не указан ID компании (-company)")
+	}
+
+	// Загружаем переменные из файла .env
+	if err := godotenv.Load(*envPath); err != nil {
+		log.Fatalf("Ошибка загрузки .env файла: %v", err)
+	}
+
+	authDb, err := sql.Open("postgres", utils.DsnString(os.Getenv("DB_AUTH_NAME")))
+	if err != nil {
+		log.Fatalf("Ошибка подключения к базе данных авторизации: %v", err)
+	}
+	defer authDb.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	switch command {
+	case "suspend":
+		err = offboarding.Suspend(ctx, authDb, *companyId, *actor, *reason)
+		if err == nil {
+			log.Printf("Работа компании %s приостановлена", *companyId)
+		}
+	case "resume":
+		err = offboarding.Resume(ctx, authDb, *companyId, *actor)
+		if err == nil {
+			log.Printf("Работа компании %s возобновлена", *companyId)
+		}
+	case "delete":
+		var deleteAfter time.Time
+		deleteAfter, err = offboarding.ScheduleDeletion(ctx, authDb, *companyId, *actor, *reason, *grace)
+		if err == nil {
+			log.Printf("Компания %s удалена, данные будут удалены безвозвратно после %s",
+				*companyId, deleteAfter.Format(time.RFC3339))
+		}
+	case "export":
+		err = export(ctx, authDb, *companyId, *actor, *out)
+	case "purge":
+		var purged int
+		purged, err = offboarding.Purge(ctx, authDb, utils.DropDatabase)
+		log.Printf("Безвозвратно удалено компаний: %d", purged)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatalf("Ошибка выполнения %s: %v", command, err)
+	}
+}
+
+// export выгружает базу данных компании в архив out и записывает выгрузку в журнал.
+// Выгрузка доступна в любом статусе компании, пока её данные не удалены безвозвратно.
+func export(ctx context.Context, authDb *sql.DB, companyId string, actor string, out string) error {
+	if actor == "" {
+		return offboarding.ErrActorRequired
+	}
+	if out == "" {
+		return fmt.Errorf("не указан файл архива (-out)")
+	}
+
+	tenants := utils.NewTenantResolver(func() (*sql.DB, error) { return authDb, nil })
+	tenant, err := tenants.ByCompanyId(ctx, companyId)
+	if err != nil {
+		return err
+	}
+
+	// Пул соединений не выдаёт базы приостановленных и удалённых компаний, поэтому подключаемся напрямую
+	db, err := sql.Open("postgres", utils.DsnString(tenant.DbName))
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к базе данных компании: %w", err)
+	}
+	defer db.Close()
+
+	file, err := os.OpenFile(out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("ошибка создания файла архива: %w", err)
+	}
+
+	manifest, err := offboarding.Export(ctx, tenant, db, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(out)
+		return err
+	}
+
+	details := fmt.Sprintf("выгружено таблиц: %d, версия схемы: %d", len(manifest.Tables), manifest.SchemaVersion)
+	if err := offboarding.RecordAudit(ctx, authDb, tenant.CompanyId, tenant.Name, tenant.DbName,
+		offboarding.ActionExported, actor, details); err != nil {
+		return err
+	}
+
+	log.Printf("Данные компании %s выгружены в %s (%s)", companyId, out, details)
+	return nil
+}
@@ -0,0 +1,89 @@
+package dbadminservice
+
+import (
+	"context"
+	"crmSystem/dbauthservice"
+	pbAdmin "crmSystem/proto/dbadmin"
+	"crmSystem/utils"
+	"database/sql"
+	"errors"
+	"log"
+	"os"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListCompanyApiKeys возвращает API ключи всех пользователей компании администратора.
+func (s AdminServiceServer) ListCompanyApiKeys(ctx context.Context, _ *pbAdmin.ListCompanyApiKeysRequest) (*pbAdmin.ListCompanyApiKeysResponse, error) {
+	identity, db, err := s.apiKeyAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := dbauthservice.ListCompanyApiKeys(ctx, db, identity.CompanyId)
+	if err != nil {
+		log.Printf("Ошибка получения API ключей компании: %v", err)
+		return nil, status.Errorf(codes.Internal, "ошибка получения API ключей")
+	}
+
+	response := &pbAdmin.ListCompanyApiKeysResponse{}
+	for _, key := range keys {
+		response.Keys = append(response.Keys, &pbAdmin.CompanyApiKey{
+			Id:         key.Id,
+			Email:      key.Email,
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scopes:     key.Scopes,
+			ExpiresAt:  unixOrZero(key.ExpiresAt),
+			CreatedAt:  key.CreatedAt.Unix(),
+			LastUsedAt: unixOrZero(key.LastUsedAt),
+		})
+	}
+	return response, nil
+}
+
+// RevokeCompanyApiKey отзывает API ключ любого пользователя компании администратора,
+// например ключ интеграции уволенного сотрудника.
+func (s AdminServiceServer) RevokeCompanyApiKey(ctx context.Context, req *pbAdmin.RevokeCompanyApiKeyRequest) (*pbAdmin.RevokeCompanyApiKeyResponse, error) {
+	identity, db, err := s.apiKeyAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = dbauthservice.RevokeCompanyApiKey(ctx, db, identity.CompanyId, req.Id)
+	if errors.Is(err, dbauthservice.ErrApiKeyNotFound) {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	if err != nil {
+		log.Printf("Ошибка отзыва API ключа: %v", err)
+		return nil, status.Errorf(codes.Internal, "ошибка отзыва API ключа")
+	}
+	return &pbAdmin.RevokeCompanyApiKeyResponse{Message: "API ключ отозван"}, nil
+}
+
+// apiKeyAdmin проверяет, что запрос выполняет администратор компании, и возвращает соединение с базой авторизации.
+func (s AdminServiceServer) apiKeyAdmin(ctx context.Context) (*utils.Identity, *sql.DB, error) {
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if identity.Role != os.Getenv("FIRST_ROLE") {
+		return nil, nil, status.Errorf(codes.PermissionDenied, "управлять API ключами компании может только администратор")
+	}
+
+	db, err := s.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
+	if err != nil {
+		log.Printf("Ошибка подключения к базе авторизации: %v", err)
+		return nil, nil, status.Errorf(codes.Internal, "Ошибка подключения к базе авторизации")
+	}
+	return identity, db, nil
+}
+
+// unixOrZero возвращает Unix время или 0, если значение не задано.
+func unixOrZero(t sql.NullTime) int64 {
+	if !t.Valid {
+		return 0
+	}
+	return t.Time.Unix()
+}
@@ -9,7 +9,6 @@ import (
 	"fmt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"log"
 	"os"
@@ -29,11 +28,6 @@ func NewGRPCDBAdminService(mapConnect *utils.MapConnectionsDB) *AdminServiceServ
 
 func (s AdminServiceServer) RegisterUsersInCompany(ctx context.Context, req *pbAdmin.RegisterUsersRequest) (*pbAdmin.RegisterUsersResponse, error) {
 
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, status.Errorf(codes.Internal, "Не удалось получить метаданные из контекста")
-	}
-
 	token, err := utils.ExtractTokenFromContext(ctx)
 	if err != nil {
 		log.Printf("Не удалось извлечь токен для логирования: %v", err)
@@ -58,36 +52,29 @@ func (s AdminServiceServer) RegisterUsersInCompany(ctx context.Context, req *pbA
 		}(conn)
 	}
 
-	// Извлекаем DatabaseName из метаданных
-	dbCheck := md["database"]
-	if len(dbCheck) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", "database не найдена в метаданных")
+	// Извлекаем данные пользователя только из проверенного токена (см. utils.IdentityInterceptor)
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
 		if errLogs != nil {
-			log.Printf("database не найдена в метаданных: %v", err)
+			log.Printf("Не удалось получить данные пользователя из токена: %v", errLogs)
 		}
-		return nil, status.Errorf(codes.Unauthenticated, "database не найдена в метаданных")
+		return nil, err
 	}
-	database := md["database"][0]
+	database := identity.Database
+	userId := identity.UserId
 
-	// Извлекаем UserId из метаданных
-	uIdCheck := md["user-id"]
-	if len(uIdCheck) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, database, "", "userId не найдена в метаданных")
-		if errLogs != nil {
-			log.Printf("userId не найдена в метаданных: %v", err)
-		}
-		return nil, status.Errorf(codes.Unauthenticated, "userId не найдена в метаданных")
+	// Компания берётся из токена, значение из запроса допускается только если совпадает с ним
+	CompanyId := identity.CompanyId
+	if req.CompanyId != "" && req.CompanyId != CompanyId {
+		return nil, utils.DenyCrossTenant(ctx, identity, fmt.Sprintf("запрошена компания %s", req.CompanyId))
 	}
-	userId := md["user-id"][0]
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
-	CompanyId := req.CompanyId
-
 	authDBName := os.Getenv("DB_AUTH_NAME")
-	dsn := utils.DsnString(authDBName)
-	dbConn, err := s.connectionsMap.GetDb(dsn)
+	dbConn, err := s.connectionsMap.GetDb(authDBName)
 	if err != nil || dbConn == nil {
 		errLogs := utils.SaveLogsError(ctx, clientLogs, database, userId, "Ошибка подключения к базе авторизации")
 		if errLogs != nil {
@@ -114,8 +101,8 @@ func (s AdminServiceServer) RegisterUsersInCompany(ctx context.Context, req *pbA
 		}
 	}()
 
-	dsnC := utils.DsnString(database)
-	dbConnCompany, err := s.connectionsMap.GetDb(dsnC)
+	dbConnCompany, release, err := s.connectionsMap.Acquire(database)
+	defer release()
 	if err != nil || dbConnCompany == nil {
 		errLogs := utils.SaveLogsError(ctx, clientLogs, database, userId, err.Error())
 		if errLogs != nil {
@@ -142,12 +129,27 @@ func (s AdminServiceServer) RegisterUsersInCompany(ctx context.Context, req *pbA
 		}
 	}()
 
+	// Приглашения новым пользователям сохраняются в redis
+	clientRedis, err, connRedis := utils.RedisServiceConnector(token)
+	if err != nil {
+		errLogs := utils.SaveLogsError(ctx, clientLogs, database, userId, err.Error())
+		if errLogs != nil {
+			log.Printf("Ошибка подключения к Redis: %v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "Ошибка подключения к Redis")
+	}
+	defer func(connRedis *grpc.ClientConn) {
+		if err := connRedis.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения c Redis: %v", err)
+		}
+	}(connRedis)
+
 	var registeredUsers []*pbAdmin.UserResponse
 
 	// Пакетное добавление authusers
 	authQuery := `
-		INSERT INTO authusers (email, phone, password, company_id) 
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO authusers (email, phone, password, company_id, status) 
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id`
 	stmtAuth, err := tx.Prepare(authQuery)
 	if err != nil {
@@ -176,10 +178,9 @@ func (s AdminServiceServer) RegisterUsersInCompany(ctx context.Context, req *pbA
 			err = txc.QueryRow("SELECT id FROM users WHERE authId = $1", existingAuthUserId).Scan(&existingUserId)
 			if err == nil { // Пользователь существует и связан с компанией
 				registeredUsers = append(registeredUsers, &pbAdmin.UserResponse{
-					Email:    user.Email,
-					Phone:    user.Phone,
-					RoleId:   user.RoleId,
-					Password: "default_password",
+					Email:  user.Email,
+					Phone:  user.Phone,
+					RoleId: user.RoleId,
 				})
 				continue
 			}
@@ -197,19 +198,30 @@ func (s AdminServiceServer) RegisterUsersInCompany(ctx context.Context, req *pbA
 				return nil, status.Errorf(codes.Internal, fmt.Sprintf("Ошибка добавления связи пользователя с компанией: "+err.Error()))
 			}
 
+			// Существующий пользователь входит со своим паролем, приглашение ему не нужно
 			registeredUsers = append(registeredUsers, &pbAdmin.UserResponse{
-				Email:    user.Email,
-				Phone:    user.Phone,
-				RoleId:   user.RoleId,
-				Password: "default_password",
+				Email:  user.Email,
+				Phone:  user.Phone,
+				RoleId: user.RoleId,
 			})
 
 			continue
 		}
 
-		// Если пользователь не существует, создаём его в таблице authusers
+		// Если пользователь не существует, создаём его в таблице authusers со статусом "invited".
+		// Пароль задаёт сам пользователь по ссылке из приглашения, до этого сохраняется хэш
+		// случайного пароля, который никому не сообщается
+		tempPassword, err := utils.GenerateTempPassword()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "Ошибка генерации пароля: %v", err)
+		}
+		passwordHash, err := utils.HashPassword(tempPassword)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "Ошибка хэширования пароля: %v", err)
+		}
+
 		var userId int
-		err = stmtAuth.QueryRow(user.Email, user.Phone, "default_password", CompanyId).Scan(&userId)
+		err = stmtAuth.QueryRow(user.Email, user.Phone, passwordHash, CompanyId, utils.AuthStatusInvited).Scan(&userId)
 		if err != nil {
 			errLogs := utils.SaveLogsError(ctx, clientLogs, database, "", err.Error())
 			if errLogs != nil {
@@ -232,11 +244,21 @@ func (s AdminServiceServer) RegisterUsersInCompany(ctx context.Context, req *pbA
 			return nil, status.Errorf(codes.Internal, fmt.Sprintf("Ошибка добавления пользователя в компанию: "+err.Error()))
 		}
 
+		inviteToken, err := utils.IssueActivationToken(ctx, clientRedis, fmt.Sprint(userId),
+			utils.ActivationPurposeInvite, utils.InviteTTL)
+		if err != nil {
+			errLogs := utils.SaveLogsError(ctx, clientLogs, database, "", err.Error())
+			if errLogs != nil {
+				log.Printf("Ошибка создания приглашения: %v", err)
+			}
+			return nil, status.Errorf(codes.Internal, "Ошибка создания приглашения: %v", err)
+		}
+
 		registeredUsers = append(registeredUsers, &pbAdmin.UserResponse{
-			Email:    user.Email,
-			Phone:    user.Phone,
-			RoleId:   user.RoleId,
-			Password: "default_password",
+			Email:       user.Email,
+			Phone:       user.Phone,
+			RoleId:      user.RoleId,
+			InviteToken: inviteToken,
 		})
 	}
 
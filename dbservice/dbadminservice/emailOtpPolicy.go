@@ -0,0 +1,44 @@
+package dbadminservice
+
+import (
+	"context"
+	"crmSystem/dbauthservice"
+	pbAdmin "crmSystem/proto/dbadmin"
+	"crmSystem/utils"
+	"log"
+	"os"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SetEmailOtpPolicy разрешает или запрещает пользователям компании администратора
+// входить без пароля по одноразовому коду из письма. Компания берётся только из токена.
+func (s AdminServiceServer) SetEmailOtpPolicy(ctx context.Context, req *pbAdmin.SetEmailOtpPolicyRequest) (*pbAdmin.SetEmailOtpPolicyResponse, error) {
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Способы входа в компанию меняет только администратор (роль первого пользователя компании)
+	if identity.Role != os.Getenv("FIRST_ROLE") {
+		return nil, status.Errorf(codes.PermissionDenied, "изменять политику входа по коду может только администратор компании")
+	}
+
+	db, err := s.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
+	if err != nil {
+		log.Printf("Ошибка подключения к базе авторизации: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка подключения к базе авторизации")
+	}
+
+	if err := dbauthservice.SetCompanyEmailOtp(ctx, db, identity.CompanyId, req.Enabled); err != nil {
+		log.Printf("Ошибка изменения политики входа по коду: %v", err)
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	message := "Вход по коду из письма отключён"
+	if req.Enabled {
+		message = "Пользователи компании могут входить по коду из письма"
+	}
+	return &pbAdmin.SetEmailOtpPolicyResponse{Message: message}, nil
+}
@@ -0,0 +1,45 @@
+package dbadminservice
+
+import (
+	"context"
+	"crmSystem/dbauthservice"
+	pbAdmin "crmSystem/proto/dbadmin"
+	"crmSystem/utils"
+	"log"
+	"os"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SetMfaPolicy включает или отключает обязательную двухфакторную аутентификацию
+// для всех пользователей компании администратора. Компания берётся только из токена.
+// Политика проверяется при любом способе входа, в том числе через корпоративного провайдера.
+func (s AdminServiceServer) SetMfaPolicy(ctx context.Context, req *pbAdmin.SetMfaPolicyRequest) (*pbAdmin.SetMfaPolicyResponse, error) {
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Политику безопасности компании меняет только администратор (роль первого пользователя компании)
+	if identity.Role != os.Getenv("FIRST_ROLE") {
+		return nil, status.Errorf(codes.PermissionDenied, "изменять политику 2FA может только администратор компании")
+	}
+
+	db, err := s.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
+	if err != nil {
+		log.Printf("Ошибка подключения к базе авторизации: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка подключения к базе авторизации")
+	}
+
+	if err := dbauthservice.SetCompanyMfaRequired(ctx, db, identity.CompanyId, req.Required); err != nil {
+		log.Printf("Ошибка изменения политики 2FA: %v", err)
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	message := "Двухфакторная аутентификация больше не обязательна"
+	if req.Required {
+		message = "Двухфакторная аутентификация обязательна для всех пользователей компании"
+	}
+	return &pbAdmin.SetMfaPolicyResponse{Message: message}, nil
+}
@@ -0,0 +1,80 @@
+package dbadminservice
+
+import (
+	"context"
+	"crmSystem/dbauthservice"
+	pbAdmin "crmSystem/proto/dbadmin"
+	"crmSystem/utils"
+	"errors"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SetOidcConfig сохраняет настройки OpenID Connect провайдера компании администратора
+// и почтовые домены, пользователи которых входят через провайдера. Компания берётся только из токена.
+func (s AdminServiceServer) SetOidcConfig(ctx context.Context, req *pbAdmin.SetOidcConfigRequest) (*pbAdmin.SetOidcConfigResponse, error) {
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if identity.Role != os.Getenv("FIRST_ROLE") {
+		return nil, status.Errorf(codes.PermissionDenied, "настраивать вход через провайдера может только администратор компании")
+	}
+
+	// Провайдер определяется адресом issuer, токены принимаются только по защищённому соединению
+	issuer, err := url.Parse(strings.TrimSpace(req.Issuer))
+	if err != nil || issuer.Scheme != "https" || issuer.Host == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "issuer должен быть адресом https://")
+	}
+	if strings.TrimSpace(req.ClientId) == "" || req.ClientSecret == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "не указаны client id или client secret")
+	}
+
+	domains := make([]string, 0, len(req.Domains))
+	seen := make(map[string]bool, len(req.Domains))
+	for _, domain := range req.Domains {
+		domain = dbauthservice.NormalizeOidcDomain(domain)
+		if domain == "" || strings.ContainsAny(domain, "@/ ") || seen[domain] {
+			continue
+		}
+		seen[domain] = true
+		domains = append(domains, domain)
+	}
+	if len(domains) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "не указаны почтовые домены компании")
+	}
+
+	db, err := s.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
+	if err != nil {
+		log.Printf("Ошибка подключения к базе авторизации: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка подключения к базе авторизации")
+	}
+
+	err = dbauthservice.SaveOidcConfig(ctx, db, dbauthservice.OidcConfig{
+		CompanyId:    identity.CompanyId,
+		Issuer:       issuer.String(),
+		ClientId:     strings.TrimSpace(req.ClientId),
+		ClientSecret: req.ClientSecret,
+		Domains:      domains,
+		Enabled:      req.Enabled,
+	})
+	if errors.Is(err, dbauthservice.ErrOidcDomainTaken) {
+		return nil, status.Errorf(codes.AlreadyExists, "%v", err)
+	}
+	if err != nil {
+		log.Printf("Ошибка сохранения настроек провайдера: %v", err)
+		return nil, status.Errorf(codes.Internal, "ошибка сохранения настроек провайдера")
+	}
+
+	message := "Вход через корпоративного провайдера настроен"
+	if !req.Enabled {
+		message = "Вход через корпоративного провайдера отключён"
+	}
+	return &pbAdmin.SetOidcConfigResponse{Message: message}, nil
+}
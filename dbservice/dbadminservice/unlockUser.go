@@ -0,0 +1,94 @@
+package dbadminservice
+
+import (
+	"context"
+	pbAdmin "crmSystem/proto/dbadmin"
+	"crmSystem/proto/logs"
+	"crmSystem/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnlockUser снимает блокировку входа пользователя после неудачных попыток и сбрасывает их счётчик.
+// Разблокировать можно только пользователя своей компании, компания берётся из токена администратора.
+func (s AdminServiceServer) UnlockUser(ctx context.Context, req *pbAdmin.UnlockUserRequest) (*pbAdmin.UnlockUserResponse, error) {
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if identity.Role != os.Getenv("FIRST_ROLE") {
+		return nil, status.Errorf(codes.PermissionDenied, "снимать блокировку входа может только администратор компании")
+	}
+
+	db, err := s.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
+	if err != nil {
+		log.Printf("Ошибка подключения к базе авторизации: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка подключения к базе авторизации")
+	}
+
+	// Пользователь ищется только среди пользователей компании администратора
+	var authUserId, email string
+	err = db.QueryRowContext(ctx,
+		"SELECT id, email FROM authusers WHERE email = $1 AND company_id = $2",
+		strings.ToLower(strings.TrimSpace(req.Email)), identity.CompanyId).Scan(&authUserId, &email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, status.Errorf(codes.NotFound, "пользователь %s не найден в компании", req.Email)
+	}
+	if err != nil {
+		log.Printf("Ошибка поиска пользователя: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка поиска пользователя")
+	}
+
+	token, err := utils.ExtractTokenFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "Не удалось извлечь токен")
+	}
+
+	clientRedis, err, connRedis := utils.RedisServiceConnector(token)
+	if err != nil {
+		log.Printf("Ошибка подключения к Redis: %v", err)
+		return nil, status.Errorf(codes.Internal, "Не удалось создать соединение с сервером Redis")
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения c Redis: %v", err)
+		}
+	}(connRedis)
+
+	// Попытки входа по email и по телефону считаются по ID пользователя
+	limiter := utils.NewLoginLimiter(clientRedis)
+	if err := limiter.Reset(ctx, utils.LoginAccount(authUserId, "", "")); err != nil {
+		log.Printf("Ошибка снятия блокировки входа: %v", err)
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	// Снятие блокировки записывается в журнал
+	clientLogs, err, connLogs := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу Logs: %v", err)
+	} else {
+		defer func(conn *grpc.ClientConn) {
+			if err := conn.Close(); err != nil {
+				log.Printf("Ошибка закрытия соединения: %v", err)
+			}
+		}(connLogs)
+
+		message := fmt.Sprintf("Администратор %s снял блокировку входа пользователя %s", identity.UserId, email)
+		if errLogs := utils.SaveLogsWarning(ctx, clientLogs, identity.Database, identity.UserId, message); errLogs != nil {
+			log.Printf("Ошибка сохранения лога: %v", errLogs)
+		}
+	}
+
+	return &pbAdmin.UnlockUserResponse{
+		Message: fmt.Sprintf("Блокировка входа пользователя %s снята", email),
+	}, nil
+}
@@ -0,0 +1,86 @@
+package dbauthservice
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	"crmSystem/utils"
+	"errors"
+	"log"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ActivateAccount активирует учётную запись по одноразовому токену из письма.
+//
+// Для подтверждения email достаточно токена. Приглашённый пользователь обязан
+// одновременно задать пароль: без пароля токен не расходуется и ссылку можно открыть повторно.
+func (s *AuthServiceServer) ActivateAccount(ctx context.Context, req *dbauth.ActivateAccountRequest) (*dbauth.ActivateAccountResponse, error) {
+	if err := utils.RequireInternalCaller(ctx); err != nil {
+		return nil, err
+	}
+
+	token, err := utils.ExtractTokenFromContext(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "%v", err)
+	}
+
+	client, err, connRedis := utils.RedisServiceConnector(token)
+	if err != nil {
+		log.Printf("Ошибка подключения к Redis: %v", err)
+		return nil, status.Errorf(codes.Internal, "ошибка подключения к Redis")
+	}
+	defer func(connRedis *grpc.ClientConn) {
+		if err := connRedis.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения c Redis: %v", err)
+		}
+	}(connRedis)
+
+	// Проверяем назначение токена до того, как он будет израсходован
+	record, err := utils.PeekActivationToken(ctx, client, req.Token)
+	if err != nil {
+		return nil, activationError(err)
+	}
+	if record.Purpose == utils.ActivationPurposeInvite && req.Password == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "для активации приглашения необходимо задать пароль")
+	}
+
+	record, err = utils.ConsumeActivationToken(ctx, client, req.Token)
+	if err != nil {
+		return nil, activationError(err)
+	}
+
+	db, err := s.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
+	if err != nil {
+		log.Printf("Ошибка при получении соединения из connectionsMap: %v", err)
+		return nil, status.Errorf(codes.Internal, "ошибка подключения к базе данных авторизации")
+	}
+
+	if req.Password != "" {
+		err = SetPassword(ctx, db, record.UserId, req.Password)
+	} else {
+		err = VerifyEmail(ctx, db, record.UserId)
+	}
+	if errors.Is(err, ErrAuthUserNotFound) {
+		return nil, status.Errorf(codes.NotFound, "%v", utils.ErrActivationTokenInvalid)
+	}
+	if err != nil {
+		log.Printf("Ошибка активации учётной записи: %v", err)
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	return &dbauth.ActivateAccountResponse{
+		Message: "Учётная запись активирована",
+	}, nil
+}
+
+// activationError приводит ошибку проверки токена активации к ошибке gRPC.
+func activationError(err error) error {
+	if errors.Is(err, utils.ErrActivationTokenInvalid) {
+		return status.Errorf(codes.NotFound, "%v", err)
+	}
+	log.Printf("Ошибка проверки токена активации: %v", err)
+	return status.Errorf(codes.Internal, "ошибка проверки токена активации")
+}
@@ -0,0 +1,270 @@
+package dbauthservice
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	"crmSystem/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrApiKeyNotFound возвращается, если ключ не найден среди действующих ключей пользователя или компании.
+	ErrApiKeyNotFound = errors.New("API ключ не найден")
+
+	// ErrApiKeyInvalid возвращается, если ключ не существует, отозван, истёк или его владелец не может войти.
+	ErrApiKeyInvalid = errors.New("API ключ недействителен")
+)
+
+// ApiKey API ключ без секрета: сам ключ показывается пользователю один раз при создании.
+type ApiKey struct {
+	Id         string
+	AuthUserId string
+	CompanyId  string
+	Email      string // Владелец ключа
+	Name       string
+	Prefix     string
+	Scopes     []string
+	ExpiresAt  sql.NullTime
+	CreatedAt  time.Time
+	LastUsedAt sql.NullTime
+}
+
+// CreateApiKey сохраняет хэш нового API ключа пользователя authUserId и возвращает ID ключа.
+// Ключ привязывается к компании пользователя.
+func CreateApiKey(ctx context.Context, db *sql.DB, authUserId, name, prefix, keyHash string, scopes []string,
+	expiresAt sql.NullTime) (string, error) {
+
+	var id string
+	err := db.QueryRowContext(ctx, `
+        INSERT INTO apiKeys (auth_user_id, company_id, name, prefix, key_hash, scopes, expires_at)
+        SELECT id, company_id, $2, $3, $4, $5, $6 FROM authusers WHERE id = $1
+        RETURNING id`,
+		authUserId, name, prefix, keyHash, strings.Join(scopes, " "), expiresAt).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrAuthUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка сохранения API ключа: %w", err)
+	}
+	return id, nil
+}
+
+// ListUserApiKeys возвращает неотозванные API ключи пользователя authUserId.
+func ListUserApiKeys(ctx context.Context, db *sql.DB, authUserId string) ([]ApiKey, error) {
+	return listApiKeys(ctx, db, "k.auth_user_id = $1", authUserId)
+}
+
+// ListCompanyApiKeys возвращает неотозванные API ключи всех пользователей компании companyId.
+func ListCompanyApiKeys(ctx context.Context, db *sql.DB, companyId string) ([]ApiKey, error) {
+	return listApiKeys(ctx, db, "k.company_id = $1", companyId)
+}
+
+func listApiKeys(ctx context.Context, db *sql.DB, condition string, value string) ([]ApiKey, error) {
+	rows, err := db.QueryContext(ctx, `
+        SELECT k.id, k.auth_user_id, k.company_id, a.email, k.name, k.prefix, k.scopes,
+               k.expires_at, k.created_at, k.last_used_at
+        FROM apiKeys k JOIN authusers a ON a.id = k.auth_user_id
+        WHERE `+condition+` AND k.revoked_at IS NULL
+        ORDER BY k.created_at DESC`, value)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения API ключей: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []ApiKey
+	for rows.Next() {
+		var key ApiKey
+		var scopes string
+		if err := rows.Scan(&key.Id, &key.AuthUserId, &key.CompanyId, &key.Email, &key.Name, &key.Prefix, &scopes,
+			&key.ExpiresAt, &key.CreatedAt, &key.LastUsedAt); err != nil {
+			return nil, fmt.Errorf("ошибка получения API ключей: %w", err)
+		}
+		key.Scopes = strings.Fields(scopes)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка получения API ключей: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeUserApiKey отзывает ключ keyId, принадлежащий пользователю authUserId.
+func RevokeUserApiKey(ctx context.Context, db *sql.DB, authUserId, keyId string) error {
+	return revokeApiKey(ctx, db, "auth_user_id = $2", keyId, authUserId)
+}
+
+// RevokeCompanyApiKey отзывает ключ keyId любого пользователя компании companyId.
+func RevokeCompanyApiKey(ctx context.Context, db *sql.DB, companyId, keyId string) error {
+	return revokeApiKey(ctx, db, "company_id = $2", keyId, companyId)
+}
+
+func revokeApiKey(ctx context.Context, db *sql.DB, condition string, keyId string, owner string) error {
+	result, err := db.ExecContext(ctx,
+		"UPDATE apiKeys SET revoked_at = NOW() WHERE id = $1 AND "+condition+" AND revoked_at IS NULL",
+		keyId, owner)
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва API ключа: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка отзыва API ключа: %w", err)
+	}
+	if updated == 0 {
+		return ErrApiKeyNotFound
+	}
+	return nil
+}
+
+// FindApiKeyByHash возвращает действующий ключ по хэшу и отмечает время его использования.
+// Ключ отключённого или ещё не активированного пользователя не принимается.
+func FindApiKeyByHash(ctx context.Context, db *sql.DB, keyHash string) (*ApiKey, error) {
+	var key ApiKey
+	var scopes string
+	err := db.QueryRowContext(ctx, `
+        UPDATE apiKeys k SET last_used_at = NOW()
+        FROM authusers a
+        WHERE a.id = k.auth_user_id AND k.key_hash = $1 AND k.revoked_at IS NULL
+          AND (k.expires_at IS NULL OR k.expires_at > NOW()) AND a.status = $2
+        RETURNING k.id, k.auth_user_id, k.company_id, a.email, k.name, k.prefix, k.scopes, k.expires_at, k.created_at`,
+		keyHash, utils.AuthStatusVerified).
+		Scan(&key.Id, &key.AuthUserId, &key.CompanyId, &key.Email, &key.Name, &key.Prefix, &scopes,
+			&key.ExpiresAt, &key.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrApiKeyInvalid
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка проверки API ключа: %w", err)
+	}
+	key.Scopes = strings.Fields(scopes)
+	return &key, nil
+}
+
+// ApiKeyInfo переводит ключ в сообщение gRPC.
+func (k *ApiKey) ApiKeyInfo() *dbauth.AuthApiKey {
+	info := &dbauth.AuthApiKey{
+		Id:        k.Id,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt.Unix(),
+	}
+	if k.ExpiresAt.Valid {
+		info.ExpiresAt = k.ExpiresAt.Time.Unix()
+	}
+	if k.LastUsedAt.Valid {
+		info.LastUsedAt = k.LastUsedAt.Time.Unix()
+	}
+	return info
+}
+
+// CreateApiKey сохраняет API ключ пользователя. Ключ создаёт auth сервис и передаёт только его хэш.
+func (s *AuthServiceServer) CreateApiKey(ctx context.Context, req *dbauth.CreateApiKeyRequest) (*dbauth.CreateApiKeyResponse, error) {
+	db, err := s.apiKeyDb(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.UserId == "" || req.KeyHash == "" || len(req.Scopes) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "не указаны пользователь, ключ или области доступа")
+	}
+
+	var expiresAt sql.NullTime
+	if req.ExpiresAt > 0 {
+		expiresAt = sql.NullTime{Time: time.Unix(req.ExpiresAt, 0), Valid: true}
+	}
+
+	id, err := CreateApiKey(ctx, db, req.UserId, req.Name, req.Prefix, req.KeyHash, req.Scopes, expiresAt)
+	if err != nil {
+		return nil, apiKeyError(err)
+	}
+	return &dbauth.CreateApiKeyResponse{Id: id}, nil
+}
+
+// ListApiKeys возвращает API ключи пользователя.
+func (s *AuthServiceServer) ListApiKeys(ctx context.Context, req *dbauth.ListApiKeysRequest) (*dbauth.ListApiKeysResponse, error) {
+	db, err := s.apiKeyDb(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := ListUserApiKeys(ctx, db, req.UserId)
+	if err != nil {
+		return nil, apiKeyError(err)
+	}
+
+	response := &dbauth.ListApiKeysResponse{}
+	for i := range keys {
+		response.Keys = append(response.Keys, keys[i].ApiKeyInfo())
+	}
+	return response, nil
+}
+
+// RevokeApiKey отзывает API ключ пользователя.
+func (s *AuthServiceServer) RevokeApiKey(ctx context.Context, req *dbauth.RevokeApiKeyRequest) (*dbauth.RevokeApiKeyResponse, error) {
+	db, err := s.apiKeyDb(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := RevokeUserApiKey(ctx, db, req.UserId, req.Id); err != nil {
+		return nil, apiKeyError(err)
+	}
+	return &dbauth.RevokeApiKeyResponse{Message: "API ключ отозван"}, nil
+}
+
+// VerifyApiKey проверяет API ключ по хэшу и передаёт данные его владельца в заголовках ответа так же, как LoginDB.
+func (s *AuthServiceServer) VerifyApiKey(ctx context.Context, req *dbauth.VerifyApiKeyRequest) (*dbauth.VerifyApiKeyResponse, error) {
+	db, err := s.apiKeyDb(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := FindApiKeyByHash(ctx, db, req.KeyHash)
+	if err != nil {
+		return nil, apiKeyError(err)
+	}
+
+	if err := s.sendLoginIdentity(ctx, db, key.AuthUserId); err != nil {
+		return nil, err
+	}
+
+	return &dbauth.VerifyApiKeyResponse{
+		Id:     key.Id,
+		Scopes: key.Scopes,
+	}, nil
+}
+
+// apiKeyDb проверяет, что метод API ключей вызван внутренним сервисом, и возвращает соединение с базой авторизации.
+func (s *AuthServiceServer) apiKeyDb(ctx context.Context) (*sql.DB, error) {
+	if err := utils.RequireInternalCaller(ctx); err != nil {
+		return nil, err
+	}
+
+	db, err := s.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
+	if err != nil {
+		log.Printf("Ошибка при получении соединения из connectionsMap: %v", err)
+		return nil, status.Errorf(codes.Internal, "ошибка подключения к базе данных авторизации")
+	}
+	return db, nil
+}
+
+// apiKeyError приводит ошибку работы с API ключами к ошибке gRPC.
+func apiKeyError(err error) error {
+	switch {
+	case errors.Is(err, ErrApiKeyInvalid):
+		return status.Errorf(codes.Unauthenticated, "%v", err)
+	case errors.Is(err, ErrApiKeyNotFound), errors.Is(err, ErrAuthUserNotFound):
+		return status.Errorf(codes.NotFound, "%v", err)
+	default:
+		log.Printf("Ошибка работы с API ключами: %v", err)
+		return status.Errorf(codes.Internal, "ошибка работы с API ключами")
+	}
+}
@@ -2,12 +2,13 @@ package dbauthservice
 
 import (
 	"context"
-	"crmSystem/migrations"
 	"crmSystem/proto/dbauth"
 	"crmSystem/proto/logs"
 	"crmSystem/proto/redis"
+	"crmSystem/provisioning"
 	"crmSystem/utils"
 	"database/sql"
+	"errors"
 	"fmt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -22,12 +23,14 @@ import (
 
 type AuthServiceServer struct {
 	dbauth.UnsafeDbAuthServiceServer
-	connectionsMap *utils.MapConnectionsDB // Используем указатель
+	connectionsMap *utils.MapConnectionsDB   // Используем указатель
+	provisioner    *provisioning.Provisioner // Создаёт базы данных зарегистрированных компаний в фоне
 }
 
-func NewGRPCDBAuthService(mapConnect *utils.MapConnectionsDB) *AuthServiceServer {
+func NewGRPCDBAuthService(mapConnect *utils.MapConnectionsDB, provisioner *provisioning.Provisioner) *AuthServiceServer {
 	return &AuthServiceServer{
 		connectionsMap: mapConnect,
+		provisioner:    provisioner,
 	}
 }
 
@@ -58,15 +61,62 @@ func (s *AuthServiceServer) LoginDB(ctx context.Context, req *dbauth.LoginDBRequ
 		}(conn)
 	}
 
-	// Проверяем пользователя, используя функцию checkUser.
-	dbName, userId, companyId, err := checkUser(s, req, token, ctx, clientLogs)
+	// Устанавливаем соединение с gRPC сервером Redis для учёта неудачных попыток входа
+	clientRedis, err, connRedis := utils.RedisServiceConnector(token)
+	if err != nil {
+		log.Printf("Ошибка подключения к Redis: %v", err)
+		return nil, status.Errorf(codes.Internal, "Не удалось создать соединение с сервером Redis")
+	}
+	defer func(connRedis *grpc.ClientConn) {
+		if err := connRedis.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения c Redis: %v", err)
+		}
+	}(connRedis)
+
+	// Проверяем логин и пароль с учётом блокировок после неудачных попыток, используя функцию checkUser.
+	attempt, err := checkUser(s, req, ctx, clientLogs, utils.NewLoginLimiter(clientRedis))
+	logLoginLockouts(ctx, clientLogs, attempt.Lockouts)
 	if err != nil {
 		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
 		if errLogs != nil {
 			log.Printf("Внутренняя ошибка проверки пользователя: %v", err)
 		}
+		if errors.Is(err, ErrLoginLocked) {
+			return nil, loginLockedError(ctx, attempt.RetryAfter)
+		}
+		if errors.Is(err, ErrInvalidCredentials) {
+			return nil, status.Errorf(codes.Unauthenticated, "%v", err)
+		}
+		if errors.Is(err, ErrAccountNotActivated) {
+			return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+		}
 		return nil, status.Errorf(codes.Internal, "%v", err)
 	}
+	authUserId, companyId := attempt.AuthUserId, attempt.CompanyId
+
+	// Если для входа нужен второй фактор, данные пользователя не передаются:
+	// auth сервис выдаст токен ожидания и запросит код TOTP
+	mfa, err := s.mfaRequirement(ctx, authUserId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	if mfa != "" {
+		if err := grpc.SendHeader(ctx, metadata.Pairs("mfa-required", mfa, "auth-user-id", authUserId)); err != nil {
+			return nil, status.Errorf(codes.Internal, "Ошибка установки метаданных: %v", err)
+		}
+		return &dbauth.LoginDBResponse{
+			Message: "Требуется подтверждение вторым фактором",
+		}, nil
+	}
+
+	dbName, userId, role, err := resolveIdentity(s, token, ctx, clientLogs, authUserId, companyId)
+	if err != nil {
+		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
+		if errLogs != nil {
+			log.Printf("Внутренняя ошибка проверки пользователя: %v", err)
+		}
+		return nil, identityError(err)
+	}
 
 	//Проверяем найдена ли база данных для данного пользователя
 	if dbName == "" {
@@ -78,17 +128,8 @@ func (s *AuthServiceServer) LoginDB(ctx context.Context, req *dbauth.LoginDBRequ
 		return nil, status.Errorf(codes.NotFound, "Ошибка нахождения базы данных: %v", err)
 	}
 
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, status.Errorf(codes.Internal, "Не удалось получить метаданные из контекста auth_serivce")
-	}
-
-	// Создаем метаданные с Database и CompanyId
-	md = metadata.Pairs(
-		"database", dbName,
-		"user-id", userId,
-		"company-id", companyId,
-	)
+	// Создаем метаданные с данными пользователя, auth сервис переносит их в claims JWT токена
+	md := identityHeader(dbName, userId, companyId, role, authUserId)
 
 	// Добавляем метаданные в контекст
 	err = grpc.SendHeader(ctx, md)
@@ -108,26 +149,78 @@ func (s *AuthServiceServer) LoginDB(ctx context.Context, req *dbauth.LoginDBRequ
 	return response, nil // Возвращаем успешный ответ.
 }
 
-// checkUser проверяет пользователя в базе данных авторизации и возвращает имя базы данных компании.
-func checkUser(server *AuthServiceServer, req *dbauth.LoginDBRequest, token string,
-	ctx context.Context, clientLogs logs.LogsServiceClient) (dbName string, userId string, companyID string, err error) {
+// identityHeader метаданные с данными пользователя, которые auth сервис переносит в claims JWT токена.
+// ID пользователя авторизации нужен auth сервису для настройки второго фактора.
+func identityHeader(dbName, userId, companyId, role, authUserId string) metadata.MD {
+	return metadata.Pairs(
+		"database", dbName,
+		"user-id", userId,
+		"company-id", companyId,
+		"role", role,
+		"auth-user-id", authUserId,
+	)
+}
+
+// checkUser проверяет логин и пароль пользователя в базе данных авторизации, считая неудачные попытки
+// через limiter, и возвращает ID пользователя авторизации и ID его компании.
+func checkUser(server *AuthServiceServer, req *dbauth.LoginDBRequest,
+	ctx context.Context, clientLogs logs.LogsServiceClient, limiter *utils.LoginLimiter) (LoginAttempt, error) {
 	// Приведение данных к нижнему регистру
 	emailLower := strings.ToLower(req.Email)
 	phoneLower := strings.ToLower(req.Phone)
 	password := req.Password // Пароль оставляем без изменений
 
-	// Формируем строку соединения с базой данных авторизации.
-	dsn := utils.DsnString(os.Getenv("DB_AUTH_NAME"))
 	// Получаем соединение с базой данных.
-	db, err := server.connectionsMap.GetDb(dsn)
+	// Получаем соединение с базой данных авторизации.
+	db, err := server.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
 	if err != nil {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, dbName, userId, err.Error())
+		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
 		if errLogs != nil {
 			log.Printf("Ошибка при получении соединения из connectionsMap: %v", err)
 		}
 		log.Printf("Ошибка при получении соединения из connectionsMap")
+		return LoginAttempt{}, err
+	}
+
+	// Проверяем пароль до обращения к кэшу: кэш хранит только данные о базе компании
+	// и не должен позволять войти без проверки пароля.
+	attempt, err := AuthenticateUserLimited(ctx, db, limiter, emailLower, phoneLower, password,
+		utils.ClientIpFromContext(ctx))
+	if err != nil {
+		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
+		if errLogs != nil {
+			log.Printf("Ошибка проверки пользователя: %v", err)
+		}
+		return attempt, err
+	}
+
+	return attempt, nil
+}
+
+// identityError приводит ошибку resolveIdentity к ошибке gRPC: вход в приостановленную
+// или удалённую компанию отклоняется с понятным сообщением, остальные ошибки внутренние.
+func identityError(err error) error {
+	if utils.IsTenantError(err) {
+		return utils.TenantStatusError(err)
+	}
+	return status.Errorf(codes.Internal, "%v", err)
+}
+
+// resolveIdentity возвращает имя базы данных компании, ID пользователя в ней и его роль
+// по ID пользователя авторизации. Вызывается только после проверки всех факторов входа.
+func resolveIdentity(server *AuthServiceServer, token string, ctx context.Context, clientLogs logs.LogsServiceClient,
+	authUserId string, companyID string) (dbName string, userId string, role string, err error) {
+
+	// Компания и её база данных берутся из реестра. Статус проверяется при каждом входе,
+	// в том числе когда данные пользователя уже есть в кэше Redis
+	tenant, err := server.connectionsMap.Tenants().ByCompanyId(ctx, companyID)
+	if err != nil {
 		return "", "", "", err
 	}
+	if err := tenant.CheckActive(); err != nil {
+		return "", "", "", err
+	}
+	dbName = tenant.DbName
 
 	// Устанавливаем соединение с gRPC сервером Redis
 	client, err, connRedis := utils.RedisServiceConnector(token)
@@ -145,9 +238,9 @@ func checkUser(server *AuthServiceServer, req *dbauth.LoginDBRequest, token stri
 		}
 	}(connRedis)
 
-	// Формируем запрос для Redis с использованием email в нижнем регистре
+	// Формируем запрос для Redis по ID пользователя авторизации
 	req1 := &redis.GetRedisRequest{
-		Key: emailLower + "Login" + userId,
+		Key: "LoginIdentity" + authUserId,
 	}
 
 	resRedis, err := client.Get(ctx, req1)
@@ -164,6 +257,7 @@ func checkUser(server *AuthServiceServer, req *dbauth.LoginDBRequest, token stri
 		Database  string
 		UserId    string
 		CompanyId string
+		Role      string
 	}
 
 	if resRedis.Status == http.StatusOK {
@@ -175,59 +269,12 @@ func checkUser(server *AuthServiceServer, req *dbauth.LoginDBRequest, token stri
 			}
 			return "", "", "", err
 		}
-		return convertedRedis.Database, convertedRedis.UserId, convertedRedis.CompanyId, nil
-	}
-
-	// SQL-запрос для проверки пользователя с данными в нижнем регистре
-	query := `
-        SELECT id, company_id
-        FROM authusers 
-        WHERE (email = $1 OR phone = $2) 
-        AND password = $3
-    `
-
-	companyID = ""
-	authUserId := ""
-
-	// Используем email и phone в нижнем регистре
-	err = db.QueryRow(query, emailLower, phoneLower, password).Scan(&authUserId, &companyID)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			errLogs := utils.SaveLogsError(ctx, clientLogs, dbName, userId, err.Error())
-			if errLogs != nil {
-				log.Printf("Пользователь не найден: %v", err)
-			}
-			return "", "", "", fmt.Errorf("Пользователь не найден")
-		}
-		errLogs := utils.SaveLogsError(ctx, clientLogs, dbName, userId, err.Error())
-		if errLogs != nil {
-			log.Printf("Ошибка при выполнении запроса: %v", err)
-		}
-		return "", "", "", err
-	}
-
-	// SQL-запрос для получения имени базы данных компании
-	queryCompanies := `
-        SELECT dbName
-        FROM companies 
-        WHERE id = $1 
-    `
-
-	err = db.QueryRow(queryCompanies, companyID).Scan(&dbName)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			errLogs := utils.SaveLogsError(ctx, clientLogs, dbName, userId, err.Error())
-			if errLogs != nil {
-				log.Printf("Запись о базе данных не найдена: %v", err)
-			}
-			return "", "", "", fmt.Errorf("запись о базе данных не найдена")
-		}
-		return "", "", "", err
+		return dbName, convertedRedis.UserId, convertedRedis.Role, nil
 	}
 
 	// Работа с базой данных компании
-	dsnC := utils.DsnString(dbName)
-	dbConnCompany, err := server.connectionsMap.GetDb(dsnC)
+	dbConnCompany, release, err := server.connectionsMap.Acquire(dbName)
+	defer release()
 	if dbConnCompany == nil {
 		log.Println("Ошибка: соединение с базой данных компании не инициализировано")
 		errLogs := utils.SaveLogsError(ctx, clientLogs, dbName, userId, "Ошибка: соединение с базой данных компании не инициализировано")
@@ -237,8 +284,11 @@ func checkUser(server *AuthServiceServer, req *dbauth.LoginDBRequest, token stri
 		return "", "", "", fmt.Errorf("соединение с базой данных компании не инициализировано")
 	}
 
-	// Получаем userId из таблицы users
-	err = dbConnCompany.QueryRow("SELECT id FROM users WHERE authId = $1", authUserId).Scan(&userId)
+	// Получаем userId и название роли пользователя в компании
+	err = dbConnCompany.QueryRow(
+		"SELECT u.id, r.roles FROM users u JOIN rights r ON r.id = u.rightsId WHERE u.authId = $1",
+		authUserId,
+	).Scan(&userId, &role)
 	if err != nil {
 		errLogs := utils.SaveLogsError(ctx, clientLogs, dbName, userId, err.Error())
 		if errLogs != nil {
@@ -252,6 +302,7 @@ func checkUser(server *AuthServiceServer, req *dbauth.LoginDBRequest, token stri
 		Database:  dbName,
 		UserId:    userId,
 		CompanyId: companyID,
+		Role:      role,
 	}
 
 	toJsonRedis, err := utils.ConvertStructToJSON(toJsonType)
@@ -263,7 +314,7 @@ func checkUser(server *AuthServiceServer, req *dbauth.LoginDBRequest, token stri
 	}
 
 	saveRequest := &redis.SaveRedisRequest{
-		Key:        emailLower + "Login" + userId, // Используем email в нижнем регистре
+		Key:        "LoginIdentity" + authUserId, // Ключ по ID пользователя авторизации
 		Value:      toJsonRedis,
 		Expiration: int64((time.Minute * 10).Seconds()),
 	}
@@ -277,7 +328,7 @@ func checkUser(server *AuthServiceServer, req *dbauth.LoginDBRequest, token stri
 		fmt.Printf("Ошибка выполнения gRPC вызова Save")
 	}
 
-	return dbName, userId, companyID, nil
+	return dbName, userId, role, nil
 }
 
 func (s *AuthServiceServer) RegisterCompany(ctx context.Context, req *dbauth.RegisterCompanyRequest) (*dbauth.RegisterCompanyResponse, error) {
@@ -310,8 +361,8 @@ func (s *AuthServiceServer) RegisterCompany(ctx context.Context, req *dbauth.Reg
 		}(conn)
 	}
 
-	// Вызываем функцию registerCompany для создания базы данных и регистрации компании.
-	dbName, companyId, userId, statusRegister, err := registerCompany(s, req, token)
+	// Регистрируем компанию, база данных компании создаётся в фоне
+	dbName, companyId, provisioningId, activationToken, statusRegister, err := registerCompany(s, req, token)
 	if err != nil {
 		// Если произошла ошибка, формируем ответ с сообщением об ошибке.
 		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
@@ -321,17 +372,19 @@ func (s *AuthServiceServer) RegisterCompany(ctx context.Context, req *dbauth.Reg
 		return nil, status.Errorf(statusRegister, fmt.Sprintf("%v", err))
 	}
 
-	// Создаем метаданные с Database и CompanyId
+	// База данных компании создаётся в фоне, регистрация не ждёт её готовности
+	s.provisioner.Enqueue(companyId)
+
+	// Вход будет доступен только после подтверждения email, поэтому вместо данных пользователя
+	// auth сервис получает токен активации и отправляет ссылку на указанный при регистрации адрес
 	md := metadata.Pairs(
-		"database", dbName,
-		"user-id", userId,
-		"company-id", companyId,
+		"activation-token", activationToken,
 	)
 
 	// Добавляем метаданные в контекст
 	err = grpc.SendHeader(ctx, md)
 	if err != nil {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, dbName, userId, err.Error())
+		errLogs := utils.SaveLogsError(ctx, clientLogs, dbName, "", err.Error())
 		if errLogs != nil {
 			log.Printf("%v", err)
 		}
@@ -340,42 +393,39 @@ func (s *AuthServiceServer) RegisterCompany(ctx context.Context, req *dbauth.Reg
 
 	// Формируем успешный ответ, если регистрация прошла успешно.
 	response := &dbauth.RegisterCompanyResponse{
-		Message: "Регистрация успешна", // Сообщение об успешной регистрации.
+		Message:            "Регистрация принята, подтвердите email по ссылке из письма", // Сообщение об успешной регистрации.
+		ProvisioningId:     provisioningId,
+		ProvisioningStatus: utils.ProvisioningPending,
 	}
 
 	return response, nil // Возвращаем успешный ответ.
 }
 
-// registerCompany регистрирует новую компанию в базе данных и создает
-// соответствующего пользователя в системе авторизации.
+// registerCompany регистрирует новую компанию и её первого пользователя в базе данных авторизации.
 //
 // Параметры:
-// - server: Указатель на экземпляр MapConnectionsDB, который используется для управления соединениями с базами данных.
+// - server: Указатель на экземпляр AuthServiceServer с пулом соединений.
 // - req: Указатель на структуру dbauth.RegisterCompanyRequest, содержащую данные о компании и пользователе (имя компании, адрес, email, телефон и пароль).
 //
 // Возвращает:
-// - nameDB: Имя созданной базы данных для компании.
-// - Ошибка, если произошла ошибка при создании базы данных, подключении к ней, выполнении миграций или операциях с базой данных.
-// В противном случае возвращает nil и код состояния http.StatusOK.
+// - nameDB: Имя базы данных, которая будет создана для компании.
+// - companyId и provisioningId: ID компании и идентификатор для опроса состояния создания базы.
+// - activationToken: Токен подтверждения email.
+// - Код и ошибку, если компания уже существует или произошла ошибка при работе с базой данных.
 //
 // Процесс выполнения:
-// 1. Получает имя базы данных авторизации из переменных окружения.
-// 2. Создает новую базу данных для компании с помощью функции createClientDatabase.
-// 3. Подключается к базе данных авторизации и проверяет состояние соединения.
-// 4. Начинает транзакцию для базы данных авторизации.
-// 5. Проверяет, существует ли уже компания с указанным именем и адресом:
-//   - Если существует, возвращает ошибку с кодом http.StatusConflict.
-//   - Если не существует, создает новую запись о компании.
+// 1. Проверяет, существует ли уже компания с указанным именем и адресом:
+//   - Если существует, возвращает ошибку с кодом AlreadyExists.
+//   - Если не существует, создает запись о компании в состоянии pending.
+//
+// 2. Создает нового пользователя в таблице authusers.
+// 3. Фиксирует транзакцию для базы данных авторизации.
+// 4. Выдаёт токен подтверждения email.
 //
-// 6. Создает нового пользователя в таблице authusers и сохраняет его ID.
-// 7. Фиксирует транзакцию для базы данных авторизации.
-// 8. Подключается к только что созданной базе данных компании и начинает новую транзакцию.
-// 9. Создает записи о правах и пользователе в базе данных компании.
-// 10. Фиксирует транзакцию для базы данных компании.
-// 11. Возвращает имя базы данных для компании и nil, если все операции выполнены успешно.
-// registerCompany регистрирует новую компанию и создает пользователя в системе авторизации.
+// Сама база данных компании создаётся из шаблона в фоне (пакет provisioning), поэтому компания
+// и пользователь записываются одной транзакцией и откатывать вручную ничего не требуется.
 func registerCompany(server *AuthServiceServer, req *dbauth.RegisterCompanyRequest, token string) (
-	nameDB string, userId string, companyId string, status codes.Code, err error) {
+	nameDB string, companyId string, provisioningId string, activationToken string, status codes.Code, err error) {
 
 	// Приведение данных из запроса к нижнему регистру
 	nameCompanyLower := strings.ToLower(req.NameCompany)
@@ -388,276 +438,95 @@ func registerCompany(server *AuthServiceServer, req *dbauth.RegisterCompanyReque
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
-	// Проверка базы данных в Redis
 	client, err, connRedis := utils.RedisServiceConnector(token)
 	if err != nil {
-		fmt.Printf("Ошибка подключения к Redis: " + err.Error())
-		return "", "", "", codes.Internal, err
-	} else {
-		defer func(connRedis *grpc.ClientConn) {
-			err := connRedis.Close()
-			if err != nil {
-				log.Printf(err.Error())
-			}
-		}(connRedis)
+		log.Printf("Ошибка подключения к Redis: %v", err)
+		return "", "", "", "", codes.Internal, err
 	}
-
-	// Формируем запрос на регистрацию компании
-	req1 := &redis.GetRedisRequest{
-		Key: nameCompanyLower + "Register", // Используем нижний регистр для ключа
-	}
-
-	// Выполняем gRPC вызов Get
-	resRedis, err := client.Get(ctx, req1)
-
-	type dbRedisType struct {
-		Message   string
-		Database  string
-		CompanyId string
-		UserId    string
-	}
-
-	if resRedis.Status == http.StatusOK {
-		convertedRedis, err := utils.ConvertJSONToStruct[dbRedisType](resRedis.Message)
-		if err != nil {
-			return "", "", "", codes.Internal, err
-		}
-		return convertedRedis.Database, convertedRedis.UserId, convertedRedis.CompanyId, codes.OK, nil
-	} else {
-		authDBName := os.Getenv("DB_AUTH_NAME")
-		newDbName := utils.RandomDBName(25)
-		dsn := utils.DsnString(authDBName)
-
-		dbConn, err := server.connectionsMap.GetDb(dsn)
-		if err != nil {
-			return "", "", "", codes.Internal, err
-		}
-
-		if dbConn == nil {
-			log.Println("Ошибка: соединение с базой данных авторизации не инициализировано")
-			return "", "", "", codes.Internal, fmt.Errorf("соединение с базой данных авторизации не инициализировано")
-		}
-
-		tx, err := dbConn.Begin()
-		if err != nil {
-			return "", "", "", codes.Internal, fmt.Errorf("не удалось начать транзакцию: %v", err)
-		}
-
-		defer func() {
-			if err != nil {
-				_ = tx.Rollback()
-				log.Printf("Транзакция откатана (auth DB) из-за ошибки: %v", err)
-			}
-		}()
-
-		// Проверяем, существует ли компания с именем и адресом в нижнем регистре
-		query := "SELECT id FROM companies WHERE name = $1 AND address = $2"
-		err = tx.QueryRow(query, nameCompanyLower, addressLower).Scan(&companyId)
-		if err != nil {
-			if err == sql.ErrNoRows {
-				// Вставляем новую компанию с данными в нижнем регистре
-				err = tx.QueryRow(
-					"INSERT INTO companies (name, address, dbname) VALUES ($1, $2, $3) RETURNING id",
-					nameCompanyLower, addressLower, newDbName,
-				).Scan(&companyId)
-				if err != nil {
-					return "", "", "", codes.Internal, fmt.Errorf("не удалось создать компанию: %v", err)
-				}
-			} else {
-				return "", "", "", codes.InvalidArgument, fmt.Errorf("ошибка при проверке существования компании: %v", err)
-			}
-		} else {
-			return "", "", "", codes.AlreadyExists, fmt.Errorf("компания с таким именем и адресом уже существует: %s", nameCompanyLower)
-		}
-
-		var authUserId string
-		// Вставляем пользователя с данными в нижнем регистре
-		err = tx.QueryRow(
-			"INSERT INTO authusers (email, phone, password, company_id) VALUES ($1, $2, $3, $4) RETURNING id",
-			emailLower, phoneLower, password, companyId,
-		).Scan(&authUserId)
-		if err != nil {
-			if strings.Contains(err.Error(), "authusers_phone_key") {
-				return "", "", "", codes.AlreadyExists, fmt.Errorf("дубликат номера телефона: %v", err)
-			}
-			if strings.Contains(err.Error(), "authusers_email_key") {
-				return "", "", "", codes.AlreadyExists, fmt.Errorf("дубликат почты: %v", err)
-			}
-			return "", "", "", codes.Internal, fmt.Errorf("не удалось создать пользователя: %v", err)
-		}
-
-		err = tx.Commit()
-		if err != nil {
-			return "", "", "", codes.Internal, fmt.Errorf("не удалось зафиксировать транзакцию auth DB: %v", err)
-		}
-
-		err = createClientDatabase(newDbName, server, ctx)
-		if err != nil {
-			return "", "", "", codes.Internal, err
-		}
-
-		dsnC := utils.DsnString(newDbName)
-		dbConnCompany, err := server.connectionsMap.GetDb(dsnC)
-		if dbConnCompany == nil {
-			log.Println("Ошибка: соединение с базой данных компании не инициализировано")
-			return "", "", "", codes.Internal, fmt.Errorf("соединение с базой данных компании не инициализировано")
-		}
-
-		txc, err := dbConnCompany.Begin()
-		if err != nil {
-			return "", "", "", codes.Internal, fmt.Errorf("не удалось начать транзакцию для компании: %v", err)
-		}
-
-		defer func() {
-			if err != nil {
-				_ = txc.Rollback()
-				log.Printf("Транзакция откатана (company DB) из-за ошибки: %v", err)
-				err = rollbackAuthDB(dbConn, companyId, authUserId, ctx)
-				log.Printf("Не удалось откатить транзакцию: %v", err)
-			}
-		}()
-
-		role := os.Getenv("FIRST_ROLE")
-		var roleID int
-		err = txc.QueryRow("INSERT INTO rights (roles) VALUES ($1) RETURNING id", role).Scan(&roleID)
-		if err != nil {
-			return "", "", "", codes.Unimplemented, fmt.Errorf("не удалось добавить название прав: %v", err)
-		}
-
-		var newUserId string
-		err = txc.QueryRow(
-			"INSERT INTO users (rightsId, authId) VALUES ($1, $2) RETURNING id",
-			roleID, authUserId,
-		).Scan(&newUserId)
-		if err != nil {
-			return "", "", "", codes.Unimplemented, fmt.Errorf("не удалось добавить пользователя: %v", err)
-		}
-
-		_, err = txc.Exec(
-			"INSERT INTO availableactions (roleId, createTasks, createChats, addWorkers) VALUES ($1, $2, $3, $4)",
-			roleID, true, true, true,
-		)
-		if err != nil {
-			return "", "", "", codes.Unimplemented, fmt.Errorf("не удалось добавить доступные действия для роли: %v", err)
-		}
-
-		err = txc.Commit()
-		if err != nil {
-			return "", "", "", codes.Unimplemented, fmt.Errorf("не удалось зафиксировать транзакцию компании: %v", err)
-		}
-
-		toJsonType := &dbRedisType{
-			Message:   nameCompanyLower, // Используем нижний регистр
-			Database:  newDbName,
-			UserId:    newUserId,
-			CompanyId: companyId,
-		}
-
-		toJsonRedis, err := utils.ConvertStructToJSON(toJsonType)
-		if err != nil {
-			fmt.Printf(err.Error())
-		}
-
-		saveRequest := &redis.SaveRedisRequest{
-			Key:        nameCompanyLower + "Register" + newUserId, // Используем нижний регистр для ключа
-			Value:      toJsonRedis,
-			Expiration: int64((time.Minute * 10).Seconds()),
-		}
-
-		_, err = client.Save(ctx, saveRequest)
+	defer func(connRedis *grpc.ClientConn) {
+		err := connRedis.Close()
 		if err != nil {
-			fmt.Printf(err.Error())
+			log.Printf(err.Error())
 		}
+	}(connRedis)
 
-		return newDbName, newUserId, companyId, codes.OK, nil
+	newDbName := utils.RandomDBName(utils.TenantDbNameLength)
+	provisioningId, err = provisioning.NewProvisioningId()
+	if err != nil {
+		return "", "", "", "", codes.Internal, err
 	}
-}
 
-// createClientDatabase создает новую базу данных с рандомным именем для компании
-// и выполняет необходимые миграции для таблицы users.
-//
-// Параметры:
-// - server: Указатель на экземпляр MapConnectionsDB, который используется для управления соединениями с базами данных.
-//
-// Возвращает:
-// - nameDB: Имя созданной базы данных.
-// - Ошибка, если произошла ошибка при создании базы данных, подключении к ней или выполнении миграций.
-// В противном случае возвращает nil.
-//
-// Процесс выполнения:
-// 1. Генерирует рандомное имя для базы данных с помощью функции utils.RandomDBName.
-// 2. Проверяет и создаёт базу данных с помощью функции createInsideDB.
-// 3. Подключается к только что созданной базе данных с помощью функции GetDb.
-// 4. Выполняет миграцию для таблицы users, используя указанный путь к миграциям (MIGRATION_COMPANYDB_PATH).
-// 5. Возвращает имя созданной базы данных и nil, если все операции выполнены успешно.
-func createClientDatabase(dbName string, server *AuthServiceServer, _ context.Context) (err error) {
-
-	// Функция проверки и создания базы данных
-	err = utils.CreateInsideDB(dbName)
+	dbConn, err := server.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
 	if err != nil {
-		return fmt.Errorf("Ошибка при создании базы данных: %w", err)
+		return "", "", "", "", codes.Internal, err
 	}
 
-	// Теперь подключаемся к только что созданной базе данных
-	newDSN := utils.DsnString(dbName) // Создаем новое соединение к этой базе
-	newDB, err := server.connectionsMap.GetDb(newDSN)
-
-	//Проверка соединения
+	tx, err := dbConn.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("Ошибка подключения к новой базе данных: %w", err)
+		return "", "", "", "", codes.Internal, fmt.Errorf("не удалось начать транзакцию: %v", err)
 	}
-	/*defer func(newDB *sql.DB) {
-		err := newDB.Close()
+	defer func() {
 		if err != nil {
-			log.Fatal("Некорректное закрытие базы данных")
+			_ = tx.Rollback()
+			log.Printf("Транзакция откатана (auth DB) из-за ошибки: %v", err)
 		}
-	}(newDB)*/
+	}()
 
-	// Миграция для таблицы users
-	migratePath := os.Getenv("MIGRATION_COMPANYDB_PATH")
-	err = migrations.Migration(newDB, migratePath, dbName)
-	if err != nil {
-		return fmt.Errorf("Ошибка при миграции базы данных: %w", err)
+	// Проверяем, существует ли компания с именем и адресом в нижнем регистре
+	query := "SELECT id FROM companies WHERE name = $1 AND address = $2"
+	err = tx.QueryRowContext(ctx, query, nameCompanyLower, addressLower).Scan(&companyId)
+	if err == nil {
+		// Повторная регистрация не выдаёт данные существующей компании: вход в неё
+		// возможен только по паролю после подтверждения email
+		err = fmt.Errorf("компания с таким именем и адресом уже существует: %s", nameCompanyLower)
+		return "", "", "", "", codes.AlreadyExists, err
+	}
+	if err != sql.ErrNoRows {
+		return "", "", "", "", codes.InvalidArgument, fmt.Errorf("ошибка при проверке существования компании: %v", err)
 	}
 
-	return nil
-}
-
-// rollbackAuthDB откатывает изменения в базе данных авторизации, удаляя пользователя и компанию.
-func rollbackAuthDB(dbConn *sql.DB, companyId, authUserId string, _ context.Context) error {
-	// Начинаем откатную транзакцию.
-	tx, err := dbConn.Begin()
+	// Вставляем новую компанию с данными в нижнем регистре, база будет создана в фоне
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO companies (name, address, dbname, provisioning_status, provisioning_id) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		nameCompanyLower, addressLower, newDbName, utils.ProvisioningPending, provisioningId,
+	).Scan(&companyId)
 	if err != nil {
-		log.Printf("Ошибка при начале откатной транзакции: %v", err) // Логируем ошибку, если не удалось начать транзакцию.
-		return err                                                   // Завершаем выполнение функции.
+		return "", "", "", "", codes.Internal, fmt.Errorf("не удалось создать компанию: %v", err)
 	}
 
-	// Удаляем пользователя из таблицы authusers по его ID.
-	_, err = tx.Exec("DELETE FROM authusers WHERE id = $1", authUserId)
+	// Сохраняем только хэш пароля
+	passwordHash, err := utils.HashPassword(password)
 	if err != nil {
-		err := tx.Rollback()
-		if err != nil {
-			return err
-		} // Откатываем транзакцию в случае ошибки.
-		log.Printf("Ошибка при удалении пользователя: %v", err) // Логируем ошибку.
-		return err                                              // Завершаем выполнение функции.
+		return "", "", "", "", codes.Internal, fmt.Errorf("не удалось хэшировать пароль: %v", err)
 	}
 
-	// Удаляем компанию из таблицы companies по её ID.
-	_, err = tx.Exec("DELETE FROM companies WHERE id = $1", companyId)
+	var authUserId string
+	// Вставляем пользователя с данными в нижнем регистре
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO authusers (email, phone, password, company_id, status) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		emailLower, phoneLower, passwordHash, companyId, utils.AuthStatusUnverified,
+	).Scan(&authUserId)
 	if err != nil {
-		err := tx.Rollback()
-		if err != nil {
-			return err
-		} // Откатываем транзакцию в случае ошибки.
-		log.Printf("Ошибка при удалении компании: %v", err) // Логируем ошибку.
-		return err                                          // Завершаем выполнение функции.
+		if strings.Contains(err.Error(), "authusers_phone_key") {
+			return "", "", "", "", codes.AlreadyExists, fmt.Errorf("дубликат номера телефона: %v", err)
+		}
+		if strings.Contains(err.Error(), "authusers_email_key") {
+			return "", "", "", "", codes.AlreadyExists, fmt.Errorf("дубликат почты: %v", err)
+		}
+		return "", "", "", "", codes.Internal, fmt.Errorf("не удалось создать пользователя: %v", err)
 	}
 
-	// Фиксируем откат транзакции.
 	err = tx.Commit()
 	if err != nil {
-		log.Printf("Ошибка при фиксации отката: %v", err) // Логируем ошибку, если фиксация не удалась.
+		return "", "", "", "", codes.Internal, fmt.Errorf("не удалось зафиксировать транзакцию auth DB: %v", err)
 	}
-	return nil
+
+	// Выдаём токен подтверждения email, вход будет доступен после перехода по ссылке
+	activationToken, err = utils.IssueActivationToken(ctx, client, authUserId,
+		utils.ActivationPurposeVerify, utils.EmailVerificationTTL)
+	if err != nil {
+		return "", "", "", "", codes.Internal, err
+	}
+
+	return newDbName, companyId, provisioningId, activationToken, codes.OK, nil
 }
@@ -0,0 +1,163 @@
+package dbauthservice
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	"crmSystem/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ErrEmailOtpDisabled возвращается, если компания пользователя не разрешила вход по коду из письма.
+var ErrEmailOtpDisabled = errors.New("вход по коду из письма не разрешён для компании пользователя")
+
+// emailOtpUserQuery выбирает пользователя вместе с политикой входа по коду его компании.
+const emailOtpUserQuery = `
+        SELECT a.id, a.status, c.allow_email_otp
+        FROM authusers a JOIN companies c ON c.id = a.company_id
+        WHERE `
+
+// FindEmailOtpUser ищет по email пользователя, которому разрешён вход по коду из письма.
+//
+// Код отправляется только на подтверждённый email, поэтому неактивированная учётная запись
+// войти по коду не может: для неё возвращается ErrAccountNotActivated.
+func FindEmailOtpUser(ctx context.Context, db *sql.DB, email string) (string, error) {
+	return emailOtpUser(ctx, db, "a.email = $1", strings.ToLower(strings.TrimSpace(email)))
+}
+
+// CheckEmailOtpAllowed повторно проверяет, что пользователю authUserId разрешён вход по коду:
+// политика компании могла измениться, пока код ждал в почте.
+func CheckEmailOtpAllowed(ctx context.Context, db *sql.DB, authUserId string) error {
+	_, err := emailOtpUser(ctx, db, "a.id = $1", authUserId)
+	return err
+}
+
+func emailOtpUser(ctx context.Context, db *sql.DB, condition string, value string) (string, error) {
+	var authUserId, userStatus string
+	var allowed bool
+	err := db.QueryRowContext(ctx, emailOtpUserQuery+condition, value).Scan(&authUserId, &userStatus, &allowed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrAuthUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка поиска пользователя: %w", err)
+	}
+	if !allowed {
+		return "", ErrEmailOtpDisabled
+	}
+	if userStatus != utils.AuthStatusVerified {
+		return "", ErrAccountNotActivated
+	}
+	return authUserId, nil
+}
+
+// SetCompanyEmailOtp разрешает или запрещает пользователям компании вход по коду из письма.
+func SetCompanyEmailOtp(ctx context.Context, db *sql.DB, companyId string, enabled bool) error {
+	result, err := db.ExecContext(ctx, "UPDATE companies SET allow_email_otp = $1 WHERE id = $2", enabled, companyId)
+	if err != nil {
+		return fmt.Errorf("ошибка изменения политики входа по коду: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка изменения политики входа по коду: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("компания %s не найдена", companyId)
+	}
+	return nil
+}
+
+// FindEmailOtpUser возвращает ID пользователя, которому можно отправить код входа.
+// Метод доступен только внутренним сервисам: ответ раскрывает существование учётной записи.
+func (s *AuthServiceServer) FindEmailOtpUser(ctx context.Context, req *dbauth.FindEmailOtpUserRequest) (*dbauth.FindEmailOtpUserResponse, error) {
+	db, err := s.emailOtpDb(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	authUserId, err := FindEmailOtpUser(ctx, db, req.Email)
+	if err != nil {
+		return nil, emailOtpError(err)
+	}
+
+	return &dbauth.FindEmailOtpUserResponse{
+		Message: "Пользователь найден",
+		UserId:  authUserId,
+	}, nil
+}
+
+// LoginEmailOtp завершает вход по коду из письма и передаёт данные пользователя в заголовках ответа
+// так же, как LoginDB. Код проверяет auth сервис, здесь повторно проверяется политика компании.
+//
+// Код из письма заменяет пароль, но не второй фактор: если пользователь подключил TOTP
+// или компания его требует, вместо данных пользователя передаётся заголовок mfa-required.
+func (s *AuthServiceServer) LoginEmailOtp(ctx context.Context, req *dbauth.LoginEmailOtpRequest) (*dbauth.LoginEmailOtpResponse, error) {
+	db, err := s.emailOtpDb(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.UserId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "не указан пользователь")
+	}
+
+	if err := CheckEmailOtpAllowed(ctx, db, req.UserId); err != nil {
+		return nil, emailOtpError(err)
+	}
+
+	mfa, err := s.mfaRequirement(ctx, req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	if mfa != "" {
+		if err := grpc.SendHeader(ctx, metadata.Pairs("mfa-required", mfa, "auth-user-id", req.UserId)); err != nil {
+			return nil, status.Errorf(codes.Internal, "Ошибка установки метаданных: %v", err)
+		}
+		return &dbauth.LoginEmailOtpResponse{
+			Message: "Требуется подтверждение вторым фактором",
+		}, nil
+	}
+
+	if err := s.sendLoginIdentity(ctx, db, req.UserId); err != nil {
+		return nil, err
+	}
+
+	return &dbauth.LoginEmailOtpResponse{
+		Message: "Пользователь найден",
+	}, nil
+}
+
+// emailOtpDb проверяет, что метод входа по коду вызван внутренним сервисом, и возвращает соединение с базой авторизации.
+func (s *AuthServiceServer) emailOtpDb(ctx context.Context) (*sql.DB, error) {
+	if err := utils.RequireInternalCaller(ctx); err != nil {
+		return nil, err
+	}
+
+	db, err := s.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
+	if err != nil {
+		log.Printf("Ошибка при получении соединения из connectionsMap: %v", err)
+		return nil, status.Errorf(codes.Internal, "ошибка подключения к базе данных авторизации")
+	}
+	return db, nil
+}
+
+// emailOtpError приводит ошибку входа по коду к ошибке gRPC.
+func emailOtpError(err error) error {
+	switch {
+	case errors.Is(err, ErrAuthUserNotFound):
+		return status.Errorf(codes.NotFound, "%v", err)
+	case errors.Is(err, ErrEmailOtpDisabled), errors.Is(err, ErrAccountNotActivated):
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	default:
+		log.Printf("Ошибка входа по коду из письма: %v", err)
+		return status.Errorf(codes.Internal, "ошибка входа по коду из письма")
+	}
+}
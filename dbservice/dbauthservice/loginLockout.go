@@ -0,0 +1,108 @@
+package dbauthservice
+
+import (
+	"context"
+	"crmSystem/proto/logs"
+	"crmSystem/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// loginLockedError возвращает ошибку заблокированного входа. Оставшееся время блокировки
+// передаётся в трейлере "retry-after" (в секундах), auth сервис переносит его в заголовок Retry-After.
+func loginLockedError(ctx context.Context, retryAfter time.Duration) error {
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	if err := grpc.SetTrailer(ctx, metadata.Pairs("retry-after", strconv.FormatInt(seconds, 10))); err != nil {
+		log.Printf("Ошибка установки трейлера retry-after: %v", err)
+	}
+	return status.Errorf(codes.ResourceExhausted,
+		"Слишком много неудачных попыток входа, повторите попытку через %d с", seconds)
+}
+
+// ErrLoginLocked возвращается, если вход заблокирован после неудачных попыток.
+var ErrLoginLocked = errors.New("вход заблокирован после неудачных попыток")
+
+// LoginAttempt результат проверки входа AuthenticateUserLimited.
+type LoginAttempt struct {
+	AuthUserId string               // ID пользователя в таблице authusers
+	CompanyId  string               // ID компании пользователя
+	RetryAfter time.Duration        // Оставшееся время блокировки при ErrLoginLocked
+	Lockouts   []utils.LoginLockout // Блокировки, установленные этой попыткой
+}
+
+// AuthenticateUserLimited проверяет логин и пароль как AuthenticateUser, но считает неудачные попытки
+// через limiter по учётной записи и IP адресу ip. Учётная запись определяется до проверки пароля,
+// поэтому попытки считаются по ID найденного пользователя, какими бы данными входа он ни был найден.
+// При блокировке возвращается ErrLoginLocked с оставшимся временем. Верный пароль сбрасывает счётчик.
+func AuthenticateUserLimited(ctx context.Context, db *sql.DB, limiter *utils.LoginLimiter,
+	email, phone, password, ip string) (LoginAttempt, error) {
+
+	user, err := FindLoginUser(ctx, db, email, phone)
+	if err != nil {
+		return LoginAttempt{}, err
+	}
+	var authUserId string
+	if user != nil {
+		authUserId = user.Id
+	}
+	account := utils.LoginAccount(authUserId, email, phone)
+
+	// Вход в заблокированную учётную запись или с заблокированного IP отклоняется до проверки пароля
+	retryAfter, err := limiter.RetryAfter(ctx, account, ip)
+	if err != nil {
+		return LoginAttempt{}, err
+	}
+	if retryAfter > 0 {
+		return LoginAttempt{RetryAfter: retryAfter}, ErrLoginLocked
+	}
+
+	err = CheckLoginPassword(ctx, db, user, password)
+	if errors.Is(err, ErrInvalidCredentials) {
+		lockouts, errLimit := limiter.RegisterFailure(ctx, account, ip)
+		if errLimit != nil {
+			// Ошибка учёта не должна менять ответ на неверный пароль
+			log.Printf("Ошибка учёта неудачной попытки входа: %v", errLimit)
+		}
+		attempt := LoginAttempt{Lockouts: lockouts}
+		for _, lockout := range lockouts {
+			if lockout.Duration > attempt.RetryAfter {
+				attempt.RetryAfter = lockout.Duration
+			}
+		}
+		if attempt.RetryAfter > 0 {
+			return attempt, ErrLoginLocked
+		}
+		return attempt, err
+	}
+	if err != nil {
+		return LoginAttempt{}, err
+	}
+
+	// Пароль верный: счётчик неудачных попыток учётной записи сбрасывается
+	if err := limiter.Reset(ctx, account); err != nil {
+		log.Printf("Ошибка сброса счётчика попыток входа: %v", err)
+	}
+	return LoginAttempt{AuthUserId: user.Id, CompanyId: user.CompanyId}, nil
+}
+
+// logLoginLockouts записывает в логи каждую новую блокировку входа.
+func logLoginLockouts(ctx context.Context, clientLogs logs.LogsServiceClient, lockouts []utils.LoginLockout) {
+	for _, lockout := range lockouts {
+		message := fmt.Sprintf("Вход заблокирован на %s: %s %s, неудачных попыток %d",
+			lockout.Duration, lockout.Scope, lockout.Subject, lockout.Failures)
+		log.Print(message)
+		if errLogs := utils.SaveLogsWarning(ctx, clientLogs, "", "", message); errLogs != nil {
+			log.Printf("Ошибка сохранения лога блокировки входа: %v", errLogs)
+		}
+	}
+}
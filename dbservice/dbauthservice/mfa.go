@@ -0,0 +1,209 @@
+package dbauthservice
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	"crmSystem/proto/logs"
+	"crmSystem/utils"
+	"database/sql"
+	"errors"
+	"log"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// BeginTotpEnrollment начинает подключение TOTP и возвращает секрет и URI для QR-кода.
+// Метод доступен только auth сервису, который проверяет токен пользователя.
+func (s *AuthServiceServer) BeginTotpEnrollment(ctx context.Context, req *dbauth.BeginTotpEnrollmentRequest) (*dbauth.BeginTotpEnrollmentResponse, error) {
+	db, err := s.mfaDb(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, uri, err := BeginTotpEnrollment(ctx, db, req.UserId)
+	if err != nil {
+		return nil, mfaError(err)
+	}
+
+	return &dbauth.BeginTotpEnrollmentResponse{
+		Secret:          secret,
+		ProvisioningUri: uri,
+	}, nil
+}
+
+// ConfirmTotpEnrollment включает TOTP по первому коду из приложения и возвращает коды восстановления.
+// В заголовках ответа передаются данные пользователя, чтобы auth сервис мог завершить вход,
+// если подключение TOTP было обязательным шагом входа.
+func (s *AuthServiceServer) ConfirmTotpEnrollment(ctx context.Context, req *dbauth.MfaCodeRequest) (*dbauth.ConfirmTotpEnrollmentResponse, error) {
+	db, err := s.mfaDb(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := ConfirmTotpEnrollment(ctx, db, req.UserId, req.Code)
+	if err != nil {
+		return nil, mfaError(err)
+	}
+
+	if err := s.sendLoginIdentity(ctx, db, req.UserId); err != nil {
+		return nil, err
+	}
+
+	return &dbauth.ConfirmTotpEnrollmentResponse{
+		Message:       "Двухфакторная аутентификация подключена",
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+// VerifyMfa проверяет второй фактор при входе и передаёт в заголовках данные пользователя,
+// как LoginDB при входе без второго фактора.
+func (s *AuthServiceServer) VerifyMfa(ctx context.Context, req *dbauth.MfaCodeRequest) (*dbauth.VerifyMfaResponse, error) {
+	db, err := s.mfaDb(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verifySecondFactor(ctx, db, req.UserId, req.Code); err != nil {
+		return nil, err
+	}
+
+	if err := s.sendLoginIdentity(ctx, db, req.UserId); err != nil {
+		return nil, err
+	}
+
+	return &dbauth.VerifyMfaResponse{
+		Message: "Пользователь найден",
+	}, nil
+}
+
+// DisableTotp отключает TOTP после проверки текущего кода или кода восстановления.
+func (s *AuthServiceServer) DisableTotp(ctx context.Context, req *dbauth.MfaCodeRequest) (*dbauth.DisableTotpResponse, error) {
+	db, err := s.mfaDb(ctx, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verifySecondFactor(ctx, db, req.UserId, req.Code); err != nil {
+		return nil, err
+	}
+	if err := DisableTotp(ctx, db, req.UserId); err != nil {
+		return nil, mfaError(err)
+	}
+
+	return &dbauth.DisableTotpResponse{
+		Message: "Двухфакторная аутентификация отключена",
+	}, nil
+}
+
+// verifySecondFactor проверяет код второго фактора с ограничением неверных попыток.
+// Счётчики и блокировки хранятся в redis, как и для входа по паролю.
+func (s *AuthServiceServer) verifySecondFactor(ctx context.Context, db *sql.DB, authUserId string, code string) error {
+	token, err := utils.ExtractTokenFromContext(ctx)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "%v", err)
+	}
+
+	clientRedis, err, connRedis := utils.RedisServiceConnector(token)
+	if err != nil {
+		log.Printf("Ошибка подключения к Redis: %v", err)
+		return status.Errorf(codes.Internal, "Не удалось создать соединение с сервером Redis")
+	}
+	defer func(connRedis *grpc.ClientConn) {
+		if err := connRedis.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения c Redis: %v", err)
+		}
+	}(connRedis)
+
+	retryAfter, err := VerifySecondFactorLimited(ctx, db, utils.NewLoginLimiter(clientRedis), authUserId, code)
+	if errors.Is(err, ErrMfaLocked) {
+		return loginLockedError(ctx, retryAfter)
+	}
+	if err != nil {
+		return mfaError(err)
+	}
+	return nil
+}
+
+// mfaRequirement возвращает требование второго фактора для входа пользователя
+// (MfaMethodTotp, MfaMethodEnroll) или пустую строку.
+func (s *AuthServiceServer) mfaRequirement(ctx context.Context, authUserId string) (string, error) {
+	db, err := s.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
+	if err != nil {
+		return "", err
+	}
+
+	state, err := GetMfaState(ctx, db, authUserId)
+	if err != nil {
+		return "", err
+	}
+	return state.Method(), nil
+}
+
+// mfaDb проверяет, что метод 2FA вызван внутренним сервисом, и возвращает соединение с базой авторизации.
+func (s *AuthServiceServer) mfaDb(ctx context.Context, authUserId string) (*sql.DB, error) {
+	if err := utils.RequireInternalCaller(ctx); err != nil {
+		return nil, err
+	}
+	if authUserId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "не указан пользователь")
+	}
+
+	db, err := s.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
+	if err != nil {
+		log.Printf("Ошибка при получении соединения из connectionsMap: %v", err)
+		return nil, status.Errorf(codes.Internal, "ошибка подключения к базе данных авторизации")
+	}
+	return db, nil
+}
+
+// sendLoginIdentity передаёт в заголовках ответа данные пользователя после проверки второго фактора.
+func (s *AuthServiceServer) sendLoginIdentity(ctx context.Context, db *sql.DB, authUserId string) error {
+	token, err := utils.ExtractTokenFromContext(ctx)
+	if err != nil {
+		return status.Errorf(codes.Unauthenticated, "%v", err)
+	}
+
+	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу: %v", err)
+		return status.Errorf(codes.Internal, "Не удалось создать соединение с сервером Logs")
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}(conn)
+
+	state, err := GetMfaState(ctx, db, authUserId)
+	if err != nil {
+		return mfaError(err)
+	}
+
+	dbName, userId, role, err := resolveIdentity(s, token, ctx, clientLogs, authUserId, state.CompanyId)
+	if err != nil {
+		return identityError(err)
+	}
+
+	if err := grpc.SendHeader(ctx, identityHeader(dbName, userId, state.CompanyId, role, authUserId)); err != nil {
+		return status.Errorf(codes.Internal, "Ошибка установки метаданных: %v", err)
+	}
+	return nil
+}
+
+// mfaError приводит ошибку двухфакторной аутентификации к ошибке gRPC.
+func mfaError(err error) error {
+	switch {
+	case errors.Is(err, ErrInvalidMfaCode):
+		return status.Errorf(codes.Unauthenticated, "%v", err)
+	case errors.Is(err, ErrTotpAlreadyEnabled), errors.Is(err, ErrTotpNotEnrolled), errors.Is(err, ErrMfaRequiredByCompany):
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	case errors.Is(err, ErrAuthUserNotFound):
+		return status.Errorf(codes.NotFound, "%v", err)
+	default:
+		log.Printf("Ошибка двухфакторной аутентификации: %v", err)
+		return status.Errorf(codes.Internal, "ошибка двухфакторной аутентификации")
+	}
+}
@@ -0,0 +1,282 @@
+package dbauthservice
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	"crmSystem/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrOidcNotConfigured возвращается, если для почтового домена не настроен вход через провайдера.
+	ErrOidcNotConfigured = errors.New("вход через корпоративного провайдера не настроен для этого домена")
+
+	// ErrOidcDomainTaken возвращается, если почтовый домен уже привязан к другой компании.
+	ErrOidcDomainTaken = errors.New("домен уже используется другой компанией")
+
+	// ErrOidcEmailNotVerified возвращается, если провайдер не подтвердил email пользователя,
+	// а учётная запись ещё не связана с пользователем провайдера.
+	ErrOidcEmailNotVerified = errors.New("провайдер не подтвердил email пользователя")
+
+	// ErrOidcSubjectMismatch возвращается, если учётная запись уже связана с другим пользователем провайдера.
+	ErrOidcSubjectMismatch = errors.New("учётная запись связана с другим пользователем провайдера")
+)
+
+// OidcConfig настройки входа через OpenID Connect провайдера компании.
+type OidcConfig struct {
+	CompanyId    string
+	Issuer       string
+	ClientId     string
+	ClientSecret string   // Секрет клиента в открытом виде, в базе хранится зашифрованным
+	Domains      []string // Почтовые домены пользователей компании
+	Enabled      bool
+}
+
+// NormalizeOidcDomain приводит почтовый домен к виду, в котором он хранится в companyOidcDomains.
+func NormalizeOidcDomain(domain string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "@")
+}
+
+// SaveOidcConfig сохраняет настройки провайдера компании и заменяет список её почтовых доменов.
+//
+// При смене провайдера связи пользователей с прежним провайдером удаляются:
+// идентификаторы sub разных провайдеров не совпадают.
+func SaveOidcConfig(ctx context.Context, db *sql.DB, config OidcConfig) error {
+	secret, err := utils.EncryptSecret(config.ClientSecret)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var previousIssuer string
+	err = tx.QueryRowContext(ctx, "SELECT issuer FROM companyOidc WHERE company_id = $1 FOR UPDATE",
+		config.CompanyId).Scan(&previousIssuer)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("ошибка получения настроек провайдера: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO companyOidc (company_id, issuer, client_id, client_secret, enabled, updatedAt)
+        VALUES ($1, $2, $3, $4, $5, NOW())
+        ON CONFLICT (company_id) DO UPDATE SET issuer = EXCLUDED.issuer, client_id = EXCLUDED.client_id,
+            client_secret = EXCLUDED.client_secret, enabled = EXCLUDED.enabled, updatedAt = NOW()`,
+		config.CompanyId, config.Issuer, config.ClientId, secret, config.Enabled)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения настроек провайдера: %w", err)
+	}
+
+	if previousIssuer != "" && previousIssuer != config.Issuer {
+		if _, err := tx.ExecContext(ctx, "UPDATE authusers SET oidc_subject = NULL WHERE company_id = $1",
+			config.CompanyId); err != nil {
+			return fmt.Errorf("ошибка удаления связей с прежним провайдером: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM companyOidcDomains WHERE company_id = $1", config.CompanyId); err != nil {
+		return fmt.Errorf("ошибка обновления доменов компании: %w", err)
+	}
+	for _, domain := range config.Domains {
+		result, err := tx.ExecContext(ctx,
+			"INSERT INTO companyOidcDomains (domain, company_id) VALUES ($1, $2) ON CONFLICT (domain) DO NOTHING",
+			NormalizeOidcDomain(domain), config.CompanyId)
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения домена %s: %w", domain, err)
+		}
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("ошибка сохранения домена %s: %w", domain, err)
+		}
+		if inserted == 0 {
+			return fmt.Errorf("%w: %s", ErrOidcDomainTaken, domain)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("не удалось зафиксировать транзакцию: %w", err)
+	}
+	return nil
+}
+
+// GetOidcConfigByDomain возвращает включённые настройки провайдера компании, которой принадлежит домен.
+func GetOidcConfigByDomain(ctx context.Context, db *sql.DB, domain string) (*OidcConfig, error) {
+	config := &OidcConfig{Enabled: true}
+	var secret string
+	err := db.QueryRowContext(ctx, `
+        SELECT o.company_id, o.issuer, o.client_id, o.client_secret
+        FROM companyOidcDomains d JOIN companyOidc o ON o.company_id = d.company_id
+        WHERE d.domain = $1 AND o.enabled`, NormalizeOidcDomain(domain)).
+		Scan(&config.CompanyId, &config.Issuer, &config.ClientId, &secret)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOidcNotConfigured
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения настроек провайдера: %w", err)
+	}
+
+	if config.ClientSecret, err = utils.DecryptSecret(secret); err != nil {
+		return nil, err
+	}
+	config.Domains = []string{NormalizeOidcDomain(domain)}
+	return config, nil
+}
+
+// LinkOidcUser находит пользователя компании, выполнившего вход через провайдера, и возвращает его ID.
+//
+// Пользователь ищется по идентификатору sub, а при первом входе - по email, подтверждённому провайдером;
+// найденная учётная запись связывается с sub и активируется. Новые пользователи не создаются:
+// их по-прежнему приглашает администратор компании.
+func LinkOidcUser(ctx context.Context, db *sql.DB, companyId, subject, email string, emailVerified bool) (string, error) {
+	var authUserId string
+	err := db.QueryRowContext(ctx, "SELECT id FROM authusers WHERE company_id = $1 AND oidc_subject = $2",
+		companyId, subject).Scan(&authUserId)
+	if err == nil {
+		return authUserId, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("ошибка поиска пользователя: %w", err)
+	}
+
+	if !emailVerified {
+		return "", ErrOidcEmailNotVerified
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var linkedSubject sql.NullString
+	err = tx.QueryRowContext(ctx,
+		"SELECT id, oidc_subject FROM authusers WHERE email = $1 AND company_id = $2 FOR UPDATE",
+		strings.ToLower(strings.TrimSpace(email)), companyId).Scan(&authUserId, &linkedSubject)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrAuthUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка поиска пользователя: %w", err)
+	}
+	if linkedSubject.Valid && linkedSubject.String != subject {
+		return "", ErrOidcSubjectMismatch
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE authusers SET oidc_subject = $1, status = $2 WHERE id = $3",
+		subject, utils.AuthStatusVerified, authUserId); err != nil {
+		return "", fmt.Errorf("ошибка связывания пользователя с провайдером: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("не удалось зафиксировать транзакцию: %w", err)
+	}
+	return authUserId, nil
+}
+
+// GetOidcProvider возвращает настройки провайдера для почтового домена пользователя.
+// Секрет клиента передаётся только внутренним сервисам.
+func (s *AuthServiceServer) GetOidcProvider(ctx context.Context, req *dbauth.GetOidcProviderRequest) (*dbauth.GetOidcProviderResponse, error) {
+	db, err := s.oidcDb(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := GetOidcConfigByDomain(ctx, db, req.Domain)
+	if err != nil {
+		return nil, oidcError(err)
+	}
+
+	return &dbauth.GetOidcProviderResponse{
+		CompanyId:    config.CompanyId,
+		Issuer:       config.Issuer,
+		ClientId:     config.ClientId,
+		ClientSecret: config.ClientSecret,
+	}, nil
+}
+
+// LoginOidc завершает вход пользователя, подтверждённого провайдером компании,
+// и передаёт его данные в заголовках ответа так же, как LoginDB.
+//
+// Провайдер заменяет пароль, но не второй фактор CRM: если пользователь подключил TOTP
+// или компания его требует, вместо данных пользователя передаётся заголовок mfa-required.
+func (s *AuthServiceServer) LoginOidc(ctx context.Context, req *dbauth.LoginOidcRequest) (*dbauth.LoginOidcResponse, error) {
+	db, err := s.oidcDb(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.CompanyId == "" || req.Subject == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "не указана компания или пользователь провайдера")
+	}
+
+	authUserId, err := LinkOidcUser(ctx, db, req.CompanyId, req.Subject, req.Email, req.EmailVerified)
+	if err != nil {
+		return nil, oidcError(err)
+	}
+
+	mfa, err := s.mfaRequirement(ctx, authUserId)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+	if mfa != "" {
+		if err := grpc.SendHeader(ctx, metadata.Pairs("mfa-required", mfa, "auth-user-id", authUserId)); err != nil {
+			return nil, status.Errorf(codes.Internal, "Ошибка установки метаданных: %v", err)
+		}
+		return &dbauth.LoginOidcResponse{
+			Message: "Требуется подтверждение вторым фактором",
+		}, nil
+	}
+
+	if err := s.sendLoginIdentity(ctx, db, authUserId); err != nil {
+		return nil, err
+	}
+
+	return &dbauth.LoginOidcResponse{
+		Message: "Пользователь найден",
+	}, nil
+}
+
+// oidcDb проверяет, что метод OIDC вызван внутренним сервисом, и возвращает соединение с базой авторизации.
+func (s *AuthServiceServer) oidcDb(ctx context.Context) (*sql.DB, error) {
+	if err := utils.RequireInternalCaller(ctx); err != nil {
+		return nil, err
+	}
+
+	db, err := s.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
+	if err != nil {
+		log.Printf("Ошибка при получении соединения из connectionsMap: %v", err)
+		return nil, status.Errorf(codes.Internal, "ошибка подключения к базе данных авторизации")
+	}
+	return db, nil
+}
+
+// oidcError приводит ошибку входа через провайдера к ошибке gRPC.
+func oidcError(err error) error {
+	switch {
+	case errors.Is(err, ErrOidcNotConfigured):
+		return status.Errorf(codes.NotFound, "%v", err)
+	case errors.Is(err, ErrAuthUserNotFound):
+		return status.Errorf(codes.NotFound, "пользователь не зарегистрирован в CRM, обратитесь к администратору компании")
+	case errors.Is(err, ErrOidcEmailNotVerified), errors.Is(err, ErrOidcSubjectMismatch):
+		return status.Errorf(codes.PermissionDenied, "%v", err)
+	default:
+		log.Printf("Ошибка входа через провайдера: %v", err)
+		return status.Errorf(codes.Internal, "ошибка входа через корпоративного провайдера")
+	}
+}
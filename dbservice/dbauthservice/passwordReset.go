@@ -0,0 +1,73 @@
+package dbauthservice
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	"crmSystem/utils"
+	"errors"
+	"log"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// FindAuthUser ищет пользователя по email для восстановления пароля.
+// Метод доступен только внутренним сервисам: ответ раскрывает существование учётной записи.
+func (s *AuthServiceServer) FindAuthUser(ctx context.Context, req *dbauth.FindAuthUserRequest) (*dbauth.FindAuthUserResponse, error) {
+	if err := utils.RequireInternalCaller(ctx); err != nil {
+		return nil, err
+	}
+
+	db, err := s.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
+	if err != nil {
+		log.Printf("Ошибка при получении соединения из connectionsMap: %v", err)
+		return nil, status.Errorf(codes.Internal, "ошибка подключения к базе данных авторизации")
+	}
+
+	authUserId, err := FindAuthUserByEmail(ctx, db, strings.ToLower(req.Email))
+	if errors.Is(err, ErrAuthUserNotFound) {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	if err != nil {
+		log.Printf("Ошибка поиска пользователя: %v", err)
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	return &dbauth.FindAuthUserResponse{
+		Message: "Пользователь найден",
+		UserId:  authUserId,
+	}, nil
+}
+
+// ResetPassword устанавливает пользователю новый пароль.
+// Проверку права на смену пароля (токен сброса) выполняет вызывающий сервис.
+func (s *AuthServiceServer) ResetPassword(ctx context.Context, req *dbauth.ResetPasswordRequest) (*dbauth.ResetPasswordResponse, error) {
+	if err := utils.RequireInternalCaller(ctx); err != nil {
+		return nil, err
+	}
+
+	if req.UserId == "" || req.Password == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "не указан пользователь или пароль")
+	}
+
+	db, err := s.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
+	if err != nil {
+		log.Printf("Ошибка при получении соединения из connectionsMap: %v", err)
+		return nil, status.Errorf(codes.Internal, "ошибка подключения к базе данных авторизации")
+	}
+
+	err = SetPassword(ctx, db, req.UserId, req.Password)
+	if errors.Is(err, ErrAuthUserNotFound) {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	if err != nil {
+		log.Printf("Ошибка обновления пароля: %v", err)
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	return &dbauth.ResetPasswordResponse{
+		Message: "Пароль обновлён",
+	}, nil
+}
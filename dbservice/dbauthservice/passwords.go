@@ -0,0 +1,241 @@
+package dbauthservice
+
+import (
+	"context"
+	"crmSystem/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// ErrInvalidCredentials возвращается, если пользователь не найден или пароль не совпал.
+// Оба случая намеренно не различаются, чтобы не раскрывать существование учётной записи.
+var ErrInvalidCredentials = errors.New("Пользователь не найден")
+
+// ErrAccountNotActivated возвращается при верном пароле, если email не подтверждён
+// или приглашённый пользователь ещё не активировал учётную запись.
+var ErrAccountNotActivated = errors.New("учётная запись не активирована, перейдите по ссылке из письма")
+
+// AuthenticateUser находит пользователя в таблице authusers по email или телефону
+// и проверяет пароль на стороне приложения.
+//
+// Параметры:
+// - ctx: Контекст запроса.
+// - db: Соединение с базой данных авторизации.
+// - email, phone: Данные для поиска пользователя (ожидаются в нижнем регистре), см. FindLoginUser.
+// - password: Пароль в открытом виде из запроса.
+//
+// Возвращает:
+// - authUserId: ID пользователя в таблице authusers.
+// - companyId: ID компании пользователя.
+// - Ошибку ErrInvalidCredentials, если пользователь не найден или пароль неверен.
+// - Ошибку ErrAccountNotActivated, если пароль верен, но учётная запись не активирована.
+//
+// Если пароль хранится в открытом виде или хэширован устаревшим способом,
+// после успешной проверки запись перезаписывается хэшем текущей версии.
+func AuthenticateUser(ctx context.Context, db *sql.DB, email, phone, password string) (authUserId string, companyId string, err error) {
+	user, err := FindLoginUser(ctx, db, email, phone)
+	if err != nil {
+		return "", "", err
+	}
+	if err := CheckLoginPassword(ctx, db, user, password); err != nil {
+		return "", "", err
+	}
+	return user.Id, user.CompanyId, nil
+}
+
+// LoginUser учётная запись, найденная по данным входа.
+type LoginUser struct {
+	Id        string // ID пользователя в таблице authusers
+	CompanyId string // ID компании пользователя
+	password  string // Хэш пароля (или пароль в открытом виде до перехэширования)
+	status    string // Статус учётной записи
+}
+
+// FindLoginUser находит пользователя для входа по email, а если email не передан - по телефону.
+// Телефон при переданном email не используется: email и телефон разных учётных записей
+// нашли бы две строки, и пароль проверялся бы у произвольной из них.
+// Если пользователь не найден, возвращает nil без ошибки.
+func FindLoginUser(ctx context.Context, db *sql.DB, email, phone string) (*LoginUser, error) {
+	query := "SELECT id, company_id, password, status FROM authusers WHERE email = $1"
+	identifier := email
+	if email == "" {
+		query = "SELECT id, company_id, password, status FROM authusers WHERE phone = $1"
+		identifier = phone
+	}
+	if identifier == "" {
+		return nil, nil
+	}
+
+	user := &LoginUser{}
+	err := db.QueryRowContext(ctx, query, identifier).Scan(&user.Id, &user.CompanyId, &user.password, &user.status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// CheckLoginPassword проверяет пароль пользователя user, найденного FindLoginUser.
+// Для nil возвращает ErrInvalidCredentials за то же время, что и для неверного пароля.
+func CheckLoginPassword(ctx context.Context, db *sql.DB, user *LoginUser, password string) error {
+	if user == nil {
+		// Выполняем хэширование впустую, чтобы время ответа не выдавало отсутствие пользователя
+		_, _, _ = utils.VerifyPassword(dummyPasswordHash, password)
+		return ErrInvalidCredentials
+	}
+
+	match, needsRehash, err := utils.VerifyPassword(user.password, password)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки пароля: %w", err)
+	}
+	if !match {
+		return ErrInvalidCredentials
+	}
+
+	// Статус проверяется только после пароля, чтобы не раскрывать состояние чужих учётных записей
+	if user.status != utils.AuthStatusVerified {
+		return ErrAccountNotActivated
+	}
+
+	if needsRehash {
+		// Ошибка перехэширования не должна мешать входу пользователя
+		if err := rehashPassword(ctx, db, user.Id, password); err != nil {
+			log.Printf("Не удалось перехэшировать пароль пользователя %s: %v", user.Id, err)
+		}
+	}
+	return nil
+}
+
+// rehashPassword записывает для пользователя хэш пароля текущей версии.
+func rehashPassword(ctx context.Context, db *sql.DB, authUserId, password string) error {
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	_, err = db.ExecContext(ctx, "UPDATE authusers SET password = $1 WHERE id = $2", hash, authUserId)
+	return err
+}
+
+// RehashLegacyPasswords однократно перехэширует все пароли, хранящиеся в открытом виде.
+//
+// Записи, уже содержащие хэш (Argon2id или bcrypt), пропускаются — bcrypt-хэши
+// будут обновлены при следующем входе пользователя, так как исходный пароль неизвестен.
+//
+// Возвращает количество обновлённых записей.
+func RehashLegacyPasswords(ctx context.Context, db *sql.DB) (int, error) {
+	rows, err := db.QueryContext(ctx, "SELECT id, password FROM authusers")
+	if err != nil {
+		return 0, fmt.Errorf("не удалось получить список пользователей: %w", err)
+	}
+
+	type legacyRow struct {
+		id       string
+		password string
+	}
+
+	var legacy []legacyRow
+	for rows.Next() {
+		var r legacyRow
+		if err := rows.Scan(&r.id, &r.password); err != nil {
+			_ = rows.Close()
+			return 0, fmt.Errorf("ошибка чтения пользователя: %w", err)
+		}
+		if !utils.IsPasswordHash(r.password) {
+			legacy = append(legacy, r)
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return 0, err
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, r := range legacy {
+		hash, err := utils.HashPassword(r.password)
+		if err != nil {
+			return updated, err
+		}
+		// Условие на старое значение защищает от перезаписи пароля, изменённого параллельно
+		res, err := db.ExecContext(ctx,
+			"UPDATE authusers SET password = $1 WHERE id = $2 AND password = $3",
+			hash, r.id, r.password)
+		if err != nil {
+			return updated, fmt.Errorf("не удалось обновить пароль пользователя %s: %w", r.id, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			updated++
+		}
+	}
+
+	return updated, nil
+}
+
+// dummyPasswordHash используется для выравнивания времени ответа при отсутствии пользователя.
+var dummyPasswordHash, _ = utils.HashPassword("dummy-password")
+
+// ErrAuthUserNotFound возвращается, если пользователь с указанными данными отсутствует в authusers.
+var ErrAuthUserNotFound = errors.New("пользователь не найден")
+
+// FindAuthUserByEmail возвращает ID пользователя в таблице authusers по email (ожидается в нижнем регистре).
+func FindAuthUserByEmail(ctx context.Context, db *sql.DB, email string) (string, error) {
+	var authUserId string
+	err := db.QueryRowContext(ctx, "SELECT id FROM authusers WHERE email = $1", email).Scan(&authUserId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrAuthUserNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("ошибка поиска пользователя: %w", err)
+	}
+	return authUserId, nil
+}
+
+// SetPassword хэширует новый пароль и записывает его пользователю authUserId.
+//
+// Пароль задаётся только по ссылке из письма (сброс пароля или приглашение),
+// что подтверждает владение email, поэтому учётная запись одновременно активируется.
+func SetPassword(ctx context.Context, db *sql.DB, authUserId, password string) error {
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	result, err := db.ExecContext(ctx, "UPDATE authusers SET password = $1, status = $2 WHERE id = $3",
+		hash, utils.AuthStatusVerified, authUserId)
+	if err != nil {
+		return fmt.Errorf("ошибка обновления пароля: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка обновления пароля: %w", err)
+	}
+	if updated == 0 {
+		return ErrAuthUserNotFound
+	}
+	return nil
+}
+
+// VerifyEmail подтверждает email пользователя, зарегистрировавшего компанию.
+// Приглашённых пользователей так активировать нельзя: им необходимо задать пароль через SetPassword.
+func VerifyEmail(ctx context.Context, db *sql.DB, authUserId string) error {
+	result, err := db.ExecContext(ctx, "UPDATE authusers SET status = $1 WHERE id = $2 AND status <> $3",
+		utils.AuthStatusVerified, authUserId, utils.AuthStatusInvited)
+	if err != nil {
+		return fmt.Errorf("ошибка подтверждения email: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка подтверждения email: %w", err)
+	}
+	if updated == 0 {
+		return ErrAuthUserNotFound
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package dbauthservice
+
+import (
+	"context"
+	"crmSystem/proto/dbauth"
+	"crmSystem/provisioning"
+	"crmSystem/utils"
+	"errors"
+	"log"
+	"os"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetProvisioningStatus возвращает состояние создания базы данных компании по идентификатору,
+// выданному при регистрации. Вызывается auth сервисом, пока клиент ожидает готовности компании.
+func (s *AuthServiceServer) GetProvisioningStatus(ctx context.Context, req *dbauth.GetProvisioningStatusRequest) (*dbauth.GetProvisioningStatusResponse, error) {
+	if err := utils.RequireInternalCaller(ctx); err != nil {
+		return nil, err
+	}
+	if req.ProvisioningId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "не указан идентификатор регистрации")
+	}
+
+	db, err := s.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
+	if err != nil {
+		log.Printf("Ошибка при получении соединения из connectionsMap: %v", err)
+		return nil, status.Errorf(codes.Internal, "ошибка подключения к базе данных авторизации")
+	}
+
+	state, _, err := provisioning.Status(ctx, db, req.ProvisioningId)
+	if errors.Is(err, provisioning.ErrNotFound) {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	if err != nil {
+		log.Printf("Ошибка получения состояния регистрации: %v", err)
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	// Текст внутренней ошибки клиенту не передаётся, он сохраняется в реестре для поддержки
+	return &dbauth.GetProvisioningStatusResponse{
+		Status:  state,
+		Message: provisioningMessage(state),
+	}, nil
+}
+
+// provisioningMessage сообщение клиенту о состоянии создания базы компании.
+func provisioningMessage(state string) string {
+	switch state {
+	case utils.ProvisioningReady:
+		return "Рабочее пространство компании готово"
+	case utils.ProvisioningFailed:
+		return utils.ErrTenantProvisioningFailed.Error()
+	default:
+		return "Рабочее пространство компании создаётся"
+	}
+}
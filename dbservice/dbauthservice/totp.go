@@ -0,0 +1,337 @@
+package dbauthservice
+
+import (
+	"context"
+	"crmSystem/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Требование второго фактора при входе.
+const (
+	MfaMethodTotp   = "totp"   // пользователь подключил TOTP и должен ввести код
+	MfaMethodEnroll = "enroll" // компания требует 2FA, пользователь должен сначала подключить TOTP
+)
+
+var (
+	// ErrInvalidMfaCode возвращается, если код TOTP или код восстановления неверен или уже использован.
+	ErrInvalidMfaCode = errors.New("неверный код подтверждения")
+
+	// ErrTotpAlreadyEnabled возвращается при попытке повторно подключить TOTP.
+	ErrTotpAlreadyEnabled = errors.New("двухфакторная аутентификация уже подключена")
+
+	// ErrTotpNotEnrolled возвращается, если TOTP не подключён или подключение не начато.
+	ErrTotpNotEnrolled = errors.New("двухфакторная аутентификация не подключена")
+
+	// ErrMfaLocked возвращается, если проверка кодов заблокирована после слишком многих неверных кодов.
+	ErrMfaLocked = errors.New("слишком много неверных кодов подтверждения")
+
+	// ErrMfaRequiredByCompany возвращается при попытке отключить TOTP, обязательный в компании.
+	ErrMfaRequiredByCompany = errors.New("двухфакторная аутентификация обязательна в вашей компании")
+)
+
+// MfaState состояние двухфакторной аутентификации пользователя.
+type MfaState struct {
+	CompanyId   string
+	TotpEnabled bool // Пользователь подключил TOTP
+	Required    bool // Компания требует 2FA для всех пользователей
+}
+
+// Method возвращает требование второго фактора при входе или пустую строку, если он не нужен.
+func (m *MfaState) Method() string {
+	switch {
+	case m.TotpEnabled:
+		return MfaMethodTotp
+	case m.Required:
+		return MfaMethodEnroll
+	default:
+		return ""
+	}
+}
+
+// GetMfaState возвращает состояние двухфакторной аутентификации пользователя и политику его компании.
+func GetMfaState(ctx context.Context, db *sql.DB, authUserId string) (*MfaState, error) {
+	state := &MfaState{}
+	err := db.QueryRowContext(ctx, `
+        SELECT a.company_id, a.totp_enabled, c.require_mfa
+        FROM authusers a JOIN companies c ON c.id = a.company_id
+        WHERE a.id = $1`, authUserId).Scan(&state.CompanyId, &state.TotpEnabled, &state.Required)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAuthUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения настроек 2FA: %w", err)
+	}
+	return state, nil
+}
+
+// BeginTotpEnrollment создаёт новый секрет TOTP для пользователя.
+// Секрет сохраняется зашифрованным и начинает действовать только после ConfirmTotpEnrollment.
+func BeginTotpEnrollment(ctx context.Context, db *sql.DB, authUserId string) (secret string, provisioningURI string, err error) {
+	var email string
+	var enabled bool
+	err = db.QueryRowContext(ctx, "SELECT email, totp_enabled FROM authusers WHERE id = $1", authUserId).
+		Scan(&email, &enabled)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", ErrAuthUserNotFound
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("ошибка поиска пользователя: %w", err)
+	}
+	if enabled {
+		return "", "", ErrTotpAlreadyEnabled
+	}
+
+	secret, provisioningURI, err = utils.GenerateTotpSecret(email)
+	if err != nil {
+		return "", "", err
+	}
+
+	encrypted, err := utils.EncryptTotpSecret(secret)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Условие на totp_enabled защищает от гонки с параллельным подтверждением
+	result, err := db.ExecContext(ctx,
+		"UPDATE authusers SET totp_secret = $1, totp_last_step = 0 WHERE id = $2 AND totp_enabled = FALSE",
+		encrypted, authUserId)
+	if err != nil {
+		return "", "", fmt.Errorf("ошибка сохранения секрета TOTP: %w", err)
+	}
+	if updated, err := result.RowsAffected(); err != nil || updated == 0 {
+		return "", "", ErrTotpAlreadyEnabled
+	}
+
+	return secret, provisioningURI, nil
+}
+
+// ConfirmTotpEnrollment включает TOTP после проверки первого кода из приложения
+// и возвращает новые коды восстановления. Ранее выданные коды восстановления удаляются.
+func ConfirmTotpEnrollment(ctx context.Context, db *sql.DB, authUserId string, code string) ([]string, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var encrypted sql.NullString
+	var enabled bool
+	var lastStep int64
+	err = tx.QueryRowContext(ctx,
+		"SELECT totp_secret, totp_enabled, totp_last_step FROM authusers WHERE id = $1 FOR UPDATE", authUserId).
+		Scan(&encrypted, &enabled, &lastStep)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAuthUserNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска пользователя: %w", err)
+	}
+	if enabled {
+		return nil, ErrTotpAlreadyEnabled
+	}
+	if !encrypted.Valid {
+		return nil, ErrTotpNotEnrolled
+	}
+
+	secret, err := utils.DecryptTotpSecret(encrypted.String)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := utils.ValidateTotpCode(secret, code, lastStep, time.Now())
+	if !ok {
+		return nil, ErrInvalidMfaCode
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE authusers SET totp_enabled = TRUE, totp_last_step = $1 WHERE id = $2", step, authUserId); err != nil {
+		return nil, fmt.Errorf("ошибка подключения TOTP: %w", err)
+	}
+
+	recoveryCodes, err := replaceRecoveryCodes(ctx, tx, authUserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("не удалось зафиксировать транзакцию: %w", err)
+	}
+	return recoveryCodes, nil
+}
+
+// VerifySecondFactor проверяет код TOTP или одноразовый код восстановления пользователя.
+// Принятый код TOTP и использованный код восстановления повторно не принимаются.
+func VerifySecondFactor(ctx context.Context, db *sql.DB, authUserId string, code string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var encrypted sql.NullString
+	var enabled bool
+	var lastStep int64
+	err = tx.QueryRowContext(ctx,
+		"SELECT totp_secret, totp_enabled, totp_last_step FROM authusers WHERE id = $1 FOR UPDATE", authUserId).
+		Scan(&encrypted, &enabled, &lastStep)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrAuthUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка поиска пользователя: %w", err)
+	}
+	if !enabled || !encrypted.Valid {
+		return ErrTotpNotEnrolled
+	}
+
+	secret, err := utils.DecryptTotpSecret(encrypted.String)
+	if err != nil {
+		return err
+	}
+
+	if step, ok := utils.ValidateTotpCode(secret, code, lastStep, time.Now()); ok {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE authusers SET totp_last_step = $1 WHERE id = $2", step, authUserId); err != nil {
+			return fmt.Errorf("ошибка сохранения кода TOTP: %w", err)
+		}
+		return tx.Commit()
+	}
+
+	// Код не подошёл как TOTP, проверяем коды восстановления
+	result, err := tx.ExecContext(ctx,
+		"UPDATE mfaRecoveryCodes SET used_at = NOW() WHERE auth_user_id = $1 AND code_hash = $2 AND used_at IS NULL",
+		authUserId, utils.HashRecoveryCode(code))
+	if err != nil {
+		return fmt.Errorf("ошибка проверки кода восстановления: %w", err)
+	}
+	used, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка проверки кода восстановления: %w", err)
+	}
+	if used == 0 {
+		return ErrInvalidMfaCode
+	}
+	return tx.Commit()
+}
+
+// VerifySecondFactorLimited проверяет второй фактор как VerifySecondFactor, но считает неверные коды
+// через limiter. После utils.AccountFailureLimit неверных кодов проверка блокируется и возвращается
+// ErrMfaLocked с оставшимся временем блокировки. Верный код сбрасывает счётчик.
+func VerifySecondFactorLimited(ctx context.Context, db *sql.DB, limiter *utils.LoginLimiter,
+	authUserId string, code string) (time.Duration, error) {
+
+	account := utils.MfaAccount(authUserId)
+	retryAfter, err := limiter.RetryAfter(ctx, account, "")
+	if err != nil {
+		return 0, err
+	}
+	if retryAfter > 0 {
+		return retryAfter, ErrMfaLocked
+	}
+
+	err = VerifySecondFactor(ctx, db, authUserId, code)
+	if errors.Is(err, ErrInvalidMfaCode) {
+		lockouts, errLimit := limiter.RegisterFailure(ctx, account, "")
+		if errLimit != nil {
+			// Ошибка учёта не должна менять ответ на неверный код
+			log.Printf("Ошибка учёта неверного кода подтверждения: %v", errLimit)
+		}
+		for _, lockout := range lockouts {
+			log.Printf("Проверка второго фактора заблокирована на %s: %s, неверных кодов %d",
+				lockout.Duration, lockout.Subject, lockout.Failures)
+			if lockout.Duration > retryAfter {
+				retryAfter = lockout.Duration
+			}
+		}
+		if retryAfter > 0 {
+			return retryAfter, ErrMfaLocked
+		}
+		return 0, err
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := limiter.Reset(ctx, account); err != nil {
+		log.Printf("Ошибка сброса счётчика неверных кодов: %v", err)
+	}
+	return 0, nil
+}
+
+// DisableTotp отключает TOTP и удаляет коды восстановления пользователя.
+// Перед вызовом необходимо проверить второй фактор через VerifySecondFactor.
+func DisableTotp(ctx context.Context, db *sql.DB, authUserId string) error {
+	state, err := GetMfaState(ctx, db, authUserId)
+	if err != nil {
+		return err
+	}
+	if state.Required {
+		return ErrMfaRequiredByCompany
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE authusers SET totp_enabled = FALSE, totp_secret = NULL, totp_last_step = 0 WHERE id = $1",
+		authUserId); err != nil {
+		return fmt.Errorf("ошибка отключения TOTP: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfaRecoveryCodes WHERE auth_user_id = $1", authUserId); err != nil {
+		return fmt.Errorf("ошибка удаления кодов восстановления: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("не удалось зафиксировать транзакцию: %w", err)
+	}
+	return nil
+}
+
+// SetCompanyMfaRequired включает или отключает обязательную 2FA для всех пользователей компании.
+func SetCompanyMfaRequired(ctx context.Context, db *sql.DB, companyId string, required bool) error {
+	result, err := db.ExecContext(ctx, "UPDATE companies SET require_mfa = $1 WHERE id = $2", required, companyId)
+	if err != nil {
+		return fmt.Errorf("ошибка изменения политики 2FA: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка изменения политики 2FA: %w", err)
+	}
+	if updated == 0 {
+		return fmt.Errorf("компания %s не найдена", companyId)
+	}
+	return nil
+}
+
+// replaceRecoveryCodes заменяет коды восстановления пользователя новыми и возвращает их.
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, authUserId string) ([]string, error) {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfaRecoveryCodes WHERE auth_user_id = $1", authUserId); err != nil {
+		return nil, fmt.Errorf("ошибка удаления кодов восстановления: %w", err)
+	}
+
+	recoveryCodes, hashes, err := utils.GenerateRecoveryCodes(utils.RecoveryCodesCount)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hash := range hashes {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO mfaRecoveryCodes (auth_user_id, code_hash) VALUES ($1, $2)", authUserId, hash); err != nil {
+			return nil, fmt.Errorf("ошибка сохранения кодов восстановления: %w", err)
+		}
+	}
+	return recoveryCodes, nil
+}
@@ -0,0 +1,218 @@
+package dbchatservice
+
+import (
+	"context"
+	"crmSystem/proto/dbchat"
+	"crmSystem/utils"
+	"database/sql"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultHistoryLimit количество сообщений в странице истории, если limit не задан
+	defaultHistoryLimit = 50
+
+	// maxHistoryLimit максимальное количество сообщений в странице истории
+	maxHistoryLimit = 200
+)
+
+// IsChatMember проверяет, что пользователь состоит в чате (таблица chat_users).
+func IsChatMember(ctx context.Context, db *sql.DB, chatId int64, userId string) (bool, error) {
+	var member bool
+	err := db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM chat_users WHERE chat_id = $1 AND user_id = $2)`,
+		chatId, userId).Scan(&member)
+	return member, err
+}
+
+// historyMessage сообщение истории и authId автора: email автора хранится в базе авторизации
+type historyMessage struct {
+	*dbchat.HistoryMessage
+	authId string
+}
+
+// chatHistory возвращает не больше limit сообщений чата в порядке id и признак наличия следующих сообщений
+// в направлении запроса. beforeId > 0 - сообщения старее beforeId, afterId > 0 - новее afterId,
+// иначе последние сообщения чата. Email автора заполняется отдельно (см. fillAuthorEmails).
+func chatHistory(ctx context.Context, db *sql.DB, chatId int64, beforeId int64, afterId int64, limit int) ([]historyMessage, bool, error) {
+	// Запрашивается на одно сообщение больше, чтобы узнать, есть ли следующая страница
+	query := `
+		SELECT m.id, m.message, m.created_at, m.user_id, u.authId, r.roles
+		FROM messages m
+		LEFT JOIN users u ON u.id = m.user_id
+		LEFT JOIN rights r ON r.id = u.rightsId
+		WHERE m.chat_id = $1 AND ($2 = 0 OR m.id < $2)
+		ORDER BY m.id DESC
+		LIMIT $3`
+	cursor := beforeId
+	if afterId > 0 {
+		query = `
+		SELECT m.id, m.message, m.created_at, m.user_id, u.authId, r.roles
+		FROM messages m
+		LEFT JOIN users u ON u.id = m.user_id
+		LEFT JOIN rights r ON r.id = u.rightsId
+		WHERE m.chat_id = $1 AND m.id > $2
+		ORDER BY m.id
+		LIMIT $3`
+		cursor = afterId
+	}
+
+	rows, err := db.QueryContext(ctx, query, chatId, cursor, limit+1)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var messages []historyMessage
+	for rows.Next() {
+		var (
+			message   = &dbchat.HistoryMessage{ChatId: chatId, Author: &dbchat.MessageAuthor{}}
+			createdAt time.Time
+			userId    sql.NullInt64
+			authId    sql.NullString
+			role      sql.NullString
+		)
+		if err := rows.Scan(&message.MessageId, &message.Content, &createdAt, &userId, &authId, &role); err != nil {
+			return nil, false, err
+		}
+		message.CreatedAt = createdAt.Unix()
+		message.Author.UserId = userId.Int64
+		message.Author.Role = role.String
+		messages = append(messages, historyMessage{HistoryMessage: message, authId: authId.String})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+
+	hasMore := len(messages) > limit
+	if hasMore {
+		messages = messages[:limit]
+	}
+	// Страница всегда возвращается в порядке id
+	if afterId == 0 {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	}
+	return messages, hasMore, nil
+}
+
+// authEmails возвращает email пользователей базы авторизации по их authId.
+// Пользователи без email в ответ не попадают.
+func authEmails(ctx context.Context, authDb *sql.DB, authIds []string) (map[string]string, error) {
+	seen := make(map[string]struct{})
+	var ids []int64
+	for _, authId := range authIds {
+		if _, ok := seen[authId]; ok {
+			continue
+		}
+		seen[authId] = struct{}{}
+		if id, err := strconv.ParseInt(authId, 10, 64); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	emails := make(map[string]string, len(ids))
+	if len(ids) == 0 {
+		return emails, nil
+	}
+	rows, err := authDb.QueryContext(ctx, `SELECT id, email FROM authUsers WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var email string
+		if err := rows.Scan(&id, &email); err != nil {
+			return nil, err
+		}
+		emails[strconv.FormatInt(id, 10)] = email
+	}
+	return emails, rows.Err()
+}
+
+// fillAuthorEmails заполняет email авторов из базы авторизации.
+// Если email не найден, поле остаётся пустым.
+func fillAuthorEmails(ctx context.Context, authDb *sql.DB, messages []historyMessage) error {
+	authIds := make([]string, len(messages))
+	for i, message := range messages {
+		authIds[i] = message.authId
+	}
+	emails, err := authEmails(ctx, authDb, authIds)
+	if err != nil {
+		return err
+	}
+	for _, message := range messages {
+		message.Author.Email = emails[message.authId]
+	}
+	return nil
+}
+
+// ListMessages возвращает страницу истории чата с данными авторов. Историю может читать только
+// участник чата (chat_users).
+func (s *ChatServiceServer) ListMessages(ctx context.Context, req *dbchat.ListMessagesRequest) (*dbchat.ListMessagesResponse, error) {
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.ChatId <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Некорректный id чата")
+	}
+	if req.BeforeId < 0 || req.AfterId < 0 || req.Limit < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "before_id, after_id и limit не могут быть отрицательными")
+	}
+	if req.BeforeId > 0 && req.AfterId > 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "before_id и after_id нельзя передавать вместе")
+	}
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = defaultHistoryLimit
+	}
+	limit = min(limit, maxHistoryLimit)
+
+	db, release, err := s.connectionsMap.Acquire(identity.Database)
+	defer release()
+	if err != nil || db == nil {
+		log.Printf("Ошибка подключения к базе данных: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка подключения к базе данных")
+	}
+
+	member, err := IsChatMember(ctx, db, req.ChatId, identity.UserId)
+	if err != nil {
+		log.Printf("Ошибка проверки участника чата: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка проверки участника чата")
+	}
+	if !member {
+		return nil, status.Errorf(codes.PermissionDenied, "Пользователь не состоит в чате %d", req.ChatId)
+	}
+
+	history, hasMore, err := chatHistory(ctx, db, req.ChatId, req.BeforeId, req.AfterId, limit)
+	if err != nil {
+		log.Printf("Ошибка получения истории чата: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка получения истории чата")
+	}
+
+	// Email авторов хранится в базе авторизации. Без него история остаётся доступной
+	authDb, err := s.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
+	if err == nil {
+		err = fillAuthorEmails(ctx, authDb, history)
+	}
+	if err != nil {
+		log.Printf("Ошибка получения email авторов сообщений: %v", err)
+	}
+
+	messages := make([]*dbchat.HistoryMessage, len(history))
+	for i, message := range history {
+		messages[i] = message.HistoryMessage
+	}
+	return &dbchat.ListMessagesResponse{Messages: messages, HasMore: hasMore}, nil
+}
@@ -0,0 +1,404 @@
+package dbchatservice
+
+import (
+	"context"
+	"crmSystem/proto/dbchat"
+	"crmSystem/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/lib/pq"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// adminChatRole роль создателя чата: управление участниками и ролями чата
+	adminChatRole = "admin_chat"
+
+	// userChatRole роль участника чата по умолчанию
+	userChatRole = "user_chat"
+)
+
+// chatActions действия роли участника в чате (таблица available_actions_chat)
+type chatActions struct {
+	addMembers    bool
+	removeMembers bool
+}
+
+// createDefaultChatRoles создаёт роли admin_chat и user_chat нового чата с их действиями.
+func createDefaultChatRoles(ctx context.Context, tx *sql.Tx, chatId int64) (adminRoleId int64, userRoleId int64, err error) {
+	for _, role := range []struct {
+		name  string
+		admin bool
+		id    *int64
+	}{
+		{name: adminChatRole, admin: true, id: &adminRoleId},
+		{name: userChatRole, admin: false, id: &userRoleId},
+	} {
+		err = tx.QueryRowContext(ctx,
+			`INSERT INTO chat_roles (chat_id, name_role, removable) VALUES ($1, $2, FALSE) RETURNING id`,
+			chatId, role.name).Scan(role.id)
+		if err != nil {
+			return 0, 0, err
+		}
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO available_actions_chat (role_id, create_role, add_members, remove_members) VALUES ($1, $2, $2, $2)`,
+			*role.id, role.admin)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	return adminRoleId, userRoleId, nil
+}
+
+// chatUsersValues формирует VALUES батч-запроса добавления пользователей в chat_users.
+// role_id = 0 сохраняется как NULL (участник без роли).
+func chatUsersValues(chatId int64, users []*dbchat.UserId) (string, []interface{}) {
+	var values []interface{}
+	var placeholders []string
+	for i, user := range users {
+		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, NULLIF($%d, 0))", i*3+1, i*3+2, i*3+3))
+		values = append(values, user.UserId, chatId, user.RoleId)
+	}
+	return strings.Join(placeholders, ","), values
+}
+
+// memberActions возвращает действия, доступные участнику в чате по его роли.
+// Если пользователь не состоит в чате, member = false.
+func memberActions(ctx context.Context, db *sql.DB, chatId int64, userId string) (actions chatActions, member bool, err error) {
+	err = db.QueryRowContext(ctx, `
+		SELECT COALESCE(a.add_members, FALSE), COALESCE(a.remove_members, FALSE)
+		FROM chat_users cu
+		LEFT JOIN available_actions_chat a ON a.role_id = cu.role_id
+		WHERE cu.chat_id = $1 AND cu.user_id = $2`, chatId, userId).Scan(&actions.addMembers, &actions.removeMembers)
+	if errors.Is(err, sql.ErrNoRows) {
+		return chatActions{}, false, nil
+	}
+	if err != nil {
+		return chatActions{}, false, err
+	}
+	return actions, true, nil
+}
+
+// authorizeMembersAction проверяет, что пользователь состоит в чате и его роль разрешает действие allowed.
+// Возвращает базу компании и аренду на неё, которую вызывающий код освобождает после запросов.
+func (s *ChatServiceServer) authorizeMembersAction(ctx context.Context, chatId int64, allowed func(chatActions) bool) (*sql.DB, func(), error) {
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, release, err := s.connectionsMap.Acquire(identity.Database)
+	if err != nil || db == nil {
+		log.Printf("Ошибка подключения к базе данных: %v", err)
+		return nil, nil, status.Errorf(codes.Internal, "Ошибка подключения к базе данных")
+	}
+
+	actions, member, err := memberActions(ctx, db, chatId, identity.UserId)
+	if err != nil {
+		release()
+		log.Printf("Ошибка проверки прав участника чата: %v", err)
+		return nil, nil, status.Errorf(codes.Internal, "Ошибка проверки прав участника чата")
+	}
+	if !member {
+		release()
+		return nil, nil, status.Errorf(codes.PermissionDenied, "Пользователь не состоит в чате %d", chatId)
+	}
+	if !allowed(actions) {
+		release()
+		return nil, nil, status.Errorf(codes.PermissionDenied, "Роль пользователя в чате %d не разрешает это действие", chatId)
+	}
+	return db, release, nil
+}
+
+// chatRoles возвращает роли чата по id и id роли участника по умолчанию (0, если её нет).
+func chatRoles(ctx context.Context, tx *sql.Tx, chatId int64) (map[int64]string, int64, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, name_role FROM chat_roles WHERE chat_id = $1`, chatId)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	roles := make(map[int64]string)
+	var defaultRoleId int64
+	for rows.Next() {
+		var id int64
+		var name string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, 0, err
+		}
+		roles[id] = name
+		if name == userChatRole {
+			defaultRoleId = id
+		}
+	}
+	return roles, defaultRoleId, rows.Err()
+}
+
+// missingUsers возвращает id пользователей, которых нет в базе компании.
+func missingUsers(ctx context.Context, tx *sql.Tx, userIds []int64) ([]int64, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id FROM users WHERE id = ANY($1)`, pq.Array(userIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[int64]struct{}, len(userIds))
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		found[id] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var missing []int64
+	for _, id := range userIds {
+		if _, ok := found[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	return missing, nil
+}
+
+// scanUserIds читает id пользователей из результата запроса.
+func scanUserIds(rows *sql.Rows) ([]int64, error) {
+	defer rows.Close()
+	var userIds []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		userIds = append(userIds, id)
+	}
+	return userIds, rows.Err()
+}
+
+// AddMembers добавляет пользователей в чат. Доступно участнику, роль которого разрешает add_members.
+// Пользователи без роли в запросе получают роль user_chat, уже состоящие в чате пропускаются.
+func (s *ChatServiceServer) AddMembers(ctx context.Context, req *dbchat.AddMembersRequest) (*dbchat.AddMembersResponse, error) {
+	if req.ChatId <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Некорректный id чата")
+	}
+	if len(req.Users) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Список пользователей пуст")
+	}
+	users := make([]*dbchat.UserId, 0, len(req.Users))
+	userIds := make([]int64, 0, len(req.Users))
+	seen := make(map[int64]struct{}, len(req.Users))
+	for _, user := range req.Users {
+		if user.GetUserId() <= 0 || user.RoleId < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "Некорректный id пользователя или роли")
+		}
+		if _, ok := seen[user.UserId]; ok {
+			continue
+		}
+		seen[user.UserId] = struct{}{}
+		users = append(users, &dbchat.UserId{UserId: user.UserId, RoleId: user.RoleId})
+		userIds = append(userIds, user.UserId)
+	}
+
+	db, release, err := s.authorizeMembersAction(ctx, req.ChatId, func(actions chatActions) bool { return actions.addMembers })
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Ошибка начала транзакции: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка начала транзакции")
+	}
+	defer func() {
+		// После Commit откат ничего не делает
+		_ = tx.Rollback()
+	}()
+
+	// Роль участника должна принадлежать этому чату
+	roles, defaultRoleId, err := chatRoles(ctx, tx, req.ChatId)
+	if err != nil {
+		log.Printf("Ошибка получения ролей чата: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка получения ролей чата")
+	}
+	for _, user := range users {
+		if user.RoleId == 0 {
+			user.RoleId = defaultRoleId
+			continue
+		}
+		if _, ok := roles[user.RoleId]; !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "Роль %d не принадлежит чату %d", user.RoleId, req.ChatId)
+		}
+	}
+
+	missing, err := missingUsers(ctx, tx, userIds)
+	if err != nil {
+		log.Printf("Ошибка проверки пользователей: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка проверки пользователей")
+	}
+	if len(missing) > 0 {
+		return nil, status.Errorf(codes.NotFound, "Пользователи не найдены: %v", missing)
+	}
+
+	placeholders, values := chatUsersValues(req.ChatId, users)
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(
+		`INSERT INTO chat_users (user_id, chat_id, role_id) VALUES %s ON CONFLICT DO NOTHING RETURNING user_id`,
+		placeholders), values...)
+	if err != nil {
+		log.Printf("Ошибка добавления пользователей в чат: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка добавления пользователей в чат %d", req.ChatId)
+	}
+	added, err := scanUserIds(rows)
+	if err != nil {
+		log.Printf("Ошибка добавления пользователей в чат: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка добавления пользователей в чат %d", req.ChatId)
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Ошибка подтверждения транзакции: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка подтверждения транзакции")
+	}
+	return &dbchat.AddMembersResponse{UserIds: added}, nil
+}
+
+// RemoveMembers удаляет пользователей из чата. Доступно участнику, роль которого разрешает remove_members.
+func (s *ChatServiceServer) RemoveMembers(ctx context.Context, req *dbchat.RemoveMembersRequest) (*dbchat.RemoveMembersResponse, error) {
+	if req.ChatId <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Некорректный id чата")
+	}
+	if len(req.UserIds) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Список пользователей пуст")
+	}
+	for _, userId := range req.UserIds {
+		if userId <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "Некорректный id пользователя")
+		}
+	}
+
+	db, release, err := s.authorizeMembersAction(ctx, req.ChatId, func(actions chatActions) bool { return actions.removeMembers })
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	rows, err := db.QueryContext(ctx,
+		`DELETE FROM chat_users WHERE chat_id = $1 AND user_id = ANY($2) RETURNING user_id`,
+		req.ChatId, pq.Array(req.UserIds))
+	if err != nil {
+		log.Printf("Ошибка удаления пользователей из чата: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка удаления пользователей из чата %d", req.ChatId)
+	}
+	removed, err := scanUserIds(rows)
+	if err != nil {
+		log.Printf("Ошибка удаления пользователей из чата: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка удаления пользователей из чата %d", req.ChatId)
+	}
+	return &dbchat.RemoveMembersResponse{UserIds: removed}, nil
+}
+
+// LeaveChat удаляет пользователя из токена из чата. Выйти из чата может любой участник.
+func (s *ChatServiceServer) LeaveChat(ctx context.Context, req *dbchat.LeaveChatRequest) (*dbchat.LeaveChatResponse, error) {
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.ChatId <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Некорректный id чата")
+	}
+
+	db, release, err := s.connectionsMap.Acquire(identity.Database)
+	defer release()
+	if err != nil || db == nil {
+		log.Printf("Ошибка подключения к базе данных: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка подключения к базе данных")
+	}
+
+	result, err := db.ExecContext(ctx, `DELETE FROM chat_users WHERE chat_id = $1 AND user_id = $2`, req.ChatId, identity.UserId)
+	if err != nil {
+		log.Printf("Ошибка выхода из чата: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка выхода из чата")
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return nil, status.Errorf(codes.NotFound, "Пользователь не состоит в чате %d", req.ChatId)
+	}
+	return &dbchat.LeaveChatResponse{}, nil
+}
+
+// ListMembers возвращает участников чата с их ролями. Доступно участникам чата.
+func (s *ChatServiceServer) ListMembers(ctx context.Context, req *dbchat.ListMembersRequest) (*dbchat.ListMembersResponse, error) {
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.ChatId <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Некорректный id чата")
+	}
+
+	db, release, err := s.connectionsMap.Acquire(identity.Database)
+	defer release()
+	if err != nil || db == nil {
+		log.Printf("Ошибка подключения к базе данных: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка подключения к базе данных")
+	}
+
+	member, err := IsChatMember(ctx, db, req.ChatId, identity.UserId)
+	if err != nil {
+		log.Printf("Ошибка проверки участника чата: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка проверки участника чата")
+	}
+	if !member {
+		return nil, status.Errorf(codes.PermissionDenied, "Пользователь не состоит в чате %d", req.ChatId)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT cu.user_id, u.authId, COALESCE(cr.id, 0), COALESCE(cr.name_role, '')
+		FROM chat_users cu
+		JOIN users u ON u.id = cu.user_id
+		LEFT JOIN chat_roles cr ON cr.id = cu.role_id
+		WHERE cu.chat_id = $1
+		ORDER BY cu.user_id`, req.ChatId)
+	if err != nil {
+		log.Printf("Ошибка получения участников чата: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка получения участников чата")
+	}
+	defer rows.Close()
+
+	var members []*dbchat.ChatMember
+	var authIds []string
+	for rows.Next() {
+		member := &dbchat.ChatMember{}
+		var authId string
+		if err := rows.Scan(&member.UserId, &authId, &member.RoleId, &member.Role); err != nil {
+			log.Printf("Ошибка получения участников чата: %v", err)
+			return nil, status.Errorf(codes.Internal, "Ошибка получения участников чата")
+		}
+		members = append(members, member)
+		authIds = append(authIds, authId)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Ошибка получения участников чата: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка получения участников чата")
+	}
+
+	// Email участников хранится в базе авторизации. Без него список участников остаётся доступным
+	authDb, err := s.connectionsMap.GetDb(os.Getenv("DB_AUTH_NAME"))
+	var emails map[string]string
+	if err == nil {
+		emails, err = authEmails(ctx, authDb, authIds)
+	}
+	if err != nil {
+		log.Printf("Ошибка получения email участников чата: %v", err)
+	}
+	for i, member := range members {
+		member.Email = emails[authIds[i]]
+	}
+	return &dbchat.ListMembersResponse{Members: members}, nil
+}
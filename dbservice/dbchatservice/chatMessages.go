@@ -0,0 +1,126 @@
+package dbchatservice
+
+import (
+	"context"
+	"crmSystem/proto/dbchat"
+	"crmSystem/utils"
+	"database/sql"
+	"log"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultMessagesLimit количество сообщений в ответе ListMessagesSince, если limit не задан
+	defaultMessagesLimit = 100
+
+	// maxMessagesLimit максимальное количество сообщений в ответе ListMessagesSince
+	maxMessagesLimit = 500
+)
+
+// UserChats возвращает чаты, в которых состоит пользователь, в порядке id.
+func UserChats(ctx context.Context, db *sql.DB, userId string) ([]*dbchat.ChatInfo, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT c.id, c.chat_name
+		FROM chats c
+		JOIN chat_users cu ON cu.chat_id = c.id
+		WHERE cu.user_id = $1
+		ORDER BY c.id`, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chats []*dbchat.ChatInfo
+	for rows.Next() {
+		chat := &dbchat.ChatInfo{}
+		if err := rows.Scan(&chat.ChatId, &chat.ChatName); err != nil {
+			return nil, err
+		}
+		chats = append(chats, chat)
+	}
+	return chats, rows.Err()
+}
+
+// MessagesSince возвращает не больше limit сообщений с id больше afterId из чатов, в которых состоит
+// пользователь, в порядке id. Используется для продолжения потока сообщений после переподключения клиента.
+func MessagesSince(ctx context.Context, db *sql.DB, userId string, afterId int64, limit int) ([]*dbchat.ChatMessage, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT m.id, m.chat_id, COALESCE(m.user_id, 0), m.message, m.created_at
+		FROM messages m
+		JOIN chat_users cu ON cu.chat_id = m.chat_id
+		WHERE cu.user_id = $1 AND m.id > $2
+		ORDER BY m.id
+		LIMIT $3`, userId, afterId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*dbchat.ChatMessage
+	for rows.Next() {
+		message := &dbchat.ChatMessage{}
+		var createdAt time.Time
+		if err := rows.Scan(&message.MessageId, &message.ChatId, &message.UserId, &message.Content, &createdAt); err != nil {
+			return nil, err
+		}
+		message.CreatedAt = createdAt.Unix()
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
+
+// ListUserChats возвращает чаты пользователя из токена.
+func (s *ChatServiceServer) ListUserChats(ctx context.Context, _ *dbchat.ListUserChatsRequest) (*dbchat.ListUserChatsResponse, error) {
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	db, release, err := s.connectionsMap.Acquire(identity.Database)
+	defer release()
+	if err != nil || db == nil {
+		log.Printf("Ошибка подключения к базе данных: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка подключения к базе данных")
+	}
+
+	chats, err := UserChats(ctx, db, identity.UserId)
+	if err != nil {
+		log.Printf("Ошибка получения чатов пользователя: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка получения чатов пользователя")
+	}
+	return &dbchat.ListUserChatsResponse{Chats: chats}, nil
+}
+
+// ListMessagesSince возвращает сообщения всех чатов пользователя из токена после сообщения after_id.
+func (s *ChatServiceServer) ListMessagesSince(ctx context.Context, req *dbchat.ListMessagesSinceRequest) (*dbchat.ListMessagesSinceResponse, error) {
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.AfterId < 0 || req.Limit < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "after_id и limit не могут быть отрицательными")
+	}
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = defaultMessagesLimit
+	}
+	limit = min(limit, maxMessagesLimit)
+
+	db, release, err := s.connectionsMap.Acquire(identity.Database)
+	defer release()
+	if err != nil || db == nil {
+		log.Printf("Ошибка подключения к базе данных: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка подключения к базе данных")
+	}
+
+	messages, err := MessagesSince(ctx, db, identity.UserId, req.AfterId, limit)
+	if err != nil {
+		log.Printf("Ошибка получения сообщений: %v", err)
+		return nil, status.Errorf(codes.Internal, "Ошибка получения сообщений")
+	}
+	return &dbchat.ListMessagesSinceResponse{Messages: messages}, nil
+}
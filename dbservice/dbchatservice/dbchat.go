@@ -6,13 +6,13 @@ import (
 	"crmSystem/proto/logs"
 	"crmSystem/utils"
 	"database/sql"
+	"errors"
 	"fmt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"log"
-	"strings"
+	"strconv"
 	"time"
 )
 
@@ -30,11 +30,6 @@ func NewGRPCDBChatService(mapConnect *utils.MapConnectionsDB) *ChatServiceServer
 // Метод для создания чата и добавления пользователя с транзакцией
 func (s *ChatServiceServer) CreateChat(ctx context.Context, req *dbchat.CreateChatRequest) (*dbchat.CreateChatResponse, error) {
 
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, status.Errorf(codes.Internal, "Не удалось получить метаданные из контекста")
-	}
-
 	token, err := utils.ExtractTokenFromContext(ctx)
 	if err != nil {
 		log.Printf("Не удалось извлечь токен для логирования: %v", err)
@@ -59,32 +54,22 @@ func (s *ChatServiceServer) CreateChat(ctx context.Context, req *dbchat.CreateCh
 		}(conn)
 	}
 
-	// Извлекаем DatabaseName из метаданных
-	dbCheck := md["database"]
-	if len(dbCheck) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", "database не найдена в метаданных")
-		if errLogs != nil {
-			log.Printf("database не найдена в метаданных: %v", err)
-		}
-		return nil, status.Errorf(codes.Unauthenticated, "database не найдена в метаданных")
-	}
-	database := md["database"][0]
-
-	// Извлекаем UserId из метаданных
-	uIdCheck := md["user-id"]
-	if len(uIdCheck) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, database, "", "userId не найдена в метаданных")
+	// Извлекаем данные пользователя только из проверенного токена (см. utils.IdentityInterceptor)
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
 		if errLogs != nil {
-			log.Printf("userId не найдена в метаданных: %v", err)
+			log.Printf("Не удалось получить данные пользователя из токена: %v", errLogs)
 		}
-		return nil, status.Errorf(codes.Unauthenticated, "userId не найдена в метаданных")
+		return nil, err
 	}
-	userId := md["user-id"][0]
+	database := identity.Database
+	userId := identity.UserId
 
 	log.Printf("CreateChat: %s", "CreateChat")
-	// Получаем строку подключения к базе данных
-	dsn := utils.DsnString(database)
-	dbConnCompany, err := s.connectionsMap.GetDb(dsn)
+	// Получаем соединение с базой данных компании
+	dbConnCompany, release, err := s.connectionsMap.Acquire(database)
+	defer release()
 	if err != nil || dbConnCompany == nil {
 		errLogs := utils.SaveLogsError(ctx, clientLogs, database, userId, fmt.Sprintf("Ошибка подключения к базе данных: %v", database))
 		if errLogs != nil {
@@ -122,6 +107,21 @@ func (s *ChatServiceServer) CreateChat(ctx context.Context, req *dbchat.CreateCh
 		return nil, status.Errorf(codes.Internal, fmt.Sprintf("Ошибка создания чата: %v", err))
 	}
 
+	// Создаём роли чата по умолчанию: создатель чата управляет участниками
+	adminRoleId, userRoleId, err := createDefaultChatRoles(ctx, tx, chatID)
+	if err != nil {
+		errRollback := tx.Rollback()
+		if errRollback != nil {
+			return nil, errRollback
+		}
+		errLogs := utils.SaveLogsError(ctx, clientLogs, database, userId, fmt.Sprintf("Ошибка создания ролей чата"))
+		if errLogs != nil {
+			log.Printf("Ошибка создания ролей чата: %v", err)
+		}
+		log.Printf("Ошибка создания ролей чата: %s", err)
+		return nil, status.Errorf(codes.Internal, fmt.Sprintf("Ошибка создания ролей чата: %v", err))
+	}
+
 	// Завершаем транзакцию на уровне создания чата
 	err = tx.Commit()
 	if err != nil {
@@ -133,10 +133,27 @@ func (s *ChatServiceServer) CreateChat(ctx context.Context, req *dbchat.CreateCh
 		return nil, status.Errorf(codes.Internal, fmt.Sprintf("Ошибка при коммите создания чата: %v", err))
 	}
 
+	// Участники получают роль user_chat, создатель чата - admin_chat. Роли из запроса не применяются:
+	// до создания чата у него нет ролей
+	var users []*dbchat.UserId
+	creatorId, errCreator := strconv.ParseInt(userId, 10, 64)
+	creatorAdded := false
+	for _, user := range req.UsersId {
+		roleId := userRoleId
+		if errCreator == nil && user.UserId == creatorId {
+			roleId = adminRoleId
+			creatorAdded = true
+		}
+		users = append(users, &dbchat.UserId{UserId: user.UserId, RoleId: roleId})
+	}
+	if errCreator == nil && !creatorAdded {
+		users = append(users, &dbchat.UserId{UserId: creatorId, RoleId: adminRoleId})
+	}
+
 	// Создаём запрос для добавления пользователей
 	addUsersReq := &dbchat.AddUsersToChatRequest{
 		ChatId:  chatID,
-		UsersId: req.UsersId,
+		UsersId: users,
 	}
 
 	// Вызываем метод AddUsersToChat
@@ -160,25 +177,20 @@ func (s *ChatServiceServer) CreateChat(ctx context.Context, req *dbchat.CreateCh
 
 func (s *ChatServiceServer) AddUsersToChat(ctx context.Context, req *dbchat.AddUsersToChatRequest, clientLogs logs.LogsServiceClient, userId string) (*dbchat.AddUsersToChatResponse, error) {
 
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, status.Errorf(codes.Internal, "Не удалось получить метаданные из контекста")
-	}
-
-	// Извлекаем DatabaseName из метаданных
-	dbCheck := md["database"]
-	if len(dbCheck) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", "database не найдена в метаданных")
+	// Извлекаем базу данных только из проверенного токена (см. utils.IdentityInterceptor)
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
 		if errLogs != nil {
-			log.Printf("database не найдена в метаданных")
+			log.Printf("Не удалось получить данные пользователя из токена: %v", errLogs)
 		}
-		return nil, status.Errorf(codes.Unauthenticated, "database не найдена в метаданных")
+		return nil, err
 	}
-	database := md["database"][0]
+	database := identity.Database
 
-	// Получаем строку подключения к базе данных
-	dsn := utils.DsnString(database)
-	dbConnCompany, err := sql.Open("postgres", dsn)
+	// Получаем соединение с базой данных компании
+	dbConnCompany, release, err := s.connectionsMap.Acquire(database)
+	defer release()
 	if err != nil {
 		errLogs := utils.SaveLogsError(ctx, clientLogs, database, userId, err.Error())
 		if errLogs != nil {
@@ -187,15 +199,6 @@ func (s *ChatServiceServer) AddUsersToChat(ctx context.Context, req *dbchat.AddU
 		log.Printf("Ошибка подключения к базе данных: %s", err)
 		return nil, status.Errorf(codes.Internal, fmt.Sprintf("Ошибка подключения к базе данных: %v", err))
 	}
-	defer func(dbConnCompany *sql.DB) {
-		err := dbConnCompany.Close()
-		if err != nil {
-			errLogs := utils.SaveLogsError(ctx, clientLogs, database, userId, err.Error())
-			if errLogs != nil {
-				log.Printf("Ошибка закрытия соединения: %v", err)
-			}
-		}
-	}(dbConnCompany)
 
 	// Начало транзакции
 	tx, err := dbConnCompany.Begin()
@@ -209,17 +212,12 @@ func (s *ChatServiceServer) AddUsersToChat(ctx context.Context, req *dbchat.AddU
 	}
 
 	// Формируем данные для батчевого запроса
-	var values []interface{}
-	var placeholders []string
-	for i, user := range req.UsersId {
-		placeholders = append(placeholders, fmt.Sprintf("($%d, $%d)", i*2+1, i*2+2))
-		values = append(values, user.UserId, req.ChatId)
-	}
+	placeholders, values := chatUsersValues(req.ChatId, req.UsersId)
 
 	// Добавляем пользователей в таблицу chat_users с использованием батч-запроса
 	addUserQuery := fmt.Sprintf(
-		`INSERT INTO chat_users (user_id, chat_id) VALUES %s ON CONFLICT DO NOTHING;`,
-		strings.Join(placeholders, ","),
+		`INSERT INTO chat_users (user_id, chat_id, role_id) VALUES %s ON CONFLICT DO NOTHING;`,
+		placeholders,
 	)
 
 	// Выполняем батч-запрос
@@ -256,11 +254,6 @@ func (s *ChatServiceServer) AddUsersToChat(ctx context.Context, req *dbchat.AddU
 
 func (s *ChatServiceServer) SaveMessage(ctx context.Context, req *dbchat.SaveMessageRequest) (*dbchat.SaveMessageResponse, error) {
 
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, status.Errorf(codes.Internal, "Не удалось получить метаданные из контекста")
-	}
-
 	token, err := utils.ExtractTokenFromContext(ctx)
 	if err != nil {
 		log.Printf("Не удалось извлечь токен для логирования: %v", err)
@@ -285,31 +278,21 @@ func (s *ChatServiceServer) SaveMessage(ctx context.Context, req *dbchat.SaveMes
 		}(conn)
 	}
 
-	// Извлекаем DatabaseName из метаданных
-	dbCheck := md["database"]
-	if len(dbCheck) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", "database не найдена в метаданных")
-		if errLogs != nil {
-			log.Printf("database не найдена в метаданных: %v", err)
-		}
-		return nil, status.Errorf(codes.Unauthenticated, "database не найдена в метаданных")
-	}
-	database := md["database"][0]
-
-	// Извлекаем UserId из метаданных
-	uIdCheck := md["user-id"]
-	if len(uIdCheck) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, database, "", "userId не найдена в метаданных")
+	// Извлекаем данные пользователя только из проверенного токена (см. utils.IdentityInterceptor)
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
 		if errLogs != nil {
-			log.Printf("userId не найдена в метаданных: %v", err)
+			log.Printf("Не удалось получить данные пользователя из токена: %v", errLogs)
 		}
-		return nil, status.Errorf(codes.Unauthenticated, "userId не найдена в метаданных")
+		return nil, err
 	}
-	userId := md["user-id"][0]
+	database := identity.Database
+	userId := identity.UserId
 
-	// Получаем строку подключения к базе данных
-	dsn := utils.DsnString(database)
-	dbConnCompany, err := s.connectionsMap.GetDb(dsn)
+	// Получаем соединение с базой данных компании
+	dbConnCompany, release, err := s.connectionsMap.Acquire(database)
+	defer release()
 	if err != nil || dbConnCompany == nil {
 		errLogs := utils.SaveLogsError(ctx, clientLogs, database, userId, "Ошибка подключения к базе данных")
 		if errLogs != nil {
@@ -319,20 +302,11 @@ func (s *ChatServiceServer) SaveMessage(ctx context.Context, req *dbchat.SaveMes
 		return nil, status.Errorf(codes.Internal, fmt.Sprintf("Ошибка подключения к базе данных: %s", err))
 	}
 
-	// SQL-запрос для вставки сообщения
-	insertMessageQuery := `
-        INSERT INTO messages (chat_id, user_id, message)
-        VALUES ($1, $2, $3)
-        RETURNING id, created_at;
-    `
-
-	// Переменные для получения возвращаемых значений
-	var messageID int64
-	var createdAt time.Time
-
-	// Выполняем запрос
-	err = dbConnCompany.QueryRowContext(ctx, insertMessageQuery, req.ChatId, userId, req.Content).
-		Scan(&messageID, &createdAt)
+	// Сообщение сохраняется только от участника чата: удалённый из чата пользователь не может в него писать
+	messageID, createdAt, err := SaveChatMessage(ctx, dbConnCompany, req.ChatId, userId, req.Content)
+	if errors.Is(err, ErrNotChatMember) {
+		return nil, status.Errorf(codes.PermissionDenied, "Пользователь не состоит в чате %d", req.ChatId)
+	}
 	if err != nil {
 		log.Printf("Ошибка при сохранении сообщения: %s", err)
 		errLogs := utils.SaveLogsError(ctx, clientLogs, database, userId, err.Error())
@@ -350,3 +324,33 @@ func (s *ChatServiceServer) SaveMessage(ctx context.Context, req *dbchat.SaveMes
 		CreatedAt: createdAt.Unix(),
 	}, nil
 }
+
+// ErrNotChatMember возвращается при попытке написать в чат, в котором пользователь не состоит.
+var ErrNotChatMember = errors.New("пользователь не состоит в чате")
+
+// SaveChatMessage сохраняет сообщение пользователя userId в чат chatId и возвращает id и время сообщения.
+// Участие в чате проверяется тем же запросом, что и для истории и списка участников.
+func SaveChatMessage(ctx context.Context, db *sql.DB, chatId int64, userId string, content string) (int64, time.Time, error) {
+	member, err := IsChatMember(ctx, db, chatId, userId)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("ошибка проверки участника чата: %w", err)
+	}
+	if !member {
+		return 0, time.Time{}, ErrNotChatMember
+	}
+
+	// SQL-запрос для вставки сообщения
+	insertMessageQuery := `
+        INSERT INTO messages (chat_id, user_id, message)
+        VALUES ($1, $2, $3)
+        RETURNING id, created_at;
+    `
+
+	var messageID int64
+	var createdAt time.Time
+	err = db.QueryRowContext(ctx, insertMessageQuery, chatId, userId, content).Scan(&messageID, &createdAt)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return messageID, createdAt, nil
+}
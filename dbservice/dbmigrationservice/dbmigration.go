@@ -0,0 +1,166 @@
+package dbmigrationservice
+
+import (
+	"context"
+	"crmSystem/migrations"
+	pbMigration "crmSystem/proto/dbmigration"
+	"crmSystem/utils"
+	"errors"
+	"log"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MigrationServiceServer управление миграциями баз данных компаний. Все методы доступны только
+// внутренним сервисам: токен пользователя компании не даёт доступа к миграциям.
+type MigrationServiceServer struct {
+	pbMigration.UnsafeDbMigrationServiceServer
+	plane *migrations.ControlPlane
+}
+
+func NewGRPCDBMigrationService(plane *migrations.ControlPlane) *MigrationServiceServer {
+	return &MigrationServiceServer{
+		plane: plane,
+	}
+}
+
+// ListMigrationStatus возвращает версию схемы и признак прерванной миграции каждой базы компании,
+// а также базы, не совпадающие с реестром компаний.
+func (s *MigrationServiceServer) ListMigrationStatus(ctx context.Context, _ *pbMigration.ListMigrationStatusRequest) (*pbMigration.ListMigrationStatusResponse, error) {
+	if err := utils.RequireInternalCaller(ctx); err != nil {
+		return nil, err
+	}
+
+	statuses, err := s.plane.Status(ctx, migrations.ConcurrencyFromEnv())
+	if err != nil {
+		log.Printf("Ошибка получения версий баз компаний: %v", err)
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	inventory, err := s.plane.Inventory(ctx)
+	if err != nil {
+		log.Printf("Ошибка получения списка баз данных: %v", err)
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	response := &pbMigration.ListMigrationStatusResponse{
+		LatestVersion:         int64(s.plane.LatestVersion()),
+		UnregisteredDatabases: inventory.Unregistered,
+		MissingDatabases:      inventory.Missing,
+	}
+	for _, st := range statuses {
+		response.Tenants = append(response.Tenants, &pbMigration.TenantMigrationStatus{
+			DbName:  st.DbName,
+			Version: int64(st.Version),
+			Dirty:   st.Dirty,
+			Error:   st.Error,
+		})
+	}
+	return response, nil
+}
+
+// RunMigrations мигрирует все базы компаний до последней версии и останавливается после первой ошибки.
+func (s *MigrationServiceServer) RunMigrations(ctx context.Context, req *pbMigration.RunMigrationsRequest) (*pbMigration.RunMigrationsResponse, error) {
+	if err := utils.RequireInternalCaller(ctx); err != nil {
+		return nil, err
+	}
+
+	concurrency := int(req.Concurrency)
+	if concurrency <= 0 {
+		concurrency = migrations.ConcurrencyFromEnv()
+	}
+	report, err := s.plane.Run(ctx, migrations.RunOptions{
+		Concurrency: concurrency,
+		DryRun:      req.DryRun,
+		Actor:       req.Actor,
+	})
+	if err != nil {
+		return nil, migrationError(err)
+	}
+
+	response := &pbMigration.RunMigrationsResponse{Stopped: report.Stopped}
+	for _, result := range report.Results {
+		response.Results = append(response.Results, resultToProto(result))
+	}
+	return response, nil
+}
+
+// MigrateTenant мигрирует или откатывает одну базу компании до указанной версии.
+func (s *MigrationServiceServer) MigrateTenant(ctx context.Context, req *pbMigration.MigrateTenantRequest) (*pbMigration.MigrateTenantResponse, error) {
+	if err := utils.RequireInternalCaller(ctx); err != nil {
+		return nil, err
+	}
+	if req.DbName == "" || req.TargetVersion < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "не указана база данных или указана неверная версия")
+	}
+
+	result, err := s.plane.MigrateTenant(ctx, req.DbName, uint(req.TargetVersion), migrations.TenantOptions{
+		DryRun: req.DryRun,
+		Force:  req.Force,
+		Actor:  req.Actor,
+	})
+	if err != nil {
+		return nil, migrationError(err)
+	}
+	return &pbMigration.MigrateTenantResponse{Result: resultToProto(*result)}, nil
+}
+
+// ListMigrationHistory возвращает последние записи истории миграций.
+func (s *MigrationServiceServer) ListMigrationHistory(ctx context.Context, req *pbMigration.ListMigrationHistoryRequest) (*pbMigration.ListMigrationHistoryResponse, error) {
+	if err := utils.RequireInternalCaller(ctx); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.plane.History(ctx, req.DbName, int(req.Limit))
+	if err != nil {
+		log.Printf("Ошибка получения истории миграций: %v", err)
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	response := &pbMigration.ListMigrationHistoryResponse{}
+	for _, e := range entries {
+		response.Entries = append(response.Entries, &pbMigration.MigrationHistoryEntry{
+			RunId:       e.RunId,
+			DbName:      e.DbName,
+			Operation:   e.Operation,
+			FromVersion: int64(e.FromVersion),
+			ToVersion:   int64(e.ToVersion),
+			Status:      e.Status,
+			Error:       e.Error,
+			Actor:       e.Actor,
+			StartedAt:   e.StartedAt.Unix(),
+			FinishedAt:  e.FinishedAt.Unix(),
+		})
+	}
+	return response, nil
+}
+
+// migrationError приводит ошибку параметров миграции к ошибке gRPC.
+func migrationError(err error) error {
+	switch {
+	case errors.Is(err, migrations.ErrActorRequired), errors.Is(err, migrations.ErrUnknownVersion):
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	case errors.Is(err, migrations.ErrUnknownDatabase):
+		return status.Errorf(codes.NotFound, "%v", err)
+	default:
+		log.Printf("Ошибка миграции: %v", err)
+		return status.Errorf(codes.Internal, "%v", err)
+	}
+}
+
+func resultToProto(result migrations.Result) *pbMigration.TenantMigrationResult {
+	pending := make([]int64, len(result.Pending))
+	for i, v := range result.Pending {
+		pending[i] = int64(v)
+	}
+	return &pbMigration.TenantMigrationResult{
+		DbName:          result.DbName,
+		Operation:       result.Operation,
+		FromVersion:     int64(result.FromVersion),
+		ToVersion:       int64(result.ToVersion),
+		PendingVersions: pending,
+		Status:          result.Status,
+		Error:           result.Error,
+	}
+}
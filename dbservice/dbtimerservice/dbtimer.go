@@ -9,7 +9,6 @@ import (
 	"fmt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 	"log"
 	"time"
@@ -28,11 +27,6 @@ func NewGRPCDBTimerService(mapConnect *utils.MapConnectionsDB) *TimerServiceServ
 
 func (s *TimerServiceServer) ChangeTimerDB(ctx context.Context, _ *dbtimer.ChangeTimerRequestDB) (*dbtimer.ChangeTimerResponseDB, error) {
 
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, status.Errorf(codes.Internal, "Не удалось получить метаданные из контекста")
-	}
-
 	token, err := utils.ExtractTokenFromContext(ctx)
 	if err != nil {
 		log.Printf("Не удалось извлечь токен для логирования: %v", err)
@@ -57,32 +51,22 @@ func (s *TimerServiceServer) ChangeTimerDB(ctx context.Context, _ *dbtimer.Chang
 		}(conn)
 	}
 
-	// Извлекаем DatabaseName из метаданных
-	dbCheck := md["database"]
-	if len(dbCheck) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", "database не найдена в метаданных")
-		if errLogs != nil {
-			log.Printf("database не найдена в метаданных: %v", err)
-		}
-		return nil, status.Errorf(codes.Unauthenticated, "database не найдена в метаданных")
-	}
-	database := md["database"][0]
-
-	// Извлекаем UserId из метаданных
-	uIdCheck := md["user-id"]
-	if len(uIdCheck) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, database, "", "userId не найдена в метаданных")
+	// Извлекаем данные пользователя только из проверенного токена (см. utils.IdentityInterceptor)
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
 		if errLogs != nil {
-			log.Printf("userId не найдена в метаданных: %v", err)
+			log.Printf("Не удалось получить данные пользователя из токена: %v", errLogs)
 		}
-		return nil, status.Errorf(codes.Unauthenticated, "userId не найдена в метаданных")
+		return nil, err
 	}
-	userId := md["user-id"][0]
+	database := identity.Database
+	userId := identity.UserId
 
-	// Открываем соединение с базой данных Авторизации
-	dsn := utils.DsnString(database)
 	// Получаем соединение с базой данных
-	db, err := s.connectionsMap.GetDb(dsn)
+	// Получаем соединение с базой данных компании
+	db, release, err := s.connectionsMap.Acquire(database)
+	defer release()
 	if err != nil {
 		// Если произошла ошибка подключения, логируем её и возвращаем ответ с ошибкой.
 		log.Printf("Ошибка подключения к базе данных: %s", err)
@@ -149,11 +133,6 @@ func (s *TimerServiceServer) ChangeTimerDB(ctx context.Context, _ *dbtimer.Chang
 
 func (s *TimerServiceServer) StartTimerDB(ctx context.Context, req *dbtimer.StartEndTimerRequestDB) (*dbtimer.StartEndTimerResponseDB, error) {
 
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, status.Errorf(codes.Internal, "Не удалось получить метаданные из контекста")
-	}
-
 	token, err := utils.ExtractTokenFromContext(ctx)
 	if err != nil {
 		log.Printf("Не удалось извлечь токен для логирования: %v", err)
@@ -178,32 +157,22 @@ func (s *TimerServiceServer) StartTimerDB(ctx context.Context, req *dbtimer.Star
 		}(conn)
 	}
 
-	// Извлекаем DatabaseName из метаданных
-	dbCheck := md["database"]
-	if len(dbCheck) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", "database не найдена в метаданных")
-		if errLogs != nil {
-			log.Printf("database не найдена в метаданных: %v", err)
-		}
-		return nil, status.Errorf(codes.Unauthenticated, "database не найдена в метаданных")
-	}
-	database := md["database"][0]
-
-	// Извлекаем UserId из метаданных
-	uIdCheck := md["user-id"]
-	if len(uIdCheck) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, database, "", "userId не найдена в метаданных")
+	// Извлекаем данные пользователя только из проверенного токена (см. utils.IdentityInterceptor)
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
 		if errLogs != nil {
-			log.Printf("userId не найдена в метаданных: %v", err)
+			log.Printf("Не удалось получить данные пользователя из токена: %v", errLogs)
 		}
-		return nil, status.Errorf(codes.Unauthenticated, "userId не найдена в метаданных")
+		return nil, err
 	}
-	userId := md["user-id"][0]
+	database := identity.Database
+	userId := identity.UserId
 
-	// Открываем соединение с базой данных Авторизации
-	dsn := utils.DsnString(database)
 	// Получаем соединение с базой данных
-	db, err := s.connectionsMap.GetDb(dsn)
+	// Получаем соединение с базой данных компании
+	db, release, err := s.connectionsMap.Acquire(database)
+	defer release()
 	if err != nil {
 		// Если произошла ошибка подключения, логируем её и возвращаем ответ с ошибкой.
 		log.Printf("Ошибка подключения к базе данных: %s", err)
@@ -359,11 +328,6 @@ func (s *TimerServiceServer) StartTimerDB(ctx context.Context, req *dbtimer.Star
 
 func (s *TimerServiceServer) GetWorkingTimerDB(ctx context.Context, req *dbtimer.WorkingTimerRequestDB) (*dbtimer.WorkingTimerResponseDB, error) {
 
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, status.Errorf(codes.Internal, "Не удалось получить метаданные из контекста")
-	}
-
 	token, err := utils.ExtractTokenFromContext(ctx)
 	if err != nil {
 		log.Printf("Не удалось извлечь токен для логирования: %v", err)
@@ -388,32 +352,22 @@ func (s *TimerServiceServer) GetWorkingTimerDB(ctx context.Context, req *dbtimer
 		}(conn)
 	}
 
-	// Извлекаем DatabaseName из метаданных
-	dbCheck := md["database"]
-	if len(dbCheck) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", "database не найдена в метаданных")
-		if errLogs != nil {
-			log.Printf("database не найдена в метаданных: %v", err)
-		}
-		return nil, status.Errorf(codes.Unauthenticated, "database не найдена в метаданных")
-	}
-	database := md["database"][0]
-
-	// Извлекаем UserId из метаданных
-	uIdCheck := md["user-id"]
-	if len(uIdCheck) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, database, "", "userId не найдена в метаданных")
+	// Извлекаем данные пользователя только из проверенного токена (см. utils.IdentityInterceptor)
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
 		if errLogs != nil {
-			log.Printf("userId не найдена в метаданных: %v", err)
+			log.Printf("Не удалось получить данные пользователя из токена: %v", errLogs)
 		}
-		return nil, status.Errorf(codes.Unauthenticated, "userId не найдена в метаданных")
+		return nil, err
 	}
-	userId := md["user-id"][0]
+	database := identity.Database
+	userId := identity.UserId
 
-	// Открываем соединение с базой данных Авторизации
-	dsn := utils.DsnString(database)
 	// Получаем соединение с базой данных
-	db, err := s.connectionsMap.GetDb(dsn)
+	// Получаем соединение с базой данных компании
+	db, release, err := s.connectionsMap.Acquire(database)
+	defer release()
 	if err != nil {
 		// Если произошла ошибка подключения, логируем её и возвращаем ответ с ошибкой.
 		log.Printf("Ошибка подключения к базе данных: %s", err)
@@ -470,11 +424,6 @@ func (s *TimerServiceServer) GetWorkingTimerDB(ctx context.Context, req *dbtimer
 
 func (s *TimerServiceServer) EndTimerDB(ctx context.Context, req *dbtimer.StartEndTimerRequestDB) (*dbtimer.StartEndTimerResponseDB, error) {
 
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, status.Errorf(codes.Internal, "Не удалось получить метаданные из контекста")
-	}
-
 	token, err := utils.ExtractTokenFromContext(ctx)
 	if err != nil {
 		log.Printf("Не удалось извлечь токен для логирования: %v", err)
@@ -499,32 +448,22 @@ func (s *TimerServiceServer) EndTimerDB(ctx context.Context, req *dbtimer.StartE
 		}(conn)
 	}
 
-	// Извлекаем DatabaseName из метаданных
-	dbCheck := md["database"]
-	if len(dbCheck) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", "database не найдена в метаданных")
-		if errLogs != nil {
-			log.Printf("database не найдена в метаданных: %v", err)
-		}
-		return nil, status.Errorf(codes.Unauthenticated, "database не найдена в метаданных")
-	}
-	database := md["database"][0]
-
-	// Извлекаем UserId из метаданных
-	uIdCheck := md["user-id"]
-	if len(uIdCheck) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, database, "", "userId не найдена в метаданных")
+	// Извлекаем данные пользователя только из проверенного токена (см. utils.IdentityInterceptor)
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
 		if errLogs != nil {
-			log.Printf("userId не найдена в метаданных: %v", err)
+			log.Printf("Не удалось получить данные пользователя из токена: %v", errLogs)
 		}
-		return nil, status.Errorf(codes.Unauthenticated, "userId не найдена в метаданных")
+		return nil, err
 	}
-	userId := md["user-id"][0]
+	database := identity.Database
+	userId := identity.UserId
 
-	// Открываем соединение с базой данных Авторизации
-	dsn := utils.DsnString(database)
 	// Получаем соединение с базой данных
-	db, err := s.connectionsMap.GetDb(dsn)
+	// Получаем соединение с базой данных компании
+	db, release, err := s.connectionsMap.Acquire(database)
+	defer release()
 	if err != nil {
 		// Если произошла ошибка подключения, логируем её и возвращаем ответ с ошибкой.
 		log.Printf("Ошибка подключения к базе данных: %s", err)
@@ -602,37 +541,22 @@ func (s *TimerServiceServer) AddTimerDB(ctx context.Context, req *dbtimer.AddTim
 		}(conn)
 	}
 
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		return nil, status.Errorf(codes.Internal, "Не удалось получить метаданные из контекста")
-	}
-
-	// Извлекаем DatabaseName из метаданных
-	dbCheck := md["database"]
-	if len(dbCheck) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", "database не найдена в метаданных")
-		if errLogs != nil {
-			log.Printf("database не найдена в метаданных: %v", err)
-		}
-		return nil, status.Errorf(codes.Unauthenticated, "database не найдена в метаданных")
-	}
-	database := md["database"][0]
-
-	// Извлекаем UserId из метаданных
-	uIdCheck := md["user-id"]
-	if len(uIdCheck) == 0 {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, database, "", "userId не найдена в метаданных")
+	// Извлекаем данные пользователя только из проверенного токена (см. utils.IdentityInterceptor)
+	identity, err := utils.IdentityFromContext(ctx)
+	if err != nil {
+		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
 		if errLogs != nil {
-			log.Printf("userId не найдена в метаданных: %v", err)
+			log.Printf("Не удалось получить данные пользователя из токена: %v", errLogs)
 		}
-		return nil, status.Errorf(codes.Unauthenticated, "userId не найдена в метаданных")
+		return nil, err
 	}
-	userId := md["user-id"][0]
+	database := identity.Database
+	userId := identity.UserId
 
-	// Открываем соединение с базой данных Авторизации
-	dsn := utils.DsnString(database)
 	// Получаем соединение с базой данных
-	db, err := s.connectionsMap.GetDb(dsn)
+	// Получаем соединение с базой данных компании
+	db, release, err := s.connectionsMap.Acquire(database)
+	defer release()
 	if err != nil {
 		// Если произошла ошибка подключения, логируем её и возвращаем ответ с ошибкой.
 		log.Printf("Ошибка подключения к базе данных: %s", err)
@@ -1,17 +1,24 @@
 package main
 
 import (
+	"context"
 	"crmSystem/dbadminservice"
 	"crmSystem/dbauthservice"
 	"crmSystem/dbchatservice"
+	"crmSystem/dbmigrationservice"
 	"crmSystem/dbtimerservice"
+	"crmSystem/lifecycle"
 	"crmSystem/migrations"
+	"crmSystem/offboarding"
 	pbAdmin "crmSystem/proto/dbadmin" // Импортируйте сгенерированный пакет из протобуферов dbtimer
 	pbAuth "crmSystem/proto/dbauth"   // Импортируйте сгенерированный пакет из протобуферов dbauth
 	pbChat "crmSystem/proto/dbchat"   // Импортируйте сгенерированный пакет из протобуферов dbchat
+	pbMigration "crmSystem/proto/dbmigration"
 	pbTimer "crmSystem/proto/dbtimer" // Импортируйте сгенерированный пакет из протобуферов dbtimer
+	"crmSystem/provisioning"
 	"crmSystem/utils"
 	"database/sql"
+	"flag"
 	"fmt"
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
@@ -21,8 +28,7 @@ import (
 	"log"
 	"net"
 	"os"
-	"strconv"
-	"sync"
+	"strings"
 	"time"
 )
 
@@ -38,10 +44,9 @@ import (
 // 1. Загружает переменные окружения из файла .env.
 // 2. Получает имя базы данных авторизации из переменной окружения DB_AUTH_NAME.
 // 3. Создает базу данных авторизации, если она еще не существует, с помощью функции createInsideDB.
-// 4. Открывает соединение с базой данных авторизации, используя функцию GetDb.
-// 5. Добавляет полученное соединение в сервер в карту mapDB.
-// 6. Выполняет миграцию для базы данных авторизации, используя указанный путь к миграциям (MIGRATION_AUTH_PATH).
-// 7. Возвращает nil, если все операции выполнены успешно.
+// 4. Открывает соединение с базой данных авторизации, используя функцию GetDb (соединение сохраняется в пуле).
+// 5. Выполняет миграцию для базы данных авторизации, используя указанный путь к миграциям (MIGRATION_AUTH_PATH).
+// 6. Возвращает nil, если все операции выполнены успешно.
 func initDB(server *utils.MapConnectionsDB) error {
 	// Загружаем переменные из файла .env
 	err := godotenv.Load("/app/.env")
@@ -70,11 +75,9 @@ func initDB(server *utils.MapConnectionsDB) error {
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
 		log.Printf("Попытка подключения к базе данных авторизации (%d/%d)", attempt, maxAttempts)
 
-		// Формируем строку подключения
-		dsn := utils.DsnString(authDBName)
-
 		// Получаем соединение с базой данных
-		authDB, err := server.GetDb(dsn)
+		// Получаем соединение с базой данных
+		authDB, err := server.GetDb(authDBName)
 		if err != nil {
 			log.Printf("Не удалось подключиться к базе данных: %s", err)
 
@@ -92,7 +95,7 @@ func initDB(server *utils.MapConnectionsDB) error {
 		// Проверяем, готова ли база данных
 		for checkAttempt := 1; checkAttempt <= maxAttempts; checkAttempt++ {
 			log.Printf("Проверка готовности базы данных (попытка %d/%d)", checkAttempt, maxAttempts)
-			if isDatabaseReady(authDB) {
+			if isDatabaseReady(context.Background(), authDB) {
 				break
 			}
 
@@ -104,9 +107,6 @@ func initDB(server *utils.MapConnectionsDB) error {
 			time.Sleep(delayBetweenAttempts)
 		}
 
-		// Добавляем соединение к базе данных авторизации в пул серверов
-		server.MapDB[authDBName] = authDB
-
 		// Выполняем миграцию для базы данных авторизации
 		err = migrations.Migration(authDB, migratePath, authDBName)
 		if err != nil {
@@ -133,10 +133,10 @@ func initDB(server *utils.MapConnectionsDB) error {
 }
 
 // Проверка готовности базы данных
-func isDatabaseReady(db *sql.DB) bool {
+func isDatabaseReady(ctx context.Context, db *sql.DB) bool {
 	query := `SELECT 1 FROM pg_database WHERE datname = current_database()`
 	var result int
-	err := db.QueryRow(query).Scan(&result)
+	err := db.QueryRowContext(ctx, query).Scan(&result)
 	if err != nil {
 		log.Printf("База данных еще не готова: %s", err)
 		return false
@@ -144,44 +144,65 @@ func isDatabaseReady(db *sql.DB) bool {
 	return result == 1
 }
 
-// fullCompaniesMigrations проходимся по всем базам данных и применяем последние миграционные обновления
-func fullCompaniesMigrations() {
-	dsn := utils.DsnString("postgres")
-	db, err := sql.Open("postgres", dsn)
+// fullCompaniesMigrations применяет последние миграции ко всем базам компаний, не более
+// MIGRATION_CONCURRENCY баз одновременно. После первой ошибки миграции останавливаются,
+// а базы с прерванной миграцией не исправляются автоматически: их проверяет оператор (cmd/migrationctl).
+// При остановке сервиса новые базы не мигрируются, начатые миграции завершаются.
+func fullCompaniesMigrations(ctx context.Context, plane *migrations.ControlPlane) {
+	reportOrphanDatabases(ctx, plane)
+
+	report, err := plane.Run(ctx, migrations.RunOptions{
+		Concurrency: migrations.ConcurrencyFromEnv(),
+		Actor:       migrations.StartupActor,
+	})
 	if err != nil {
-		log.Fatalf("Ошибка подключения к базе данных postgres: %v", err)
+		log.Fatalf("Ошибка получения списка баз данных: %v", err)
 	}
-	defer db.Close()
 
-	// Получаем список баз данных для миграции
-	dbNames, err := migrations.GetDatabasesToMigrate(db)
+	counts := make(map[string]int)
+	for _, result := range report.Results {
+		counts[result.Status]++
+	}
+	log.Printf("Миграционные обновления завершены (запуск %s): обновлено %d, без изменений %d, ошибок %d, пропущено %d",
+		report.RunId, counts[migrations.StatusApplied], counts[migrations.StatusUpToDate],
+		counts[migrations.StatusFailed], counts[migrations.StatusSkipped])
+	if report.Stopped {
+		log.Printf("Миграции остановлены после первой ошибки, состояние баз: go run ./cmd/migrationctl status")
+	}
+}
+
+// reportOrphanDatabases пишет в лог базы, не совпадающие с реестром компаний: такие базы не мигрируются
+// и требуют проверки оператором.
+func reportOrphanDatabases(ctx context.Context, plane *migrations.ControlPlane) {
+	inventory, err := plane.Inventory(ctx)
 	if err != nil {
 		log.Fatalf("Ошибка получения списка баз данных: %v", err)
 	}
-
-	// Путь к миграциям для компаний
-	migratePath := os.Getenv("MIGRATION_COMPANYDB_PATH")
-
-	// Запускаем миграции в параллельных горутинах
-	var wg sync.WaitGroup
-
-	//Счётчик базщ данных
-	counter := 0
-
-	for _, dbName := range dbNames {
-		wg.Add(1)
-
-		go migrations.MigrateCompanyDatabase(dbName, migratePath, &wg, &counter)
+	if len(inventory.Unregistered) > 0 {
+		log.Printf("Базы данных, не зарегистрированные в реестре компаний (не мигрируются): %s",
+			strings.Join(inventory.Unregistered, ", "))
+	}
+	if len(inventory.Missing) > 0 {
+		log.Printf("Базы данных зарегистрированных компаний отсутствуют на сервере: %s",
+			strings.Join(inventory.Missing, ", "))
 	}
-
-	// Ожидаем завершения всех горутин
-	wg.Wait()
-
-	log.Println("Миграционные обновления завершены для всех баз данных.")
-	log.Printf("Проверено и обновлено %s баз данных", strconv.Itoa(counter))
 }
 
 func main() {
+	healthcheck := flag.Bool("healthcheck", false, "проверить состояние запущенного сервиса и завершиться (healthcheck docker-compose)")
+	flag.Parse()
+	if *healthcheck {
+		if err := lifecycle.ProbeHealth("localhost:8081", utils.ClientCACertFile, utils.ServerCertFile, utils.ServerKeyFile); err != nil {
+			log.Fatalf("Сервис не готов: %v", err)
+		}
+		return
+	}
+
+	// Остановка по SIGINT и SIGTERM: новые запросы не принимаются, текущие завершаются в течение
+	// DRAIN_TIMEOUT, затем останавливаются фоновые задачи и закрываются соединения с базами данных
+	ctx, stop := lifecycle.ShutdownContext()
+	defer stop()
+
 	// Инициализация пула сервера
 	serverPoll := utils.NewMapConnectionsDB()
 
@@ -193,7 +214,35 @@ func main() {
 		log.Fatal("Ошибка при инициализации первичной БД")
 	}
 
-	fullCompaniesMigrations()
+	// База-шаблон мигрируется вместе с базами компаний, из неё создаются базы новых компаний
+	if err := provisioning.EnsureTemplate(); err != nil {
+		log.Fatalf("Ошибка создания базы-шаблона компаний: %v", err)
+	}
+
+	// Управление миграциями баз компаний, результаты сохраняются в историю базы авторизации
+	migrationPlane, err := migrations.NewControlPlane(os.Getenv("MIGRATION_COMPANYDB_PATH"), provisioning.TemplateName(), func() (*sql.DB, error) {
+		return serverPoll.GetDb(os.Getenv("DB_AUTH_NAME"))
+	})
+	if err != nil {
+		log.Fatalf("Ошибка чтения миграций компаний: %v", err)
+	}
+
+	fullCompaniesMigrations(ctx, migrationPlane)
+	if ctx.Err() != nil {
+		log.Printf("Получен сигнал остановки во время миграций, сервер не запускается")
+		if err := serverPoll.CloseAllDatabases(); err != nil {
+			log.Printf("Не удалось закрыть базы данных: %v", err)
+		}
+		return
+	}
+
+	// Периодически закрываем соединения с базами компаний, к которым давно не было запросов
+	stopIdleEviction := serverPoll.StartIdleEviction(time.Minute)
+	defer stopIdleEviction()
+
+	// Раз в час безвозвратно удаляем компании, срок восстановления которых истёк
+	stopPurge := offboarding.StartPurge(serverPoll, time.Hour)
+	defer stopPurge()
 
 	// Откладываем закрытие всех баз данных до завершения работы программы
 	defer func() {
@@ -214,6 +263,12 @@ func main() {
 	if err != nil {
 		log.Fatalf("Невозможно загрузить учетные данные TLS: %s", err)
 	}
+	// Публичные ключи для проверки JWT токенов пользователей и сервисов, ключ выбирается по kid токена
+	verificationKeys, err := utils.LoadKeySet()
+	if err != nil {
+		log.Fatalf("Невозможно загрузить публичные ключи JWT: %s", err)
+	}
+
 	opts = []grpc.ServerOption{
 		grpc.Creds(tlsCredentials), // Добавление TLS опций
 		grpc.KeepaliveParams(keepalive.ServerParameters{
@@ -222,7 +277,13 @@ func main() {
 			MaxConnectionAgeGrace: 5 * time.Minute,
 			Time:                  5 * time.Second, // Таймаут на соединение
 		}),
-		grpc.UnaryInterceptor(utils.RecoveryInterceptor),
+		// Данные пользователя (база компании, id, роль) берутся только из проверенного токена,
+		// запросы к приостановленным и удалённым компаниям отклоняются по реестру компаний
+		grpc.ChainUnaryInterceptor(
+			utils.RecoveryInterceptor,
+			utils.NewIdentityInterceptor(verificationKeys, utils.ReportCrossTenantAccess),
+			utils.NewTenantInterceptor(serverPoll.Tenants()),
+		),
 	}
 
 	// Создаем новый gRPC сервер
@@ -231,8 +292,13 @@ func main() {
 	// Включаем отражение для gRPC сервера
 	reflection.Register(grpcServer)
 
+	// Базы данных зарегистрированных компаний создаются в фоне, в том числе оставшиеся после перезапуска
+	provisioner := provisioning.NewProvisioner(serverPoll)
+	stopProvisioner := provisioner.Start(2)
+	defer stopProvisioner()
+
 	// Регистрируем AuthService с привязкой к общему переданному пулу соединений
-	authService := dbauthservice.NewGRPCDBAuthService(serverPoll)
+	authService := dbauthservice.NewGRPCDBAuthService(serverPoll, provisioner)
 	pbAuth.RegisterDbAuthServiceServer(grpcServer, authService)
 
 	// Регистрируем TimerService с привязкой к общему переданному пулу соединений
@@ -247,10 +313,41 @@ func main() {
 	adminService := dbadminservice.NewGRPCDBAdminService(serverPoll)
 	pbAdmin.RegisterDbAdminServiceServer(grpcServer, adminService)
 
+	// Регистрируем MigrationService для управления миграциями баз компаний
+	migrationService := dbmigrationservice.NewGRPCDBMigrationService(migrationPlane)
+	pbMigration.RegisterDbMigrationServiceServer(grpcServer, migrationService)
+
+	// Состояние сервера для healthcheck docker-compose: сервер запускается только после миграций,
+	// а готовность к работе определяется доступностью базы данных авторизации
+	stopHealth := lifecycle.StartHealthServer(grpcServer, 10*time.Second, lifecycle.HealthCheck{
+		Name: "postgres",
+		Check: func(ctx context.Context) error {
+			authDB, err := serverPoll.GetDb(os.Getenv("DB_AUTH_NAME"))
+			if err != nil {
+				return err
+			}
+			if !isDatabaseReady(ctx, authDB) {
+				return fmt.Errorf("база данных авторизации не готова")
+			}
+			return nil
+		},
+	})
+
 	log.Printf("gRPC сервер запущен на %s с TLS", ":8081")
 
 	// Запуск сервера
-	if err := grpcServer.Serve(lis); err != nil {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- grpcServer.Serve(lis)
+	}()
+
+	select {
+	case err := <-serveErr:
 		log.Fatalf("Ошибка запуска gRPC сервера: %v", err)
+	case <-ctx.Done():
 	}
+
+	log.Printf("Получен сигнал остановки, завершаем текущие запросы")
+	stopHealth()
+	lifecycle.StopGRPCServer(grpcServer, lifecycle.DrainTimeout())
 }
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS companies_provisioning_pending_idx;
+ALTER TABLE companies DROP COLUMN IF EXISTS provisioning_updatedAt;
+ALTER TABLE companies DROP COLUMN IF EXISTS provisioning_error;
+ALTER TABLE companies DROP COLUMN IF EXISTS provisioning_attempts;
+ALTER TABLE companies DROP COLUMN IF EXISTS provisioning_id;
+ALTER TABLE companies DROP COLUMN IF EXISTS provisioning_status;
@@ -0,0 +1,13 @@
+-- Создание базы данных компании выполняется в фоне после регистрации:
+-- provisioning_status: pending - база создаётся, ready - база готова, failed - все попытки создания неудачны;
+-- provisioning_id - случайный идентификатор, по которому клиент опрашивает состояние после регистрации;
+-- provisioning_attempts и provisioning_error - число неудачных попыток и последняя ошибка.
+-- Базы существующих компаний уже созданы.
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS provisioning_status VARCHAR(20) NOT NULL DEFAULT 'ready'
+    CHECK (provisioning_status IN ('pending', 'ready', 'failed'));
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS provisioning_id VARCHAR(64) UNIQUE;
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS provisioning_attempts INT NOT NULL DEFAULT 0;
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS provisioning_error TEXT;
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS provisioning_updatedAt TIMESTAMPTZ NOT NULL DEFAULT NOW();
+CREATE INDEX IF NOT EXISTS companies_provisioning_pending_idx ON companies (provisioning_status)
+    WHERE provisioning_status = 'pending';
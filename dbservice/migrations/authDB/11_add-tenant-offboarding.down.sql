@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS tenantAudit;
+DROP INDEX IF EXISTS companies_delete_after_idx;
+ALTER TABLE companies DROP COLUMN IF EXISTS delete_after;
+ALTER TABLE companies DROP COLUMN IF EXISTS status_reason;
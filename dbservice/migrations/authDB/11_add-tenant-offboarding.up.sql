@@ -0,0 +1,21 @@
+-- Отключение компаний: status_reason - причина приостановки или удаления,
+-- delete_after - время, после которого база данных и записи удалённой компании удаляются безвозвратно.
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS status_reason TEXT;
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS delete_after TIMESTAMPTZ;
+CREATE INDEX IF NOT EXISTS companies_delete_after_idx ON companies (delete_after)
+    WHERE status = 'deleted';
+
+-- Журнал операций с компаниями. Внешнего ключа на companies нет:
+-- записи остаются после безвозвратного удаления компании.
+CREATE TABLE IF NOT EXISTS tenantAudit
+(
+    id           SERIAL PRIMARY KEY,
+    company_id   INT          NOT NULL,
+    company_name VARCHAR(100) NOT NULL,
+    dbName       VARCHAR(100) NOT NULL,
+    action       VARCHAR(32)  NOT NULL, -- suspended, resumed, exported, deletion_scheduled, purged
+    actor        VARCHAR(255) NOT NULL, -- Кто выполнил операцию, system для фонового удаления
+    details      TEXT,
+    createdAt    TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS tenantAudit_company_idx ON tenantAudit (company_id);
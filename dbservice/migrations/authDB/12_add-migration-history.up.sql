@@ -0,0 +1,17 @@
+-- История миграций баз данных компаний. run_id объединяет базы одного запуска,
+-- версия 0 означает, что миграции к базе не применялись.
+CREATE TABLE IF NOT EXISTS migrationHistory
+(
+    id           SERIAL PRIMARY KEY,
+    run_id       VARCHAR(32)  NOT NULL,
+    dbName       VARCHAR(100) NOT NULL,
+    operation    VARCHAR(20)  NOT NULL, -- up, migrate или rollback
+    from_version BIGINT       NOT NULL,
+    to_version   BIGINT       NOT NULL,
+    status       VARCHAR(20)  NOT NULL, -- applied, up_to_date, failed
+    error        TEXT,
+    actor        VARCHAR(255) NOT NULL,
+    startedAt    TIMESTAMPTZ  NOT NULL,
+    finishedAt   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS migrationHistory_dbName_idx ON migrationHistory (dbName, id);
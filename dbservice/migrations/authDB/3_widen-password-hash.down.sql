@@ -0,0 +1 @@
+ALTER TABLE authUsers ALTER COLUMN password TYPE VARCHAR(100);
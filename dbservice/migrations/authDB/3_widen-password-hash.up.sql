@@ -0,0 +1,2 @@
+-- Хэш Argon2id в формате PHC не помещается в VARCHAR(100), расширяем колонку
+ALTER TABLE authUsers ALTER COLUMN password TYPE VARCHAR(255);
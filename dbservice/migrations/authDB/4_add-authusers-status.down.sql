@@ -0,0 +1 @@
+ALTER TABLE authUsers DROP COLUMN IF EXISTS status;
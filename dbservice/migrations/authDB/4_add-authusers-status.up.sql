@@ -0,0 +1,8 @@
+-- Статус учётной записи:
+-- unverified - email не подтверждён после регистрации компании;
+-- invited - пользователь приглашён администратором и ещё не задал пароль;
+-- verified - учётная запись активирована, вход разрешён.
+-- Существующие записи считаются подтверждёнными, новые по умолчанию создаются неподтверждёнными.
+ALTER TABLE authUsers ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'verified'
+    CHECK (status IN ('unverified', 'verified', 'invited'));
+ALTER TABLE authUsers ALTER COLUMN status SET DEFAULT 'unverified';
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS mfaRecoveryCodes;
+ALTER TABLE companies DROP COLUMN IF EXISTS require_mfa;
+ALTER TABLE authUsers DROP COLUMN IF EXISTS totp_last_step;
+ALTER TABLE authUsers DROP COLUMN IF EXISTS totp_enabled;
+ALTER TABLE authUsers DROP COLUMN IF EXISTS totp_secret;
@@ -0,0 +1,21 @@
+-- Двухфакторная аутентификация (TOTP, RFC 6238):
+-- totp_secret - секрет TOTP, зашифрованный ключом MFA_ENCRYPTION_KEY;
+-- totp_enabled - второй фактор подтверждён и обязателен при входе;
+-- totp_last_step - последний принятый временной шаг, повторно тот же код не принимается.
+ALTER TABLE authUsers ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(255);
+ALTER TABLE authUsers ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE authUsers ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+-- Администратор компании может обязать всех пользователей использовать второй фактор
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS require_mfa BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Одноразовые коды восстановления, хранится только SHA-256 хэш кода
+CREATE TABLE IF NOT EXISTS mfaRecoveryCodes
+(
+    id           SERIAL PRIMARY KEY,
+    auth_user_id INT      NOT NULL,
+    code_hash    CHAR(64) NOT NULL,
+    used_at      TIMESTAMPTZ,                  -- Время использования, NULL - код ещё действителен
+    FOREIGN KEY (auth_user_id) REFERENCES authUsers(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS mfaRecoveryCodes_auth_user_id_idx ON mfaRecoveryCodes (auth_user_id);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS authUsers_company_oidc_subject_idx;
+ALTER TABLE authUsers DROP COLUMN IF EXISTS oidc_subject;
+DROP TABLE IF EXISTS companyOidcDomains;
+DROP TABLE IF EXISTS companyOidc;
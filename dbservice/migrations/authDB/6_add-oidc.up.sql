@@ -0,0 +1,28 @@
+-- Вход через корпоративного OpenID Connect провайдера компании:
+-- issuer - адрес провайдера, по нему загружается /.well-known/openid-configuration;
+-- client_secret - секрет клиента, зашифрованный ключом SECRETS_ENCRYPTION_KEY;
+-- enabled - вход через провайдера разрешён.
+CREATE TABLE IF NOT EXISTS companyOidc
+(
+    company_id    INT          PRIMARY KEY,
+    issuer        VARCHAR(255) NOT NULL,
+    client_id     VARCHAR(255) NOT NULL,
+    client_secret TEXT         NOT NULL,
+    enabled       BOOLEAN      NOT NULL DEFAULT TRUE,
+    updatedAt     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE
+);
+
+-- Почтовые домены компании: по домену email пользователя выбирается провайдер.
+-- Один домен может принадлежать только одной компании.
+CREATE TABLE IF NOT EXISTS companyOidcDomains
+(
+    domain     VARCHAR(255) PRIMARY KEY,
+    company_id INT          NOT NULL,
+    FOREIGN KEY (company_id) REFERENCES companyOidc(company_id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS companyOidcDomains_company_id_idx ON companyOidcDomains (company_id);
+
+-- Идентификатор пользователя у провайдера (claim sub), связывается при первом входе
+ALTER TABLE authUsers ADD COLUMN IF NOT EXISTS oidc_subject VARCHAR(255);
+CREATE UNIQUE INDEX IF NOT EXISTS authUsers_company_oidc_subject_idx ON authUsers (company_id, oidc_subject);
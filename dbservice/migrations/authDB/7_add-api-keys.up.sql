@@ -0,0 +1,24 @@
+-- API ключи для доступа интеграций и скриптов без cookie сессии:
+-- prefix - начало ключа для отображения пользователю, сам ключ не хранится;
+-- key_hash - SHA-256 хэш ключа;
+-- scopes - области доступа через пробел (chats:read, timers:write, admin:read ...);
+-- expires_at - время окончания действия, NULL - ключ бессрочный;
+-- revoked_at - время отзыва, отозванный ключ не принимается.
+CREATE TABLE IF NOT EXISTS apiKeys
+(
+    id           SERIAL PRIMARY KEY,
+    auth_user_id INT          NOT NULL,
+    company_id   INT          NOT NULL,
+    name         VARCHAR(100) NOT NULL,
+    prefix       VARCHAR(20)  NOT NULL,
+    key_hash     CHAR(64)     NOT NULL UNIQUE,
+    scopes       VARCHAR(255) NOT NULL,
+    expires_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ,
+    FOREIGN KEY (auth_user_id) REFERENCES authUsers(id) ON DELETE CASCADE,
+    FOREIGN KEY (company_id) REFERENCES companies(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS apiKeys_auth_user_id_idx ON apiKeys (auth_user_id);
+CREATE INDEX IF NOT EXISTS apiKeys_company_id_idx ON apiKeys (company_id);
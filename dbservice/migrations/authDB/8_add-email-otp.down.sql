@@ -0,0 +1 @@
+ALTER TABLE companies DROP COLUMN IF EXISTS allow_email_otp;
@@ -0,0 +1,2 @@
+-- Администратор компании может разрешить вход без пароля по одноразовому коду из письма
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS allow_email_otp BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS companies_status_idx;
+ALTER TABLE companies DROP COLUMN IF EXISTS createdAt;
+ALTER TABLE companies DROP COLUMN IF EXISTS plan;
+ALTER TABLE companies DROP COLUMN IF EXISTS status;
@@ -0,0 +1,7 @@
+-- Реестр компаний (арендаторов): состояние, тарифный план и дата создания.
+-- status: active - компания работает, suspended - вход и запросы заблокированы, deleted - компания удалена.
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'active'
+    CHECK (status IN ('active', 'suspended', 'deleted'));
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS plan VARCHAR(50) NOT NULL DEFAULT 'standard';
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS createdAt TIMESTAMPTZ NOT NULL DEFAULT NOW();
+CREATE INDEX IF NOT EXISTS companies_status_idx ON companies (status);
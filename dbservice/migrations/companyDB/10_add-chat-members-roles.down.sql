@@ -0,0 +1,5 @@
+ALTER TABLE available_actions_chat
+    DROP COLUMN IF EXISTS add_members,
+    DROP COLUMN IF EXISTS remove_members;
+
+ALTER TABLE chat_users DROP COLUMN IF EXISTS role_id;
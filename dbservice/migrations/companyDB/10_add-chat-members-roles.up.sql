@@ -0,0 +1,27 @@
+-- Роль участника в чате и права роли на управление участниками
+ALTER TABLE chat_users
+    ADD COLUMN IF NOT EXISTS role_id INTEGER REFERENCES chat_roles(id) ON DELETE SET NULL; -- Роль участника, NULL - без роли
+
+ALTER TABLE available_actions_chat
+    ADD COLUMN IF NOT EXISTS add_members    BOOLEAN DEFAULT FALSE, -- Определяет возможность добавления участников
+    ADD COLUMN IF NOT EXISTS remove_members BOOLEAN DEFAULT FALSE; -- Определяет возможность удаления участников
+
+-- Роли по умолчанию для существующих чатов: admin_chat управляет участниками и ролями, user_chat - обычный участник
+INSERT INTO chat_roles (chat_id, name_role, removable)
+SELECT c.id, r.name_role, FALSE
+FROM chats c
+CROSS JOIN (VALUES ('admin_chat'), ('user_chat')) AS r(name_role)
+ON CONFLICT (chat_id, name_role) DO NOTHING;
+
+INSERT INTO available_actions_chat (role_id, create_role, add_members, remove_members)
+SELECT id, name_role = 'admin_chat', name_role = 'admin_chat', name_role = 'admin_chat'
+FROM chat_roles
+WHERE name_role IN ('admin_chat', 'user_chat')
+ON CONFLICT (role_id) DO UPDATE SET add_members    = EXCLUDED.add_members,
+                                    remove_members = EXCLUDED.remove_members;
+
+-- До появления ролей участники чатов не различались по правам, поэтому существующие участники получают admin_chat
+UPDATE chat_users cu
+SET role_id = cr.id
+FROM chat_roles cr
+WHERE cr.chat_id = cu.chat_id AND cr.name_role = 'admin_chat' AND cu.role_id IS NULL;
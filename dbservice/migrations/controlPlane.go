@@ -0,0 +1,608 @@
+package migrations
+
+import (
+	"context"
+	"crmSystem/utils"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+)
+
+// Операции миграции базы компании.
+const (
+	OperationUp       = "up"       // До последней версии при миграции всех баз
+	OperationMigrate  = "migrate"  // До указанной версии
+	OperationRollback = "rollback" // Откат до указанной версии
+)
+
+// Результаты миграции базы компании.
+const (
+	StatusApplied  = "applied"    // Миграции применены
+	StatusUpToDate = "up_to_date" // База уже на нужной версии
+	StatusPlanned  = "planned"    // dry-run: миграции будут применены
+	StatusFailed   = "failed"
+	StatusSkipped  = "skipped" // База не мигрировалась: запуск остановлен после ошибки
+)
+
+// StartupActor исполнитель миграций, выполняемых при запуске dbservice.
+const StartupActor = "startup"
+
+// defaultConcurrency число баз, мигрируемых одновременно, если MIGRATION_CONCURRENCY не задана.
+const defaultConcurrency = 4
+
+// defaultHistoryLimit число записей истории, возвращаемых по умолчанию.
+const defaultHistoryLimit = 100
+
+var (
+	// ErrDirty возвращается для базы, миграция которой была прервана. Автоматически такая база
+	// не исправляется: оператор проверяет её и повторяет миграцию с force.
+	ErrDirty = errors.New("миграция базы была прервана (dirty), проверьте базу и повторите миграцию с force")
+
+	// ErrUnknownVersion возвращается, если указанной версии нет среди миграций компаний.
+	ErrUnknownVersion = errors.New("версия миграции не найдена")
+
+	// ErrUnknownDatabase возвращается, если база не входит в список мигрируемых баз компаний.
+	ErrUnknownDatabase = errors.New("база данных не найдена среди баз зарегистрированных компаний")
+
+	// ErrActorRequired возвращается, если не указано, кто запускает миграции.
+	ErrActorRequired = errors.New("не указан исполнитель миграции")
+)
+
+// Migrator миграции одной базы данных.
+type Migrator interface {
+	// Version возвращает текущую версию и признак прерванной миграции, 0 - миграции не применялись.
+	Version() (version uint, dirty bool, err error)
+	// Migrate применяет или откатывает миграции до версии version.
+	Migrate(version uint) error
+	// Force устанавливает версию без выполнения миграций и снимает признак dirty, -1 - миграции не применялись.
+	Force(version int) error
+	Close() error
+}
+
+// ControlPlaneConfig источники данных для управления миграциями. Пустые поля заполняет NewControlPlane.
+type ControlPlaneConfig struct {
+	Versions        []uint                                              // Доступные версии миграций по возрастанию
+	Template        string                                              // База-шаблон, мигрируется вместе с базами компаний
+	Registered      func(ctx context.Context) ([]TenantDatabase, error) // Базы компаний из реестра companies
+	ServerDatabases func(ctx context.Context) ([]string, error)         // Базы, существующие на сервере PostgreSQL
+	Open            func(dbName string) (Migrator, error)
+	AuthDb          func() (*sql.DB, error) // База авторизации для истории миграций, nil - история не сохраняется
+}
+
+// ControlPlane управляет миграциями баз данных компаний: показывает версии баз, мигрирует
+// все базы с ограничением параллельности, мигрирует или откатывает одну базу и сохраняет
+// результаты в историю migrationHistory базы авторизации.
+type ControlPlane struct {
+	config ControlPlaneConfig
+	latest uint
+}
+
+// TenantStatus версия схемы базы компании.
+type TenantStatus struct {
+	DbName  string
+	Version uint
+	Dirty   bool
+	Error   string
+}
+
+// Result результат миграции одной базы компании.
+type Result struct {
+	DbName      string
+	Operation   string
+	FromVersion uint
+	ToVersion   uint
+	Pending     []uint // Миграции в порядке выполнения
+	Status      string
+	Error       string
+}
+
+// RunOptions параметры миграции всех баз компаний.
+type RunOptions struct {
+	Concurrency int  // Число баз, мигрируемых одновременно
+	DryRun      bool // Только отчёт: базы не изменяются и мигрируются все, без остановки после ошибки
+	Actor       string
+}
+
+// RunReport результат миграции всех баз компаний.
+type RunReport struct {
+	RunId   string
+	Results []Result
+	Stopped bool // Миграции остановлены после первой ошибки, оставшиеся базы пропущены
+}
+
+// TenantOptions параметры миграции одной базы компании.
+type TenantOptions struct {
+	DryRun bool
+	Force  bool // Прерванная миграция считается не применённой и выполняется повторно
+	Actor  string
+}
+
+// HistoryEntry запись истории миграций.
+type HistoryEntry struct {
+	RunId       string
+	DbName      string
+	Operation   string
+	FromVersion uint
+	ToVersion   uint
+	Status      string
+	Error       string
+	Actor       string
+	StartedAt   time.Time
+	FinishedAt  time.Time
+}
+
+// NewControlPlane создаёт управление миграциями баз компаний из каталога migratePath.
+// Мигрируются шаблон template и базы компаний, зарегистрированных в базе авторизации authDb,
+// туда же сохраняется история миграций.
+func NewControlPlane(migratePath string, template string, authDb func() (*sql.DB, error)) (*ControlPlane, error) {
+	versions, err := LoadVersions(migratePath)
+	if err != nil {
+		return nil, err
+	}
+	return NewControlPlaneWithConfig(ControlPlaneConfig{
+		Versions: versions,
+		Template: template,
+		Registered: func(ctx context.Context) ([]TenantDatabase, error) {
+			db, err := authDb()
+			if err != nil {
+				return nil, fmt.Errorf("ошибка подключения к базе данных авторизации: %w", err)
+			}
+			return RegisteredDatabases(ctx, db)
+		},
+		ServerDatabases: serverDatabases,
+		Open:            openMigrator(migratePath),
+		AuthDb:          authDb,
+	}), nil
+}
+
+// NewControlPlaneWithConfig создаёт управление миграциями с указанными источниками данных.
+func NewControlPlaneWithConfig(config ControlPlaneConfig) *ControlPlane {
+	versions := append([]uint(nil), config.Versions...)
+	sort.Slice(versions, func(i, j int) bool { return versions[i] < versions[j] })
+	config.Versions = versions
+
+	plane := &ControlPlane{config: config}
+	if len(versions) > 0 {
+		plane.latest = versions[len(versions)-1]
+	}
+	return plane
+}
+
+// LatestVersion возвращает последнюю доступную версию миграций.
+func (p *ControlPlane) LatestVersion() uint {
+	return p.latest
+}
+
+// Status возвращает версии всех баз компаний, упорядоченные по имени базы.
+func (p *ControlPlane) Status(ctx context.Context, concurrency int) ([]TenantStatus, error) {
+	dbNames, err := p.databases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]TenantStatus, len(dbNames))
+	forEach(ctx, dbNames, concurrency, func(i int, dbName string) bool {
+		statuses[i] = p.status(dbName)
+		return true
+	})
+	return statuses, nil
+}
+
+func (p *ControlPlane) status(dbName string) TenantStatus {
+	status := TenantStatus{DbName: dbName}
+	m, err := p.config.Open(dbName)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	defer closeMigrator(dbName, m)
+
+	status.Version, status.Dirty, err = m.Version()
+	if err != nil {
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// Run мигрирует все базы компаний до последней версии, одновременно не более opts.Concurrency баз.
+// После первой ошибки новые базы не мигрируются, а уже начатые миграции завершаются.
+func (p *ControlPlane) Run(ctx context.Context, opts RunOptions) (*RunReport, error) {
+	if opts.Actor == "" {
+		return nil, ErrActorRequired
+	}
+	dbNames, err := p.databases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RunReport{RunId: newRunId(), Results: make([]Result, len(dbNames))}
+	for i, dbName := range dbNames {
+		report.Results[i] = Result{DbName: dbName, Operation: OperationUp, Status: StatusSkipped}
+	}
+
+	var failed atomic.Bool
+	forEach(ctx, dbNames, opts.Concurrency, func(i int, dbName string) bool {
+		if failed.Load() {
+			return false
+		}
+		startedAt := time.Now()
+		result := p.migrate(dbName, p.latest, OperationUp, opts.DryRun, false)
+		report.Results[i] = result
+		if !opts.DryRun {
+			p.record(ctx, report.RunId, result, opts.Actor, startedAt)
+		}
+		if result.Status == StatusFailed && !opts.DryRun {
+			failed.Store(true)
+			return false
+		}
+		return true
+	})
+
+	report.Stopped = failed.Load()
+	return report, nil
+}
+
+// MigrateTenant мигрирует базу компании dbName до версии target (0 - последняя версия).
+// Версия ниже текущей откатывает миграции. Ошибка возвращается только для неверных параметров,
+// ошибка самой миграции возвращается в результате со статусом failed.
+func (p *ControlPlane) MigrateTenant(ctx context.Context, dbName string, target uint, opts TenantOptions) (*Result, error) {
+	if opts.Actor == "" {
+		return nil, ErrActorRequired
+	}
+	if target == 0 {
+		target = p.latest
+	} else if !p.hasVersion(target) {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownVersion, target)
+	}
+
+	dbNames, err := p.databases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if i := sort.SearchStrings(dbNames, dbName); i == len(dbNames) || dbNames[i] != dbName {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDatabase, dbName)
+	}
+
+	startedAt := time.Now()
+	result := p.migrate(dbName, target, "", opts.DryRun, opts.Force)
+	if !opts.DryRun {
+		p.record(ctx, newRunId(), result, opts.Actor, startedAt)
+	}
+	return &result, nil
+}
+
+// migrate мигрирует одну базу до версии target. Пустая operation определяется по направлению миграции.
+func (p *ControlPlane) migrate(dbName string, target uint, operation string, dryRun bool, force bool) Result {
+	result := Result{DbName: dbName, Operation: operation, ToVersion: target}
+	fail := func(err error) Result {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+		log.Printf("Ошибка миграции базы данных %s: %v", dbName, err)
+		return result
+	}
+
+	m, err := p.config.Open(dbName)
+	if err != nil {
+		return fail(err)
+	}
+	defer closeMigrator(dbName, m)
+
+	from, dirty, err := m.Version()
+	if err != nil {
+		return fail(fmt.Errorf("ошибка получения версии: %w", err))
+	}
+	result.FromVersion = from
+	if result.Operation == "" {
+		result.Operation = OperationMigrate
+		if target < from {
+			result.Operation = OperationRollback
+		}
+	}
+
+	forced := false
+	if dirty {
+		if !force {
+			return fail(fmt.Errorf("%w: версия %d", ErrDirty, from))
+		}
+		// Прерванная миграция from считается не применённой и будет выполнена повторно
+		from = p.previousVersion(from)
+		if !dryRun {
+			if err := m.Force(forceVersion(from)); err != nil {
+				return fail(fmt.Errorf("ошибка сброса прерванной миграции: %w", err))
+			}
+			forced = true
+		}
+	}
+
+	result.Pending = p.plan(from, target)
+	switch {
+	case len(result.Pending) == 0 && !forced:
+		result.Status = StatusUpToDate
+		return result
+	case dryRun:
+		result.Status = StatusPlanned
+		return result
+	}
+
+	if err := m.Migrate(target); err != nil {
+		return fail(err)
+	}
+	result.Status = StatusApplied
+	return result
+}
+
+// History возвращает последние записи истории миграций базы dbName или всех баз, если dbName пустое.
+func (p *ControlPlane) History(ctx context.Context, dbName string, limit int) ([]HistoryEntry, error) {
+	if p.config.AuthDb == nil {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	db, err := p.config.AuthDb()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подключения к базе данных авторизации: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT run_id, dbName, operation, from_version, to_version, status, COALESCE(error, ''), actor, startedAt, finishedAt
+		FROM migrationHistory WHERE $1 = '' OR dbName = $1 ORDER BY id DESC LIMIT $2`,
+		dbName, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения истории миграций: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []HistoryEntry
+	for rows.Next() {
+		var e HistoryEntry
+		if err := rows.Scan(&e.RunId, &e.DbName, &e.Operation, &e.FromVersion, &e.ToVersion, &e.Status, &e.Error,
+			&e.Actor, &e.StartedAt, &e.FinishedAt); err != nil {
+			return nil, fmt.Errorf("ошибка получения истории миграций: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// record сохраняет результат миграции в историю. Пропущенные базы не сохраняются.
+// Ошибка сохранения не отменяет миграцию и только пишется в лог.
+func (p *ControlPlane) record(ctx context.Context, runId string, result Result, actor string, startedAt time.Time) {
+	if p.config.AuthDb == nil || result.Status == StatusSkipped {
+		return
+	}
+	db, err := p.config.AuthDb()
+	if err != nil {
+		log.Printf("Ошибка подключения к базе данных авторизации: %v", err)
+		return
+	}
+
+	_, err = db.ExecContext(ctx,
+		`INSERT INTO migrationHistory (run_id, dbName, operation, from_version, to_version, status, error, actor, startedAt)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9)`,
+		runId, result.DbName, result.Operation, int64(result.FromVersion), int64(result.ToVersion),
+		result.Status, result.Error, actor, startedAt)
+	if err != nil {
+		log.Printf("Ошибка сохранения истории миграции базы данных %s: %v", result.DbName, err)
+	}
+}
+
+// Inventory сопоставляет базы сервера PostgreSQL с реестром компаний: возвращает мигрируемые базы,
+// незарегистрированные базы в формате баз компаний и отсутствующие базы зарегистрированных компаний.
+func (p *ControlPlane) Inventory(ctx context.Context) (*Inventory, error) {
+	registered, err := p.config.Registered(ctx)
+	if err != nil {
+		return nil, err
+	}
+	server, err := p.config.ServerDatabases(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewInventory(server, registered, p.config.Template), nil
+}
+
+// databases возвращает мигрируемые базы, упорядоченные по имени.
+func (p *ControlPlane) databases(ctx context.Context) ([]string, error) {
+	inventory, err := p.Inventory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return inventory.Databases, nil
+}
+
+// plan возвращает миграции, выполняемые при переходе с версии from на версию to, в порядке выполнения.
+func (p *ControlPlane) plan(from uint, to uint) []uint {
+	var pending []uint
+	if to >= from {
+		for _, v := range p.config.Versions {
+			if v > from && v <= to {
+				pending = append(pending, v)
+			}
+		}
+		return pending
+	}
+	for i := len(p.config.Versions) - 1; i >= 0; i-- {
+		if v := p.config.Versions[i]; v <= from && v > to {
+			pending = append(pending, v)
+		}
+	}
+	return pending
+}
+
+// previousVersion возвращает версию, предшествующую version, или 0.
+func (p *ControlPlane) previousVersion(version uint) uint {
+	var previous uint
+	for _, v := range p.config.Versions {
+		if v >= version {
+			break
+		}
+		previous = v
+	}
+	return previous
+}
+
+func (p *ControlPlane) hasVersion(version uint) bool {
+	i := sort.Search(len(p.config.Versions), func(i int) bool { return p.config.Versions[i] >= version })
+	return i < len(p.config.Versions) && p.config.Versions[i] == version
+}
+
+// forEach вызывает fn для каждой базы, одновременно не более concurrency вызовов.
+// Если fn возвращает false или контекст отменён, новые вызовы не начинаются.
+func forEach(ctx context.Context, dbNames []string, concurrency int, fn func(i int, dbName string) bool) {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	var stop atomic.Bool
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, dbName := range dbNames {
+		sem <- struct{}{}
+		if stop.Load() || ctx.Err() != nil {
+			<-sem
+			break
+		}
+		wg.Add(1)
+		go func(i int, dbName string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if !fn(i, dbName) {
+				stop.Store(true)
+			}
+		}(i, dbName)
+	}
+	wg.Wait()
+}
+
+// ConcurrencyFromEnv возвращает число баз, мигрируемых одновременно, из переменной MIGRATION_CONCURRENCY.
+func ConcurrencyFromEnv() int {
+	if value, err := strconv.Atoi(os.Getenv("MIGRATION_CONCURRENCY")); err == nil && value > 0 {
+		return value
+	}
+	return defaultConcurrency
+}
+
+// LoadVersions возвращает версии миграций из каталога migratePath по возрастанию.
+func LoadVersions(migratePath string) ([]uint, error) {
+	src, err := source.Open(migratePath)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения миграций %s: %w", migratePath, err)
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения миграций %s: %w", migratePath, err)
+	}
+
+	versions := []uint{version}
+	for {
+		version, err = src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return versions, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ошибка чтения миграций %s: %w", migratePath, err)
+		}
+		versions = append(versions, version)
+	}
+}
+
+// serverDatabases возвращает базы сервера PostgreSQL.
+func serverDatabases(ctx context.Context) ([]string, error) {
+	db, err := sql.Open("postgres", utils.DsnString("postgres"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подключения к базе данных postgres: %w", err)
+	}
+	defer db.Close()
+	return ServerDatabases(ctx, db)
+}
+
+// openMigrator открывает миграции базы компании из каталога migratePath.
+func openMigrator(migratePath string) func(dbName string) (Migrator, error) {
+	return func(dbName string) (Migrator, error) {
+		db, err := sql.Open("postgres", utils.DsnString(dbName))
+		if err != nil {
+			return nil, fmt.Errorf("ошибка подключения к базе данных %s: %w", dbName, err)
+		}
+		driver, err := postgres.WithInstance(db, &postgres.Config{})
+		if err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("ошибка создания инстанса миграции для %s: %w", dbName, err)
+		}
+		m, err := migrate.NewWithDatabaseInstance(migratePath, dbName, driver)
+		if err != nil {
+			_ = driver.Close()
+			return nil, fmt.Errorf("ошибка создания миграции базы данных %s: %w", dbName, err)
+		}
+		return &migrator{m: m}, nil
+	}
+}
+
+// migrator Migrator на основе golang-migrate.
+type migrator struct {
+	m *migrate.Migrate
+}
+
+func (m *migrator) Version() (uint, bool, error) {
+	version, dirty, err := m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+func (m *migrator) Migrate(version uint) error {
+	if err := m.m.Migrate(version); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+func (m *migrator) Force(version int) error {
+	return m.m.Force(version)
+}
+
+func (m *migrator) Close() error {
+	sourceErr, dbErr := m.m.Close()
+	return errors.Join(sourceErr, dbErr)
+}
+
+func closeMigrator(dbName string, m Migrator) {
+	if err := m.Close(); err != nil {
+		log.Printf("Ошибка закрытия соединения с базой данных %s: %v", dbName, err)
+	}
+}
+
+// forceVersion приводит версию к аргументу Force: -1 означает, что миграции не применялись.
+func forceVersion(version uint) int {
+	if version == 0 {
+		return -1
+	}
+	return int(version)
+}
+
+// newRunId создаёт идентификатор запуска миграций.
+func newRunId() string {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(buf)
+}
@@ -12,7 +12,6 @@ import (
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"log"
-	"sync"
 )
 
 func Migration(db *sql.DB, migratePath string, dbName string) error {
@@ -149,75 +148,3 @@ func Migration(db *sql.DB, migratePath string, dbName string) error {
 
 	return nil
 }
-
-// Обертка для функции миграции по всем базам данных
-func MigrateCompanyDatabase(dbName string, migratePath string, wg *sync.WaitGroup, counter *int) {
-	defer wg.Done()
-
-	ctx := context.Background()
-
-	token, err := utils.JwtGenerate()
-	if err != nil {
-		err := status.Errorf(codes.Internal, "Не удалось создать токен ", err)
-		if err != nil {
-			log.Println(err)
-			return
-		}
-	}
-
-	// Устанавливаем соединение с gRPC сервером Logs
-	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
-	if err != nil {
-		log.Printf("Не удалось подключиться к серверу: %v", err)
-		return
-	} else {
-		defer func(conn *grpc.ClientConn) {
-			err := conn.Close()
-			if err != nil {
-				log.Printf("Ошибка закрытия соединения: %v", err)
-				errLogs := utils.SaveLogsError(ctx, clientLogs, "basemigration", "", err.Error())
-				if errLogs != nil {
-					log.Printf("Ошибка закрытия соединения: %v", err)
-				}
-			}
-		}(conn)
-	}
-
-	// Создаем DSN для подключения к базе данных
-	dsn := utils.DsnString(dbName)
-	db, err := sql.Open("postgres", dsn)
-	if err != nil {
-		log.Printf("Ошибка подключения к базе данных %s: %v", dbName, err)
-		errLogs := utils.SaveLogsError(ctx, clientLogs, "basemigration", "", err.Error())
-		if errLogs != nil {
-			log.Printf("Ошибка закрытия соединения: %v", err)
-		}
-		return
-	}
-	defer func(db *sql.DB) {
-		err := db.Close()
-		if err != nil {
-			errLogs := utils.SaveLogsError(ctx, clientLogs, "basemigration", "", err.Error())
-			if errLogs != nil {
-				log.Printf("Ошибка закрытия соединения: %v", err)
-			}
-		}
-	}(db)
-
-	// Выполняем миграцию с помощью функции Migration
-	err = Migration(db, migratePath, dbName)
-	if err != nil {
-		errLogs := utils.SaveLogsError(ctx, clientLogs, dbName, "", err.Error())
-		if errLogs != nil {
-			errLogs := utils.SaveLogsError(ctx, clientLogs, "basemigration", "", err.Error())
-			if errLogs != nil {
-				log.Printf("Ошибка закрытия соединения: %v", err)
-			}
-			log.Printf("Ошибка сохранения логов: %v", err)
-		}
-		log.Printf("Ошибка миграции базы данных %s: %v", dbName, err)
-	} else {
-		*counter++
-		//log.Printf("Миграция для базы данных %s успешно выполнена", dbName)
-	}
-}
@@ -0,0 +1,106 @@
+package migrations
+
+import (
+	"context"
+	"crmSystem/utils"
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// ServerDatabases возвращает все базы данных сервера PostgreSQL, кроме системных шаблонов.
+func ServerDatabases(ctx context.Context, db *sql.DB) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT datname FROM pg_database WHERE datistemplate = false`)
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка запроса списка баз данных: %w", err)
+	}
+	defer rows.Close()
+
+	var dbNames []string
+	for rows.Next() {
+		var dbName string
+		if err := rows.Scan(&dbName); err != nil {
+			return nil, fmt.Errorf("Ошибка при сканировании имени базы данных: %w", err)
+		}
+		dbNames = append(dbNames, dbName)
+	}
+	return dbNames, rows.Err()
+}
+
+// TenantDatabase база данных зарегистрированной компании.
+type TenantDatabase struct {
+	DbName string
+	// Ready создание базы завершено. Базы компаний, ожидающих создания, копируются из
+	// мигрированного шаблона и не мигрируются.
+	Ready bool
+}
+
+// RegisteredDatabases возвращает базы данных всех компаний, зарегистрированных в companies.
+func RegisteredDatabases(ctx context.Context, authDb *sql.DB) ([]TenantDatabase, error) {
+	rows, err := authDb.QueryContext(ctx, `SELECT dbName, provisioning_status = $1 FROM companies`,
+		utils.ProvisioningReady)
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка запроса списка баз данных компаний: %w", err)
+	}
+	defer rows.Close()
+
+	var databases []TenantDatabase
+	for rows.Next() {
+		var db TenantDatabase
+		if err := rows.Scan(&db.DbName, &db.Ready); err != nil {
+			return nil, fmt.Errorf("Ошибка при сканировании имени базы данных компании: %w", err)
+		}
+		databases = append(databases, db)
+	}
+	return databases, rows.Err()
+}
+
+// Inventory базы данных компаний на сервере PostgreSQL.
+type Inventory struct {
+	// Databases мигрируемые базы: шаблон и существующие базы зарегистрированных компаний.
+	Databases []string
+	// Unregistered базы сервера с именем в формате баз компаний, не зарегистрированные в companies.
+	// Такие базы не мигрируются: это остатки удалённых компаний или ошибок создания.
+	Unregistered []string
+	// Missing базы зарегистрированных компаний с завершённым созданием, отсутствующие на сервере.
+	Missing []string
+}
+
+// NewInventory сопоставляет базы сервера server с базами зарегистрированных компаний registered.
+// Шаблон template мигрируется, если он существует на сервере.
+func NewInventory(server []string, registered []TenantDatabase, template string) *Inventory {
+	exists := make(map[string]bool, len(server))
+	for _, dbName := range server {
+		exists[dbName] = true
+	}
+	isRegistered := make(map[string]bool, len(registered))
+
+	inventory := &Inventory{}
+	if template != "" && exists[template] {
+		isRegistered[template] = true
+		inventory.Databases = append(inventory.Databases, template)
+	}
+	for _, db := range registered {
+		if isRegistered[db.DbName] {
+			continue
+		}
+		isRegistered[db.DbName] = true
+		switch {
+		case !db.Ready:
+		case exists[db.DbName]:
+			inventory.Databases = append(inventory.Databases, db.DbName)
+		default:
+			inventory.Missing = append(inventory.Missing, db.DbName)
+		}
+	}
+	for _, dbName := range server {
+		if !isRegistered[dbName] && utils.LooksLikeTenantDb(dbName) {
+			inventory.Unregistered = append(inventory.Unregistered, dbName)
+		}
+	}
+
+	sort.Strings(inventory.Databases)
+	sort.Strings(inventory.Unregistered)
+	sort.Strings(inventory.Missing)
+	return inventory
+}
@@ -0,0 +1,180 @@
+package offboarding
+
+import (
+	"archive/zip"
+	"context"
+	"crmSystem/utils"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Формат архива экспорта компании.
+const (
+	ExportFormat  = "crm-tenant-export"
+	ExportVersion = 1
+)
+
+// Manifest описание архива экспорта: данные компании, версия схемы и выгруженные таблицы.
+type Manifest struct {
+	Format        string          `json:"format"`
+	Version       int             `json:"version"`
+	CompanyId     string          `json:"companyId"`
+	CompanyName   string          `json:"companyName"`
+	DbName        string          `json:"dbName"`
+	Plan          string          `json:"plan"`
+	Status        string          `json:"status"`
+	SchemaVersion int64           `json:"schemaVersion"` // Последняя применённая миграция базы компании
+	ExportedAt    time.Time       `json:"exportedAt"`
+	Tables        []ManifestTable `json:"tables"`
+}
+
+// ManifestTable выгруженная таблица: файл в архиве и число строк.
+type ManifestTable struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	Rows int64  `json:"rows"`
+}
+
+// Export выгружает всю базу данных компании tenant в zip архив, записываемый в w.
+//
+// Каждая таблица сохраняется в файл tables/<таблица>.json как массив JSON объектов (по одному на строку),
+// описание архива - в manifest.json. Таблицы читаются в одной транзакции, поэтому архив
+// соответствует одному моменту времени, даже если компания продолжает работать.
+func Export(ctx context.Context, tenant *utils.Tenant, db *sql.DB, w io.Writer) (manifest *Manifest, err error) {
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	manifest = &Manifest{
+		Format:      ExportFormat,
+		Version:     ExportVersion,
+		CompanyId:   tenant.CompanyId,
+		CompanyName: tenant.Name,
+		DbName:      tenant.DbName,
+		Plan:        tenant.Plan,
+		Status:      tenant.Status,
+		ExportedAt:  time.Now().UTC(),
+		Tables:      []ManifestTable{},
+	}
+
+	manifest.SchemaVersion, err = schemaVersion(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := listTables(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := zip.NewWriter(w)
+	for _, table := range tables {
+		entry := ManifestTable{Name: table, File: "tables/" + table + ".json"}
+		file, err := archive.Create(entry.File)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка записи архива: %w", err)
+		}
+		entry.Rows, err = exportTable(ctx, tx, table, file)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Tables = append(manifest.Tables, entry)
+	}
+
+	file, err := archive.Create("manifest.json")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка записи архива: %w", err)
+	}
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(manifest); err != nil {
+		return nil, fmt.Errorf("ошибка записи описания архива: %w", err)
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, fmt.Errorf("ошибка записи архива: %w", err)
+	}
+	return manifest, nil
+}
+
+// listTables возвращает таблицы базы компании, кроме служебной таблицы миграций.
+func listTables(ctx context.Context, tx *sql.Tx) ([]string, error) {
+	rows, err := tx.QueryContext(ctx,
+		`SELECT table_name FROM information_schema.tables
+		WHERE table_schema = 'public' AND table_type = 'BASE TABLE' AND table_name <> 'schema_migrations'
+		ORDER BY table_name`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения списка таблиц: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, fmt.Errorf("ошибка получения списка таблиц: %w", err)
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
+
+// schemaVersion возвращает номер последней применённой миграции или 0, если миграции не применялись.
+func schemaVersion(ctx context.Context, tx *sql.Tx) (int64, error) {
+	var version int64
+	err := tx.QueryRowContext(ctx, "SELECT version FROM schema_migrations LIMIT 1").Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка получения версии схемы: %w", err)
+	}
+	return version, nil
+}
+
+// exportTable записывает строки таблицы в w массивом JSON объектов, не загружая таблицу в память.
+// Значения приводит к JSON сам PostgreSQL (row_to_json). Возвращает число строк.
+func exportTable(ctx context.Context, tx *sql.Tx, table string, w io.Writer) (int64, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT row_to_json(t) FROM %s AS t", utils.QuoteIdentifier(table)))
+	if err != nil {
+		return 0, fmt.Errorf("ошибка чтения таблицы %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return 0, fmt.Errorf("ошибка записи архива: %w", err)
+	}
+	var count int64
+	for rows.Next() {
+		var row []byte
+		if err := rows.Scan(&row); err != nil {
+			return 0, fmt.Errorf("ошибка чтения таблицы %s: %w", table, err)
+		}
+		separator := "\n"
+		if count > 0 {
+			separator = ",\n"
+		}
+		if _, err := io.WriteString(w, separator); err != nil {
+			return 0, fmt.Errorf("ошибка записи архива: %w", err)
+		}
+		if _, err := w.Write(row); err != nil {
+			return 0, fmt.Errorf("ошибка записи архива: %w", err)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("ошибка чтения таблицы %s: %w", table, err)
+	}
+	if _, err := io.WriteString(w, "\n]\n"); err != nil {
+		return 0, fmt.Errorf("ошибка записи архива: %w", err)
+	}
+	return count, nil
+}
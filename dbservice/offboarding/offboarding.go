@@ -0,0 +1,298 @@
+package offboarding
+
+import (
+	"context"
+	"crmSystem/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// Операции журнала tenantAudit.
+const (
+	ActionSuspended         = "suspended"
+	ActionResumed           = "resumed"
+	ActionExported          = "exported"
+	ActionDeletionScheduled = "deletion_scheduled"
+	ActionPurged            = "purged"
+)
+
+// SystemActor исполнитель операций, выполняемых сервисом без участия оператора.
+const SystemActor = "system"
+
+// DefaultGracePeriod срок между удалением компании и безвозвратным удалением её данных.
+// В течение этого срока компанию можно восстановить, а данные выгрузить.
+const DefaultGracePeriod = 30 * 24 * time.Hour
+
+var (
+	// ErrCompanyNotFound возвращается, если компании нет в реестре.
+	ErrCompanyNotFound = errors.New("компания не найдена в реестре")
+
+	// ErrCompanyDeleted возвращается при попытке приостановить или повторно удалить удалённую компанию.
+	ErrCompanyDeleted = errors.New("компания уже удалена, её можно только восстановить до безвозвратного удаления")
+
+	// ErrActorRequired возвращается, если не указано, кто выполняет операцию.
+	ErrActorRequired = errors.New("не указан исполнитель операции")
+)
+
+// company запись реестра, заблокированная на время операции.
+type company struct {
+	id     string
+	name   string
+	dbName string
+	status string
+}
+
+// Suspend приостанавливает работу компании: вход и запросы к её базе данных отклоняются.
+// Повторная приостановка ничего не меняет.
+func Suspend(ctx context.Context, authDb *sql.DB, companyId string, actor string, reason string) error {
+	return transition(ctx, authDb, companyId, actor, func(tx *sql.Tx, c *company) error {
+		switch c.status {
+		case utils.TenantStatusSuspended:
+			return nil
+		case utils.TenantStatusDeleted:
+			return ErrCompanyDeleted
+		}
+		if err := setStatus(ctx, tx, c.id, utils.TenantStatusSuspended, reason, nil); err != nil {
+			return err
+		}
+		return RecordAudit(ctx, tx, c.id, c.name, c.dbName, ActionSuspended, actor, reason)
+	})
+}
+
+// Resume возобновляет работу приостановленной компании или отменяет удаление,
+// если данные компании ещё не удалены безвозвратно.
+func Resume(ctx context.Context, authDb *sql.DB, companyId string, actor string) error {
+	return transition(ctx, authDb, companyId, actor, func(tx *sql.Tx, c *company) error {
+		if c.status == utils.TenantStatusActive {
+			return nil
+		}
+		if err := setStatus(ctx, tx, c.id, utils.TenantStatusActive, "", nil); err != nil {
+			return err
+		}
+		return RecordAudit(ctx, tx, c.id, c.name, c.dbName, ActionResumed, actor, "")
+	})
+}
+
+// ScheduleDeletion удаляет компанию: вход и запросы отклоняются сразу, а база данных
+// и записи компании в базе авторизации удаляются безвозвратно через grace.
+// Возвращает время безвозвратного удаления.
+func ScheduleDeletion(ctx context.Context, authDb *sql.DB, companyId string, actor string, reason string,
+	grace time.Duration) (deleteAfter time.Time, err error) {
+
+	deleteAfter = time.Now().Add(grace).UTC()
+	err = transition(ctx, authDb, companyId, actor, func(tx *sql.Tx, c *company) error {
+		if c.status == utils.TenantStatusDeleted {
+			return ErrCompanyDeleted
+		}
+		if err := setStatus(ctx, tx, c.id, utils.TenantStatusDeleted, reason, &deleteAfter); err != nil {
+			return err
+		}
+		details := fmt.Sprintf("безвозвратное удаление после %s", deleteAfter.Format(time.RFC3339))
+		if reason != "" {
+			details = reason + "; " + details
+		}
+		return RecordAudit(ctx, tx, c.id, c.name, c.dbName, ActionDeletionScheduled, actor, details)
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	return deleteAfter, nil
+}
+
+// execer транзакция или соединение с базой данных авторизации.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// RecordAudit сохраняет операцию с компанией в журнал tenantAudit.
+func RecordAudit(ctx context.Context, db execer, companyId string, name string, dbName string,
+	action string, actor string, details string) error {
+
+	_, err := db.ExecContext(ctx,
+		"INSERT INTO tenantAudit (company_id, company_name, dbName, action, actor, details) VALUES ($1, $2, $3, $4, $5, $6)",
+		companyId, name, dbName, action, actor, details,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка записи в журнал операций с компаниями: %w", err)
+	}
+	return nil
+}
+
+// Purge безвозвратно удаляет компании, срок восстановления которых истёк: базу данных
+// удаляет drop, после чего удаляются записи компании и её пользователей в базе авторизации.
+// Возвращает число удалённых компаний. Ошибка одной компании не прерывает удаление остальных,
+// она будет удалена при следующем вызове.
+func Purge(ctx context.Context, authDb *sql.DB, drop func(dbName string) error) (int, error) {
+	rows, err := authDb.QueryContext(ctx,
+		"SELECT id FROM companies WHERE status = $1 AND delete_after <= NOW() ORDER BY id", utils.TenantStatusDeleted)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка получения компаний для удаления: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("ошибка получения компаний для удаления: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("ошибка получения компаний для удаления: %w", err)
+	}
+
+	purged := 0
+	var errs []error
+	for _, id := range ids {
+		if err := purgeCompany(ctx, authDb, id, drop); err != nil {
+			errs = append(errs, fmt.Errorf("компания %s: %w", id, err))
+			continue
+		}
+		purged++
+	}
+	return purged, errors.Join(errs...)
+}
+
+// purgeCompany удаляет одну компанию. Запись компании заблокирована до конца удаления,
+// поэтому восстановить компанию одновременно с удалением нельзя.
+func purgeCompany(ctx context.Context, authDb *sql.DB, companyId string, drop func(dbName string) error) (err error) {
+	tx, err := authDb.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	c := company{id: companyId}
+	err = tx.QueryRowContext(ctx,
+		"SELECT name, dbName FROM companies WHERE id = $1 AND status = $2 AND delete_after <= NOW() FOR UPDATE",
+		companyId, utils.TenantStatusDeleted,
+	).Scan(&c.name, &c.dbName)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Компания восстановлена или уже удалена другим экземпляром сервиса
+		return tx.Rollback()
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка получения компании: %w", err)
+	}
+
+	// База удаляется первой: если удаление записей не удастся, повторная попытка
+	// найдёт компанию снова, а удаление отсутствующей базы не считается ошибкой
+	if err = drop(c.dbName); err != nil {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM authusers WHERE company_id = $1", companyId)
+	if err != nil {
+		return fmt.Errorf("ошибка удаления пользователей компании: %w", err)
+	}
+	users, _ := result.RowsAffected()
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM companies WHERE id = $1", companyId); err != nil {
+		return fmt.Errorf("ошибка удаления компании: %w", err)
+	}
+
+	details := fmt.Sprintf("удалена база данных и %d учётных записей", users)
+	if err = RecordAudit(ctx, tx, c.id, c.name, c.dbName, ActionPurged, SystemActor, details); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("не удалось зафиксировать удаление компании: %w", err)
+	}
+	log.Printf("Компания %s (%s) удалена безвозвратно", c.id, c.dbName)
+	return nil
+}
+
+// StartPurge периодически безвозвратно удаляет компании, срок восстановления которых истёк.
+// Соединения с удаляемой базой закрываются в пуле pool. Возвращает функцию остановки.
+func StartPurge(pool *utils.MapConnectionsDB, interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	drop := func(dbName string) error {
+		pool.Remove(dbName)
+		return utils.DropDatabase(dbName)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			authDb, err := pool.GetDb(os.Getenv("DB_AUTH_NAME"))
+			if err != nil {
+				log.Printf("Ошибка подключения к базе данных авторизации: %v", err)
+				continue
+			}
+			purged, err := Purge(ctx, authDb, drop)
+			if err != nil {
+				log.Printf("Ошибка безвозвратного удаления компаний: %v", err)
+			}
+			if purged > 0 {
+				log.Printf("Безвозвратно удалено компаний: %d", purged)
+			}
+		}
+	}()
+	return cancel
+}
+
+// transition блокирует запись компании и выполняет change в одной транзакции.
+func transition(ctx context.Context, authDb *sql.DB, companyId string, actor string,
+	change func(tx *sql.Tx, c *company) error) (err error) {
+
+	if actor == "" {
+		return ErrActorRequired
+	}
+
+	tx, err := authDb.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	c := company{id: companyId}
+	err = tx.QueryRowContext(ctx, "SELECT name, dbName, status FROM companies WHERE id = $1 FOR UPDATE", companyId).
+		Scan(&c.name, &c.dbName, &c.status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCompanyNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка получения компании: %w", err)
+	}
+
+	if err = change(tx, &c); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("не удалось зафиксировать изменение компании: %w", err)
+	}
+	return nil
+}
+
+// setStatus меняет статус компании. deleteAfter задаётся только для удалённой компании.
+func setStatus(ctx context.Context, tx *sql.Tx, companyId string, status string, reason string, deleteAfter *time.Time) error {
+	_, err := tx.ExecContext(ctx,
+		"UPDATE companies SET status = $1, status_reason = NULLIF($2, ''), delete_after = $3 WHERE id = $4",
+		status, reason, deleteAfter, companyId,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка изменения статуса компании: %w", err)
+	}
+	return nil
+}
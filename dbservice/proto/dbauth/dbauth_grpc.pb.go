@@ -19,8 +19,24 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	DbAuthService_RegisterCompany_FullMethodName = "/protobuff.dbAuthService/RegisterCompany"
-	DbAuthService_LoginDB_FullMethodName         = "/protobuff.dbAuthService/LoginDB"
+	DbAuthService_RegisterCompany_FullMethodName       = "/protobuff.dbAuthService/RegisterCompany"
+	DbAuthService_GetProvisioningStatus_FullMethodName = "/protobuff.dbAuthService/GetProvisioningStatus"
+	DbAuthService_LoginDB_FullMethodName               = "/protobuff.dbAuthService/LoginDB"
+	DbAuthService_FindAuthUser_FullMethodName          = "/protobuff.dbAuthService/FindAuthUser"
+	DbAuthService_ResetPassword_FullMethodName         = "/protobuff.dbAuthService/ResetPassword"
+	DbAuthService_ActivateAccount_FullMethodName       = "/protobuff.dbAuthService/ActivateAccount"
+	DbAuthService_BeginTotpEnrollment_FullMethodName   = "/protobuff.dbAuthService/BeginTotpEnrollment"
+	DbAuthService_ConfirmTotpEnrollment_FullMethodName = "/protobuff.dbAuthService/ConfirmTotpEnrollment"
+	DbAuthService_VerifyMfa_FullMethodName             = "/protobuff.dbAuthService/VerifyMfa"
+	DbAuthService_DisableTotp_FullMethodName           = "/protobuff.dbAuthService/DisableTotp"
+	DbAuthService_GetOidcProvider_FullMethodName       = "/protobuff.dbAuthService/GetOidcProvider"
+	DbAuthService_LoginOidc_FullMethodName             = "/protobuff.dbAuthService/LoginOidc"
+	DbAuthService_CreateApiKey_FullMethodName          = "/protobuff.dbAuthService/CreateApiKey"
+	DbAuthService_ListApiKeys_FullMethodName           = "/protobuff.dbAuthService/ListApiKeys"
+	DbAuthService_RevokeApiKey_FullMethodName          = "/protobuff.dbAuthService/RevokeApiKey"
+	DbAuthService_VerifyApiKey_FullMethodName          = "/protobuff.dbAuthService/VerifyApiKey"
+	DbAuthService_FindEmailOtpUser_FullMethodName      = "/protobuff.dbAuthService/FindEmailOtpUser"
+	DbAuthService_LoginEmailOtp_FullMethodName         = "/protobuff.dbAuthService/LoginEmailOtp"
 )
 
 // DbAuthServiceClient is the client API for DbAuthService service.
@@ -29,8 +45,40 @@ const (
 type DbAuthServiceClient interface {
 	// Метод для регистрации
 	RegisterCompany(ctx context.Context, in *RegisterCompanyRequest, opts ...grpc.CallOption) (*RegisterCompanyResponse, error)
+	// Метод для опроса состояния создания базы данных зарегистрированной компании
+	GetProvisioningStatus(ctx context.Context, in *GetProvisioningStatusRequest, opts ...grpc.CallOption) (*GetProvisioningStatusResponse, error)
 	// Метод для логинизации
 	LoginDB(ctx context.Context, in *LoginDBRequest, opts ...grpc.CallOption) (*LoginDBResponse, error)
+	// Метод для поиска пользователя по email (восстановление пароля)
+	FindAuthUser(ctx context.Context, in *FindAuthUserRequest, opts ...grpc.CallOption) (*FindAuthUserResponse, error)
+	// Метод для установки нового пароля пользователя
+	ResetPassword(ctx context.Context, in *ResetPasswordRequest, opts ...grpc.CallOption) (*ResetPasswordResponse, error)
+	// Метод для активации учётной записи по ссылке из письма
+	ActivateAccount(ctx context.Context, in *ActivateAccountRequest, opts ...grpc.CallOption) (*ActivateAccountResponse, error)
+	// Метод для начала подключения TOTP: создаёт секрет и URI для QR-кода
+	BeginTotpEnrollment(ctx context.Context, in *BeginTotpEnrollmentRequest, opts ...grpc.CallOption) (*BeginTotpEnrollmentResponse, error)
+	// Метод для подтверждения подключения TOTP первым кодом, возвращает коды восстановления
+	ConfirmTotpEnrollment(ctx context.Context, in *MfaCodeRequest, opts ...grpc.CallOption) (*ConfirmTotpEnrollmentResponse, error)
+	// Метод для проверки второго фактора при входе (код TOTP или код восстановления)
+	VerifyMfa(ctx context.Context, in *MfaCodeRequest, opts ...grpc.CallOption) (*VerifyMfaResponse, error)
+	// Метод для отключения TOTP
+	DisableTotp(ctx context.Context, in *MfaCodeRequest, opts ...grpc.CallOption) (*DisableTotpResponse, error)
+	// Метод для получения настроек OpenID Connect провайдера по почтовому домену пользователя
+	GetOidcProvider(ctx context.Context, in *GetOidcProviderRequest, opts ...grpc.CallOption) (*GetOidcProviderResponse, error)
+	// Метод для входа пользователя, подтверждённого OpenID Connect провайдером компании
+	LoginOidc(ctx context.Context, in *LoginOidcRequest, opts ...grpc.CallOption) (*LoginOidcResponse, error)
+	// Метод для сохранения нового API ключа пользователя (передаётся только хэш ключа)
+	CreateApiKey(ctx context.Context, in *CreateApiKeyRequest, opts ...grpc.CallOption) (*CreateApiKeyResponse, error)
+	// Метод для получения API ключей пользователя
+	ListApiKeys(ctx context.Context, in *ListApiKeysRequest, opts ...grpc.CallOption) (*ListApiKeysResponse, error)
+	// Метод для отзыва API ключа пользователя
+	RevokeApiKey(ctx context.Context, in *RevokeApiKeyRequest, opts ...grpc.CallOption) (*RevokeApiKeyResponse, error)
+	// Метод для проверки API ключа, данные владельца передаются в заголовках ответа
+	VerifyApiKey(ctx context.Context, in *VerifyApiKeyRequest, opts ...grpc.CallOption) (*VerifyApiKeyResponse, error)
+	// Метод для поиска пользователя, которому разрешён вход по коду из письма
+	FindEmailOtpUser(ctx context.Context, in *FindEmailOtpUserRequest, opts ...grpc.CallOption) (*FindEmailOtpUserResponse, error)
+	// Метод для входа по коду из письма, данные пользователя передаются в заголовках ответа так же, как LoginDB
+	LoginEmailOtp(ctx context.Context, in *LoginEmailOtpRequest, opts ...grpc.CallOption) (*LoginEmailOtpResponse, error)
 }
 
 type dbAuthServiceClient struct {
@@ -51,6 +99,16 @@ func (c *dbAuthServiceClient) RegisterCompany(ctx context.Context, in *RegisterC
 	return out, nil
 }
 
+func (c *dbAuthServiceClient) GetProvisioningStatus(ctx context.Context, in *GetProvisioningStatusRequest, opts ...grpc.CallOption) (*GetProvisioningStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetProvisioningStatusResponse)
+	err := c.cc.Invoke(ctx, DbAuthService_GetProvisioningStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *dbAuthServiceClient) LoginDB(ctx context.Context, in *LoginDBRequest, opts ...grpc.CallOption) (*LoginDBResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(LoginDBResponse)
@@ -61,14 +119,196 @@ func (c *dbAuthServiceClient) LoginDB(ctx context.Context, in *LoginDBRequest, o
 	return out, nil
 }
 
+func (c *dbAuthServiceClient) FindAuthUser(ctx context.Context, in *FindAuthUserRequest, opts ...grpc.CallOption) (*FindAuthUserResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindAuthUserResponse)
+	err := c.cc.Invoke(ctx, DbAuthService_FindAuthUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAuthServiceClient) ResetPassword(ctx context.Context, in *ResetPasswordRequest, opts ...grpc.CallOption) (*ResetPasswordResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResetPasswordResponse)
+	err := c.cc.Invoke(ctx, DbAuthService_ResetPassword_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAuthServiceClient) ActivateAccount(ctx context.Context, in *ActivateAccountRequest, opts ...grpc.CallOption) (*ActivateAccountResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ActivateAccountResponse)
+	err := c.cc.Invoke(ctx, DbAuthService_ActivateAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAuthServiceClient) BeginTotpEnrollment(ctx context.Context, in *BeginTotpEnrollmentRequest, opts ...grpc.CallOption) (*BeginTotpEnrollmentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BeginTotpEnrollmentResponse)
+	err := c.cc.Invoke(ctx, DbAuthService_BeginTotpEnrollment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAuthServiceClient) ConfirmTotpEnrollment(ctx context.Context, in *MfaCodeRequest, opts ...grpc.CallOption) (*ConfirmTotpEnrollmentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConfirmTotpEnrollmentResponse)
+	err := c.cc.Invoke(ctx, DbAuthService_ConfirmTotpEnrollment_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAuthServiceClient) VerifyMfa(ctx context.Context, in *MfaCodeRequest, opts ...grpc.CallOption) (*VerifyMfaResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyMfaResponse)
+	err := c.cc.Invoke(ctx, DbAuthService_VerifyMfa_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAuthServiceClient) DisableTotp(ctx context.Context, in *MfaCodeRequest, opts ...grpc.CallOption) (*DisableTotpResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DisableTotpResponse)
+	err := c.cc.Invoke(ctx, DbAuthService_DisableTotp_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAuthServiceClient) GetOidcProvider(ctx context.Context, in *GetOidcProviderRequest, opts ...grpc.CallOption) (*GetOidcProviderResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetOidcProviderResponse)
+	err := c.cc.Invoke(ctx, DbAuthService_GetOidcProvider_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAuthServiceClient) LoginOidc(ctx context.Context, in *LoginOidcRequest, opts ...grpc.CallOption) (*LoginOidcResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoginOidcResponse)
+	err := c.cc.Invoke(ctx, DbAuthService_LoginOidc_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAuthServiceClient) CreateApiKey(ctx context.Context, in *CreateApiKeyRequest, opts ...grpc.CallOption) (*CreateApiKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateApiKeyResponse)
+	err := c.cc.Invoke(ctx, DbAuthService_CreateApiKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAuthServiceClient) ListApiKeys(ctx context.Context, in *ListApiKeysRequest, opts ...grpc.CallOption) (*ListApiKeysResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListApiKeysResponse)
+	err := c.cc.Invoke(ctx, DbAuthService_ListApiKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAuthServiceClient) RevokeApiKey(ctx context.Context, in *RevokeApiKeyRequest, opts ...grpc.CallOption) (*RevokeApiKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RevokeApiKeyResponse)
+	err := c.cc.Invoke(ctx, DbAuthService_RevokeApiKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAuthServiceClient) VerifyApiKey(ctx context.Context, in *VerifyApiKeyRequest, opts ...grpc.CallOption) (*VerifyApiKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyApiKeyResponse)
+	err := c.cc.Invoke(ctx, DbAuthService_VerifyApiKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAuthServiceClient) FindEmailOtpUser(ctx context.Context, in *FindEmailOtpUserRequest, opts ...grpc.CallOption) (*FindEmailOtpUserResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FindEmailOtpUserResponse)
+	err := c.cc.Invoke(ctx, DbAuthService_FindEmailOtpUser_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbAuthServiceClient) LoginEmailOtp(ctx context.Context, in *LoginEmailOtpRequest, opts ...grpc.CallOption) (*LoginEmailOtpResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LoginEmailOtpResponse)
+	err := c.cc.Invoke(ctx, DbAuthService_LoginEmailOtp_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // DbAuthServiceServer is the server API for DbAuthService service.
 // All implementations must embed UnimplementedDbAuthServiceServer
 // for forward compatibility.
 type DbAuthServiceServer interface {
 	// Метод для регистрации
 	RegisterCompany(context.Context, *RegisterCompanyRequest) (*RegisterCompanyResponse, error)
+	// Метод для опроса состояния создания базы данных зарегистрированной компании
+	GetProvisioningStatus(context.Context, *GetProvisioningStatusRequest) (*GetProvisioningStatusResponse, error)
 	// Метод для логинизации
 	LoginDB(context.Context, *LoginDBRequest) (*LoginDBResponse, error)
+	// Метод для поиска пользователя по email (восстановление пароля)
+	FindAuthUser(context.Context, *FindAuthUserRequest) (*FindAuthUserResponse, error)
+	// Метод для установки нового пароля пользователя
+	ResetPassword(context.Context, *ResetPasswordRequest) (*ResetPasswordResponse, error)
+	// Метод для активации учётной записи по ссылке из письма
+	ActivateAccount(context.Context, *ActivateAccountRequest) (*ActivateAccountResponse, error)
+	// Метод для начала подключения TOTP: создаёт секрет и URI для QR-кода
+	BeginTotpEnrollment(context.Context, *BeginTotpEnrollmentRequest) (*BeginTotpEnrollmentResponse, error)
+	// Метод для подтверждения подключения TOTP первым кодом, возвращает коды восстановления
+	ConfirmTotpEnrollment(context.Context, *MfaCodeRequest) (*ConfirmTotpEnrollmentResponse, error)
+	// Метод для проверки второго фактора при входе (код TOTP или код восстановления)
+	VerifyMfa(context.Context, *MfaCodeRequest) (*VerifyMfaResponse, error)
+	// Метод для отключения TOTP
+	DisableTotp(context.Context, *MfaCodeRequest) (*DisableTotpResponse, error)
+	// Метод для получения настроек OpenID Connect провайдера по почтовому домену пользователя
+	GetOidcProvider(context.Context, *GetOidcProviderRequest) (*GetOidcProviderResponse, error)
+	// Метод для входа пользователя, подтверждённого OpenID Connect провайдером компании
+	LoginOidc(context.Context, *LoginOidcRequest) (*LoginOidcResponse, error)
+	// Метод для сохранения нового API ключа пользователя (передаётся только хэш ключа)
+	CreateApiKey(context.Context, *CreateApiKeyRequest) (*CreateApiKeyResponse, error)
+	// Метод для получения API ключей пользователя
+	ListApiKeys(context.Context, *ListApiKeysRequest) (*ListApiKeysResponse, error)
+	// Метод для отзыва API ключа пользователя
+	RevokeApiKey(context.Context, *RevokeApiKeyRequest) (*RevokeApiKeyResponse, error)
+	// Метод для проверки API ключа, данные владельца передаются в заголовках ответа
+	VerifyApiKey(context.Context, *VerifyApiKeyRequest) (*VerifyApiKeyResponse, error)
+	// Метод для поиска пользователя, которому разрешён вход по коду из письма
+	FindEmailOtpUser(context.Context, *FindEmailOtpUserRequest) (*FindEmailOtpUserResponse, error)
+	// Метод для входа по коду из письма, данные пользователя передаются в заголовках ответа так же, как LoginDB
+	LoginEmailOtp(context.Context, *LoginEmailOtpRequest) (*LoginEmailOtpResponse, error)
 	mustEmbedUnimplementedDbAuthServiceServer()
 }
 
@@ -82,9 +322,57 @@ type UnimplementedDbAuthServiceServer struct{}
 func (UnimplementedDbAuthServiceServer) RegisterCompany(context.Context, *RegisterCompanyRequest) (*RegisterCompanyResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RegisterCompany not implemented")
 }
+func (UnimplementedDbAuthServiceServer) GetProvisioningStatus(context.Context, *GetProvisioningStatusRequest) (*GetProvisioningStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProvisioningStatus not implemented")
+}
 func (UnimplementedDbAuthServiceServer) LoginDB(context.Context, *LoginDBRequest) (*LoginDBResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LoginDB not implemented")
 }
+func (UnimplementedDbAuthServiceServer) FindAuthUser(context.Context, *FindAuthUserRequest) (*FindAuthUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindAuthUser not implemented")
+}
+func (UnimplementedDbAuthServiceServer) ResetPassword(context.Context, *ResetPasswordRequest) (*ResetPasswordResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetPassword not implemented")
+}
+func (UnimplementedDbAuthServiceServer) ActivateAccount(context.Context, *ActivateAccountRequest) (*ActivateAccountResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ActivateAccount not implemented")
+}
+func (UnimplementedDbAuthServiceServer) BeginTotpEnrollment(context.Context, *BeginTotpEnrollmentRequest) (*BeginTotpEnrollmentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BeginTotpEnrollment not implemented")
+}
+func (UnimplementedDbAuthServiceServer) ConfirmTotpEnrollment(context.Context, *MfaCodeRequest) (*ConfirmTotpEnrollmentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmTotpEnrollment not implemented")
+}
+func (UnimplementedDbAuthServiceServer) VerifyMfa(context.Context, *MfaCodeRequest) (*VerifyMfaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyMfa not implemented")
+}
+func (UnimplementedDbAuthServiceServer) DisableTotp(context.Context, *MfaCodeRequest) (*DisableTotpResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableTotp not implemented")
+}
+func (UnimplementedDbAuthServiceServer) GetOidcProvider(context.Context, *GetOidcProviderRequest) (*GetOidcProviderResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOidcProvider not implemented")
+}
+func (UnimplementedDbAuthServiceServer) LoginOidc(context.Context, *LoginOidcRequest) (*LoginOidcResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoginOidc not implemented")
+}
+func (UnimplementedDbAuthServiceServer) CreateApiKey(context.Context, *CreateApiKeyRequest) (*CreateApiKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateApiKey not implemented")
+}
+func (UnimplementedDbAuthServiceServer) ListApiKeys(context.Context, *ListApiKeysRequest) (*ListApiKeysResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListApiKeys not implemented")
+}
+func (UnimplementedDbAuthServiceServer) RevokeApiKey(context.Context, *RevokeApiKeyRequest) (*RevokeApiKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeApiKey not implemented")
+}
+func (UnimplementedDbAuthServiceServer) VerifyApiKey(context.Context, *VerifyApiKeyRequest) (*VerifyApiKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyApiKey not implemented")
+}
+func (UnimplementedDbAuthServiceServer) FindEmailOtpUser(context.Context, *FindEmailOtpUserRequest) (*FindEmailOtpUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindEmailOtpUser not implemented")
+}
+func (UnimplementedDbAuthServiceServer) LoginEmailOtp(context.Context, *LoginEmailOtpRequest) (*LoginEmailOtpResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoginEmailOtp not implemented")
+}
 func (UnimplementedDbAuthServiceServer) mustEmbedUnimplementedDbAuthServiceServer() {}
 func (UnimplementedDbAuthServiceServer) testEmbeddedByValue()                       {}
 
@@ -124,6 +412,24 @@ func _DbAuthService_RegisterCompany_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DbAuthService_GetProvisioningStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProvisioningStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAuthServiceServer).GetProvisioningStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAuthService_GetProvisioningStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAuthServiceServer).GetProvisioningStatus(ctx, req.(*GetProvisioningStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _DbAuthService_LoginDB_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(LoginDBRequest)
 	if err := dec(in); err != nil {
@@ -142,6 +448,276 @@ func _DbAuthService_LoginDB_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _DbAuthService_FindAuthUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindAuthUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAuthServiceServer).FindAuthUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAuthService_FindAuthUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAuthServiceServer).FindAuthUser(ctx, req.(*FindAuthUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAuthService_ResetPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetPasswordRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAuthServiceServer).ResetPassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAuthService_ResetPassword_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAuthServiceServer).ResetPassword(ctx, req.(*ResetPasswordRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAuthService_ActivateAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ActivateAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAuthServiceServer).ActivateAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAuthService_ActivateAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAuthServiceServer).ActivateAccount(ctx, req.(*ActivateAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAuthService_BeginTotpEnrollment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BeginTotpEnrollmentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAuthServiceServer).BeginTotpEnrollment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAuthService_BeginTotpEnrollment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAuthServiceServer).BeginTotpEnrollment(ctx, req.(*BeginTotpEnrollmentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAuthService_ConfirmTotpEnrollment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MfaCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAuthServiceServer).ConfirmTotpEnrollment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAuthService_ConfirmTotpEnrollment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAuthServiceServer).ConfirmTotpEnrollment(ctx, req.(*MfaCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAuthService_VerifyMfa_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MfaCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAuthServiceServer).VerifyMfa(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAuthService_VerifyMfa_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAuthServiceServer).VerifyMfa(ctx, req.(*MfaCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAuthService_DisableTotp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MfaCodeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAuthServiceServer).DisableTotp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAuthService_DisableTotp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAuthServiceServer).DisableTotp(ctx, req.(*MfaCodeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAuthService_GetOidcProvider_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOidcProviderRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAuthServiceServer).GetOidcProvider(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAuthService_GetOidcProvider_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAuthServiceServer).GetOidcProvider(ctx, req.(*GetOidcProviderRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAuthService_LoginOidc_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginOidcRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAuthServiceServer).LoginOidc(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAuthService_LoginOidc_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAuthServiceServer).LoginOidc(ctx, req.(*LoginOidcRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAuthService_CreateApiKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateApiKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAuthServiceServer).CreateApiKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAuthService_CreateApiKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAuthServiceServer).CreateApiKey(ctx, req.(*CreateApiKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAuthService_ListApiKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListApiKeysRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAuthServiceServer).ListApiKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAuthService_ListApiKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAuthServiceServer).ListApiKeys(ctx, req.(*ListApiKeysRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAuthService_RevokeApiKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeApiKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAuthServiceServer).RevokeApiKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAuthService_RevokeApiKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAuthServiceServer).RevokeApiKey(ctx, req.(*RevokeApiKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAuthService_VerifyApiKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyApiKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAuthServiceServer).VerifyApiKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAuthService_VerifyApiKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAuthServiceServer).VerifyApiKey(ctx, req.(*VerifyApiKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAuthService_FindEmailOtpUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindEmailOtpUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAuthServiceServer).FindEmailOtpUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAuthService_FindEmailOtpUser_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAuthServiceServer).FindEmailOtpUser(ctx, req.(*FindEmailOtpUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbAuthService_LoginEmailOtp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LoginEmailOtpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbAuthServiceServer).LoginEmailOtp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbAuthService_LoginEmailOtp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbAuthServiceServer).LoginEmailOtp(ctx, req.(*LoginEmailOtpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // DbAuthService_ServiceDesc is the grpc.ServiceDesc for DbAuthService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -153,10 +729,74 @@ var DbAuthService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RegisterCompany",
 			Handler:    _DbAuthService_RegisterCompany_Handler,
 		},
+		{
+			MethodName: "GetProvisioningStatus",
+			Handler:    _DbAuthService_GetProvisioningStatus_Handler,
+		},
 		{
 			MethodName: "LoginDB",
 			Handler:    _DbAuthService_LoginDB_Handler,
 		},
+		{
+			MethodName: "FindAuthUser",
+			Handler:    _DbAuthService_FindAuthUser_Handler,
+		},
+		{
+			MethodName: "ResetPassword",
+			Handler:    _DbAuthService_ResetPassword_Handler,
+		},
+		{
+			MethodName: "ActivateAccount",
+			Handler:    _DbAuthService_ActivateAccount_Handler,
+		},
+		{
+			MethodName: "BeginTotpEnrollment",
+			Handler:    _DbAuthService_BeginTotpEnrollment_Handler,
+		},
+		{
+			MethodName: "ConfirmTotpEnrollment",
+			Handler:    _DbAuthService_ConfirmTotpEnrollment_Handler,
+		},
+		{
+			MethodName: "VerifyMfa",
+			Handler:    _DbAuthService_VerifyMfa_Handler,
+		},
+		{
+			MethodName: "DisableTotp",
+			Handler:    _DbAuthService_DisableTotp_Handler,
+		},
+		{
+			MethodName: "GetOidcProvider",
+			Handler:    _DbAuthService_GetOidcProvider_Handler,
+		},
+		{
+			MethodName: "LoginOidc",
+			Handler:    _DbAuthService_LoginOidc_Handler,
+		},
+		{
+			MethodName: "CreateApiKey",
+			Handler:    _DbAuthService_CreateApiKey_Handler,
+		},
+		{
+			MethodName: "ListApiKeys",
+			Handler:    _DbAuthService_ListApiKeys_Handler,
+		},
+		{
+			MethodName: "RevokeApiKey",
+			Handler:    _DbAuthService_RevokeApiKey_Handler,
+		},
+		{
+			MethodName: "VerifyApiKey",
+			Handler:    _DbAuthService_VerifyApiKey_Handler,
+		},
+		{
+			MethodName: "FindEmailOtpUser",
+			Handler:    _DbAuthService_FindEmailOtpUser_Handler,
+		},
+		{
+			MethodName: "LoginEmailOtp",
+			Handler:    _DbAuthService_LoginEmailOtp_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "dbservice/proto/dbauth.proto",
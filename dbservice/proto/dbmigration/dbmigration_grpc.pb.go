@@ -0,0 +1,247 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.27.3
+// source: dbservice/proto/dbmigration.proto
+
+package dbmigration
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	DbMigrationService_ListMigrationStatus_FullMethodName  = "/protobuff.dbMigrationService/ListMigrationStatus"
+	DbMigrationService_RunMigrations_FullMethodName        = "/protobuff.dbMigrationService/RunMigrations"
+	DbMigrationService_MigrateTenant_FullMethodName        = "/protobuff.dbMigrationService/MigrateTenant"
+	DbMigrationService_ListMigrationHistory_FullMethodName = "/protobuff.dbMigrationService/ListMigrationHistory"
+)
+
+// DbMigrationServiceClient is the client API for DbMigrationService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Управление миграциями баз данных компаний. Методы доступны только внутренним сервисам.
+type DbMigrationServiceClient interface {
+	// Метод для получения версии схемы и признака незавершённой миграции каждой базы компании
+	ListMigrationStatus(ctx context.Context, in *ListMigrationStatusRequest, opts ...grpc.CallOption) (*ListMigrationStatusResponse, error)
+	// Метод для миграции всех баз компаний до последней версии с ограничением параллельности
+	RunMigrations(ctx context.Context, in *RunMigrationsRequest, opts ...grpc.CallOption) (*RunMigrationsResponse, error)
+	// Метод для миграции или отката одной базы компании до указанной версии
+	MigrateTenant(ctx context.Context, in *MigrateTenantRequest, opts ...grpc.CallOption) (*MigrateTenantResponse, error)
+	// Метод для получения истории миграций
+	ListMigrationHistory(ctx context.Context, in *ListMigrationHistoryRequest, opts ...grpc.CallOption) (*ListMigrationHistoryResponse, error)
+}
+
+type dbMigrationServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDbMigrationServiceClient(cc grpc.ClientConnInterface) DbMigrationServiceClient {
+	return &dbMigrationServiceClient{cc}
+}
+
+func (c *dbMigrationServiceClient) ListMigrationStatus(ctx context.Context, in *ListMigrationStatusRequest, opts ...grpc.CallOption) (*ListMigrationStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMigrationStatusResponse)
+	err := c.cc.Invoke(ctx, DbMigrationService_ListMigrationStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbMigrationServiceClient) RunMigrations(ctx context.Context, in *RunMigrationsRequest, opts ...grpc.CallOption) (*RunMigrationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RunMigrationsResponse)
+	err := c.cc.Invoke(ctx, DbMigrationService_RunMigrations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbMigrationServiceClient) MigrateTenant(ctx context.Context, in *MigrateTenantRequest, opts ...grpc.CallOption) (*MigrateTenantResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MigrateTenantResponse)
+	err := c.cc.Invoke(ctx, DbMigrationService_MigrateTenant_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *dbMigrationServiceClient) ListMigrationHistory(ctx context.Context, in *ListMigrationHistoryRequest, opts ...grpc.CallOption) (*ListMigrationHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMigrationHistoryResponse)
+	err := c.cc.Invoke(ctx, DbMigrationService_ListMigrationHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DbMigrationServiceServer is the server API for DbMigrationService service.
+// All implementations must embed UnimplementedDbMigrationServiceServer
+// for forward compatibility.
+//
+// Управление миграциями баз данных компаний. Методы доступны только внутренним сервисам.
+type DbMigrationServiceServer interface {
+	// Метод для получения версии схемы и признака незавершённой миграции каждой базы компании
+	ListMigrationStatus(context.Context, *ListMigrationStatusRequest) (*ListMigrationStatusResponse, error)
+	// Метод для миграции всех баз компаний до последней версии с ограничением параллельности
+	RunMigrations(context.Context, *RunMigrationsRequest) (*RunMigrationsResponse, error)
+	// Метод для миграции или отката одной базы компании до указанной версии
+	MigrateTenant(context.Context, *MigrateTenantRequest) (*MigrateTenantResponse, error)
+	// Метод для получения истории миграций
+	ListMigrationHistory(context.Context, *ListMigrationHistoryRequest) (*ListMigrationHistoryResponse, error)
+	mustEmbedUnimplementedDbMigrationServiceServer()
+}
+
+// UnimplementedDbMigrationServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDbMigrationServiceServer struct{}
+
+func (UnimplementedDbMigrationServiceServer) ListMigrationStatus(context.Context, *ListMigrationStatusRequest) (*ListMigrationStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMigrationStatus not implemented")
+}
+func (UnimplementedDbMigrationServiceServer) RunMigrations(context.Context, *RunMigrationsRequest) (*RunMigrationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunMigrations not implemented")
+}
+func (UnimplementedDbMigrationServiceServer) MigrateTenant(context.Context, *MigrateTenantRequest) (*MigrateTenantResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MigrateTenant not implemented")
+}
+func (UnimplementedDbMigrationServiceServer) ListMigrationHistory(context.Context, *ListMigrationHistoryRequest) (*ListMigrationHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMigrationHistory not implemented")
+}
+func (UnimplementedDbMigrationServiceServer) mustEmbedUnimplementedDbMigrationServiceServer() {}
+func (UnimplementedDbMigrationServiceServer) testEmbeddedByValue()                            {}
+
+// UnsafeDbMigrationServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DbMigrationServiceServer will
+// result in compilation errors.
+type UnsafeDbMigrationServiceServer interface {
+	mustEmbedUnimplementedDbMigrationServiceServer()
+}
+
+func RegisterDbMigrationServiceServer(s grpc.ServiceRegistrar, srv DbMigrationServiceServer) {
+	// If the following call pancis, it indicates UnimplementedDbMigrationServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&DbMigrationService_ServiceDesc, srv)
+}
+
+func _DbMigrationService_ListMigrationStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMigrationStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbMigrationServiceServer).ListMigrationStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbMigrationService_ListMigrationStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbMigrationServiceServer).ListMigrationStatus(ctx, req.(*ListMigrationStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbMigrationService_RunMigrations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RunMigrationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbMigrationServiceServer).RunMigrations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbMigrationService_RunMigrations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbMigrationServiceServer).RunMigrations(ctx, req.(*RunMigrationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbMigrationService_MigrateTenant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MigrateTenantRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbMigrationServiceServer).MigrateTenant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbMigrationService_MigrateTenant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbMigrationServiceServer).MigrateTenant(ctx, req.(*MigrateTenantRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DbMigrationService_ListMigrationHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMigrationHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DbMigrationServiceServer).ListMigrationHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: DbMigrationService_ListMigrationHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DbMigrationServiceServer).ListMigrationHistory(ctx, req.(*ListMigrationHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// DbMigrationService_ServiceDesc is the grpc.ServiceDesc for DbMigrationService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var DbMigrationService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "protobuff.dbMigrationService",
+	HandlerType: (*DbMigrationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListMigrationStatus",
+			Handler:    _DbMigrationService_ListMigrationStatus_Handler,
+		},
+		{
+			MethodName: "RunMigrations",
+			Handler:    _DbMigrationService_RunMigrations_Handler,
+		},
+		{
+			MethodName: "MigrateTenant",
+			Handler:    _DbMigrationService_MigrateTenant_Handler,
+		},
+		{
+			MethodName: "ListMigrationHistory",
+			Handler:    _DbMigrationService_ListMigrationHistory_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "dbservice/proto/dbmigration.proto",
+}
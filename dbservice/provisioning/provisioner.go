@@ -0,0 +1,264 @@
+package provisioning
+
+import (
+	"context"
+	"crmSystem/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Параметры фонового создания баз данных компаний
+const (
+	defaultTemplateName = "company_template" // DB_TEMPLATE_NAME
+	maxAttempts         = 5                  // Попыток создания базы до состояния failed
+	retryDelay          = 30 * time.Second   // Задержка перед повтором, растёт с каждой попыткой
+	attemptTimeout      = 2 * time.Minute    // Время на одну попытку
+	recordTimeout       = 10 * time.Second   // Время на запись результата попытки
+	resumeInterval      = 5 * time.Minute    // Как часто проверяются компании, ожидающие создания базы
+	queueSize           = 100
+)
+
+// TemplateName возвращает имя базы-шаблона, из которой создаются базы компаний.
+func TemplateName() string {
+	if name := os.Getenv("DB_TEMPLATE_NAME"); name != "" {
+		return name
+	}
+	return defaultTemplateName
+}
+
+// EnsureTemplate создаёт базу-шаблон, если её ещё нет. Миграции компаний применяются
+// к шаблону вместе с базами компаний, поэтому новые базы создаются уже мигрированными.
+func EnsureTemplate() error {
+	return utils.CreateInsideDB(TemplateName())
+}
+
+// Provisioner создаёт базы данных зарегистрированных компаний в фоне.
+//
+// База компании копируется из мигрированного шаблона, после чего в ней создаётся владелец.
+// Состояние хранится в реестре companies: регистрация сразу возвращает ответ, а клиент
+// опрашивает состояние по идентификатору регистрации. Неудачная попытка повторяется с задержкой,
+// каждый шаг можно безопасно повторить, а одну компанию одновременно обрабатывает один обработчик.
+type Provisioner struct {
+	pool     *utils.MapConnectionsDB
+	template string
+	queue    chan string // ID компаний, ожидающих создания базы
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	createMu sync.Mutex // PostgreSQL копирует шаблон только для одного CREATE DATABASE одновременно
+}
+
+// NewProvisioner создаёт обработчик, работающий с базами через пул pool.
+func NewProvisioner(pool *utils.MapConnectionsDB) *Provisioner {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Provisioner{
+		pool:     pool,
+		template: TemplateName(),
+		queue:    make(chan string, queueSize),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start запускает workers обработчиков и периодическую проверку компаний, ожидающих создания базы,
+// в том числе оставшихся после перезапуска сервиса. Возвращает функцию остановки, которая
+// дожидается завершения начатых попыток.
+func (p *Provisioner) Start(workers int) (stop func()) {
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		ticker := time.NewTicker(resumeInterval)
+		defer ticker.Stop()
+		for {
+			p.resume()
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() {
+		p.cancel()
+		p.wg.Wait()
+	}
+}
+
+// Enqueue ставит создание базы компании companyId в очередь и не ждёт его завершения.
+func (p *Provisioner) Enqueue(companyId string) {
+	select {
+	case p.queue <- companyId:
+	default:
+		// Очередь заполнена: ждём места в отдельной горутине, чтобы не задерживать регистрацию
+		go func() {
+			select {
+			case p.queue <- companyId:
+			case <-p.ctx.Done():
+			}
+		}()
+	}
+}
+
+func (p *Provisioner) worker() {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case companyId := <-p.queue:
+			if err := p.provision(companyId); err != nil {
+				log.Printf("Ошибка создания базы данных компании %s: %v", companyId, err)
+			}
+		}
+	}
+}
+
+// resume ставит в очередь все компании, ожидающие создания базы.
+func (p *Provisioner) resume() {
+	authDb, err := p.pool.GetDb(os.Getenv("DB_AUTH_NAME"))
+	if err != nil {
+		log.Printf("Ошибка подключения к базе данных авторизации: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(p.ctx, 10*time.Second)
+	defer cancel()
+	ids, err := PendingCompanies(ctx, authDb)
+	if err != nil {
+		log.Printf("%v", err)
+		return
+	}
+	for _, id := range ids {
+		p.Enqueue(id)
+	}
+}
+
+// provision выполняет одну попытку создания базы компании companyId.
+func (p *Provisioner) provision(companyId string) error {
+	authDb, err := p.pool.GetDb(os.Getenv("DB_AUTH_NAME"))
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к базе данных авторизации: %w", err)
+	}
+
+	attempt, err := RunAttempt(p.ctx, authDb, companyId, attemptTimeout, p.build)
+	if err != nil {
+		return err
+	}
+	if attempt.Job == nil {
+		// База уже создана или её создаёт другой обработчик
+		return nil
+	}
+	p.pool.Tenants().Invalidate(companyId)
+
+	if attempt.BuildErr != nil {
+		if attempt.Failed {
+			return fmt.Errorf("попытки создания базы исчерпаны: %w", attempt.BuildErr)
+		}
+		p.retryLater(companyId, attempt.Job.Attempts+1)
+		return fmt.Errorf("попытка %d/%d: %w", attempt.Job.Attempts+1, maxAttempts, attempt.BuildErr)
+	}
+
+	log.Printf("База данных компании %s готова", companyId)
+	return nil
+}
+
+// Attempt результат попытки создания базы компании.
+type Attempt struct {
+	Job      *Job  // Захваченное задание, nil, если компания не ожидает создания базы
+	BuildErr error // Ошибка создания базы, nil, если база готова
+	Failed   bool  // Попытки исчерпаны, компания получила состояние failed
+}
+
+// RunAttempt выполняет одну попытку создания базы компании companyId: захватывает задание в транзакции
+// базы авторизации authDb, создаёт базу функцией build не дольше timeout и сохраняет результат в реестре.
+//
+// Транзакция и запись результата не ограничены timeout: database/sql откатывает транзакцию при отмене
+// её контекста, и неудачу попытки, не уложившейся в timeout, нельзя было бы сохранить.
+// Результат сохраняется с отдельным таймаутом, транзакция отменяется только вместе с ctx.
+func RunAttempt(ctx context.Context, authDb *sql.DB, companyId string, timeout time.Duration,
+	build func(ctx context.Context, job *Job) error) (attempt Attempt, err error) {
+
+	tx, err := authDb.BeginTx(ctx, nil)
+	if err != nil {
+		return Attempt{}, fmt.Errorf("не удалось начать транзакцию: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	buildCtx, cancelBuild := context.WithTimeout(ctx, timeout)
+	defer cancelBuild()
+
+	job, err := ClaimPending(buildCtx, tx, companyId)
+	if errors.Is(err, ErrNotPending) {
+		return Attempt{}, tx.Rollback()
+	}
+	if err != nil {
+		return Attempt{}, err
+	}
+
+	attempt = Attempt{Job: job, BuildErr: build(buildCtx, job)}
+
+	recordCtx, cancelRecord := context.WithTimeout(ctx, recordTimeout)
+	defer cancelRecord()
+
+	if attempt.BuildErr != nil {
+		attempt.Failed, err = MarkFailure(recordCtx, tx, job, maxAttempts, attempt.BuildErr)
+	} else {
+		err = MarkReady(recordCtx, tx, companyId)
+	}
+	if err != nil {
+		return attempt, err
+	}
+	if err = tx.Commit(); err != nil {
+		return attempt, fmt.Errorf("ошибка сохранения состояния создания базы: %w", err)
+	}
+	return attempt, nil
+}
+
+// build создаёт базу компании из шаблона и добавляет в неё владельца.
+func (p *Provisioner) build(ctx context.Context, job *Job) error {
+	p.createMu.Lock()
+	err := utils.CreateDBFromTemplate(job.DbName, p.template)
+	p.createMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// База ещё не готова, поэтому пул её не выдаёт: подключаемся напрямую
+	db, err := sql.Open("postgres", utils.DsnString(job.DbName))
+	if err != nil {
+		return fmt.Errorf("ошибка подключения к базе данных компании: %w", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}()
+
+	return SeedCompanyDatabase(ctx, db, job.OwnerAuthUserId, os.Getenv("FIRST_ROLE"))
+}
+
+// retryLater повторяет создание базы после задержки, растущей с числом попыток.
+func (p *Provisioner) retryLater(companyId string, attempts int) {
+	time.AfterFunc(retryDelay*time.Duration(attempts), func() {
+		if p.ctx.Err() == nil {
+			p.Enqueue(companyId)
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package provisioning
+
+import (
+	"context"
+	"crmSystem/utils"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// maxErrorLength ограничение длины последней ошибки, сохраняемой в реестре.
+const maxErrorLength = 1000
+
+var (
+	// ErrNotFound возвращается, если регистрация с таким идентификатором не найдена.
+	ErrNotFound = errors.New("регистрация не найдена")
+
+	// ErrNotPending возвращается, если база компании уже создана, создание прекращено
+	// или прямо сейчас выполняется другим обработчиком.
+	ErrNotPending = errors.New("база данных компании не ожидает создания")
+)
+
+// Job задание на создание базы данных компании.
+type Job struct {
+	CompanyId       string
+	DbName          string
+	OwnerAuthUserId string // Пользователь, зарегистрировавший компанию
+	Attempts        int    // Неудачных попыток до текущей
+}
+
+// NewProvisioningId создаёт случайный идентификатор для опроса состояния регистрации.
+func NewProvisioningId() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать идентификатор регистрации: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Status возвращает состояние создания базы компании и текст последней ошибки по идентификатору регистрации.
+func Status(ctx context.Context, authDb *sql.DB, provisioningId string) (state string, lastError string, err error) {
+	var errText sql.NullString
+	err = authDb.QueryRowContext(ctx,
+		"SELECT provisioning_status, provisioning_error FROM companies WHERE provisioning_id = $1",
+		provisioningId,
+	).Scan(&state, &errText)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", ErrNotFound
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("ошибка получения состояния регистрации: %w", err)
+	}
+	return state, errText.String, nil
+}
+
+// PendingCompanies возвращает компании, базы которых ожидают создания.
+func PendingCompanies(ctx context.Context, authDb *sql.DB) ([]string, error) {
+	rows, err := authDb.QueryContext(ctx,
+		"SELECT id FROM companies WHERE provisioning_status = $1 ORDER BY id", utils.ProvisioningPending)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения компаний, ожидающих создания базы: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("ошибка получения компаний, ожидающих создания базы: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ClaimPending блокирует запись компании в транзакции tx и возвращает задание на создание её базы.
+//
+// Запись, заблокированная другим обработчиком (в том числе другим экземпляром dbservice), пропускается:
+// одна и та же база создаётся только одним обработчиком. Блокировка держится до завершения tx.
+func ClaimPending(ctx context.Context, tx *sql.Tx, companyId string) (*Job, error) {
+	job := Job{CompanyId: companyId}
+	err := tx.QueryRowContext(ctx,
+		"SELECT dbName, provisioning_attempts FROM companies WHERE id = $1 AND provisioning_status = $2 FOR UPDATE SKIP LOCKED",
+		companyId, utils.ProvisioningPending,
+	).Scan(&job.DbName, &job.Attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotPending
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения компании для создания базы: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx,
+		"SELECT id FROM authusers WHERE company_id = $1 ORDER BY id LIMIT 1", companyId,
+	).Scan(&job.OwnerAuthUserId)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения владельца компании: %w", err)
+	}
+	return &job, nil
+}
+
+// SeedCompanyDatabase создаёт в базе компании роль владельца, его пользователя и доступные действия.
+// Если пользователь владельца уже создан, ничего не делает, поэтому вызов можно безопасно повторить.
+func SeedCompanyDatabase(ctx context.Context, db *sql.DB, ownerAuthUserId string, role string) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("не удалось начать транзакцию для компании: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE authId = $1)", ownerAuthUserId).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("ошибка проверки владельца компании: %w", err)
+	}
+	if exists {
+		return tx.Commit()
+	}
+
+	var roleID int
+	err = tx.QueryRowContext(ctx, "INSERT INTO rights (roles) VALUES ($1) RETURNING id", role).Scan(&roleID)
+	if err != nil {
+		return fmt.Errorf("не удалось добавить название прав: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO users (rightsId, authId) VALUES ($1, $2)", roleID, ownerAuthUserId)
+	if err != nil {
+		return fmt.Errorf("не удалось добавить пользователя: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO availableactions (roleId, createTasks, createChats, addWorkers) VALUES ($1, $2, $3, $4)",
+		roleID, true, true, true,
+	)
+	if err != nil {
+		return fmt.Errorf("не удалось добавить доступные действия для роли: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("не удалось зафиксировать транзакцию компании: %w", err)
+	}
+	return nil
+}
+
+// MarkReady отмечает базу компании созданной.
+func MarkReady(ctx context.Context, tx *sql.Tx, companyId string) error {
+	_, err := tx.ExecContext(ctx,
+		"UPDATE companies SET provisioning_status = $1, provisioning_error = NULL, provisioning_updatedAt = NOW() WHERE id = $2",
+		utils.ProvisioningReady, companyId,
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка сохранения состояния создания базы: %w", err)
+	}
+	return nil
+}
+
+// MarkFailure сохраняет неудачную попытку создания базы. После maxAttempts попыток
+// компания получает состояние failed и больше не обрабатывается, возвращается failed = true.
+func MarkFailure(ctx context.Context, tx *sql.Tx, job *Job, maxAttempts int, cause error) (failed bool, err error) {
+	attempts := job.Attempts + 1
+	state := utils.ProvisioningPending
+	if attempts >= maxAttempts {
+		state = utils.ProvisioningFailed
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE companies SET provisioning_status = $1, provisioning_attempts = $2, provisioning_error = $3, provisioning_updatedAt = NOW() WHERE id = $4",
+		state, attempts, truncateError(cause.Error()), job.CompanyId,
+	)
+	if err != nil {
+		return false, fmt.Errorf("ошибка сохранения состояния создания базы: %w", err)
+	}
+	return state == utils.ProvisioningFailed, nil
+}
+
+// truncateError обрезает текст ошибки до maxErrorLength символов.
+func truncateError(message string) string {
+	if utf8.RuneCountInString(message) <= maxErrorLength {
+		return message
+	}
+	return string([]rune(message)[:maxErrorLength])
+}
@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/dbauthservice"
+	"crmSystem/utils"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	findApiKeyQuery   = `UPDATE apiKeys k SET last_used_at = NOW\(\) FROM authusers a WHERE .* k.key_hash = \$1 AND k.revoked_at IS NULL`
+	revokeApiKeyQuery = `UPDATE apiKeys SET revoked_at = NOW\(\) WHERE id = \$1 AND (auth_user_id|company_id) = \$2 AND revoked_at IS NULL`
+)
+
+// TestFindApiKeyByHash checks only active keys of verified users are accepted.
+func TestFindApiKeyByHash(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Active key", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		createdAt := time.Now().Add(-time.Hour)
+		mock.ExpectQuery(findApiKeyQuery).WithArgs("hash", utils.AuthStatusVerified).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "auth_user_id", "company_id", "email", "name", "prefix", "scopes", "expires_at", "created_at"}).
+				AddRow("5", "11", "3", "bot@corp.example", "reports", "crm_abcdefgh", "chats:read timers:write", nil, createdAt))
+
+		key, err := dbauthservice.FindApiKeyByHash(ctx, db, "hash")
+		require.NoError(t, err)
+		assert.Equal(t, "11", key.AuthUserId)
+		assert.Equal(t, []string{"chats:read", "timers:write"}, key.Scopes)
+		assert.False(t, key.ExpiresAt.Valid)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Revoked, expired or unknown key", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(findApiKeyQuery).WithArgs("hash", utils.AuthStatusVerified).WillReturnError(sql.ErrNoRows)
+
+		_, err = dbauthservice.FindApiKeyByHash(ctx, db, "hash")
+		assert.ErrorIs(t, err, dbauthservice.ErrApiKeyInvalid)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// TestRevokeApiKey checks a key can only be revoked by its owner or within its company.
+func TestRevokeApiKey(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(revokeApiKeyQuery).WithArgs("5", "11").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(revokeApiKeyQuery).WithArgs("5", "12").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(revokeApiKeyQuery).WithArgs("5", "3").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, dbauthservice.RevokeUserApiKey(ctx, db, "11", "5"))
+	assert.ErrorIs(t, dbauthservice.RevokeUserApiKey(ctx, db, "12", "5"), dbauthservice.ErrApiKeyNotFound)
+	assert.ErrorIs(t, dbauthservice.RevokeCompanyApiKey(ctx, db, "3", "5"), dbauthservice.ErrApiKeyNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
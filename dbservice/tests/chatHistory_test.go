@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/dbchatservice"
+	"crmSystem/proto/dbchat"
+	"crmSystem/utils"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	selectChatMember   = `SELECT EXISTS\(SELECT 1 FROM chat_users WHERE chat_id = \$1 AND user_id = \$2\)`
+	selectHistoryPage  = `FROM messages m\s+LEFT JOIN users u ON u.id = m.user_id\s+LEFT JOIN rights r ON r.id = u.rightsId\s+WHERE m.chat_id = \$1 AND \(\$2 = 0 OR m.id < \$2\)\s+ORDER BY m.id DESC\s+LIMIT \$3`
+	selectHistoryAfter = `WHERE m.chat_id = \$1 AND m.id > \$2\s+ORDER BY m.id\s+LIMIT \$3`
+)
+
+// TestListMessages checks history pages are returned in id order with authors, and only to chat members.
+func TestListMessages(t *testing.T) {
+	companyDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer companyDB.Close()
+
+	t.Setenv("DB_AUTH_NAME", "auth_db")
+	authDb, authMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer authDb.Close()
+	authMock.ExpectQuery(selectTenantByDbName).WithArgs("test_company_db").
+		WillReturnRows(sqlmock.NewRows(tenantColumns).
+			AddRow("1", "Test", "test_company_db", utils.TenantStatusActive, "standard", time.Now(), utils.ProvisioningReady))
+
+	pool := utils.NewMapConnectionsDB()
+	pool.Add("auth_db", authDb)
+	pool.Add("test_company_db", companyDB)
+	chatService := dbchatservice.NewGRPCDBChatService(pool)
+	ctx := utils.ContextWithIdentity(context.Background(), &utils.Identity{Database: "test_company_db", UserId: "7"})
+
+	historyColumns := []string{"id", "message", "created_at", "user_id", "authid", "roles"}
+	sent := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// Latest page: the query reads newest first with one extra row to detect older messages
+	mock.ExpectQuery(selectChatMember).WithArgs(int64(3), "7").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(selectHistoryPage).WithArgs(int64(3), int64(0), 3).
+		WillReturnRows(sqlmock.NewRows(historyColumns).
+			AddRow(45, "third", sent, 7, "11", "admin").
+			AddRow(44, "second", sent, 8, "12", "user").
+			AddRow(42, "first", sent, nil, nil, nil))
+	authMock.ExpectQuery(`SELECT id, email FROM authUsers WHERE id = ANY\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(11, "alice@example.com").AddRow(12, "bob@example.com"))
+
+	resp, err := chatService.ListMessages(ctx, &dbchat.ListMessagesRequest{ChatId: 3, Limit: 2})
+	require.NoError(t, err)
+	assert.True(t, resp.HasMore)
+	require.Len(t, resp.Messages, 2)
+	assert.Equal(t, &dbchat.HistoryMessage{
+		MessageId: 44, ChatId: 3, Content: "second", CreatedAt: sent.Unix(),
+		Author: &dbchat.MessageAuthor{UserId: 8, Email: "bob@example.com", Role: "user"},
+	}, resp.Messages[0])
+	assert.Equal(t, int64(45), resp.Messages[1].MessageId)
+	assert.Equal(t, "alice@example.com", resp.Messages[1].Author.Email)
+
+	// Newer page after a cursor, the deleted author has no user data
+	mock.ExpectQuery(selectChatMember).WithArgs(int64(3), "7").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(selectHistoryAfter).WithArgs(int64(3), int64(41), 51).
+		WillReturnRows(sqlmock.NewRows(historyColumns).AddRow(42, "first", sent, nil, nil, nil))
+
+	resp, err = chatService.ListMessages(ctx, &dbchat.ListMessagesRequest{ChatId: 3, AfterId: 41})
+	require.NoError(t, err)
+	assert.False(t, resp.HasMore)
+	require.Len(t, resp.Messages, 1)
+	assert.Equal(t, &dbchat.MessageAuthor{}, resp.Messages[0].Author)
+
+	// Users outside the chat cannot read its history
+	mock.ExpectQuery(selectChatMember).WithArgs(int64(5), "7").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	_, err = chatService.ListMessages(ctx, &dbchat.ListMessagesRequest{ChatId: 5})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = chatService.ListMessages(ctx, &dbchat.ListMessagesRequest{ChatId: 3, BeforeId: 10, AfterId: 5})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, authMock.ExpectationsWereMet())
+}
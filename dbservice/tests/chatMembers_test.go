@@ -0,0 +1,173 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/dbchatservice"
+	"crmSystem/proto/dbchat"
+	"crmSystem/utils"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const selectMemberActions = `SELECT COALESCE\(a.add_members, FALSE\), COALESCE\(a.remove_members, FALSE\)\s+FROM chat_users cu\s+LEFT JOIN available_actions_chat a ON a.role_id = cu.role_id\s+WHERE cu.chat_id = \$1 AND cu.user_id = \$2`
+
+// TestChatMembers checks membership changes are authorized by the chat role actions and members are listed with roles.
+func TestChatMembers(t *testing.T) {
+	companyDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer companyDB.Close()
+
+	t.Setenv("DB_AUTH_NAME", "auth_db")
+	authDb, authMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer authDb.Close()
+	authMock.ExpectQuery(selectTenantByDbName).WithArgs("test_company_db").
+		WillReturnRows(sqlmock.NewRows(tenantColumns).
+			AddRow("1", "Test", "test_company_db", utils.TenantStatusActive, "standard", time.Now(), utils.ProvisioningReady))
+
+	pool := utils.NewMapConnectionsDB()
+	pool.Add("auth_db", authDb)
+	pool.Add("test_company_db", companyDB)
+	chatService := dbchatservice.NewGRPCDBChatService(pool)
+	ctx := utils.ContextWithIdentity(context.Background(), &utils.Identity{Database: "test_company_db", UserId: "7"})
+	actionColumns := []string{"add_members", "remove_members"}
+
+	// An admin adds users: users without a role get user_chat, existing members are skipped
+	mock.ExpectQuery(selectMemberActions).WithArgs(int64(3), "7").
+		WillReturnRows(sqlmock.NewRows(actionColumns).AddRow(true, true))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, name_role FROM chat_roles WHERE chat_id = \$1`).WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name_role"}).AddRow(10, "admin_chat").AddRow(11, "user_chat"))
+	mock.ExpectQuery(`SELECT id FROM users WHERE id = ANY\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8).AddRow(9))
+	mock.ExpectQuery(`INSERT INTO chat_users \(user_id, chat_id, role_id\) VALUES \(\$1, \$2, NULLIF\(\$3, 0\)\),\(\$4, \$5, NULLIF\(\$6, 0\)\) ON CONFLICT DO NOTHING RETURNING user_id`).
+		WithArgs(int64(8), int64(3), int64(11), int64(9), int64(3), int64(10)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(8))
+	mock.ExpectCommit()
+
+	added, err := chatService.AddMembers(ctx, &dbchat.AddMembersRequest{ChatId: 3, Users: []*dbchat.UserId{
+		{UserId: 8},
+		{UserId: 9, RoleId: 10},
+		{UserId: 8},
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{8}, added.UserIds)
+
+	// A role of another chat is rejected
+	mock.ExpectQuery(selectMemberActions).WithArgs(int64(3), "7").
+		WillReturnRows(sqlmock.NewRows(actionColumns).AddRow(true, true))
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, name_role FROM chat_roles WHERE chat_id = \$1`).WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name_role"}).AddRow(10, "admin_chat").AddRow(11, "user_chat"))
+	mock.ExpectRollback()
+
+	_, err = chatService.AddMembers(ctx, &dbchat.AddMembersRequest{ChatId: 3, Users: []*dbchat.UserId{{UserId: 8, RoleId: 42}}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// A regular member cannot remove members, non-members cannot change the chat at all
+	mock.ExpectQuery(selectMemberActions).WithArgs(int64(3), "7").
+		WillReturnRows(sqlmock.NewRows(actionColumns).AddRow(false, false))
+	_, err = chatService.RemoveMembers(ctx, &dbchat.RemoveMembersRequest{ChatId: 3, UserIds: []int64{8}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	mock.ExpectQuery(selectMemberActions).WithArgs(int64(5), "7").
+		WillReturnRows(sqlmock.NewRows(actionColumns))
+	_, err = chatService.AddMembers(ctx, &dbchat.AddMembersRequest{ChatId: 5, Users: []*dbchat.UserId{{UserId: 8}}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// Only members of the chat are reported as removed
+	mock.ExpectQuery(selectMemberActions).WithArgs(int64(3), "7").
+		WillReturnRows(sqlmock.NewRows(actionColumns).AddRow(false, true))
+	mock.ExpectQuery(`DELETE FROM chat_users WHERE chat_id = \$1 AND user_id = ANY\(\$2\) RETURNING user_id`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(9))
+	removed, err := chatService.RemoveMembers(ctx, &dbchat.RemoveMembersRequest{ChatId: 3, UserIds: []int64{9, 12}})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{9}, removed.UserIds)
+
+	// Members are listed with their roles and emails from the auth database
+	mock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM chat_users WHERE chat_id = \$1 AND user_id = \$2\)`).WithArgs(int64(3), "7").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(`FROM chat_users cu\s+JOIN users u ON u.id = cu.user_id\s+LEFT JOIN chat_roles cr ON cr.id = cu.role_id\s+WHERE cu.chat_id = \$1`).
+		WithArgs(int64(3)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "authid", "role_id", "role"}).
+			AddRow(7, "11", 10, "admin_chat").
+			AddRow(8, "12", 0, ""))
+	authMock.ExpectQuery(`SELECT id, email FROM authUsers WHERE id = ANY\(\$1\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(11, "alice@example.com"))
+
+	members, err := chatService.ListMembers(ctx, &dbchat.ListMembersRequest{ChatId: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []*dbchat.ChatMember{
+		{UserId: 7, Email: "alice@example.com", RoleId: 10, Role: "admin_chat"},
+		{UserId: 8},
+	}, members.Members)
+
+	// Any member can leave, leaving a chat twice is reported
+	mock.ExpectExec(`DELETE FROM chat_users WHERE chat_id = \$1 AND user_id = \$2`).WithArgs(int64(3), "7").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	_, err = chatService.LeaveChat(ctx, &dbchat.LeaveChatRequest{ChatId: 3})
+	require.NoError(t, err)
+
+	mock.ExpectExec(`DELETE FROM chat_users WHERE chat_id = \$1 AND user_id = \$2`).WithArgs(int64(3), "7").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	_, err = chatService.LeaveChat(ctx, &dbchat.LeaveChatRequest{ChatId: 3})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, authMock.ExpectationsWereMet())
+}
+
+// TestSaveMessageAfterRemoval checks a user removed from a chat can no longer post to it.
+func TestSaveMessageAfterRemoval(t *testing.T) {
+	companyDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer companyDB.Close()
+
+	t.Setenv("DB_AUTH_NAME", "auth_db")
+	authDb, authMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer authDb.Close()
+	authMock.ExpectQuery(selectTenantByDbName).WithArgs("test_company_db").
+		WillReturnRows(sqlmock.NewRows(tenantColumns).
+			AddRow("1", "Test", "test_company_db", utils.TenantStatusActive, "standard", time.Now(), utils.ProvisioningReady))
+
+	pool := utils.NewMapConnectionsDB()
+	pool.Add("auth_db", authDb)
+	pool.Add("test_company_db", companyDB)
+	chatService := dbchatservice.NewGRPCDBChatService(pool)
+	ctx := context.Background()
+	insertMessage := `INSERT INTO messages \(chat_id, user_id, message\)\s+VALUES \(\$1, \$2, \$3\)\s+RETURNING id, created_at`
+
+	// A member posts to the chat
+	mock.ExpectQuery(selectChatMember).WithArgs(int64(3), "8").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(insertMessage).WithArgs(int64(3), "8", "hello").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(41, time.Now()))
+	messageID, _, err := dbchatservice.SaveChatMessage(ctx, companyDB, 3, "8", "hello")
+	require.NoError(t, err)
+	assert.Equal(t, int64(41), messageID)
+
+	// The admin removes the member
+	mock.ExpectQuery(selectMemberActions).WithArgs(int64(3), "7").
+		WillReturnRows(sqlmock.NewRows([]string{"add_members", "remove_members"}).AddRow(true, true))
+	mock.ExpectQuery(`DELETE FROM chat_users WHERE chat_id = \$1 AND user_id = ANY\(\$2\) RETURNING user_id`).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(8))
+	adminCtx := utils.ContextWithIdentity(ctx, &utils.Identity{Database: "test_company_db", UserId: "7"})
+	_, err = chatService.RemoveMembers(adminCtx, &dbchat.RemoveMembersRequest{ChatId: 3, UserIds: []int64{8}})
+	require.NoError(t, err)
+
+	// The next message of the removed user is rejected before anything is written
+	mock.ExpectQuery(selectChatMember).WithArgs(int64(3), "8").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	_, _, err = dbchatservice.SaveChatMessage(ctx, companyDB, 3, "8", "still here?")
+	assert.ErrorIs(t, err, dbchatservice.ErrNotChatMember)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, authMock.ExpectationsWereMet())
+}
@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/dbchatservice"
+	"crmSystem/proto/dbchat"
+	"crmSystem/utils"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestListMessagesSince checks only messages of the user's chats after the given id are returned with a capped limit.
+func TestListMessagesSince(t *testing.T) {
+	companyDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer companyDB.Close()
+
+	t.Setenv("DB_AUTH_NAME", "auth_db")
+	authDb, authMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer authDb.Close()
+	authMock.ExpectQuery(selectTenantByDbName).WithArgs("test_company_db").
+		WillReturnRows(sqlmock.NewRows(tenantColumns).
+			AddRow("1", "Test", "test_company_db", utils.TenantStatusActive, "standard", time.Now(), utils.ProvisioningReady))
+
+	pool := utils.NewMapConnectionsDB()
+	pool.Add("auth_db", authDb)
+	pool.Add("test_company_db", companyDB)
+	chatService := dbchatservice.NewGRPCDBChatService(pool)
+	ctx := utils.ContextWithIdentity(context.Background(), &utils.Identity{Database: "test_company_db", UserId: "7"})
+
+	sent := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	mock.ExpectQuery(`FROM messages m\s+JOIN chat_users cu ON cu.chat_id = m.chat_id\s+WHERE cu.user_id = \$1 AND m.id > \$2\s+ORDER BY m.id\s+LIMIT \$3`).
+		WithArgs("7", int64(41), 500).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_id", "user_id", "message", "created_at"}).
+			AddRow(42, 3, 8, "hello", sent).
+			AddRow(45, 5, 7, "reply", sent))
+
+	resp, err := chatService.ListMessagesSince(ctx, &dbchat.ListMessagesSinceRequest{AfterId: 41, Limit: 10000})
+	require.NoError(t, err)
+	require.Len(t, resp.Messages, 2)
+	assert.Equal(t, &dbchat.ChatMessage{MessageId: 42, ChatId: 3, UserId: 8, Content: "hello", CreatedAt: sent.Unix()}, resp.Messages[0])
+	assert.Equal(t, int64(45), resp.Messages[1].MessageId)
+
+	_, err = chatService.ListMessagesSince(ctx, &dbchat.ListMessagesSinceRequest{AfterId: -1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	mock.ExpectQuery(`FROM chats c\s+JOIN chat_users cu ON cu.chat_id = c.id\s+WHERE cu.user_id = \$1`).
+		WithArgs("7").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "chat_name"}).AddRow(3, "general").AddRow(5, "sales"))
+
+	chats, err := chatService.ListUserChats(ctx, &dbchat.ListUserChatsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []*dbchat.ChatInfo{{ChatId: 3, ChatName: "general"}, {ChatId: 5, ChatName: "sales"}}, chats.Chats)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
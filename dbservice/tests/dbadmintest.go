@@ -38,8 +38,8 @@ func TestRegisterUsersInCompany(t *testing.T) {
 	defer companyDB.Close()
 
 	// Manually add mock databases to the server pool
-	serverPool.MapDB["auth_db"] = authDB
-	serverPool.MapDB["test_company_db"] = companyDB
+	serverPool.Add("auth_db", authDB)
+	serverPool.Add("test_company_db", companyDB)
 
 	// Create the AdminServiceServer instance
 	adminService := dbadminservice.NewGRPCDBAdminService(serverPool)
@@ -63,11 +63,9 @@ func TestRegisterUsersInCompany(t *testing.T) {
 					{Email: "user2@example.com", Phone: "0987654321", RoleId: 2},
 				},
 			},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "admin1",
+			ctx: utils.ContextWithIdentity(metadata.NewIncomingContext(context.Background(), metadata.Pairs(
 				"authorization", "Bearer test_token",
-			)),
+			)), &utils.Identity{Database: "test_company_db", UserId: "admin1", CompanyId: "1"}),
 			prepareMocks: func(authMock, companyMock sqlmock.Sqlmock) {
 				// Mock authusers table checks (no existing users)
 				authMock.ExpectBegin()
@@ -79,12 +77,12 @@ func TestRegisterUsersInCompany(t *testing.T) {
 					WillReturnError(sql.ErrNoRows)
 
 				// Mock authusers table inserts
-				authMock.ExpectPrepare(`INSERT INTO authusers \(email, phone, password, company_id\) VALUES \(\$1, \$2, \$3, \$4\) RETURNING id`)
+				authMock.ExpectPrepare(`INSERT INTO authusers \(email, phone, password, company_id, status\) VALUES \(\$1, \$2, \$3, \$4, \$5\) RETURNING id`)
 				authMock.ExpectQuery(`INSERT INTO authusers`).
-					WithArgs("user1@example.com", "1234567890", "default_password", int64(1)).
+					WithArgs("user1@example.com", "1234567890", "default_password", int64(1), utils.AuthStatusInvited).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 				authMock.ExpectQuery(`INSERT INTO authusers`).
-					WithArgs("user2@example.com", "0987654321", "default_password", int64(1)).
+					WithArgs("user2@example.com", "0987654321", "default_password", int64(1), utils.AuthStatusInvited).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(2))
 				authMock.ExpectCommit()
 
@@ -100,8 +98,8 @@ func TestRegisterUsersInCompany(t *testing.T) {
 			},
 			expectedResp: &pbAdmin.RegisterUsersResponse{
 				Users: []*pbAdmin.UserResponse{
-					{Email: "user1@example.com", Phone: "1234567890", RoleId: 1, Password: "default_password"},
-					{Email: "user2@example.com", Phone: "0987654321", RoleId: 2, Password: "default_password"},
+					{Email: "user1@example.com", Phone: "1234567890", RoleId: 1},
+					{Email: "user2@example.com", Phone: "0987654321", RoleId: 2},
 				},
 				Message: "Пользователи успешно добавлены",
 			},
@@ -115,11 +113,9 @@ func TestRegisterUsersInCompany(t *testing.T) {
 					{Email: "user1@example.com", Phone: "1234567890", RoleId: 1},
 				},
 			},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "admin1",
+			ctx: utils.ContextWithIdentity(metadata.NewIncomingContext(context.Background(), metadata.Pairs(
 				"authorization", "Bearer test_token",
-			)),
+			)), &utils.Identity{Database: "test_company_db", UserId: "admin1", CompanyId: "1"}),
 			prepareMocks: func(authMock, companyMock sqlmock.Sqlmock) {
 				// Mock authusers table check (existing user)
 				authMock.ExpectBegin()
@@ -142,7 +138,7 @@ func TestRegisterUsersInCompany(t *testing.T) {
 			},
 			expectedResp: &pbAdmin.RegisterUsersResponse{
 				Users: []*pbAdmin.UserResponse{
-					{Email: "user1@example.com", Phone: "1234567890", RoleId: 1, Password: "default_password"},
+					{Email: "user1@example.com", Phone: "1234567890", RoleId: 1},
 				},
 				Message: "Пользователи успешно добавлены",
 			},
@@ -155,13 +151,12 @@ func TestRegisterUsersInCompany(t *testing.T) {
 				Users:     []*pbAdmin.User{{Email: "user1@example.com", Phone: "1234567890", RoleId: 1}},
 			},
 			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"user-id", "admin1",
 				"authorization", "Bearer test_token",
 			)),
 			prepareMocks:   func(authMock, companyMock sqlmock.Sqlmock) {},
 			expectedResp:   nil,
 			expectedErr:    true,
-			expectedErrMsg: "database не найдена в метаданных",
+			expectedErrMsg: "данные пользователя не найдены в токене",
 		},
 		{
 			name: "Missing user-id metadata",
@@ -170,13 +165,12 @@ func TestRegisterUsersInCompany(t *testing.T) {
 				Users:     []*pbAdmin.User{{Email: "user1@example.com", Phone: "1234567890", RoleId: 1}},
 			},
 			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
 				"authorization", "Bearer test_token",
 			)),
 			prepareMocks:   func(authMock, companyMock sqlmock.Sqlmock) {},
 			expectedResp:   nil,
 			expectedErr:    true,
-			expectedErrMsg: "userId не найдена в метаданных",
+			expectedErrMsg: "данные пользователя не найдены в токене",
 		},
 		{
 			name: "Missing authorization token",
@@ -184,10 +178,7 @@ func TestRegisterUsersInCompany(t *testing.T) {
 				CompanyId: "1",
 				Users:     []*pbAdmin.User{{Email: "user1@example.com", Phone: "1234567890", RoleId: 1}},
 			},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "admin1",
-			)),
+			ctx:            utils.ContextWithIdentity(context.Background(), &utils.Identity{Database: "test_company_db", UserId: "admin1", CompanyId: "1"}),
 			prepareMocks:   func(authMock, companyMock sqlmock.Sqlmock) {},
 			expectedResp:   nil,
 			expectedErr:    true,
@@ -5,6 +5,7 @@ import (
 	"crmSystem/dbauthservice"
 	"crmSystem/proto/dbauth"
 	"crmSystem/proto/redis"
+	"crmSystem/provisioning"
 	"crmSystem/utils"
 	"database/sql"
 	"encoding/json"
@@ -59,11 +60,11 @@ func TestLoginDB(t *testing.T) {
 	defer companyDB.Close()
 
 	// Manually add mock databases to the server pool
-	serverPool.MapDB["auth_db"] = authDB
-	serverPool.MapDB["test_company_db"] = companyDB
+	serverPool.Add("auth_db", authDB)
+	serverPool.Add("test_company_db", companyDB)
 
 	// Create the AuthServiceServer instance
-	authService := dbauthservice.NewGRPCDBAuthService(serverPool)
+	authService := dbauthservice.NewGRPCDBAuthService(serverPool, provisioning.NewProvisioner(serverPool))
 
 	// Test cases
 	tests := []struct {
@@ -95,9 +96,10 @@ func TestLoginDB(t *testing.T) {
 				}
 
 				// Mock authusers table query
-				authMock.ExpectQuery(`SELECT id, company_id FROM authusers WHERE \(email = \$1 OR phone = \$2\) AND password = \$3`).
-					WithArgs("user@example.com", "1234567890", "password123").
-					WillReturnRows(sqlmock.NewRows([]string{"id", "company_id"}).AddRow("1", "100"))
+				passwordHash, _ := utils.HashPassword("password123")
+				authMock.ExpectQuery(`SELECT id, company_id, password, status FROM authusers WHERE email = \$1`).
+					WithArgs("user@example.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "password", "status"}).AddRow("1", "100", passwordHash, utils.AuthStatusVerified))
 
 				// Mock companies table query
 				authMock.ExpectQuery(`SELECT dbName FROM companies WHERE id = \$1`).
@@ -105,9 +107,9 @@ func TestLoginDB(t *testing.T) {
 					WillReturnRows(sqlmock.NewRows([]string{"dbName"}).AddRow("test_company_db"))
 
 				// Mock users table query in company DB
-				companyMock.ExpectQuery(`SELECT id FROM users WHERE authId = \$1`).
+				companyMock.ExpectQuery(`SELECT u.id, r.roles FROM users u JOIN rights r ON r.id = u.rightsId WHERE u.authId = \$1`).
 					WithArgs("1").
-					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("user1"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "roles"}).AddRow("user1", "admin"))
 			},
 			expectedResp: &dbauth.LoginDBResponse{
 				Message: "Пользователь найден",
@@ -131,8 +133,8 @@ func TestLoginDB(t *testing.T) {
 				}
 
 				// Mock authusers table query (no rows)
-				authMock.ExpectQuery(`SELECT id, company_id FROM authusers WHERE \(email = \$1 OR phone = \$2\) AND password = \$3`).
-					WithArgs("user@example.com", "1234567890", "wrongpassword").
+				authMock.ExpectQuery(`SELECT id, company_id, password, status FROM authusers WHERE email = \$1`).
+					WithArgs("user@example.com").
 					WillReturnError(sql.ErrNoRows)
 			},
 			expectedResp:   nil,
@@ -163,6 +165,12 @@ func TestLoginDB(t *testing.T) {
 				"authorization", "Bearer test_token",
 			)),
 			prepareMocks: func(authMock, companyMock sqlmock.Sqlmock, redisClient *MockRedisClient) {
+				// The password is always verified, the cache only stores the company database
+				passwordHash, _ := utils.HashPassword("password123")
+				authMock.ExpectQuery(`SELECT id, company_id, password, status FROM authusers WHERE email = \$1`).
+					WithArgs("user@example.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "password", "status"}).AddRow("1", "100", passwordHash, utils.AuthStatusVerified))
+
 				// Mock Redis Get (cache hit)
 				redisClient.getFunc = func(ctx context.Context, in *redis.GetRedisRequest, opts ...grpc.CallOption) (*redis.GetRedisResponse, error) {
 					data := struct {
@@ -250,11 +258,11 @@ func TestRegisterCompany(t *testing.T) {
 	defer companyDB.Close()
 
 	// Manually add mock databases to the server pool
-	serverPool.MapDB["auth_db"] = authDB
-	serverPool.MapDB["test_company_db"] = companyDB
+	serverPool.Add("auth_db", authDB)
+	serverPool.Add("test_company_db", companyDB)
 
 	// Create the AuthServiceServer instance
-	authService := dbauthservice.NewGRPCDBAuthService(serverPool)
+	authService := dbauthservice.NewGRPCDBAuthService(serverPool, provisioning.NewProvisioner(serverPool))
 
 	// Test cases
 	tests := []struct {
@@ -298,8 +306,8 @@ func TestRegisterCompany(t *testing.T) {
 					WithArgs("test company", "123 main st", "test_company_db").
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("100"))
 				// Mock authusers table insert
-				authMock.ExpectQuery(`INSERT INTO authusers \(email, phone, password, company_id\) VALUES \(\$1, \$2, \$3, \$4\) RETURNING id`).
-					WithArgs("user@example.com", "1234567890", "password123", "100").
+				authMock.ExpectQuery(`INSERT INTO authusers \(email, phone, password, company_id, status\) VALUES \(\$1, \$2, \$3, \$4, \$5\) RETURNING id`).
+					WithArgs("user@example.com", "1234567890", sqlmock.AnyArg(), "100", utils.AuthStatusUnverified).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
 				authMock.ExpectCommit()
 
@@ -382,8 +390,8 @@ func TestRegisterCompany(t *testing.T) {
 					WithArgs("test company", "123 main st", "test_company_db").
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("100"))
 				// Mock authusers table insert (duplicate email)
-				authMock.ExpectQuery(`INSERT INTO authusers \(email, phone, password, company_id\) VALUES \(\$1, \$2, \$3, \$4\) RETURNING id`).
-					WithArgs("user@example.com", "1234567890", "password123", "100").
+				authMock.ExpectQuery(`INSERT INTO authusers \(email, phone, password, company_id, status\) VALUES \(\$1, \$2, \$3, \$4, \$5\) RETURNING id`).
+					WithArgs("user@example.com", "1234567890", sqlmock.AnyArg(), "100", utils.AuthStatusUnverified).
 					WillReturnError(fmt.Errorf("authusers_email_key"))
 			},
 			expectedResp:   nil,
@@ -26,7 +26,7 @@ func TestCreateChat(t *testing.T) {
 	defer companyDB.Close()
 
 	// Добавляем мок базы данных в пул соединений
-	serverPool.MapDB["test_company_db"] = companyDB
+	serverPool.Add("test_company_db", companyDB)
 
 	// Создаём экземпляр ChatServiceServer
 	chatService := dbchatservice.NewGRPCDBChatService(serverPool)
@@ -50,23 +50,35 @@ func TestCreateChat(t *testing.T) {
 					{UserId: 2, RoleId: 1},
 				},
 			},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "admin1",
+			ctx: utils.ContextWithIdentity(metadata.NewIncomingContext(context.Background(), metadata.Pairs(
 				"authorization", "Bearer test_token",
-			)),
+			)), &utils.Identity{Database: "test_company_db", UserId: "admin1"}),
 			prepareMocks: func(companyMock sqlmock.Sqlmock) {
 				// Мок для создания чата
 				companyMock.ExpectBegin()
 				companyMock.ExpectQuery(`INSERT INTO chats \(chat_name\) VALUES \(\$1\) RETURNING id`).
 					WithArgs("Test Chat").
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+				// Моки для создания ролей чата по умолчанию
+				companyMock.ExpectQuery(`INSERT INTO chat_roles \(chat_id, name_role, removable\)`).
+					WithArgs(int64(1), "admin_chat").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10))
+				companyMock.ExpectExec(`INSERT INTO available_actions_chat`).
+					WithArgs(int64(10), true).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+				companyMock.ExpectQuery(`INSERT INTO chat_roles \(chat_id, name_role, removable\)`).
+					WithArgs(int64(1), "user_chat").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+				companyMock.ExpectExec(`INSERT INTO available_actions_chat`).
+					WithArgs(int64(11), false).
+					WillReturnResult(sqlmock.NewResult(2, 1))
 				companyMock.ExpectCommit()
 
-				// Мок для добавления пользователей в чат
+				// Мок для добавления пользователей в чат с ролью user_chat
 				companyMock.ExpectBegin()
-				companyMock.ExpectExec(`INSERT INTO chat_users \(user_id, chat_id\) VALUES \(\$1, \$2\),\(\$3, \$4\)`).
-					WithArgs(int64(1), int64(1), int64(2), int64(1)).
+				companyMock.ExpectExec(`INSERT INTO chat_users \(user_id, chat_id, role_id\) VALUES \(\$1, \$2, NULLIF\(\$3, 0\)\),\(\$4, \$5, NULLIF\(\$6, 0\)\)`).
+					WithArgs(int64(1), int64(1), int64(11), int64(2), int64(1), int64(11)).
 					WillReturnResult(sqlmock.NewResult(0, 2))
 				companyMock.ExpectCommit()
 			},
@@ -84,13 +96,12 @@ func TestCreateChat(t *testing.T) {
 				UsersId:  []*dbchat.UserId{{UserId: 1, RoleId: 1}},
 			},
 			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"user-id", "admin1",
 				"authorization", "Bearer test_token",
 			)),
 			prepareMocks:   func(companyMock sqlmock.Sqlmock) {},
 			expectedResp:   nil,
 			expectedErr:    true,
-			expectedErrMsg: "database не найдена в метаданных",
+			expectedErrMsg: "данные пользователя не найдены в токене",
 		},
 		{
 			name: "Отсутствует метаданные user-id",
@@ -99,13 +110,12 @@ func TestCreateChat(t *testing.T) {
 				UsersId:  []*dbchat.UserId{{UserId: 1, RoleId: 1}},
 			},
 			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
 				"authorization", "Bearer test_token",
 			)),
 			prepareMocks:   func(companyMock sqlmock.Sqlmock) {},
 			expectedResp:   nil,
 			expectedErr:    true,
-			expectedErrMsg: "userId не найдена в метаданных",
+			expectedErrMsg: "данные пользователя не найдены в токене",
 		},
 		{
 			name: "Отсутствует токен авторизации",
@@ -113,10 +123,7 @@ func TestCreateChat(t *testing.T) {
 				ChatName: "Test Chat",
 				UsersId:  []*dbchat.UserId{{UserId: 1, RoleId: 1}},
 			},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "admin1",
-			)),
+			ctx:            utils.ContextWithIdentity(context.Background(), &utils.Identity{Database: "test_company_db", UserId: "admin1"}),
 			prepareMocks:   func(companyMock sqlmock.Sqlmock) {},
 			expectedResp:   nil,
 			expectedErr:    true,
@@ -128,11 +135,9 @@ func TestCreateChat(t *testing.T) {
 				ChatName: "Test Chat",
 				UsersId:  []*dbchat.UserId{{UserId: 1, RoleId: 1}},
 			},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "admin1",
+			ctx: utils.ContextWithIdentity(metadata.NewIncomingContext(context.Background(), metadata.Pairs(
 				"authorization", "Bearer test_token",
-			)),
+			)), &utils.Identity{Database: "test_company_db", UserId: "admin1"}),
 			prepareMocks: func(companyMock sqlmock.Sqlmock) {
 				companyMock.ExpectBegin()
 				companyMock.ExpectQuery(`INSERT INTO chats \(chat_name\) VALUES \(\$1\) RETURNING id`).
@@ -187,7 +192,7 @@ func TestAddUsersToChat(t *testing.T) {
 	defer companyDB.Close()
 
 	// Добавляем мок базы данных в пул соединений
-	serverPool.MapDB["test_company_db"] = companyDB
+	serverPool.Add("test_company_db", companyDB)
 
 	// Создаём экземпляр ChatServiceServer
 	chatService := dbchatservice.NewGRPCDBChatService(serverPool)
@@ -211,14 +216,11 @@ func TestAddUsersToChat(t *testing.T) {
 					{UserId: 2, RoleId: 1},
 				},
 			},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "admin1",
-			)),
+			ctx: utils.ContextWithIdentity(context.Background(), &utils.Identity{Database: "test_company_db", UserId: "admin1"}),
 			prepareMocks: func(companyMock sqlmock.Sqlmock) {
 				companyMock.ExpectBegin()
-				companyMock.ExpectExec(`INSERT INTO chat_users \(user_id, chat_id\) VALUES \(\$1, \$2\),\(\$3, \$4\)`).
-					WithArgs(int64(1), int64(1), int64(2), int64(1)).
+				companyMock.ExpectExec(`INSERT INTO chat_users \(user_id, chat_id, role_id\) VALUES \(\$1, \$2, NULLIF\(\$3, 0\)\),\(\$4, \$5, NULLIF\(\$6, 0\)\)`).
+					WithArgs(int64(1), int64(1), int64(1), int64(2), int64(1), int64(1)).
 					WillReturnResult(sqlmock.NewResult(0, 2))
 				companyMock.ExpectCommit()
 			},
@@ -233,13 +235,11 @@ func TestAddUsersToChat(t *testing.T) {
 				ChatId:  1,
 				UsersId: []*dbchat.UserId{{UserId: 1, RoleId: 1}},
 			},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"user-id", "admin1",
-			)),
+			ctx:            context.Background(),
 			prepareMocks:   func(companyMock sqlmock.Sqlmock) {},
 			expectedResp:   nil,
 			expectedErr:    true,
-			expectedErrMsg: "database не найдена в метаданных",
+			expectedErrMsg: "данные пользователя не найдены в токене",
 		},
 		{
 			name: "Ошибка добавления пользователей",
@@ -247,14 +247,11 @@ func TestAddUsersToChat(t *testing.T) {
 				ChatId:  1,
 				UsersId: []*dbchat.UserId{{UserId: 1, RoleId: 1}},
 			},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "admin1",
-			)),
+			ctx: utils.ContextWithIdentity(context.Background(), &utils.Identity{Database: "test_company_db", UserId: "admin1"}),
 			prepareMocks: func(companyMock sqlmock.Sqlmock) {
 				companyMock.ExpectBegin()
-				companyMock.ExpectExec(`INSERT INTO chat_users \(user_id, chat_id\) VALUES \(\$1, \$2\)`).
-					WithArgs(int64(1), int64(1)).
+				companyMock.ExpectExec(`INSERT INTO chat_users \(user_id, chat_id, role_id\) VALUES \(\$1, \$2, NULLIF\(\$3, 0\)\)`).
+					WithArgs(int64(1), int64(1), int64(1)).
 					WillReturnError(fmt.Errorf("database error"))
 				companyMock.ExpectRollback()
 			},
@@ -301,7 +298,7 @@ func TestSaveMessage(t *testing.T) {
 	defer companyDB.Close()
 
 	// Добавляем мок базы данных в пул соединений
-	serverPool.MapDB["test_company_db"] = companyDB
+	serverPool.Add("test_company_db", companyDB)
 
 	// Создаём экземпляр ChatServiceServer
 	chatService := dbchatservice.NewGRPCDBChatService(serverPool)
@@ -322,12 +319,13 @@ func TestSaveMessage(t *testing.T) {
 				ChatId:  1,
 				Content: "Hello, world!",
 			},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "user1",
+			ctx: utils.ContextWithIdentity(metadata.NewIncomingContext(context.Background(), metadata.Pairs(
 				"authorization", "Bearer test_token",
-			)),
+			)), &utils.Identity{Database: "test_company_db", UserId: "user1"}),
 			prepareMocks: func(companyMock sqlmock.Sqlmock) {
+				companyMock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM chat_users WHERE chat_id = \$1 AND user_id = \$2\)`).
+					WithArgs(int64(1), "user1").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
 				companyMock.ExpectQuery(`INSERT INTO messages \(chat_id, user_id, message\) VALUES \(\$1, \$2, \$3\) RETURNING id, created_at`).
 					WithArgs(int64(1), "user1", "Hello, world!").
 					WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, time.Now()))
@@ -347,13 +345,12 @@ func TestSaveMessage(t *testing.T) {
 				Content: "Hello, world!",
 			},
 			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"user-id", "user1",
 				"authorization", "Bearer test_token",
 			)),
 			prepareMocks:   func(companyMock sqlmock.Sqlmock) {},
 			expectedResp:   nil,
 			expectedErr:    true,
-			expectedErrMsg: "database не найдена в метаданных",
+			expectedErrMsg: "данные пользователя не найдены в токене",
 		},
 		{
 			name: "Отсутствует метаданные user-id",
@@ -362,13 +359,12 @@ func TestSaveMessage(t *testing.T) {
 				Content: "Hello, world!",
 			},
 			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
 				"authorization", "Bearer test_token",
 			)),
 			prepareMocks:   func(companyMock sqlmock.Sqlmock) {},
 			expectedResp:   nil,
 			expectedErr:    true,
-			expectedErrMsg: "userId не найдена в метаданных",
+			expectedErrMsg: "данные пользователя не найдены в токене",
 		},
 		{
 			name: "Отсутствует токен авторизации",
@@ -376,10 +372,7 @@ func TestSaveMessage(t *testing.T) {
 				ChatId:  1,
 				Content: "Hello, world!",
 			},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "user1",
-			)),
+			ctx:            utils.ContextWithIdentity(context.Background(), &utils.Identity{Database: "test_company_db", UserId: "user1"}),
 			prepareMocks:   func(companyMock sqlmock.Sqlmock) {},
 			expectedResp:   nil,
 			expectedErr:    true,
@@ -391,12 +384,13 @@ func TestSaveMessage(t *testing.T) {
 				ChatId:  1,
 				Content: "Hello, world!",
 			},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "user1",
+			ctx: utils.ContextWithIdentity(metadata.NewIncomingContext(context.Background(), metadata.Pairs(
 				"authorization", "Bearer test_token",
-			)),
+			)), &utils.Identity{Database: "test_company_db", UserId: "user1"}),
 			prepareMocks: func(CompanyMock sqlmock.Sqlmock) {
+				companyMock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM chat_users WHERE chat_id = \$1 AND user_id = \$2\)`).
+					WithArgs(int64(1), "user1").
+					WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
 				companyMock.ExpectQuery(`INSERT INTO messages \(chat_id, user_id, message\) VALUES \(\$1, \$2, \$3\) RETURNING id, created_at`).
 					WithArgs(int64(1), "user1", "Hello, world!").
 					WillReturnError(fmt.Errorf("database error"))
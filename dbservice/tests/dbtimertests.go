@@ -21,7 +21,7 @@ func TestChangeTimerDB(t *testing.T) {
 		t.Fatalf("Failed to create mock database: %v", err)
 	}
 	defer companyDB.Close()
-	serverPool.MapDB["test_company_db"] = companyDB
+	serverPool.Add("test_company_db", companyDB)
 	timerService := dbtimerservice.NewGRPCDBTimerService(serverPool)
 
 	tests := []struct {
@@ -36,11 +36,9 @@ func TestChangeTimerDB(t *testing.T) {
 		{
 			name: "Successful timer change",
 			req:  &dbtimer.ChangeTimerRequestDB{},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "user1",
+			ctx: utils.ContextWithIdentity(metadata.NewIncomingContext(context.Background(), metadata.Pairs(
 				"authorization", "Bearer test_token",
-			)),
+			)), &utils.Identity{Database: "test_company_db", UserId: "user1"}),
 			prepareMocks: func(companyMock sqlmock.Sqlmock) {
 				startTime := time.Now()
 				companyMock.ExpectQuery(`UPDATE user_timers SET start_time = CASE WHEN \$1 IS NOT NULL AND \$1 != '' THEN \$1 ELSE start_time END, end_time = CASE WHEN \$2 IS NOT NULL AND \$2 != '' THEN \$2 ELSE end_time END, is_active = CASE WHEN \$3 IS NOT NULL AND \$3 != '' THEN \$3 ELSE is_active END, description = CASE WHEN \$4 IS NOT NULL AND \$4 != '' THEN \$4 ELSE description END WHERE user_id = \$5 RETURNING start_time, end_time, id, duration, description, is_active`).
@@ -63,22 +61,19 @@ func TestChangeTimerDB(t *testing.T) {
 			name: "Missing database metadata",
 			req:  &dbtimer.ChangeTimerRequestDB{},
 			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"user-id", "user1",
 				"authorization", "Bearer test_token",
 			)),
 			prepareMocks:   func(companyMock sqlmock.Sqlmock) {},
 			expectedResp:   nil,
 			expectedErr:    true,
-			expectedErrMsg: "database не найдена в метаданных",
+			expectedErrMsg: "данные пользователя не найдены в токене",
 		},
 		{
 			name: "Database error",
 			req:  &dbtimer.ChangeTimerRequestDB{},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "user1",
+			ctx: utils.ContextWithIdentity(metadata.NewIncomingContext(context.Background(), metadata.Pairs(
 				"authorization", "Bearer test_token",
-			)),
+			)), &utils.Identity{Database: "test_company_db", UserId: "user1"}),
 			prepareMocks: func(companyMock sqlmock.Sqlmock) {
 				companyMock.ExpectQuery(`UPDATE user_timers SET start_time = CASE WHEN \$1 IS NOT NULL AND \$1 != '' THEN \$1 ELSE start_time END, end_time = CASE WHEN \$2 IS NOT NULL AND \$2 != '' THEN \$2 ELSE end_time END, is_active = CASE WHEN \$3 IS NOT NULL AND \$3 != '' THEN \$3 ELSE is_active END, description = CASE WHEN \$4 IS NOT NULL AND \$4 != '' THEN \$4 ELSE description END WHERE user_id = \$5 RETURNING start_time, end_time, id, duration, description, is_active`).
 					WithArgs("", "", "", "", "user1").
@@ -118,7 +113,7 @@ func TestStartTimerDB(t *testing.T) {
 		t.Fatalf("Failed to create mock database: %v", err)
 	}
 	defer companyDB.Close()
-	serverPool.MapDB["test_company_db"] = companyDB
+	serverPool.Add("test_company_db", companyDB)
 	timerService := dbtimerservice.NewGRPCDBTimerService(serverPool)
 
 	tests := []struct {
@@ -135,11 +130,9 @@ func TestStartTimerDB(t *testing.T) {
 			req: &dbtimer.StartEndTimerRequestDB{
 				Description: "test timer",
 			},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "user1",
+			ctx: utils.ContextWithIdentity(metadata.NewIncomingContext(context.Background(), metadata.Pairs(
 				"authorization", "Bearer test_token",
-			)),
+			)), &utils.Identity{Database: "test_company_db", UserId: "user1"}),
 			prepareMocks: func(companyMock sqlmock.Sqlmock) {
 				companyMock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM user_timers WHERE user_id = \$1 AND is_active = TRUE\)`).
 					WithArgs("user1").
@@ -163,11 +156,9 @@ func TestStartTimerDB(t *testing.T) {
 			req: &dbtimer.StartEndTimerRequestDB{
 				Description: "test timer",
 			},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "user1",
+			ctx: utils.ContextWithIdentity(metadata.NewIncomingContext(context.Background(), metadata.Pairs(
 				"authorization", "Bearer test_token",
-			)),
+			)), &utils.Identity{Database: "test_company_db", UserId: "user1"}),
 			prepareMocks: func(companyMock sqlmock.Sqlmock) {
 				companyMock.ExpectQuery(`SELECT EXISTS\(SELECT 1 FROM user_timers WHERE user_id = \$1 AND is_active = TRUE\)`).
 					WithArgs("user1").
@@ -195,13 +186,12 @@ func TestStartTimerDB(t *testing.T) {
 			name: "Missing user-id metadata",
 			req:  &dbtimer.StartEndTimerRequestDB{},
 			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
 				"authorization", "Bearer test_token",
 			)),
 			prepareMocks:   func(companyMock sqlmock.Sqlmock) {},
 			expectedResp:   nil,
 			expectedErr:    true,
-			expectedErrMsg: "userId не найдена в метаданных",
+			expectedErrMsg: "данные пользователя не найдены в токене",
 		},
 	}
 
@@ -233,7 +223,7 @@ func TestGetWorkingTimerDB(t *testing.T) {
 		t.Fatalf("Failed to create mock database: %v", err)
 	}
 	defer companyDB.Close()
-	serverPool.MapDB["test_company_db"] = companyDB
+	serverPool.Add("test_company_db", companyDB)
 	timerService := dbtimerservice.NewGRPCDBTimerService(serverPool)
 
 	tests := []struct {
@@ -248,11 +238,9 @@ func TestGetWorkingTimerDB(t *testing.T) {
 		{
 			name: "Successful get working timer",
 			req:  &dbtimer.WorkingTimerRequestDB{},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "user1",
+			ctx: utils.ContextWithIdentity(metadata.NewIncomingContext(context.Background(), metadata.Pairs(
 				"authorization", "Bearer test_token",
-			)),
+			)), &utils.Identity{Database: "test_company_db", UserId: "user1"}),
 			prepareMocks: func(companyMock sqlmock.Sqlmock) {
 				startTime := time.Now()
 				companyMock.ExpectQuery(`SELECT start_time, end_time, id FROM user_timers WHERE user_id = \$1 AND is_active = TRUE`).
@@ -269,12 +257,9 @@ func TestGetWorkingTimerDB(t *testing.T) {
 			expectedErr: false,
 		},
 		{
-			name: "Missing authorization token",
-			req:  &dbtimer.WorkingTimerRequestDB{},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "user1",
-			)),
+			name:           "Missing authorization token",
+			req:            &dbtimer.WorkingTimerRequestDB{},
+			ctx:            utils.ContextWithIdentity(context.Background(), &utils.Identity{Database: "test_company_db", UserId: "user1"}),
 			prepareMocks:   func(companyMock sqlmock.Sqlmock) {},
 			expectedResp:   nil,
 			expectedErr:    true,
@@ -310,7 +295,7 @@ func TestEndTimerDB(t *testing.T) {
 		t.Fatalf("Failed to create mock database: %v", err)
 	}
 	defer companyDB.Close()
-	serverPool.MapDB["test_company_db"] = companyDB
+	serverPool.Add("test_company_db", companyDB)
 	timerService := dbtimerservice.NewGRPCDBTimerService(serverPool)
 
 	tests := []struct {
@@ -325,11 +310,9 @@ func TestEndTimerDB(t *testing.T) {
 		{
 			name: "Successful end timer",
 			req:  &dbtimer.StartEndTimerRequestDB{},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "user1",
+			ctx: utils.ContextWithIdentity(metadata.NewIncomingContext(context.Background(), metadata.Pairs(
 				"authorization", "Bearer test_token",
-			)),
+			)), &utils.Identity{Database: "test_company_db", UserId: "user1"}),
 			prepareMocks: func(companyMock sqlmock.Sqlmock) {
 				startTime := time.Now().Add(-1 * time.Hour)
 				endTime := time.Now()
@@ -349,11 +332,9 @@ func TestEndTimerDB(t *testing.T) {
 		{
 			name: "Database error",
 			req:  &dbtimer.StartEndTimerRequestDB{},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "user1",
+			ctx: utils.ContextWithIdentity(metadata.NewIncomingContext(context.Background(), metadata.Pairs(
 				"authorization", "Bearer test_token",
-			)),
+			)), &utils.Identity{Database: "test_company_db", UserId: "user1"}),
 			prepareMocks: func(companyMock sqlmock.Sqlmock) {
 				companyMock.ExpectQuery(`UPDATE user_timers SET end_time = NOW\(\), is_active = FALSE WHERE user_id = \$1 AND is_active = TRUE RETURNING start_time, end_time, id`).
 					WithArgs("user1").
@@ -394,7 +375,7 @@ func TestAddTimerDB(t *testing.T) {
 		t.Fatalf("Failed to create mock database: %v", err)
 	}
 	defer companyDB.Close()
-	serverPool.MapDB["test_company_db"] = companyDB
+	serverPool.Add("test_company_db", companyDB)
 	timerService := dbtimerservice.NewGRPCDBTimerService(serverPool)
 
 	tests := []struct {
@@ -413,11 +394,9 @@ func TestAddTimerDB(t *testing.T) {
 				StartTime:   time.Now().UTC().Format(time.RFC3339),
 				EndTime:     time.Now().Add(1 * time.Hour).UTC().Format(time.RFC3339),
 			},
-			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"database", "test_company_db",
-				"user-id", "user1",
+			ctx: utils.ContextWithIdentity(metadata.NewIncomingContext(context.Background(), metadata.Pairs(
 				"authorization", "Bearer test_token",
-			)),
+			)), &utils.Identity{Database: "test_company_db", UserId: "user1"}),
 			prepareMocks: func(companyMock sqlmock.Sqlmock) {
 				startTime := time.Now()
 				endTime := time.Now().Add(1 * time.Hour)
@@ -440,13 +419,12 @@ func TestAddTimerDB(t *testing.T) {
 			name: "Missing database metadata",
 			req:  &dbtimer.AddTimerRequestDB{},
 			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs(
-				"user-id", "user1",
 				"authorization", "Bearer test_token",
 			)),
 			prepareMocks:   func(companyMock sqlmock.Sqlmock) {},
 			expectedResp:   nil,
 			expectedErr:    true,
-			expectedErrMsg: "database не найдена в метаданных",
+			expectedErrMsg: "данные пользователя не найдены в токене",
 		},
 	}
 
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/dbauthservice"
+	"crmSystem/utils"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	selectEmailOtpUserByEmail = `SELECT a.id, a.status, c.allow_email_otp FROM authusers a JOIN companies c ON c.id = a.company_id WHERE a.email = \$1`
+	selectEmailOtpUserById    = `SELECT a.id, a.status, c.allow_email_otp FROM authusers a JOIN companies c ON c.id = a.company_id WHERE a.id = \$1`
+	updateEmailOtpPolicy      = `UPDATE companies SET allow_email_otp = \$1 WHERE id = \$2`
+)
+
+// TestFindEmailOtpUser checks a login code is only sent to verified users of companies that allow it.
+func TestFindEmailOtpUser(t *testing.T) {
+	ctx := context.Background()
+	columns := []string{"id", "status", "allow_email_otp"}
+
+	t.Run("Allowed", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(selectEmailOtpUserByEmail).WithArgs("user@example.com").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("7", utils.AuthStatusVerified, true))
+
+		authUserId, err := dbauthservice.FindEmailOtpUser(ctx, db, " User@Example.com")
+		require.NoError(t, err)
+		assert.Equal(t, "7", authUserId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown email", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(selectEmailOtpUserByEmail).WithArgs("user@example.com").WillReturnRows(sqlmock.NewRows(columns))
+
+		_, err = dbauthservice.FindEmailOtpUser(ctx, db, "user@example.com")
+		assert.ErrorIs(t, err, dbauthservice.ErrAuthUserNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Company policy disabled", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(selectEmailOtpUserByEmail).WithArgs("user@example.com").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("7", utils.AuthStatusVerified, false))
+
+		_, err = dbauthservice.FindEmailOtpUser(ctx, db, "user@example.com")
+		assert.ErrorIs(t, err, dbauthservice.ErrEmailOtpDisabled)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Account not activated", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(selectEmailOtpUserById).WithArgs("7").
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("7", utils.AuthStatusUnverified, true))
+
+		err = dbauthservice.CheckEmailOtpAllowed(ctx, db, "7")
+		assert.ErrorIs(t, err, dbauthservice.ErrAccountNotActivated)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// TestSetCompanyEmailOtp checks the per-company email code policy is stored on the company row.
+func TestSetCompanyEmailOtp(t *testing.T) {
+	ctx := context.Background()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(updateEmailOtpPolicy).WithArgs(true, "3").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(updateEmailOtpPolicy).WithArgs(true, "404").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, dbauthservice.SetCompanyEmailOtp(ctx, db, "3", true))
+	assert.Error(t, dbauthservice.SetCompanyEmailOtp(ctx, db, "404", true))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
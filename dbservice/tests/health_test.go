@@ -0,0 +1,29 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/jwks"
+	"crmSystem/utils"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// TestHealthMethodWithoutToken checks health checks pass the identity interceptor without a JWT token.
+func TestHealthMethodWithoutToken(t *testing.T) {
+	interceptor := utils.NewIdentityInterceptor(jwks.NewKeySet("", nil), nil)
+	handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+
+	resp, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, handler)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", resp)
+
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/protobuff.dbChatService/CreateChat"}, handler)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,199 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/jwks"
+	"crmSystem/utils"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// signTestToken signs claims with the given key without a kid header, as tokens issued before key rotation.
+func signTestToken(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+	require.NoError(t, err)
+	return token
+}
+
+// signTestTokenWithKid signs claims with the given key and its kid the same way the auth service does.
+func signTestTokenWithKid(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = jwks.Thumbprint(&key.PublicKey)
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+// TestIdentityInterceptor tests that identity is taken only from the verified token.
+func TestIdentityInterceptor(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	// The next key of a rotation, already published but not the default one
+	nextKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	exp := time.Now().Add(time.Minute).Unix()
+	userClaims := jwt.MapClaims{
+		"sub":  "7",
+		"db":   "company_a",
+		"cid":  "1",
+		"role": "admin",
+		"typ":  "access",
+		"exp":  exp,
+	}
+
+	tests := []struct {
+		name             string
+		md               metadata.MD
+		expectedCode     codes.Code
+		expectedIdentity *utils.Identity
+		expectedReported bool
+	}{
+		{
+			name:             "Identity from token",
+			md:               metadata.Pairs("authorization", "Bearer "+signTestToken(t, key, userClaims)),
+			expectedCode:     codes.OK,
+			expectedIdentity: &utils.Identity{Database: "company_a", UserId: "7", CompanyId: "1", Role: "admin"},
+		},
+		{
+			name: "Matching legacy metadata is allowed",
+			md: metadata.Pairs("authorization", "Bearer "+signTestToken(t, key, userClaims),
+				"database", "company_a", "user-id", "7"),
+			expectedCode:     codes.OK,
+			expectedIdentity: &utils.Identity{Database: "company_a", UserId: "7", CompanyId: "1", Role: "admin"},
+		},
+		{
+			name: "Foreign database in metadata",
+			md: metadata.Pairs("authorization", "Bearer "+signTestToken(t, key, userClaims),
+				"database", "company_b"),
+			expectedCode:     codes.PermissionDenied,
+			expectedReported: true,
+		},
+		{
+			name: "Foreign user in metadata",
+			md: metadata.Pairs("authorization", "Bearer "+signTestToken(t, key, userClaims),
+				"user-id", "8"),
+			expectedCode:     codes.PermissionDenied,
+			expectedReported: true,
+		},
+		{
+			name:         "Internal service token without identity",
+			md:           metadata.Pairs("authorization", "Bearer "+signTestToken(t, key, jwt.MapClaims{"foo": "bar", "exp": exp})),
+			expectedCode: codes.OK,
+		},
+		{
+			name: "Pending second factor token is not an internal token",
+			md: metadata.Pairs("authorization", "Bearer "+signTestToken(t, key, jwt.MapClaims{
+				"typ": utils.MfaPendingTokenType, "aid": "3", "exp": exp,
+			})),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name: "Refresh token is not accepted as an access token",
+			md: metadata.Pairs("authorization", "Bearer "+signTestToken(t, key, jwt.MapClaims{
+				"sub": "7", "db": "company_a", "cid": "1", "role": "admin", "typ": "refresh", "exp": exp,
+			})),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name: "User token without a type",
+			md: metadata.Pairs("authorization", "Bearer "+signTestToken(t, key, jwt.MapClaims{
+				"sub": "7", "db": "company_a", "cid": "1", "role": "admin", "exp": exp,
+			})),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:             "Token signed with the next key during rotation",
+			md:               metadata.Pairs("authorization", "Bearer "+signTestTokenWithKid(t, nextKey, userClaims)),
+			expectedCode:     codes.OK,
+			expectedIdentity: &utils.Identity{Database: "company_a", UserId: "7", CompanyId: "1", Role: "admin"},
+		},
+		{
+			name:         "Token without kid is checked with the default key only",
+			md:           metadata.Pairs("authorization", "Bearer "+signTestToken(t, nextKey, userClaims)),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "Token with unknown kid",
+			md:           metadata.Pairs("authorization", "Bearer "+signTestTokenWithKid(t, otherKey, userClaims)),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "Token signed with another key",
+			md:           metadata.Pairs("authorization", "Bearer "+signTestToken(t, otherKey, userClaims)),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name: "Expired token",
+			md: metadata.Pairs("authorization", "Bearer "+signTestToken(t, key, jwt.MapClaims{
+				"sub": "7", "db": "company_a", "cid": "1", "exp": time.Now().Add(-time.Minute).Unix(),
+			})),
+			expectedCode: codes.Unauthenticated,
+		},
+		{
+			name:         "Missing token",
+			md:           metadata.Pairs("database", "company_a"),
+			expectedCode: codes.Unauthenticated,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reported := false
+			interceptor := utils.NewIdentityInterceptor(jwks.NewKeySet("", nil, &key.PublicKey, &nextKey.PublicKey),
+				func(_ context.Context, identity *utils.Identity, method string, _ string) {
+					reported = true
+					assert.Equal(t, "company_a", identity.Database)
+					assert.Equal(t, "/protobuff.dbChatService/CreateChat", method)
+				})
+
+			var gotIdentity *utils.Identity
+			handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+				gotIdentity, _ = utils.IdentityFromContext(ctx)
+				return "ok", nil
+			}
+
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			info := &grpc.UnaryServerInfo{FullMethod: "/protobuff.dbChatService/CreateChat"}
+
+			resp, err := interceptor(ctx, nil, info, handler)
+
+			assert.Equal(t, tt.expectedCode, status.Code(err))
+			if tt.expectedCode == codes.OK {
+				assert.Equal(t, "ok", resp)
+				assert.Equal(t, tt.expectedIdentity, gotIdentity)
+			} else {
+				assert.Nil(t, resp)
+			}
+			assert.Equal(t, tt.expectedReported, reported)
+		})
+	}
+}
+
+// TestIdentityFromContextWithoutIdentity checks that handlers reject requests without user identity.
+func TestIdentityFromContextWithoutIdentity(t *testing.T) {
+	_, err := utils.IdentityFromContext(context.Background())
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+// TestRequireInternalCaller checks that user tokens cannot call internal-only methods.
+func TestRequireInternalCaller(t *testing.T) {
+	assert.NoError(t, utils.RequireInternalCaller(context.Background()))
+
+	ctx := utils.ContextWithIdentity(context.Background(), &utils.Identity{Database: "company_a", UserId: "7"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(utils.RequireInternalCaller(ctx)))
+}
@@ -0,0 +1,192 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/dbauthservice"
+	"crmSystem/proto/redis"
+	"crmSystem/utils"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// memoryRedisClient keeps values and counters in memory, expirations are ignored.
+type memoryRedisClient struct {
+	redis.RedisServiceClient
+	values map[string]string
+}
+
+func newMemoryRedisClient() *memoryRedisClient {
+	return &memoryRedisClient{values: map[string]string{}}
+}
+
+func (m *memoryRedisClient) Get(_ context.Context, in *redis.GetRedisRequest, _ ...grpc.CallOption) (*redis.GetRedisResponse, error) {
+	value, ok := m.values[in.Key]
+	if !ok {
+		return &redis.GetRedisResponse{Status: http.StatusNotFound}, nil
+	}
+	return &redis.GetRedisResponse{Status: http.StatusOK, Message: value}, nil
+}
+
+func (m *memoryRedisClient) Set(_ context.Context, in *redis.SaveRedisRequest, _ ...grpc.CallOption) (*redis.SaveRedisResponse, error) {
+	m.values[in.Key] = in.Value
+	return &redis.SaveRedisResponse{Status: http.StatusOK}, nil
+}
+
+func (m *memoryRedisClient) Increment(_ context.Context, in *redis.IncrementRedisRequest, _ ...grpc.CallOption) (*redis.IncrementRedisResponse, error) {
+	value, _ := strconv.ParseInt(m.values[in.Key], 10, 64)
+	value++
+	m.values[in.Key] = strconv.FormatInt(value, 10)
+	return &redis.IncrementRedisResponse{Status: http.StatusOK, Value: value}, nil
+}
+
+func (m *memoryRedisClient) Delete(_ context.Context, in *redis.DeleteRedisRequest, _ ...grpc.CallOption) (*redis.DeleteRedisResponse, error) {
+	for _, key := range in.Keys {
+		delete(m.values, key)
+	}
+	return &redis.DeleteRedisResponse{Status: http.StatusOK}, nil
+}
+
+// TestLoginLockoutDuration checks the lockout starts at the limit and doubles up to the maximum.
+func TestLoginLockoutDuration(t *testing.T) {
+	assert.Equal(t, time.Duration(0), utils.LoginLockoutDuration(4, 5))
+	assert.Equal(t, utils.LoginLockoutBase, utils.LoginLockoutDuration(5, 5))
+	assert.Equal(t, 2*utils.LoginLockoutBase, utils.LoginLockoutDuration(6, 5))
+	assert.Equal(t, 8*utils.LoginLockoutBase, utils.LoginLockoutDuration(8, 5))
+	assert.Equal(t, utils.LoginLockoutMax, utils.LoginLockoutDuration(1000, 5))
+}
+
+// TestLoginLimiter checks an account is locked after the failure limit and unlocked by Reset.
+func TestLoginLimiter(t *testing.T) {
+	ctx := context.Background()
+	limiter := utils.NewLoginLimiter(newMemoryRedisClient())
+	account := utils.LoginAccount("", "User@Example.com", "")
+	assert.Equal(t, "email:user@example.com", account)
+
+	for i := 1; i < utils.AccountFailureLimit; i++ {
+		lockouts, err := limiter.RegisterFailure(ctx, account, "10.0.0.1")
+		require.NoError(t, err)
+		assert.Empty(t, lockouts)
+	}
+
+	retryAfter, err := limiter.RetryAfter(ctx, account, "10.0.0.1")
+	require.NoError(t, err)
+	assert.Zero(t, retryAfter)
+
+	lockouts, err := limiter.RegisterFailure(ctx, account, "10.0.0.1")
+	require.NoError(t, err)
+	require.Len(t, lockouts, 1)
+	assert.Equal(t, utils.LockoutScopeAccount, lockouts[0].Scope)
+	assert.Equal(t, utils.LoginLockoutBase, lockouts[0].Duration)
+
+	// The account lockout applies from any IP
+	retryAfter, err = limiter.RetryAfter(ctx, account, "10.0.0.2")
+	require.NoError(t, err)
+	assert.InDelta(t, utils.LoginLockoutBase.Seconds(), retryAfter.Seconds(), 2)
+
+	require.NoError(t, limiter.Reset(ctx, account))
+	retryAfter, err = limiter.RetryAfter(ctx, account, "10.0.0.2")
+	require.NoError(t, err)
+	assert.Zero(t, retryAfter)
+}
+
+// TestLoginLimiterIp checks failures from one IP across accounts lock that IP only.
+func TestLoginLimiterIp(t *testing.T) {
+	ctx := context.Background()
+	limiter := utils.NewLoginLimiter(newMemoryRedisClient())
+
+	var lockouts []utils.LoginLockout
+	for i := 0; i < utils.IpFailureLimit; i++ {
+		var err error
+		lockouts, err = limiter.RegisterFailure(ctx, utils.LoginAccount("", "", strconv.Itoa(i)), "10.0.0.1")
+		require.NoError(t, err)
+	}
+	require.Len(t, lockouts, 1)
+	assert.Equal(t, utils.LockoutScopeIp, lockouts[0].Scope)
+
+	retryAfter, err := limiter.RetryAfter(ctx, utils.LoginAccount("", "other@example.com", ""), "10.0.0.1")
+	require.NoError(t, err)
+	assert.Greater(t, retryAfter, time.Duration(0))
+
+	retryAfter, err = limiter.RetryAfter(ctx, utils.LoginAccount("", "other@example.com", ""), "10.0.0.2")
+	require.NoError(t, err)
+	assert.Zero(t, retryAfter)
+}
+
+// TestAuthenticateUserLimitedCountsByAccount checks failed logins are counted on the resolved account:
+// pairing the victim's phone with a new email per attempt neither checks the victim's password nor
+// spreads the victim's failures over fresh counters, and attempts by email and by phone share one counter.
+func TestAuthenticateUserLimitedCountsByAccount(t *testing.T) {
+	hash, err := utils.HashPassword("victim-password")
+	require.NoError(t, err)
+	columns := []string{"id", "company_id", "password", "status"}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+	limiter := utils.NewLoginLimiter(newMemoryRedisClient())
+
+	// The victim's phone next to a throwaway email only looks the email up, the victim's row is not checked
+	for i := 0; i < 2*utils.AccountFailureLimit; i++ {
+		email := fmt.Sprintf("throwaway%d@example.com", i)
+		mock.ExpectQuery(selectAuthUserQuery).WithArgs(email).WillReturnError(sql.ErrNoRows)
+		_, err := dbauthservice.AuthenticateUserLimited(ctx, db, limiter, email, "5550001", "guess", fmt.Sprintf("10.0.1.%d", i))
+		assert.ErrorIs(t, err, dbauthservice.ErrInvalidCredentials)
+	}
+
+	// Wrong passwords by phone and by email land on the victim's single counter, from different IPs
+	for i := 0; i < utils.AccountFailureLimit; i++ {
+		email, phone := "victim@example.com", ""
+		query, arg := selectAuthUserQuery, "victim@example.com"
+		if i%2 == 0 {
+			email, phone = "", "5550001"
+			query, arg = selectAuthUserByPhoneQuery, "5550001"
+		}
+		mock.ExpectQuery(query).WithArgs(arg).
+			WillReturnRows(sqlmock.NewRows(columns).AddRow("1", "100", hash, utils.AuthStatusVerified))
+		attempt, err := dbauthservice.AuthenticateUserLimited(ctx, db, limiter, email, phone, "guess", fmt.Sprintf("10.0.2.%d", i))
+		if i < utils.AccountFailureLimit-1 {
+			assert.ErrorIs(t, err, dbauthservice.ErrInvalidCredentials)
+			continue
+		}
+		assert.ErrorIs(t, err, dbauthservice.ErrLoginLocked)
+		assert.Equal(t, utils.LoginLockoutBase, attempt.RetryAfter)
+		require.Len(t, attempt.Lockouts, 1)
+		assert.Equal(t, utils.LoginAccount("1", "", ""), attempt.Lockouts[0].Subject)
+	}
+
+	// While locked even the right password is not checked
+	mock.ExpectQuery(selectAuthUserQuery).WithArgs("victim@example.com").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("1", "100", hash, utils.AuthStatusVerified))
+	attempt, err := dbauthservice.AuthenticateUserLimited(ctx, db, limiter, "victim@example.com", "", "victim-password", "10.0.3.1")
+	assert.ErrorIs(t, err, dbauthservice.ErrLoginLocked)
+	assert.Greater(t, attempt.RetryAfter, time.Duration(0))
+
+	// After an unlock the right password signs in and resets the counter
+	require.NoError(t, limiter.Reset(ctx, utils.LoginAccount("1", "", "")))
+	mock.ExpectQuery(selectAuthUserByPhoneQuery).WithArgs("5550001").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("1", "100", hash, utils.AuthStatusVerified))
+	attempt, err = dbauthservice.AuthenticateUserLimited(ctx, db, limiter, "", "5550001", "victim-password", "10.0.3.1")
+	require.NoError(t, err)
+	assert.Equal(t, "1", attempt.AuthUserId)
+	assert.Equal(t, "100", attempt.CompanyId)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestClientIpFromContext checks the client IP is read from the incoming metadata.
+func TestClientIpFromContext(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("client-ip", "10.0.0.1"))
+	assert.Equal(t, "10.0.0.1", utils.ClientIpFromContext(ctx))
+	assert.Equal(t, "", utils.ClientIpFromContext(context.Background()))
+}
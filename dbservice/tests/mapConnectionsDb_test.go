@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/utils"
+	"database/sql"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPool is a connection pool whose databases are sqlmock connections.
+// Every company database is registered as an active tenant in the mocked auth database.
+type testPool struct {
+	pool     *utils.MapConnectionsDB
+	authMock sqlmock.Sqlmock
+	opened   sync.Map // database name -> number of opens
+}
+
+func newTestPool(t *testing.T, config utils.PoolConfig, companies ...string) *testPool {
+	t.Helper()
+	t.Setenv("DB_AUTH_NAME", "auth_db")
+
+	authDb, authMock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { authDb.Close() })
+	authMock.MatchExpectationsInOrder(false)
+	for i, name := range companies {
+		authMock.ExpectQuery(selectTenantByDbName).WithArgs(name).
+			WillReturnRows(sqlmock.NewRows(tenantColumns).
+				AddRow(fmt.Sprint(i+1), name, name, utils.TenantStatusActive, "standard", time.Now(), utils.ProvisioningReady))
+	}
+
+	tp := &testPool{authMock: authMock}
+	config.Open = func(dbName string) (*sql.DB, error) {
+		counter, _ := tp.opened.LoadOrStore(dbName, new(atomic.Int32))
+		counter.(*atomic.Int32).Add(1)
+		if dbName == "auth_db" {
+			return authDb, nil
+		}
+		// Opening is slow enough for concurrent callers to overlap
+		time.Sleep(10 * time.Millisecond)
+		db, _, err := sqlmock.New()
+		return db, err
+	}
+	tp.pool = utils.NewMapConnectionsDBWithConfig(config)
+
+	// Warm the tenant cache so that concurrent callers do not query the registry
+	for _, name := range companies {
+		_, err := tp.pool.Tenants().ByDbName(context.Background(), name)
+		require.NoError(t, err)
+	}
+	return tp
+}
+
+func (tp *testPool) opens(dbName string) int32 {
+	counter, ok := tp.opened.Load(dbName)
+	if !ok {
+		return 0
+	}
+	return counter.(*atomic.Int32).Load()
+}
+
+// TestPoolSingleflight checks concurrent requests to one database open it only once.
+func TestPoolSingleflight(t *testing.T) {
+	tp := newTestPool(t, utils.PoolConfig{MaxOpenConns: 100, MaxOpenConnsPerDb: 10}, "company_a")
+	before := tp.pool.Stats()
+
+	const callers = 50
+	dbs := make([]*sql.DB, callers)
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			db, err := tp.pool.GetDb("company_a")
+			assert.NoError(t, err)
+			dbs[i] = db
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), tp.opens("company_a"))
+	for _, db := range dbs {
+		assert.Same(t, dbs[0], db)
+	}
+
+	stats := tp.pool.Stats()
+	assert.Equal(t, int64(1), stats.Misses-before.Misses)
+	assert.Equal(t, int64(callers-1), stats.Hits-before.Hits)
+	// The auth database and the company database
+	assert.Equal(t, 2, stats.Databases)
+}
+
+// TestPoolLruEviction checks the least recently used database is closed when the cap is reached.
+func TestPoolLruEviction(t *testing.T) {
+	// Three databases fit: the auth database and two companies
+	tp := newTestPool(t, utils.PoolConfig{MaxOpenConns: 3, MaxOpenConnsPerDb: 1}, "company_a", "company_b", "company_c")
+
+	dbA, err := tp.pool.GetDb("company_a")
+	require.NoError(t, err)
+	_, err = tp.pool.GetDb("company_b")
+	require.NoError(t, err)
+
+	// company_a becomes the most recently used, so company_b is evicted
+	_, err = tp.pool.GetDb("company_a")
+	require.NoError(t, err)
+	_, err = tp.pool.GetDb("company_c")
+	require.NoError(t, err)
+
+	stats := tp.pool.Stats()
+	assert.Equal(t, 3, stats.Databases)
+	assert.Equal(t, 3, stats.MaxDatabases)
+	assert.Equal(t, int64(1), stats.Evictions)
+
+	again, err := tp.pool.GetDb("company_a")
+	require.NoError(t, err)
+	assert.Same(t, dbA, again)
+	assert.Equal(t, int32(1), tp.opens("company_a"))
+
+	// The evicted database is opened again on the next request
+	_, err = tp.pool.GetDb("company_b")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), tp.opens("company_b"))
+	assert.Equal(t, int32(1), tp.opens("auth_db"))
+}
+
+// TestPoolExhausted checks databases in recent use are not evicted and the cap is enforced.
+func TestPoolExhausted(t *testing.T) {
+	tp := newTestPool(t, utils.PoolConfig{MaxOpenConns: 2, MaxOpenConnsPerDb: 1, EvictionGrace: time.Hour},
+		"company_a", "company_b")
+
+	_, err := tp.pool.GetDb("company_a")
+	require.NoError(t, err)
+
+	_, err = tp.pool.GetDb("company_b")
+	assert.ErrorIs(t, err, utils.ErrPoolExhausted)
+	assert.Equal(t, int32(0), tp.opens("company_b"))
+}
+
+// TestPoolIdleEviction checks idle company databases are closed while the auth database stays open.
+func TestPoolIdleEviction(t *testing.T) {
+	tp := newTestPool(t, utils.PoolConfig{MaxOpenConns: 10, MaxOpenConnsPerDb: 1, IdleTimeout: time.Millisecond},
+		"company_a")
+
+	_, err := tp.pool.GetDb("company_a")
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	assert.Equal(t, 1, tp.pool.EvictIdle())
+	stats := tp.pool.Stats()
+	assert.Equal(t, 1, stats.Databases)
+	assert.Equal(t, int64(1), stats.Evictions)
+}
+
+// TestPoolConcurrentAccess runs lookups, idle eviction and stats together under the race detector.
+func TestPoolConcurrentAccess(t *testing.T) {
+	companies := []string{"company_a", "company_b", "company_c", "company_d", "company_e"}
+	tp := newTestPool(t, utils.PoolConfig{MaxOpenConns: 4, MaxOpenConnsPerDb: 1, IdleTimeout: time.Millisecond},
+		companies...)
+	before := tp.pool.Stats()
+
+	var served atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// More databases are opened at once than fit the cap, the extra ones are refused
+			db, release, err := tp.pool.Acquire(companies[i%len(companies)])
+			defer release()
+			if err != nil {
+				assert.ErrorIs(t, err, utils.ErrPoolExhausted)
+			} else {
+				assert.NotNil(t, db)
+				served.Add(1)
+			}
+			if i%10 == 0 {
+				tp.pool.EvictIdle()
+				tp.pool.Stats()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	stats := tp.pool.Stats()
+	assert.LessOrEqual(t, stats.Databases, stats.MaxDatabases)
+	assert.Equal(t, served.Load(), stats.Hits+stats.Misses-before.Hits-before.Misses)
+}
+
+// TestPoolLeases checks a leased database is neither evicted nor closed until the lease is released.
+func TestPoolLeases(t *testing.T) {
+	// The auth database and one company fit
+	tp := newTestPool(t, utils.PoolConfig{MaxOpenConns: 2, MaxOpenConnsPerDb: 1, IdleTimeout: time.Millisecond},
+		"company_a", "company_b")
+
+	db, release, err := tp.pool.Acquire("company_a")
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	// The lease keeps the idle database open and its slot taken
+	assert.Equal(t, 0, tp.pool.EvictIdle())
+	_, err = tp.pool.GetDb("company_b")
+	assert.ErrorIs(t, err, utils.ErrPoolExhausted)
+
+	// Removed from the pool while leased, the database stays usable for the lease holder
+	tp.pool.Remove("company_a")
+	assert.NoError(t, db.Ping())
+
+	release()
+	assert.Error(t, db.Ping(), "the database is closed with the last lease")
+	release()
+
+	_, releaseB, err := tp.pool.Acquire("company_b")
+	require.NoError(t, err)
+	releaseB()
+}
+
+// TestPoolRejectsInactiveTenant checks no connection is opened to a database outside the registry.
+func TestPoolRejectsInactiveTenant(t *testing.T) {
+	tp := newTestPool(t, utils.PoolConfig{MaxOpenConns: 10, MaxOpenConnsPerDb: 1})
+	tp.authMock.ExpectQuery(selectTenantByDbName).WithArgs("unknown_db").WillReturnRows(sqlmock.NewRows(tenantColumns))
+
+	_, err := tp.pool.GetDb("unknown_db")
+	assert.ErrorIs(t, err, utils.ErrTenantNotFound)
+	assert.Equal(t, int32(0), tp.opens("unknown_db"))
+}
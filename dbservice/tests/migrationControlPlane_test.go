@@ -0,0 +1,297 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/migrations"
+	"database/sql"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMigrator is a company database schema kept in memory.
+type fakeMigrator struct {
+	plane   *fakeSchemas
+	dbName  string
+	version uint
+	dirty   bool
+}
+
+func (m *fakeMigrator) Version() (uint, bool, error) {
+	return m.version, m.dirty, nil
+}
+
+func (m *fakeMigrator) Migrate(version uint) error {
+	m.plane.mu.Lock()
+	defer m.plane.mu.Unlock()
+	if err := m.plane.failures[m.dbName]; err != nil {
+		m.plane.schemas[m.dbName] = schema{version: version, dirty: true}
+		return err
+	}
+	m.plane.migrated = append(m.plane.migrated, m.dbName)
+	m.plane.schemas[m.dbName] = schema{version: version}
+	return nil
+}
+
+func (m *fakeMigrator) Force(version int) error {
+	m.plane.mu.Lock()
+	defer m.plane.mu.Unlock()
+	m.plane.forced[m.dbName] = version
+	m.version, m.dirty = uint(max(version, 0)), false
+	m.plane.schemas[m.dbName] = schema{version: m.version}
+	return nil
+}
+
+func (m *fakeMigrator) Close() error {
+	m.plane.open.Add(-1)
+	return nil
+}
+
+type schema struct {
+	version uint
+	dirty   bool
+}
+
+// fakeSchemas opens fake migrators and tracks how many are open at once.
+type fakeSchemas struct {
+	mu       sync.Mutex
+	schemas  map[string]schema
+	failures map[string]error
+	forced   map[string]int
+	migrated []string
+
+	open    atomic.Int32
+	maxOpen atomic.Int32
+}
+
+func newFakeSchemas(schemas map[string]schema) *fakeSchemas {
+	return &fakeSchemas{schemas: schemas, failures: map[string]error{}, forced: map[string]int{}}
+}
+
+func (f *fakeSchemas) config(authDb *sql.DB) migrations.ControlPlaneConfig {
+	config := migrations.ControlPlaneConfig{
+		Versions: []uint{1, 2, 3, 4, 5, 6, 7, 8, 9},
+		Registered: func(context.Context) ([]migrations.TenantDatabase, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			var databases []migrations.TenantDatabase
+			for name := range f.schemas {
+				databases = append(databases, migrations.TenantDatabase{DbName: name, Ready: true})
+			}
+			return databases, nil
+		},
+		ServerDatabases: func(context.Context) ([]string, error) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			names := []string{"postgres", "AuthorizationDB"}
+			for name := range f.schemas {
+				names = append(names, name)
+			}
+			return names, nil
+		},
+		Open: func(dbName string) (migrations.Migrator, error) {
+			open := f.open.Add(1)
+			for {
+				current := f.maxOpen.Load()
+				if open <= current || f.maxOpen.CompareAndSwap(current, open) {
+					break
+				}
+			}
+			// Migrations are slow enough for concurrent runs to overlap
+			time.Sleep(5 * time.Millisecond)
+
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			s := f.schemas[dbName]
+			return &fakeMigrator{plane: f, dbName: dbName, version: s.version, dirty: s.dirty}, nil
+		},
+	}
+	if authDb != nil {
+		config.AuthDb = func() (*sql.DB, error) { return authDb, nil }
+	}
+	return config
+}
+
+// TestRunMigrationsConcurrencyLimit checks every database is migrated with at most the given number at once.
+func TestRunMigrationsConcurrencyLimit(t *testing.T) {
+	schemas := map[string]schema{}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		schemas["company_"+name] = schema{version: 7}
+	}
+	schemas["company_new"] = schema{version: 9}
+	fake := newFakeSchemas(schemas)
+	plane := migrations.NewControlPlaneWithConfig(fake.config(nil))
+
+	report, err := plane.Run(context.Background(), migrations.RunOptions{Concurrency: 3, Actor: "ops"})
+	require.NoError(t, err)
+
+	assert.False(t, report.Stopped)
+	assert.LessOrEqual(t, fake.maxOpen.Load(), int32(3))
+	assert.Len(t, fake.migrated, 8)
+	for _, result := range report.Results {
+		if result.DbName == "company_new" {
+			assert.Equal(t, migrations.StatusUpToDate, result.Status)
+			continue
+		}
+		assert.Equal(t, migrations.StatusApplied, result.Status)
+		assert.Equal(t, []uint{8, 9}, result.Pending)
+	}
+}
+
+// TestRunMigrationsStopsOnFailure checks no database is migrated after the first failure and the results are recorded.
+func TestRunMigrationsStopsOnFailure(t *testing.T) {
+	authDb, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer authDb.Close()
+
+	fake := newFakeSchemas(map[string]schema{
+		"company_a": {version: 8},
+		"company_b": {version: 8},
+		"company_c": {version: 8},
+	})
+	fake.failures["company_b"] = errors.New("column already exists")
+	plane := migrations.NewControlPlaneWithConfig(fake.config(authDb))
+
+	mock.ExpectExec(`INSERT INTO migrationHistory`).
+		WithArgs(sqlmock.AnyArg(), "company_a", migrations.OperationUp, int64(8), int64(9), migrations.StatusApplied, "", "ops", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO migrationHistory`).
+		WithArgs(sqlmock.AnyArg(), "company_b", migrations.OperationUp, int64(8), int64(9), migrations.StatusFailed, "column already exists", "ops", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+
+	report, err := plane.Run(context.Background(), migrations.RunOptions{Concurrency: 1, Actor: "ops"})
+	require.NoError(t, err)
+
+	assert.True(t, report.Stopped)
+	assert.Equal(t, []string{"company_a"}, fake.migrated)
+	assert.Equal(t, migrations.StatusApplied, report.Results[0].Status)
+	assert.Equal(t, migrations.StatusFailed, report.Results[1].Status)
+	assert.Equal(t, migrations.StatusSkipped, report.Results[2].Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestRunMigrationsDryRun checks a dry run reports every database without changing any.
+func TestRunMigrationsDryRun(t *testing.T) {
+	fake := newFakeSchemas(map[string]schema{
+		"company_a": {version: 6},
+		"company_b": {version: 5, dirty: true},
+		"company_c": {version: 9},
+	})
+	plane := migrations.NewControlPlaneWithConfig(fake.config(nil))
+
+	report, err := plane.Run(context.Background(), migrations.RunOptions{DryRun: true, Actor: "ops"})
+	require.NoError(t, err)
+
+	assert.False(t, report.Stopped)
+	assert.Empty(t, fake.migrated)
+	assert.Equal(t, migrations.StatusPlanned, report.Results[0].Status)
+	assert.Equal(t, []uint{7, 8, 9}, report.Results[0].Pending)
+	assert.Equal(t, migrations.StatusFailed, report.Results[1].Status)
+	assert.Contains(t, report.Results[1].Error, "dirty")
+	assert.Equal(t, migrations.StatusUpToDate, report.Results[2].Status)
+}
+
+// TestMigrateTenant checks a single database is rolled back or migrated to the requested version.
+func TestMigrateTenant(t *testing.T) {
+	ctx := context.Background()
+	fake := newFakeSchemas(map[string]schema{
+		"company_a": {version: 9},
+		"company_b": {version: 6, dirty: true},
+	})
+	plane := migrations.NewControlPlaneWithConfig(fake.config(nil))
+
+	t.Run("Rollback", func(t *testing.T) {
+		result, err := plane.MigrateTenant(ctx, "company_a", 7, migrations.TenantOptions{Actor: "ops"})
+		require.NoError(t, err)
+		assert.Equal(t, migrations.OperationRollback, result.Operation)
+		assert.Equal(t, []uint{9, 8}, result.Pending)
+		assert.Equal(t, migrations.StatusApplied, result.Status)
+		assert.Equal(t, uint(7), fake.schemas["company_a"].version)
+	})
+
+	t.Run("Dirty database needs force", func(t *testing.T) {
+		result, err := plane.MigrateTenant(ctx, "company_b", 0, migrations.TenantOptions{Actor: "ops"})
+		require.NoError(t, err)
+		assert.Equal(t, migrations.StatusFailed, result.Status)
+		assert.True(t, fake.schemas["company_b"].dirty)
+		assert.Empty(t, fake.forced)
+	})
+
+	t.Run("Force repeats the interrupted migration", func(t *testing.T) {
+		result, err := plane.MigrateTenant(ctx, "company_b", 0, migrations.TenantOptions{Actor: "ops", Force: true})
+		require.NoError(t, err)
+		assert.Equal(t, migrations.StatusApplied, result.Status)
+		assert.Equal(t, 5, fake.forced["company_b"])
+		assert.Equal(t, []uint{6, 7, 8, 9}, result.Pending)
+		assert.Equal(t, schema{version: 9}, fake.schemas["company_b"])
+	})
+
+	t.Run("Invalid arguments", func(t *testing.T) {
+		_, err := plane.MigrateTenant(ctx, "company_a", 42, migrations.TenantOptions{Actor: "ops"})
+		assert.ErrorIs(t, err, migrations.ErrUnknownVersion)
+
+		_, err = plane.MigrateTenant(ctx, "AuthorizationDB", 0, migrations.TenantOptions{Actor: "ops"})
+		assert.ErrorIs(t, err, migrations.ErrUnknownDatabase)
+
+		_, err = plane.MigrateTenant(ctx, "company_a", 0, migrations.TenantOptions{})
+		assert.ErrorIs(t, err, migrations.ErrActorRequired)
+	})
+}
+
+// TestMigrationInventory checks only the template and registered tenants are migrated and orphans are reported.
+func TestMigrationInventory(t *testing.T) {
+	const (
+		registered   = "qwertyuiopasdfghjklzxcvbn"
+		pending      = "QWERTYUIOPASDFGHJKLZXCVBN"
+		unregistered = "mnbvcxzlkjhgfdsapoiuytrew"
+		missing      = "MNBVCXZLKJHGFDSAPOIUYTREW"
+	)
+	authDb, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer authDb.Close()
+	serverDb, serverMock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer serverDb.Close()
+
+	fake := newFakeSchemas(map[string]schema{})
+	config := fake.config(nil)
+	config.Template = "company_template"
+	config.Registered = func(ctx context.Context) ([]migrations.TenantDatabase, error) {
+		return migrations.RegisteredDatabases(ctx, authDb)
+	}
+	config.ServerDatabases = func(ctx context.Context) ([]string, error) {
+		return migrations.ServerDatabases(ctx, serverDb)
+	}
+	plane := migrations.NewControlPlaneWithConfig(config)
+
+	mock.ExpectQuery(`SELECT dbName, provisioning_status = \$1 FROM companies`).
+		WithArgs("ready").
+		WillReturnRows(sqlmock.NewRows([]string{"dbName", "ready"}).
+			AddRow(registered, true).
+			AddRow(pending, false).
+			AddRow(missing, true))
+	serverMock.ExpectQuery(`SELECT datname FROM pg_database`).
+		WillReturnRows(sqlmock.NewRows([]string{"datname"}).
+			AddRow("postgres").
+			AddRow("AuthorizationDB").
+			AddRow("analytics").
+			AddRow("company_template").
+			AddRow(registered).
+			AddRow(pending).
+			AddRow(unregistered))
+
+	inventory, err := plane.Inventory(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"company_template", registered}, inventory.Databases)
+	assert.Equal(t, []string{unregistered}, inventory.Unregistered)
+	assert.Equal(t, []string{missing}, inventory.Missing)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.NoError(t, serverMock.ExpectationsWereMet())
+}
@@ -0,0 +1,224 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crmSystem/offboarding"
+	"crmSystem/utils"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	selectCompanyForUpdate = `SELECT name, dbName, status FROM companies WHERE id = \$1 FOR UPDATE`
+	updateCompanyStatus    = `UPDATE companies SET status = \$1, status_reason = NULLIF\(\$2, ''\), delete_after = \$3 WHERE id = \$4`
+	insertTenantAudit      = `INSERT INTO tenantAudit \(company_id, company_name, dbName, action, actor, details\) VALUES`
+	selectDueCompanies     = `SELECT id FROM companies WHERE status = \$1 AND delete_after <= NOW\(\) ORDER BY id`
+	selectDueCompany       = `SELECT name, dbName FROM companies WHERE id = \$1 AND status = \$2 AND delete_after <= NOW\(\) FOR UPDATE`
+)
+
+// TestSuspendCompany checks suspension is recorded in the audit log and is refused for deleted companies.
+func TestSuspendCompany(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Active company", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectCompanyForUpdate).WithArgs("3").
+			WillReturnRows(sqlmock.NewRows([]string{"name", "dbName", "status"}).AddRow("acme", "company_c", utils.TenantStatusActive))
+		mock.ExpectExec(updateCompanyStatus).WithArgs(utils.TenantStatusSuspended, "unpaid", nil, "3").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insertTenantAudit).
+			WithArgs("3", "acme", "company_c", offboarding.ActionSuspended, "ops@example.com", "unpaid").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, offboarding.Suspend(ctx, db, "3", "ops@example.com", "unpaid"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Deleted company", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectCompanyForUpdate).WithArgs("3").
+			WillReturnRows(sqlmock.NewRows([]string{"name", "dbName", "status"}).AddRow("acme", "company_c", utils.TenantStatusDeleted))
+		mock.ExpectRollback()
+
+		assert.ErrorIs(t, offboarding.Suspend(ctx, db, "3", "ops@example.com", ""), offboarding.ErrCompanyDeleted)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown company", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectCompanyForUpdate).WithArgs("404").
+			WillReturnRows(sqlmock.NewRows([]string{"name", "dbName", "status"}))
+		mock.ExpectRollback()
+
+		assert.ErrorIs(t, offboarding.Suspend(ctx, db, "404", "ops@example.com", ""), offboarding.ErrCompanyNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("No actor", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		assert.ErrorIs(t, offboarding.Suspend(ctx, db, "3", "", ""), offboarding.ErrActorRequired)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// TestScheduleDeletion checks a deleted company gets a purge date after the grace period.
+func TestScheduleDeletion(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(selectCompanyForUpdate).WithArgs("3").
+		WillReturnRows(sqlmock.NewRows([]string{"name", "dbName", "status"}).AddRow("acme", "company_c", utils.TenantStatusSuspended))
+	mock.ExpectExec(updateCompanyStatus).WithArgs(utils.TenantStatusDeleted, "gdpr request", sqlmock.AnyArg(), "3").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(insertTenantAudit).
+		WithArgs("3", "acme", "company_c", offboarding.ActionDeletionScheduled, "ops@example.com", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	deleteAfter, err := offboarding.ScheduleDeletion(ctx, db, "3", "ops@example.com", "gdpr request", 72*time.Hour)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(72*time.Hour), deleteAfter, time.Minute)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestPurge checks the database is dropped before the registry rows are removed and the purge is audited.
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Grace period expired", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(selectDueCompanies).WithArgs(utils.TenantStatusDeleted).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("3"))
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectDueCompany).WithArgs("3", utils.TenantStatusDeleted).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "dbName"}).AddRow("acme", "company_c"))
+		mock.ExpectExec(`DELETE FROM authusers WHERE company_id = \$1`).WithArgs("3").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`DELETE FROM companies WHERE id = \$1`).WithArgs("3").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insertTenantAudit).
+			WithArgs("3", "acme", "company_c", offboarding.ActionPurged, offboarding.SystemActor, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		var dropped []string
+		purged, err := offboarding.Purge(ctx, db, func(dbName string) error {
+			dropped = append(dropped, dbName)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged)
+		assert.Equal(t, []string{"company_c"}, dropped)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Drop fails", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(selectDueCompanies).WithArgs(utils.TenantStatusDeleted).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("3"))
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectDueCompany).WithArgs("3", utils.TenantStatusDeleted).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "dbName"}).AddRow("acme", "company_c"))
+		mock.ExpectRollback()
+
+		// The registry rows stay, so the company is purged on the next run
+		purged, err := offboarding.Purge(ctx, db, func(string) error {
+			return errors.New("database is being accessed by other users")
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 0, purged)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// TestExport checks the archive holds a JSON file per table and a manifest describing them.
+func TestExport(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT version FROM schema_migrations LIMIT 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow(4))
+	mock.ExpectQuery(`SELECT table_name FROM information_schema.tables`).
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("chats").AddRow("users"))
+	mock.ExpectQuery(`SELECT row_to_json\(t\) FROM "chats" AS t`).
+		WillReturnRows(sqlmock.NewRows([]string{"row_to_json"}))
+	mock.ExpectQuery(`SELECT row_to_json\(t\) FROM "users" AS t`).
+		WillReturnRows(sqlmock.NewRows([]string{"row_to_json"}).
+			AddRow([]byte(`{"id":1,"rightsid":1,"authid":12}`)).
+			AddRow([]byte(`{"id":2,"rightsid":2,"authid":13}`)))
+	mock.ExpectRollback()
+
+	tenant := &utils.Tenant{CompanyId: "3", Name: "acme", DbName: "company_c", Status: utils.TenantStatusDeleted, Plan: "standard"}
+	var buf bytes.Buffer
+	manifest, err := offboarding.Export(context.Background(), tenant, db, &buf)
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	assert.Equal(t, int64(4), manifest.SchemaVersion)
+	assert.Equal(t, []offboarding.ManifestTable{
+		{Name: "chats", File: "tables/chats.json", Rows: 0},
+		{Name: "users", File: "tables/users.json", Rows: 2},
+	}, manifest.Tables)
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	files := map[string][]byte{}
+	for _, file := range archive.File {
+		r, err := file.Open()
+		require.NoError(t, err)
+		files[file.Name], err = io.ReadAll(r)
+		require.NoError(t, err)
+		r.Close()
+	}
+
+	var chats []map[string]any
+	require.NoError(t, json.Unmarshal(files["tables/chats.json"], &chats))
+	assert.Empty(t, chats)
+
+	var users []map[string]any
+	require.NoError(t, json.Unmarshal(files["tables/users.json"], &users))
+	assert.Len(t, users, 2)
+	assert.Equal(t, float64(13), users[1]["authid"])
+
+	var stored offboarding.Manifest
+	require.NoError(t, json.Unmarshal(files["manifest.json"], &stored))
+	assert.Equal(t, offboarding.ExportFormat, stored.Format)
+	assert.Equal(t, "company_c", stored.DbName)
+	assert.Equal(t, manifest.Tables, stored.Tables)
+}
@@ -0,0 +1,248 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/dbauthservice"
+	"crmSystem/proto/dbauth"
+	"crmSystem/provisioning"
+	"crmSystem/utils"
+	"database/sql/driver"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	selectOidcSubjectQuery = `SELECT id FROM authusers WHERE company_id = \$1 AND oidc_subject = \$2`
+	selectOidcEmailQuery   = `SELECT id, oidc_subject FROM authusers WHERE email = \$1 AND company_id = \$2 FOR UPDATE`
+	linkOidcSubjectQuery   = `UPDATE authusers SET oidc_subject = \$1, status = \$2 WHERE id = \$3`
+)
+
+// TestLinkOidcUser checks IdP users are matched by subject first and linked by verified email on first login.
+func TestLinkOidcUser(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Linked subject", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(selectOidcSubjectQuery).WithArgs("3", "sub-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("7"))
+
+		authUserId, err := dbauthservice.LinkOidcUser(ctx, db, "3", "sub-1", "user@corp.example", false)
+		require.NoError(t, err)
+		assert.Equal(t, "7", authUserId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("First login links verified email", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(selectOidcSubjectQuery).WithArgs("3", "sub-1").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectOidcEmailQuery).WithArgs("user@corp.example", "3").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "oidc_subject"}).AddRow("7", nil))
+		mock.ExpectExec(linkOidcSubjectQuery).WithArgs("sub-1", utils.AuthStatusVerified, "7").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		authUserId, err := dbauthservice.LinkOidcUser(ctx, db, "3", "sub-1", " User@Corp.example", true)
+		require.NoError(t, err)
+		assert.Equal(t, "7", authUserId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unverified email is not linked", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(selectOidcSubjectQuery).WithArgs("3", "sub-1").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		_, err = dbauthservice.LinkOidcUser(ctx, db, "3", "sub-1", "user@corp.example", false)
+		assert.ErrorIs(t, err, dbauthservice.ErrOidcEmailNotVerified)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Account linked to another subject", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(selectOidcSubjectQuery).WithArgs("3", "sub-2").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectOidcEmailQuery).WithArgs("user@corp.example", "3").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "oidc_subject"}).AddRow("7", "sub-1"))
+		mock.ExpectRollback()
+
+		_, err = dbauthservice.LinkOidcUser(ctx, db, "3", "sub-2", "user@corp.example", true)
+		assert.ErrorIs(t, err, dbauthservice.ErrOidcSubjectMismatch)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("User of another company", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(selectOidcSubjectQuery).WithArgs("3", "sub-1").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectOidcEmailQuery).WithArgs("user@corp.example", "3").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "oidc_subject"}))
+		mock.ExpectRollback()
+
+		_, err = dbauthservice.LinkOidcUser(ctx, db, "3", "sub-1", "user@corp.example", true)
+		assert.ErrorIs(t, err, dbauthservice.ErrAuthUserNotFound)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// TestOidcClientSecretIsEncrypted checks the client secret is stored encrypted and domains are normalized.
+func TestOidcClientSecretIsEncrypted(t *testing.T) {
+	setSecretsKey(t)
+	ctx := context.Background()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT issuer FROM companyOidc WHERE company_id = \$1 FOR UPDATE`).WithArgs("3").
+		WillReturnRows(sqlmock.NewRows([]string{"issuer"}).AddRow("https://old.example"))
+	mock.ExpectExec(`INSERT INTO companyOidc`).
+		WithArgs("3", "https://idp.example", "crm", notEqualArg("s3cret"), true).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE authusers SET oidc_subject = NULL WHERE company_id = \$1`).WithArgs("3").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`DELETE FROM companyOidcDomains WHERE company_id = \$1`).WithArgs("3").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO companyOidcDomains`).WithArgs("corp.example", "3").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = dbauthservice.SaveOidcConfig(ctx, db, dbauthservice.OidcConfig{
+		CompanyId: "3", Issuer: "https://idp.example", ClientId: "crm", ClientSecret: "s3cret",
+		Domains: []string{"@Corp.Example"}, Enabled: true,
+	})
+	require.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// The stored value is read back and decrypted
+	mock.ExpectQuery(`SELECT o.company_id, o.issuer, o.client_id, o.client_secret`).WithArgs("corp.example").
+		WillReturnRows(sqlmock.NewRows([]string{"company_id", "issuer", "client_id", "client_secret"}).
+			AddRow("3", "https://idp.example", "crm", mustEncrypt(t, "s3cret")))
+	config, err := dbauthservice.GetOidcConfigByDomain(ctx, db, "CORP.example")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", config.ClientSecret)
+
+	// Unknown domain
+	mock.ExpectQuery(`SELECT o.company_id, o.issuer, o.client_id, o.client_secret`).WithArgs("other.example").
+		WillReturnRows(sqlmock.NewRows([]string{"company_id", "issuer", "client_id", "client_secret"}))
+	_, err = dbauthservice.GetOidcConfigByDomain(ctx, db, "other.example")
+	assert.ErrorIs(t, err, dbauthservice.ErrOidcNotConfigured)
+}
+
+// notEqualArg matches any query argument except the given value.
+type notEqualArg string
+
+func (a notEqualArg) Match(v driver.Value) bool {
+	value, ok := v.(string)
+	return ok && value != "" && value != string(a)
+}
+
+func mustEncrypt(t *testing.T, value string) string {
+	t.Helper()
+	encrypted, err := utils.EncryptSecret(value)
+	require.NoError(t, err)
+	return encrypted
+}
+
+// setSecretsKey sets a test encryption key for integration secrets.
+func setSecretsKey(t *testing.T) {
+	t.Helper()
+	t.Setenv("SECRETS_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString([]byte(strings.Repeat("s", 32))))
+}
+
+// TestSecretEncryptionUsesOwnKey checks integration secrets do not depend on the TOTP key.
+func TestSecretEncryptionUsesOwnKey(t *testing.T) {
+	setSecretsKey(t)
+	t.Setenv("MFA_ENCRYPTION_KEY", "")
+
+	encrypted, err := utils.EncryptSecret("s3cret")
+	require.NoError(t, err)
+	assert.NotContains(t, encrypted, "s3cret")
+
+	secret, err := utils.DecryptSecret(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "s3cret", secret)
+
+	// A TOTP secret cannot be decrypted as an integration secret and vice versa
+	setMfaKey(t)
+	totpEncrypted, err := utils.EncryptTotpSecret("s3cret")
+	require.NoError(t, err)
+	_, err = utils.DecryptSecret(totpEncrypted)
+	assert.Error(t, err)
+	_, err = utils.DecryptTotpSecret(encrypted)
+	assert.Error(t, err)
+
+	t.Setenv("SECRETS_ENCRYPTION_KEY", "")
+	_, err = utils.EncryptSecret("s3cret")
+	assert.ErrorIs(t, err, utils.ErrSecretsKeyNotConfigured)
+}
+
+// TestLoginOidcRequiresCompanyMfa checks the company 2FA policy also applies to provider logins.
+func TestLoginOidcRequiresCompanyMfa(t *testing.T) {
+	t.Setenv("DB_AUTH_NAME", "auth_db")
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	pool := utils.NewMapConnectionsDB()
+	pool.Add("auth_db", db)
+	service := dbauthservice.NewGRPCDBAuthService(pool, provisioning.NewProvisioner(pool))
+
+	mock.ExpectQuery(selectOidcSubjectQuery).WithArgs("3", "idp-user-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("7"))
+	mock.ExpectQuery(`SELECT a.company_id, a.totp_enabled, c.require_mfa`).WithArgs("7").
+		WillReturnRows(sqlmock.NewRows([]string{"company_id", "totp_enabled", "require_mfa"}).AddRow("3", false, true))
+
+	stream := &headerStream{}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+	resp, err := service.LoginOidc(ctx, &dbauth.LoginOidcRequest{
+		CompanyId: "3", Subject: "idp-user-1", Email: "user@corp.example", EmailVerified: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Требуется подтверждение вторым фактором", resp.Message)
+
+	// Instead of the user data auth receives a second factor requirement
+	assert.Equal(t, []string{dbauthservice.MfaMethodEnroll}, stream.header.Get("mfa-required"))
+	assert.Equal(t, []string{"7"}, stream.header.Get("auth-user-id"))
+	assert.Empty(t, stream.header.Get("database"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// headerStream records headers sent by a gRPC method called directly.
+type headerStream struct {
+	header metadata.MD
+}
+
+func (s *headerStream) Method() string { return "" }
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *headerStream) SendHeader(md metadata.MD) error { return s.SetHeader(md) }
+
+func (s *headerStream) SetTrailer(md metadata.MD) error { return nil }
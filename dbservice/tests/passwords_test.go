@@ -0,0 +1,370 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/dbauthservice"
+	"crmSystem/utils"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	selectAuthUserQuery        = `SELECT id, company_id, password, status FROM authusers WHERE email = \$1`
+	selectAuthUserByPhoneQuery = `SELECT id, company_id, password, status FROM authusers WHERE phone = \$1`
+)
+
+// TestHashPassword checks the versioned hash format and verification of every supported format.
+func TestHashPassword(t *testing.T) {
+	hash, err := utils.HashPassword("password123")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=65536,t=3,p=2$"))
+	assert.LessOrEqual(t, len(hash), 255)
+
+	// Two hashes of the same password must differ because of the salt
+	other, err := utils.HashPassword("password123")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		stored         string
+		password       string
+		expectedMatch  bool
+		expectedRehash bool
+		expectedErr    bool
+		expectedIsHash bool
+	}{
+		{name: "Argon2id match", stored: hash, password: "password123", expectedMatch: true, expectedIsHash: true},
+		{name: "Argon2id mismatch", stored: hash, password: "wrong", expectedIsHash: true},
+		{name: "Tampered Argon2id parameters", stored: tamperedArgon2Hash(t, hash), password: "password123",
+			expectedIsHash: true},
+		{name: "bcrypt match needs rehash", stored: string(bcryptHash), password: "password123",
+			expectedMatch: true, expectedRehash: true, expectedIsHash: true},
+		{name: "bcrypt mismatch", stored: string(bcryptHash), password: "wrong", expectedIsHash: true},
+		{name: "Plaintext match needs rehash", stored: "password123", password: "password123",
+			expectedMatch: true, expectedRehash: true},
+		{name: "Plaintext mismatch", stored: "password123", password: "wrong"},
+		{name: "Corrupted Argon2id hash", stored: "$argon2id$v=19$broken", password: "password123",
+			expectedErr: true, expectedIsHash: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, rehash, err := utils.VerifyPassword(tt.stored, tt.password)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedMatch, match)
+			assert.Equal(t, tt.expectedRehash, rehash)
+			assert.Equal(t, tt.expectedIsHash, utils.IsPasswordHash(tt.stored))
+		})
+	}
+}
+
+// tamperedArgon2Hash replaces the cost parameters in the hash so the key no longer matches them.
+func tamperedArgon2Hash(t *testing.T, hash string) string {
+	t.Helper()
+	return strings.Replace(hash, "t=3", "t=1", 1)
+}
+
+// TestAuthenticateUser tests password verification at login against the authusers table.
+func TestAuthenticateUser(t *testing.T) {
+	hash, err := utils.HashPassword("password123")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		password       string
+		prepareMocks   func(mock sqlmock.Sqlmock)
+		expectedUserId string
+		expectedErr    error
+	}{
+		{
+			name:     "Hashed password",
+			password: "password123",
+			prepareMocks: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectAuthUserQuery).
+					WithArgs("user@example.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "password", "status"}).AddRow("1", "100", hash, utils.AuthStatusVerified))
+			},
+			expectedUserId: "1",
+		},
+		{
+			name:     "Legacy plaintext password is rehashed",
+			password: "password123",
+			prepareMocks: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectAuthUserQuery).
+					WithArgs("user@example.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "password", "status"}).AddRow("1", "100", "password123", utils.AuthStatusVerified))
+				mock.ExpectExec(`UPDATE authusers SET password = \$1 WHERE id = \$2`).
+					WithArgs(argon2Arg{}, "1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedUserId: "1",
+		},
+		{
+			name:     "Wrong password",
+			password: "wrong",
+			prepareMocks: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectAuthUserQuery).
+					WithArgs("user@example.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "password", "status"}).AddRow("1", "100", hash, utils.AuthStatusVerified))
+			},
+			expectedErr: dbauthservice.ErrInvalidCredentials,
+		},
+		{
+			name:     "Wrong legacy password is not rehashed",
+			password: "wrong",
+			prepareMocks: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectAuthUserQuery).
+					WithArgs("user@example.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "password", "status"}).AddRow("1", "100", "password123", utils.AuthStatusVerified))
+			},
+			expectedErr: dbauthservice.ErrInvalidCredentials,
+		},
+		{
+			name:     "Email not verified",
+			password: "password123",
+			prepareMocks: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectAuthUserQuery).
+					WithArgs("user@example.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "password", "status"}).AddRow("1", "100", hash, utils.AuthStatusUnverified))
+			},
+			expectedErr: dbauthservice.ErrAccountNotActivated,
+		},
+		{
+			name:     "Invited user with wrong password",
+			password: "wrong",
+			prepareMocks: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectAuthUserQuery).
+					WithArgs("user@example.com").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "company_id", "password", "status"}).AddRow("1", "100", hash, utils.AuthStatusInvited))
+			},
+			expectedErr: dbauthservice.ErrInvalidCredentials,
+		},
+		{
+			name:     "User not found",
+			password: "password123",
+			prepareMocks: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(selectAuthUserQuery).
+					WithArgs("user@example.com").
+					WillReturnError(sql.ErrNoRows)
+			},
+			expectedErr: dbauthservice.ErrInvalidCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tt.prepareMocks(mock)
+
+			userId, companyId, err := dbauthservice.AuthenticateUser(context.Background(), db,
+				"user@example.com", "1234567890", tt.password)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				assert.Empty(t, userId)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedUserId, userId)
+				assert.Equal(t, "100", companyId)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// TestAuthenticateUserIdentifierPrecedence checks the login row is found by email when one is given
+// and by phone only otherwise, so an email and a phone of two accounts never select both rows.
+func TestAuthenticateUserIdentifierPrecedence(t *testing.T) {
+	hash, err := utils.HashPassword("password123")
+	require.NoError(t, err)
+	columns := []string{"id", "company_id", "password", "status"}
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	ctx := context.Background()
+
+	// The victim's phone is ignored next to another email, only the email account is checked
+	mock.ExpectQuery(selectAuthUserQuery).WithArgs("attacker@example.com").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("2", "200", hash, utils.AuthStatusVerified))
+	userId, _, err := dbauthservice.AuthenticateUser(ctx, db, "attacker@example.com", "5550001", "victim-password")
+	assert.ErrorIs(t, err, dbauthservice.ErrInvalidCredentials)
+	assert.Empty(t, userId)
+
+	// Without an email the phone identifies the account
+	mock.ExpectQuery(selectAuthUserByPhoneQuery).WithArgs("5550001").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("1", "100", hash, utils.AuthStatusVerified))
+	userId, companyId, err := dbauthservice.AuthenticateUser(ctx, db, "", "5550001", "password123")
+	require.NoError(t, err)
+	assert.Equal(t, "1", userId)
+	assert.Equal(t, "100", companyId)
+
+	// Neither identifier: nothing is queried
+	_, _, err = dbauthservice.AuthenticateUser(ctx, db, "", "", "password123")
+	assert.ErrorIs(t, err, dbauthservice.ErrInvalidCredentials)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestRehashLegacyPasswords tests the one-off migration of plaintext passwords.
+func TestRehashLegacyPasswords(t *testing.T) {
+	hash, err := utils.HashPassword("already-hashed")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		prepareMocks    func(mock sqlmock.Sqlmock)
+		expectedUpdated int
+		expectedErr     bool
+	}{
+		{
+			name: "Only plaintext rows are updated",
+			prepareMocks: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT id, password FROM authusers`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).
+						AddRow("1", "plain-one").
+						AddRow("2", hash).
+						AddRow("3", "plain-two"))
+				mock.ExpectExec(`UPDATE authusers SET password = \$1 WHERE id = \$2 AND password = \$3`).
+					WithArgs(argon2Arg{}, "1", "plain-one").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`UPDATE authusers SET password = \$1 WHERE id = \$2 AND password = \$3`).
+					WithArgs(argon2Arg{}, "3", "plain-two").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			expectedUpdated: 2,
+		},
+		{
+			name: "Row changed concurrently is skipped",
+			prepareMocks: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT id, password FROM authusers`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow("1", "plain-one"))
+				mock.ExpectExec(`UPDATE authusers SET password = \$1 WHERE id = \$2 AND password = \$3`).
+					WithArgs(argon2Arg{}, "1", "plain-one").
+					WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			expectedUpdated: 0,
+		},
+		{
+			name: "Nothing to update",
+			prepareMocks: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT id, password FROM authusers`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "password"}).AddRow("2", hash))
+			},
+			expectedUpdated: 0,
+		},
+		{
+			name: "Query error",
+			prepareMocks: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT id, password FROM authusers`).
+					WillReturnError(sql.ErrConnDone)
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tt.prepareMocks(mock)
+
+			updated, err := dbauthservice.RehashLegacyPasswords(context.Background(), db)
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedUpdated, updated)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// argon2Arg matches any argument that is an Argon2id hash.
+type argon2Arg struct{}
+
+func (argon2Arg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	return ok && strings.HasPrefix(s, "$argon2id$")
+}
+
+// TestFindAuthUserByEmail tests the lookup used by password recovery.
+func TestFindAuthUserByEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT id FROM authusers WHERE email = \$1`).
+		WithArgs("user@example.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+	mock.ExpectQuery(`SELECT id FROM authusers WHERE email = \$1`).
+		WithArgs("missing@example.com").
+		WillReturnError(sql.ErrNoRows)
+
+	userId, err := dbauthservice.FindAuthUserByEmail(context.Background(), db, "user@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", userId)
+
+	_, err = dbauthservice.FindAuthUserByEmail(context.Background(), db, "missing@example.com")
+	assert.ErrorIs(t, err, dbauthservice.ErrAuthUserNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestSetPassword checks that a new password is stored only as an Argon2id hash
+// and that setting it activates the account.
+func TestSetPassword(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`UPDATE authusers SET password = \$1, status = \$2 WHERE id = \$3`).
+		WithArgs(argon2Arg{}, utils.AuthStatusVerified, "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE authusers SET password = \$1, status = \$2 WHERE id = \$3`).
+		WithArgs(argon2Arg{}, utils.AuthStatusVerified, "2").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, dbauthservice.SetPassword(context.Background(), db, "1", "NewPassword1!"))
+	assert.ErrorIs(t, dbauthservice.SetPassword(context.Background(), db, "2", "NewPassword1!"),
+		dbauthservice.ErrAuthUserNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestVerifyEmail checks that email confirmation activates only an unverified account.
+func TestVerifyEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`UPDATE authusers SET status = \$1 WHERE id = \$2 AND status <> \$3`).
+		WithArgs(utils.AuthStatusVerified, "1", utils.AuthStatusInvited).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE authusers SET status = \$1 WHERE id = \$2 AND status <> \$3`).
+		WithArgs(utils.AuthStatusVerified, "2", utils.AuthStatusInvited).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	assert.NoError(t, dbauthservice.VerifyEmail(context.Background(), db, "1"))
+	assert.ErrorIs(t, dbauthservice.VerifyEmail(context.Background(), db, "2"), dbauthservice.ErrAuthUserNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
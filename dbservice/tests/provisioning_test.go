@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/provisioning"
+	"crmSystem/utils"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	selectPendingCompany  = `SELECT dbName, provisioning_attempts FROM companies WHERE id = \$1 AND provisioning_status = \$2 FOR UPDATE SKIP LOCKED`
+	selectCompanyOwner    = `SELECT id FROM authusers WHERE company_id = \$1 ORDER BY id LIMIT 1`
+	selectOwnerExists     = `SELECT EXISTS\(SELECT 1 FROM users WHERE authId = \$1\)`
+	updateProvisioningErr = `UPDATE companies SET provisioning_status = \$1, provisioning_attempts = \$2, provisioning_error = \$3, provisioning_updatedAt = NOW\(\) WHERE id = \$4`
+	selectProvisioning    = `SELECT provisioning_status, provisioning_error FROM companies WHERE provisioning_id = \$1`
+)
+
+// TestClaimPending checks only companies waiting for their database are claimed, together with the owner.
+func TestClaimPending(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Pending", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectPendingCompany).WithArgs("3", utils.ProvisioningPending).
+			WillReturnRows(sqlmock.NewRows([]string{"dbName", "provisioning_attempts"}).AddRow("company_c", 1))
+		mock.ExpectQuery(selectCompanyOwner).WithArgs("3").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("12"))
+
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		job, err := provisioning.ClaimPending(ctx, tx, "3")
+		require.NoError(t, err)
+		assert.Equal(t, &provisioning.Job{CompanyId: "3", DbName: "company_c", OwnerAuthUserId: "12", Attempts: 1}, job)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Ready or locked by another worker", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectPendingCompany).WithArgs("3", utils.ProvisioningPending).
+			WillReturnRows(sqlmock.NewRows([]string{"dbName", "provisioning_attempts"}))
+
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		_, err = provisioning.ClaimPending(ctx, tx, "3")
+		assert.ErrorIs(t, err, provisioning.ErrNotPending)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// TestSeedCompanyDatabase checks the owner is created once, so a retried attempt does not duplicate it.
+func TestSeedCompanyDatabase(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("New database", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectOwnerExists).WithArgs("12").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectQuery(`INSERT INTO rights \(roles\) VALUES \(\$1\) RETURNING id`).WithArgs("admin").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(`INSERT INTO users \(rightsId, authId\) VALUES \(\$1, \$2\)`).WithArgs(1, "12").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`INSERT INTO availableactions`).WithArgs(1, true, true, true).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		require.NoError(t, provisioning.SeedCompanyDatabase(ctx, db, "12", "admin"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Owner already created", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectOwnerExists).WithArgs("12").
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectCommit()
+
+		require.NoError(t, provisioning.SeedCompanyDatabase(ctx, db, "12", "admin"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// TestMarkFailure checks failed attempts stay pending for a retry until the attempts are used up.
+func TestMarkFailure(t *testing.T) {
+	ctx := context.Background()
+	cause := errors.New("source database is being accessed by other users")
+
+	tests := []struct {
+		name          string
+		attempts      int
+		expectedState string
+	}{
+		{"Retry", 1, utils.ProvisioningPending},
+		{"Attempts exhausted", 4, utils.ProvisioningFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectExec(updateProvisioningErr).WithArgs(tt.expectedState, tt.attempts+1, cause.Error(), "3").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			tx, err := db.Begin()
+			require.NoError(t, err)
+			failed, err := provisioning.MarkFailure(ctx, tx, &provisioning.Job{CompanyId: "3", Attempts: tt.attempts}, 5, cause)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedState == utils.ProvisioningFailed, failed)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// TestRunAttemptRecordsTimeout checks an attempt that runs out of its time is still recorded for a retry.
+func TestRunAttemptRecordsTimeout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(selectPendingCompany).WithArgs("3", utils.ProvisioningPending).
+		WillReturnRows(sqlmock.NewRows([]string{"dbName", "provisioning_attempts"}).AddRow("company_c", 1))
+	mock.ExpectQuery(selectCompanyOwner).WithArgs("3").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("12"))
+	mock.ExpectExec(updateProvisioningErr).
+		WithArgs(utils.ProvisioningPending, 2, context.DeadlineExceeded.Error(), "3").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// The database copy outlives the attempt timeout
+	attempt, err := provisioning.RunAttempt(context.Background(), db, "3", 20*time.Millisecond,
+		func(ctx context.Context, job *provisioning.Job) error {
+			<-ctx.Done()
+			time.Sleep(20 * time.Millisecond)
+			return ctx.Err()
+		})
+	require.NoError(t, err)
+	assert.ErrorIs(t, attempt.BuildErr, context.DeadlineExceeded)
+	assert.False(t, attempt.Failed)
+	assert.Equal(t, "company_c", attempt.Job.DbName)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestProvisioningStatus checks the status is looked up by the registration id.
+func TestProvisioningStatus(t *testing.T) {
+	ctx := context.Background()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectQuery(selectProvisioning).WithArgs("abc").
+		WillReturnRows(sqlmock.NewRows([]string{"provisioning_status", "provisioning_error"}).AddRow(utils.ProvisioningPending, nil))
+	mock.ExpectQuery(selectProvisioning).WithArgs("unknown").
+		WillReturnRows(sqlmock.NewRows([]string{"provisioning_status", "provisioning_error"}))
+
+	state, _, err := provisioning.Status(ctx, db, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, utils.ProvisioningPending, state)
+
+	_, _, err = provisioning.Status(ctx, db, "unknown")
+	assert.ErrorIs(t, err, provisioning.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestTenantProvisioningState checks a company is not served until its database is ready.
+func TestTenantProvisioningState(t *testing.T) {
+	tenant := utils.Tenant{Status: utils.TenantStatusActive, Provisioning: utils.ProvisioningPending}
+	assert.ErrorIs(t, tenant.CheckActive(), utils.ErrTenantProvisioning)
+
+	tenant.Provisioning = utils.ProvisioningFailed
+	assert.ErrorIs(t, tenant.CheckActive(), utils.ErrTenantProvisioningFailed)
+
+	tenant.Provisioning = utils.ProvisioningReady
+	assert.NoError(t, tenant.CheckActive())
+}
@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/utils"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	selectTenantByDbName  = `SELECT id, name, dbName, status, plan, createdAt, provisioning_status FROM companies WHERE dbName = \$1`
+	selectTenantByCompany = `SELECT id, name, dbName, status, plan, createdAt, provisioning_status FROM companies WHERE id = \$1`
+)
+
+var tenantColumns = []string{"id", "name", "dbName", "status", "plan", "createdAt", "provisioning_status"}
+
+func newTestTenantResolver(t *testing.T) (*utils.TenantResolver, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return utils.NewTenantResolver(func() (*sql.DB, error) { return db, nil }), mock
+}
+
+// TestTenantResolverCache checks a tenant is read from the registry once and then served from the cache.
+func TestTenantResolverCache(t *testing.T) {
+	ctx := context.Background()
+	resolver, mock := newTestTenantResolver(t)
+
+	mock.ExpectQuery(selectTenantByDbName).WithArgs("company_a").
+		WillReturnRows(sqlmock.NewRows(tenantColumns).
+			AddRow("1", "Company A", "company_a", utils.TenantStatusActive, "standard", time.Now(), utils.ProvisioningReady))
+
+	tenant, err := resolver.RequireActive(ctx, "company_a")
+	require.NoError(t, err)
+	assert.Equal(t, "1", tenant.CompanyId)
+	assert.Equal(t, "standard", tenant.Plan)
+
+	// Both lookups are answered from the cache without new queries
+	_, err = resolver.ByDbName(ctx, "company_a")
+	require.NoError(t, err)
+	tenant, err = resolver.ByCompanyId(ctx, "1")
+	require.NoError(t, err)
+	assert.Equal(t, "company_a", tenant.DbName)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// After invalidation the new status is read from the registry
+	resolver.Invalidate("1")
+	mock.ExpectQuery(selectTenantByCompany).WithArgs("1").
+		WillReturnRows(sqlmock.NewRows(tenantColumns).
+			AddRow("1", "Company A", "company_a", utils.TenantStatusSuspended, "standard", time.Now(), utils.ProvisioningReady))
+
+	tenant, err = resolver.ByCompanyId(ctx, "1")
+	require.NoError(t, err)
+	assert.ErrorIs(t, tenant.CheckActive(), utils.ErrTenantSuspended)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestTenantResolverNotFound checks missing tenants are not cached, so a new company is visible at once.
+func TestTenantResolverNotFound(t *testing.T) {
+	ctx := context.Background()
+	resolver, mock := newTestTenantResolver(t)
+
+	mock.ExpectQuery(selectTenantByDbName).WithArgs("company_b").WillReturnRows(sqlmock.NewRows(tenantColumns))
+	mock.ExpectQuery(selectTenantByDbName).WithArgs("company_b").
+		WillReturnRows(sqlmock.NewRows(tenantColumns).
+			AddRow("2", "Company B", "company_b", utils.TenantStatusActive, "standard", time.Now(), utils.ProvisioningReady))
+
+	_, err := resolver.RequireActive(ctx, "company_b")
+	assert.ErrorIs(t, err, utils.ErrTenantNotFound)
+
+	_, err = resolver.RequireActive(ctx, "company_b")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestTenantStatusError checks tenant states map to distinct gRPC codes.
+func TestTenantStatusError(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode codes.Code
+	}{
+		{"Suspended", utils.ErrTenantSuspended, codes.FailedPrecondition},
+		{"Deleted", utils.ErrTenantDeleted, codes.NotFound},
+		{"Not found", utils.ErrTenantNotFound, codes.NotFound},
+		{"Provisioning", utils.ErrTenantProvisioning, codes.Unavailable},
+		{"Provisioning failed", utils.ErrTenantProvisioningFailed, codes.FailedPrecondition},
+		{"Registry failure", sql.ErrConnDone, codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedCode, status.Code(utils.TenantStatusError(tt.err)))
+		})
+	}
+	assert.True(t, utils.IsTenantError(utils.ErrTenantSuspended))
+	assert.False(t, utils.IsTenantError(sql.ErrConnDone))
+}
+
+// TestTenantInterceptor checks user requests to a suspended company are rejected before the handler runs.
+func TestTenantInterceptor(t *testing.T) {
+	resolver, mock := newTestTenantResolver(t)
+	interceptor := utils.NewTenantInterceptor(resolver)
+	info := &grpc.UnaryServerInfo{FullMethod: "/dbchat.DbChatService/GetUserChats"}
+
+	called := false
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return "ok", nil
+	}
+
+	// Internal service calls carry no user identity and are not checked
+	_, err := interceptor(context.Background(), nil, info, handler)
+	require.NoError(t, err)
+	assert.True(t, called)
+
+	mock.ExpectQuery(selectTenantByDbName).WithArgs("company_a").
+		WillReturnRows(sqlmock.NewRows(tenantColumns).
+			AddRow("1", "Company A", "company_a", utils.TenantStatusSuspended, "standard", time.Now(), utils.ProvisioningReady))
+
+	called = false
+	ctx := utils.ContextWithIdentity(context.Background(), &utils.Identity{UserId: "7", Database: "company_a", CompanyId: "1"})
+	_, err = interceptor(ctx, nil, info, handler)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.False(t, called)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
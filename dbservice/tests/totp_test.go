@@ -0,0 +1,304 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/dbauthservice"
+	"crmSystem/utils"
+	"database/sql"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const selectTotpQuery = `SELECT totp_secret, totp_enabled, totp_last_step FROM authusers WHERE id = \$1 FOR UPDATE`
+
+// setMfaKey sets a test encryption key for TOTP secrets.
+func setMfaKey(t *testing.T) {
+	t.Helper()
+	t.Setenv("MFA_ENCRYPTION_KEY", base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+}
+
+// TestValidateTotpCode checks clock skew and that a code is accepted only once.
+func TestValidateTotpCode(t *testing.T) {
+	secret, uri, err := utils.GenerateTotpSecret("user@example.com")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/"))
+	assert.Contains(t, uri, "secret="+secret)
+
+	now := time.Unix(1_700_000_000, 0)
+	current := now.Unix() / utils.TotpPeriod
+
+	code, err := totp.GenerateCode(secret, now)
+	require.NoError(t, err)
+
+	step, ok := utils.ValidateTotpCode(secret, code, 0, now)
+	assert.True(t, ok)
+	assert.Equal(t, current, step)
+
+	// The same code after it was accepted
+	_, ok = utils.ValidateTotpCode(secret, code, step, now)
+	assert.False(t, ok)
+
+	// The previous step is accepted to tolerate clock skew
+	previous, err := totp.GenerateCode(secret, now.Add(-utils.TotpPeriod*time.Second))
+	require.NoError(t, err)
+	step, ok = utils.ValidateTotpCode(secret, previous, 0, now)
+	assert.True(t, ok)
+	assert.Equal(t, current-1, step)
+
+	// A code from two minutes ago is rejected
+	old, err := totp.GenerateCode(secret, now.Add(-2*time.Minute))
+	require.NoError(t, err)
+	_, ok = utils.ValidateTotpCode(secret, old, 0, now)
+	assert.False(t, ok)
+}
+
+// TestRecoveryCodes checks that recovery codes are unique and hashed independently of formatting.
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := utils.GenerateRecoveryCodes(utils.RecoveryCodesCount)
+	require.NoError(t, err)
+	require.Len(t, codes, utils.RecoveryCodesCount)
+
+	seen := map[string]bool{}
+	for i, code := range codes {
+		assert.False(t, seen[code])
+		seen[code] = true
+		assert.Equal(t, hashes[i], utils.HashRecoveryCode(strings.ToUpper(strings.ReplaceAll(code, "-", " "))))
+		assert.NotContains(t, hashes[i], code)
+	}
+}
+
+// TestTotpSecretEncryption checks the secret is stored encrypted and requires the key.
+func TestTotpSecretEncryption(t *testing.T) {
+	setMfaKey(t)
+
+	encrypted, err := utils.EncryptTotpSecret("JBSWY3DPEHPK3PXP")
+	require.NoError(t, err)
+	assert.NotContains(t, encrypted, "JBSWY3DPEHPK3PXP")
+
+	secret, err := utils.DecryptTotpSecret(encrypted)
+	require.NoError(t, err)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", secret)
+
+	t.Setenv("MFA_ENCRYPTION_KEY", "")
+	_, err = utils.EncryptTotpSecret("JBSWY3DPEHPK3PXP")
+	assert.ErrorIs(t, err, utils.ErrMfaKeyNotConfigured)
+}
+
+// TestVerifySecondFactor tests TOTP and recovery code checks at login.
+func TestVerifySecondFactor(t *testing.T) {
+	setMfaKey(t)
+
+	secret, _, err := utils.GenerateTotpSecret("user@example.com")
+	require.NoError(t, err)
+	encrypted, err := utils.EncryptTotpSecret(secret)
+	require.NoError(t, err)
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+
+	totpRow := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"totp_secret", "totp_enabled", "totp_last_step"}).AddRow(encrypted, true, 0)
+	}
+	recoveryQuery := `UPDATE mfaRecoveryCodes SET used_at = NOW\(\) WHERE auth_user_id = \$1 AND code_hash = \$2 AND used_at IS NULL`
+
+	tests := []struct {
+		name         string
+		code         string
+		prepareMocks func(mock sqlmock.Sqlmock)
+		expectedErr  error
+	}{
+		{
+			name: "Valid TOTP code",
+			code: code,
+			prepareMocks: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectTotpQuery).WithArgs("1").WillReturnRows(totpRow())
+				mock.ExpectExec(`UPDATE authusers SET totp_last_step = \$1 WHERE id = \$2`).
+					WithArgs(sqlmock.AnyArg(), "1").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Unused recovery code",
+			code: "abcd-efgh",
+			prepareMocks: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectTotpQuery).WithArgs("1").WillReturnRows(totpRow())
+				mock.ExpectExec(recoveryQuery).
+					WithArgs("1", utils.HashRecoveryCode("abcd-efgh")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name: "Wrong or used code",
+			code: "abcd-efgh",
+			prepareMocks: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectTotpQuery).WithArgs("1").WillReturnRows(totpRow())
+				mock.ExpectExec(recoveryQuery).
+					WithArgs("1", utils.HashRecoveryCode("abcd-efgh")).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectRollback()
+			},
+			expectedErr: dbauthservice.ErrInvalidMfaCode,
+		},
+		{
+			name: "TOTP not enabled",
+			code: code,
+			prepareMocks: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectTotpQuery).WithArgs("1").
+					WillReturnRows(sqlmock.NewRows([]string{"totp_secret", "totp_enabled", "totp_last_step"}).
+						AddRow(nil, false, 0))
+				mock.ExpectRollback()
+			},
+			expectedErr: dbauthservice.ErrTotpNotEnrolled,
+		},
+		{
+			name: "User not found",
+			code: code,
+			prepareMocks: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(selectTotpQuery).WithArgs("1").WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			expectedErr: dbauthservice.ErrAuthUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			require.NoError(t, err)
+			defer db.Close()
+
+			tt.prepareMocks(mock)
+
+			err = dbauthservice.VerifySecondFactor(context.Background(), db, "1", tt.code)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// TestVerifySecondFactorLimited checks wrong codes lock the second factor check and a valid code resets the counter.
+func TestVerifySecondFactorLimited(t *testing.T) {
+	setMfaKey(t)
+
+	secret, _, err := utils.GenerateTotpSecret("user@example.com")
+	require.NoError(t, err)
+	encrypted, err := utils.EncryptTotpSecret(secret)
+	require.NoError(t, err)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	limiter := utils.NewLoginLimiter(newMemoryRedisClient())
+	ctx := context.Background()
+	expectWrongCode := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectTotpQuery).WithArgs("1").
+			WillReturnRows(sqlmock.NewRows([]string{"totp_secret", "totp_enabled", "totp_last_step"}).AddRow(encrypted, true, 0))
+		mock.ExpectExec(`UPDATE mfaRecoveryCodes SET used_at = NOW\(\)`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+	}
+
+	// A valid code resets the failures counted before it
+	for i := 0; i < utils.AccountFailureLimit-1; i++ {
+		expectWrongCode()
+		_, err = dbauthservice.VerifySecondFactorLimited(ctx, db, limiter, "1", "wrong-code")
+		assert.ErrorIs(t, err, dbauthservice.ErrInvalidMfaCode)
+	}
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+	mock.ExpectBegin()
+	mock.ExpectQuery(selectTotpQuery).WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"totp_secret", "totp_enabled", "totp_last_step"}).AddRow(encrypted, true, 0))
+	mock.ExpectExec(`UPDATE authusers SET totp_last_step = \$1 WHERE id = \$2`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	_, err = dbauthservice.VerifySecondFactorLimited(ctx, db, limiter, "1", code)
+	require.NoError(t, err)
+
+	// The limit is reached again from zero, the last wrong code locks the check
+	for i := 0; i < utils.AccountFailureLimit-1; i++ {
+		expectWrongCode()
+		_, err = dbauthservice.VerifySecondFactorLimited(ctx, db, limiter, "1", "wrong-code")
+		assert.ErrorIs(t, err, dbauthservice.ErrInvalidMfaCode)
+	}
+	expectWrongCode()
+	retryAfter, err := dbauthservice.VerifySecondFactorLimited(ctx, db, limiter, "1", "wrong-code")
+	assert.ErrorIs(t, err, dbauthservice.ErrMfaLocked)
+	assert.Equal(t, utils.LoginLockoutBase, retryAfter)
+
+	// While locked the code is not checked against the database at all
+	retryAfter, err = dbauthservice.VerifySecondFactorLimited(ctx, db, limiter, "1", code)
+	assert.ErrorIs(t, err, dbauthservice.ErrMfaLocked)
+	assert.Greater(t, retryAfter, time.Duration(0))
+
+	// The password login counter of the same user is not affected
+	passwordLock, err := limiter.RetryAfter(ctx, utils.LoginAccount("1", "", ""), "")
+	require.NoError(t, err)
+	assert.Zero(t, passwordLock)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestConfirmTotpEnrollment checks that enrollment issues hashed recovery codes.
+func TestConfirmTotpEnrollment(t *testing.T) {
+	setMfaKey(t)
+
+	secret, _, err := utils.GenerateTotpSecret("user@example.com")
+	require.NoError(t, err)
+	encrypted, err := utils.EncryptTotpSecret(secret)
+	require.NoError(t, err)
+	code, err := totp.GenerateCode(secret, time.Now())
+	require.NoError(t, err)
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(selectTotpQuery).WithArgs("1").
+		WillReturnRows(sqlmock.NewRows([]string{"totp_secret", "totp_enabled", "totp_last_step"}).AddRow(encrypted, false, 0))
+	mock.ExpectExec(`UPDATE authusers SET totp_enabled = TRUE, totp_last_step = \$1 WHERE id = \$2`).
+		WithArgs(sqlmock.AnyArg(), "1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM mfaRecoveryCodes WHERE auth_user_id = \$1`).
+		WithArgs("1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for i := 0; i < utils.RecoveryCodesCount; i++ {
+		mock.ExpectExec(`INSERT INTO mfaRecoveryCodes \(auth_user_id, code_hash\) VALUES \(\$1, \$2\)`).
+			WithArgs("1", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+	}
+	mock.ExpectCommit()
+
+	recoveryCodes, err := dbauthservice.ConfirmTotpEnrollment(context.Background(), db, "1", code)
+	require.NoError(t, err)
+	assert.Len(t, recoveryCodes, utils.RecoveryCodesCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestMfaStateMethod checks which second factor step login requires.
+func TestMfaStateMethod(t *testing.T) {
+	assert.Equal(t, "", (&dbauthservice.MfaState{}).Method())
+	assert.Equal(t, dbauthservice.MfaMethodTotp, (&dbauthservice.MfaState{TotpEnabled: true, Required: true}).Method())
+	assert.Equal(t, dbauthservice.MfaMethodEnroll, (&dbauthservice.MfaState{Required: true}).Method())
+}
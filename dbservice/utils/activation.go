@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"context"
+	"crmSystem/proto/redis"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Статусы учётной записи в таблице authusers.
+const (
+	AuthStatusUnverified = "unverified" // email не подтверждён после регистрации компании
+	AuthStatusInvited    = "invited"    // пользователь приглашён и ещё не задал пароль
+	AuthStatusVerified   = "verified"   // учётная запись активирована
+)
+
+// Назначение токена активации.
+const (
+	ActivationPurposeVerify = "verify" // подтверждение email при регистрации компании
+	ActivationPurposeInvite = "invite" // приглашение пользователя в компанию
+)
+
+// Время действия ссылок активации.
+const (
+	EmailVerificationTTL = 24 * time.Hour
+	InviteTTL            = 72 * time.Hour
+)
+
+const activationKeyPrefix = "accountActivation:"
+
+// ErrActivationTokenInvalid возвращается, если токен активации не найден, уже использован или истёк.
+var ErrActivationTokenInvalid = errors.New("ссылка активации недействительна или устарела")
+
+// ActivationRecord данные, сохраняемые в redis для токена активации.
+type ActivationRecord struct {
+	UserId  string `json:"user_id"` // ID пользователя в базе данных авторизации
+	Purpose string `json:"purpose"` // ActivationPurposeVerify или ActivationPurposeInvite
+}
+
+// IssueActivationToken создаёт одноразовый токен активации учётной записи.
+// В redis сохраняется только SHA-256 хэш токена, сам токен отправляется пользователю в письме.
+func IssueActivationToken(ctx context.Context, client redis.RedisServiceClient, authUserId string,
+	purpose string, ttl time.Duration) (string, error) {
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать токен активации: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	value, err := json.Marshal(&ActivationRecord{UserId: authUserId, Purpose: purpose})
+	if err != nil {
+		return "", err
+	}
+
+	res, err := client.Save(ctx, &redis.SaveRedisRequest{
+		Key:        activationKey(token),
+		Value:      string(value),
+		Expiration: int64(ttl.Seconds()),
+	})
+	if err != nil {
+		return "", fmt.Errorf("не удалось сохранить токен активации: %w", err)
+	}
+	if res.GetStatus() != http.StatusOK {
+		return "", fmt.Errorf("не удалось сохранить токен активации: %s", res.GetMessage())
+	}
+
+	return token, nil
+}
+
+// PeekActivationToken возвращает данные токена активации, не помечая его использованным.
+func PeekActivationToken(ctx context.Context, client redis.RedisServiceClient, token string) (*ActivationRecord, error) {
+	res, err := client.Get(ctx, &redis.GetRedisRequest{Key: activationKey(token)})
+	return activationRecordFromResponse(res, err)
+}
+
+// ConsumeActivationToken возвращает данные токена активации и удаляет его.
+// Один токен может быть использован только один раз.
+func ConsumeActivationToken(ctx context.Context, client redis.RedisServiceClient, token string) (*ActivationRecord, error) {
+	res, err := client.GetDel(ctx, &redis.GetRedisRequest{Key: activationKey(token)})
+	return activationRecordFromResponse(res, err)
+}
+
+// activationRecordFromResponse разбирает ответ redis сервиса с данными токена активации.
+func activationRecordFromResponse(res *redis.GetRedisResponse, err error) (*ActivationRecord, error) {
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения токена активации: %w", err)
+	}
+	if res.GetStatus() == http.StatusNotFound {
+		return nil, ErrActivationTokenInvalid
+	}
+	if res.GetStatus() != http.StatusOK {
+		return nil, fmt.Errorf("ошибка получения токена активации: %s", res.GetMessage())
+	}
+
+	record := &ActivationRecord{}
+	if err := json.Unmarshal([]byte(res.GetMessage()), record); err != nil {
+		return nil, fmt.Errorf("повреждены данные токена активации: %w", err)
+	}
+	return record, nil
+}
+
+// activationKey ключ redis для токена активации.
+func activationKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return activationKeyPrefix + hex.EncodeToString(sum[:])
+}
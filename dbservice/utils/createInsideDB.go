@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 )
 
 // CreateInsideDB создает новую базу данных с указанным именем, если она еще не существует.
@@ -65,3 +66,73 @@ func CreateInsideDB(dbName string) error {
 	log.Printf("База данных %s успешно создана", dbName)
 	return nil
 }
+
+// CreateDBFromTemplate создает базу данных dbName копированием уже мигрированной базы template.
+// Если база данных уже существует, ничего не делает, поэтому вызов можно безопасно повторить.
+//
+// PostgreSQL копирует шаблон только при отсутствии других подключений к нему,
+// поэтому соединения с шаблоном не хранятся в пуле и закрываются сразу после миграций.
+func CreateDBFromTemplate(dbName string, template string) error {
+	if dbName == "" || template == "" {
+		return fmt.Errorf("Имя базы данных и шаблона не может быть пустым")
+	}
+
+	db, err := sql.Open("postgres", DsnString(os.Getenv("SERVER_NAME")))
+	if err != nil {
+		return fmt.Errorf("Ошибка подключения к базе данных: %w", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Ошибка при закрытии текущего соединения: %v", err)
+		}
+	}()
+
+	var exists bool
+	err = db.QueryRow(`SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname = $1)`, dbName).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("Ошибка проверки существования базы данных: %w", err)
+	}
+	if exists {
+		log.Printf("База данных %s уже существует", dbName)
+		return nil
+	}
+
+	_, err = db.Exec(fmt.Sprintf(`CREATE DATABASE %s TEMPLATE %s`, QuoteIdentifier(dbName), QuoteIdentifier(template)))
+	if err != nil {
+		return fmt.Errorf("Ошибка создания базы данных %s из шаблона %s: %w", dbName, template, err)
+	}
+
+	log.Printf("База данных %s создана из шаблона %s", dbName, template)
+	return nil
+}
+
+// DropDatabase безвозвратно удаляет базу данных dbName, завершая открытые к ней соединения.
+// Отсутствующая база не считается ошибкой, поэтому вызов можно безопасно повторить.
+func DropDatabase(dbName string) error {
+	if dbName == "" {
+		return fmt.Errorf("Имя базы данных не может быть пустым")
+	}
+
+	db, err := sql.Open("postgres", DsnString(os.Getenv("SERVER_NAME")))
+	if err != nil {
+		return fmt.Errorf("Ошибка подключения к базе данных: %w", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Ошибка при закрытии текущего соединения: %v", err)
+		}
+	}()
+
+	_, err = db.Exec(fmt.Sprintf(`DROP DATABASE IF EXISTS %s WITH (FORCE)`, QuoteIdentifier(dbName)))
+	if err != nil {
+		return fmt.Errorf("Ошибка удаления базы данных %s: %w", dbName, err)
+	}
+
+	log.Printf("База данных %s удалена", dbName)
+	return nil
+}
+
+// QuoteIdentifier экранирует имя базы данных для подстановки в SQL запрос.
+func QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,227 @@
+package utils
+
+import (
+	"context"
+	"crmSystem/jwks"
+	"crmSystem/lifecycle"
+	"crmSystem/proto/logs"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"log"
+)
+
+// Названия claims, в которых auth сервис передаёт данные пользователя.
+const (
+	ClaimDatabase  = "db"
+	ClaimUserId    = "sub"
+	ClaimCompanyId = "cid"
+	ClaimRole      = "role"
+	ClaimTokenType = "typ"
+)
+
+// MfaPendingTokenType тип токена, который auth сервис выдаёт после проверки пароля
+// до ввода второго фактора. Такой токен не даёт доступа ни к одному методу.
+const MfaPendingTokenType = "mfa_pending"
+
+// AccessTokenType тип токена, с которым пользователь обращается к сервисам.
+// Refresh токен содержит те же данные пользователя, но принимается только auth сервисом.
+const AccessTokenType = "access"
+
+// Ключи метаданных, которые ранее использовались для передачи данных пользователя.
+// Теперь они не принимаются как источник данных, но их расхождение с токеном
+// считается попыткой доступа к чужой компании.
+var identityMetadataKeys = map[string]string{
+	"database":   ClaimDatabase,
+	"user-id":    ClaimUserId,
+	"company-id": ClaimCompanyId,
+}
+
+// Identity данные пользователя, полученные из подписанного JWT токена.
+type Identity struct {
+	Database  string // База данных компании
+	UserId    string // ID пользователя в базе данных компании
+	CompanyId string // ID компании в базе данных авторизации
+	Role      string // Роль пользователя в компании
+}
+
+type identityContextKey struct{}
+
+// ContextWithIdentity возвращает контекст с привязанными данными пользователя.
+func ContextWithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, identity)
+}
+
+// IdentityFromContext возвращает данные пользователя, установленные IdentityInterceptor.
+// Если токен запроса не содержит данных пользователя (например внутренний токен сервиса),
+// возвращает ошибку Unauthenticated.
+func IdentityFromContext(ctx context.Context) (*Identity, error) {
+	identity, ok := ctx.Value(identityContextKey{}).(*Identity)
+	if !ok || identity == nil {
+		return nil, status.Errorf(codes.Unauthenticated, "данные пользователя не найдены в токене")
+	}
+	return identity, nil
+}
+
+// RequireInternalCaller разрешает вызов метода только внутренним сервисам.
+// Токен пользователя (с данными компании) для таких методов не принимается.
+func RequireInternalCaller(ctx context.Context) error {
+	if _, err := IdentityFromContext(ctx); err == nil {
+		return status.Errorf(codes.PermissionDenied, "метод доступен только внутренним сервисам")
+	}
+	return nil
+}
+
+// CrossTenantReporter вызывается при обнаружении попытки доступа к данным другой компании.
+type CrossTenantReporter func(ctx context.Context, identity *Identity, method string, reason string)
+
+// NewIdentityInterceptor создаёт gRPC interceptor, который проверяет подпись JWT токена
+// из заголовка authorization и переносит данные пользователя из claims в контекст.
+//
+// Данные пользователя берутся только из проверенного токена. Если в метаданных запроса
+// переданы database, user-id или company-id, отличающиеся от токена, запрос отклоняется
+// с кодом PermissionDenied, а попытка передаётся в report. Токены пользователя с claim typ, отличным
+// от access (refresh, mfa_pending), отклоняются. Проверки состояния grpc.health.v1.Health
+// выполняются без токена.
+func NewIdentityInterceptor(keys *jwks.KeySet, report CrossTenantReporter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if lifecycle.IsHealthMethod(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		tokenString, err := ExtractTokenFromContext(ctx)
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "%v", err)
+		}
+
+		claims, err := ParseToken(tokenString, keys)
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "недействительный токен: %v", err)
+		}
+
+		// Токен ожидания второго фактора не содержит данных компании и иначе
+		// был бы принят как внутренний токен сервиса
+		tokenType := claimString(claims, ClaimTokenType)
+		if tokenType == MfaPendingTokenType {
+			return nil, status.Errorf(codes.Unauthenticated, "вход не завершён: требуется второй фактор")
+		}
+
+		identity := identityFromClaims(claims)
+		if identity == nil && tokenType == "" {
+			// Внутренний токен сервиса: данных пользователя нет, методы,
+			// которым они нужны, получат ошибку из IdentityFromContext
+			return handler(ctx, req)
+		}
+
+		// Токен пользователя принимается, только если это access token: refresh token
+		// с теми же данными не должен открывать доступ к методам
+		if identity == nil || tokenType != AccessTokenType {
+			return nil, status.Errorf(codes.Unauthenticated, "требуется access token")
+		}
+
+		if reason := metadataMismatch(ctx, claims); reason != "" {
+			if report != nil {
+				report(ctx, identity, info.FullMethod, reason)
+			}
+			return nil, status.Errorf(codes.PermissionDenied, "доступ к данным другой компании запрещён")
+		}
+
+		return handler(ContextWithIdentity(ctx, identity), req)
+	}
+}
+
+// ParseToken проверяет подпись и срок действия токена и возвращает его claims.
+// Ключ проверки выбирается из keys по заголовку kid токена.
+func ParseToken(tokenString string, keys *jwks.KeySet) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, keys.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("токен недействителен")
+	}
+	return claims, nil
+}
+
+// identityFromClaims собирает Identity из claims. Возвращает nil, если токен не содержит базы данных.
+func identityFromClaims(claims jwt.MapClaims) *Identity {
+	database := claimString(claims, ClaimDatabase)
+	if database == "" {
+		return nil
+	}
+	return &Identity{
+		Database:  database,
+		UserId:    claimString(claims, ClaimUserId),
+		CompanyId: claimString(claims, ClaimCompanyId),
+		Role:      claimString(claims, ClaimRole),
+	}
+}
+
+// metadataMismatch сравнивает устаревшие ключи метаданных с claims токена.
+// Возвращает описание расхождения или пустую строку.
+func metadataMismatch(ctx context.Context, claims jwt.MapClaims) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	for key, claim := range identityMetadataKeys {
+		for _, value := range md.Get(key) {
+			if value != claimString(claims, claim) {
+				return fmt.Sprintf("метаданные %s=%q не совпадают с токеном (%q)", key, value, claimString(claims, claim))
+			}
+		}
+	}
+	return ""
+}
+
+// claimString возвращает строковое значение claim, приводя числа к строке.
+func claimString(claims jwt.MapClaims, name string) string {
+	switch v := claims[name].(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	default:
+		return ""
+	}
+}
+
+// DenyCrossTenant фиксирует попытку обращения к данным другой компании
+// и возвращает ошибку PermissionDenied для клиента.
+func DenyCrossTenant(ctx context.Context, identity *Identity, reason string) error {
+	method, _ := grpc.Method(ctx)
+	ReportCrossTenantAccess(ctx, identity, method, reason)
+	return status.Errorf(codes.PermissionDenied, "доступ к данным другой компании запрещён")
+}
+
+// ReportCrossTenantAccess записывает попытку доступа к данным другой компании в сервис логов.
+func ReportCrossTenantAccess(ctx context.Context, identity *Identity, method string, reason string) {
+	message := fmt.Sprintf("Попытка доступа к данным другой компании: метод %s, компания %s, пользователь %s: %s",
+		method, identity.CompanyId, identity.UserId, reason)
+	log.Print(message)
+
+	token, err := ExtractTokenFromContext(ctx)
+	if err != nil {
+		return
+	}
+
+	clientLogs, err, conn := GRPCServiceConnector(token, logs.NewLogsServiceClient)
+	if err != nil {
+		log.Printf("Не удалось подключиться к серверу логов: %v", err)
+		return
+	}
+	defer func(conn *grpc.ClientConn) {
+		if err := conn.Close(); err != nil {
+			log.Printf("Ошибка закрытия соединения: %v", err)
+		}
+	}(conn)
+
+	if err := SaveLogsWarning(ctx, clientLogs, identity.Database, identity.UserId, message); err != nil {
+		log.Printf("Не удалось сохранить лог: %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"context"
+	"crmSystem/proto/redis"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Ограничения неудачных попыток входа.
+//
+// Неудачные попытки считаются отдельно для учётной записи и для IP адреса в пределах окна
+// LoginFailureWindow. После достижения лимита вход блокируется на LoginLockoutBase, каждая
+// следующая неудачная попытка удваивает блокировку, но не более LoginLockoutMax.
+const (
+	AccountFailureLimit = 5  // Неудачных попыток для одной учётной записи до блокировки
+	IpFailureLimit      = 20 // Неудачных попыток с одного IP адреса до блокировки
+
+	LoginFailureWindow = time.Hour
+	LoginLockoutBase   = 30 * time.Second
+	LoginLockoutMax    = time.Hour
+)
+
+// Область блокировки входа.
+const (
+	LockoutScopeAccount = "account"
+	LockoutScopeIp      = "ip"
+)
+
+const (
+	loginFailuresKeyPrefix = "loginFailures:"
+	loginLockoutKeyPrefix  = "loginLockout:"
+)
+
+// LoginLockout новая блокировка, установленная после неудачной попытки входа.
+type LoginLockout struct {
+	Scope    string        // LockoutScopeAccount или LockoutScopeIp
+	Subject  string        // Учётная запись или IP адрес
+	Failures int64         // Количество неудачных попыток в окне
+	Duration time.Duration // Время блокировки
+}
+
+// LoginLimiter ведёт счётчики неудачных попыток входа и блокировки в redis.
+type LoginLimiter struct {
+	client redis.RedisServiceClient
+	now    func() time.Time
+}
+
+func NewLoginLimiter(client redis.RedisServiceClient) *LoginLimiter {
+	return &LoginLimiter{client: client, now: time.Now}
+}
+
+// LoginAccount возвращает идентификатор учётной записи, по которому считаются попытки входа.
+// Попытки найденного пользователя считаются по его ID в таблице authusers: вход по email и по телефону
+// попадает в один счётчик, и подстановка других данных входа его не обходит. Для несуществующих
+// учётных записей (пустой authUserId) попытки считаются по email или телефону, поэтому ответ
+// не выдаёт, зарегистрирован ли адрес.
+func LoginAccount(authUserId string, email string, phone string) string {
+	if authUserId != "" {
+		return "user:" + authUserId
+	}
+	if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+		return "email:" + email
+	}
+	return "phone:" + strings.TrimSpace(phone)
+}
+
+// MfaAccount возвращает идентификатор, по которому считаются неудачные попытки ввода второго фактора.
+// Счётчик отделён от счётчика пароля, чтобы верный пароль не сбрасывал попытки подбора кода.
+func MfaAccount(authUserId string) string {
+	return "mfa:" + authUserId
+}
+
+// LoginLockoutDuration возвращает время блокировки после failures неудачных попыток
+// или 0, если лимит limit ещё не достигнут.
+func LoginLockoutDuration(failures int64, limit int64) time.Duration {
+	if failures < limit {
+		return 0
+	}
+	duration := LoginLockoutBase
+	for i := limit; i < failures && duration < LoginLockoutMax; i++ {
+		duration *= 2
+	}
+	if duration > LoginLockoutMax {
+		duration = LoginLockoutMax
+	}
+	return duration
+}
+
+// RetryAfter возвращает оставшееся время блокировки учётной записи account или IP адреса ip.
+// Если вход не заблокирован, возвращается 0. Пустой ip не проверяется.
+func (l *LoginLimiter) RetryAfter(ctx context.Context, account string, ip string) (time.Duration, error) {
+	var retryAfter time.Duration
+	for _, key := range l.lockoutKeys(account, ip) {
+		res, err := l.client.Get(ctx, &redis.GetRedisRequest{Key: key})
+		if err != nil {
+			return 0, fmt.Errorf("ошибка проверки блокировки входа: %w", err)
+		}
+		if res.GetStatus() != http.StatusOK {
+			continue
+		}
+
+		// Значение - время окончания блокировки в секундах Unix
+		unlockAt, err := strconv.ParseInt(res.GetMessage(), 10, 64)
+		if err != nil {
+			continue
+		}
+		if left := time.Unix(unlockAt, 0).Sub(l.now()); left > retryAfter {
+			retryAfter = left
+		}
+	}
+
+	if retryAfter > 0 && retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+	return retryAfter, nil
+}
+
+// RegisterFailure учитывает неудачную попытку входа и возвращает установленные ею блокировки.
+func (l *LoginLimiter) RegisterFailure(ctx context.Context, account string, ip string) ([]LoginLockout, error) {
+	var lockouts []LoginLockout
+
+	counters := []struct {
+		scope   string
+		subject string
+		limit   int64
+	}{
+		{LockoutScopeAccount, account, AccountFailureLimit},
+		{LockoutScopeIp, ip, IpFailureLimit},
+	}
+
+	for _, counter := range counters {
+		if counter.subject == "" {
+			continue
+		}
+
+		res, err := l.client.Increment(ctx, &redis.IncrementRedisRequest{
+			Key:        loginFailuresKeyPrefix + counter.scope + ":" + counter.subject,
+			Expiration: int64(LoginFailureWindow.Seconds()),
+		})
+		if err != nil {
+			return lockouts, fmt.Errorf("ошибка учёта неудачной попытки входа: %w", err)
+		}
+		if res.GetStatus() != http.StatusOK {
+			return lockouts, fmt.Errorf("ошибка учёта неудачной попытки входа: %s", res.GetMessage())
+		}
+
+		duration := LoginLockoutDuration(res.GetValue(), counter.limit)
+		if duration == 0 {
+			continue
+		}
+
+		unlockAt := l.now().Add(duration).Unix()
+		_, err = l.client.Set(ctx, &redis.SaveRedisRequest{
+			Key:        loginLockoutKeyPrefix + counter.scope + ":" + counter.subject,
+			Value:      strconv.FormatInt(unlockAt, 10),
+			Expiration: int64(duration.Seconds()),
+		})
+		if err != nil {
+			return lockouts, fmt.Errorf("ошибка сохранения блокировки входа: %w", err)
+		}
+
+		lockouts = append(lockouts, LoginLockout{
+			Scope:    counter.scope,
+			Subject:  counter.subject,
+			Failures: res.GetValue(),
+			Duration: duration,
+		})
+	}
+
+	return lockouts, nil
+}
+
+// Reset сбрасывает счётчик неудачных попыток и блокировку учётных записей accounts.
+// Вызывается после успешного входа и при разблокировке администратором.
+func (l *LoginLimiter) Reset(ctx context.Context, accounts ...string) error {
+	var keys []string
+	for _, account := range accounts {
+		keys = append(keys,
+			loginFailuresKeyPrefix+LockoutScopeAccount+":"+account,
+			loginLockoutKeyPrefix+LockoutScopeAccount+":"+account,
+		)
+	}
+
+	res, err := l.client.Delete(ctx, &redis.DeleteRedisRequest{Keys: keys})
+	if err != nil {
+		return fmt.Errorf("ошибка сброса блокировки входа: %w", err)
+	}
+	if res.GetStatus() != http.StatusOK {
+		return fmt.Errorf("ошибка сброса блокировки входа: %s", res.GetMessage())
+	}
+	return nil
+}
+
+func (l *LoginLimiter) lockoutKeys(account string, ip string) []string {
+	keys := []string{loginLockoutKeyPrefix + LockoutScopeAccount + ":" + account}
+	if ip != "" {
+		keys = append(keys, loginLockoutKeyPrefix+LockoutScopeIp+":"+ip)
+	}
+	return keys
+}
+
+// ClientIpFromContext возвращает IP адрес клиента, переданный auth сервисом в метаданных "client-ip".
+func ClientIpFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get("client-ip"); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
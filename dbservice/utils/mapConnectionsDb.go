@@ -1,73 +1,559 @@
 package utils
 
 import (
+	"container/list"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Значения по умолчанию для ограничений пула, переопределяются переменными окружения
+const (
+	defaultPoolMaxOpenConns      = 400              // DB_POOL_MAX_CONNS
+	defaultPoolMaxOpenConnsPerDb = 10               // DB_POOL_CONNS_PER_DB
+	defaultPoolMaxIdleConnsPerDb = 2                // DB_POOL_IDLE_CONNS_PER_DB
+	defaultPoolIdleTimeout       = 10 * time.Minute // DB_POOL_IDLE_TIMEOUT
+	defaultPoolEvictionGrace     = 5 * time.Second
+)
+
+// poolHealthCheckInterval как часто проверяется соединение, выдаваемое из пула.
+// Чаще соединение не пингуется, чтобы не добавлять лишний запрос к каждому вызову.
+const poolHealthCheckInterval = time.Minute
+
+// ErrPoolExhausted возвращается, если достигнут общий предел соединений и все открытые базы заняты.
+var ErrPoolExhausted = errors.New("достигнут предел открытых соединений с базами данных, повторите запрос позже")
+
+// PoolConfig ограничения пула соединений с базами данных.
+//
+// Каждая база получает собственный *sql.DB не более чем с MaxOpenConnsPerDb соединениями,
+// поэтому одновременно открыто не более MaxOpenConns / MaxOpenConnsPerDb баз.
+type PoolConfig struct {
+	MaxOpenConns      int           // Общий предел открытых соединений со всеми базами
+	MaxOpenConnsPerDb int           // Предел открытых соединений с одной базой
+	MaxIdleConnsPerDb int           // Простаивающих соединений, сохраняемых для одной базы
+	IdleTimeout       time.Duration // База, не запрашивавшаяся дольше, закрывается EvictIdle
+	EvictionGrace     time.Duration // Недавно выданная база не вытесняется, пока обработчик не начал запрос
+
+	// Open открывает соединение с базой dbName, по умолчанию postgres по строке DsnString
+	Open func(dbName string) (*sql.DB, error)
+}
+
+// DefaultPoolConfig возвращает ограничения пула из переменных окружения
+// DB_POOL_MAX_CONNS, DB_POOL_CONNS_PER_DB, DB_POOL_IDLE_CONNS_PER_DB и DB_POOL_IDLE_TIMEOUT.
+func DefaultPoolConfig() PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:      envInt("DB_POOL_MAX_CONNS", defaultPoolMaxOpenConns),
+		MaxOpenConnsPerDb: envInt("DB_POOL_CONNS_PER_DB", defaultPoolMaxOpenConnsPerDb),
+		MaxIdleConnsPerDb: envInt("DB_POOL_IDLE_CONNS_PER_DB", defaultPoolMaxIdleConnsPerDb),
+		IdleTimeout:       envDuration("DB_POOL_IDLE_TIMEOUT", defaultPoolIdleTimeout),
+		EvictionGrace:     defaultPoolEvictionGrace,
+		Open:              openPostgres,
+	}
+}
+
+// PoolStats метрики пула соединений.
+type PoolStats struct {
+	Hits      int64 // Соединение выдано из пула
+	Misses    int64 // Соединение открыто заново
+	Evictions int64 // Базы, закрытые для освобождения места или из-за простоя
+
+	Databases       int // Открытых баз
+	MaxDatabases    int // Предел открытых баз
+	OpenConnections int // Открытых соединений со всеми базами
+	InUse           int // Соединений, занятых запросами
+}
+
+type poolEntry struct {
+	name      string
+	db        *sql.DB
+	elem      *list.Element // Позиция в lru
+	lastUsed  time.Time
+	checkedAt time.Time
+	leases    int  // Выданные Acquire и ещё не освобождённые аренды
+	retired   bool // Удалена из пула, закрывается после освобождения последней аренды
+}
+
+// poolCall открытие базы, которого ожидают параллельные запросы к той же базе.
+type poolCall struct {
+	done  chan struct{}
+	entry *poolEntry
+	err   error
+}
+
+// MapConnectionsDB потокобезопасный пул соединений с базами данных компаний.
+//
+// Базы хранятся в порядке последнего использования: при достижении общего предела
+// закрывается давно не использовавшаяся база без аренд и выполняемых запросов. Параллельные запросы
+// к ещё не открытой базе открывают её один раз. База авторизации из пула не вытесняется.
+//
+// Обработчики запросов берут базу компании через Acquire и освобождают аренду по завершении:
+// база, удалённая из пула во время аренды (Remove, ошибка проверки соединения), закрывается
+// только после освобождения последней аренды.
 type MapConnectionsDB struct {
-	MapDB map[string]*sql.DB // Карта для хранения соединений с базами данных
+	config       PoolConfig
+	maxDatabases int
+	tenants      *TenantResolver // Реестр компаний, соединения открываются только с базами активных компаний
+
+	mu       sync.Mutex
+	dbs      map[string]*poolEntry
+	lru      *list.List // Имена баз, в начале - недавно использованные
+	inflight map[string]*poolCall
+
+	hits      atomic.Int64
+	misses    atomic.Int64
+	evictions atomic.Int64
 }
 
-// Конструктор для инициализации MapConnectionsDB
+// Конструктор для инициализации MapConnectionsDB с ограничениями из переменных окружения
 func NewMapConnectionsDB() *MapConnectionsDB {
-	return &MapConnectionsDB{
-		MapDB: make(map[string]*sql.DB),
+	return NewMapConnectionsDBWithConfig(DefaultPoolConfig())
+}
+
+// NewMapConnectionsDBWithConfig создаёт пул с заданными ограничениями.
+func NewMapConnectionsDBWithConfig(config PoolConfig) *MapConnectionsDB {
+	if config.MaxOpenConnsPerDb <= 0 {
+		config.MaxOpenConnsPerDb = defaultPoolMaxOpenConnsPerDb
+	}
+	if config.MaxOpenConns < config.MaxOpenConnsPerDb {
+		config.MaxOpenConns = config.MaxOpenConnsPerDb
+	}
+	if config.MaxIdleConnsPerDb <= 0 {
+		config.MaxIdleConnsPerDb = defaultPoolMaxIdleConnsPerDb
+	}
+	if config.MaxIdleConnsPerDb > config.MaxOpenConnsPerDb {
+		config.MaxIdleConnsPerDb = config.MaxOpenConnsPerDb
 	}
+	if config.Open == nil {
+		config.Open = openPostgres
+	}
+
+	s := &MapConnectionsDB{
+		config:       config,
+		maxDatabases: config.MaxOpenConns / config.MaxOpenConnsPerDb,
+		dbs:          make(map[string]*poolEntry),
+		lru:          list.New(),
+		inflight:     make(map[string]*poolCall),
+	}
+	// Реестр компаний читается через соединение с базой авторизации из этого же пула
+	s.tenants = NewTenantResolver(func() (*sql.DB, error) {
+		return s.GetDb(os.Getenv("DB_AUTH_NAME"))
+	})
+	return s
 }
 
-// GetDb проверяет существование открытого соединения с базой данных по имени dbName.
-// Если соединение уже существует и активно, возвращает его.
-// В противном случае создаёт новое соединение к базе данных.
+// GetDb возвращает соединение с базой данных dbName, открывая его при необходимости.
 //
 // Параметры:
-// - dbName: Имя базы данных, для которой необходимо получить соединение.
+// - dbName: Имя базы данных (не строка подключения).
 //
 // Возвращает:
 // - Указатель на sql.DB, если соединение успешно получено или создано.
-// - Ошибка, если произошла ошибка при открытии нового соединения или при проверке существующего.
+// - Ошибка, если база не принадлежит активной компании, достигнут предел соединений
+// или соединение не удалось открыть.
 //
-// Если существующее соединение не активно, оно будет закрыто и удалено из карты mapDB.
+// Соединение, не использовавшееся дольше poolHealthCheckInterval, проверяется перед выдачей
+// и при ошибке открывается заново. Вызывающий код не закрывает полученное соединение.
+// Соединение выдаётся без аренды, поэтому подходит для базы авторизации, которая не вытесняется;
+// для баз компаний используется Acquire.
 func (s *MapConnectionsDB) GetDb(dbName string) (*sql.DB, error) {
-	if db, exists := s.MapDB[dbName]; exists {
-		// Проверяем, активен ли connection
-		if err := db.Ping(); err == nil {
-			return db, nil // Соединение активное, возвращаем его
+	entry, err := s.acquire(dbName, false)
+	if err != nil {
+		return nil, err
+	}
+	return entry.db, nil
+}
+
+// Acquire возвращает соединение с базой данных dbName и аренду на него. Пока аренда не освобождена
+// вызовом release, база не вытесняется и не закрывается. release вызывается один раз,
+// при ошибке возвращается release, который ничего не делает.
+func (s *MapConnectionsDB) Acquire(dbName string) (db *sql.DB, release func(), err error) {
+	entry, err := s.acquire(dbName, true)
+	if err != nil {
+		return nil, func() {}, err
+	}
+	var once sync.Once
+	return entry.db, func() { once.Do(func() { s.release(entry) }) }, nil
+}
+
+// acquire возвращает запись базы dbName, при lease - с арендой.
+func (s *MapConnectionsDB) acquire(dbName string, lease bool) (*poolEntry, error) {
+	// Базы приостановленных, удалённых и незарегистрированных компаний недоступны,
+	// в том числе через уже открытые соединения
+	if err := s.checkTenant(dbName); err != nil {
+		return nil, err
+	}
+
+	for {
+		if entry, ok := s.cached(dbName, lease); ok {
+			s.hits.Add(1)
+			return entry, nil
 		}
+		entry, err := s.open(dbName, lease)
+		if err != nil || entry != nil {
+			return entry, err
+		}
+		// База, открытая параллельным вызовом, уже удалена из пула: открываем заново
+	}
+}
 
-		// Соединение не активно, закрываем и удаляем из карты
-		delete(s.MapDB, dbName)
-		_ = db.Close() // Игнорируем ошибки закрытия
+// useLocked отмечает использование базы и, при lease, выдаёт аренду.
+func (s *MapConnectionsDB) useLocked(entry *poolEntry, lease bool) {
+	entry.lastUsed = time.Now()
+	s.lru.MoveToFront(entry.elem)
+	if lease {
+		entry.leases++
+	}
+}
+
+// release освобождает аренду и закрывает удалённую из пула базу, если аренд больше нет.
+func (s *MapConnectionsDB) release(entry *poolEntry) {
+	s.mu.Lock()
+	entry.leases--
+	closeNow := entry.retired && entry.leases == 0
+	s.mu.Unlock()
+
+	if closeNow {
+		_ = entry.db.Close() // Игнорируем ошибки закрытия
+	}
+}
+
+// cached возвращает открытую базу dbName и отмечает её использование.
+func (s *MapConnectionsDB) cached(dbName string, lease bool) (*poolEntry, bool) {
+	s.mu.Lock()
+	entry, ok := s.dbs[dbName]
+	if !ok {
+		s.mu.Unlock()
+		return nil, false
+	}
+	s.useLocked(entry, lease)
+	needCheck := entry.lastUsed.Sub(entry.checkedAt) > poolHealthCheckInterval
+	s.mu.Unlock()
+
+	if !needCheck {
+		return entry, true
 	}
 
-	dsn := DsnString(dbName)
-	// Если соединения нет или оно было закрыто, создаем новое
-	db, err := sql.Open("postgres", dsn)
+	// Проверяем, активно ли соединение
+	if err := entry.db.Ping(); err != nil {
+		// Соединение не активно, удаляем из пула. Закрывается оно, когда не останется аренд
+		s.mu.Lock()
+		closeNow := s.dbs[dbName] == entry && s.retireLocked(entry)
+		s.mu.Unlock()
+		if closeNow {
+			_ = entry.db.Close() // Игнорируем ошибки закрытия
+		}
+		if lease {
+			s.release(entry)
+		}
+		return nil, false
+	}
+
+	s.mu.Lock()
+	entry.checkedAt = time.Now()
+	s.mu.Unlock()
+	return entry, true
+}
+
+// open открывает базу dbName. Параллельные вызовы для одной базы ожидают первое открытие.
+// Возвращает nil без ошибки, если открытая параллельным вызовом база уже удалена из пула.
+func (s *MapConnectionsDB) open(dbName string, lease bool) (*poolEntry, error) {
+	s.mu.Lock()
+	if call, ok := s.inflight[dbName]; ok {
+		s.mu.Unlock()
+		<-call.done
+		if call.err != nil {
+			return nil, call.err
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.dbs[dbName] != call.entry {
+			return nil, nil
+		}
+		s.useLocked(call.entry, lease)
+		s.hits.Add(1)
+		return call.entry, nil
+	}
+	// Пока база открывалась, её мог добавить другой вызов
+	if entry, ok := s.dbs[dbName]; ok {
+		s.useLocked(entry, lease)
+		s.mu.Unlock()
+		s.hits.Add(1)
+		return entry, nil
+	}
+
+	call := &poolCall{done: make(chan struct{})}
+	s.inflight[dbName] = call
+	evicted, err := s.makeRoomLocked()
 	if err != nil {
+		delete(s.inflight, dbName)
+		s.mu.Unlock()
+		call.err = err
+		close(call.done)
 		return nil, err
 	}
+	s.mu.Unlock()
+
+	s.misses.Add(1)
+	closeDatabases(evicted)
+
+	db, err := s.config.Open(dbName)
+	if err == nil {
+		// Настройка пула соединений базы
+		db.SetMaxOpenConns(s.config.MaxOpenConnsPerDb)
+		db.SetMaxIdleConns(s.config.MaxIdleConnsPerDb)
+		db.SetConnMaxLifetime(time.Hour)
+		db.SetConnMaxIdleTime(time.Minute * 5)
+	}
+
+	s.mu.Lock()
+	delete(s.inflight, dbName)
+	if err == nil {
+		call.entry = s.insertLocked(dbName, db)
+		if lease {
+			call.entry.leases++
+		}
+	}
+	s.mu.Unlock()
+
+	call.err = err
+	close(call.done)
+	return call.entry, err
+}
+
+// makeRoomLocked вытесняет давно не использовавшиеся базы, пока открытые и открываемые базы
+// не помещаются в предел. Возвращает вытесненные базы, которые нужно закрыть после снятия блокировки.
+func (s *MapConnectionsDB) makeRoomLocked() ([]*sql.DB, error) {
+	var evicted []*sql.DB
+	now := time.Now()
+	for len(s.dbs)+len(s.inflight) > s.maxDatabases {
+		victim := s.evictionCandidateLocked(now)
+		if victim == nil {
+			// Вытеснять нечего: возвращаем уже вытесненные базы вызывающему коду для закрытия
+			closeDatabases(evicted)
+			return nil, ErrPoolExhausted
+		}
+		s.retireLocked(victim)
+		s.evictions.Add(1)
+		evicted = append(evicted, victim.db)
+	}
+	return evicted, nil
+}
 
-	// Настройка пула соединений
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(10)
-	db.SetConnMaxLifetime(time.Hour)
-	db.SetConnMaxIdleTime(time.Minute * 30)
+// evictionCandidateLocked возвращает наиболее давно использованную базу, которую можно закрыть.
+func (s *MapConnectionsDB) evictionCandidateLocked(now time.Time) *poolEntry {
+	for elem := s.lru.Back(); elem != nil; elem = elem.Prev() {
+		entry := elem.Value.(*poolEntry)
+		if s.evictable(entry, now, s.config.EvictionGrace) {
+			return entry
+		}
+	}
+	return nil
+}
 
-	s.MapDB[dbName] = db // Сохраняем новое соединение в карту
-	return db, nil
+// evictable сообщает, что базу можно закрыть: это не база авторизации, на неё нет аренд,
+// она не использовалась дольше idle и в ней нет выполняемых запросов.
+func (s *MapConnectionsDB) evictable(entry *poolEntry, now time.Time, idle time.Duration) bool {
+	if entry.name == os.Getenv("DB_AUTH_NAME") || entry.leases > 0 {
+		return false
+	}
+	return now.Sub(entry.lastUsed) >= idle && entry.db.Stats().InUse == 0
 }
 
-// CloseAllDatabases закрывает все открытые базы данных, хранящиеся в mapDB.
+// Add добавляет в пул уже открытое соединение с базой dbName, заменяя существующее.
+func (s *MapConnectionsDB) Add(dbName string, db *sql.DB) {
+	s.mu.Lock()
+	var replaced *sql.DB
+	if entry, ok := s.dbs[dbName]; ok {
+		if entry.db == db {
+			s.mu.Unlock()
+			return
+		}
+		if s.retireLocked(entry) {
+			replaced = entry.db
+		}
+	}
+	s.insertLocked(dbName, db)
+	s.mu.Unlock()
+
+	if replaced != nil {
+		_ = replaced.Close()
+	}
+}
+
+func (s *MapConnectionsDB) insertLocked(dbName string, db *sql.DB) *poolEntry {
+	now := time.Now()
+	entry := &poolEntry{name: dbName, db: db, lastUsed: now, checkedAt: now}
+	entry.elem = s.lru.PushFront(entry)
+	s.dbs[dbName] = entry
+	return entry
+}
+
+// retireLocked удаляет запись из пула. Возвращает true, если аренд нет и базу нужно закрыть сейчас,
+// иначе её закроет release последней аренды.
+func (s *MapConnectionsDB) retireLocked(entry *poolEntry) bool {
+	delete(s.dbs, entry.name)
+	s.lru.Remove(entry.elem)
+	entry.retired = true
+	return entry.leases == 0
+}
+
+// Remove удаляет соединение с базой dbName из пула и закрывает его, например перед удалением базы.
+// Если на базу есть аренды, соединение закрывается после освобождения последней из них.
+func (s *MapConnectionsDB) Remove(dbName string) {
+	s.mu.Lock()
+	entry, ok := s.dbs[dbName]
+	closeNow := ok && s.retireLocked(entry)
+	s.mu.Unlock()
+
+	if closeNow {
+		_ = entry.db.Close()
+	}
+}
+
+// EvictIdle закрывает базы, не использовавшиеся дольше IdleTimeout. Возвращает число закрытых баз.
+func (s *MapConnectionsDB) EvictIdle() int {
+	if s.config.IdleTimeout <= 0 {
+		return 0
+	}
+
+	s.mu.Lock()
+	var evicted []*sql.DB
+	now := time.Now()
+	for elem := s.lru.Back(); elem != nil; {
+		entry := elem.Value.(*poolEntry)
+		elem = elem.Prev()
+		if s.evictable(entry, now, s.config.IdleTimeout) {
+			s.retireLocked(entry)
+			evicted = append(evicted, entry.db)
+		}
+	}
+	s.mu.Unlock()
+
+	s.evictions.Add(int64(len(evicted)))
+	closeDatabases(evicted)
+	return len(evicted)
+}
+
+// StartIdleEviction периодически закрывает простаивающие базы и пишет метрики пула в лог.
+// Возвращает функцию остановки.
+func (s *MapConnectionsDB) StartIdleEviction(interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if closed := s.EvictIdle(); closed > 0 {
+					stats := s.Stats()
+					log.Printf("Закрыто простаивающих баз данных: %d, открыто баз: %d/%d, соединений: %d, попаданий: %d, промахов: %d, вытеснений: %d",
+						closed, stats.Databases, stats.MaxDatabases, stats.OpenConnections, stats.Hits, stats.Misses, stats.Evictions)
+				}
+			}
+		}
+	}()
+	return cancel
+}
+
+// Stats возвращает метрики пула соединений.
+func (s *MapConnectionsDB) Stats() PoolStats {
+	stats := PoolStats{
+		Hits:         s.hits.Load(),
+		Misses:       s.misses.Load(),
+		Evictions:    s.evictions.Load(),
+		MaxDatabases: s.maxDatabases,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats.Databases = len(s.dbs)
+	for _, entry := range s.dbs {
+		dbStats := entry.db.Stats()
+		stats.OpenConnections += dbStats.OpenConnections
+		stats.InUse += dbStats.InUse
+	}
+	return stats
+}
+
+// Tenants возвращает реестр компаний, по которому проверяются соединения с базами компаний.
+func (s *MapConnectionsDB) Tenants() *TenantResolver {
+	return s.tenants
+}
+
+// checkTenant проверяет, что база данных dbName принадлежит активной компании.
+func (s *MapConnectionsDB) checkTenant(dbName string) error {
+	if dbName == os.Getenv("DB_AUTH_NAME") {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := s.tenants.RequireActive(ctx, dbName); err != nil {
+		return fmt.Errorf("соединение с базой данных %s запрещено: %w", dbName, err)
+	}
+	return nil
+}
+
+// CloseAllDatabases закрывает все открытые базы данных пула. Базы с арендами
+// закрываются после освобождения последней аренды.
 func (s *MapConnectionsDB) CloseAllDatabases() error {
-	// Проходим по каждой базе данных в карте mapDB.
-	for name, db := range s.MapDB {
+	s.mu.Lock()
+	entries := make(map[string]*poolEntry, len(s.dbs))
+	for name, entry := range s.dbs {
+		if s.retireLocked(entry) {
+			entries[name] = entry
+		}
+	}
+	s.mu.Unlock()
+
+	var errs []error
+	// Проходим по каждой базе данных пула.
+	for name, entry := range entries {
 		// Закрываем соединение с текущей базой данных.
-		if err := db.Close(); err != nil {
-			// Если произошла ошибка при закрытии, возвращаем ошибку с именем базы данных и текстом ошибки.
-			return fmt.Errorf("Ошибка закрытия базы данных %s: %v", name, err)
+		if err := entry.db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("Ошибка закрытия базы данных %s: %v", name, err))
 		}
 	}
 	// Если все базы данных успешно закрыты, возвращаем nil.
-	return nil
+	return errors.Join(errs...)
+}
+
+// closeDatabases закрывает вытесненные базы. Close дожидается завершения начатых запросов.
+func closeDatabases(dbs []*sql.DB) {
+	for _, db := range dbs {
+		_ = db.Close() // Игнорируем ошибки закрытия
+	}
+}
+
+// openPostgres открывает соединение с базой dbName сервера postgres.
+func openPostgres(dbName string) (*sql.DB, error) {
+	return sql.Open("postgres", DsnString(dbName))
+}
+
+// envInt возвращает положительное целое из переменной окружения name или def.
+func envInt(name string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return def
+	}
+	return value
+}
+
+// envDuration возвращает длительность (например, 10m) из переменной окружения name или def.
+func envDuration(name string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return def
+	}
+	return value
 }
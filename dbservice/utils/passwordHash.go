@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Параметры Argon2id для текущей версии формата хэша.
+// При изменении параметров старые хэши продолжают проверяться по параметрам,
+// записанным в самой строке, и помечаются как требующие перехэширования.
+const (
+	argon2Memory  uint32 = 64 * 1024 // Объём памяти в KiB
+	argon2Time    uint32 = 3         // Количество итераций
+	argon2Threads uint8  = 2         // Степень параллелизма
+	argon2SaltLen        = 16        // Длина соли в байтах
+	argon2KeyLen  uint32 = 32        // Длина итогового ключа в байтах
+
+	argon2Prefix = "$argon2id$"
+)
+
+// ErrInvalidPasswordHash возвращается, если строка хэша повреждена или имеет неизвестный формат.
+var ErrInvalidPasswordHash = errors.New("некорректный формат хэша пароля")
+
+// HashPassword хэширует пароль алгоритмом Argon2id со случайной солью.
+//
+// Результат записывается в версионированном формате PHC:
+// $argon2id$v=19$m=65536,t=3,p=2$<соль base64>$<хэш base64>
+// что позволяет в дальнейшем менять параметры без потери совместимости.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать соль: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2Prefix,
+		argon2.Version,
+		argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// IsPasswordHash проверяет, является ли сохранённое значение хэшем известного формата
+// (Argon2id или bcrypt), а не паролем в открытом виде.
+func IsPasswordHash(stored string) bool {
+	return strings.HasPrefix(stored, argon2Prefix) || isBcryptHash(stored)
+}
+
+// VerifyPassword сравнивает пароль с сохранённым значением.
+//
+// Поддерживаемые форматы:
+// - Argon2id в формате PHC (текущая версия);
+// - bcrypt ($2a$, $2b$, $2y$) — проверяется, но требует перехэширования;
+// - открытый текст (записи, созданные до введения хэширования) — требует перехэширования.
+//
+// Возвращает:
+// - match: совпал ли пароль;
+// - needsRehash: нужно ли перезаписать значение хэшем текущей версии (имеет смысл только при match);
+// - err: ошибка разбора сохранённого хэша.
+func VerifyPassword(stored, password string) (match bool, needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(stored, argon2Prefix):
+		return verifyArgon2id(stored, password)
+	case isBcryptHash(stored):
+		err = bcrypt.CompareHashAndPassword([]byte(stored), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		return true, true, nil
+	default:
+		// Пароль хранится в открытом виде: сравниваем за постоянное время
+		match = subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+		return match, match, nil
+	}
+}
+
+// verifyArgon2id разбирает строку формата PHC и проверяет пароль по записанным в ней параметрам.
+func verifyArgon2id(stored, password string) (bool, bool, error) {
+	// Ожидаем: "", "argon2id", "v=19", "m=...,t=...,p=...", соль, хэш
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 {
+		return false, false, ErrInvalidPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return false, false, ErrInvalidPasswordHash
+	}
+	if version != argon2.Version {
+		return false, false, fmt.Errorf("%w: неподдерживаемая версия argon2 %d", ErrInvalidPasswordHash, version)
+	}
+
+	var memory, iterations uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads); err != nil {
+		return false, false, ErrInvalidPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, ErrInvalidPasswordHash
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false, ErrInvalidPasswordHash
+	}
+
+	key := argon2.IDKey([]byte(password), salt, iterations, memory, threads, uint32(len(expected)))
+	if subtle.ConstantTimeCompare(key, expected) != 1 {
+		return false, false, nil
+	}
+
+	// Хэш устарел, если он создан с параметрами, отличными от текущих
+	outdated := memory != argon2Memory || iterations != argon2Time || threads != argon2Threads ||
+		len(salt) != argon2SaltLen || uint32(len(expected)) != argon2KeyLen
+
+	return true, outdated, nil
+}
+
+// isBcryptHash определяет хэш bcrypt по его префиксу.
+func isBcryptHash(stored string) bool {
+	return len(stored) == 60 &&
+		(strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$"))
+}
+
+// GenerateTempPassword создаёт случайный временный пароль для новых пользователей.
+func GenerateTempPassword() (string, error) {
+	buf := make([]byte, 12)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
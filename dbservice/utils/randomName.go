@@ -8,6 +8,9 @@ import (
 
 const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 
+// TenantDbNameLength длина имени базы данных компании.
+const TenantDbNameLength = 25
+
 func RandomDBName(numberSymbols int) string {
 	// Создаем новый генератор случайных чисел с seed на основе текущего времени
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
@@ -18,3 +21,17 @@ func RandomDBName(numberSymbols int) string {
 	}
 	return sb.String()
 }
+
+// LooksLikeTenantDb проверяет, что имя базы данных совпадает с форматом имён баз компаний:
+// TenantDbNameLength латинских букв.
+func LooksLikeTenantDb(dbName string) bool {
+	if len(dbName) != TenantDbNameLength {
+		return false
+	}
+	for i := 0; i < len(dbName); i++ {
+		if strings.IndexByte(letterBytes, dbName[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
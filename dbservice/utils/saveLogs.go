@@ -24,3 +24,17 @@ func SaveLogsError(ctx context.Context, clientLogs logs.LogsServiceClient, datab
 
 	return nil
 }
+
+// SaveLogsWarning сохраняет предупреждение (например о подозрительном запросе) в сервис логов.
+func SaveLogsWarning(ctx context.Context, clientLogs logs.LogsServiceClient, database string, userId string, message string) error {
+	logRequest := &logs.LogRequest{
+		Name:     "dbservice",
+		Level:    "warning",
+		Message:  message,
+		Database: database,
+		UserID:   userId,
+	}
+
+	_, err := clientLogs.SaveLogs(ctx, logRequest)
+	return err
+}
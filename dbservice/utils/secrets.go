@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrSecretsKeyNotConfigured возвращается, если не задан ключ шифрования секретов интеграций.
+var ErrSecretsKeyNotConfigured = errors.New("не задан ключ шифрования SECRETS_ENCRYPTION_KEY")
+
+// EncryptSecret шифрует секрет интеграции (например, секрет клиента OIDC провайдера)
+// ключом SECRETS_ENCRYPTION_KEY (AES-256-GCM). Ключ не связан с MFA_ENCRYPTION_KEY,
+// поэтому их можно заменять независимо.
+func EncryptSecret(secret string) (string, error) {
+	aead, err := envCipher("SECRETS_ENCRYPTION_KEY", ErrSecretsKeyNotConfigured)
+	if err != nil {
+		return "", err
+	}
+	return seal(aead, secret)
+}
+
+// DecryptSecret расшифровывает секрет, сохранённый EncryptSecret.
+func DecryptSecret(encrypted string) (string, error) {
+	aead, err := envCipher("SECRETS_ENCRYPTION_KEY", ErrSecretsKeyNotConfigured)
+	if err != nil {
+		return "", err
+	}
+	return open(aead, encrypted)
+}
+
+// envCipher создаёт AES-GCM из ключа в переменной окружения name (32 байта в base64).
+// Если переменная не задана, возвращается errNotConfigured.
+func envCipher(name string, errNotConfigured error) (cipher.AEAD, error) {
+	encoded := os.Getenv(name)
+	if encoded == "" {
+		return nil, errNotConfigured
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("ключ %s должен содержать 32 байта в base64", name)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal шифрует значение со случайным nonce и возвращает nonce и шифртекст в base64.
+func seal(aead cipher.AEAD, value string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("не удалось сгенерировать nonce: %w", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(value), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open расшифровывает значение, сохранённое seal.
+func open(aead cipher.AEAD, encrypted string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("повреждён зашифрованный секрет")
+	}
+
+	value, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("не удалось расшифровать секрет: %w", err)
+	}
+	return string(value), nil
+}
@@ -0,0 +1,232 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Состояния компании (арендатора) в реестре companies базы данных авторизации.
+const (
+	TenantStatusActive    = "active"    // Компания работает
+	TenantStatusSuspended = "suspended" // Вход и запросы к базе компании временно заблокированы
+	TenantStatusDeleted   = "deleted"   // Компания удалена, база данных будет удалена
+)
+
+// Состояния создания базы данных компании.
+const (
+	ProvisioningPending = "pending" // База создаётся после регистрации
+	ProvisioningReady   = "ready"   // База готова
+	ProvisioningFailed  = "failed"  // Все попытки создания базы неудачны
+)
+
+// tenantCacheTTL как долго данные компании берутся из памяти без запроса к базе авторизации.
+// Изменение статуса компании в другом экземпляре dbservice вступает в силу не позже этого времени.
+const tenantCacheTTL = 30 * time.Second
+
+var (
+	// ErrTenantNotFound возвращается, если база данных или компания отсутствует в реестре.
+	ErrTenantNotFound = errors.New("компания не найдена в реестре")
+
+	// ErrTenantSuspended возвращается для приостановленной компании.
+	ErrTenantSuspended = errors.New("работа компании приостановлена, обратитесь к администратору CRM")
+
+	// ErrTenantDeleted возвращается для удалённой компании.
+	ErrTenantDeleted = errors.New("компания удалена")
+
+	// ErrTenantProvisioning возвращается, пока база данных компании создаётся.
+	ErrTenantProvisioning = errors.New("рабочее пространство компании ещё создаётся, повторите попытку через минуту")
+
+	// ErrTenantProvisioningFailed возвращается, если база данных компании не была создана.
+	ErrTenantProvisioningFailed = errors.New("не удалось создать рабочее пространство компании, обратитесь в поддержку CRM")
+)
+
+// Tenant запись реестра компаний.
+type Tenant struct {
+	CompanyId string
+	Name      string
+	DbName    string // База данных компании
+	Status    string // TenantStatusActive, TenantStatusSuspended или TenantStatusDeleted
+	Plan      string // Тарифный план
+	CreatedAt time.Time
+
+	Provisioning string // ProvisioningPending, ProvisioningReady или ProvisioningFailed
+}
+
+// CheckActive возвращает ErrTenantSuspended или ErrTenantDeleted, если компания не активна,
+// и ErrTenantProvisioning или ErrTenantProvisioningFailed, если её база данных не готова.
+func (t *Tenant) CheckActive() error {
+	switch t.Status {
+	case TenantStatusActive:
+		switch t.Provisioning {
+		case ProvisioningPending:
+			return ErrTenantProvisioning
+		case ProvisioningFailed:
+			return ErrTenantProvisioningFailed
+		}
+		return nil
+	case TenantStatusSuspended:
+		return ErrTenantSuspended
+	case TenantStatusDeleted:
+		return ErrTenantDeleted
+	default:
+		return fmt.Errorf("неизвестный статус компании %q", t.Status)
+	}
+}
+
+// IsTenantError сообщает, что ошибка вызвана состоянием компании, а не сбоем.
+func IsTenantError(err error) bool {
+	return errors.Is(err, ErrTenantNotFound) || errors.Is(err, ErrTenantSuspended) || errors.Is(err, ErrTenantDeleted) ||
+		errors.Is(err, ErrTenantProvisioning) || errors.Is(err, ErrTenantProvisioningFailed)
+}
+
+// TenantStatusError приводит ошибку состояния компании к ошибке gRPC:
+// приостановленная компания получает FailedPrecondition с понятным сообщением,
+// удалённая и отсутствующая в реестре - NotFound, компания с ещё создаваемой базой - Unavailable.
+func TenantStatusError(err error) error {
+	switch {
+	case errors.Is(err, ErrTenantSuspended):
+		return status.Errorf(codes.FailedPrecondition, "%v", ErrTenantSuspended)
+	case errors.Is(err, ErrTenantProvisioningFailed):
+		return status.Errorf(codes.FailedPrecondition, "%v", ErrTenantProvisioningFailed)
+	case errors.Is(err, ErrTenantProvisioning):
+		return status.Errorf(codes.Unavailable, "%v", ErrTenantProvisioning)
+	case errors.Is(err, ErrTenantDeleted), errors.Is(err, ErrTenantNotFound):
+		return status.Errorf(codes.NotFound, "%v", err)
+	default:
+		return status.Errorf(codes.Internal, "ошибка проверки компании: %v", err)
+	}
+}
+
+type cachedTenant struct {
+	tenant    Tenant
+	expiresAt time.Time
+}
+
+// TenantResolver находит компании в реестре по имени базы данных или ID компании.
+//
+// Найденные записи кэшируются на tenantCacheTTL, поэтому проверка статуса компании
+// в каждом запросе не обращается к базе авторизации. Отсутствующие записи не кэшируются:
+// только что зарегистрированная компания доступна сразу.
+type TenantResolver struct {
+	authDb func() (*sql.DB, error) // Соединение с базой данных авторизации
+
+	mu        sync.RWMutex
+	byDbName  map[string]cachedTenant
+	byCompany map[string]cachedTenant
+	now       func() time.Time
+}
+
+// NewTenantResolver создаёт резолвер, читающий реестр через соединение authDb.
+func NewTenantResolver(authDb func() (*sql.DB, error)) *TenantResolver {
+	return &TenantResolver{
+		authDb:    authDb,
+		byDbName:  make(map[string]cachedTenant),
+		byCompany: make(map[string]cachedTenant),
+		now:       time.Now,
+	}
+}
+
+// ByDbName возвращает компанию, которой принадлежит база данных dbName.
+func (r *TenantResolver) ByDbName(ctx context.Context, dbName string) (*Tenant, error) {
+	if tenant, ok := r.cached(r.byDbName, dbName); ok {
+		return tenant, nil
+	}
+	return r.load(ctx, "dbName = $1", dbName)
+}
+
+// ByCompanyId возвращает компанию по её ID в базе данных авторизации.
+func (r *TenantResolver) ByCompanyId(ctx context.Context, companyId string) (*Tenant, error) {
+	if tenant, ok := r.cached(r.byCompany, companyId); ok {
+		return tenant, nil
+	}
+	return r.load(ctx, "id = $1", companyId)
+}
+
+// RequireActive возвращает компанию базы данных dbName, если она активна.
+func (r *TenantResolver) RequireActive(ctx context.Context, dbName string) (*Tenant, error) {
+	tenant, err := r.ByDbName(ctx, dbName)
+	if err != nil {
+		return nil, err
+	}
+	if err := tenant.CheckActive(); err != nil {
+		return nil, err
+	}
+	return tenant, nil
+}
+
+// Invalidate удаляет компанию из кэша после изменения её записи в реестре.
+func (r *TenantResolver) Invalidate(companyId string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if entry, ok := r.byCompany[companyId]; ok {
+		delete(r.byDbName, entry.tenant.DbName)
+	}
+	delete(r.byCompany, companyId)
+}
+
+func (r *TenantResolver) cached(cache map[string]cachedTenant, key string) (*Tenant, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	entry, ok := cache[key]
+	if !ok || r.now().After(entry.expiresAt) {
+		return nil, false
+	}
+	tenant := entry.tenant
+	return &tenant, true
+}
+
+func (r *TenantResolver) load(ctx context.Context, condition string, value string) (*Tenant, error) {
+	db, err := r.authDb()
+	if err != nil {
+		return nil, fmt.Errorf("ошибка подключения к базе данных авторизации: %w", err)
+	}
+
+	var tenant Tenant
+	err = db.QueryRowContext(ctx, "SELECT id, name, dbName, status, plan, createdAt, provisioning_status FROM companies WHERE "+condition, value).
+		Scan(&tenant.CompanyId, &tenant.Name, &tenant.DbName, &tenant.Status, &tenant.Plan, &tenant.CreatedAt, &tenant.Provisioning)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения компании из реестра: %w", err)
+	}
+
+	entry := cachedTenant{tenant: tenant, expiresAt: r.now().Add(tenantCacheTTL)}
+	r.mu.Lock()
+	r.byDbName[tenant.DbName] = entry
+	r.byCompany[tenant.CompanyId] = entry
+	r.mu.Unlock()
+
+	return &tenant, nil
+}
+
+// NewTenantInterceptor создаёт gRPC interceptor, который пропускает запросы пользователей
+// только к активным компаниям. Вызывается после IdentityInterceptor: база данных и компания
+// берутся из проверенного токена и должны совпадать с записью реестра.
+// Запросы внутренних сервисов (без данных пользователя) не проверяются.
+func NewTenantInterceptor(resolver *TenantResolver) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		identity, err := IdentityFromContext(ctx)
+		if err != nil {
+			return handler(ctx, req)
+		}
+
+		tenant, err := resolver.RequireActive(ctx, identity.Database)
+		if err != nil {
+			return nil, TenantStatusError(err)
+		}
+		if identity.CompanyId != "" && identity.CompanyId != tenant.CompanyId {
+			return nil, DenyCrossTenant(ctx, identity, fmt.Sprintf("база данных %s принадлежит другой компании", identity.Database))
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -1,9 +1,11 @@
 package utils
 
 import (
+	"crmSystem/jwks"
+	"crypto/rsa"
 	"encoding/pem"
 	"fmt"
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/youmark/pkcs8"
 	"io/ioutil"
 	"os"
@@ -44,8 +46,11 @@ func JwtGenerate() (string, error) {
 		return "", fmt.Errorf("Ошибка расшифровки ключа: %v", err)
 	}
 
-	// Генерация JWT
+	// Генерация JWT, kid позволяет сервисам выбрать ключ проверки во время ротации
 	token := jwt.New(jwt.SigningMethodRS256)
+	if rsaKey, ok := privKey.(*rsa.PrivateKey); ok {
+		token.Header["kid"] = jwks.Thumbprint(&rsaKey.PublicKey)
+	}
 
 	// Установка данных в токен
 	claims := token.Claims.(jwt.MapClaims)
@@ -95,8 +100,11 @@ func InternalJwtGenerator() (string, error) {
 		return "", fmt.Errorf("Ошибка расшифровки ключа: %v", err)
 	}
 
-	// Генерация JWT
+	// Генерация JWT, kid позволяет сервисам выбрать ключ проверки во время ротации
 	token := jwt.New(jwt.SigningMethodRS256)
+	if rsaKey, ok := privKey.(*rsa.PrivateKey); ok {
+		token.Header["kid"] = jwks.Thumbprint(&rsaKey.PublicKey)
+	}
 
 	// Установка данных в токен
 	claims := token.Claims.(jwt.MapClaims)
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/hotp"
+	"github.com/pquerna/otp/totp"
+)
+
+// Параметры TOTP (RFC 6238), совместимые с Google Authenticator и аналогами.
+const (
+	TotpIssuer    = "CRM System"
+	TotpPeriod    = 30 // Длительность шага в секундах
+	totpDigits    = otp.DigitsSix
+	totpAlgorithm = otp.AlgorithmSHA1
+	totpSkew      = 1 // Допустимое расхождение часов в шагах в каждую сторону
+
+	RecoveryCodesCount = 10 // Количество кодов восстановления, выдаваемых при подключении TOTP
+)
+
+// ErrMfaKeyNotConfigured возвращается, если не задан ключ шифрования секретов TOTP.
+var ErrMfaKeyNotConfigured = errors.New("не задан ключ шифрования MFA_ENCRYPTION_KEY")
+
+// GenerateTotpSecret создаёт новый секрет TOTP для учётной записи account
+// и возвращает его вместе с URI otpauth:// для QR-кода приложения-аутентификатора.
+func GenerateTotpSecret(account string) (secret string, provisioningURI string, err error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      TotpIssuer,
+		AccountName: account,
+		Period:      TotpPeriod,
+		Digits:      totpDigits,
+		Algorithm:   totpAlgorithm,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("не удалось сгенерировать секрет TOTP: %w", err)
+	}
+	return key.Secret(), key.URL(), nil
+}
+
+// ValidateTotpCode проверяет код TOTP на момент now с учётом расхождения часов.
+//
+// Возвращает номер принятого временного шага. Код принимается, только если его шаг
+// больше lastStep, поэтому один и тот же код нельзя использовать повторно.
+func ValidateTotpCode(secret string, code string, lastStep int64, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	current := now.Unix() / TotpPeriod
+
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= lastStep {
+			continue
+		}
+		expected, err := hotp.GenerateCodeCustom(secret, uint64(step), hotp.ValidateOpts{
+			Digits:    totpDigits,
+			Algorithm: totpAlgorithm,
+		})
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes создаёт одноразовые коды восстановления и их хэши для сохранения в базе.
+// Коды показываются пользователю один раз, в базе хранятся только хэши.
+func GenerateRecoveryCodes(count int) (codes []string, hashes []string, err error) {
+	for i := 0; i < count; i++ {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("не удалось сгенерировать код восстановления: %w", err)
+		}
+		raw := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))
+		code := raw[:4] + "-" + raw[4:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode возвращает SHA-256 хэш кода восстановления.
+// Регистр, пробелы и дефисы при вводе не учитываются.
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// EncryptTotpSecret шифрует секрет TOTP ключом MFA_ENCRYPTION_KEY (AES-256-GCM).
+func EncryptTotpSecret(secret string) (string, error) {
+	aead, err := envCipher("MFA_ENCRYPTION_KEY", ErrMfaKeyNotConfigured)
+	if err != nil {
+		return "", err
+	}
+	return seal(aead, secret)
+}
+
+// DecryptTotpSecret расшифровывает секрет TOTP, сохранённый EncryptTotpSecret.
+func DecryptTotpSecret(encrypted string) (string, error) {
+	aead, err := envCipher("MFA_ENCRYPTION_KEY", ErrMfaKeyNotConfigured)
+	if err != nil {
+		return "", err
+	}
+	return open(aead, encrypted)
+}
@@ -3,6 +3,7 @@ module crmSystem
 go 1.23
 
 require (
+	crmSystem/lifecycle v0.0.0
 	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/joho/godotenv v1.5.1
 	github.com/rabbitmq/amqp091-go v1.10.0
@@ -18,3 +19,5 @@ require (
 	golang.org/x/text v0.19.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20241015192408-796eee8c2d53 // indirect
 )
+
+replace crmSystem/lifecycle => ../lifecycle
@@ -6,46 +6,76 @@ import (
 	pb "crmSystem/proto/email-service"
 	"crmSystem/proto/logs"
 	"crmSystem/utils"
+	"fmt"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"log"
 )
 
-// StartConsumer будет слушать очередь и обрабатывать сообщения
-func StartConsumer(ch *amqp.Channel, queueName string, emailService *service.EmailService, workerID int, clientLogs logs.LogsServiceClient) {
+// StartConsumer будет слушать очередь и обрабатывать сообщения до отмены ctx.
+// Сообщение подтверждается после обработки: при остановке сервиса полученные, но не обработанные
+// сообщения возвращаются в очередь при закрытии канала.
+func StartConsumer(ctx context.Context, ch *amqp.Channel, queueName string, emailService *service.EmailService, workerID int, clientLogs logs.LogsServiceClient) {
+	consumerTag := fmt.Sprintf("email-worker-%d", workerID)
 	msgs, err := ch.Consume(
-		queueName, // Имя очереди
-		"",        // Имя потребителя
-		true,      // Автоматическое подтверждение
-		false,     // Не эксклюзивное соединение
-		false,     // Не заблокированное соединение
-		false,     // Не ожидать сообщений
-		nil,       // Дополнительные параметры
+		queueName,   // Имя очереди
+		consumerTag, // Имя потребителя
+		false,       // Подтверждение после обработки сообщения
+		false,       // Не эксклюзивное соединение
+		false,       // Не заблокированное соединение
+		false,       // Не ожидать сообщений
+		nil,         // Дополнительные параметры
 	)
 	if err != nil {
 		log.Fatalf("Не удалось начать потребление сообщений: %v", err)
 	}
 
-	for msg := range msgs {
-		log.Printf("Worker %d: Получено сообщение: %s", workerID, string(msg.Body))
-
-		// Создаем контекст для gRPC запроса (можно добавить тайм-ауты или отмену)
-		ctx := context.Background()
+	for {
+		select {
+		case <-ctx.Done():
+			// Прекращаем получение новых сообщений, текущее сообщение уже обработано
+			if err := ch.Cancel(consumerTag, false); err != nil {
+				log.Printf("Worker %d: Ошибка отмены потребителя: %v", workerID, err)
+			}
+			log.Printf("Worker %d: остановлен", workerID)
+			return
 
-		// Отправляем email
-		resp, err := emailService.SendEmail(ctx, &pb.SendEmailRequest{
-			Email:   string(msg.Body), // Или другой параметр, в зависимости от структуры вашего сообщения
-			Message: "Тема письма",
-			Body:    "Текст письма",
-		})
+		case msg, ok := <-msgs:
+			if !ok {
+				log.Printf("Worker %d: канал RabbitMQ закрыт", workerID)
+				return
+			}
+			handleMessage(msg, emailService, workerID, clientLogs)
 
-		if err != nil {
-			errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
-			if errLogs != nil {
-				log.Printf("Ошибка при отправке email: %v", err)
+			// Письмо не отправляется повторно и при ошибке: ошибка сохраняется в логи
+			if err := msg.Ack(false); err != nil {
+				log.Printf("Worker %d: Ошибка подтверждения сообщения: %v", workerID, err)
 			}
-			log.Printf("Worker %d: Ошибка при отправке email: %v", workerID, err)
-		} else {
-			log.Printf("Worker %d: Email успешно отправлен: %v", workerID, resp.Message)
 		}
 	}
 }
+
+// handleMessage отправляет письмо из сообщения очереди. Отправка не прерывается при остановке
+// сервиса: на её завершение отводится DRAIN_TIMEOUT.
+func handleMessage(msg amqp.Delivery, emailService *service.EmailService, workerID int, clientLogs logs.LogsServiceClient) {
+	log.Printf("Worker %d: Получено сообщение: %s", workerID, string(msg.Body))
+
+	// Создаем контекст для gRPC запроса (можно добавить тайм-ауты или отмену)
+	ctx := context.Background()
+
+	// Отправляем email
+	resp, err := emailService.SendEmail(ctx, &pb.SendEmailRequest{
+		Email:   string(msg.Body), // Или другой параметр, в зависимости от структуры вашего сообщения
+		Message: "Тема письма",
+		Body:    "Текст письма",
+	})
+
+	if err != nil {
+		errLogs := utils.SaveLogsError(ctx, clientLogs, "", "", err.Error())
+		if errLogs != nil {
+			log.Printf("Ошибка при отправке email: %v", err)
+		}
+		log.Printf("Worker %d: Ошибка при отправке email: %v", workerID, err)
+	} else {
+		log.Printf("Worker %d: Email успешно отправлен: %v", workerID, resp.Message)
+	}
+}
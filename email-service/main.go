@@ -4,10 +4,12 @@ import (
 	"context"
 	"crmSystem/internal/handler"
 	"crmSystem/internal/service"
+	"crmSystem/lifecycle"
 	pb "crmSystem/proto/email-service"
 	"crmSystem/proto/logs"
 	"crmSystem/utils"
 	"errors"
+	"flag"
 	"github.com/joho/godotenv"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"google.golang.org/grpc"
@@ -18,6 +20,7 @@ import (
 	"log"
 	"net"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -50,6 +53,15 @@ func main() {
 		log.Fatalf("Error loading .env file: %v", err)
 	}
 
+	healthcheck := flag.Bool("healthcheck", false, "проверить состояние запущенного сервиса и завершиться (healthcheck docker-compose)")
+	flag.Parse()
+	if *healthcheck {
+		if err := lifecycle.ProbeHealth("localhost:"+os.Getenv("EMAIL_SERVICE_HTTP_PORT"), utils.ClientCACertFile, utils.ServerCertFile, utils.ServerKeyFile); err != nil {
+			log.Fatalf("Сервис не готов: %v", err)
+		}
+		return
+	}
+
 	// Подключение к RabbitMQ
 	// Загружаем URL RabbitMQ из переменных окружения
 	rabbitMQURL := os.Getenv("RABBITMQ_URL")
@@ -67,9 +79,9 @@ func main() {
 		log.Fatalf("Ошибка подключения к RabbitMQ: %v", err)
 	} else {
 		defer func(conn *amqp.Connection) {
-			err := conn.Close()
-			if err != nil {
-				log.Fatal(err)
+			// Соединение могло быть уже закрыто RabbitMQ
+			if err := conn.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
+				log.Printf("Ошибка закрытия соединения с RabbitMQ: %v", err)
 			}
 		}(conn)
 	}
@@ -80,9 +92,9 @@ func main() {
 		log.Fatalf("Не удалось открыть канал: %v", err)
 	}
 	defer func(ch *amqp.Channel) {
-		err := ch.Close()
-		if err != nil {
-			log.Fatal(err)
+		// Неподтверждённые сообщения возвращаются в очередь при закрытии канала
+		if err := ch.Close(); err != nil && !errors.Is(err, amqp.ErrClosed) {
+			log.Printf("Ошибка закрытия канала RabbitMQ: %v", err)
 		}
 	}(ch)
 
@@ -126,10 +138,24 @@ func main() {
 		}(connLogs)
 	}
 
+	// Контекст отменяется при получении SIGINT/SIGTERM
+	shutdownCtx, stop := lifecycle.ShutdownContext()
+	defer stop()
+
 	// Запуск нескольких воркеров (потребителей)
 	numWorkers := 5
+	// Каждый воркер получает не больше одного неподтверждённого сообщения: при остановке
+	// сервиса в обработке остаётся не больше numWorkers писем
+	if err := ch.Qos(1, 0, false); err != nil {
+		log.Fatalf("Не удалось настроить количество сообщений на воркера: %v", err)
+	}
+	var workers sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
-		go handler.StartConsumer(ch, q.Name, service.NewEmailService(), i, clientLogs)
+		workers.Add(1)
+		go func(workerID int) {
+			defer workers.Done()
+			handler.StartConsumer(shutdownCtx, ch, q.Name, service.NewEmailService(), workerID, clientLogs)
+		}(i)
 	}
 
 	// Настроим gRPC сервер для общения с другими микросервисами
@@ -162,8 +188,45 @@ func main() {
 	grpcServer := grpc.NewServer(opts...)
 	pb.RegisterEmailServiceServer(grpcServer, service.NewEmailService())
 
-	log.Printf("EmailService работает на порту %s", grpcPort)
-	if err := grpcServer.Serve(listener); err != nil {
+	// Состояние сервера для healthcheck docker-compose: задачи отправки писем приходят через RabbitMQ
+	stopHealth := lifecycle.StartHealthServer(grpcServer, 10*time.Second, lifecycle.HealthCheck{
+		Name: "rabbitmq",
+		Check: func(context.Context) error {
+			if conn.IsClosed() || ch.IsClosed() {
+				return errors.New("соединение с RabbitMQ закрыто")
+			}
+			return nil
+		},
+	})
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("EmailService работает на порту %s", grpcPort)
+		serveErr <- grpcServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
 		log.Fatalf("Ошибка при запуске gRPC: %v", err)
+	case <-shutdownCtx.Done():
+	}
+
+	// Healthcheck сразу сообщает NOT_SERVING, после чего сервер дожидается текущих запросов
+	// и воркеры дописывают полученные письма
+	log.Printf("Получен сигнал остановки, завершаем текущие запросы")
+	stopHealth()
+	drainTimeout := lifecycle.DrainTimeout()
+	lifecycle.StopGRPCServer(grpcServer, drainTimeout)
+
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+		log.Printf("Воркеры остановлены")
+	case <-time.After(drainTimeout):
+		log.Printf("Воркеры не завершили обработку за %s, неподтверждённые сообщения вернутся в очередь", drainTimeout)
 	}
 }
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"crypto/rsa"
 	"encoding/pem"
 	"fmt"
 	"github.com/golang-jwt/jwt/v5"
@@ -44,8 +45,11 @@ func InternalJwtGenerator() (string, error) {
 		return "", fmt.Errorf("Ошибка расшифровки ключа: %v", err)
 	}
 
-	// Генерация JWT
+	// Генерация JWT, kid позволяет сервисам выбрать ключ проверки во время ротации
 	token := jwt.New(jwt.SigningMethodRS256)
+	if rsaKey, ok := privKey.(*rsa.PrivateKey); ok {
+		token.Header["kid"] = JwkThumbprint(&rsaKey.PublicKey)
+	}
 
 	// Установка данных в токен
 	claims := token.Claims.(jwt.MapClaims)
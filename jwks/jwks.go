@@ -0,0 +1,241 @@
+// Package jwks публичные ключи проверки токенов, общие для auth и сервисов, которые проверяют его токены.
+// Ключ токена выбирается по kid (JWK Thumbprint, RFC 7638), поэтому во время ротации действуют
+// одновременно старый и новый ключи.
+package jwks
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// RefreshInterval как часто можно запрашивать набор ключей у auth сервиса при неизвестном kid.
+var RefreshInterval = 30 * time.Second
+
+// Jwk публичный RSA ключ в формате JSON Web Key.
+type Jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// Jwks набор публичных ключей, который auth сервис отдаёт на /.well-known/jwks.json.
+type Jwks struct {
+	Keys []Jwk `json:"keys"`
+}
+
+// KeySet действующие публичные ключи проверки токенов по их kid.
+// Если задан адрес JWKS auth сервиса, при токене с неизвестным kid набор запрашивается повторно,
+// поэтому новый ключ подхватывается без перезапуска, а выведенный из auth ключ перестаёт приниматься.
+type KeySet struct {
+	mu         sync.RWMutex
+	keys       map[string]*rsa.PublicKey
+	local      map[string]*rsa.PublicKey // Ключи из конфигурации сервиса, остаются при любом ответе auth
+	defaultKid string                    // Ключ для токенов без kid
+
+	jwksURL   string
+	client    *http.Client
+	refreshMu sync.Mutex // Один запрос к auth сервису за раз, проверку токенов не блокирует
+	fetchedAt time.Time
+}
+
+// NewKeySet создаёт набор ключей. Первый ключ используется для токенов без kid.
+// Если jwksURL не пустой, неизвестные kid ищутся в наборе ключей по этому адресу.
+func NewKeySet(jwksURL string, client *http.Client, keys ...*rsa.PublicKey) *KeySet {
+	ks := &KeySet{local: make(map[string]*rsa.PublicKey, len(keys)), jwksURL: jwksURL, client: client}
+	for _, key := range keys {
+		kid := Thumbprint(key)
+		if ks.defaultKid == "" {
+			ks.defaultKid = kid
+		}
+		ks.local[kid] = key
+	}
+	ks.keys = ks.local
+	return ks
+}
+
+// Keyfunc выбирает ключ проверки токена по заголовку kid.
+func (ks *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		return nil, fmt.Errorf("неверный метод подписи")
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		kid = ks.defaultKid
+	}
+	if key := ks.key(kid); key != nil {
+		return key, nil
+	}
+
+	if err := ks.refresh(); err != nil {
+		return nil, err
+	}
+	if key := ks.key(kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("неизвестный ключ подписи %q", kid)
+}
+
+func (ks *KeySet) key(kid string) *rsa.PublicKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.keys[kid]
+}
+
+// refresh запрашивает набор ключей у auth сервиса не чаще RefreshInterval.
+// Запрос выполняется без блокировки набора: токены с известным kid проверяются, пока он идёт,
+// а набор блокируется только для замены ключей. Полученный набор заменяет ранее полученные ключи,
+// поэтому удалённый из auth ключ больше не принимается; ключи из файлов остаются.
+func (ks *KeySet) refresh() error {
+	if ks.jwksURL == "" {
+		return nil
+	}
+
+	// Горутины с тем же неизвестным kid ждут текущий запрос и затем ищут ключ в обновлённом наборе
+	ks.refreshMu.Lock()
+	defer ks.refreshMu.Unlock()
+	if time.Since(ks.fetchedAt) < RefreshInterval {
+		return nil
+	}
+	ks.fetchedAt = time.Now()
+
+	keys, err := ks.fetch()
+	if err != nil {
+		return err
+	}
+
+	for kid, key := range ks.local {
+		keys[kid] = key
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys = keys
+	return nil
+}
+
+// fetch получает набор ключей auth сервиса. Ключ принимается, только если его kid совпадает с отпечатком.
+func (ks *KeySet) fetch() (map[string]*rsa.PublicKey, error) {
+	resp, err := ks.client.Get(ks.jwksURL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка получения ключей проверки токенов: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ошибка получения ключей проверки токенов: статус %d", resp.StatusCode)
+	}
+
+	var jwks Jwks
+	if err := json.NewDecoder(resp.Body).Decode(&jwks); err != nil {
+		return nil, fmt.Errorf("ошибка разбора ключей проверки токенов: %v", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		// kid вычисляется заново, чтобы ключ нельзя было подменить под чужим идентификатором
+		if Thumbprint(key) == jwk.Kid {
+			keys[jwk.Kid] = key
+		}
+	}
+	return keys, nil
+}
+
+// JWKS возвращает ключи набора в формате JSON Web Key Set, отсортированные по kid.
+func (ks *KeySet) JWKS() Jwks {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	jwks := Jwks{Keys: make([]Jwk, 0, len(ks.keys))}
+	for kid, key := range ks.keys {
+		jwks.Keys = append(jwks.Keys, Jwk{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+	return jwks
+}
+
+// Thumbprint вычисляет kid ключа как JWK Thumbprint (RFC 7638): SHA-256 от канонического JSON
+// с полями e, kty, n. Идентификатор не нужно задавать вручную, и он одинаков во всех сервисах.
+func Thumbprint(key *rsa.PublicKey) string {
+	canonical, _ := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		Kty: "RSA",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+	})
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// LoadKeyFiles загружает ключ defaultFile и ключи ротации (*.pem) из каталога dir.
+// Ключ defaultFile идёт первым, им проверяются токены без kid.
+func LoadKeyFiles(defaultFile string, dir string) ([]*rsa.PublicKey, error) {
+	defaultKey, err := LoadPublicKeyFile(defaultFile)
+	if err != nil {
+		return nil, err
+	}
+	keys := []*rsa.PublicKey{defaultKey}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска ключей ротации: %v", err)
+	}
+	for _, file := range files {
+		key, err := LoadPublicKeyFile(file)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// LoadPublicKeyFile загружает публичный RSA ключ из PEM файла.
+func LoadPublicKeyFile(file string) (*rsa.PublicKey, error) {
+	keyData, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения публичного ключа: %v", err)
+	}
+	if len(keyData) == 0 {
+		return nil, fmt.Errorf("файл публичного ключа %s пуст", file)
+	}
+
+	pubKey, err := jwt.ParseRSAPublicKeyFromPEM(keyData)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка парсинга публичного ключа %s: %v", file, err)
+	}
+	return pubKey, nil
+}
@@ -0,0 +1,215 @@
+package tests
+
+import (
+	"crmSystem/jwks"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sign signs a short-lived token with key, kid is omitted when empty.
+func sign(t *testing.T, key *rsa.PrivateKey, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"exp": time.Now().Add(time.Minute).Unix()})
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+// jwkOf returns key as a JWK published under kid.
+func jwkOf(kid string, key *rsa.PublicKey) jwks.Jwk {
+	return jwks.Jwk{
+		Kty: "RSA", Kid: kid, Use: "sig", Alg: "RS256",
+		N: base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// TestKeySetSelectsKeyByKid checks tokens signed by any published key are accepted during a rotation window.
+func TestKeySetSelectsKeyByKid(t *testing.T) {
+	currentKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	nextKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keys := jwks.NewKeySet("", nil, &currentKey.PublicKey, &nextKey.PublicKey)
+
+	_, err = jwt.Parse(sign(t, nextKey, jwks.Thumbprint(&nextKey.PublicKey)), keys.Keyfunc)
+	assert.NoError(t, err)
+
+	// Tokens issued before kid was added are checked with the default key
+	_, err = jwt.Parse(sign(t, currentKey, ""), keys.Keyfunc)
+	assert.NoError(t, err)
+	_, err = jwt.Parse(sign(t, nextKey, ""), keys.Keyfunc)
+	assert.Error(t, err)
+
+	// A kid can not redirect the check to another published key
+	_, err = jwt.Parse(sign(t, nextKey, jwks.Thumbprint(&currentKey.PublicKey)), keys.Keyfunc)
+	assert.Error(t, err)
+
+	unknownKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, err = jwt.Parse(sign(t, unknownKey, jwks.Thumbprint(&unknownKey.PublicKey)), keys.Keyfunc)
+	assert.Error(t, err)
+}
+
+// TestKeySetFetchesRotatedKey checks a new key is picked up from the auth JWKS without a restart.
+func TestKeySetFetchesRotatedKey(t *testing.T) {
+	currentKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	nextKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	forgedKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_ = json.NewEncoder(w).Encode(jwks.Jwks{Keys: []jwks.Jwk{
+			jwkOf(jwks.Thumbprint(&nextKey.PublicKey), &nextKey.PublicKey),
+			// A key published under another key's kid is not accepted
+			jwkOf(jwks.Thumbprint(&currentKey.PublicKey), &forgedKey.PublicKey),
+		}})
+	}))
+	defer server.Close()
+
+	keys := jwks.NewKeySet(server.URL, server.Client(), &currentKey.PublicKey)
+
+	_, err = jwt.Parse(sign(t, currentKey, jwks.Thumbprint(&currentKey.PublicKey)), keys.Keyfunc)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, requests, "a known key needs no request to auth")
+
+	_, err = jwt.Parse(sign(t, nextKey, jwks.Thumbprint(&nextKey.PublicKey)), keys.Keyfunc)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests)
+
+	// The forged key did not replace the current one
+	_, err = jwt.Parse(sign(t, currentKey, jwks.Thumbprint(&currentKey.PublicKey)), keys.Keyfunc)
+	assert.NoError(t, err)
+
+	// Unknown kids do not cause a request per token
+	_, err = jwt.Parse(sign(t, forgedKey, jwks.Thumbprint(&forgedKey.PublicKey)), keys.Keyfunc)
+	assert.Error(t, err)
+	_, err = jwt.Parse(sign(t, forgedKey, jwks.Thumbprint(&forgedKey.PublicKey)), keys.Keyfunc)
+	assert.Error(t, err)
+	assert.Equal(t, 1, requests)
+}
+
+// TestKeySetDropsRetiredKey checks a key removed from the auth JWKS is no longer accepted
+// after the next request, while the configured key stays.
+func TestKeySetDropsRetiredKey(t *testing.T) {
+	interval := jwks.RefreshInterval
+	jwks.RefreshInterval = 0
+	defer func() { jwks.RefreshInterval = interval }()
+
+	currentKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	retiredKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	nextKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	published := &retiredKey.PublicKey
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jwks.Jwks{Keys: []jwks.Jwk{jwkOf(jwks.Thumbprint(published), published)}})
+	}))
+	defer server.Close()
+
+	keys := jwks.NewKeySet(server.URL, server.Client(), &currentKey.PublicKey)
+
+	_, err = jwt.Parse(sign(t, retiredKey, jwks.Thumbprint(&retiredKey.PublicKey)), keys.Keyfunc)
+	require.NoError(t, err)
+
+	// auth publishes the next key instead of the retired one
+	published = &nextKey.PublicKey
+	_, err = jwt.Parse(sign(t, nextKey, jwks.Thumbprint(&nextKey.PublicKey)), keys.Keyfunc)
+	require.NoError(t, err)
+
+	_, err = jwt.Parse(sign(t, retiredKey, jwks.Thumbprint(&retiredKey.PublicKey)), keys.Keyfunc)
+	assert.Error(t, err)
+	_, err = jwt.Parse(sign(t, currentKey, jwks.Thumbprint(&currentKey.PublicKey)), keys.Keyfunc)
+	assert.NoError(t, err)
+	assert.Len(t, keys.JWKS().Keys, 2)
+}
+
+// TestKeySetRefreshDoesNotBlockKnownKeys checks tokens with a known kid are verified while a JWKS request is in flight.
+func TestKeySetRefreshDoesNotBlockKnownKeys(t *testing.T) {
+	currentKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	unknownKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	requested := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requested)
+		<-release
+		_ = json.NewEncoder(w).Encode(jwks.Jwks{})
+	}))
+	defer server.Close()
+	defer close(release)
+
+	keys := jwks.NewKeySet(server.URL, server.Client(), &currentKey.PublicKey)
+
+	// A token with an unknown kid starts a slow JWKS request
+	go func() {
+		_, _ = jwt.Parse(sign(t, unknownKey, jwks.Thumbprint(&unknownKey.PublicKey)), keys.Keyfunc)
+	}()
+	<-requested
+
+	verified := make(chan error, 1)
+	go func() {
+		_, err := jwt.Parse(sign(t, currentKey, jwks.Thumbprint(&currentKey.PublicKey)), keys.Keyfunc)
+		verified <- err
+	}()
+	select {
+	case err := <-verified:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("a known key waited for the JWKS request")
+	}
+}
+
+// TestJwksDocument checks the published JWKS contains every key with its RFC 7638 thumbprint as kid.
+func TestJwksDocument(t *testing.T) {
+	currentKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	nextKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	document := jwks.NewKeySet("", nil, &currentKey.PublicKey, &nextKey.PublicKey, &currentKey.PublicKey).JWKS()
+	require.Len(t, document.Keys, 2)
+
+	for _, jwk := range document.Keys {
+		assert.Equal(t, "RSA", jwk.Kty)
+		assert.Equal(t, "RS256", jwk.Alg)
+		assert.Equal(t, "sig", jwk.Use)
+
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		require.NoError(t, err)
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		require.NoError(t, err)
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		assert.Equal(t, jwks.Thumbprint(key), jwk.Kid)
+	}
+}
+
+// TestThumbprint checks the kid against the example key of RFC 7638, section 3.1.
+func TestThumbprint(t *testing.T) {
+	n, err := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	require.NoError(t, err)
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", jwks.Thumbprint(key))
+}
@@ -0,0 +1,183 @@
+// Package lifecycle проверки состояния и остановка сервисов, общие для всех сервисов системы.
+package lifecycle
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthCheckTimeout время на одну проверку зависимости сервиса.
+const healthCheckTimeout = 3 * time.Second
+
+// HealthCheck проверка зависимости сервиса: nil - зависимость доступна.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// RunHealthChecks выполняет проверки и возвращает ошибки недоступных зависимостей по имени проверки.
+func RunHealthChecks(ctx context.Context, checks []HealthCheck) map[string]string {
+	failed := make(map[string]string)
+	for _, check := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		if err := check.Check(checkCtx); err != nil {
+			failed[check.Name] = err.Error()
+		}
+		cancel()
+	}
+	return failed
+}
+
+// IsHealthMethod проверяет, что метод относится к grpc.health.v1.Health. Проверки состояния
+// выполняются healthcheck docker-compose без JWT токена.
+func IsHealthMethod(fullMethod string) bool {
+	return strings.HasPrefix(fullMethod, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
+
+// StartHealthServer регистрирует grpc.health.v1.Health в grpcServer и раз в interval обновляет
+// состояние сервера и всех зарегистрированных в нём сервисов по проверкам checks.
+// До первой успешной проверки и после любой неудачной состояние NOT_SERVING.
+// Вызывается после регистрации всех сервисов сервера.
+func StartHealthServer(grpcServer *grpc.Server, interval time.Duration, checks ...HealthCheck) (stop func()) {
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+
+	services := []string{""}
+	for name := range grpcServer.GetServiceInfo() {
+		services = append(services, name)
+	}
+	setStatus := func(status healthpb.HealthCheckResponse_ServingStatus) {
+		for _, service := range services {
+			healthServer.SetServingStatus(service, status)
+		}
+	}
+	setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ready := false
+		for first := true; ; first = false {
+			// В лог пишется только смена состояния
+			failed := RunHealthChecks(context.Background(), checks)
+			switch {
+			case len(failed) == 0 && (first || !ready):
+				log.Printf("Сервис готов к работе")
+				setStatus(healthpb.HealthCheckResponse_SERVING)
+			case len(failed) > 0 && (first || ready):
+				log.Printf("Сервис не готов к работе: %v", failed)
+				setStatus(healthpb.HealthCheckResponse_NOT_SERVING)
+			}
+			ready = len(failed) == 0
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		// Клиенты, следящие за состоянием (Watch), узнают об остановке сервера
+		healthServer.Shutdown()
+	}
+}
+
+// ProbeHealth запрашивает состояние gRPC сервера по адресу addr, используется в healthcheck docker-compose.
+// Сервер требует клиентский сертификат, поэтому используется сертификат сервиса certFile и keyFile,
+// сертификат сервера проверяется по CA caCertFile.
+// Возвращает ошибку, если сервер недоступен или не готов к работе.
+func ProbeHealth(addr string, caCertFile string, certFile string, keyFile string) error {
+	caCert, err := os.ReadFile(caCertFile)
+	if err != nil {
+		return fmt.Errorf("не удалось прочитать CA сертификат: %w", err)
+	}
+	caCertPool := x509.NewCertPool()
+	caCertPool.AppendCertsFromPEM(caCert)
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("не удалось загрузить клиентские сертификаты: %w", err)
+	}
+	creds := credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      caCertPool,
+		ServerName:   "localhost",
+	})
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return fmt.Errorf("не удалось подключиться к %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	response, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return fmt.Errorf("ошибка проверки состояния %s: %w", addr, err)
+	}
+	if response.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("сервис %s не готов к работе: %s", addr, response.Status)
+	}
+	return nil
+}
+
+// HealthResponse ответ проверок состояния сервиса.
+type HealthResponse struct {
+	Status string            `json:"status"`           // ok, ready или not_ready
+	Checks map[string]string `json:"checks,omitempty"` // ok или ошибка каждой зависимости
+}
+
+// LivenessHandler проверка /healthz для docker-compose: отвечает, пока процесс работает.
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeHealth(w, http.StatusOK, HealthResponse{Status: "ok"})
+	})
+}
+
+// ReadinessHandler проверка /readyz для docker-compose: отвечает 200, только если доступны
+// все зависимости checks, иначе 503 с ошибками недоступных зависимостей.
+func ReadinessHandler(checks ...HealthCheck) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		failed := RunHealthChecks(req.Context(), checks)
+		response := HealthResponse{Status: "ready", Checks: make(map[string]string, len(checks))}
+		for _, check := range checks {
+			response.Checks[check.Name] = "ok"
+		}
+		for name, err := range failed {
+			response.Checks[name] = err
+		}
+		if len(failed) > 0 {
+			response.Status = "not_ready"
+			writeHealth(w, http.StatusServiceUnavailable, response)
+			return
+		}
+		writeHealth(w, http.StatusOK, response)
+	})
+}
+
+func writeHealth(w http.ResponseWriter, status int, response HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("Ошибка при отправке JSON-ответа: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package lifecycle
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// defaultDrainTimeout время на завершение текущих запросов при остановке, если DRAIN_TIMEOUT не задана.
+const defaultDrainTimeout = 20 * time.Second
+
+// DrainTimeout возвращает время на завершение текущих запросов при остановке сервиса
+// из переменной DRAIN_TIMEOUT (например 30s).
+func DrainTimeout() time.Duration {
+	if value, err := time.ParseDuration(os.Getenv("DRAIN_TIMEOUT")); err == nil && value > 0 {
+		return value
+	}
+	return defaultDrainTimeout
+}
+
+// ShutdownContext возвращает контекст, который отменяется при получении SIGINT или SIGTERM.
+func ShutdownContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+}
+
+// StopGRPCServer останавливает gRPC сервер: новые запросы не принимаются, текущие завершаются
+// в течение timeout, после чего оставшиеся соединения закрываются принудительно.
+func StopGRPCServer(server *grpc.Server, timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Printf("gRPC сервер остановлен")
+	case <-time.After(timeout):
+		log.Printf("Текущие запросы не завершились за %s, gRPC сервер останавливается принудительно", timeout)
+		server.Stop()
+		<-done
+	}
+}
+
+// ShutdownHTTPServer останавливает HTTP сервер: новые соединения не принимаются, текущие запросы
+// завершаются в течение timeout, после чего оставшиеся соединения закрываются принудительно.
+func ShutdownHTTPServer(server *http.Server, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("Текущие запросы не завершились за %s, HTTP сервер останавливается принудительно: %v", timeout, err)
+		_ = server.Close()
+		return
+	}
+	log.Printf("HTTP сервер остановлен")
+}
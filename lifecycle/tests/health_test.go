@@ -0,0 +1,119 @@
+package tests
+
+import (
+	"context"
+	"crmSystem/lifecycle"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// TestHealthServerStatus checks the serving status follows the dependency checks.
+func TestHealthServerStatus(t *testing.T) {
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+
+	var databaseDown atomic.Bool
+	databaseDown.Store(true)
+	stop := lifecycle.StartHealthServer(grpcServer, 10*time.Millisecond, lifecycle.HealthCheck{
+		Name: "postgres",
+		Check: func(context.Context) error {
+			if databaseDown.Load() {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+	})
+	go func() { _ = grpcServer.Serve(listener) }()
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	statusOf := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		response, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return healthpb.HealthCheckResponse_UNKNOWN
+		}
+		return response.Status
+	}
+
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, statusOf(""))
+
+	databaseDown.Store(false)
+	assert.Eventually(t, func() bool {
+		return statusOf("") == healthpb.HealthCheckResponse_SERVING
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, statusOf("grpc.health.v1.Health"))
+
+	databaseDown.Store(true)
+	assert.Eventually(t, func() bool {
+		return statusOf("") == healthpb.HealthCheckResponse_NOT_SERVING
+	}, time.Second, 10*time.Millisecond)
+
+	stop()
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, statusOf(""))
+}
+
+// TestHealthHandlers checks /healthz always answers and /readyz reports unavailable dependencies with 503.
+func TestHealthHandlers(t *testing.T) {
+	var rabbitDown atomic.Bool
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", lifecycle.LivenessHandler())
+	mux.Handle("/readyz", lifecycle.ReadinessHandler(
+		lifecycle.HealthCheck{Name: "postgres", Check: func(context.Context) error { return nil }},
+		lifecycle.HealthCheck{Name: "rabbitmq", Check: func(context.Context) error {
+			if rabbitDown.Load() {
+				return errors.New("connection closed")
+			}
+			return nil
+		}},
+	))
+
+	get := func(path string) (int, lifecycle.HealthResponse) {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var response lifecycle.HealthResponse
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&response))
+		return w.Code, response
+	}
+
+	code, response := get("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", response.Status)
+	assert.Equal(t, map[string]string{"postgres": "ok", "rabbitmq": "ok"}, response.Checks)
+
+	rabbitDown.Store(true)
+	code, response = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", response.Status)
+	assert.Equal(t, map[string]string{"postgres": "ok", "rabbitmq": "connection closed"}, response.Checks)
+
+	code, response = get("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ok", response.Status)
+}
+
+// TestIsHealthMethod checks only grpc.health.v1.Health methods are recognized.
+func TestIsHealthMethod(t *testing.T) {
+	assert.True(t, lifecycle.IsHealthMethod("/grpc.health.v1.Health/Check"))
+	assert.True(t, lifecycle.IsHealthMethod("/grpc.health.v1.Health/Watch"))
+	assert.False(t, lifecycle.IsHealthMethod("/grpc.health.v1.HealthCheck/Check"))
+	assert.False(t, lifecycle.IsHealthMethod("/protobuff.dbChatService/CreateChat"))
+}
@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"crmSystem/lifecycle"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDrainTimeout checks DRAIN_TIMEOUT is used when it is a positive duration.
+func TestDrainTimeout(t *testing.T) {
+	t.Setenv("DRAIN_TIMEOUT", "45s")
+	assert.Equal(t, 45*time.Second, lifecycle.DrainTimeout())
+
+	t.Setenv("DRAIN_TIMEOUT", "soon")
+	assert.Equal(t, 20*time.Second, lifecycle.DrainTimeout())
+
+	t.Setenv("DRAIN_TIMEOUT", "-1s")
+	assert.Equal(t, 20*time.Second, lifecycle.DrainTimeout())
+}
+
+// TestShutdownHTTPServer checks a request that outlives the timeout does not keep the server running.
+func TestShutdownHTTPServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	server := &http.Server{Handler: http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		close(started)
+		<-release
+	})}
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(listener) }()
+
+	go func() { _, _ = http.Get("http://" + listener.Addr().String()) }()
+	<-started
+
+	stopped := make(chan struct{})
+	go func() {
+		lifecycle.ShutdownHTTPServer(server, 50*time.Millisecond)
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the server waited for the hanging request")
+	}
+	assert.ErrorIs(t, <-served, http.ErrServerClosed)
+}
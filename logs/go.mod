@@ -5,6 +5,7 @@ go 1.23.0
 toolchain go1.23.3
 
 require (
+	crmSystem/lifecycle v0.0.0
 	github.com/grafana/loki-client-go v0.0.0-20240913122146-e119d400c3a5
 	github.com/joho/godotenv v1.3.0
 	github.com/prometheus/common v0.62.0
@@ -43,3 +44,5 @@ require (
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20250224174004-546df14abb99 // indirect
 	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
+
+replace crmSystem/lifecycle => ../lifecycle
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"crmSystem/lifecycle"
 	logsservice "crmSystem/service"
 	"crmSystem/utils"
+	"flag"
 	"fmt"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/keepalive"
@@ -25,6 +27,15 @@ func main() {
 		log.Fatalf("Error loading .env file: %v", err)
 	}
 
+	healthcheck := flag.Bool("healthcheck", false, "проверить состояние запущенного сервиса и завершиться (healthcheck docker-compose)")
+	flag.Parse()
+	if *healthcheck {
+		if err := lifecycle.ProbeHealth("localhost:"+os.Getenv("LOGS_SERVICE_HTTP_PORT"), utils.ClientCACertFile, utils.ServerCertFile, utils.ServerKeyFile); err != nil {
+			log.Fatalf("Сервис не готов: %v", err)
+		}
+		return
+	}
+
 	// Настройка URL для Loki
 	lokiURL := os.Getenv("LOKI_URL")
 
@@ -48,6 +59,8 @@ func main() {
 	if err != nil {
 		log.Fatalf("Ошибка создания клиента Loki: %v", err)
 	}
+	// Остановка клиента отправляет в Loki накопленные записи, поэтому выполняется после
+	// завершения всех запросов к серверу
 	defer client.Stop()
 
 	grpcPort := os.Getenv("LOGS_SERVICE_HTTP_PORT")
@@ -82,9 +95,30 @@ func main() {
 	logsService := logsservice.NewGRPCDBLogsService(lokiURL)
 	pb.RegisterLogsServiceServer(grpcServer, logsService)
 
+	// Состояние сервера для healthcheck docker-compose: логи сохраняются в Loki
+	stopHealth := lifecycle.StartHealthServer(grpcServer, 10*time.Second, lifecycle.HealthCheck{
+		Name:  "loki",
+		Check: logsService.CheckLoki,
+	})
+
+	// Контекст отменяется при получении SIGINT/SIGTERM
+	ctx, stop := lifecycle.ShutdownContext()
+	defer stop()
+
 	// Запуск сервера
 	log.Println(fmt.Sprintf("gRPC сервер запускается на %s", os.Getenv("LOGS_SERVICE_HTTP_PORT")))
-	if err := grpcServer.Serve(lis); err != nil {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- grpcServer.Serve(lis)
+	}()
+
+	select {
+	case err := <-serveErr:
 		log.Fatalf("Ошибка запуска gRPC сервера: %v", err)
+	case <-ctx.Done():
 	}
+
+	log.Printf("Получен сигнал остановки, завершаем текущие запросы")
+	stopHealth()
+	lifecycle.StopGRPCServer(grpcServer, lifecycle.DrainTimeout())
 }
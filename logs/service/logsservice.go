@@ -91,3 +91,22 @@ func (s *LogsServer) SaveLogs(_ context.Context, req *pb.LogRequest) (*pb.LogRes
 	// Успешный ответ
 	return &pb.LogResponse{Message: "Лог сохранен."}, nil
 }
+
+// CheckLoki проверяет готовность Loki к приёму логов.
+func (s *LogsServer) CheckLoki(ctx context.Context) error {
+	reqHTTP, err := http.NewRequestWithContext(ctx, http.MethodGet, s.lokiURL+"/ready", nil)
+	if err != nil {
+		return fmt.Errorf("ошибка создания запроса: %w", err)
+	}
+
+	resp, err := s.client.Do(reqHTTP)
+	if err != nil {
+		return fmt.Errorf("Loki недоступен: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Loki не готов: статус %d", resp.StatusCode)
+	}
+	return nil
+}
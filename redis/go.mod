@@ -3,6 +3,7 @@ module crmSystem
 go 1.23
 
 require (
+	crmSystem/lifecycle v0.0.0
 	github.com/go-redis/redis/v8 v8.11.5
 	github.com/joho/godotenv v1.5.1
 	google.golang.org/grpc v1.68.0
@@ -17,3 +18,5 @@ require (
 	golang.org/x/text v0.18.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20240903143218-8af14fe29dc1 // indirect
 )
+
+replace crmSystem/lifecycle => ../lifecycle
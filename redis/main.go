@@ -2,8 +2,10 @@ package main
 
 import (
 	"context"
+	"crmSystem/lifecycle"
 	pb "crmSystem/proto/redis"
 	"crmSystem/utils"
+	"flag"
 	"github.com/go-redis/redis/v8"
 	"github.com/joho/godotenv"
 	"google.golang.org/grpc"
@@ -28,8 +30,8 @@ func NewRedisClient() *redis.Client {
 
 func (s *server) Save(ctx context.Context, req *pb.SaveRedisRequest) (*pb.SaveRedisResponse, error) {
 
-	//Считываем указанное время существования кэша
-	expiration := time.Duration(req.Expiration)
+	//Считываем указанное время существования кэша (передаётся в секундах)
+	expiration := time.Duration(req.Expiration) * time.Second
 
 	//С помощью клиента для редис кэша сохраняем данные
 	success, err := s.RedisClient.SetNX(ctx, req.Key, req.Value, expiration).Result()
@@ -78,6 +80,163 @@ func (s *server) Get(ctx context.Context, req *pb.GetRedisRequest) (*pb.GetRedis
 	}, nil
 }
 
+func (s *server) Set(ctx context.Context, req *pb.SaveRedisRequest) (*pb.SaveRedisResponse, error) {
+
+	//Считываем указанное время существования кэша (передаётся в секундах)
+	expiration := time.Duration(req.Expiration) * time.Second
+
+	//Сохраняем данные, перезаписывая существующее значение
+	err := s.RedisClient.Set(ctx, req.Key, req.Value, expiration).Err()
+	if err != nil {
+		return &pb.SaveRedisResponse{
+			Message: "Ошибка при сохранении: " + err.Error(),
+			Status:  http.StatusInternalServerError,
+		}, err
+	}
+
+	return &pb.SaveRedisResponse{
+		Message: "Ключ сохранён успешно",
+		Status:  http.StatusOK,
+	}, nil
+}
+
+func (s *server) GetDel(ctx context.Context, req *pb.GetRedisRequest) (*pb.GetRedisResponse, error) {
+
+	//Получаем значение и удаляем ключ одной атомарной операцией,
+	//поэтому одно значение может быть получено только одним запросом
+	value, err := s.RedisClient.GetDel(ctx, req.Key).Result()
+
+	if err == redis.Nil {
+		return &pb.GetRedisResponse{
+			Message: "Ключ не найден",
+			Status:  http.StatusNotFound,
+		}, nil
+	} else if err != nil {
+		return &pb.GetRedisResponse{
+			Message: "Ошибка при получении данных по ключу: " + err.Error(),
+			Status:  http.StatusInternalServerError,
+		}, err
+	}
+
+	return &pb.GetRedisResponse{
+		Message: value,
+		Status:  http.StatusOK,
+	}, nil
+}
+
+func (s *server) Delete(ctx context.Context, req *pb.DeleteRedisRequest) (*pb.DeleteRedisResponse, error) {
+
+	if len(req.Keys) == 0 {
+		return &pb.DeleteRedisResponse{
+			Message: "Ключи для удаления не переданы",
+			Status:  http.StatusOK,
+		}, nil
+	}
+
+	deleted, err := s.RedisClient.Del(ctx, req.Keys...).Result()
+	if err != nil {
+		return &pb.DeleteRedisResponse{
+			Message: "Ошибка при удалении: " + err.Error(),
+			Status:  http.StatusInternalServerError,
+		}, err
+	}
+
+	return &pb.DeleteRedisResponse{
+		Message: "Ключи удалены успешно",
+		Status:  http.StatusOK,
+		Deleted: deleted,
+	}, nil
+}
+
+func (s *server) SetAdd(ctx context.Context, req *pb.SetRedisRequest) (*pb.SetRedisResponse, error) {
+
+	members := make([]interface{}, len(req.Members))
+	for i, member := range req.Members {
+		members[i] = member
+	}
+
+	//Добавляем элементы в множество и при необходимости продлеваем его время существования
+	pipe := s.RedisClient.TxPipeline()
+	pipe.SAdd(ctx, req.Key, members...)
+	if req.Expiration > 0 {
+		pipe.Expire(ctx, req.Key, time.Duration(req.Expiration)*time.Second)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return &pb.SetRedisResponse{
+			Message: "Ошибка при добавлении в множество: " + err.Error(),
+			Status:  http.StatusInternalServerError,
+		}, err
+	}
+
+	return &pb.SetRedisResponse{
+		Message: "Элементы добавлены в множество",
+		Status:  http.StatusOK,
+	}, nil
+}
+
+func (s *server) SetRemove(ctx context.Context, req *pb.SetRedisRequest) (*pb.SetRedisResponse, error) {
+
+	members := make([]interface{}, len(req.Members))
+	for i, member := range req.Members {
+		members[i] = member
+	}
+
+	if err := s.RedisClient.SRem(ctx, req.Key, members...).Err(); err != nil {
+		return &pb.SetRedisResponse{
+			Message: "Ошибка при удалении из множества: " + err.Error(),
+			Status:  http.StatusInternalServerError,
+		}, err
+	}
+
+	return &pb.SetRedisResponse{
+		Message: "Элементы удалены из множества",
+		Status:  http.StatusOK,
+	}, nil
+}
+
+func (s *server) SetMembers(ctx context.Context, req *pb.GetRedisRequest) (*pb.SetMembersRedisResponse, error) {
+
+	members, err := s.RedisClient.SMembers(ctx, req.Key).Result()
+	if err != nil {
+		return &pb.SetMembersRedisResponse{
+			Message: "Ошибка при получении множества: " + err.Error(),
+			Status:  http.StatusInternalServerError,
+		}, err
+	}
+
+	return &pb.SetMembersRedisResponse{
+		Message: "Множество получено",
+		Status:  http.StatusOK,
+		Members: members,
+	}, nil
+}
+
+// incrementScript увеличивает счётчик и задаёт время существования только при его создании,
+// поэтому окно подсчёта отсчитывается от первой попытки и не продлевается последующими
+var incrementScript = redis.NewScript(`
+local value = redis.call('INCR', KEYS[1])
+if value == 1 and tonumber(ARGV[1]) > 0 then
+    redis.call('EXPIRE', KEYS[1], ARGV[1])
+end
+return value`)
+
+func (s *server) Increment(ctx context.Context, req *pb.IncrementRedisRequest) (*pb.IncrementRedisResponse, error) {
+
+	value, err := incrementScript.Run(ctx, s.RedisClient, []string{req.Key}, req.Expiration).Int64()
+	if err != nil {
+		return &pb.IncrementRedisResponse{
+			Message: "Ошибка при увеличении счётчика: " + err.Error(),
+			Status:  http.StatusInternalServerError,
+		}, err
+	}
+
+	return &pb.IncrementRedisResponse{
+		Message: "Счётчик увеличен",
+		Status:  http.StatusOK,
+		Value:   value,
+	}, nil
+}
+
 func main() {
 
 	// Загружаем переменные из .env файла
@@ -85,6 +244,15 @@ func main() {
 		log.Fatalf("Error loading .env file: %v", err)
 	}
 
+	healthcheck := flag.Bool("healthcheck", false, "проверить состояние запущенного сервиса и завершиться (healthcheck docker-compose)")
+	flag.Parse()
+	if *healthcheck {
+		if err := lifecycle.ProbeHealth("localhost:"+os.Getenv("GRPC_PORT"), utils.ClientCACertFile, utils.ServerCertFile, utils.ServerKeyFile); err != nil {
+			log.Fatalf("Сервис не готов: %v", err)
+		}
+		return
+	}
+
 	//Создаём соединение с редис кэшем
 	redisClient := NewRedisClient()
 	defer func(redisClient *redis.Client) {
@@ -122,8 +290,32 @@ func main() {
 	grpcServer := grpc.NewServer(opts...)
 	pb.RegisterRedisServiceServer(grpcServer, &server{RedisClient: redisClient})
 
+	// Состояние сервера для healthcheck docker-compose: проверяется доступность Redis командой PING
+	stopHealth := lifecycle.StartHealthServer(grpcServer, 10*time.Second, lifecycle.HealthCheck{
+		Name: "redis",
+		Check: func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		},
+	})
+
+	// Контекст отменяется при получении SIGINT/SIGTERM
+	ctx, stop := lifecycle.ShutdownContext()
+	defer stop()
+
 	log.Println("gRPC server for RedisService is running on port" + os.Getenv("GRPC_PORT"))
-	if err := grpcServer.Serve(listener); err != nil {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- grpcServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
 		log.Fatalf("Проблема в запуске сервера: %v", err)
+	case <-ctx.Done():
 	}
+
+	// Соединение с Redis закрывается после завершения текущих запросов
+	log.Printf("Получен сигнал остановки, завершаем текущие запросы")
+	stopHealth()
+	lifecycle.StopGRPCServer(grpcServer, lifecycle.DrainTimeout())
 }
@@ -9,15 +9,15 @@ import (
 )
 
 const (
-	serverCertFile   = "sslkeys/server.pem"
-	serverKeyFile    = "sslkeys/server.key"
-	clientCACertFile = "sslkeys/ca.crt"
+	ServerCertFile   = "sslkeys/server.pem"
+	ServerKeyFile    = "sslkeys/server.key"
+	ClientCACertFile = "sslkeys/ca.crt"
 )
 
 // LoadTLSCredentials загружает TLS-учетные данные для сервера.
 func LoadTLSCredentials() (credentials.TransportCredentials, error) {
 	// Загрузка сертификата CA сервера
-	pemServerCA, err := ioutil.ReadFile(clientCACertFile)
+	pemServerCA, err := ioutil.ReadFile(ClientCACertFile)
 	if err != nil {
 		return nil, fmt.Errorf("не удалось загрузить сертификат CA сервера: %v", err)
 	}
@@ -29,7 +29,7 @@ func LoadTLSCredentials() (credentials.TransportCredentials, error) {
 	}
 
 	// Загрузка сертификата и закрытого ключа сервера
-	serverCert, err := tls.LoadX509KeyPair(serverCertFile, serverKeyFile)
+	serverCert, err := tls.LoadX509KeyPair(ServerCertFile, ServerKeyFile)
 	if err != nil {
 		return nil, fmt.Errorf("не удалось загрузить сертификат и ключ сервера: %v", err)
 	}
@@ -3,6 +3,8 @@ module crmSystem
 go 1.23
 
 require (
+	crmSystem/jwks v0.0.0
+	crmSystem/lifecycle v0.0.0
 	github.com/go-playground/validator/v10 v10.24.0
 	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/gorilla/mux v1.8.1
@@ -23,3 +25,6 @@ require (
 	golang.org/x/text v0.21.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20241202173237-19429a94021a // indirect
 )
+
+replace crmSystem/jwks => ../jwks
+replace crmSystem/lifecycle => ../lifecycle
@@ -1,13 +1,14 @@
 package main
 
 import (
+	"crmSystem/lifecycle"
 	"crmSystem/transport_rest"
 	"crmSystem/utils"
+	"errors"
 	"github.com/joho/godotenv"
 	"log"
 	"net/http"
 	"os"
-	"sync"
 )
 
 func main() {
@@ -30,18 +31,20 @@ func main() {
 		Handler: handler.InitRouter(),
 	}
 
-	// Используем WaitGroup для ожидания завершения сервера
-	var wg sync.WaitGroup
-	wg.Add(1)
+	// Остановка по SIGINT и SIGTERM: новые запросы не принимаются, текущие завершаются в течение DRAIN_TIMEOUT
+	ctx, stop := lifecycle.ShutdownContext()
+	defer stop()
 
 	// Запускаем HTTP сервер с TLS в отдельной горутине
 	go func() {
 		log.Println("HTTP SERVER STARTED WITH TLS ON PORT", port)
-		if err := httpServer.ListenAndServeTLS(utils.ServerCertFile, utils.ServerKeyFile); err != nil {
+		if err := httpServer.ListenAndServeTLS(utils.ServerCertFile, utils.ServerKeyFile); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatalf("Ошибка запуска HTTP сервера с TLS: %v", err)
 		}
 	}()
 
-	// Ожидаем завершения работы HTTP сервера
-	wg.Wait()
+	// Ожидаем сигнала остановки
+	<-ctx.Done()
+	log.Printf("Получен сигнал остановки, завершаем текущие запросы")
+	lifecycle.ShutdownHTTPServer(httpServer, lifecycle.DrainTimeout())
 }
@@ -1,7 +1,7 @@
 package transport_rest
 
 import (
-	"context"
+	"crmSystem/lifecycle"
 	"crmSystem/proto/dbtimer"
 	"crmSystem/proto/logs"
 	"crmSystem/transport_rest/types"
@@ -9,7 +9,6 @@ import (
 	"encoding/json"
 	"github.com/gorilla/mux"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/metadata"
 	"log"
 	"net/http"
 )
@@ -24,6 +23,10 @@ func NewHandler() *Handler {
 func (h *Handler) InitRouter() *mux.Router {
 	r := mux.NewRouter()
 
+	// Проверки состояния сервиса для docker-compose
+	r.Handle("/healthz", lifecycle.LivenessHandler()).Methods(http.MethodGet)
+	r.Handle("/readyz", lifecycle.ReadinessHandler()).Methods(http.MethodGet)
+
 	timerRouts := r.PathPrefix("/timer").Subrouter()
 	{
 		timerRouts.HandleFunc("/start-timer", utils.RecoverMiddleware(h.StartTimer)).Methods(http.MethodPost)
@@ -45,28 +48,16 @@ func (h *Handler) StartTimer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Получаем cookie с именами
-	token := utils.GetFromCookies(w, r, "access-token")
-	if token == "" {
+	// Получаем токен и данные пользователя из подписанного access token,
+	// в dbservice они не передаются в метаданных: он берёт их из проверенного токена
+	token, user := utils.GetUserFromToken(w, r)
+	if user == nil {
 		return
 	}
+	database := user.Database
+	userId := user.UserId
 
-	userId := utils.GetFromCookies(w, r, "user-id")
-	if userId == "" {
-		return
-	}
-
-	database := utils.GetFromCookies(w, r, "database")
-	if database == "" {
-		return
-	}
-
-	md := metadata.Pairs(
-		"user-id", userId,
-		"database", database,
-	)
-
-	ctxWithMetadata := metadata.NewOutgoingContext(context.Background(), md)
+	ctxWithMetadata := r.Context()
 
 	// Устанавливаем соединение с gRPC сервером Logs
 	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
@@ -143,28 +134,16 @@ func (h *Handler) EndTimer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Получаем cookie с именами
-	token := utils.GetFromCookies(w, r, "access-token")
-	if token == "" {
-		return
-	}
-
-	userId := utils.GetFromCookies(w, r, "user-id")
-	if userId == "" {
-		return
-	}
-
-	database := utils.GetFromCookies(w, r, "database")
-	if database == "" {
+	// Получаем токен и данные пользователя из подписанного access token,
+	// в dbservice они не передаются в метаданных: он берёт их из проверенного токена
+	token, user := utils.GetUserFromToken(w, r)
+	if user == nil {
 		return
 	}
+	database := user.Database
+	userId := user.UserId
 
-	md := metadata.Pairs(
-		"user-id", userId,
-		"database", database,
-	)
-
-	ctxWithMetadata := metadata.NewOutgoingContext(context.Background(), md)
+	ctxWithMetadata := r.Context()
 
 	// Устанавливаем соединение с gRPC сервером Logs
 	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
@@ -231,28 +210,14 @@ func (h *Handler) EndTimer(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) GetWorkingTimer(w http.ResponseWriter, r *http.Request) {
 
-	// Получаем cookie с именами
-	token := utils.GetFromCookies(w, r, "access-token")
-	if token == "" {
-		return
-	}
-
-	userId := utils.GetFromCookies(w, r, "user-id")
-	if userId == "" {
+	// Получаем токен и данные пользователя из подписанного access token,
+	// в dbservice они не передаются в метаданных: он берёт их из проверенного токена
+	token, user := utils.GetUserFromToken(w, r)
+	if user == nil {
 		return
 	}
 
-	database := utils.GetFromCookies(w, r, "database")
-	if database == "" {
-		return
-	}
-
-	md := metadata.Pairs(
-		"user-id", userId,
-		"database", database,
-	)
-
-	ctxWithMetadata := metadata.NewOutgoingContext(context.Background(), md)
+	ctxWithMetadata := r.Context()
 
 	// Устанавливаем соединение с gRPC сервером Logs
 	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
@@ -325,28 +290,14 @@ func (h *Handler) ChangeTimer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Получаем cookie с именами
-	token := utils.GetFromCookies(w, r, "access-token")
-	if token == "" {
-		return
-	}
-
-	userId := utils.GetFromCookies(w, r, "user-id")
-	if userId == "" {
-		return
-	}
-
-	database := utils.GetFromCookies(w, r, "database")
-	if database == "" {
+	// Получаем токен и данные пользователя из подписанного access token,
+	// в dbservice они не передаются в метаданных: он берёт их из проверенного токена
+	token, user := utils.GetUserFromToken(w, r)
+	if user == nil {
 		return
 	}
 
-	md := metadata.Pairs(
-		"user-id", userId,
-		"database", database,
-	)
-
-	ctxWithMetadata := metadata.NewOutgoingContext(context.Background(), md)
+	ctxWithMetadata := r.Context()
 
 	// Устанавливаем соединение с gRPC сервером Logs
 	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
@@ -430,28 +381,14 @@ func (h *Handler) AddTimer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Получаем cookie с именами
-	token := utils.GetFromCookies(w, r, "access-token")
-	if token == "" {
-		return
-	}
-
-	userId := utils.GetFromCookies(w, r, "user-id")
-	if userId == "" {
+	// Получаем токен и данные пользователя из подписанного access token,
+	// в dbservice они не передаются в метаданных: он берёт их из проверенного токена
+	token, user := utils.GetUserFromToken(w, r)
+	if user == nil {
 		return
 	}
 
-	database := utils.GetFromCookies(w, r, "database")
-	if database == "" {
-		return
-	}
-
-	md := metadata.Pairs(
-		"user-id", userId,
-		"database", database,
-	)
-
-	ctxWithMetadata := metadata.NewOutgoingContext(context.Background(), md)
+	ctxWithMetadata := r.Context()
 
 	// Устанавливаем соединение с gRPC сервером Logs
 	clientLogs, err, conn := utils.GRPCServiceConnector(token, logs.NewLogsServiceClient)
@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"crmSystem/jwks"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	// publicKeyFile публичный ключ текущей пары, им проверяются токены без kid, выпущенные до ротации.
+	publicKeyFile = "./opensslkeys/public_key.pem"
+
+	// jwksKeysDir дополнительные публичные ключи (*.pem), действующие во время ротации.
+	jwksKeysDir = "./opensslkeys/jwks"
+)
+
+// LoadKeySet загружает ключи проверки токенов из public_key.pem и каталога opensslkeys/jwks.
+// Если задана переменная окружения JWKS_URL, набор дополняется ключами auth сервиса.
+func LoadKeySet() (*jwks.KeySet, error) {
+	keys, err := jwks.LoadKeyFiles(publicKeyFile, jwksKeysDir)
+	if err != nil {
+		return nil, err
+	}
+
+	jwksURL := os.Getenv("JWKS_URL")
+	var client *http.Client
+	if jwksURL != "" {
+		// Сертификат auth сервиса подписан общим CA
+		pemCA, err := ioutil.ReadFile(ClientCACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("не удалось загрузить сертификат CA: %v", err)
+		}
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(pemCA) {
+			return nil, fmt.Errorf("не удалось добавить сертификат CA в пул")
+		}
+		client = &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: certPool}},
+		}
+	}
+
+	return jwks.NewKeySet(jwksURL, client, keys...), nil
+}
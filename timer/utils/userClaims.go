@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"crmSystem/jwks"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"sync"
+)
+
+// UserClaims данные пользователя из подписанного access token.
+// База данных компании и id пользователя берутся только отсюда, а не из cookie.
+type UserClaims struct {
+	Database  string
+	UserId    string
+	CompanyId string
+	Role      string
+}
+
+var (
+	keySetOnce sync.Once
+	keySet     *jwks.KeySet
+	keySetErr  error
+)
+
+// verificationKeys загружает ключи проверки токенов (один раз за время работы).
+func verificationKeys() (*jwks.KeySet, error) {
+	keySetOnce.Do(func() {
+		keySet, keySetErr = LoadKeySet()
+	})
+	return keySet, keySetErr
+}
+
+// ParseUserToken проверяет подпись access token и возвращает данные пользователя из claims.
+func ParseUserToken(tokenString string) (*UserClaims, error) {
+	keys, err := verificationKeys()
+	if err != nil {
+		return nil, err
+	}
+	return ParseUserTokenWith(keys, tokenString)
+}
+
+// ParseUserTokenWith проверяет подпись access token ключами keys и возвращает данные пользователя из claims.
+func ParseUserTokenWith(keys *jwks.KeySet, tokenString string) (*UserClaims, error) {
+	// Ключ выбирается по kid токена, во время ротации действуют старый и новый ключи
+	token, err := jwt.Parse(tokenString, keys.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, fmt.Errorf("токен недействителен")
+	}
+
+	// Refresh token и токен ожидания второго фактора подписаны тем же ключом, но доступа к сервису не дают
+	if typ, _ := claims["typ"].(string); typ != "access" {
+		return nil, fmt.Errorf("требуется access token")
+	}
+
+	user := &UserClaims{}
+	user.UserId, _ = claims["sub"].(string)
+	user.Database, _ = claims["db"].(string)
+	user.CompanyId, _ = claims["cid"].(string)
+	user.Role, _ = claims["role"].(string)
+	if user.UserId == "" || user.Database == "" {
+		return nil, fmt.Errorf("токен не содержит данных пользователя")
+	}
+	return user, nil
+}
+
+// GetUserFromToken получает access_token из cookie и проверяет его.
+// Если токен отсутствует или недействителен, записывает ошибку в ответ и возвращает nil.
+func GetUserFromToken(w http.ResponseWriter, r *http.Request) (string, *UserClaims) {
+	token := GetFromCookies(w, r, "access_token")
+	if token == "" {
+		return "", nil
+	}
+
+	user, err := ParseUserToken(token)
+	if err != nil {
+		CreateError(w, http.StatusUnauthorized, "Недействительный токен", err)
+		return "", nil
+	}
+	return token, user
+}